@@ -113,6 +113,8 @@ type ForeignClusterSpec struct {
 	// this ForeignCluster will be removed if no updates have been received.
 	// +kubebuilder:validation:Minimum=0
 	TTL int `json:"ttl,omitempty"`
+	// ResourceDemands is the amount of resources demanded to the remote cluster, forwarded in the ResourceRequest.
+	ResourceDemands *ResourceDemands `json:"resourceDemands,omitempty"`
 }
 
 // ClusterIdentity contains the information about a remote cluster (ID and Name).
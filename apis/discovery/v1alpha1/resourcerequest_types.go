@@ -15,6 +15,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -31,6 +32,32 @@ const (
 	OfferStateNone OfferStateType = "None"
 )
 
+// DemandsStateType defines to which extent the resources demanded by the consumer cluster have been granted.
+type DemandsStateType string
+
+const (
+	// DemandsStateNone indicates that the consumer cluster did not demand any specific amount of resources.
+	DemandsStateNone DemandsStateType = "None"
+	// DemandsStateSatisfied indicates that all the desired resources have been granted.
+	DemandsStateSatisfied DemandsStateType = "Satisfied"
+	// DemandsStatePartiallySatisfied indicates that the minimum resources have been granted, but not all the desired ones.
+	DemandsStatePartiallySatisfied DemandsStateType = "PartiallySatisfied"
+	// DemandsStateUnsatisfied indicates that not even the minimum resources have been granted.
+	DemandsStateUnsatisfied DemandsStateType = "Unsatisfied"
+)
+
+// ResourceDemands defines the amount of resources demanded by a consumer cluster to a provider cluster.
+type ResourceDemands struct {
+	// Desired is the amount of resources (e.g., cpu, memory, pods and extended resources) the consumer cluster would
+	// like to be granted. The provider cluster grants up to this amount, within the limits of its sharing policies.
+	// Resources not listed here are granted according to the sharing policies only.
+	Desired corev1.ResourceList `json:"desired,omitempty"`
+	// Minimum is the amount of resources below which the demands are considered as unsatisfied.
+	Minimum corev1.ResourceList `json:"minimum,omitempty"`
+	// StorageClasses is the list of storage classes the consumer cluster would like to be granted (all, if empty).
+	StorageClasses []string `json:"storageClasses,omitempty"`
+}
+
 // ResourceRequestSpec defines the desired state of ResourceRequest.
 type ResourceRequestSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -42,6 +69,9 @@ type ResourceRequestSpec struct {
 	AuthURL string `json:"authUrl"`
 	// WithdrawalTimestamp is set when a graceful deletion is requested by the user.
 	WithdrawalTimestamp *metav1.Time `json:"withdrawalTimestamp,omitempty"`
+	// Demands is the amount of resources demanded by the consumer cluster (if unset, the provider cluster decides
+	// on the basis of its sharing policies only).
+	Demands *ResourceDemands `json:"demands,omitempty"`
 }
 
 // ResourceRequestStatus defines the observed state of ResourceRequest.
@@ -51,6 +81,12 @@ type ResourceRequestStatus struct {
 	// +kubebuilder:validation:Enum="None";"Created"
 	// +kubebuilder:default="None"
 	OfferState OfferStateType `json:"offerState"`
+	// GrantedResources is the amount of resources currently granted to the consumer cluster.
+	GrantedResources corev1.ResourceList `json:"grantedResources,omitempty"`
+	// DemandsState indicates to which extent the resources demanded by the consumer cluster have been granted.
+	// +kubebuilder:validation:Enum="None";"Satisfied";"PartiallySatisfied";"Unsatisfied"
+	// +kubebuilder:validation:Optional
+	DemandsState DemandsStateType `json:"demandsState,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -58,6 +94,7 @@ type ResourceRequestStatus struct {
 // +kubebuilder:subresource:status
 
 // ResourceRequest is the Schema for the ResourceRequests API.
+// +kubebuilder:printcolumn:name="Demands",type=string,priority=1,JSONPath=`.status.demandsState`
 // +kubebuilder:printcolumn:name="Local",type=string,JSONPath=`.metadata.labels.liqo\.io/replication`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type ResourceRequest struct {
@@ -20,6 +20,7 @@
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -106,6 +107,11 @@ func (in *ForeignClusterSpec) DeepCopyInto(out *ForeignClusterSpec) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.ResourceDemands != nil {
+		in, out := &in.ResourceDemands, &out.ResourceDemands
+		*out = new(ResourceDemands)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForeignClusterSpec.
@@ -157,6 +163,40 @@ func (in *PeeringCondition) DeepCopy() *PeeringCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceDemands) DeepCopyInto(out *ResourceDemands) {
+	*out = *in
+	if in.Desired != nil {
+		in, out := &in.Desired, &out.Desired
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Minimum != nil {
+		in, out := &in.Minimum, &out.Minimum
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.StorageClasses != nil {
+		in, out := &in.StorageClasses, &out.StorageClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceDemands.
+func (in *ResourceDemands) DeepCopy() *ResourceDemands {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceDemands)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequest) DeepCopyInto(out *ResourceRequest) {
 	*out = *in
@@ -224,6 +264,11 @@ func (in *ResourceRequestSpec) DeepCopyInto(out *ResourceRequestSpec) {
 		in, out := &in.WithdrawalTimestamp, &out.WithdrawalTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.Demands != nil {
+		in, out := &in.Demands, &out.Demands
+		*out = new(ResourceDemands)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRequestSpec.
@@ -243,6 +288,13 @@ func (in *ResourceRequestStatus) DeepCopyInto(out *ResourceRequestStatus) {
 		in, out := &in.OfferWithdrawalTimestamp, &out.OfferWithdrawalTimestamp
 		*out = (*in).DeepCopy()
 	}
+	if in.GrantedResources != nil {
+		in, out := &in.GrantedResources, &out.GrantedResources
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceRequestStatus.
@@ -0,0 +1,70 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceServiceExports is the name of the serviceexport resources.
+var ResourceServiceExports = "serviceexports"
+
+const (
+	// ServiceExportValid means that the service referenced by the ServiceExport has been recognized as valid for export.
+	ServiceExportValid = "Valid"
+	// ServiceExportConflict means that there is a conflict between two exports for the same service.
+	ServiceExportConflict = "Conflict"
+)
+
+// ServiceExportStatus contains the current status of an export.
+type ServiceExportStatus struct {
+	// Conditions describe the current state of the export.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName={svcex,svcexport}
+// +kubebuilder:subresource:status
+
+// ServiceExport declares that the Service with the same name and namespace as this export should be consumable
+// from other clusters.
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ServiceExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Status describes the current state of an exported service.
+	// Service configuration comes from the Service that had the same name and namespace as this ServiceExport.
+	// +optional
+	Status ServiceExportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ServiceExportList contains a list of ServiceExport.
+type ServiceExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceExport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServiceExport{}, &ServiceExportList{})
+}
@@ -0,0 +1,127 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceServiceImports is the name of the serviceimport resources.
+var ResourceServiceImports = "serviceimports"
+
+// ServiceImportType designates the type of a ServiceImport.
+// +kubebuilder:validation:Enum=ClusterSetIP;Headless
+type ServiceImportType string
+
+const (
+	// ClusterSetIP are only accessible via the ClusterSet IP.
+	ClusterSetIP ServiceImportType = "ClusterSetIP"
+	// Headless services allow backend pods to be addressed directly.
+	Headless ServiceImportType = "Headless"
+)
+
+// ServiceImportSpec describes an imported service and the information necessary to consume it.
+type ServiceImportSpec struct {
+	// +listType=atomic
+	Ports []ServicePort `json:"ports"`
+	// IPs are the IP addresses the imported service can be reached at (i.e., the ClusterSet IP).
+	// +kubebuilder:validation:MaxItems:=1
+	// +optional
+	IPs []string `json:"ips,omitempty"`
+	// Type defines the type of this service.
+	// Must be ClusterSetIP or Headless.
+	Type ServiceImportType `json:"type"`
+	// Supports "ClientIP" and "None". Used to maintain session affinity.
+	// Enable client IP based session affinity.
+	// Must be ClientIP or None.
+	// Defaults to None.
+	// +optional
+	SessionAffinity corev1.ServiceAffinity `json:"sessionAffinity,omitempty"`
+	// SessionAffinityConfig contains session affinity configuration.
+	// +optional
+	SessionAffinityConfig *corev1.SessionAffinityConfig `json:"sessionAffinityConfig,omitempty"`
+}
+
+// ServicePort represents the port on which the service is exposed.
+type ServicePort struct {
+	// The name of this port within the service. This must be a DNS_LABEL.
+	// All ports within a ServiceSpec must have unique names. When considering
+	// the endpoints for a Service, this must match the 'name' field in the
+	// EndpointPort.
+	// Optional if only one ServicePort is defined on this service.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// The IP protocol for this port. Supports "TCP", "UDP", and "SCTP".
+	// Default is TCP.
+	// +optional
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// The application protocol for this port.
+	// +optional
+	AppProtocol *string `json:"appProtocol,omitempty"`
+	// The port that will be exposed by this service.
+	Port int32 `json:"port"`
+}
+
+// ServiceImportStatus describes derived state of an imported service.
+type ServiceImportStatus struct {
+	// Clusters is the list of exporting clusters from which this service was derived.
+	// +optional
+	// +patchStrategy=merge
+	// +patchMergeKey=cluster
+	// +listType=map
+	// +listMapKey=cluster
+	Clusters []ClusterStatus `json:"clusters,omitempty"`
+}
+
+// ClusterStatus contains service configuration mapped to a specific source cluster.
+type ClusterStatus struct {
+	// Cluster is the ID of the exporting cluster.
+	Cluster string `json:"cluster"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName={svcim,svcimport}
+// +kubebuilder:subresource:status
+
+// ServiceImport describes a service imported from clusters in a ClusterSet.
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="IP",type=string,JSONPath=`.spec.ips`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ServiceImport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec defines the behavior of a ServiceImport.
+	// +optional
+	Spec ServiceImportSpec `json:"spec,omitempty"`
+	// Status contains information about the exported services that form the multi-cluster service
+	// referenced by this ServiceImport.
+	// +optional
+	Status ServiceImportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ServiceImportList contains a list of ServiceImport.
+type ServiceImportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceImport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServiceImport{}, &ServiceImportList{})
+}
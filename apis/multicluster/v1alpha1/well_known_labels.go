@@ -0,0 +1,24 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+const (
+	// LabelServiceName is the label key used to indicate the name of the multi-cluster service
+	// an EndpointSlice (or a derived Service) belongs to.
+	LabelServiceName = "multicluster.kubernetes.io/service-name"
+
+	// LabelSourceCluster is the label key used to indicate the cluster the endpoints of an EndpointSlice originate from.
+	LabelSourceCluster = "multicluster.kubernetes.io/source-cluster"
+)
@@ -0,0 +1,74 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+)
+
+// ResourceExportedServices is the name of the exportedservices resources.
+var ResourceExportedServices = "exportedservices"
+
+// ExportedServiceSpec defines the desired state of ExportedService.
+type ExportedServiceSpec struct {
+	// The namespace of the exported service, which is the same in the exporting and the importing clusters.
+	ServiceNamespace string `json:"serviceNamespace"`
+	// The name of the exported service, which is the same in the exporting and the importing clusters.
+	ServiceName string `json:"serviceName"`
+	// The characteristics of the exported service.
+	Service mcsv1alpha1.ServiceImportSpec `json:"service"`
+	// The ready endpoints backing the exported service, as seen by the exporting cluster.
+	// +kubebuilder:validation:Optional
+	Endpoints []ExportedEndpoints `json:"endpoints,omitempty"`
+}
+
+// ExportedEndpoints describes a set of endpoints backing an exported service, sharing the same ports.
+type ExportedEndpoints struct {
+	// The IP addresses of the endpoints, as seen by the exporting cluster.
+	Addresses []string `json:"addresses"`
+	// The ports exposed by the endpoints.
+	Ports []mcsv1alpha1.ServicePort `json:"ports"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories=liqo
+
+// ExportedService is the Schema for the exportedservices API, which is used to propagate a service exported
+// through a ServiceExport towards the peered clusters.
+// +kubebuilder:printcolumn:name="Namespace",type=string,JSONPath=`.spec.serviceNamespace`
+// +kubebuilder:printcolumn:name="Service",type=string,JSONPath=`.spec.serviceName`
+// +kubebuilder:printcolumn:name="Local",type=string,JSONPath=`.metadata.labels.liqo\.io/replication`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type ExportedService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ExportedServiceSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ExportedServiceList contains a list of ExportedService.
+type ExportedServiceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExportedService `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExportedService{}, &ExportedServiceList{})
+}
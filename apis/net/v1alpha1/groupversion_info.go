@@ -56,6 +56,19 @@ var (
 	NatMappingGroupResource = schema.GroupVersionResource{Group: GroupVersion.Group, Version: GroupVersion.Version,
 		Resource: "natmappings"}
 
+	// ExportedServiceGroupResource is group resource used to register exportedservices.
+	ExportedServiceGroupResource = schema.GroupResource{Group: GroupVersion.Group,
+		Resource: ResourceExportedServices}
+
+	// ExportedServiceGroupVersionResource is group version resource used by dynamic client.
+	ExportedServiceGroupVersionResource = schema.GroupVersionResource{Group: GroupVersion.Group,
+		Version:  GroupVersion.Version,
+		Resource: ResourceExportedServices}
+
+	// PeeringFirewallGroupResource is group resource used to register peeringfirewalls.
+	PeeringFirewallGroupResource = schema.GroupResource{Group: GroupVersion.Group,
+		Resource: ResourcePeeringFirewalls}
+
 	// SchemeBuilder is used to add go types to the GroupVersionKind scheme.
 	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
 
@@ -80,6 +80,9 @@ type IpamSpec struct {
 	PodCIDR string `json:"podCIDR"`
 	// ServiceCIDR
 	ServiceCIDR string `json:"serviceCIDR"`
+	// Map used to keep track of the networks reserved to forward the traffic between remote clusters (i.e., multi-hop peering).
+	// Key is the network, value is the set of IDs of the remote clusters which requested it.
+	TransitNetworks map[string][]string `json:"transitNetworks,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -43,6 +43,32 @@ type NetworkConfigSpec struct {
 	BackendType string `json:"backendType"`
 	// Connection parameters
 	BackendConfig map[string]string `json:"backend_config"`
+	// The ID of the intermediate cluster through which the traffic towards the remote cluster is routed,
+	// in case the two clusters cannot establish a direct tunnel. Empty in case of direct connectivity.
+	// +kubebuilder:validation:Optional
+	TransitClusterID string `json:"transitClusterID,omitempty"`
+	// The routes the remote cluster is requested to forward on behalf of the local one, acting as an
+	// intermediate hop towards the clusters not directly reachable from the local cluster.
+	// +kubebuilder:validation:Optional
+	TransitRoutes []TransitRoute `json:"transitRoutes,omitempty"`
+	// The ID of the cluster acting as rendezvous to establish the tunnel towards the remote cluster,
+	// in case both gateways are behind NAT (i.e., NAT traversal). Empty if not needed.
+	// +kubebuilder:validation:Optional
+	RendezvousClusterID string `json:"rendezvousClusterID,omitempty"`
+	// The public endpoints (in the ip:port format) of the clusters for which the local one acts as rendezvous,
+	// as observed by the local gateway. The key is the cluster ID.
+	// +kubebuilder:validation:Optional
+	ObservedEndpoints map[string]string `json:"observedEndpoints,omitempty"`
+}
+
+// TransitRoute describes the networks to be forwarded by an intermediate cluster between two peered clusters.
+type TransitRoute struct {
+	// The ID of the cluster the traffic is forwarded to.
+	DestinationClusterID string `json:"destinationClusterID"`
+	// The networks of the origin cluster (i.e., the one requesting the route), as seen by the destination cluster.
+	SourceCIDRs []string `json:"sourceCIDRs"`
+	// The networks of the destination cluster, as seen by the origin cluster.
+	DestinationCIDRs []string `json:"destinationCIDRs"`
 }
 
 // NetworkConfigStatus defines the observed state of NetworkConfig.
@@ -59,6 +85,9 @@ type NetworkConfigStatus struct {
 	// The new subnet used to NAT the externalCIDR of the remote cluster. The original ExternalCIDR may have been mapped
 	// to this network by the remote cluster.
 	ExternalCIDRNAT string `json:"externalCIDRNAT,omitempty"`
+	// The transit routes accepted by the remote cluster, acting as intermediate hop towards the clusters not directly
+	// reachable from the local one. The corresponding networks have been reserved by the IPAM of the remote cluster.
+	TransitRoutes []TransitRoute `json:"transitRoutes,omitempty"`
 }
 
 // +kubebuilder:object:root=true
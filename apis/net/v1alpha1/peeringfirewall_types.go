@@ -0,0 +1,117 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourcePeeringFirewalls is the name of the peeringfirewall resources.
+var ResourcePeeringFirewalls = "peeringfirewalls"
+
+// PeeringFirewallSpec defines the desired state of PeeringFirewall.
+type PeeringFirewallSpec struct {
+	// ClusterID is the identifier of the remote cluster the rules apply to.
+	ClusterID string `json:"clusterID"`
+	// Ingress is the list of rules whitelisting the local destinations the remote cluster is allowed to reach.
+	// Once at least one PeeringFirewall refers to a given remote cluster, all the traffic it originates towards the
+	// local cluster is dropped, unless matching one of the rules (or belonging to a connection started locally).
+	// +kubebuilder:validation:Optional
+	Ingress []PeeringFirewallRule `json:"ingress,omitempty"`
+}
+
+// PeeringFirewallRule describes a set of local destinations and ports the remote cluster is allowed to reach.
+type PeeringFirewallRule struct {
+	// To is the list of allowed destinations. If empty, all the local destinations are allowed.
+	// +kubebuilder:validation:Optional
+	To []PeeringFirewallPeer `json:"to,omitempty"`
+	// Ports is the list of allowed destination ports. If empty, all the ports are allowed.
+	// +kubebuilder:validation:Optional
+	Ports []PeeringFirewallPort `json:"ports,omitempty"`
+}
+
+// PeeringFirewallPeer describes a set of local destinations, identified either by label selectors or by a CIDR.
+type PeeringFirewallPeer struct {
+	// NamespaceSelector selects the namespaces hosting the allowed pods. If omitted, the pods are selected in all namespaces.
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// PodSelector selects the allowed pods. If omitted, all the pods in the selected namespaces are allowed.
+	// +kubebuilder:validation:Optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// CIDR is a block of local addresses to be allowed (e.g., a node subnet). It is mutually exclusive with the selectors.
+	// +kubebuilder:validation:Optional
+	CIDR string `json:"cidr,omitempty"`
+}
+
+// PeeringFirewallPort describes a destination port (or range of ports) the remote cluster is allowed to reach.
+type PeeringFirewallPort struct {
+	// Protocol is the protocol the rule applies to.
+	// +kubebuilder:validation:Enum=TCP;UDP;SCTP
+	// +kubebuilder:default=TCP
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
+	// Port is the allowed destination port. If omitted, all the ports of the given protocol are allowed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port *int32 `json:"port,omitempty"`
+	// EndPort, if set, identifies the range of allowed ports from Port to EndPort (included).
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	EndPort *int32 `json:"endPort,omitempty"`
+}
+
+// PeeringFirewallStatus defines the observed state of PeeringFirewall.
+type PeeringFirewallStatus struct {
+	// ObservedGeneration is the generation of the PeeringFirewall the resolved destinations refer to.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Rules contains the destinations resolved for each ingress rule, in the same order of the spec.
+	Rules []PeeringFirewallRuleStatus `json:"rules,omitempty"`
+}
+
+// PeeringFirewallRuleStatus contains the destinations resolved for a given ingress rule.
+type PeeringFirewallRuleStatus struct {
+	// Destinations is the list of IP addresses and CIDRs the rule currently allows.
+	Destinations []string `json:"destinations,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster,categories=liqo
+//+kubebuilder:printcolumn:name="Cluster ID",type=string,JSONPath=`.spec.clusterID`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// PeeringFirewall restricts the local destinations a given remote cluster is allowed to reach through the network fabric.
+type PeeringFirewall struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PeeringFirewallSpec   `json:"spec,omitempty"`
+	Status PeeringFirewallStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// PeeringFirewallList contains a list of PeeringFirewall.
+type PeeringFirewallList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PeeringFirewall `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PeeringFirewall{}, &PeeringFirewallList{})
+}
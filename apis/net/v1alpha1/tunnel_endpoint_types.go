@@ -63,6 +63,20 @@ type TunnelEndpointSpec struct {
 	BackendType string `json:"backendType"`
 	// Connection parameters.
 	BackendConfig map[string]string `json:"backend_config"`
+	// The ID of the intermediate cluster through which the traffic towards the remote cluster is routed,
+	// in case the two clusters cannot establish a direct tunnel. Empty in case of direct connectivity.
+	// +kubebuilder:validation:Optional
+	TransitClusterID string `json:"transitClusterID,omitempty"`
+	// The routes to be forwarded on behalf of the remote cluster, which leverages the local one as intermediate hop.
+	// +kubebuilder:validation:Optional
+	TransitRoutes []TransitRoute `json:"transitRoutes,omitempty"`
+	// The ID of the cluster acting as rendezvous to establish the tunnel towards the remote cluster,
+	// in case both gateways are behind NAT (i.e., NAT traversal). Empty if not needed.
+	// +kubebuilder:validation:Optional
+	RendezvousClusterID string `json:"rendezvousClusterID,omitempty"`
+	// The public endpoint (in the ip:port format) of the remote cluster, as observed by the rendezvous cluster.
+	// +kubebuilder:validation:Optional
+	ObservedEndpoint string `json:"observedEndpoint,omitempty"`
 }
 
 // TunnelEndpointStatus defines the observed state of TunnelEndpoint.
@@ -73,7 +87,11 @@ type TunnelEndpointStatus struct {
 	VethIFaceName    string     `json:"vethIFaceName,omitempty"`
 	VethIP           string     `json:"vethIP,omitempty"`
 	GatewayIP        string     `json:"gatewayIP,omitempty"`
+	GatewayPodName   string     `json:"gatewayPodName,omitempty"`
 	Connection       Connection `json:"connection,omitempty"`
+	// PathMTU is the MTU discovered for the path towards the remote cluster, which is applied to the routes
+	// towards its (remapped) networks. Zero if not (yet) discovered, in which case the default MTU is used.
+	PathMTU int `json:"pathMTU,omitempty"`
 }
 
 // ConnectionLatency represents the latency between two clusters.
@@ -82,12 +100,21 @@ type ConnectionLatency struct {
 	Timestamp metav1.Time `json:"timestamp,omitempty"`
 }
 
+// ConnectionQuality represents the quality of the connection between two clusters, as measured by the connection check.
+type ConnectionQuality struct {
+	// Jitter is the smoothed variation of the round-trip time.
+	Jitter string `json:"jitter,omitempty"`
+	// PacketLoss is the percentage of probes lost over the last measurement window.
+	PacketLoss string `json:"packetLoss,omitempty"`
+}
+
 // Connection holds the configuration and status of a vpn tunnel connecting to remote cluster.
 type Connection struct {
 	Status            ConnectionStatus  `json:"status,omitempty"`
 	StatusMessage     string            `json:"statusMessage,omitempty"`
 	PeerConfiguration map[string]string `json:"peerConfiguration,omitempty"`
 	Latency           ConnectionLatency `json:"latency,omitempty"`
+	Quality           ConnectionQuality `json:"quality,omitempty"`
 }
 
 // ConnectionStatus type that describes the status of vpn connection with a remote cluster.
@@ -116,7 +143,11 @@ const (
 // +kubebuilder:printcolumn:name="Peering Cluster",type=string,JSONPath=`.spec.clusterIdentity.clusterName`
 // +kubebuilder:printcolumn:name="Endpoint IP",type=string,JSONPath=`.spec.endpointIP`,priority=1
 // +kubebuilder:printcolumn:name="Backend type",type=string,JSONPath=`.spec.backendType`
+// +kubebuilder:printcolumn:name="Transit cluster",type=string,JSONPath=`.spec.transitClusterID`,priority=1
+// +kubebuilder:printcolumn:name="Rendezvous cluster",type=string,JSONPath=`.spec.rendezvousClusterID`,priority=1
+// +kubebuilder:printcolumn:name="Active gateway",type=string,JSONPath=`.status.gatewayPodName`,priority=1
 // +kubebuilder:printcolumn:name="Latency",type=string,JSONPath=`.status.connection.latency.value`,priority=1
+// +kubebuilder:printcolumn:name="Path MTU",type=integer,JSONPath=`.status.pathMTU`,priority=1
 // +kubebuilder:printcolumn:name="Connection status",type=string,JSONPath=`.status.connection.status`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 type TunnelEndpoint struct {
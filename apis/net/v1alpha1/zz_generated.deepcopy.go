@@ -20,6 +20,8 @@
 package v1alpha1
 
 import (
+	multiclusterv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -64,6 +66,7 @@ func (in *Connection) DeepCopyInto(out *Connection) {
 		}
 	}
 	in.Latency.DeepCopyInto(&out.Latency)
+	out.Quality = in.Quality
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Connection.
@@ -92,6 +95,21 @@ func (in *ConnectionLatency) DeepCopy() *ConnectionLatency {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionQuality) DeepCopyInto(out *ConnectionQuality) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionQuality.
+func (in *ConnectionQuality) DeepCopy() *ConnectionQuality {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionQuality)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EndpointMapping) DeepCopyInto(out *EndpointMapping) {
 	*out = *in
@@ -114,6 +132,114 @@ func (in *EndpointMapping) DeepCopy() *EndpointMapping {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportedEndpoints) DeepCopyInto(out *ExportedEndpoints) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]multiclusterv1alpha1.ServicePort, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedEndpoints.
+func (in *ExportedEndpoints) DeepCopy() *ExportedEndpoints {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportedEndpoints)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportedService) DeepCopyInto(out *ExportedService) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedService.
+func (in *ExportedService) DeepCopy() *ExportedService {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportedService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExportedService) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportedServiceList) DeepCopyInto(out *ExportedServiceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExportedService, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedServiceList.
+func (in *ExportedServiceList) DeepCopy() *ExportedServiceList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportedServiceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExportedServiceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportedServiceSpec) DeepCopyInto(out *ExportedServiceSpec) {
+	*out = *in
+	in.Service.DeepCopyInto(&out.Service)
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]ExportedEndpoints, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportedServiceSpec.
+func (in *ExportedServiceSpec) DeepCopy() *ExportedServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportedServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IpamSpec) DeepCopyInto(out *IpamSpec) {
 	*out = *in
@@ -163,6 +289,21 @@ func (in *IpamSpec) DeepCopyInto(out *IpamSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.TransitNetworks != nil {
+		in, out := &in.TransitNetworks, &out.TransitNetworks
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IpamSpec.
@@ -356,7 +497,7 @@ func (in *NetworkConfig) DeepCopyInto(out *NetworkConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfig.
@@ -420,6 +561,20 @@ func (in *NetworkConfigSpec) DeepCopyInto(out *NetworkConfigSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.TransitRoutes != nil {
+		in, out := &in.TransitRoutes, &out.TransitRoutes
+		*out = make([]TransitRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ObservedEndpoints != nil {
+		in, out := &in.ObservedEndpoints, &out.ObservedEndpoints
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfigSpec.
@@ -435,6 +590,13 @@ func (in *NetworkConfigSpec) DeepCopy() *NetworkConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkConfigStatus) DeepCopyInto(out *NetworkConfigStatus) {
 	*out = *in
+	if in.TransitRoutes != nil {
+		in, out := &in.TransitRoutes, &out.TransitRoutes
+		*out = make([]TransitRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfigStatus.
@@ -447,6 +609,208 @@ func (in *NetworkConfigStatus) DeepCopy() *NetworkConfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringFirewall) DeepCopyInto(out *PeeringFirewall) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringFirewall.
+func (in *PeeringFirewall) DeepCopy() *PeeringFirewall {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringFirewall)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PeeringFirewall) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringFirewallList) DeepCopyInto(out *PeeringFirewallList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PeeringFirewall, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringFirewallList.
+func (in *PeeringFirewallList) DeepCopy() *PeeringFirewallList {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringFirewallList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PeeringFirewallList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringFirewallPeer) DeepCopyInto(out *PeeringFirewallPeer) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringFirewallPeer.
+func (in *PeeringFirewallPeer) DeepCopy() *PeeringFirewallPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringFirewallPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringFirewallPort) DeepCopyInto(out *PeeringFirewallPort) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int32)
+		**out = **in
+	}
+	if in.EndPort != nil {
+		in, out := &in.EndPort, &out.EndPort
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringFirewallPort.
+func (in *PeeringFirewallPort) DeepCopy() *PeeringFirewallPort {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringFirewallPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringFirewallRule) DeepCopyInto(out *PeeringFirewallRule) {
+	*out = *in
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]PeeringFirewallPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]PeeringFirewallPort, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringFirewallRule.
+func (in *PeeringFirewallRule) DeepCopy() *PeeringFirewallRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringFirewallRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringFirewallRuleStatus) DeepCopyInto(out *PeeringFirewallRuleStatus) {
+	*out = *in
+	if in.Destinations != nil {
+		in, out := &in.Destinations, &out.Destinations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringFirewallRuleStatus.
+func (in *PeeringFirewallRuleStatus) DeepCopy() *PeeringFirewallRuleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringFirewallRuleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringFirewallSpec) DeepCopyInto(out *PeeringFirewallSpec) {
+	*out = *in
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = make([]PeeringFirewallRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringFirewallSpec.
+func (in *PeeringFirewallSpec) DeepCopy() *PeeringFirewallSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringFirewallSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PeeringFirewallStatus) DeepCopyInto(out *PeeringFirewallStatus) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]PeeringFirewallRuleStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PeeringFirewallStatus.
+func (in *PeeringFirewallStatus) DeepCopy() *PeeringFirewallStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PeeringFirewallStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Subnets) DeepCopyInto(out *Subnets) {
 	*out = *in
@@ -462,6 +826,31 @@ func (in *Subnets) DeepCopy() *Subnets {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransitRoute) DeepCopyInto(out *TransitRoute) {
+	*out = *in
+	if in.SourceCIDRs != nil {
+		in, out := &in.SourceCIDRs, &out.SourceCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DestinationCIDRs != nil {
+		in, out := &in.DestinationCIDRs, &out.DestinationCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransitRoute.
+func (in *TransitRoute) DeepCopy() *TransitRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(TransitRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TunnelEndpoint) DeepCopyInto(out *TunnelEndpoint) {
 	*out = *in
@@ -532,6 +921,13 @@ func (in *TunnelEndpointSpec) DeepCopyInto(out *TunnelEndpointSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.TransitRoutes != nil {
+		in, out := &in.TransitRoutes, &out.TransitRoutes
+		*out = make([]TransitRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TunnelEndpointSpec.
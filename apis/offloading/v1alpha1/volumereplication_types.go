@@ -0,0 +1,113 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeReplicationResource is the resource name used to register the VolumeReplication CRD.
+var VolumeReplicationResource = "volumereplications"
+
+// VolumeReplicationPhaseType represents the different phases of a volume replication.
+type VolumeReplicationPhaseType string
+
+const (
+	// PendingVolumeReplicationPhaseType -> the replication infrastructure is being set up.
+	PendingVolumeReplicationPhaseType VolumeReplicationPhaseType = "Pending"
+	// ReplicatingVolumeReplicationPhaseType -> a replication is currently in progress.
+	ReplicatingVolumeReplicationPhaseType VolumeReplicationPhaseType = "Replicating"
+	// UpToDateVolumeReplicationPhaseType -> the last replication completed successfully within the configured RPO.
+	UpToDateVolumeReplicationPhaseType VolumeReplicationPhaseType = "UpToDate"
+	// FailedVolumeReplicationPhaseType -> the last replication attempt failed, or the configuration is invalid.
+	FailedVolumeReplicationPhaseType VolumeReplicationPhaseType = "Failed"
+)
+
+// VolumeReplicationSpec defines the desired state of VolumeReplication.
+type VolumeReplicationSpec struct {
+	// PersistentVolumeClaimName is the name of the PVC to be replicated, which shall belong to the same namespace
+	// of the VolumeReplication and to the Liqo virtual storage class.
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+	// TargetNode is the name of the node (typically, a virtual node) where the replicas are stored,
+	// and the volume is expected to be restored in case of failover.
+	TargetNode string `json:"targetNode"`
+	// RecoveryPointObjective is the maximum interval between two consecutive replications.
+	// +kubebuilder:default="1h"
+	// +kubebuilder:validation:Optional
+	RecoveryPointObjective metav1.Duration `json:"recoveryPointObjective"`
+	// PasswordSecretRef references the key of a secret, in the same namespace of the VolumeReplication,
+	// containing the password used to encrypt the restic repository storing the replicas.
+	PasswordSecretRef corev1.SecretKeySelector `json:"passwordSecretRef"`
+	// KeepLast is the number of most recent snapshots retained in the repository.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Optional
+	KeepLast int32 `json:"keepLast"`
+	// Suspend pauses the periodic replications, without removing the already replicated data.
+	// +kubebuilder:validation:Optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// VolumeReplicationStatus defines the observed state of VolumeReplication.
+type VolumeReplicationStatus struct {
+	// Phase is the current phase of the replication.
+	Phase VolumeReplicationPhaseType `json:"phase,omitempty"`
+	// Message is a human readable message providing additional details about the current phase.
+	Message string `json:"message,omitempty"`
+	// LastSuccessfulReplicationTime is the completion time of the last successful replication.
+	LastSuccessfulReplicationTime *metav1.Time `json:"lastSuccessfulReplicationTime,omitempty"`
+	// LastReplicationAttemptTime is the start time of the last replication attempt.
+	LastReplicationAttemptTime *metav1.Time `json:"lastReplicationAttemptTime,omitempty"`
+	// SourceRepositoryURL is the URL of the restic repository, as reachable from the cluster hosting the volume.
+	SourceRepositoryURL string `json:"sourceRepositoryURL,omitempty"`
+	// TargetRepositoryURL is the URL of the restic repository, as reachable from the cluster hosting the target node.
+	TargetRepositoryURL string `json:"targetRepositoryURL,omitempty"`
+	// The generation observed by the VolumeReplication controller.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName="vrep",categories=liqo
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="PVC",type=string,JSONPath=`.spec.persistentVolumeClaimName`
+// +kubebuilder:printcolumn:name="TargetNode",type=string,JSONPath=`.spec.targetNode`
+// +kubebuilder:printcolumn:name="RPO",type=string,JSONPath=`.spec.recoveryPointObjective`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="LastReplication",type=date,JSONPath=`.status.lastSuccessfulReplicationTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VolumeReplication is the Schema for the volumereplications API, which configures the continuous asynchronous
+// replication of a Liqo volume towards a standby node.
+type VolumeReplication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VolumeReplicationSpec   `json:"spec"`
+	Status VolumeReplicationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VolumeReplicationList contains a list of VolumeReplication.
+type VolumeReplicationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VolumeReplication `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VolumeReplication{}, &VolumeReplicationList{})
+}
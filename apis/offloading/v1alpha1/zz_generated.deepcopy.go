@@ -166,3 +166,102 @@ func (in RemoteNamespaceConditions) DeepCopy() RemoteNamespaceConditions {
 	in.DeepCopyInto(out)
 	return *out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeReplication) DeepCopyInto(out *VolumeReplication) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeReplication.
+func (in *VolumeReplication) DeepCopy() *VolumeReplication {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeReplication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeReplication) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeReplicationList) DeepCopyInto(out *VolumeReplicationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VolumeReplication, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeReplicationList.
+func (in *VolumeReplicationList) DeepCopy() *VolumeReplicationList {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeReplicationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VolumeReplicationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeReplicationSpec) DeepCopyInto(out *VolumeReplicationSpec) {
+	*out = *in
+	out.RecoveryPointObjective = in.RecoveryPointObjective
+	in.PasswordSecretRef.DeepCopyInto(&out.PasswordSecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeReplicationSpec.
+func (in *VolumeReplicationSpec) DeepCopy() *VolumeReplicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeReplicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeReplicationStatus) DeepCopyInto(out *VolumeReplicationStatus) {
+	*out = *in
+	if in.LastSuccessfulReplicationTime != nil {
+		in, out := &in.LastSuccessfulReplicationTime, &out.LastSuccessfulReplicationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastReplicationAttemptTime != nil {
+		in, out := &in.LastReplicationAttemptTime, &out.LastReplicationAttemptTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeReplicationStatus.
+func (in *VolumeReplicationStatus) DeepCopy() *VolumeReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -16,6 +16,7 @@ package v1alpha1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -30,6 +31,36 @@ type StorageType struct {
 	Default bool `json:"default,omitempty"`
 }
 
+// NodesHealth summarizes the health of the physical nodes of a cluster.
+type NodesHealth struct {
+	// Total is the number of physical nodes of the cluster.
+	Total int32 `json:"total"`
+	// NotReady is the number of nodes which are not ready.
+	NotReady int32 `json:"notReady,omitempty"`
+	// Unschedulable is the number of nodes which are ready, but marked as unschedulable (e.g., cordoned).
+	Unschedulable int32 `json:"unschedulable,omitempty"`
+	// MemoryPressure is the number of nodes under memory pressure.
+	MemoryPressure int32 `json:"memoryPressure,omitempty"`
+	// DiskPressure is the number of nodes under disk pressure.
+	DiskPressure int32 `json:"diskPressure,omitempty"`
+	// PIDPressure is the number of nodes under PID pressure.
+	PIDPressure int32 `json:"pidPressure,omitempty"`
+}
+
+// ExtendedResourceClass describes a class of devices (e.g., a GPU model) backing an extended resource.
+type ExtendedResourceClass struct {
+	// Name is the name of the extended resource (e.g., nvidia.com/gpu).
+	Name corev1.ResourceName `json:"name"`
+	// Attributes describe the devices of the class (e.g., model, memory, MIG profile), and are retrieved from the
+	// labels of the nodes exposing the resource.
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Quantity is the amount of the resource available on the nodes exposing the devices of the class.
+	Quantity resource.Quantity `json:"quantity"`
+	// ResourceName is the class-qualified name under which the devices of the class are offered (e.g., nvidia.com/gpu-1a2b3c4d),
+	// to allow targeting them specifically. It is set only if the extended resource is backed by multiple classes.
+	ResourceName corev1.ResourceName `json:"resourceName,omitempty"`
+}
+
 // ResourceOfferSpec defines the desired state of ResourceOffer.
 type ResourceOfferSpec struct {
 	// ClusterID is the identifier of the cluster that is sending this ResourceOffer.
@@ -47,6 +78,11 @@ type ResourceOfferSpec struct {
 	WithdrawalTimestamp *metav1.Time `json:"withdrawalTimestamp,omitempty"`
 	// StorageClasses contains the list of the storage classes offered by the cluster.
 	StorageClasses []StorageType `json:"storageClasses,omitempty"`
+	// NodesHealth summarizes the health of the nodes of the cluster sending this ResourceOffer. It is part of the spec,
+	// rather than of the status, since the latter is managed by the cluster receiving the ResourceOffer.
+	NodesHealth *NodesHealth `json:"nodesHealth,omitempty"`
+	// ExtendedResources describes the classes of devices backing the extended resources offered by the cluster.
+	ExtendedResources []ExtendedResourceClass `json:"extendedResources,omitempty"`
 }
 
 // OfferPhase describes the phase of the ResourceOffer.
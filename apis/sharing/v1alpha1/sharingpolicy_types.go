@@ -0,0 +1,64 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SharingPolicySpec defines the desired state of SharingPolicy.
+type SharingPolicySpec struct {
+	// ClusterSelector selects the consumer clusters the policy applies to, matching the labels of the corresponding
+	// ForeignClusters (e.g., discovery.liqo.io/cluster-id to target a specific cluster). An empty selector matches
+	// all consumer clusters.
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// Reserved is the amount of resources guaranteed to each selected consumer cluster, and withheld from the others.
+	Reserved corev1.ResourceList `json:"reserved,omitempty"`
+	// Limits is the maximum amount of resources offered to each selected consumer cluster.
+	Limits corev1.ResourceList `json:"limits,omitempty"`
+	// Weight is the weight of each selected consumer cluster in the fair sharing of the resources which are
+	// not reserved. Consumer clusters not selected by any policy are assigned a weight equal to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Optional
+	Weight int32 `json:"weight"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,categories=liqo
+
+// SharingPolicy is the Schema for the sharingpolicies API.
+// +kubebuilder:printcolumn:name="Weight",type=integer,JSONPath=`.spec.weight`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+type SharingPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SharingPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SharingPolicyList contains a list of SharingPolicy.
+type SharingPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SharingPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SharingPolicy{}, &SharingPolicyList{})
+}
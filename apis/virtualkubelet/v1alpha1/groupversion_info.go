@@ -32,6 +32,15 @@ var (
 	// NamespaceMapGroupVersionResource is groupResourceVersion used to register these objects.
 	NamespaceMapGroupVersionResource = SchemeGroupVersion.WithResource(NamespaceMapResource)
 
+	// ReflectionPolicyResource is the resource name used to register the ReflectionPolicy CRD.
+	ReflectionPolicyResource = "reflectionpolicies"
+
+	// ReflectionPolicyGroupResource is group resource used to register these objects.
+	ReflectionPolicyGroupResource = schema.GroupResource{Group: SchemeGroupVersion.Group, Resource: ReflectionPolicyResource}
+
+	// ReflectionPolicyGroupVersionResource is groupResourceVersion used to register these objects.
+	ReflectionPolicyGroupVersionResource = SchemeGroupVersion.WithResource(ReflectionPolicyResource)
+
 	// ShadowPodResource is the resource name used to register the ShadowPod CRD.
 	ShadowPodResource = "shadowpods"
 
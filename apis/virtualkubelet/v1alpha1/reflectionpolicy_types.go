@@ -0,0 +1,79 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReflectionMode defines which objects of a given resource type are reflected towards remote clusters.
+type ReflectionMode string
+
+const (
+	// ReflectionModeAll indicates that all objects are reflected (default).
+	ReflectionModeAll ReflectionMode = "All"
+	// ReflectionModeOptIn indicates that only the objects matching the label selector are reflected.
+	ReflectionModeOptIn ReflectionMode = "OptIn"
+	// ReflectionModeOnlyReferencedByPods indicates that only the objects referenced by offloaded pods are reflected
+	// (supported only by ConfigMaps and Secrets).
+	ReflectionModeOnlyReferencedByPods ReflectionMode = "OnlyReferencedByPods"
+	// ReflectionModeNone indicates that no objects are reflected.
+	ReflectionModeNone ReflectionMode = "None"
+)
+
+// ResourceReflectionPolicy defines the reflection policy for a given resource type.
+type ResourceReflectionPolicy struct {
+	// Resource is the name of the reflected resource type the policy refers to (e.g., ConfigMap, Secret, Service,
+	// EndpointSlice, Ingress, or <resource>.<group> for the additional resource types configured in the virtual kubelet).
+	Resource string `json:"resource"`
+	// Mode is the reflection mode enforced for the given resource type.
+	// +kubebuilder:validation:Enum="All";"OptIn";"OnlyReferencedByPods";"None"
+	// +kubebuilder:default="All"
+	Mode ReflectionMode `json:"mode"`
+	// Selector selects the objects to be reflected, in case the OptIn mode is configured.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// ReflectionPolicySpec defines the desired state of ReflectionPolicy.
+type ReflectionPolicySpec struct {
+	// Policies is the list of reflection policies, one per resource type.
+	// Resource types not listed here are reflected according to the All mode.
+	Policies []ResourceReflectionPolicy `json:"policies,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:categories=liqo
+// +genclient
+
+// ReflectionPolicy is the Schema for the reflectionpolicies API.
+type ReflectionPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReflectionPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReflectionPolicyList contains a list of ReflectionPolicy.
+type ReflectionPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReflectionPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReflectionPolicy{}, &ReflectionPolicyList{})
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/liqotech/liqo/pkg/utils/apiserver"
 	"github.com/liqotech/liqo/pkg/utils/args"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 func main() {
@@ -56,6 +57,7 @@ func main() {
 	apiserver.InitFlags(nil)
 
 	restcfg.InitFlags(nil)
+	tracing.InitFlags(nil)
 	klog.InitFlags(nil)
 	flag.Parse()
 
@@ -63,6 +65,17 @@ func main() {
 
 	config := restcfg.SetRateLimiter(ctrl.GetConfigOrDie())
 
+	shutdownTracing, err := tracing.Init(context.Background(), "liqo-auth")
+	if err != nil {
+		klog.Errorf("Failed to initialize the tracing: %v", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("Failed to shut down the tracing: %v", err)
+		}
+	}()
+
 	clusterIdentity := clusterFlags.ReadOrDie()
 	authService, err := authservice.NewAuthServiceCtrl(
 		context.Background(), config, *namespace, awsConfig, *resync, apiserver.GetConfig(), *enableAuth, *useTLS, clusterIdentity)
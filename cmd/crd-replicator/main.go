@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 	"time"
@@ -35,6 +36,7 @@ import (
 	"github.com/liqotech/liqo/pkg/utils/args"
 	"github.com/liqotech/liqo/pkg/utils/mapper"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 var scheme = runtime.NewScheme()
@@ -51,11 +53,23 @@ func main() {
 	workers := flag.Uint("workers", 1, "The number of workers managing the reflection of each remote cluster")
 
 	restcfg.InitFlags(nil)
+	tracing.InitFlags(nil)
 	klog.InitFlags(nil)
 
 	flag.Parse()
 
 	ctx := ctrl.SetupSignalHandler()
+
+	shutdownTracing, err := tracing.Init(ctx, "liqo-crd-replicator")
+	if err != nil {
+		klog.Errorf("Failed to initialize the tracing: %v", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("Failed to shut down the tracing: %v", err)
+		}
+	}()
 	clusterIdentity := clusterFlags.ReadOrDie()
 
 	cfg := restcfg.SetRateLimiter(ctrl.GetConfigOrDie())
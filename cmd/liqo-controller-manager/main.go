@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"fmt"
@@ -24,11 +25,16 @@ import (
 	"sync"
 	"time"
 
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	certificates "k8s.io/api/certificates/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/selection"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -44,32 +50,43 @@ import (
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	offloadingv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
 	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	virtualkubeletv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
 	identitymanager "github.com/liqotech/liqo/pkg/identityManager"
+	clustersetdns "github.com/liqotech/liqo/pkg/liqo-controller-manager/clusterset-dns"
 	foreignclusteroperator "github.com/liqotech/liqo/pkg/liqo-controller-manager/foreign-cluster-operator"
 	mapsctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/namespacemap-controller"
 	nsoffctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/namespaceoffloading-controller"
 	resourceRequestOperator "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller"
 	resourcemonitors "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller/resource-monitors"
 	resourceoffercontroller "github.com/liqotech/liqo/pkg/liqo-controller-manager/resourceoffer-controller"
+	serviceexportctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/serviceexport-controller"
+	serviceimportctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/serviceimport-controller"
 	shadowpodctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/shadowpod-controller"
 	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
 	virtualNodectrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/virtualNode-controller"
+	volumeexpansionctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/volumeexpansion-controller"
+	volumegatewayctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/volumegateway-controller"
+	volumereplicationctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/volumereplication-controller"
+	volumesnapshotctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/volumesnapshot-controller"
 	fcwh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/foreigncluster"
 	nsoffwh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/namespaceoffloading"
 	podwh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/pod"
+	rowh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/resourceoffer"
 	shadowpodswh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/shadowpod"
 	peeringroles "github.com/liqotech/liqo/pkg/peering-roles"
 	tenantnamespace "github.com/liqotech/liqo/pkg/tenantNamespace"
 	argsutils "github.com/liqotech/liqo/pkg/utils/args"
 	"github.com/liqotech/liqo/pkg/utils/csr"
 	liqoerrors "github.com/liqotech/liqo/pkg/utils/errors"
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
 	"github.com/liqotech/liqo/pkg/utils/mapper"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 	"github.com/liqotech/liqo/pkg/vkMachinery/forge"
 )
 
@@ -85,12 +102,14 @@ func init() {
 	_ = discoveryv1alpha1.AddToScheme(scheme)
 	_ = offloadingv1alpha1.AddToScheme(scheme)
 	_ = virtualkubeletv1alpha1.AddToScheme(scheme)
+	_ = mcsv1alpha1.AddToScheme(scheme)
+	_ = snapshotv1.AddToScheme(scheme)
 }
 
 func main() {
 	var clusterLabels argsutils.StringMap
 	var kubeletExtraAnnotations, kubeletExtraLabels argsutils.StringMap
-	var kubeletExtraArgs argsutils.StringList
+	var kubeletExtraArgs, offerImageRegistries, offerExtendedResourceAttributes argsutils.StringList
 	var nodeExtraAnnotations, nodeExtraLabels argsutils.StringMap
 	var kubeletCPURequests, kubeletCPULimits argsutils.Quantity
 	var kubeletRAMRequests, kubeletRAMLimits argsutils.Quantity
@@ -127,12 +146,26 @@ func main() {
 	resourceSharingPercentage := argsutils.Percentage{Val: 50}
 	flag.Var(&resourceSharingPercentage, "resource-sharing-percentage",
 		"The amount (in percentage) of cluster resources possibly shared with foreign clusters (ignored when using an external resource monitor)")
+	enableSharingPolicies := flag.Bool("enable-sharing-policies", false,
+		"Share the resources among the consumer clusters according to the SharingPolicies (i.e., reservations, limits and weighted fair sharing)")
 	enableIncomingPeering := flag.Bool("enable-incoming-peering", true,
 		"Enable remote clusters to establish an incoming peering with the local cluster (can be overwritten on a per foreign cluster basis)")
 	offerDisableAutoAccept := flag.Bool("offer-disable-auto-accept", false, "Disable the automatic acceptance of resource offers")
+	var offerAutoAcceptMaxResources argsutils.ResourceList
+	flag.Var(&offerAutoAcceptMaxResources, "offer-auto-accept-max-resources",
+		"The maximum amount of resources (e.g., cpu=8,memory=16Gi) of the resource offers accepted anyway when the automatic acceptance is disabled")
+	offerAutoAcceptClusterSelector := flag.String("offer-auto-accept-cluster-selector", "",
+		"The label selector of the foreign clusters whose resource offers are accepted anyway when the automatic acceptance is disabled")
 	offerUpdateThreshold := argsutils.Percentage{}
 	flag.Var(&offerUpdateThreshold, "offer-update-threshold-percentage",
 		"The threshold (in percentage) of resources quantity variation which triggers a ResourceOffer update")
+	offerMaxImages := flag.Uint("offer-max-images", 50,
+		"The maximum number of container images stored in the local cluster advertised in the ResourceOffers (0 to disable the advertisement)")
+	flag.Var(&offerImageRegistries, "offer-image-registries",
+		"The registries the container images advertised in the ResourceOffers are restricted to (default: all registries)")
+	flag.Var(&offerExtendedResourceAttributes, "offer-extended-resource-attributes",
+		"The node labels advertised in the ResourceOffers as attributes of the extended resources, e.g., the GPU model "+
+			"(default: the NVIDIA GPU feature discovery ones)")
 
 	// Virtual-kubelet parameters
 	kubeletImage := flag.String("kubelet-image", "ghcr.io/liqotech/virtual-kubelet", "The image of the virtual kubelet to be deployed")
@@ -151,16 +184,45 @@ func main() {
 	virtualStorageClassName := flag.String("virtual-storage-class-name", "liqo", "Name of the virtual storage class")
 	realStorageClassName := flag.String("real-storage-class-name", "", "Name of the real storage class to use for the actual volumes")
 	storageNamespace := flag.String("storage-namespace", "liqo-storage", "Namespace where the liqo storage-related resources are stored")
+	volumeReplicationNamespace := flag.String("volume-replication-namespace", "liqo-replication",
+		"Namespace where the restic repositories storing the volume replicas are deployed")
+	enableVolumeSnapshots := flag.Bool("enable-volume-snapshots", false,
+		"Enable the support for VolumeSnapshots of the virtual storage class (requires the snapshot.storage.k8s.io API in all clusters)")
+	enableVolumeRemoteAccess := flag.Bool("enable-volume-remote-access", false,
+		"Enable the access to the annotated ReadWriteMany PVCs from offloaded pods, through NFS gateways reachable across the network fabric")
+
+	// Multi-cluster services parameters
+	enableMultiClusterServices := flag.Bool("enable-multicluster-services", false,
+		"Enable the support for the Multi-Cluster Services API (ServiceExport and ServiceImport)")
+	clusterSetDNSAddress := flag.String("clusterset-dns-address", ":5353",
+		"The address the DNS server resolving the clusterset.local names binds to (if multi-cluster services are enabled)")
 
 	liqoerrors.InitFlags(nil)
 	restcfg.InitFlags(nil)
+	tracing.InitFlags(nil)
 	klog.InitFlags(nil)
 	flag.Parse()
 
+	// The extended resource attributes default to the NVIDIA GPU ones, unless explicitly configured (possibly empty).
+	if offerExtendedResourceAttributes.StringList == nil {
+		offerExtendedResourceAttributes.StringList = extendedresources.DefaultAttributeLabels
+	}
+
 	clusterIdentity := clusterIdentityFlags.ReadOrDie()
 
 	ctx := ctrl.SetupSignalHandler()
 
+	shutdownTracing, err := tracing.Init(ctx, "liqo-controller-manager")
+	if err != nil {
+		klog.Errorf("Failed to initialize the tracing: %v", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("Failed to shut down the tracing: %v", err)
+		}
+	}()
+
 	config := restcfg.SetRateLimiter(ctrl.GetConfigOrDie())
 
 	// Create a label selector to filter out the events for pods not managed by a ShadowPod,
@@ -168,8 +230,24 @@ func main() {
 	podsLabelRequirement, err := labels.NewRequirement(consts.ManagedByLabelKey, selection.Equals, []string{consts.ManagedByShadowPodValue})
 	utilruntime.Must(err)
 
+	// Similarly, jobs and statefulsets are relevant only if associated with a VolumeReplication.
+	replicationLabelRequirement, err := labels.NewRequirement(consts.VolumeReplicationNameLabel, selection.Exists, nil)
+	utilruntime.Must(err)
+
+	var additionalGroupVersions []schema.GroupVersion
+	if *enableMultiClusterServices {
+		additionalGroupVersions = append(additionalGroupVersions, mcsv1alpha1.GroupVersion, discoveryv1.SchemeGroupVersion)
+	}
+	if *enableStorage {
+		// Required by the volume replication controller, which manages the replication jobs.
+		additionalGroupVersions = append(additionalGroupVersions, batchv1.SchemeGroupVersion)
+		if *enableVolumeSnapshots {
+			additionalGroupVersions = append(additionalGroupVersions, snapshotv1.SchemeGroupVersion)
+		}
+	}
+
 	mgr, err := ctrl.NewManager(config, ctrl.Options{
-		MapperProvider:                mapper.LiqoMapperProvider(scheme),
+		MapperProvider:                mapper.LiqoMapperProvider(scheme, additionalGroupVersions...),
 		Scheme:                        scheme,
 		MetricsBindAddress:            *metricsAddr,
 		HealthProbeBindAddress:        *probeAddr,
@@ -184,6 +262,12 @@ func main() {
 				&corev1.Pod{}: {
 					Label: labels.NewSelector().Add(*podsLabelRequirement),
 				},
+				&batchv1.Job{}: {
+					Label: labels.NewSelector().Add(*replicationLabelRequirement),
+				},
+				&appsv1.StatefulSet{}: {
+					Label: labels.NewSelector().Add(*replicationLabelRequirement),
+				},
 			},
 		}),
 	})
@@ -210,6 +294,7 @@ func main() {
 	mgr.GetWebhookServer().Register("/validate/shadowpods", &webhook.Admission{Handler: spv})
 	mgr.GetWebhookServer().Register("/validate/namespace-offloading", nsoffwh.New())
 	mgr.GetWebhookServer().Register("/mutate/pod", podwh.New(mgr.GetClient()))
+	mgr.GetWebhookServer().Register("/mutate/resource-offer", rowh.New())
 
 	clientset := kubernetes.NewForConfigOrDie(config)
 
@@ -267,8 +352,16 @@ func main() {
 			Factor:   float32(resourceSharingPercentage.Val) / 100.,
 		}
 	}
+	if *enableSharingPolicies {
+		monitor, err = resourcemonitors.NewSharingPolicyReader(ctx, monitor, mgr.GetClient(), mgr.GetCache())
+		if err != nil {
+			klog.Errorf("error on creating sharing policy reader: %s", err)
+			os.Exit(1)
+		}
+	}
 	offerUpdater := resourceRequestOperator.NewOfferUpdater(ctx, mgr.GetClient(), clusterIdentity,
-		clusterLabels.StringMap, monitor, uint(offerUpdateThreshold.Val), *realStorageClassName, *enableStorage)
+		clusterLabels.StringMap, monitor, uint(offerUpdateThreshold.Val), *realStorageClassName, *enableStorage,
+		*offerMaxImages, offerImageRegistries.StringList, offerExtendedResourceAttributes.StringList)
 	resourceRequestReconciler = &resourceRequestOperator.ResourceRequestReconciler{
 		Client:                mgr.GetClient(),
 		Scheme:                mgr.GetScheme(),
@@ -292,10 +385,24 @@ func main() {
 		RequestsRAM:          kubeletRAMRequests.Quantity,
 		LimitsCPU:            kubeletCPULimits.Quantity,
 		LimitsRAM:            kubeletRAMLimits.Quantity,
+
+		EnableVolumeSnapshots: *enableStorage && *enableVolumeSnapshots,
+	}
+
+	var offerAutoAcceptPolicy *resourceoffercontroller.AutoAcceptPolicy
+	if len(offerAutoAcceptMaxResources.ResourceList) > 0 || *offerAutoAcceptClusterSelector != "" {
+		selector, err := labels.Parse(*offerAutoAcceptClusterSelector)
+		if err != nil {
+			klog.Fatalf("Failed to parse the auto-accept cluster selector: %v", err)
+		}
+		offerAutoAcceptPolicy = &resourceoffercontroller.AutoAcceptPolicy{
+			MaxResources:    offerAutoAcceptMaxResources.ResourceList,
+			ClusterSelector: selector,
+		}
 	}
 
 	resourceOfferReconciler := resourceoffercontroller.NewResourceOfferController(
-		mgr, clusterIdentity, *resyncPeriod, *liqoNamespace, virtualKubeletOpts, *offerDisableAutoAccept)
+		mgr, clusterIdentity, *resyncPeriod, *liqoNamespace, virtualKubeletOpts, *offerDisableAutoAccept, offerAutoAcceptPolicy)
 	if err = resourceOfferReconciler.SetupWithManager(mgr); err != nil {
 		klog.Fatal(err)
 	}
@@ -328,14 +435,38 @@ func main() {
 	}
 
 	shadowPodReconciler := &shadowpodctrl.Reconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		ClientSet: clientset,
 	}
 
 	if err = shadowPodReconciler.SetupWithManager(mgr, *shadowPodWorkers); err != nil {
 		klog.Fatal(err)
 	}
 
+	if *enableMultiClusterServices {
+		serviceExportReconciler := &serviceexportctrl.Reconciler{
+			Client: mgr.GetClient(),
+		}
+
+		if err = serviceExportReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatal(err)
+		}
+
+		serviceImportReconciler := &serviceimportctrl.Reconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}
+
+		if err = serviceImportReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatal(err)
+		}
+
+		if err = mgr.Add(clustersetdns.NewServer(mgr.GetClient(), *clusterSetDNSAddress)); err != nil {
+			klog.Fatal(err)
+		}
+	}
+
 	// Start the handler to approve the virtual kubelet certificate signing requests.
 	csrWatcher := csr.NewWatcher(clientset, *resyncPeriod, labels.Everything(), fields.Everything())
 	csrWatcher.RegisterHandler(csr.ApproverHandler(clientset, "LiqoApproval", "This CSR was approved by Liqo",
@@ -372,6 +503,52 @@ func main() {
 		}); err != nil {
 			klog.Fatal(err)
 		}
+
+		volumeReplicationReconciler := &volumereplicationctrl.Reconciler{
+			Client:                  mgr.GetClient(),
+			Recorder:                mgr.GetEventRecorderFor("volumereplication-controller"),
+			VirtualStorageClassName: *virtualStorageClassName,
+			Namespace:               *volumeReplicationNamespace,
+		}
+
+		if err = volumeReplicationReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatal(err)
+		}
+
+		volumeExpansionReconciler := &volumeexpansionctrl.Reconciler{
+			Client:                  mgr.GetClient(),
+			Recorder:                mgr.GetEventRecorderFor("volumeexpansion-controller"),
+			VirtualStorageClassName: *virtualStorageClassName,
+			StorageNamespace:        *storageNamespace,
+		}
+
+		if err = volumeExpansionReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatal(err)
+		}
+
+		if *enableVolumeSnapshots {
+			volumeSnapshotReconciler := &volumesnapshotctrl.Reconciler{
+				Client:           mgr.GetClient(),
+				Recorder:         mgr.GetEventRecorderFor("volumesnapshot-controller"),
+				StorageNamespace: *storageNamespace,
+			}
+
+			if err = volumeSnapshotReconciler.SetupWithManager(mgr); err != nil {
+				klog.Fatal(err)
+			}
+		}
+	}
+
+	if *enableVolumeRemoteAccess {
+		volumeGatewayReconciler := &volumegatewayctrl.Reconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("volumegateway-controller"),
+		}
+
+		if err = volumeGatewayReconciler.SetupWithManager(mgr); err != nil {
+			klog.Fatal(err)
+		}
 	}
 
 	klog.Info("starting manager as controller manager")
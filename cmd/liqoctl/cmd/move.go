@@ -42,6 +42,10 @@ process leverages Restic to backup the source data and restore it into a volume
 in the target cluster. Warning: only PVCs not currently mounted by any pod can
 be moved to a different cluster.
 
+In case the PVC is continuously replicated towards the target node through a
+VolumeReplication resource, the existing replicas are leveraged, and only a
+final incremental snapshot is taken before restoring the data.
+
 Examples:
   $ {{ .Executable }} move volume database01 --namespace foo --target-node worker-023
 or
@@ -0,0 +1,129 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/liqotech/liqo/pkg/liqoctl/completion"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/offer"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+)
+
+const liqoctlOfferListLongHelp = `List the resource offers received from remote clusters.
+
+This command lists the resource offers received from the remote clusters, along
+with the offered resources, their phase, and the information concerning their
+manual approval (if any).
+
+Examples:
+  $ {{ .Executable }} offer list
+`
+
+const liqoctlOfferAcceptLongHelp = `Accept the resource offer received from a remote cluster.
+
+This command accepts the resource offer received from a remote cluster, causing
+the corresponding virtual node to be created. It is typically leveraged when the
+automatic acceptance of resource offers is disabled, and a new peering requires
+an explicit approval. The user performing the approval, as well as the timestamp,
+are recorded in the annotations of the resource offer.
+
+Examples:
+  $ {{ .Executable }} offer accept eternal-donkey
+`
+
+const liqoctlOfferRefuseLongHelp = `Refuse the resource offer received from a remote cluster.
+
+This command refuses the resource offer received from a remote cluster. In case
+the offer had already been accepted, the corresponding virtual node is drained
+and destroyed, and all offloaded workloads are rescheduled. The user performing
+the refusal, as well as the timestamp, are recorded in the annotations of the
+resource offer.
+
+Examples:
+  $ {{ .Executable }} offer refuse eternal-donkey
+`
+
+func newOfferCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "offer",
+		Short: "Manage the resource offers received from remote clusters",
+		Long:  "Manage the resource offers received from remote clusters.",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(newOfferListCommand(ctx, f))
+	cmd.AddCommand(newOfferAcceptCommand(ctx, f))
+	cmd.AddCommand(newOfferRefuseCommand(ctx, f))
+	return cmd
+}
+
+func newOfferListCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	options := offer.Options{Factory: f}
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the resource offers received from remote clusters",
+		Long:    WithTemplate(liqoctlOfferListLongHelp),
+		Args:    cobra.NoArgs,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			output.ExitOnErr(options.RunList(ctx))
+		},
+	}
+
+	return cmd
+}
+
+func newOfferAcceptCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	options := offer.Options{Factory: f}
+	cmd := &cobra.Command{
+		Use:   "accept cluster-name",
+		Short: "Accept the resource offer received from a remote cluster",
+		Long:  WithTemplate(liqoctlOfferAcceptLongHelp),
+
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ForeignClusters(ctx, f, 1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			options.ClusterName = args[0]
+			output.ExitOnErr(options.RunAccept(ctx))
+		},
+	}
+
+	return cmd
+}
+
+func newOfferRefuseCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	options := offer.Options{Factory: f}
+	cmd := &cobra.Command{
+		Use:   "refuse cluster-name",
+		Short: "Refuse the resource offer received from a remote cluster",
+		Long:  WithTemplate(liqoctlOfferRefuseLongHelp),
+
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.ForeignClusters(ctx, f, 1),
+
+		Run: func(cmd *cobra.Command, args []string) {
+			options.ClusterName = args[0]
+			output.ExitOnErr(options.RunRefuse(ctx))
+		},
+	}
+
+	return cmd
+}
@@ -20,11 +20,14 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/liqoctl/completion"
 	"github.com/liqotech/liqo/pkg/liqoctl/factory"
 	"github.com/liqotech/liqo/pkg/liqoctl/output"
 	"github.com/liqotech/liqo/pkg/liqoctl/peer"
+	"github.com/liqotech/liqo/pkg/liqoctl/peerexport"
 	"github.com/liqotech/liqo/pkg/liqoctl/peerib"
+	"github.com/liqotech/liqo/pkg/liqoctl/peerimport"
 	"github.com/liqotech/liqo/pkg/liqoctl/peeroob"
 )
 
@@ -109,6 +112,40 @@ or
       --namespace liqo-system --remote-namespace liqo
 `
 
+const liqoctlPeerExportLongHelp = `Export the configuration to peer with the local cluster as a bundle of manifests.
+
+Upon execution, this command retrieves the information concerning the local
+cluster (i.e., authentication endpoint and token, cluster ID, ...) and generates
+a self-contained bundle, including the ForeignCluster resource and the secret
+containing the authentication token, to establish an out-of-band outgoing peering
+from a *different* cluster towards the local one. The bundle can be either applied
+directly (e.g., committed into a GitOps repository), or imported through the
+"{{ .Executable }} peer import" command.
+
+Since the bundle contains the authentication token, it can be optionally sealed
+through PGP encryption, given the armored public keys of the intended recipients.
+
+Examples:
+  $ {{ .Executable }} peer export --output peering.yaml
+or
+  $ {{ .Executable }} peer export --output peering.yaml.asc --recipients-keyring pubkeys.asc \
+      --remote-namespace liqo-system
+`
+
+const liqoctlPeerImportLongHelp = `Import a peering bundle, and enable an out-of-band peering towards the corresponding cluster.
+
+This command reads a peering bundle generated by "{{ .Executable }} peer export" on
+the target remote cluster, unseals it if necessary, and enables an out-of-band peering
+towards that cluster. The operation is idempotent, hence it can be safely executed
+multiple times with the same bundle (e.g., as part of a GitOps pipeline).
+
+Examples:
+  $ {{ .Executable }} peer import --input peering.yaml
+or
+  $ {{ .Executable }} peer import --input peering.yaml.asc --private-keyring privkey.asc \
+      --namespace liqo-system
+`
+
 func newPeerCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
 	options := &peer.Options{Factory: f}
 	cmd := &cobra.Command{
@@ -128,6 +165,8 @@ func newPeerCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
 
 	cmd.AddCommand(newPeerOutOfBandCommand(ctx, options))
 	cmd.AddCommand(newPeerInBandCommand(ctx, options))
+	cmd.AddCommand(newPeerExportCommand(ctx, f))
+	cmd.AddCommand(newPeerImportCommand(ctx, options))
 	return cmd
 }
 
@@ -198,3 +237,56 @@ func newPeerInBandCommand(ctx context.Context, peerOptions *peer.Options) *cobra
 
 	return cmd
 }
+
+func newPeerExportCommand(ctx context.Context, f *factory.Factory) *cobra.Command {
+	options := &peerexport.Options{Factory: f}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the configuration to peer with the local cluster as a bundle of manifests",
+		Long:  WithTemplate(liqoctlPeerExportLongHelp),
+		Args:  cobra.NoArgs,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			output.ExitOnErr(options.Run(ctx))
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.OutputFile, "output", "o", "",
+		"The file where the bundle is written (default: standard output)")
+	cmd.Flags().StringVar(&options.RecipientsKeyring, "recipients-keyring", "",
+		"The file containing the armored PGP public keys of the recipients, to seal the bundle (default: not sealed)")
+	cmd.Flags().StringVar(&options.RemoteLiqoNamespace, "remote-namespace", consts.DefaultLiqoNamespace,
+		"The namespace where Liqo is installed in the cluster importing the bundle")
+	cmd.Flags().BoolVar(&options.InsecureSkipTLSVerify, "insecure-skip-tls-verify", false,
+		"Whether the cluster importing the bundle should skip the TLS verification of the local authentication service")
+
+	f.AddLiqoNamespaceFlag(cmd.Flags())
+	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc(factory.FlagNamespace, completion.Namespaces(ctx, f, completion.NoLimit)))
+	return cmd
+}
+
+func newPeerImportCommand(ctx context.Context, peerOptions *peer.Options) *cobra.Command {
+	options := &peerimport.Options{Options: peerOptions}
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import a peering bundle, and enable an out-of-band peering towards the corresponding cluster",
+		Long:  WithTemplate(liqoctlPeerImportLongHelp),
+		Args:  cobra.NoArgs,
+
+		Run: func(cmd *cobra.Command, args []string) {
+			output.ExitOnErr(options.Run(ctx))
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.InputFile, "input", "i", "",
+		"The file containing the bundle to import (\"-\" to read from standard input)")
+	cmd.Flags().StringVar(&options.PrivateKeyring, "private-keyring", "",
+		"The file containing the armored PGP private key to unseal the bundle, if sealed")
+
+	f := peerOptions.Factory
+	f.AddLiqoNamespaceFlag(cmd.Flags())
+	f.Printer.CheckErr(cmd.RegisterFlagCompletionFunc(factory.FlagNamespace, completion.Namespaces(ctx, f, completion.NoLimit)))
+	f.Printer.CheckErr(cmd.MarkFlagRequired("input"))
+
+	return cmd
+}
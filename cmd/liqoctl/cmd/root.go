@@ -112,6 +112,7 @@ func NewRootCommand(ctx context.Context) *cobra.Command {
 	cmd.AddCommand(newGenerateCommand(ctx, f))
 	cmd.AddCommand(newOffloadCommand(ctx, f))
 	cmd.AddCommand(newUnoffloadCommand(ctx, f))
+	cmd.AddCommand(newOfferCommand(ctx, f))
 	cmd.AddCommand(newStatusCommand(ctx, f))
 	cmd.AddCommand(newMoveCommand(ctx, f))
 	cmd.AddCommand(newVersionCommand(ctx, f))
@@ -47,6 +47,12 @@ type gatewayOperatorFlags struct {
 	tunnelMTU            uint
 	tunnelListeningPort  uint
 	updateStatusInterval time.Duration
+	keysRotationInterval time.Duration
+	keysRotationOverlap  time.Duration
+	natTraversalTimeout  time.Duration
+	natTraversalRetry    time.Duration
+	pmtuDiscovery        time.Duration
+	maxMTU               uint
 }
 
 func addGatewayOperatorFlags(liqonet *gatewayOperatorFlags) {
@@ -64,10 +70,24 @@ func addGatewayOperatorFlags(liqonet *gatewayOperatorFlags) {
 		"listening-port is the port used by the vpn tunnel")
 	flag.DurationVar(&liqonet.updateStatusInterval, "gateway.ping-latency-update-interval", 30*time.Second,
 		"ping-latency-update-interval is the interval at which the gateway operator updates the latency value in the status of the tunnel-endpoint")
+	flag.DurationVar(&liqonet.keysRotationInterval, "gateway.keys-rotation-interval", 0,
+		"keys-rotation-interval is the interval after which the keys of the vpn tunnel are rotated (0 to disable the rotation)")
+	flag.DurationVar(&liqonet.keysRotationOverlap, "gateway.keys-rotation-overlap", 5*time.Minute,
+		"keys-rotation-overlap is the interval the new keys are announced to the remote clusters before being used")
+	flag.DurationVar(&liqonet.natTraversalTimeout, "gateway.nat-traversal-timeout", time.Minute,
+		"nat-traversal-timeout is the time after which a failed hole punching attempt falls back to relaying through the rendezvous cluster")
+	flag.DurationVar(&liqonet.natTraversalRetry, "gateway.nat-traversal-retry-interval", 10*time.Minute,
+		"nat-traversal-retry-interval is the interval after which the hole punching is attempted again, while relaying through the rendezvous cluster")
+	flag.DurationVar(&liqonet.pmtuDiscovery, "gateway.pmtu-discovery-interval", 0,
+		"pmtu-discovery-interval is the interval after which the path MTU towards each remote cluster is discovered again (0 to disable the discovery)")
+	flag.UintVar(&liqonet.maxMTU, "gateway.max-mtu", 1420,
+		"max-mtu is the maximum transmission unit of the tunnel interface, used as upper bound of the path MTU discovery (if enabled)")
 	flag.UintVar(&conncheck.PingLossThreshold, "gateway.ping-loss-threshold", 5,
 		"ping-loss-threshold is the number of lost packets after which the connection check is considered as failed.")
 	flag.DurationVar(&conncheck.PingInterval, "gateway.ping-interval", 2*time.Second,
 		"ping-interval is the interval between two connection checks")
+	flag.UintVar(&conncheck.PingPacketSize, "gateway.ping-packet-size", 0,
+		"ping-packet-size is the size of the connection check packets, which are padded if necessary (0 to disable padding)")
 }
 
 func runGatewayOperator(commonFlags *liqonetCommonFlags, gatewayFlags *gatewayOperatorFlags) {
@@ -82,6 +102,10 @@ func runGatewayOperator(commonFlags *liqonetCommonFlags, gatewayFlags *gatewayOp
 		klog.Errorf("port %d should be greater than %d and minor than %d", gatewayFlags.tunnelListeningPort, liqoconst.UDPMinPort, liqoconst.UDPMaxPort)
 		os.Exit(1)
 	}
+	if conncheck.PingPacketSize > conncheck.MaxPingPacketSize {
+		klog.Errorf("ping packet size %d should not be greater than %d", conncheck.PingPacketSize, conncheck.MaxPingPacketSize)
+		os.Exit(1)
+	}
 	port := gatewayFlags.tunnelListeningPort
 	MTU := gatewayFlags.tunnelMTU
 	updateStatusInterval := gatewayFlags.updateStatusInterval
@@ -92,6 +116,11 @@ func runGatewayOperator(commonFlags *liqonetCommonFlags, gatewayFlags *gatewayOp
 		klog.Errorf("unable to get podIP: %v", err)
 		os.Exit(1)
 	}
+	podName, err := liqonetutils.GetPodName()
+	if err != nil {
+		klog.Errorf("unable to get pod name: %v", err)
+		os.Exit(1)
+	}
 	podNamespace, err := liqonetutils.GetPodNamespace()
 	if err != nil {
 		klog.Errorf("unable to get pod namespace: %v", err)
@@ -147,8 +176,10 @@ func runGatewayOperator(commonFlags *liqonetCommonFlags, gatewayFlags *gatewayOp
 		klog.Errorf("unable to setup labeler controller: %s", err)
 		os.Exit(1)
 	}
-	tunnelController, err := tunneloperator.NewTunnelController(podIP.String(), podNamespace, eventRecorder,
-		clientset, main.GetClient(), &readyClustersMutex, readyClusters, gatewayNetns, hostNetns, int(MTU), int(port), updateStatusInterval)
+	tunnelController, err := tunneloperator.NewTunnelController(podIP.String(), podName, podNamespace, eventRecorder,
+		clientset, main.GetClient(), &readyClustersMutex, readyClusters, gatewayNetns, hostNetns, int(MTU), int(gatewayFlags.maxMTU), int(port),
+		updateStatusInterval, gatewayFlags.keysRotationInterval, gatewayFlags.keysRotationOverlap, gatewayFlags.natTraversalTimeout,
+		gatewayFlags.natTraversalRetry, gatewayFlags.pmtuDiscovery)
 	// If something goes wrong while creating and configuring the tunnel controller
 	// then make sure that we remove all the resources created during the create process.
 	if err != nil {
@@ -177,6 +208,16 @@ func runGatewayOperator(commonFlags *liqonetCommonFlags, gatewayFlags *gatewayOp
 		klog.Errorf("unable to setup natmapping controller: %s", err)
 		os.Exit(1)
 	}
+	peeringFirewallController, err := tunneloperator.NewPeeringFirewallController(main.GetClient(), &readyClustersMutex,
+		readyClusters, gatewayNetns)
+	if err != nil {
+		klog.Errorf("an error occurred while creating the peeringfirewall controller: %v", err)
+		os.Exit(1)
+	}
+	if err = peeringFirewallController.SetupWithManager(main); err != nil {
+		klog.Errorf("unable to setup peeringfirewall controller: %s", err)
+		os.Exit(1)
+	}
 
 	klog.Info("Starting manager as Tunnel-Operator")
 	if err := main.Start(tunnelController.SetupSignalHandlerForTunnelOperator()); err != nil {
@@ -27,6 +27,7 @@ import (
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 const (
@@ -51,6 +52,7 @@ func init() {
 func main() {
 	klog.InitFlags(nil)
 	restcfg.InitFlags(nil)
+	tracing.InitFlags(nil)
 
 	commonFlags := &liqonetCommonFlags{}
 	routeFlags := &routeOperatorFlags{}
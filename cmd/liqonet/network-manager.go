@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"os"
 
@@ -25,6 +26,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 
+	"github.com/liqotech/liqo/internal/liqonet/network-manager/firewallresolver"
 	"github.com/liqotech/liqo/internal/liqonet/network-manager/netcfgcreator"
 	"github.com/liqotech/liqo/internal/liqonet/network-manager/tunnelendpointcreator"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
@@ -33,6 +35,7 @@ import (
 	"github.com/liqotech/liqo/pkg/utils/args"
 	"github.com/liqotech/liqo/pkg/utils/mapper"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 type networkManagerFlags struct {
@@ -59,6 +62,17 @@ func runNetworkManager(commonFlags *liqonetCommonFlags, managerFlags *networkMan
 		os.Exit(1)
 	}
 
+	shutdownTracing, err := tracing.Init(context.Background(), liqoconst.LiqoNetworkManagerName)
+	if err != nil {
+		klog.Errorf("unable to initialize the tracing: %v", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("unable to shut down the tracing: %v", err)
+		}
+	}()
+
 	mgr, err := ctrl.NewManager(restcfg.SetRateLimiter(ctrl.GetConfigOrDie()), ctrl.Options{
 		MapperProvider:     mapper.LiqoMapperProvider(scheme),
 		Scheme:             scheme,
@@ -112,6 +126,12 @@ func runNetworkManager(commonFlags *liqonetCommonFlags, managerFlags *networkMan
 		os.Exit(1)
 	}
 
+	fr := &firewallresolver.FirewallResolver{Client: mgr.GetClient()}
+	if err = fr.SetupWithManager(mgr); err != nil {
+		klog.Errorf("unable to create controller FirewallResolver: %s", err)
+		os.Exit(1)
+	}
+
 	klog.Info("starting manager as liqo-network-manager")
 	if err := mgr.Start(tec.SetupSignalHandlerForTunEndCreator()); err != nil {
 		klog.Errorf("an error occurred while starting manager: %s", err)
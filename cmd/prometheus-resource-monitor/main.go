@@ -0,0 +1,104 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"google.golang.org/grpc"
+	"k8s.io/klog/v2"
+
+	resourcemonitors "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller/resource-monitors"
+	prometheusmonitor "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller/resource-monitors/prometheus-monitor"
+	argsutils "github.com/liqotech/liqo/pkg/utils/args"
+)
+
+func main() {
+	headroom := argsutils.Percentage{Val: 10}
+
+	listenAddress := flag.String("listen-address", ":7000", "The address the gRPC server binds to")
+	prometheusURL := flag.String("prometheus-url", "", "The URL of the Prometheus server the metrics are retrieved from")
+	queriesFile := flag.String("queries-file", "",
+		"The path of a YAML file containing the PromQL queries for each resource, overriding the default ones")
+	flag.Var(&headroom, "headroom", "The percentage of allocatable resources which is never offered to foreign clusters")
+	smoothingWindow := flag.Duration("smoothing-window", 5*time.Minute,
+		"The time window the usage metrics are aggregated over (replaces the $window placeholder in the queries)")
+	resyncPeriod := flag.Duration("resync-period", 30*time.Second, "The interval between two consecutive evaluations of the queries")
+	queryTimeout := flag.Duration("query-timeout", 10*time.Second, "The maximum duration of each evaluation of the queries")
+
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if *prometheusURL == "" {
+		klog.Error("The Prometheus URL must be specified")
+		os.Exit(1)
+	}
+
+	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	queries, err := prometheusmonitor.LoadQueries(*queriesFile)
+	if err != nil {
+		klog.Errorf("Failed to load the resource queries: %v", err)
+		os.Exit(1)
+	}
+
+	client, err := promapi.NewClient(promapi.Config{Address: *prometheusURL})
+	if err != nil {
+		klog.Errorf("Failed to create the Prometheus client: %v", err)
+		os.Exit(1)
+	}
+
+	server, err := prometheusmonitor.NewServer(promv1.NewAPI(client), &prometheusmonitor.Options{
+		Queries:         queries,
+		Headroom:        headroom.Val,
+		SmoothingWindow: *smoothingWindow,
+		ResyncPeriod:    *resyncPeriod,
+		QueryTimeout:    *queryTimeout,
+	})
+	if err != nil {
+		klog.Errorf("Failed to create the resource monitor: %v", err)
+		os.Exit(1)
+	}
+
+	lis, err := net.Listen("tcp", *listenAddress)
+	if err != nil {
+		klog.Errorf("Failed to listen on %s: %v", *listenAddress, err)
+		os.Exit(1)
+	}
+
+	grpcServer := grpc.NewServer()
+	resourcemonitors.RegisterResourceReaderServer(grpcServer, server)
+
+	go server.Start(ctx)
+	go func() {
+		<-ctx.Done()
+		klog.Info("Stopping gracefully")
+		grpcServer.GracefulStop()
+	}()
+
+	klog.Infof("Listening on %s", *listenAddress)
+	if err := grpcServer.Serve(lis); err != nil {
+		klog.Errorf("Failed to serve the gRPC API: %v", err)
+		os.Exit(1)
+	}
+}
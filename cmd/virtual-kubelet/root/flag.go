@@ -21,6 +21,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 // InstallFlags configures the virtual kubelet flags.
@@ -50,6 +51,10 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flags.UintVar(&o.SecretWorkers, "secret-reflection-workers", o.SecretWorkers, "The number of secret reflection workers")
 	flags.UintVar(&o.PersistentVolumeClaimWorkers, "persistentvolumeclaim-reflection-workers", o.PersistentVolumeClaimWorkers,
 		"The number of persistentvolumeclaim reflection workers")
+	flags.UintVar(&o.CustomResourceWorkers, "custom-resource-reflection-workers", o.CustomResourceWorkers,
+		"The number of reflection workers for each additional resource type")
+	flags.StringArrayVar(&o.CustomResourceReflection, "custom-resource-reflection", o.CustomResourceReflection,
+		"An additional resource type to be reflected, in the <resource>.<version>.<group>[;status][;exclude=<field>,...] format (can be repeated)")
 
 	flags.DurationVar(&o.NodeLeaseDuration, "node-lease-duration", o.NodeLeaseDuration, "The duration of the node leases")
 	flags.DurationVar(&o.NodePingInterval, "node-ping-interval", o.NodePingInterval,
@@ -57,6 +62,15 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flags.DurationVar(&o.NodePingTimeout, "node-ping-timeout", o.NodePingTimeout,
 		"The timeout of the remote API server reachability check")
 
+	flags.DurationVar(&o.NetworkMaxLatency, "network-max-latency", o.NetworkMaxLatency,
+		"The latency towards the remote cluster above which the virtual node is marked as network degraded, 0 to disable")
+
+	flags.Float64Var(&o.RemoteNodesUnhealthyThreshold, "remote-nodes-unhealthy-threshold", o.RemoteNodesUnhealthyThreshold,
+		"The percentage of remote nodes under pressure, not ready or unschedulable above which the virtual node reflects "+
+			"the corresponding conditions and taints, 0 to disable")
+	flags.Float64Var(&o.NetworkMaxPacketLoss, "network-max-packet-loss", o.NetworkMaxPacketLoss,
+		"The packet loss percentage towards the remote cluster above which the virtual node is marked as network degraded, 0 to disable")
+
 	flags.Var(&o.NodeExtraAnnotations, "node-extra-annotations", "Extra annotations to add to the Virtual Node")
 	flags.Var(&o.NodeExtraLabels, "node-extra-labels", "Extra labels to add to the Virtual Node")
 
@@ -65,6 +79,8 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flags.BoolVar(&o.EnableStorage, "enable-storage", false, "Enable the Liqo storage reflection")
 	flags.StringVar(&o.VirtualStorageClassName, "virtual-storage-class-name", "liqo", "Name of the virtual storage class")
 	flags.StringVar(&o.RemoteRealStorageClassName, "remote-real-storage-class-name", "", "Name of the real storage class to use for the actual volumes")
+	flags.BoolVar(&o.EnableVolumeSnapshots, "enable-volume-snapshots", false,
+		"Enable the reflection of the VolumeSnapshots of the virtual storage class (requires the storage reflection)")
 
 	flagset := flag.NewFlagSet("klog", flag.PanicOnError)
 	klog.InitFlags(flagset)
@@ -76,4 +92,8 @@ func InstallFlags(flags *pflag.FlagSet, o *Opts) {
 	flagset = flag.NewFlagSet("restcfg", flag.PanicOnError)
 	restcfg.InitFlags(flagset)
 	flags.AddGoFlagSet(flagset)
+
+	flagset = flag.NewFlagSet("tracing", flag.PanicOnError)
+	tracing.InitFlags(flagset)
+	flags.AddGoFlagSet(flagset)
 }
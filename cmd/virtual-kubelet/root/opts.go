@@ -49,8 +49,11 @@ const (
 	DefaultConfigMapWorkers            = 3
 	DefaultSecretWorkers               = 3
 	DefaultPersistenVolumeClaimWorkers = 3
+	DefaultCustomResourceWorkers       = 3
 
 	DefaultNodePingTimeout = 1 * time.Second
+
+	DefaultRemoteNodesUnhealthyThreshold = 50
 )
 
 // Opts stores all the options for configuring the root virtual-kubelet command.
@@ -81,11 +84,20 @@ type Opts struct {
 	ConfigMapWorkers             uint
 	SecretWorkers                uint
 	PersistentVolumeClaimWorkers uint
+	CustomResourceWorkers        uint
+
+	// Additional resource types to be reflected, in the <resource>.<version>.<group>[;options] format
+	CustomResourceReflection []string
 
 	NodeLeaseDuration time.Duration
 	NodePingInterval  time.Duration
 	NodePingTimeout   time.Duration
 
+	NetworkMaxLatency    time.Duration
+	NetworkMaxPacketLoss float64
+
+	RemoteNodesUnhealthyThreshold float64
+
 	NodeExtraAnnotations argsutils.StringMap
 	NodeExtraLabels      argsutils.StringMap
 
@@ -93,6 +105,7 @@ type Opts struct {
 	EnableStorage              bool
 	VirtualStorageClassName    string
 	RemoteRealStorageClassName string
+	EnableVolumeSnapshots      bool
 }
 
 // NewOpts returns an Opts struct with the default values set.
@@ -116,9 +129,12 @@ func NewOpts() *Opts {
 		ConfigMapWorkers:             DefaultConfigMapWorkers,
 		SecretWorkers:                DefaultSecretWorkers,
 		PersistentVolumeClaimWorkers: DefaultPersistenVolumeClaimWorkers,
+		CustomResourceWorkers:        DefaultCustomResourceWorkers,
 
 		NodeLeaseDuration: node.DefaultLeaseDuration * time.Second,
 		NodePingInterval:  node.DefaultPingInterval,
 		NodePingTimeout:   DefaultNodePingTimeout,
+
+		RemoteNodesUnhealthyThreshold: DefaultRemoteNodesUnhealthyThreshold,
 	}
 }
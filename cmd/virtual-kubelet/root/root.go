@@ -35,8 +35,10 @@ import (
 	tenantnamespace "github.com/liqotech/liqo/pkg/tenantNamespace"
 	"github.com/liqotech/liqo/pkg/utils"
 	"github.com/liqotech/liqo/pkg/utils/restcfg"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 	nodeprovider "github.com/liqotech/liqo/pkg/virtualKubelet/liqoNodeProvider"
 	podprovider "github.com/liqotech/liqo/pkg/virtualKubelet/provider"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/custom"
 )
 
 const defaultVersion = "v1.25.0" // This should follow the version of k8s.io/kubernetes we are importing
@@ -63,6 +65,21 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 		return errors.New("cluster name is mandatory")
 	}
 
+	customResources, err := custom.ParseResources(c.CustomResourceReflection)
+	if err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, "liqo-virtual-kubelet")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			klog.Errorf("Failed to shut down the tracing: %v", err)
+		}
+	}()
+
 	localConfig, err := utils.GetRestConfig(c.HomeKubeconfig)
 	if err != nil {
 		return err
@@ -103,11 +120,15 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 		ConfigMapWorkers:            c.ConfigMapWorkers,
 		SecretWorkers:               c.SecretWorkers,
 		PersistenVolumeClaimWorkers: c.PersistentVolumeClaimWorkers,
+		CustomResourceWorkers:       c.CustomResourceWorkers,
+
+		CustomResources: customResources,
 
 		EnableAPIServerSupport:     c.EnableAPIServerSupport,
 		EnableStorage:              c.EnableStorage,
 		VirtualStorageClassName:    c.VirtualStorageClassName,
 		RemoteRealStorageClassName: c.RemoteRealStorageClassName,
+		EnableVolumeSnapshots:      c.EnableVolumeSnapshots,
 	}
 
 	eb := record.NewBroadcaster()
@@ -135,6 +156,12 @@ func runRootCommand(ctx context.Context, c *Opts) error {
 
 		InformerResyncPeriod: c.InformerResyncPeriod,
 		PingDisabled:         c.NodePingInterval == 0,
+
+		NetworkThresholds: nodeprovider.NetworkThresholds{
+			MaxLatency:    c.NetworkMaxLatency,
+			MaxPacketLoss: c.NetworkMaxPacketLoss / 100,
+		},
+		RemoteNodesUnhealthyThreshold: c.RemoteNodesUnhealthyThreshold / 100,
 	}
 
 	nodeProvider := nodeprovider.NewLiqoNodeProvider(&nodecfg)
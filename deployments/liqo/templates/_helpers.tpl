@@ -180,6 +180,15 @@ Get the liqo clusterID ConfigMap name
 {{ include "liqo.prefixedName" $config }}
 {{- end -}}
 
+{{/*
+Get the arguments to configure the export of the OpenTelemetry spans
+*/}}
+{{- define "liqo.tracingArgs" -}}
+- --tracing-otlp-endpoint={{ .Values.tracing.otlpEndpoint }}
+- --tracing-otlp-insecure={{ .Values.tracing.insecure }}
+- --tracing-sampling-ratio={{ .Values.tracing.samplingRatio }}
+{{- end -}}
+
 {{/*
 Get the Pod security context
 */}}
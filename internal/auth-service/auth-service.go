@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
@@ -138,10 +139,13 @@ func (authService *Controller) Start(ctx context.Context, address string, useTLS
 	router.POST(auth.CertIdentityURI, authService.identity)
 	router.GET(auth.IdsURI, authService.ids)
 
+	// Wrap the router to extract the span context propagated by the remote clusters through the request headers.
+	handler := otelhttp.NewHandler(router, "auth-service")
+
 	if useTLS {
-		err = http.ListenAndServeTLS(address, certPath, keyPath, router)
+		err = http.ListenAndServeTLS(address, certPath, keyPath, handler)
 	} else {
-		err = http.ListenAndServe(address, router)
+		err = http.ListenAndServe(address, handler)
 	}
 	if err != nil {
 		klog.Error(err)
@@ -21,6 +21,7 @@ import (
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel/attribute"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
@@ -30,6 +31,7 @@ import (
 	autherrors "github.com/liqotech/liqo/pkg/auth/errors"
 	"github.com/liqotech/liqo/pkg/utils/authenticationtoken"
 	traceutils "github.com/liqotech/liqo/pkg/utils/trace"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 // identity handles the certificate identity http request.
@@ -80,10 +82,12 @@ func (authService *Controller) identity(w http.ResponseWriter, r *http.Request,
 
 // handleIdentity creates a certificate and a CertificateIdentityResponse, given a CertificateIdentityRequest.
 func (authService *Controller) handleIdentity(
-	ctx context.Context, identityRequest auth.CertificateIdentityRequest) (*auth.CertificateIdentityResponse, error) {
+	ctx context.Context, identityRequest auth.CertificateIdentityRequest) (_ *auth.CertificateIdentityResponse, err error) {
 	tracer := trace.FromContext(ctx).Nest("Identity handling")
 	defer tracer.LogIfLong(traceutils.LongThreshold())
-	var err error
+
+	ctx, span := tracing.Start(ctx, "Identity handling", attribute.String("liqo.cluster-id", identityRequest.ClusterIdentity.ClusterID))
+	defer func() { tracing.End(span, err) }()
 
 	// check that the provided credentials are valid
 	klog.V(4).Info("Checking credentials")
@@ -20,7 +20,9 @@ import (
 	"reflect"
 	"strconv"
 
+	"go.opentelemetry.io/otel/attribute"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -34,6 +36,7 @@ import (
 
 	"github.com/liqotech/liqo/pkg/consts"
 	traceutils "github.com/liqotech/liqo/pkg/utils/trace"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 const (
@@ -50,7 +53,7 @@ type item struct {
 }
 
 // handle is the reconciliation function which is executed to reflect an object.
-func (r *Reflector) handle(ctx context.Context, key item) error {
+func (r *Reflector) handle(ctx context.Context, key item) (err error) {
 	tracer := trace.New("Handle", trace.Field{Key: "RemoteClusterID", Value: r.remoteClusterID},
 		trace.Field{Key: "Resource", Value: key.gvr}, trace.Field{Key: "Name", Value: key.name})
 	defer tracer.LogIfLong(traceutils.LongThreshold())
@@ -64,6 +67,17 @@ func (r *Reflector) handle(ctx context.Context, key item) error {
 
 	// Retrieve the resource from the local cluster
 	local, err := resource.local.Get(key.name)
+
+	// The span is child of the one which originated the local object, if any.
+	var annotations map[string]string
+	if accessor, aerr := meta.Accessor(local); err == nil && aerr == nil {
+		annotations = accessor.GetAnnotations()
+	}
+	ctx, span := tracing.Start(tracing.ExtractAnnotations(ctx, annotations), "CRD replicator handle",
+		attribute.String("liqo.cluster-id", r.remoteClusterID), attribute.String("liqo.resource", key.gvr.String()),
+		attribute.String("liqo.name", key.name))
+	defer func() { tracing.End(span, err) }()
+
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			klog.Infof("[%v] Deleting remote %v with name %v, since the local one does no longer exist",
@@ -157,7 +171,8 @@ func (r *Reflector) createRemoteObject(ctx context.Context, resource *reflectedR
 	remote.SetNamespace(r.remoteNamespace)
 	remote.SetName(local.GetName())
 	remote.SetLabels(r.mutateLabelsForRemote(local.GetLabels()))
-	remote.SetAnnotations(local.GetAnnotations())
+	// Propagate the span context, so that the handling of the object can be traced in the remote cluster as well.
+	remote.SetAnnotations(tracing.InjectAnnotations(ctx, local.GetAnnotations()))
 
 	// Retrieve the spec of the local object
 	spec, err := r.getNestedMap(local, specKey, resource.gvr)
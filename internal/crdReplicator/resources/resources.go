@@ -53,6 +53,11 @@ func GetResourcesToReplicate() []Resource {
 			PeeringPhase:         consts.PeeringPhaseEstablished,
 			Ownership:            consts.OwnershipShared,
 		},
+		{
+			GroupVersionResource: netv1alpha1.ExportedServiceGroupVersionResource,
+			PeeringPhase:         consts.PeeringPhaseEstablished,
+			Ownership:            consts.OwnershipShared,
+		},
 		{
 			GroupVersionResource: vkv1alpha1.NamespaceMapGroupVersionResource,
 			PeeringPhase:         consts.PeeringPhaseOutgoing,
@@ -0,0 +1,237 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firewallresolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+)
+
+// FirewallResolver reconciles PeeringFirewall objects to resolve the selected destinations.
+type FirewallResolver struct {
+	client.Client
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups=net.liqo.io,resources=peeringfirewalls,verbs=get;list;watch
+// +kubebuilder:rbac:groups=net.liqo.io,resources=peeringfirewalls/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// Reconcile resolves the destinations selected by each rule of a PeeringFirewall, and stores them in its status.
+func (fr *FirewallResolver) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var firewall netv1alpha1.PeeringFirewall
+	if err := fr.Get(ctx, req.NamespacedName, &firewall); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			klog.Errorf("Failed retrieving PeeringFirewall %q: %v", req.Name, err)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	status := netv1alpha1.PeeringFirewallStatus{
+		ObservedGeneration: firewall.Generation,
+		Rules:              make([]netv1alpha1.PeeringFirewallRuleStatus, len(firewall.Spec.Ingress)),
+	}
+	for i := range firewall.Spec.Ingress {
+		destinations, err := fr.resolveDestinations(ctx, firewall.Spec.Ingress[i].To)
+		if err != nil {
+			klog.Errorf("Failed resolving the destinations of PeeringFirewall %q: %v", req.Name, err)
+			return ctrl.Result{}, err
+		}
+		status.Rules[i].Destinations = destinations
+	}
+
+	if equality.Semantic.DeepEqual(firewall.Status, status) {
+		return ctrl.Result{}, nil
+	}
+
+	firewall.Status = status
+	if err := fr.Status().Update(ctx, &firewall); err != nil {
+		klog.Errorf("Failed updating the status of PeeringFirewall %q: %v", req.Name, err)
+		return ctrl.Result{}, err
+	}
+	klog.Infof("Destinations of PeeringFirewall %q correctly updated", req.Name)
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager registers a new controller for PeeringFirewall resources.
+func (fr *FirewallResolver) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&netv1alpha1.PeeringFirewall{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(fr.firewallsEnqueuer),
+			builder.WithPredicates(podPredicate())).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(fr.firewallsEnqueuer),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Complete(fr)
+}
+
+// firewallsEnqueuer enqueues all the PeeringFirewalls, since a change of pods
+// and namespaces may potentially affect the destinations selected by any of them.
+func (fr *FirewallResolver) firewallsEnqueuer(_ client.Object) []ctrl.Request {
+	var firewalls netv1alpha1.PeeringFirewallList
+	if err := fr.List(context.Background(), &firewalls); err != nil {
+		klog.Errorf("Failed retrieving PeeringFirewalls: %v", err)
+		return nil
+	}
+
+	requests := make([]ctrl.Request, 0, len(firewalls.Items))
+	for i := range firewalls.Items {
+		requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Name: firewalls.Items[i].Name}})
+	}
+	return requests
+}
+
+// podPredicate selects the pod events possibly changing the resolved destinations.
+func podPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(ev event.UpdateEvent) bool {
+			oldPod, okOld := ev.ObjectOld.(*corev1.Pod)
+			newPod, okNew := ev.ObjectNew.(*corev1.Pod)
+			if !okOld || !okNew {
+				return false
+			}
+			return oldPod.Status.PodIP != newPod.Status.PodIP || isTerminated(oldPod) != isTerminated(newPod) ||
+				!labels.Equals(oldPod.GetLabels(), newPod.GetLabels())
+		},
+	}
+}
+
+// resolveDestinations returns the sorted list of IP addresses and CIDRs selected by the given peers.
+func (fr *FirewallResolver) resolveDestinations(ctx context.Context, peers []netv1alpha1.PeeringFirewallPeer) ([]string, error) {
+	destinations := sets.NewString()
+	for i := range peers {
+		peer := &peers[i]
+		if peer.CIDR != "" {
+			cidr, err := normalizeCIDR(peer.CIDR)
+			if err != nil {
+				// The error is not returned, as retrying would not help.
+				klog.Warningf("Skipping invalid CIDR %q: %v", peer.CIDR, err)
+				continue
+			}
+			destinations.Insert(cidr)
+			continue
+		}
+
+		if peer.NamespaceSelector == nil && peer.PodSelector == nil {
+			continue
+		}
+
+		ips, err := fr.resolvePods(ctx, peer.NamespaceSelector, peer.PodSelector)
+		if err != nil {
+			return nil, err
+		}
+		destinations.Insert(ips...)
+	}
+
+	// The list is sorted to prevent spurious updates, as well as unnecessary changes of the iptables rules.
+	list := destinations.UnsortedList()
+	sort.Strings(list)
+	return list, nil
+}
+
+// resolvePods returns the IP addresses of the pods matching the given selectors.
+func (fr *FirewallResolver) resolvePods(ctx context.Context, nsSelector, podSelector *metav1.LabelSelector) ([]string, error) {
+	podSel := labels.Everything()
+	if podSelector != nil {
+		var err error
+		if podSel, err = metav1.LabelSelectorAsSelector(podSelector); err != nil {
+			klog.Warningf("Skipping invalid pod selector: %v", err)
+			return nil, nil
+		}
+	}
+
+	// Pods are selected from all namespaces, unless a namespace selector is specified.
+	namespaces := []string{metav1.NamespaceAll}
+	if nsSelector != nil {
+		nsSel, err := metav1.LabelSelectorAsSelector(nsSelector)
+		if err != nil {
+			klog.Warningf("Skipping invalid namespace selector: %v", err)
+			return nil, nil
+		}
+
+		var nsList corev1.NamespaceList
+		if err := fr.List(ctx, &nsList, client.MatchingLabelsSelector{Selector: nsSel}); err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		namespaces = make([]string, 0, len(nsList.Items))
+		for i := range nsList.Items {
+			namespaces = append(namespaces, nsList.Items[i].Name)
+		}
+	}
+
+	var ips []string
+	for _, namespace := range namespaces {
+		var pods corev1.PodList
+		if err := fr.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: podSel}); err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			// Host network pods are excluded, since their IP is not part of the pod CIDR.
+			if pod.Spec.HostNetwork || isTerminated(pod) {
+				continue
+			}
+			if ip := net.ParseIP(pod.Status.PodIP); ip != nil && ip.To4() != nil {
+				ips = append(ips, ip.String())
+			}
+		}
+	}
+	return ips, nil
+}
+
+// isTerminated returns whether the given pod is terminated (hence, its IP might be reused).
+func isTerminated(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
+
+// normalizeCIDR returns the canonical representation of the given CIDR (or IP address).
+// Single addresses are returned without the prefix length, consistently with the iptables rules.
+func normalizeCIDR(cidr string) (string, error) {
+	if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+		return ip.String(), nil
+	}
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", err
+	}
+	if network.IP.To4() == nil {
+		return "", fmt.Errorf("only IPv4 CIDRs are supported")
+	}
+	if ones, bits := network.Mask.Size(); ones == bits {
+		return network.IP.String(), nil
+	}
+	return network.String(), nil
+}
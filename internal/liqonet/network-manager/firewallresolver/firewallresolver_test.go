@@ -0,0 +1,119 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firewallresolver
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+)
+
+var _ = Describe("FirewallResolver", func() {
+	const name = "firewall"
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		resolver   *FirewallResolver
+		firewall   *netv1alpha1.PeeringFirewall
+		objects    []client.Object
+		reconciled netv1alpha1.PeeringFirewall
+		err        error
+	)
+
+	forgeNamespace := func(name string, lbls map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: lbls}}
+	}
+
+	forgePod := func(namespace, name, ip string, lbls map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: lbls},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+
+		hostNetworkPod := forgePod("foo", "host", "172.16.0.1", map[string]string{"app": "web"})
+		hostNetworkPod.Spec.HostNetwork = true
+		terminatedPod := forgePod("foo", "terminated", "10.0.0.9", map[string]string{"app": "web"})
+		terminatedPod.Status.Phase = corev1.PodSucceeded
+
+		objects = []client.Object{
+			forgeNamespace("foo", map[string]string{"team": "foo"}),
+			forgeNamespace("bar", map[string]string{"team": "bar"}),
+			forgePod("foo", "web", "10.0.0.1", map[string]string{"app": "web"}),
+			forgePod("foo", "db", "10.0.0.2", map[string]string{"app": "db"}),
+			forgePod("bar", "web", "10.0.1.1", map[string]string{"app": "web"}),
+			forgePod("bar", "pending", "", map[string]string{"app": "web"}),
+			hostNetworkPod, terminatedPod,
+		}
+
+		firewall = &netv1alpha1.PeeringFirewall{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 3},
+			Spec: netv1alpha1.PeeringFirewallSpec{
+				ClusterID: "remote-cluster-id",
+				Ingress: []netv1alpha1.PeeringFirewallRule{
+					{To: []netv1alpha1.PeeringFirewallPeer{
+						{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+					}},
+					{To: []netv1alpha1.PeeringFirewallPeer{
+						{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "foo"}}},
+						{CIDR: "192.168.0.0/16"}, {CIDR: "192.168.1.1/32"}, {CIDR: "invalid"},
+					}},
+					{To: []netv1alpha1.PeeringFirewallPeer{{
+						NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "bar"}},
+						PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+					}}},
+					{},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, firewall)...).Build()
+		resolver = &FirewallResolver{Client: cl}
+
+		_, err = resolver.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
+		Expect(client.IgnoreNotFound(cl.Get(ctx, types.NamespacedName{Name: name}, &reconciled))).To(Succeed())
+	})
+
+	It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+	It("should set the observed generation", func() { Expect(reconciled.Status.ObservedGeneration).To(BeNumerically("==", 3)) })
+	It("should resolve the destinations of each rule", func() {
+		Expect(reconciled.Status.Rules).To(Equal([]netv1alpha1.PeeringFirewallRuleStatus{
+			{Destinations: []string{"10.0.0.1", "10.0.1.1"}},
+			{Destinations: []string{"10.0.0.1", "10.0.0.2", "192.168.0.0/16", "192.168.1.1"}},
+			{},
+			{},
+		}))
+	})
+
+	When("the PeeringFirewall does not exist", func() {
+		BeforeEach(func() { firewall.Name = "other" })
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+	})
+})
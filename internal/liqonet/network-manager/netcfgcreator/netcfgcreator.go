@@ -21,6 +21,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
@@ -28,12 +29,15 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	"github.com/liqotech/liqo/internal/crdReplicator/reflection"
+	"github.com/liqotech/liqo/pkg/consts"
 	foreigncluster "github.com/liqotech/liqo/pkg/utils/foreignCluster"
 	"github.com/liqotech/liqo/pkg/utils/syncset"
 	traceutils "github.com/liqotech/liqo/pkg/utils/trace"
@@ -57,6 +61,7 @@ type NetworkConfigCreator struct {
 // cluster-roles
 // +kubebuilder:rbac:groups=discovery.liqo.io,resources=foreignclusters,verbs=get;list;watch
 // +kubebuilder:rbac:groups=net.liqo.io,resources=networkconfigs,verbs=get;list;watch;create;update;patch;delete;deletecollection
+// +kubebuilder:rbac:groups=net.liqo.io,resources=tunnelendpoints,verbs=get;list;watch
 // roles
 // +kubebuilder:rbac:groups=core,namespace="do-not-care",resources=secrets,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,namespace="do-not-care",resources=services,verbs=get;list;watch
@@ -128,5 +133,56 @@ func (ncc *NetworkConfigCreator) SetupWithManager(mgr ctrl.Manager) error {
 			predicate.Or(predicate.GenerationChangedPredicate{}, predicate.LabelChangedPredicate{}), localNetcfg)).
 		Watches(&source.Kind{Type: &corev1.Secret{}}, ncc.secretWatcher.Handlers(), builder.WithPredicates(ncc.secretWatcher.Predicates())).
 		Watches(&source.Kind{Type: &corev1.Service{}}, ncc.serviceWatcher.Handlers(), builder.WithPredicates(ncc.serviceWatcher.Predicates())).
+		Watches(&source.Kind{Type: &netv1alpha1.TunnelEndpoint{}}, handler.EnqueueRequestsFromMapFunc(ncc.transitEnqueuer),
+			builder.WithPredicates(predicate.Or(transitPredicate(), rendezvousPredicate()))).
 		Complete(ncc)
 }
+
+// transitEnqueuer enqueues all the known ForeignClusters, to update the routes requested to the intermediate
+// clusters and the endpoints observed as rendezvous when a transit TunnelEndpoint changes.
+func (ncc *NetworkConfigCreator) transitEnqueuer(_ client.Object) []ctrl.Request {
+	var requests []ctrl.Request
+	ncc.foreignClusters.ForEach(func(fc string) {
+		requests = append(requests, ctrl.Request{NamespacedName: types.NamespacedName{Name: fc}})
+	})
+	return requests
+}
+
+// transitPredicate selects the TunnelEndpoints referring to clusters reached through an intermediate cluster.
+func transitPredicate() predicate.Predicate {
+	isTransit := func(obj client.Object) bool {
+		tep, ok := obj.(*netv1alpha1.TunnelEndpoint)
+		return ok && tep.Spec.TransitClusterID != ""
+	}
+
+	return predicate.Funcs{
+		CreateFunc:  func(ev event.CreateEvent) bool { return isTransit(ev.Object) },
+		UpdateFunc:  func(ev event.UpdateEvent) bool { return isTransit(ev.ObjectOld) || isTransit(ev.ObjectNew) },
+		DeleteFunc:  func(ev event.DeleteEvent) bool { return isTransit(ev.Object) },
+		GenericFunc: func(ev event.GenericEvent) bool { return isTransit(ev.Object) },
+	}
+}
+
+// rendezvousPredicate selects the TunnelEndpoints involved in NAT traversal (i.e., referring to clusters reached
+// through a rendezvous, or whose peer requested transit routes), limited to the changes of the specifications
+// and of the observed endpoints (to avoid reacting to the periodic updates of the connection status).
+func rendezvousPredicate() predicate.Predicate {
+	isRendezvous := func(obj client.Object) bool {
+		tep, ok := obj.(*netv1alpha1.TunnelEndpoint)
+		return ok && (tep.Spec.RendezvousClusterID != "" || len(tep.Spec.TransitRoutes) > 0)
+	}
+
+	observedEndpoint := func(obj client.Object) string {
+		return obj.(*netv1alpha1.TunnelEndpoint).Status.Connection.PeerConfiguration[consts.ObservedEndpoint]
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(ev event.CreateEvent) bool { return isRendezvous(ev.Object) },
+		UpdateFunc: func(ev event.UpdateEvent) bool {
+			return (isRendezvous(ev.ObjectOld) || isRendezvous(ev.ObjectNew)) &&
+				(ev.ObjectOld.GetGeneration() != ev.ObjectNew.GetGeneration() || observedEndpoint(ev.ObjectOld) != observedEndpoint(ev.ObjectNew))
+		},
+		DeleteFunc:  func(ev event.DeleteEvent) bool { return isRendezvous(ev.Object) },
+		GenericFunc: func(ev event.GenericEvent) bool { return isRendezvous(ev.Object) },
+	}
+}
@@ -31,6 +31,7 @@ import (
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
+	liqonetutils "github.com/liqotech/liqo/pkg/liqonet/utils"
 	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
 )
 
@@ -119,6 +120,18 @@ func (ncc *NetworkConfigCreator) EnforceNetworkConfigPresence(ctx context.Contex
 		consts.LocalResourceOwnership: componentName,
 	}
 
+	// Retrieve the routes the remote cluster is requested to forward, in case it acts as intermediate hop.
+	routes, err := ncc.transitRoutes(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
+	// Retrieve the public endpoints of the peers of the remote cluster, in case the local one acts as rendezvous.
+	endpoints, err := ncc.observedEndpoints(ctx, clusterID)
+	if err != nil {
+		return err
+	}
+
 	// Check if the resource for the remote cluster already exists
 	netcfg, err := GetLocalNetworkConfig(ctx, ncc.Client, labels, clusterID, fc.Status.TenantNamespace.Local)
 	if client.IgnoreNotFound(err) != nil {
@@ -127,22 +140,101 @@ func (ncc *NetworkConfigCreator) EnforceNetworkConfigPresence(ctx context.Contex
 
 	// Create the resource if not already present (if the error is not nil, then at this point is a not found one)
 	if err != nil {
-		return ncc.createNetworkConfig(ctx, fc)
+		return ncc.createNetworkConfig(ctx, fc, routes, endpoints)
 	}
 
 	// Otherwise, update the resource to ensure it is up-to-date
-	return ncc.updateNetworkConfig(ctx, netcfg, fc)
+	return ncc.updateNetworkConfig(ctx, netcfg, fc, routes, endpoints)
+}
+
+// transitRoutes returns the routes to be forwarded by the given cluster, acting as intermediate hop
+// towards the remote clusters not directly reachable from the local one. The clusters reached through
+// NAT traversal are included as well, since the rendezvous cluster acts as relay in case the hole punching fails.
+func (ncc *NetworkConfigCreator) transitRoutes(ctx context.Context, transitClusterID string) ([]netv1alpha1.TransitRoute, error) {
+	var teps netv1alpha1.TunnelEndpointList
+	if err := ncc.List(ctx, &teps); err != nil {
+		klog.Errorf("An error occurred while listing TunnelEndpoints: %v", err)
+		return nil, err
+	}
+
+	var routes []netv1alpha1.TransitRoute
+	for i := range teps.Items {
+		tep := &teps.Items[i]
+		if (tep.Spec.TransitClusterID == transitClusterID || tep.Spec.RendezvousClusterID == transitClusterID) &&
+			tep.GetDeletionTimestamp().IsZero() {
+			routes = append(routes, liqonetutils.ForgeTransitRoute(tep))
+		}
+	}
+
+	// Sort the routes, to guarantee a deterministic output.
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].DestinationClusterID < routes[j].DestinationClusterID
+	})
+	return routes, nil
+}
+
+// observedEndpoints returns the public endpoints, as observed by the local gateway, of the clusters the given one
+// requested to reach through the local cluster (i.e., acting as rendezvous). The endpoint of a cluster is returned
+// only if it is directly connected to the local one, and it requested in turn to reach the given cluster.
+func (ncc *NetworkConfigCreator) observedEndpoints(ctx context.Context, clusterID string) (map[string]string, error) {
+	var teps netv1alpha1.TunnelEndpointList
+	if err := ncc.List(ctx, &teps); err != nil {
+		klog.Errorf("An error occurred while listing TunnelEndpoints: %v", err)
+		return nil, err
+	}
+
+	byCluster := make(map[string]*netv1alpha1.TunnelEndpoint, len(teps.Items))
+	for i := range teps.Items {
+		byCluster[teps.Items[i].Spec.ClusterIdentity.ClusterID] = &teps.Items[i]
+	}
+
+	tep, found := byCluster[clusterID]
+	if !found {
+		return nil, nil
+	}
+
+	endpoints := make(map[string]string)
+	for i := range tep.Spec.TransitRoutes {
+		peer, found := byCluster[tep.Spec.TransitRoutes[i].DestinationClusterID]
+		if !found || !peer.GetDeletionTimestamp().IsZero() || peer.Spec.TransitClusterID != "" || peer.Spec.RendezvousClusterID != "" {
+			continue
+		}
+
+		if !hasTransitRouteTowards(peer, clusterID) {
+			continue
+		}
+
+		if endpoint := peer.Status.Connection.PeerConfiguration[consts.ObservedEndpoint]; endpoint != "" {
+			endpoints[peer.Spec.ClusterIdentity.ClusterID] = endpoint
+		}
+	}
+
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+	return endpoints, nil
+}
+
+// hasTransitRouteTowards returns whether the given TunnelEndpoint includes a transit route towards the given cluster.
+func hasTransitRouteTowards(tep *netv1alpha1.TunnelEndpoint, clusterID string) bool {
+	for i := range tep.Spec.TransitRoutes {
+		if tep.Spec.TransitRoutes[i].DestinationClusterID == clusterID {
+			return true
+		}
+	}
+	return false
 }
 
 // createNetworkConfig creates a new local NetworkConfig associated with the given ForeignCluster.
-func (ncc *NetworkConfigCreator) createNetworkConfig(ctx context.Context, fc *discoveryv1alpha1.ForeignCluster) error {
+func (ncc *NetworkConfigCreator) createNetworkConfig(ctx context.Context, fc *discoveryv1alpha1.ForeignCluster,
+	routes []netv1alpha1.TransitRoute, endpoints map[string]string) error {
 	netcfg := netv1alpha1.NetworkConfig{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      foreignclusterutils.UniqueName(&fc.Spec.ClusterIdentity),
 			Namespace: fc.Status.TenantNamespace.Local,
 		},
 	}
-	utilruntime.Must(ncc.populateNetworkConfig(&netcfg, fc))
+	utilruntime.Must(ncc.populateNetworkConfig(&netcfg, fc, routes, endpoints))
 
 	if err := ncc.Create(ctx, &netcfg); err != nil {
 		klog.Errorf("An error occurred while creating NetworkConfig: %v", err)
@@ -154,10 +246,10 @@ func (ncc *NetworkConfigCreator) createNetworkConfig(ctx context.Context, fc *di
 
 // updateNetworkConfig ensures the local NetworkConfig associated with the given ForeignCluster is up-to-date.
 func (ncc *NetworkConfigCreator) updateNetworkConfig(ctx context.Context, netcfg *netv1alpha1.NetworkConfig,
-	fc *discoveryv1alpha1.ForeignCluster) error {
+	fc *discoveryv1alpha1.ForeignCluster, routes []netv1alpha1.TransitRoute, endpoints map[string]string) error {
 	original := netcfg.DeepCopy()
 
-	if err := ncc.populateNetworkConfig(netcfg, fc); err != nil {
+	if err := ncc.populateNetworkConfig(netcfg, fc, routes, endpoints); err != nil {
 		klog.Errorf("An error occurred while updating NetworkConfig %q: %v", klog.KObj(netcfg), err)
 		return err
 	}
@@ -176,7 +268,8 @@ func (ncc *NetworkConfigCreator) updateNetworkConfig(ctx context.Context, netcfg
 }
 
 // populateNetworkConfig sets the correct parameters of the NetworkConfig.
-func (ncc *NetworkConfigCreator) populateNetworkConfig(netcfg *netv1alpha1.NetworkConfig, fc *discoveryv1alpha1.ForeignCluster) error {
+func (ncc *NetworkConfigCreator) populateNetworkConfig(netcfg *netv1alpha1.NetworkConfig, fc *discoveryv1alpha1.ForeignCluster,
+	routes []netv1alpha1.TransitRoute, endpoints map[string]string) error {
 	clusterIdentity := fc.Spec.ClusterIdentity
 
 	if netcfg.Labels == nil {
@@ -193,12 +286,21 @@ func (ncc *NetworkConfigCreator) populateNetworkConfig(netcfg *netv1alpha1.Netwo
 	netcfg.Spec.ExternalCIDR = ncc.ExternalCIDR
 	netcfg.Spec.EndpointIP = wgEndpointIP
 	netcfg.Spec.BackendType = consts.DriverName
+	netcfg.Spec.TransitClusterID = fc.GetAnnotations()[consts.TransitClusterAnnotationKey]
+	netcfg.Spec.TransitRoutes = routes
+	netcfg.Spec.RendezvousClusterID = fc.GetAnnotations()[consts.RendezvousClusterAnnotationKey]
+	netcfg.Spec.ObservedEndpoints = endpoints
 
 	if netcfg.Spec.BackendConfig == nil {
 		netcfg.Spec.BackendConfig = map[string]string{}
 	}
 	netcfg.Spec.BackendConfig[consts.PublicKey] = ncc.secretWatcher.WiregardPublicKey()
 	netcfg.Spec.BackendConfig[consts.ListeningPort] = wgEndpointPort
+	if nextPublicKey := ncc.secretWatcher.WiregardNextPublicKey(); nextPublicKey != "" {
+		netcfg.Spec.BackendConfig[consts.NextPublicKey] = nextPublicKey
+	} else {
+		delete(netcfg.Spec.BackendConfig, consts.NextPublicKey)
+	}
 
 	return controllerutil.SetControllerReference(fc, netcfg, ncc.Scheme)
 }
@@ -259,6 +259,7 @@ var _ = Describe("Network config functions", func() {
 				Expect(netcfg.Spec.BackendType).To(BeIdenticalTo(consts.DriverName))
 				Expect(netcfg.Spec.BackendConfig).To(HaveKeyWithValue(consts.PublicKey, "public-key"))
 				Expect(netcfg.Spec.BackendConfig).To(HaveKeyWithValue(consts.ListeningPort, "9999"))
+				Expect(netcfg.Spec.BackendConfig).ToNot(HaveKey(consts.NextPublicKey))
 			}
 
 			When("the network config associated with the given foreign cluster does not exist", func() {
@@ -368,4 +369,74 @@ var _ = Describe("Network config functions", func() {
 			})
 		})
 	})
+
+	Describe("The observedEndpoints function", func() {
+		var (
+			endpoints map[string]string
+			err       error
+		)
+
+		forgeTunnelEndpoint := func(name, remoteClusterID, endpoint string, destinations ...string) *netv1alpha1.TunnelEndpoint {
+			tep := &netv1alpha1.TunnelEndpoint{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: netv1alpha1.TunnelEndpointSpec{
+					ClusterIdentity: discoveryv1alpha1.ClusterIdentity{ClusterID: remoteClusterID, ClusterName: remoteClusterID},
+				},
+			}
+			for _, destination := range destinations {
+				tep.Spec.TransitRoutes = append(tep.Spec.TransitRoutes, netv1alpha1.TransitRoute{DestinationClusterID: destination})
+			}
+			if endpoint != "" {
+				tep.Status.Connection.PeerConfiguration = map[string]string{consts.ObservedEndpoint: endpoint}
+			}
+			return tep
+		}
+
+		JustBeforeEach(func() {
+			endpoints, err = fcw.observedEndpoints(ctx, "foo")
+		})
+
+		When("both clusters requested to reach each other through the local one", func() {
+			BeforeEach(func() {
+				clientBuilder.WithObjects(
+					forgeTunnelEndpoint("foo", "foo", "1.1.1.1:5871", "bar"),
+					forgeTunnelEndpoint("bar", "bar", "2.2.2.2:5871", "foo"),
+				)
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should return the endpoint observed for the peer cluster", func() {
+				Expect(endpoints).To(Equal(map[string]string{"bar": "2.2.2.2:5871"}))
+			})
+		})
+
+		When("the peer cluster did not request to reach the given one", func() {
+			BeforeEach(func() {
+				clientBuilder.WithObjects(
+					forgeTunnelEndpoint("foo", "foo", "1.1.1.1:5871", "bar"),
+					forgeTunnelEndpoint("bar", "bar", "2.2.2.2:5871"),
+				)
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should return no endpoints", func() { Expect(endpoints).To(BeNil()) })
+		})
+
+		When("the endpoint of the peer cluster has not been observed yet", func() {
+			BeforeEach(func() {
+				clientBuilder.WithObjects(
+					forgeTunnelEndpoint("foo", "foo", "1.1.1.1:5871", "bar"),
+					forgeTunnelEndpoint("bar", "bar", "", "foo"),
+				)
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should return no endpoints", func() { Expect(endpoints).To(BeNil()) })
+		})
+
+		When("the given cluster is not directly connected", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should return no endpoints", func() { Expect(endpoints).To(BeNil()) })
+		})
+	})
 })
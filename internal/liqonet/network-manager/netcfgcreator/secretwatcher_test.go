@@ -69,6 +69,20 @@ var _ = Describe("Secret Watcher functions", func() {
 			})
 		})
 
+		When("given a valid secret with the next public key", func() {
+			const nextKey = "bmV4dC1wdWJsaWMta2V5LW9mLXRoZS1sZW5ndGgtMzI="
+
+			BeforeEach(func() {
+				secret.Data = map[string][]byte{consts.PublicKey: []byte(key), consts.NextPublicKey: []byte(nextKey)}
+				sw.wiregardPublicKey = key
+				sw.configured = true
+			})
+
+			It("should retrieve the correct public key", func() { Expect(sw.WiregardPublicKey()).To(BeIdenticalTo(key)) })
+			It("should retrieve the correct next public key", func() { Expect(sw.WiregardNextPublicKey()).To(BeIdenticalTo(nextKey)) })
+			It("should execute the handle function", func() { Expect(handled).To(BeClosed()) })
+		})
+
 		When("given an invalid secret", func() {
 			BeforeEach(func() {
 				secret.Data = map[string][]byte{"incorrect-key": []byte(key)}
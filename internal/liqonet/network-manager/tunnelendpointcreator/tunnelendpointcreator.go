@@ -60,6 +60,10 @@ type networkParam struct {
 	localNatExternalCIDR  string
 	backendType           string
 	backendConfig         map[string]string
+	transitClusterID      string
+	transitRoutes         []netv1alpha1.TransitRoute
+	rendezvousClusterID   string
+	observedEndpoint      string
 }
 
 // TunnelEndpointCreator manages the most of liqo networking.
@@ -169,9 +173,34 @@ func (tec *TunnelEndpointCreator) SetupWithManager(mgr ctrl.Manager) error {
 		For(&netv1alpha1.NetworkConfig{}).
 		Watches(&source.Kind{Type: &netv1alpha1.TunnelEndpoint{}},
 			&handler.EnqueueRequestForOwner{OwnerType: &netv1alpha1.NetworkConfig{}, IsController: false}).
+		Watches(&source.Kind{Type: &netv1alpha1.NetworkConfig{}}, handler.EnqueueRequestsFromMapFunc(tec.rendezvousEnqueuer)).
 		Complete(tec)
 }
 
+// rendezvousEnqueuer enqueues the local NetworkConfigs associated with the clusters whose public endpoints
+// are advertised by the given remote NetworkConfig (i.e., the remote cluster acts as rendezvous).
+func (tec *TunnelEndpointCreator) rendezvousEnqueuer(obj client.Object) []ctrl.Request {
+	netcfg, ok := obj.(*netv1alpha1.NetworkConfig)
+	if !ok || len(netcfg.Spec.ObservedEndpoints) == 0 {
+		return nil
+	}
+
+	if _, remote := netcfg.GetLabels()[liqoconst.ReplicationOriginLabel]; !remote {
+		return nil
+	}
+
+	var requests []ctrl.Request
+	for clusterID := range netcfg.Spec.ObservedEndpoints {
+		local, err := netcfgcreator.GetLocalNetworkConfig(context.TODO(), tec.Client, nil, clusterID, "")
+		if err != nil {
+			klog.V(4).Infof("Failed to retrieve the local NetworkConfig for cluster %v: %v", clusterID, err)
+			continue
+		}
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(local)})
+	}
+	return requests
+}
+
 // SetupSignalHandlerForTunEndCreator registers for SIGTERM, SIGINT, SIGKILL. A stop channel is returned
 // which is closed on one of these signals.
 func (tec *TunnelEndpointCreator) SetupSignalHandlerForTunEndCreator() context.Context {
@@ -289,6 +318,14 @@ func (tec *TunnelEndpointCreator) enforceRemoteNetConfigStatus(ctx context.Conte
 	}
 	tracer.Step("CIDR remappings retrieval")
 
+	// Reserve the networks of the transit routes requested by the remote cluster, in case the local one acts as intermediate hop.
+	transitRoutes, err := tec.IPManager.SetTransitRoutesPerCluster(clusterID, netcfg.Spec.TransitRoutes)
+	if err != nil {
+		klog.Errorf("An error occurred while reserving the transit networks for resource %q: %v", klog.KObj(netcfg), err)
+		return err
+	}
+	tracer.Step("Transit networks reservation")
+
 	// Set the default values in case the CIDRs have not been remapped
 	if podCIDR == netcfg.Spec.PodCIDR {
 		podCIDR = liqoconst.DefaultCIDRValue
@@ -302,6 +339,7 @@ func (tec *TunnelEndpointCreator) enforceRemoteNetConfigStatus(ctx context.Conte
 	netcfg.Status.Processed = true
 	netcfg.Status.PodCIDRNAT = podCIDR
 	netcfg.Status.ExternalCIDRNAT = externalCIDR
+	netcfg.Status.TransitRoutes = transitRoutes
 
 	// Avoid performing updates in case it is not necessary
 	if !reflect.DeepEqual(original, netcfg.Status) {
@@ -332,6 +370,26 @@ func (tec *TunnelEndpointCreator) enforceTunnelEndpoint(ctx context.Context, loc
 		localNatExternalCIDR:  local.Status.ExternalCIDRNAT,
 		backendType:           remote.Spec.BackendType,
 		backendConfig:         remote.Spec.BackendConfig,
+		transitClusterID:      local.Spec.TransitClusterID,
+		transitRoutes:         remote.Status.TransitRoutes,
+		rendezvousClusterID:   local.Spec.RendezvousClusterID,
+	}
+
+	// The intermediate cluster may be configured only by the remote cluster.
+	if param.transitClusterID == "" {
+		param.transitClusterID = remote.Spec.TransitClusterID
+	}
+
+	// The same applies to the rendezvous cluster, which advertises the public endpoint of the remote cluster.
+	if param.rendezvousClusterID == "" {
+		param.rendezvousClusterID = remote.Spec.RendezvousClusterID
+	}
+	if param.rendezvousClusterID != "" {
+		endpoint, err := tec.observedEndpoint(ctx, param.rendezvousClusterID, param.remoteCluster.ClusterID)
+		if err != nil {
+			return err
+		}
+		param.observedEndpoint = endpoint
 	}
 
 	// Try to get the tunnelEndpoint, which may not exist
@@ -430,6 +488,26 @@ func (tec *TunnelEndpointCreator) fillTunnelEndpointSpec(tep *netv1alpha1.Tunnel
 	tep.Spec.EndpointIP = param.remoteEndpointIP
 	tep.Spec.BackendType = param.backendType
 	tep.Spec.BackendConfig = param.backendConfig
+	tep.Spec.TransitClusterID = param.transitClusterID
+	tep.Spec.TransitRoutes = param.transitRoutes
+	tep.Spec.RendezvousClusterID = param.rendezvousClusterID
+	tep.Spec.ObservedEndpoint = param.observedEndpoint
+}
+
+// observedEndpoint returns the public endpoint of the given cluster, as advertised by the rendezvous one.
+// An empty string is returned if the endpoint has not been advertised (yet).
+func (tec *TunnelEndpointCreator) observedEndpoint(ctx context.Context, rendezvousClusterID, clusterID string) (string, error) {
+	netcfg, err := netcfgcreator.GetRemoteNetworkConfig(ctx, tec.Client, rendezvousClusterID, "")
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("No remote NetworkConfig for rendezvous cluster %v found yet", rendezvousClusterID)
+			return "", nil
+		}
+
+		klog.Errorf("Failed to retrieve remote NetworkConfig for rendezvous cluster %v: %v", rendezvousClusterID, err)
+		return "", err
+	}
+	return netcfg.Spec.ObservedEndpoints[clusterID], nil
 }
 
 func (tec *TunnelEndpointCreator) deleteTunEndpoint(ctx context.Context, netConfig *netv1alpha1.NetworkConfig) error {
@@ -0,0 +1,121 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tunneloperator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/liqonet/iptables"
+)
+
+// PeeringFirewallController reconciles the PeeringFirewall objects referring to a given remote cluster.
+type PeeringFirewallController struct {
+	client.Client
+	iptables.IPTHandler
+	readyClustersMutex *sync.Mutex
+	readyClusters      map[string]struct{}
+	gatewayNetns       ns.NetNS
+}
+
+//+kubebuilder:rbac:groups=net.liqo.io,resources=peeringfirewalls,verbs=get;list;watch
+
+// Reconcile function handles requests made on PeeringFirewall resources, by guaranteeing that
+// the filtering rules of the remote cluster (whose ID is the name of the request) are updated.
+func (pfc *PeeringFirewallController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	clusterID := req.Name
+
+	// The following logic has to be executed in the custom network namespace,
+	// and not on the root namespace. Therefore it must be defined in a closure
+	// and then used as parameter of method Do of netNs
+	if err := pfc.gatewayNetns.Do(func(netNamespace ns.NetNS) error {
+		// Is the remote cluster tunnel ready? If not, do nothing, as the rules are configured by the tunnel controller.
+		pfc.readyClustersMutex.Lock()
+		defer pfc.readyClustersMutex.Unlock()
+		if _, ready := pfc.readyClusters[clusterID]; !ready {
+			return fmt.Errorf("tunnel for cluster {%s} is not ready", clusterID)
+		}
+		return ensureForwardACLRules(ctx, pfc.Client, pfc.IPTHandler, clusterID)
+	}); err != nil {
+		klog.Error(err)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (pfc *PeeringFirewallController) SetupWithManager(mgr ctrl.Manager) error {
+	// PeeringFirewalls are mapped to the corresponding remote cluster, since multiple resources contribute to the same rules.
+	enqueuer := func(obj client.Object) []ctrl.Request {
+		firewall, ok := obj.(*netv1alpha1.PeeringFirewall)
+		if !ok || firewall.Spec.ClusterID == "" {
+			return nil
+		}
+		return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: firewall.Spec.ClusterID}}}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("peeringfirewall").
+		Watches(&source.Kind{Type: &netv1alpha1.PeeringFirewall{}}, handler.EnqueueRequestsFromMapFunc(enqueuer)).
+		Complete(pfc)
+}
+
+// NewPeeringFirewallController returns a PeeringFirewall controller instance.
+func NewPeeringFirewallController(cl client.Client, readyClustersMutex *sync.Mutex,
+	readyClusters map[string]struct{}, gatewayNetns ns.NetNS) (*PeeringFirewallController, error) {
+	iptablesHandler, err := iptables.NewIPTHandler()
+	if err != nil {
+		return nil, err
+	}
+	return &PeeringFirewallController{
+		Client:             cl,
+		IPTHandler:         iptablesHandler,
+		readyClustersMutex: readyClustersMutex,
+		readyClusters:      readyClusters,
+		gatewayNetns:       gatewayNetns,
+	}, nil
+}
+
+// ensureForwardACLRules configures the filtering rules of the given remote cluster, according to the
+// PeeringFirewalls referring to it. It must be invoked while holding the ready clusters mutex, to prevent
+// outdated rules from being enforced in case of concurrent invocations.
+func ensureForwardACLRules(ctx context.Context, cl client.Client, ipt iptables.IPTHandler, clusterID string) error {
+	var firewalls netv1alpha1.PeeringFirewallList
+	if err := cl.List(ctx, &firewalls); err != nil {
+		return fmt.Errorf("unable to list PeeringFirewalls: %w", err)
+	}
+
+	selected := make([]netv1alpha1.PeeringFirewall, 0)
+	for i := range firewalls.Items {
+		if firewalls.Items[i].Spec.ClusterID == clusterID {
+			selected = append(selected, firewalls.Items[i])
+		}
+	}
+
+	if err := ipt.EnsureForwardACLRules(clusterID, selected); err != nil {
+		return fmt.Errorf("unable to ensure filtering rules for cluster {%s}: %w", clusterID, err)
+	}
+	return nil
+}
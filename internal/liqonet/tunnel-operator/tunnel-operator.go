@@ -31,6 +31,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8sApiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	k8s "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
@@ -38,6 +39,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
@@ -53,6 +55,9 @@ import (
 	liqolabels "github.com/liqotech/liqo/pkg/utils/labels"
 )
 
+// keysRotationCheckInterval is the interval between two checks of the keys rotation status.
+const keysRotationCheckInterval = time.Minute
+
 // TunnelController type of the tunnel controller.
 type TunnelController struct {
 	client.Client
@@ -64,6 +69,7 @@ type TunnelController struct {
 	drivers              map[string]tunnel.Driver
 	namespace            string
 	podIP                string
+	podName              string
 	finalizer            string
 	hostNetns            ns.NetNS
 	gatewayNetns         ns.NetNS
@@ -72,11 +78,17 @@ type TunnelController struct {
 	readyClustersMutex   *sync.Mutex
 	readyClusters        map[string]struct{}
 	updateStatusInterval time.Duration
+	// natTraversalTimeout is the timeout after which a failed hole punching attempt falls back to the relayed mode.
+	natTraversalTimeout time.Duration
+	// routesMTU is the MTU of the routes towards the remote clusters whose path MTU has not been discovered
+	// (zero to inherit the one of the tunnel device).
+	routesMTU int
 }
 
 // cluster-role
 // +kubebuilder:rbac:groups=net.liqo.io,resources=tunnelendpoints,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=net.liqo.io,resources=tunnelendpoints/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=net.liqo.io,resources=peeringfirewalls,verbs=get;list;watch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
 // role
 // +kubebuilder:rbac:groups=coordination.k8s.io,namespace="do-not-care",resources=leases,verbs=get;create;update
@@ -84,15 +96,17 @@ type TunnelController struct {
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 
 // NewTunnelController instantiates and initializes the tunnel controller.
-func NewTunnelController(podIP, namespace string, er record.EventRecorder, k8sClient k8s.Interface, cl client.Client,
-	readyClustersMutex *sync.Mutex, readyClusters map[string]struct{}, gatewayNetns, hostNetns ns.NetNS, mtu, port int,
-	updateStatusInterval time.Duration) (*TunnelController, error) {
+func NewTunnelController(podIP, podName, namespace string, er record.EventRecorder, k8sClient k8s.Interface, cl client.Client,
+	readyClustersMutex *sync.Mutex, readyClusters map[string]struct{}, gatewayNetns, hostNetns ns.NetNS, mtu, maxMTU, port int,
+	updateStatusInterval, keysRotationInterval, keysRotationOverlap, natTraversalTimeout, natTraversalRetryInterval,
+	pmtuDiscoveryInterval time.Duration) (*TunnelController, error) {
 	tunnelEndpointFinalizer := liqoconst.LiqoGatewayOperatorName + "." + liqoconst.FinalizersSuffix
 	tc := &TunnelController{
 		Client:               cl,
 		EventRecorder:        er,
 		k8sClient:            k8sClient,
 		podIP:                podIP,
+		podName:              podName,
 		namespace:            namespace,
 		finalizer:            tunnelEndpointFinalizer,
 		readyClustersMutex:   readyClustersMutex,
@@ -100,11 +114,26 @@ func NewTunnelController(podIP, namespace string, er record.EventRecorder, k8sCl
 		gatewayNetns:         gatewayNetns,
 		hostNetns:            hostNetns,
 		updateStatusInterval: updateStatusInterval,
+		natTraversalTimeout:  natTraversalTimeout,
+	}
+
+	// In case the path MTU discovery is enabled, the tunnel device is configured with the maximum MTU, while the routes
+	// towards each remote cluster are configured with the discovered one (falling back to the default MTU, if unknown).
+	deviceMTU := mtu
+	if pmtuDiscoveryInterval > 0 && maxMTU > mtu {
+		deviceMTU = maxMTU
+		tc.routesMTU = mtu
 	}
 
 	err := tc.SetUpTunnelDrivers(tunnel.Config{
-		MTU:           mtu,
-		ListeningPort: port,
+		MTU:                  deviceMTU,
+		ListeningPort:        port,
+		KeysRotationInterval: keysRotationInterval,
+		KeysRotationOverlap:  keysRotationOverlap,
+
+		NATTraversalTimeout:       natTraversalTimeout,
+		NATTraversalRetryInterval: natTraversalRetryInterval,
+		PathMTUDiscoveryInterval:  pmtuDiscoveryInterval,
 	})
 	if err != nil {
 		return nil, err
@@ -113,7 +142,7 @@ func NewTunnelController(podIP, namespace string, er record.EventRecorder, k8sCl
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve tunnel iface from host netns: %w", err)
 	}
-	if err = tc.setUpGWNetns(liqoconst.HostVethName, liqoconst.GatewayVethName, mtu); err != nil {
+	if err = tc.setUpGWNetns(liqoconst.HostVethName, liqoconst.GatewayVethName, deviceMTU); err != nil {
 		return nil, fmt.Errorf("failed to setup gateway netns: %w", err)
 	}
 	// Move wireguard interface in the gateway network namespace.
@@ -176,9 +205,10 @@ func (tc *TunnelController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	var err error
 	var remotePodCIDR string
 	var con *netv1alpha1.Connection
+	var pathMTU int
 
 	var configGWNetns = func(netNamespace ns.NetNS) error {
-		if err = tc.EnsureIPTablesRulesPerCluster(tep); err != nil {
+		if err = tc.EnsureIPTablesRulesPerCluster(ctx, tep); err != nil {
 			return err
 		}
 		con, err = tc.connectToPeer(tep, tc.forgeConncheckUpdateStatus(ctx, req))
@@ -189,7 +219,11 @@ func (tc *TunnelController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		tc.readyClustersMutex.Lock()
 		defer tc.readyClustersMutex.Unlock()
 		tc.readyClusters[tep.Spec.ClusterIdentity.ClusterID] = struct{}{}
-		added, err := tc.EnsureRoutesPerCluster(tep)
+		// The routes are configured according to the current path MTU, which is stored in the status afterwards.
+		pathMTU = tc.pathMTU(tep)
+		routed := tep.DeepCopy()
+		routed.Status.PathMTU = pathMTU
+		added, err := tc.EnsureRoutesPerCluster(routed)
 		if err != nil {
 			klog.Errorf("%s -> unable to configure route '%s': %s", tep.Spec.ClusterIdentity, remotePodCIDR, err)
 			tc.Eventf(tep, "Warning", "Processing", "unable to remove outdated route: %s", err.Error())
@@ -275,11 +309,22 @@ func (tc *TunnelController) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		// If object is being deleted and does not have a finalizer we just return.
 		return ctrl.Result{}, nil
 	}
+	// In case of failover, the current replica is immediately announced as the active one, so that the route operators
+	// switch the routes towards it while the tunnel is being configured.
+	if err := tc.takeOver(ctx, tep); err != nil {
+		return ctrl.Result{}, err
+	}
 	if err := tc.gatewayNetns.Do(configGWNetns); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, tc.updateStatus(con, tep)
+	// The NAT traversal mode is periodically re-evaluated, since the hole punching attempts may fail silently.
+	var result ctrl.Result
+	if tep.Spec.RendezvousClusterID != "" {
+		result.RequeueAfter = tc.natTraversalTimeout / 2
+	}
+
+	return result, tc.updateStatus(con, pathMTU, tep)
 }
 
 // EnforceIP enforce the presence of an ip on an interface.
@@ -317,6 +362,16 @@ func (tc *TunnelController) connectToPeer(ep *netv1alpha1.TunnelEndpoint, update
 	return con, nil
 }
 
+// pathMTU returns the path MTU discovered towards the remote cluster described by the given tep,
+// or zero if not available (e.g., the driver does not support the discovery).
+func (tc *TunnelController) pathMTU(ep *netv1alpha1.TunnelEndpoint) int {
+	discoverer, ok := tc.drivers[ep.Spec.BackendType].(tunnel.PathMTUDiscoverer)
+	if !ok {
+		return 0
+	}
+	return discoverer.PathMTU(ep.Spec.ClusterIdentity.ClusterID)
+}
+
 func (tc *TunnelController) disconnectFromPeer(ep *netv1alpha1.TunnelEndpoint) error {
 	// retrieve driver based on backend type
 	driver, ok := tc.drivers[ep.Spec.BackendType]
@@ -336,7 +391,7 @@ func (tc *TunnelController) disconnectFromPeer(ep *netv1alpha1.TunnelEndpoint) e
 }
 
 func (tc *TunnelController) forgeConncheckUpdateStatus(ctx context.Context, req ctrl.Request) conncheck.UpdateFunc {
-	return func(connected bool, latency time.Duration, timestamp time.Time) error {
+	return func(connected bool, quality conncheck.Quality, timestamp time.Time) error {
 		var tep = new(netv1alpha1.TunnelEndpoint)
 		if err := tc.Get(ctx, req.NamespacedName, tep); err != nil && !k8sApiErrors.IsNotFound(err) {
 			return fmt.Errorf("unable to fetch resource %s: %w", req.String(), err)
@@ -349,16 +404,26 @@ func (tc *TunnelController) forgeConncheckUpdateStatus(ctx context.Context, req
 			conn.Status = netv1alpha1.ConnectionError
 			conn.StatusMessage = netv1alpha1.ConnectionErrorMessage
 		}
+		// A change of the path MTU is propagated to the status, which in turn triggers the reconciliation of the routes.
+		pathMTU := tc.pathMTU(tep)
 		if tep.Status.Connection.Status != conn.Status || tep.Status.Connection.StatusMessage != conn.StatusMessage ||
-			timestamp.Sub(tep.Status.Connection.Latency.Timestamp.Time) > tc.updateStatusInterval {
+			tep.Status.PathMTU != pathMTU || timestamp.Sub(tep.Status.Connection.Latency.Timestamp.Time) > tc.updateStatusInterval {
 			if tep.Status.Connection.Status != conn.Status || tep.Status.Connection.StatusMessage != conn.StatusMessage {
 				klog.Infof("%s -> changing status to %s %q",
 					tep.Spec.ClusterIdentity, conn.Status, conn.StatusMessage)
 			}
+			if tep.Status.PathMTU != pathMTU {
+				klog.Infof("%s -> changing path MTU from %d to %d", tep.Spec.ClusterIdentity, tep.Status.PathMTU, pathMTU)
+			}
+			tep.Status.PathMTU = pathMTU
 			conn.Latency = netv1alpha1.ConnectionLatency{
-				Value:     liqonetutils.FormatLatency(latency),
+				Value:     liqonetutils.FormatLatency(quality.Latency),
 				Timestamp: metav1.Time{Time: timestamp},
 			}
+			conn.Quality = netv1alpha1.ConnectionQuality{
+				Jitter:     liqonetutils.FormatLatency(quality.Jitter),
+				PacketLoss: liqonetutils.FormatPacketLoss(quality.PacketLoss),
+			}
 			tep.Status.Connection = conn
 			if err := tc.Client.Status().Update(ctx, tep); err != nil {
 				return fmt.Errorf("unable to update resource %s: %w", req.String(), err)
@@ -384,12 +449,19 @@ func (tc *TunnelController) RemoveAllTunnels() {
 
 // EnsureIPTablesRulesPerCluster ensures the iptables rules needed to configure the network for
 // a given remote cluster.
-func (tc *TunnelController) EnsureIPTablesRulesPerCluster(tep *netv1alpha1.TunnelEndpoint) error {
+func (tc *TunnelController) EnsureIPTablesRulesPerCluster(ctx context.Context, tep *netv1alpha1.TunnelEndpoint) error {
 	if err := tc.EnsureChainsPerCluster(tep.Spec.ClusterIdentity.ClusterID); err != nil {
 		klog.Errorf("%s -> an error occurred while creating iptables chains for the remote peer: %s", tep.Spec.ClusterIdentity, err.Error())
 		tc.Eventf(tep, "Warning", "Processing", "unable to insert iptables rules: %v", err)
 		return err
 	}
+	// The filtering rules are configured before the chain rules, to prevent the traffic from being
+	// temporarily allowed through the (still empty) filtering chain of the remote cluster.
+	if err := tc.ensureForwardACLRules(ctx, tep.Spec.ClusterIdentity.ClusterID); err != nil {
+		klog.Errorf("%s -> an error occurred while inserting iptables filtering rules for the remote peer: %s", tep.Spec.ClusterIdentity, err.Error())
+		tc.Eventf(tep, "Warning", "Processing", "unable to insert iptables rules: %v", err)
+		return err
+	}
 	if err := tc.EnsureChainRulesPerCluster(tep); err != nil {
 		klog.Errorf("%s -> an error occurred while inserting iptables chain rules for the remote peer: %s", tep.Spec.ClusterIdentity, err.Error())
 		tc.Eventf(tep, "Warning", "Processing", "unable to insert iptables rules: %v", err)
@@ -410,10 +482,21 @@ func (tc *TunnelController) EnsureIPTablesRulesPerCluster(tep *netv1alpha1.Tunne
 		tc.Eventf(tep, "Warning", "Processing", "unable to insert iptables rules: %v", err)
 		return err
 	}
+	if err := tc.EnsureTransitRules(tep); err != nil {
+		klog.Errorf("%s -> an error occurred while inserting iptables transit rules for the remote peer: %s", tep.Spec.ClusterIdentity, err.Error())
+		tc.Eventf(tep, "Warning", "Processing", "unable to insert iptables rules: %v", err)
+		return err
+	}
 	tc.Event(tep, "Normal", "Processing", "iptables rules correctly inserted")
 	return nil
 }
 
+func (tc *TunnelController) ensureForwardACLRules(ctx context.Context, clusterID string) error {
+	tc.readyClustersMutex.Lock()
+	defer tc.readyClustersMutex.Unlock()
+	return ensureForwardACLRules(ctx, tc.Client, tc.IPTHandler, clusterID)
+}
+
 // SetupSignalHandlerForTunnelOperator registers for SIGTERM, SIGINT, SIGKILL. A context is returned
 // which is closed on one of these signals.
 func (tc *TunnelController) SetupSignalHandlerForTunnelOperator() context.Context {
@@ -439,11 +522,31 @@ func (tc *TunnelController) SetupWithManager(mgr ctrl.Manager) error {
 			return false
 		},
 	}
+	// The keys rotation is performed by the active replica only, hence the runnable requires the leader election.
+	if err := mgr.Add(manager.RunnableFunc(tc.rotateKeys)); err != nil {
+		return fmt.Errorf("failed to add the keys rotation runnable: %w", err)
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&netv1alpha1.TunnelEndpoint{}).WithEventFilter(resourceToBeProccesedPredicate).
 		Complete(tc)
 }
 
+// rotateKeys periodically triggers the keys rotation for the drivers supporting it, until the context is canceled.
+func (tc *TunnelController) rotateKeys(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		for tunnelType, driver := range tc.drivers {
+			rotator, ok := driver.(tunnel.KeysRotator)
+			if !ok {
+				continue
+			}
+			if err := rotator.RotateKeys(ctx); err != nil {
+				klog.Errorf("an error occurred while rotating the keys of the %s driver: %v", tunnelType, err)
+			}
+		}
+	}, keysRotationCheckInterval)
+	return nil
+}
+
 // SetUpTunnelDrivers for each registered tunnel implementation it creates and initializes the driver.
 func (tc *TunnelController) SetUpTunnelDrivers(config tunnel.Config) error {
 	tc.drivers = make(map[string]tunnel.Driver)
@@ -488,7 +591,7 @@ func (tc *TunnelController) SetUpIPTablesHandler() error {
 func (tc *TunnelController) SetUpRouteManager() error {
 	// Todo make the gateway routing manager to support more than one vpn technology at the same time.
 	// Todo it should use the right tunnel based on the backend type set inside the tep.
-	grm, err := liqorouting.NewGatewayRoutingManager(unix.RT_TABLE_MAIN, tc.drivers[liqoconst.DriverName].GetLink())
+	grm, err := liqorouting.NewGatewayRoutingManager(unix.RT_TABLE_MAIN, tc.drivers[liqoconst.DriverName].GetLink(), tc.routesMTU)
 	if err != nil {
 		return err
 	}
@@ -534,17 +637,16 @@ func (tc *TunnelController) setUpGWNetns(hostVethName, gatewayVethName string, v
 	})
 }
 
-func (tc *TunnelController) updateStatus(con *netv1alpha1.Connection, tep *netv1alpha1.TunnelEndpoint) error {
-	if reflect.DeepEqual(*con, tep.Status.Connection) && tep.Status.GatewayIP == tc.podIP &&
+func (tc *TunnelController) updateStatus(con *netv1alpha1.Connection, pathMTU int, tep *netv1alpha1.TunnelEndpoint) error {
+	if reflect.DeepEqual(*con, tep.Status.Connection) && tep.Status.PathMTU == pathMTU &&
+		tep.Status.GatewayIP == tc.podIP && tep.Status.GatewayPodName == tc.podName &&
 		tep.Status.VethIFaceIndex == tc.hostVeth.Index && tep.Status.VethIP == liqoconst.GatewayVethIPAddr {
 		return nil
 	}
 
 	tep.Status.Connection = *con
-	tep.Status.GatewayIP = tc.podIP
-	tep.Status.VethIFaceIndex = tc.hostVeth.Index
-	tep.Status.VethIFaceName = tc.hostVeth.Name
-	tep.Status.VethIP = liqoconst.GatewayVethIPAddr
+	tep.Status.PathMTU = pathMTU
+	tc.setActiveReplica(tep)
 
 	if err := tc.Status().Update(context.Background(), tep); err != nil {
 		if k8sApiErrors.IsConflict(err) {
@@ -558,6 +660,33 @@ func (tc *TunnelController) updateStatus(con *netv1alpha1.Connection, tep *netv1
 	return nil
 }
 
+// takeOver announces the current replica as the active one in the status of the given tep, in case it has been previously
+// configured by a different replica (i.e., failover). The rest of the status is preserved, and then updated once the tunnel is
+// configured. This way, the route operators can promptly switch the routes, rather than waiting for the tunnel configuration.
+func (tc *TunnelController) takeOver(ctx context.Context, tep *netv1alpha1.TunnelEndpoint) error {
+	if tep.Status.GatewayIP == "" || (tep.Status.GatewayIP == tc.podIP && tep.Status.GatewayPodName == tc.podName) {
+		return nil
+	}
+
+	klog.Infof("%s -> taking over the tunnel from gateway replica %q (%s)", tep.Spec.ClusterIdentity, tep.Status.GatewayPodName, tep.Status.GatewayIP)
+	tc.Eventf(tep, "Normal", "Failover", "gateway replica %q taking over from replica %q", tc.podName, tep.Status.GatewayPodName)
+	tc.setActiveReplica(tep)
+	if err := tc.Status().Update(ctx, tep); err != nil {
+		klog.Errorf("%s -> an error occurred while announcing the active gateway replica for resource %s: %v", tep.Spec.ClusterIdentity, tep.Name, err)
+		return err
+	}
+	return nil
+}
+
+// setActiveReplica sets the current replica as the active one in the status of the given tep.
+func (tc *TunnelController) setActiveReplica(tep *netv1alpha1.TunnelEndpoint) {
+	tep.Status.GatewayIP = tc.podIP
+	tep.Status.GatewayPodName = tc.podName
+	tep.Status.VethIFaceIndex = tc.hostVeth.Index
+	tep.Status.VethIFaceName = tc.hostVeth.Name
+	tep.Status.VethIP = liqoconst.GatewayVethIPAddr
+}
+
 // cleanupRouteFinalizers removes possible leftover route controller finalizers,
 // which might have not been deleted in case a node is tore down ungracefully.
 func (tc *TunnelController) cleanupRouteFinalizers(ctx context.Context, tep *netv1alpha1.TunnelEndpoint) error {
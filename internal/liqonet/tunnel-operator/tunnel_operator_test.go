@@ -15,11 +15,17 @@
 package tunneloperator
 
 import (
+	"net"
+
 	"github.com/containernetworking/plugins/pkg/ns"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/vishvananda/netlink"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
 )
 
@@ -69,4 +75,54 @@ var _ = Describe("TunnelOperator", func() {
 			})
 		})
 	})
+
+	Describe("failover between gateway replicas", func() {
+		var (
+			standby *TunnelController
+			tep     *netv1alpha1.TunnelEndpoint
+		)
+
+		BeforeEach(func() {
+			standby = &TunnelController{
+				Client:        k8sClient,
+				EventRecorder: record.NewFakeRecorder(10),
+				podIP:         "10.0.0.2",
+				podName:       "liqo-gateway-standby",
+				hostVeth:      net.Interface{Index: 42, Name: liqoconst.HostVethName},
+			}
+			tep = tep1.DeepCopy()
+			tep.ObjectMeta = metav1.ObjectMeta{GenerateName: "failover-", Namespace: labelerNamespace}
+			Expect(k8sClient.Create(ctx, tep)).To(Succeed())
+			tep.Status = netv1alpha1.TunnelEndpointStatus{
+				GatewayIP:      "10.0.0.1",
+				GatewayPodName: "liqo-gateway-active",
+				VethIFaceIndex: 7,
+				Connection:     netv1alpha1.Connection{Status: netv1alpha1.Connected, PeerConfiguration: map[string]string{"key": "value"}},
+			}
+			Expect(k8sClient.Status().Update(ctx, tep)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, tep)).To(Succeed())
+		})
+
+		It("should immediately announce the new active replica, preserving the shared state", func() {
+			Expect(standby.takeOver(ctx, tep)).To(Succeed())
+
+			var updated netv1alpha1.TunnelEndpoint
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(tep), &updated)).To(Succeed())
+			Expect(updated.Status.GatewayIP).To(Equal("10.0.0.2"))
+			Expect(updated.Status.GatewayPodName).To(Equal("liqo-gateway-standby"))
+			Expect(updated.Status.VethIFaceIndex).To(Equal(42))
+			Expect(updated.Status.VethIP).To(Equal(liqoconst.GatewayVethIPAddr))
+			Expect(updated.Status.Connection.PeerConfiguration).To(HaveKeyWithValue("key", "value"))
+		})
+
+		It("should not update the status if the replica is already the active one", func() {
+			standby.podIP, standby.podName = tep.Status.GatewayIP, tep.Status.GatewayPodName
+			resourceVersion := tep.ResourceVersion
+			Expect(standby.takeOver(ctx, tep)).To(Succeed())
+			Expect(tep.ResourceVersion).To(Equal(resourceVersion))
+		})
+	})
 })
@@ -31,6 +31,10 @@ func (c *FakeVirtualkubeletV1alpha1) NamespaceMaps(namespace string) v1alpha1.Na
 	return &FakeNamespaceMaps{c, namespace}
 }
 
+func (c *FakeVirtualkubeletV1alpha1) ReflectionPolicies(namespace string) v1alpha1.ReflectionPolicyInterface {
+	return &FakeReflectionPolicies{c, namespace}
+}
+
 func (c *FakeVirtualkubeletV1alpha1) ShadowPods(namespace string) v1alpha1.ShadowPodInterface {
 	return &FakeShadowPods{c, namespace}
 }
@@ -18,4 +18,5 @@ package v1alpha1
 
 type NamespaceMapExpansion interface{}
 
+type ReflectionPolicyExpansion interface{}
 type ShadowPodExpansion interface{}
@@ -24,6 +24,8 @@ import (
 type Interface interface {
 	// NamespaceMaps returns a NamespaceMapInformer.
 	NamespaceMaps() NamespaceMapInformer
+	// ReflectionPolicies returns a ReflectionPolicyInformer.
+	ReflectionPolicies() ReflectionPolicyInformer
 	// ShadowPods returns a ShadowPodInformer.
 	ShadowPods() ShadowPodInformer
 }
@@ -44,6 +46,11 @@ func (v *version) NamespaceMaps() NamespaceMapInformer {
 	return &namespaceMapInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ReflectionPolicies returns a ReflectionPolicyInformer.
+func (v *version) ReflectionPolicies() ReflectionPolicyInformer {
+	return &reflectionPolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // ShadowPods returns a ShadowPodInformer.
 func (v *version) ShadowPods() ShadowPodInformer {
 	return &shadowPodInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
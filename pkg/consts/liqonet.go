@@ -105,6 +105,18 @@ const (
 	GatewayServiceAnnotationKey = "net.liqo.io/gatewayNodeIP"
 	// NetworkConfigNamePrefix prefix used to generate the names of the networkconfigs.
 	NetworkConfigNamePrefix = "net-config-"
+	// TransitClusterAnnotationKey is the annotation set on a ForeignCluster to specify the ID of the intermediate
+	// cluster through which the traffic towards the given remote cluster is routed (i.e., multi-hop peering).
+	TransitClusterAnnotationKey = "net.liqo.io/transit-cluster-id"
+	// RendezvousClusterAnnotationKey is the annotation set on a ForeignCluster to specify the ID of the cluster acting
+	// as rendezvous to establish the tunnel towards the given remote cluster, in case both gateways are behind NAT.
+	RendezvousClusterAnnotationKey = "net.liqo.io/rendezvous-cluster-id"
+	// MaxLatencyAnnotationKey is the annotation set on a ForeignCluster to override the latency (e.g., 100ms) above
+	// which the connection towards the given remote cluster is considered degraded.
+	MaxLatencyAnnotationKey = "net.liqo.io/max-latency"
+	// MaxPacketLossAnnotationKey is the annotation set on a ForeignCluster to override the packet loss percentage
+	// (e.g., 5%) above which the connection towards the given remote cluster is considered degraded.
+	MaxPacketLossAnnotationKey = "net.liqo.io/max-packet-loss"
 )
 
 // LiqoRouteFinalizer returns the finalizer used by the route operator, based on its pod IP.
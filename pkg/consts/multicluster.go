@@ -0,0 +1,29 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consts
+
+const (
+	// ExportedServiceNamespaceLabel is the label used to mark the namespace of the service an ExportedService refers to.
+	ExportedServiceNamespaceLabel = "net.liqo.io/exported-service-namespace"
+	// ExportedServiceNameLabel is the label used to mark the name of the service an ExportedService refers to.
+	ExportedServiceNameLabel = "net.liqo.io/exported-service-name"
+
+	// ManagedByMultiClusterServicesValue is the value of the ManagedByLabelKey label used to mark the
+	// resources (e.g., derived services and endpointslices) created to implement the multi-cluster services.
+	ManagedByMultiClusterServicesValue = "multicluster-services"
+
+	// ClusterSetDomain is the domain the imported multi-cluster services are resolvable at.
+	ClusterSetDomain = "clusterset.local"
+)
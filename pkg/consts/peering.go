@@ -31,3 +31,27 @@ const (
 	// PeeringPhaseBidirectional -> both incoming and outgoing peerings have been established.
 	PeeringPhaseBidirectional PeeringPhase = "Bidirectional"
 )
+
+// PeerIdentityGroup is the group the identities granted to the remote clusters belong to.
+const PeerIdentityGroup = "liqo.io"
+
+// ResourceOfferApproval is the decision taken by an operator concerning a ResourceOffer.
+type ResourceOfferApproval string
+
+const (
+	// ResourceOfferApprovalAnnotationKey is the annotation set on a ResourceOffer to accept or refuse it,
+	// overriding the automatic decision.
+	ResourceOfferApprovalAnnotationKey = "liqo.io/offer-approval"
+	// ResourceOfferApprovedByAnnotationKey is the annotation recording the user who accepted or refused a ResourceOffer.
+	ResourceOfferApprovedByAnnotationKey = "liqo.io/offer-approved-by"
+	// ResourceOfferApprovalTimestampAnnotationKey is the annotation recording when a ResourceOffer was accepted or refused.
+	ResourceOfferApprovalTimestampAnnotationKey = "liqo.io/offer-approval-timestamp"
+	// ResourceOfferApprovalGenerationAnnotationKey is the annotation recording the generation of the ResourceOffer
+	// a decision refers to, so that the decision is discarded in case the spec changes afterwards.
+	ResourceOfferApprovalGenerationAnnotationKey = "liqo.io/offer-approval-generation"
+
+	// ResourceOfferApprovalAccepted -> the ResourceOffer has been manually accepted.
+	ResourceOfferApprovalAccepted ResourceOfferApproval = "accepted"
+	// ResourceOfferApprovalRefused -> the ResourceOffer has been manually refused.
+	ResourceOfferApprovalRefused ResourceOfferApproval = "refused"
+)
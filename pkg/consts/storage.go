@@ -26,6 +26,28 @@ const (
 	// VirtualPvcNameLabel is the label used to mark the name of a virtual PVC.
 	VirtualPvcNameLabel = "storage.liqo.io/virtual-pvc-name"
 
+	// VirtualSnapshotNamespaceLabel is the label used to mark the namespace of a virtual VolumeSnapshot.
+	VirtualSnapshotNamespaceLabel = "storage.liqo.io/virtual-snapshot-namespace"
+	// VirtualSnapshotNameLabel is the label used to mark the name of a virtual VolumeSnapshot.
+	VirtualSnapshotNameLabel = "storage.liqo.io/virtual-snapshot-name"
+	// VirtualSnapshotContentAnnotation is the annotation used to mark the name of the VolumeSnapshotContent
+	// a real VolumeSnapshot is backing.
+	VirtualSnapshotContentAnnotation = "storage.liqo.io/virtual-snapshot-content"
+
+	// VolumeRemoteAccessAnnotation is the annotation used to enable the access to a PVC from the pods offloaded to remote clusters.
+	VolumeRemoteAccessAnnotation = "storage.liqo.io/remote-access"
+	// VolumeRemoteAccessEndpointAnnotation is the annotation used to mark the address of the gateway exposing a PVC to remote clusters.
+	VolumeRemoteAccessEndpointAnnotation = "storage.liqo.io/remote-access-endpoint"
+	// VolumeGatewayLabel is the label used to mark the resources composing the gateway exposing a PVC to remote clusters.
+	VolumeGatewayLabel = "storage.liqo.io/volume-gateway"
+
 	// StorageNamespaceLabel is the label used to mark the liqo storage namespace.
 	StorageNamespaceLabel = "liqo.io/storage-provisioner"
+
+	// VolumeReplicationNamespaceLabel is the label used to mark the namespace of the VolumeReplication
+	// a replication-related resource refers to.
+	VolumeReplicationNamespaceLabel = "storage.liqo.io/volume-replication-namespace"
+	// VolumeReplicationNameLabel is the label used to mark the name of the VolumeReplication
+	// a replication-related resource refers to.
+	VolumeReplicationNameLabel = "storage.liqo.io/volume-replication-name"
 )
@@ -21,3 +21,16 @@ const NodeFinalizer = "liqo.io/node"
 // VirtualKubeletFinalizer is the finalizer added on a ResourceOffer when the related VirtualKubelet is up.
 // (managed by the ResourceOffer Operator).
 const VirtualKubeletFinalizer = "liqo.io/virtualkubelet"
+
+// RemoteNodesUnavailableTaintKey is the key of the taint added on a VirtualNode when a significant fraction of the nodes
+// of the remote cluster is either not ready or unschedulable (managed by the VirtualKubelet).
+const RemoteNodesUnavailableTaintKey = "liqo.io/remote-nodes-unavailable"
+
+const (
+	// RequestedResourcesAnnotationKey is the annotation set on a VirtualNode to report the resources demanded
+	// to the remote cluster (the granted ones are reported as the node capacity).
+	RequestedResourcesAnnotationKey = "liqo.io/requested-resources"
+	// ResourceDemandsStateAnnotationKey is the annotation set on a VirtualNode to report to which extent the
+	// resources demanded to the remote cluster have been granted.
+	ResourceDemandsStateAnnotationKey = "liqo.io/resource-demands-state"
+)
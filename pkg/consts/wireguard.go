@@ -17,14 +17,24 @@ package consts
 const (
 	// PublicKey is the key of publicKey entry in back-end map and also for the secret containing the wireguard keys.
 	PublicKey = "publicKey"
+	// NextPublicKey is the key of nextPublicKey entry in back-end map and also for the secret containing the wireguard keys.
+	// It is announced to the remote clusters before replacing the current public key, as part of the keys rotation.
+	NextPublicKey = "nextPublicKey"
 	// ListeningPort is the key of the listeningPort entry in the back-end map.
 	ListeningPort = "port"
+	// ObservedEndpoint is the key of the observedEndpoint entry in the peer configuration, set to the public endpoint
+	// (i.e., ip:port) of the remote gateway, as observed by the local one (or by the rendezvous cluster, in case of NAT traversal).
+	ObservedEndpoint = "observedEndpoint"
 	// DeviceName name of wireguard tunnel created on the custom network namespace.
 	DeviceName = "liqo.tunnel"
 	// DriverName  name of the driver which is also used as the type of the backend in tunnelendpoint CRD.
 	DriverName = "wireguard"
 	// KeysLabel label for the secret that contains the public key.
 	KeysLabel = "net.liqo.io/key"
+	// KeysGenerationTimestampAnnotation annotation of the secret containing the wireguard keys, set to the generation time of the current keys.
+	KeysGenerationTimestampAnnotation = "net.liqo.io/keys-generation-timestamp"
+	// NextKeysGenerationTimestampAnnotation annotation of the secret containing the wireguard keys, set to the generation time of the next keys.
+	NextKeysGenerationTimestampAnnotation = "net.liqo.io/next-keys-generation-timestamp"
 	// TunnelIP is the IP address of the tunnel.
 	TunnelIP = "169.254.0.1"
 )
@@ -14,7 +14,9 @@
 
 package identitymanager
 
-const defaultOrganization = "liqo.io"
+import "github.com/liqotech/liqo/pkg/consts"
+
+const defaultOrganization = consts.PeerIdentityGroup
 
 const (
 	localIdentitySecretLabel  = "discovery.liqo.io/local-identity"
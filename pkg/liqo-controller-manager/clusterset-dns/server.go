@@ -0,0 +1,200 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clustersetdns
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	serviceimportctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/serviceimport-controller"
+)
+
+// TTL is the time-to-live of the returned records, in seconds. It is kept small, since the set of
+// backends of headless services may change frequently.
+const TTL = 5
+
+// zone is the DNS zone the server is authoritative for.
+var zone = "svc." + consts.ClusterSetDomain + "."
+
+// Server is a DNS server resolving the names of the imported multi-cluster services.
+type Server struct {
+	client.Reader
+	Address string
+}
+
+var _ manager.Runnable = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// NewServer returns a new Server, listening on the given address and retrieving the data through the given reader.
+func NewServer(reader client.Reader, address string) *Server {
+	return &Server{Reader: reader, Address: address}
+}
+
+// Start starts the DNS server (both UDP and TCP), until the given context is canceled.
+func (s *Server) Start(ctx context.Context) error {
+	servers := []*dns.Server{
+		{Addr: s.Address, Net: "udp", Handler: s},
+		{Addr: s.Address, Net: "tcp", Handler: s},
+	}
+
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server *dns.Server) { errs <- server.ListenAndServe() }(server)
+	}
+	klog.Infof("ClusterSet DNS server listening on %v", s.Address)
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+		klog.Errorf("ClusterSet DNS server failed: %v", err)
+	}
+
+	for _, server := range servers {
+		// Errors are ignored, as they are returned also if the server failed to start.
+		_ = server.Shutdown()
+	}
+	return err
+}
+
+// NeedLeaderElection returns false, as the DNS server can be run by all replicas.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// ServeDNS answers the given DNS request.
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	msg := s.answer(context.Background(), r)
+	if err := w.WriteMsg(msg); err != nil {
+		klog.Warningf("Failed to write DNS response: %v", err)
+	}
+}
+
+// answer forges the response to the given DNS request.
+func (s *Server) answer(ctx context.Context, r *dns.Msg) *dns.Msg {
+	msg := new(dns.Msg)
+	msg.SetReply(r)
+
+	if len(r.Question) != 1 {
+		msg.SetRcode(r, dns.RcodeFormatError)
+		return msg
+	}
+
+	question := r.Question[0]
+	key, ok := parseName(question.Name)
+	if !ok {
+		if !dns.IsSubDomain(zone, strings.ToLower(question.Name)) {
+			msg.SetRcode(r, dns.RcodeRefused)
+			return msg
+		}
+		msg.SetRcode(r, dns.RcodeNameError)
+		msg.Authoritative = true
+		return msg
+	}
+
+	addresses, err := s.resolve(ctx, key)
+	switch {
+	case apierrors.IsNotFound(err):
+		msg.SetRcode(r, dns.RcodeNameError)
+		msg.Authoritative = true
+		return msg
+	case err != nil:
+		klog.Warningf("Failed to resolve %q: %v", question.Name, err)
+		msg.SetRcode(r, dns.RcodeServerFailure)
+		return msg
+	}
+
+	msg.Authoritative = true
+	if question.Qtype != dns.TypeA && question.Qtype != dns.TypeANY {
+		// The name exists, but no records of the requested type are available.
+		return msg
+	}
+
+	for _, address := range addresses {
+		msg.Answer = append(msg.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: question.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: TTL},
+			A:   address,
+		})
+	}
+	return msg
+}
+
+// resolve returns the IPv4 addresses associated with the given imported service. In case of ClusterSetIP
+// services, they correspond to the IPs of the ServiceImport, while in case of headless services to the
+// ready endpoints of the imported EndpointSlices.
+func (s *Server) resolve(ctx context.Context, key types.NamespacedName) ([]net.IP, error) {
+	var si mcsv1alpha1.ServiceImport
+	if err := s.Get(ctx, key, &si); err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	switch si.Spec.Type {
+	case mcsv1alpha1.Headless:
+		var endpointslices discoveryv1.EndpointSliceList
+		if err := s.List(ctx, &endpointslices, client.InNamespace(key.Namespace), client.MatchingLabels{
+			discoveryv1.LabelManagedBy:   serviceimportctrl.EndpointSliceManagedBy,
+			mcsv1alpha1.LabelServiceName: key.Name,
+		}); err != nil {
+			return nil, err
+		}
+
+		for i := range endpointslices.Items {
+			for j := range endpointslices.Items[i].Endpoints {
+				endpoint := &endpointslices.Items[i].Endpoints[j]
+				if pointer.BoolDeref(endpoint.Conditions.Ready, true) {
+					candidates = append(candidates, endpoint.Addresses...)
+				}
+			}
+		}
+	default:
+		candidates = si.Spec.IPs
+	}
+
+	addresses := make([]net.IP, 0, len(candidates))
+	for _, candidate := range candidates {
+		if ip := net.ParseIP(candidate).To4(); ip != nil {
+			addresses = append(addresses, ip)
+		}
+	}
+	return addresses, nil
+}
+
+// parseName extracts the namespace and name of the service from the given DNS name,
+// in the <service>.<namespace>.svc.clusterset.local. form.
+func parseName(name string) (types.NamespacedName, bool) {
+	name = strings.ToLower(dns.Fqdn(name))
+	if !strings.HasSuffix(name, "."+zone) {
+		return types.NamespacedName{}, false
+	}
+
+	labels := strings.Split(strings.TrimSuffix(name, "."+zone), ".")
+	if len(labels) != 2 || labels[0] == "" || labels[1] == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: labels[1], Name: labels[0]}, true
+}
@@ -0,0 +1,137 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clustersetdns
+
+import (
+	"context"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	serviceimportctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/serviceimport-controller"
+)
+
+var _ = Describe("ClusterSet DNS server", func() {
+	var (
+		server   *Server
+		objects  []client.Object
+		request  *dns.Msg
+		response *dns.Msg
+	)
+
+	BeforeEach(func() {
+		objects = []client.Object{
+			&mcsv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+				Spec:       mcsv1alpha1.ServiceImportSpec{Type: mcsv1alpha1.ClusterSetIP, IPs: []string{"10.96.0.42"}},
+			},
+			&mcsv1alpha1.ServiceImport{
+				ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: "bar"},
+				Spec:       mcsv1alpha1.ServiceImportSpec{Type: mcsv1alpha1.Headless},
+			},
+			&discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: "headless-slice", Namespace: "bar", Labels: map[string]string{
+					discoveryv1.LabelManagedBy:   serviceimportctrl.EndpointSliceManagedBy,
+					mcsv1alpha1.LabelServiceName: "headless",
+				}},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints: []discoveryv1.Endpoint{
+					{Addresses: []string{"10.50.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)}},
+					{Addresses: []string{"10.50.0.2"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(false)}},
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(mcsv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		server = NewServer(fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(), ":0")
+		response = server.answer(context.Background(), request)
+	})
+
+	addresses := func() []string {
+		var result []string
+		for _, rr := range response.Answer {
+			Expect(rr).To(BeAssignableToTypeOf(&dns.A{}))
+			Expect(rr.Header().Ttl).To(BeNumerically("==", TTL))
+			result = append(result, rr.(*dns.A).A.String())
+		}
+		return result
+	}
+
+	When("querying a ClusterSetIP service", func() {
+		BeforeEach(func() { request = new(dns.Msg).SetQuestion("foo.bar.svc.clusterset.local.", dns.TypeA) })
+		It("should return the ServiceImport IP", func() {
+			Expect(response.Rcode).To(Equal(dns.RcodeSuccess))
+			Expect(response.Authoritative).To(BeTrue())
+			Expect(addresses()).To(ConsistOf("10.96.0.42"))
+		})
+	})
+
+	When("querying a headless service", func() {
+		BeforeEach(func() { request = new(dns.Msg).SetQuestion("headless.bar.svc.clusterset.local.", dns.TypeA) })
+		It("should return the addresses of the ready endpoints", func() {
+			Expect(response.Rcode).To(Equal(dns.RcodeSuccess))
+			Expect(addresses()).To(ConsistOf("10.50.0.1"))
+		})
+	})
+
+	When("querying a record type different from A", func() {
+		BeforeEach(func() { request = new(dns.Msg).SetQuestion("foo.bar.svc.clusterset.local.", dns.TypeAAAA) })
+		It("should return an empty answer", func() {
+			Expect(response.Rcode).To(Equal(dns.RcodeSuccess))
+			Expect(response.Answer).To(BeEmpty())
+		})
+	})
+
+	When("querying a non existing service", func() {
+		BeforeEach(func() { request = new(dns.Msg).SetQuestion("missing.bar.svc.clusterset.local.", dns.TypeA) })
+		It("should return NXDOMAIN", func() { Expect(response.Rcode).To(Equal(dns.RcodeNameError)) })
+	})
+
+	When("querying a name outside the zone", func() {
+		BeforeEach(func() { request = new(dns.Msg).SetQuestion("foo.bar.svc.cluster.local.", dns.TypeA) })
+		It("should refuse the query", func() { Expect(response.Rcode).To(Equal(dns.RcodeRefused)) })
+	})
+
+	DescribeTable("the parseName function",
+		func(name string, expected types.NamespacedName, ok bool) {
+			key, valid := parseName(name)
+			Expect(valid).To(Equal(ok))
+			Expect(key).To(Equal(expected))
+		},
+		Entry("a valid name", "foo.bar.svc.clusterset.local.", types.NamespacedName{Namespace: "bar", Name: "foo"}, true),
+		Entry("a valid name, not fully qualified", "Foo.Bar.svc.clusterset.local", types.NamespacedName{Namespace: "bar", Name: "foo"}, true),
+		Entry("a name with too many labels", "a.foo.bar.svc.clusterset.local.", types.NamespacedName{}, false),
+		Entry("the zone itself", "svc.clusterset.local.", types.NamespacedName{}, false),
+	)
+
+	It("should not require leader election", func() {
+		Expect(NewServer(nil, "").NeedLeaderElection()).To(BeFalse())
+	})
+})
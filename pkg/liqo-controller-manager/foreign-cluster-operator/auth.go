@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/http"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -198,7 +199,8 @@ func (r *ForeignClusterReconciler) sendIdentityRequest(ctx context.Context, requ
 
 func sendRequest(ctx context.Context, transport *http.Transport, url string, payload *bytes.Buffer) (*http.Response, error) {
 	client := &http.Client{
-		Transport: transport,
+		// Propagate the span context to the remote authentication service through the request headers.
+		Transport: otelhttp.NewTransport(transport),
 		Timeout:   utils.HTTPRequestTimeout,
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, payload)
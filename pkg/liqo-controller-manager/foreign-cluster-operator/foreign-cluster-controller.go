@@ -21,6 +21,7 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -50,6 +51,7 @@ import (
 	liqolabels "github.com/liqotech/liqo/pkg/utils/labels"
 	peeringconditionsutils "github.com/liqotech/liqo/pkg/utils/peeringConditions"
 	traceutils "github.com/liqotech/liqo/pkg/utils/trace"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 const (
@@ -139,6 +141,9 @@ func (r *ForeignClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	ctx = trace.ContextWithTrace(ctx, tracer)
 	defer tracer.LogIfLong(traceutils.LongThreshold())
 
+	ctx, span := tracing.Start(ctx, "ForeignCluster reconcile", attribute.String("liqo.foreigncluster", req.Name))
+	defer func() { tracing.End(span, err) }()
+
 	var foreignCluster discoveryv1alpha1.ForeignCluster
 	if err := r.Client.Get(ctx, req.NamespacedName, &foreignCluster); err != nil && !errors.IsNotFound(err) {
 		klog.Error(err)
@@ -26,6 +26,7 @@ import (
 	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/utils"
 	foreigncluster "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 // ensureResourceRequest ensures the presence of a resource request to be sent to the specified ForeignCluster.
@@ -60,9 +61,15 @@ func (r *ForeignClusterReconciler) ensureResourceRequest(ctx context.Context,
 		}
 		resourceRequest.SetLabels(labels)
 
+		// Propagate the span context at creation time, so that the peering can be traced in the remote cluster as well.
+		if resourceRequest.CreationTimestamp.IsZero() {
+			resourceRequest.SetAnnotations(tracing.InjectAnnotations(ctx, resourceRequest.GetAnnotations()))
+		}
+
 		resourceRequest.Spec = discoveryv1alpha1.ResourceRequestSpec{
 			ClusterIdentity: r.HomeCluster,
 			AuthURL:         authURL,
+			Demands:         foreignCluster.Spec.ResourceDemands.DeepCopy(),
 		}
 
 		return controllerutil.SetControllerReference(foreignCluster, resourceRequest, r.Scheme)
@@ -0,0 +1,39 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcerequestoperator
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
+)
+
+// getExtendedResources returns the classes of devices backing the extended resources exposed by the given physical nodes
+// of the local cluster, to be advertised in the ResourceOffers (so that the remote clusters can target specific devices).
+func (u *OfferUpdater) getExtendedResources(nodes []corev1.Node) []sharingv1alpha1.ExtendedResourceClass {
+	return extendedresources.Classes(nodes, u.extendedResourceAttributes)
+}
+
+// extendedResourcesChanged returns whether the classes of devices backing the extended resources, given the current
+// physical nodes, changed since the last ResourceOffer update.
+func (u *OfferUpdater) extendedResourcesChanged(nodes []corev1.Node) bool {
+	classes := u.getExtendedResources(nodes)
+	u.extendedResourcesMutex.Lock()
+	defer u.extendedResourcesMutex.Unlock()
+	return !reflect.DeepEqual(classes, u.currentExtendedResources)
+}
@@ -0,0 +1,136 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcerequestoperator
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// getImages returns the container images stored on the given physical nodes of the local cluster, to be advertised in the
+// ResourceOffers (so that the scheduler of the remote clusters can favor the virtual nodes already storing the images).
+func (u *OfferUpdater) getImages(nodes []corev1.Node) []corev1.ContainerImage {
+	if u.maxImages == 0 {
+		return nil
+	}
+	return aggregateImages(nodes, u.imageRegistries, int(u.maxImages))
+}
+
+// listPhysicalNodes returns the physical nodes of the local cluster (i.e., excluding the virtual nodes).
+// The nodes are retrieved from the informer cache backing the client of the manager, hence without contacting the API server.
+func (u *OfferUpdater) listPhysicalNodes(ctx context.Context) ([]corev1.Node, error) {
+	req, err := labels.NewRequirement(consts.TypeLabel, selection.NotEquals, []string{consts.TypeNode})
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes corev1.NodeList
+	if err := u.client.List(ctx, &nodes, client.MatchingLabelsSelector{Selector: labels.NewSelector().Add(*req)}); err != nil {
+		return nil, err
+	}
+	return nodes.Items, nil
+}
+
+// imagesChanged returns whether the container images to be advertised, given the current physical nodes,
+// changed since the last ResourceOffer update.
+func (u *OfferUpdater) imagesChanged(nodes []corev1.Node) bool {
+	if u.maxImages == 0 {
+		return false
+	}
+
+	images := u.getImages(nodes)
+	u.imagesMutex.Lock()
+	defer u.imagesMutex.Unlock()
+	return !reflect.DeepEqual(images, u.currentImages)
+}
+
+// aggregateImages returns the container images stored on the given nodes, deduplicated, filtered according to the given
+// registries (if any), and sorted by decreasing size. At most maxImages images are returned, favoring the largest ones
+// (i.e., the ones whose pull would take longer), consistently with the image list reported by the kubelet.
+func aggregateImages(nodes []corev1.Node, registries []string, maxImages int) []corev1.ContainerImage {
+	var images []corev1.ContainerImage
+	// indexes maps each image name to the index of the corresponding image.
+	indexes := make(map[string]int)
+
+	for i := range nodes {
+		for j := range nodes[i].Status.Images {
+			names := filterImageNames(nodes[i].Status.Images[j].Names, registries)
+			if len(names) == 0 {
+				continue
+			}
+
+			// The same image is stored on multiple nodes, possibly referred to with different names (e.g., tag and digest).
+			index, found := -1, false
+			for _, name := range names {
+				if index, found = indexes[name]; found {
+					break
+				}
+			}
+			if !found {
+				index = len(images)
+				images = append(images, corev1.ContainerImage{SizeBytes: nodes[i].Status.Images[j].SizeBytes})
+			}
+
+			for _, name := range names {
+				if _, found := indexes[name]; !found {
+					indexes[name] = index
+					images[index].Names = append(images[index].Names, name)
+				}
+			}
+		}
+	}
+
+	for i := range images {
+		sort.Strings(images[i].Names)
+	}
+	sort.SliceStable(images, func(i, j int) bool {
+		if images[i].SizeBytes != images[j].SizeBytes {
+			return images[i].SizeBytes > images[j].SizeBytes
+		}
+		return images[i].Names[0] < images[j].Names[0]
+	})
+
+	if len(images) > maxImages {
+		images = images[:maxImages]
+	}
+	return images
+}
+
+// filterImageNames returns the image names belonging to one of the given registries (all, if no registry is given).
+func filterImageNames(names, registries []string) []string {
+	if len(registries) == 0 {
+		return names
+	}
+
+	var filtered []string
+	for _, name := range names {
+		for _, registry := range registries {
+			if strings.HasPrefix(name, strings.TrimSuffix(registry, "/")+"/") {
+				filtered = append(filtered, name)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,98 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcerequestoperator
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Container images", func() {
+	Describe("The aggregateImages function", func() {
+		var nodes []corev1.Node
+
+		node := func(images ...corev1.ContainerImage) corev1.Node {
+			return corev1.Node{Status: corev1.NodeStatus{Images: images}}
+		}
+
+		BeforeEach(func() {
+			nodes = []corev1.Node{
+				node(
+					corev1.ContainerImage{Names: []string{"docker.io/library/nginx:1.23"}, SizeBytes: 100},
+					corev1.ContainerImage{Names: []string{"ghcr.io/foo/model@sha256:abc", "ghcr.io/foo/model:v1"}, SizeBytes: 5000},
+				),
+				node(
+					corev1.ContainerImage{Names: []string{"ghcr.io/foo/model:v1", "ghcr.io/foo/model:latest"}, SizeBytes: 5000},
+					corev1.ContainerImage{Names: []string{"registry.k8s.io/pause:3.8"}, SizeBytes: 10},
+				),
+			}
+		})
+
+		It("should deduplicate the images and sort them by decreasing size", func() {
+			Expect(aggregateImages(nodes, nil, 10)).To(Equal([]corev1.ContainerImage{
+				{Names: []string{"ghcr.io/foo/model:latest", "ghcr.io/foo/model:v1", "ghcr.io/foo/model@sha256:abc"}, SizeBytes: 5000},
+				{Names: []string{"docker.io/library/nginx:1.23"}, SizeBytes: 100},
+				{Names: []string{"registry.k8s.io/pause:3.8"}, SizeBytes: 10},
+			}))
+		})
+
+		It("should return at most the given number of images, favoring the largest ones", func() {
+			images := aggregateImages(nodes, nil, 2)
+			Expect(images).To(HaveLen(2))
+			Expect(images[0].SizeBytes).To(BeNumerically("==", 5000))
+			Expect(images[1].SizeBytes).To(BeNumerically("==", 100))
+		})
+
+		It("should filter the images according to the given registries", func() {
+			Expect(aggregateImages(nodes, []string{"docker.io/", "registry.k8s.io"}, 10)).To(Equal([]corev1.ContainerImage{
+				{Names: []string{"docker.io/library/nginx:1.23"}, SizeBytes: 100},
+				{Names: []string{"registry.k8s.io/pause:3.8"}, SizeBytes: 10},
+			}))
+		})
+
+		It("should return no images if no nodes are given", func() {
+			Expect(aggregateImages(nil, nil, 10)).To(BeEmpty())
+		})
+	})
+
+	Describe("The imagesChanged function", func() {
+		var (
+			u     *OfferUpdater
+			nodes []corev1.Node
+		)
+
+		BeforeEach(func() {
+			u = &OfferUpdater{maxImages: 10}
+			nodes = []corev1.Node{{Status: corev1.NodeStatus{Images: []corev1.ContainerImage{
+				{Names: []string{"docker.io/library/nginx:1.23"}, SizeBytes: 100},
+			}}}}
+		})
+
+		It("should report a change if the aggregated images differ from the advertised ones", func() {
+			Expect(u.imagesChanged(nodes)).To(BeTrue())
+		})
+
+		It("should not report a change if the aggregated images match the advertised ones", func() {
+			u.currentImages = u.getImages(nodes)
+			Expect(u.imagesChanged(nodes)).To(BeFalse())
+		})
+
+		It("should not report a change if the advertisement is disabled", func() {
+			u.maxImages = 0
+			Expect(u.imagesChanged(nodes)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,66 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcerequestoperator
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils"
+)
+
+// nodesHealthChanged returns whether the health of the given physical nodes changed since the last ResourceOffer update.
+// The summary of the health of the nodes is advertised in the ResourceOffers (so that the remote clusters can stop
+// offloading pods when the local cluster is in trouble).
+func (u *OfferUpdater) nodesHealthChanged(nodes []corev1.Node) bool {
+	health := aggregateNodesHealth(nodes)
+	u.nodesHealthMutex.Lock()
+	defer u.nodesHealthMutex.Unlock()
+	return !reflect.DeepEqual(health, u.currentNodesHealth)
+}
+
+// aggregateNodesHealth returns the summary of the health of the given nodes.
+func aggregateNodesHealth(nodes []corev1.Node) *sharingv1alpha1.NodesHealth {
+	health := sharingv1alpha1.NodesHealth{Total: int32(len(nodes))}
+
+	for i := range nodes {
+		node := &nodes[i]
+		switch {
+		case !utils.IsNodeReady(node):
+			health.NotReady++
+		case node.Spec.Unschedulable:
+			health.Unschedulable++
+		}
+
+		for j := range node.Status.Conditions {
+			if node.Status.Conditions[j].Status != corev1.ConditionTrue {
+				continue
+			}
+
+			switch node.Status.Conditions[j].Type {
+			case corev1.NodeMemoryPressure:
+				health.MemoryPressure++
+			case corev1.NodeDiskPressure:
+				health.DiskPressure++
+			case corev1.NodePIDPressure:
+				health.PIDPressure++
+			}
+		}
+	}
+
+	return &health
+}
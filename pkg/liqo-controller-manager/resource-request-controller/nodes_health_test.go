@@ -0,0 +1,59 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcerequestoperator
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+)
+
+var _ = Describe("Nodes health", func() {
+	Describe("The aggregateNodesHealth function", func() {
+		node := func(unschedulable bool, conditions ...corev1.NodeConditionType) corev1.Node {
+			node := corev1.Node{Spec: corev1.NodeSpec{Unschedulable: unschedulable}}
+			for _, condition := range conditions {
+				node.Status.Conditions = append(node.Status.Conditions,
+					corev1.NodeCondition{Type: condition, Status: corev1.ConditionTrue})
+			}
+			return node
+		}
+
+		It("should return an empty summary if no nodes are present", func() {
+			Expect(aggregateNodesHealth(nil)).To(Equal(&sharingv1alpha1.NodesHealth{}))
+		})
+
+		It("should correctly summarize the health of the given nodes", func() {
+			nodes := []corev1.Node{
+				node(false, corev1.NodeReady),
+				node(false, corev1.NodeReady, corev1.NodeMemoryPressure, corev1.NodeDiskPressure),
+				node(true, corev1.NodeReady, corev1.NodePIDPressure),
+				node(true),
+				node(false, corev1.NodeMemoryPressure),
+			}
+
+			Expect(aggregateNodesHealth(nodes)).To(Equal(&sharingv1alpha1.NodesHealth{
+				Total:          5,
+				NotReady:       2,
+				Unschedulable:  1,
+				MemoryPressure: 2,
+				DiskPressure:   1,
+				PIDPressure:    1,
+			}))
+		})
+	})
+})
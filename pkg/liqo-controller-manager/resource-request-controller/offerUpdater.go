@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -33,6 +34,9 @@ import (
 	"github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/discovery"
 	resourcemonitors "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller/resource-monitors"
+	"github.com/liqotech/liqo/pkg/utils/demands"
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
+	"github.com/liqotech/liqo/pkg/utils/slice"
 )
 
 // OfferUpdater is a component that responds to ResourceRequests with the cluster's resources read from ResourceReader.
@@ -51,25 +55,46 @@ type OfferUpdater struct {
 	currentResources map[string]corev1.ResourceList
 	// updateThresholdPercentage is the change in resources that triggers an update of ResourceOffers.
 	updateThresholdPercentage uint
+	// maxImages is the maximum number of container images advertised in the ResourceOffers (0 disables the advertisement).
+	maxImages uint
+	// imageRegistries are the registries the advertised container images are restricted to (all, if empty).
+	imageRegistries []string
+	// currentImages are the container images that we last advertised in the ResourceOffers.
+	currentImages []corev1.ContainerImage
+	imagesMutex   sync.Mutex
+	// currentNodesHealth is the summary of the health of the local nodes that we last advertised in the ResourceOffers.
+	currentNodesHealth *sharingv1alpha1.NodesHealth
+	nodesHealthMutex   sync.Mutex
+	// extendedResourceAttributes are the node labels advertised as attributes of the extended resources.
+	extendedResourceAttributes []string
+	// currentExtendedResources are the classes of devices backing the extended resources that we last advertised.
+	currentExtendedResources []sharingv1alpha1.ExtendedResourceClass
+	extendedResourcesMutex   sync.Mutex
 
 	clusterIdentityCache map[string]discoveryv1alpha1.ClusterIdentity
 }
 
 // NewOfferUpdater constructs a new OfferUpdater.
+// The ResourceOffers advertise at most maxImages container images stored in the local cluster, possibly restricted
+// to the given registries, as well as the extended resources described by the given node labels.
 func NewOfferUpdater(ctx context.Context, k8sClient client.Client, homeCluster discoveryv1alpha1.ClusterIdentity,
 	clusterLabels map[string]string, reader resourcemonitors.ResourceReader, updateThresholdPercentage uint,
-	localRealStorageClassName string, enableStorage bool) *OfferUpdater {
+	localRealStorageClassName string, enableStorage bool, maxImages uint, imageRegistries []string,
+	extendedResourceAttributes []string) *OfferUpdater {
 	updater := &OfferUpdater{
-		ResourceReader:            reader,
-		client:                    k8sClient,
-		homeCluster:               homeCluster,
-		clusterLabels:             clusterLabels,
-		scheme:                    k8sClient.Scheme(),
-		localRealStorageClassName: localRealStorageClassName,
-		enableStorage:             enableStorage,
-		currentResources:          map[string]corev1.ResourceList{},
-		updateThresholdPercentage: updateThresholdPercentage,
-		clusterIdentityCache:      map[string]discoveryv1alpha1.ClusterIdentity{},
+		ResourceReader:             reader,
+		client:                     k8sClient,
+		homeCluster:                homeCluster,
+		clusterLabels:              clusterLabels,
+		scheme:                     k8sClient.Scheme(),
+		localRealStorageClassName:  localRealStorageClassName,
+		enableStorage:              enableStorage,
+		currentResources:           map[string]corev1.ResourceList{},
+		updateThresholdPercentage:  updateThresholdPercentage,
+		maxImages:                  maxImages,
+		imageRegistries:            imageRegistries,
+		extendedResourceAttributes: extendedResourceAttributes,
+		clusterIdentityCache:       map[string]discoveryv1alpha1.ClusterIdentity{},
 	}
 	updater.OfferQueue = NewOfferQueue(updater)
 	reader.Register(ctx, updater)
@@ -103,8 +128,24 @@ func (u *OfferUpdater) CreateOrUpdateOffer(cluster discoveryv1alpha1.ClusterIden
 	if err != nil {
 		return true, fmt.Errorf("error while reading resources from external resource monitor: %w", err)
 	}
+	nodes, err := u.listPhysicalNodes(ctx)
+	if err != nil {
+		return true, fmt.Errorf("error while retrieving the physical nodes: %w", err)
+	}
+	images := u.getImages(nodes)
+	nodesHealth := aggregateNodesHealth(nodes)
+	extendedResources := u.getExtendedResources(nodes)
 	u.currentResources[cluster.ClusterID] = resources.DeepCopy()
 	u.clusterIdentityCache[cluster.ClusterID] = cluster
+	u.imagesMutex.Lock()
+	u.currentImages = images
+	u.imagesMutex.Unlock()
+	u.nodesHealthMutex.Lock()
+	u.currentNodesHealth = nodesHealth
+	u.nodesHealthMutex.Unlock()
+	u.extendedResourcesMutex.Lock()
+	u.currentExtendedResources = extendedResources
+	u.extendedResourcesMutex.Unlock()
 	offer := &sharingv1alpha1.ResourceOffer{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: request.GetNamespace(),
@@ -125,13 +166,18 @@ func (u *OfferUpdater) CreateOrUpdateOffer(cluster discoveryv1alpha1.ClusterIden
 			}
 		}
 		offer.Spec.ClusterID = u.homeCluster.ClusterID
-		offer.Spec.ResourceQuota.Hard = resources.DeepCopy()
+		// The classes of devices offered under a class-qualified name are granted in addition to the aggregated resources.
+		offer.Spec.ResourceQuota.Hard = extendedresources.Grant(extendedResources, demands.Grant(request.Spec.Demands, resources))
 		offer.Spec.Labels = u.clusterLabels
+		offer.Spec.Images = images
+		offer.Spec.NodesHealth = nodesHealth
+		offer.Spec.ExtendedResources = extendedresources.Filter(extendedResources, offer.Spec.ResourceQuota.Hard)
 
 		offer.Spec.StorageClasses, err = u.getStorageClasses(ctx)
 		if err != nil {
 			return err
 		}
+		offer.Spec.StorageClasses = grantStorageClasses(request.Spec.Demands, offer.Spec.StorageClasses)
 
 		return controllerutil.SetControllerReference(request, offer, u.scheme)
 	})
@@ -147,8 +193,11 @@ func (u *OfferUpdater) CreateOrUpdateOffer(cluster discoveryv1alpha1.ClusterIden
 // identified by clusterID or for all clusters by passing resourcemonitors.AllClusterIDs.
 func (u *OfferUpdater) NotifyChange(clusterID string) {
 	if clusterID == resourcemonitors.AllClusterIDs {
+		// The advertised images, nodes health and extended resources are the same for all clusters, hence the check
+		// is performed only once, comparing the results aggregated from the cached nodes with the last advertised ones.
+		changed := u.physicalNodesChanged()
 		for clusterID := range u.currentResources {
-			if u.shouldUpdate(clusterID) {
+			if changed || u.shouldUpdate(clusterID) {
 				u.OfferQueue.Push(u.clusterIdentityCache[clusterID])
 			}
 		}
@@ -157,6 +206,21 @@ func (u *OfferUpdater) NotifyChange(clusterID string) {
 	}
 }
 
+// physicalNodesChanged returns whether the information advertised about the physical nodes (i.e., images, nodes health
+// and extended resources) changed since the last ResourceOffer update.
+func (u *OfferUpdater) physicalNodesChanged() bool {
+	// The timeout only applies in case the informer cache is not yet synced, since the nodes are not retrieved from the API server.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nodes, err := u.listPhysicalNodes(ctx)
+	if err != nil {
+		// Errors are ignored, since the nodes will be retrieved again at the next offer update.
+		return false
+	}
+	return u.imagesChanged(nodes) || u.nodesHealthChanged(nodes) || u.extendedResourcesChanged(nodes)
+}
+
 func (u *OfferUpdater) getStorageClasses(ctx context.Context) ([]sharingv1alpha1.StorageType, error) {
 	if !u.enableStorage {
 		return []sharingv1alpha1.StorageType{}, nil
@@ -188,6 +252,21 @@ func (u *OfferUpdater) getStorageClasses(ctx context.Context) ([]sharingv1alpha1
 	return storageTypes, nil
 }
 
+// grantStorageClasses returns the storage classes to be granted to a consumer cluster, given its demands.
+func grantStorageClasses(dmnds *discoveryv1alpha1.ResourceDemands, available []sharingv1alpha1.StorageType) []sharingv1alpha1.StorageType {
+	if dmnds == nil || len(dmnds.StorageClasses) == 0 {
+		return available
+	}
+
+	granted := []sharingv1alpha1.StorageType{}
+	for i := range available {
+		if slice.ContainsString(dmnds.StorageClasses, available[i].StorageClassName) {
+			granted = append(granted, available[i])
+		}
+	}
+	return granted
+}
+
 // SetThreshold sets the threshold for resource updates to trigger an update of the ResourceOffers.
 func (u *OfferUpdater) SetThreshold(updateThresholdPercentage uint) {
 	u.updateThresholdPercentage = updateThresholdPercentage
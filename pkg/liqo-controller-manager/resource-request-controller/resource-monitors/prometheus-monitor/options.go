@@ -0,0 +1,143 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusmonitor
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	liqoconsts "github.com/liqotech/liqo/pkg/consts"
+)
+
+const (
+	// WindowPlaceholder is the placeholder replaced with the smoothing window in the configured queries.
+	WindowPlaceholder = "$window"
+	// ClusterIDPlaceholder is the placeholder replaced with the ID of the requesting cluster in the cluster usage queries.
+	ClusterIDPlaceholder = "$clusterID"
+)
+
+// ResourceQuery contains the PromQL expressions used to compute the amount of a given resource which can be offered.
+// Each expression shall evaluate to either a scalar or an instant vector, whose samples are summed together.
+type ResourceQuery struct {
+	// Allocatable is the expression returning the total amount of the resource allocatable in the cluster.
+	Allocatable string `json:"allocatable"`
+	// Usage is the expression returning the amount of the resource currently used in the cluster.
+	Usage string `json:"usage"`
+	// ClusterUsage is an optional expression returning the amount of the resource used by the workloads offloaded
+	// by the cluster identified by the $clusterID placeholder. This amount is given back to that cluster, since
+	// resources consumed by a cluster shall not reduce the ones offered to the cluster itself.
+	ClusterUsage string `json:"clusterUsage,omitempty"`
+}
+
+// virtualNodesFilter excludes the metrics concerning Liqo virtual nodes, to prevent offering back the resources
+// obtained from other clusters. It requires kube-state-metrics to be configured to export the liqo.io/type node label.
+var virtualNodesFilter = fmt.Sprintf(` unless on(node) kube_node_labels{label_%s=%q}`,
+	strings.NewReplacer(".", "_", "/", "_").Replace(liqoconsts.TypeLabel), liqoconsts.TypeNode)
+
+// DefaultQueries returns the default queries, relying on the metrics exported by kube-state-metrics and cAdvisor.
+func DefaultQueries() map[corev1.ResourceName]ResourceQuery {
+	allocatable := func(resource corev1.ResourceName) string {
+		return fmt.Sprintf(`sum(kube_node_status_allocatable{resource=%q}%s)`, resource, virtualNodesFilter)
+	}
+
+	return map[corev1.ResourceName]ResourceQuery{
+		corev1.ResourceCPU: {
+			Allocatable: allocatable(corev1.ResourceCPU),
+			Usage:       `sum(rate(container_cpu_usage_seconds_total{container!=""}[$window]))`,
+		},
+		corev1.ResourceMemory: {
+			Allocatable: allocatable(corev1.ResourceMemory),
+			Usage:       `sum(max_over_time(container_memory_working_set_bytes{container!=""}[$window]))`,
+		},
+		corev1.ResourcePods: {
+			Allocatable: allocatable(corev1.ResourcePods),
+			Usage:       `sum(kube_pod_status_phase{phase=~"Pending|Running"})`,
+		},
+	}
+}
+
+// LoadQueries reads the queries from the given YAML file, and merges them with the default ones.
+// Entries referring to an already existing resource replace the default ones.
+func LoadQueries(path string) (map[corev1.ResourceName]ResourceQuery, error) {
+	queries := DefaultQueries()
+	if path == "" {
+		return queries, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queries file %q: %w", path, err)
+	}
+
+	var custom map[corev1.ResourceName]ResourceQuery
+	if err := yaml.UnmarshalStrict(content, &custom); err != nil {
+		return nil, fmt.Errorf("failed to parse queries file %q: %w", path, err)
+	}
+
+	for name, query := range custom {
+		queries[name] = query
+	}
+	return queries, nil
+}
+
+// Options contains the configuration of the Prometheus resource monitor.
+type Options struct {
+	// Queries maps each resource to be offered to the queries used to compute its amount.
+	Queries map[corev1.ResourceName]ResourceQuery
+	// Headroom is the percentage of the allocatable resources which is never offered to foreign clusters,
+	// to absorb utilization spikes.
+	Headroom uint64
+	// SmoothingWindow is the time window the usage metrics are aggregated over, to prevent the offered
+	// resources from oscillating due to short-lived utilization changes.
+	SmoothingWindow time.Duration
+	// ResyncPeriod is the interval between two consecutive evaluations of the queries.
+	ResyncPeriod time.Duration
+	// QueryTimeout is the maximum duration of each evaluation of the queries.
+	QueryTimeout time.Duration
+}
+
+// Validate checks whether the options are valid.
+func (o *Options) Validate() error {
+	if len(o.Queries) == 0 {
+		return fmt.Errorf("at least one resource query shall be specified")
+	}
+	for name, query := range o.Queries {
+		if query.Allocatable == "" || query.Usage == "" {
+			return fmt.Errorf("both the allocatable and the usage queries shall be specified for resource %q", name)
+		}
+	}
+	if o.Headroom >= 100 {
+		return fmt.Errorf("invalid headroom %d: it shall be lower than 100", o.Headroom)
+	}
+	if o.SmoothingWindow <= 0 || o.ResyncPeriod <= 0 || o.QueryTimeout <= 0 {
+		return fmt.Errorf("the smoothing window, the resync period and the query timeout shall be positive")
+	}
+	return nil
+}
+
+// forgeQuery replaces the placeholders in the given query. The cluster ID is escaped, as it is expected to be
+// enclosed in a double-quoted label matcher.
+func (o *Options) forgeQuery(query, clusterID string) string {
+	return strings.NewReplacer(
+		WindowPlaceholder, model.Duration(o.SmoothingWindow).String(),
+		ClusterIDPlaceholder, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(clusterID),
+	).Replace(query)
+}
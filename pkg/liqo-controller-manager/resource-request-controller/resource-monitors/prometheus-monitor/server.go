@@ -0,0 +1,259 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusmonitor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	resourcemonitors "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller/resource-monitors"
+)
+
+// stalenessFactor is the number of resync periods after which the retrieved values are considered stale.
+const stalenessFactor = 3
+
+// sample contains the values retrieved for a given resource.
+type sample struct {
+	allocatable float64
+	usage       float64
+}
+
+// Server is a ResourceReader gRPC server computing the resources to be offered from the metrics stored in Prometheus.
+type Server struct {
+	resourcemonitors.UnimplementedResourceReaderServer
+
+	api     promv1.API
+	options Options
+
+	mutex       sync.RWMutex
+	samples     map[corev1.ResourceName]sample
+	timestamp   time.Time
+	subscribers map[chan struct{}]struct{}
+}
+
+// NewServer returns a new Prometheus resource monitor server, retrieving the metrics through the given API.
+func NewServer(api promv1.API, options *Options) (*Server, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		api:         api,
+		options:     *options,
+		subscribers: make(map[chan struct{}]struct{}),
+	}, nil
+}
+
+// Start periodically evaluates the queries, notifying the subscribers in case the offerable resources changed.
+// It blocks until the context is canceled.
+func (s *Server) Start(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := s.refresh(ctx); err != nil {
+			klog.Errorf("Failed to retrieve the resource metrics from Prometheus: %v", err)
+		}
+	}, s.options.ResyncPeriod)
+}
+
+// ReadResources returns the resources offerable to the given cluster. An error is returned in case the values
+// retrieved from Prometheus are older than a few resync periods, and they cannot be refreshed, to prevent
+// offering resources based on outdated information.
+func (s *Server) ReadResources(ctx context.Context, req *resourcemonitors.ClusterIdentity) (*resourcemonitors.ResourceList, error) {
+	s.mutex.RLock()
+	samples, timestamp := s.samples, s.timestamp
+	s.mutex.RUnlock()
+
+	if samples == nil || time.Since(timestamp) > stalenessFactor*s.options.ResyncPeriod {
+		// The metrics have not been retrieved yet, or they are stale, hence perform the evaluation synchronously.
+		if err := s.refresh(ctx); err != nil {
+			if samples != nil {
+				return nil, fmt.Errorf("the resource metrics are stale (last retrieved at %v): %w", timestamp.Format(time.RFC3339), err)
+			}
+			return nil, err
+		}
+		s.mutex.RLock()
+		samples = s.samples
+		s.mutex.RUnlock()
+	}
+
+	response := &resourcemonitors.ResourceList{Resources: make(map[string]*resource.Quantity, len(samples))}
+	for name, current := range samples {
+		var clusterUsage float64
+		if query := s.options.Queries[name].ClusterUsage; query != "" && req.ClusterID != "" {
+			value, err := s.query(ctx, s.options.forgeQuery(query, req.ClusterID))
+			if err != nil {
+				return nil, fmt.Errorf("failed to retrieve the %v usage of cluster %q: %w", name, req.ClusterID, err)
+			}
+			clusterUsage = value
+		}
+
+		quantity := toQuantity(name, s.offerable(current, clusterUsage))
+		response.Resources[name.String()] = &quantity
+	}
+
+	return response, nil
+}
+
+// Subscribe notifies the client every time the offerable resources change, until the stream is closed.
+func (s *Server) Subscribe(_ *resourcemonitors.Empty, srv resourcemonitors.ResourceReader_SubscribeServer) error {
+	// The channel is buffered, so that multiple notifications can be coalesced if the client is slow.
+	notifications := make(chan struct{}, 1)
+
+	s.mutex.Lock()
+	s.subscribers[notifications] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.subscribers, notifications)
+		s.mutex.Unlock()
+	}()
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case <-notifications:
+			if err := srv.Send(&resourcemonitors.ClusterIdentity{ClusterID: resourcemonitors.AllClusterIDs}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// RemoveCluster is a no-op, since the server does not keep any per-cluster state.
+func (s *Server) RemoveCluster(context.Context, *resourcemonitors.ClusterIdentity) (*resourcemonitors.Empty, error) {
+	return &resourcemonitors.Empty{}, nil
+}
+
+// refresh evaluates the queries, recording the evaluation timestamp, and notifies the subscribers in case the
+// retrieved values changed.
+func (s *Server) refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.options.QueryTimeout)
+	defer cancel()
+
+	timestamp := time.Now()
+	samples := make(map[corev1.ResourceName]sample, len(s.options.Queries))
+	for name, query := range s.options.Queries {
+		allocatable, err := s.queryAt(ctx, s.options.forgeQuery(query.Allocatable, ""), timestamp)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve the allocatable %v: %w", name, err)
+		}
+		usage, err := s.queryAt(ctx, s.options.forgeQuery(query.Usage, ""), timestamp)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve the %v usage: %w", name, err)
+		}
+		samples[name] = sample{allocatable: allocatable, usage: usage}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.timestamp = timestamp
+	if s.changed(samples) {
+		klog.V(4).Infof("Offerable resources changed, notifying %d subscribers", len(s.subscribers))
+		s.samples = samples
+		for subscriber := range s.subscribers {
+			select {
+			case subscriber <- struct{}{}:
+			default:
+				// A notification is already pending.
+			}
+		}
+	}
+	return nil
+}
+
+// changed returns whether the offerable resources computed from the given samples differ from the current ones.
+// It shall be called while holding the mutex.
+func (s *Server) changed(samples map[corev1.ResourceName]sample) bool {
+	if s.samples == nil || len(s.samples) != len(samples) {
+		return true
+	}
+	for name, current := range samples {
+		previous, found := s.samples[name]
+		if !found {
+			return true
+		}
+		previousQuantity := toQuantity(name, s.offerable(previous, 0))
+		if currentQuantity := toQuantity(name, s.offerable(current, 0)); !previousQuantity.Equal(currentQuantity) {
+			return true
+		}
+	}
+	return false
+}
+
+// offerable computes the amount of resources which can be offered, given the retrieved sample and the amount
+// of resources already consumed by the requesting cluster.
+func (s *Server) offerable(current sample, clusterUsage float64) float64 {
+	available := current.allocatable * float64(100-s.options.Headroom) / 100
+	return math.Max(0, math.Min(available, available-current.usage+clusterUsage))
+}
+
+// query evaluates the given expression at the current time, and returns the resulting value.
+func (s *Server) query(ctx context.Context, query string) (float64, error) {
+	return s.queryAt(ctx, query, time.Now())
+}
+
+// queryAt evaluates the given expression at the given time, and returns the resulting value. Instant vectors
+// are summed together, while empty results are considered as zero.
+func (s *Server) queryAt(ctx context.Context, query string, timestamp time.Time) (float64, error) {
+	value, warnings, err := s.api.Query(ctx, query, timestamp)
+	if err != nil {
+		return 0, fmt.Errorf("failed to evaluate query %q: %w", query, err)
+	}
+	for _, warning := range warnings {
+		klog.Warningf("Query %q returned warning: %s", query, warning)
+	}
+
+	var result float64
+	switch typed := value.(type) {
+	case *model.Scalar:
+		result = float64(typed.Value)
+	case model.Vector:
+		for _, sample := range typed {
+			result += float64(sample.Value)
+		}
+	default:
+		return 0, fmt.Errorf("query %q returned unsupported result type %v", query, value.Type())
+	}
+
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, fmt.Errorf("query %q returned invalid value %v", query, result)
+	}
+	return result, nil
+}
+
+// toQuantity converts the given value into a quantity, rounding it down to the closest integer
+// (milli-units in case of CPU).
+func toQuantity(name corev1.ResourceName, value float64) resource.Quantity {
+	switch name {
+	case corev1.ResourceCPU:
+		return *resource.NewMilliQuantity(int64(math.Floor(value*1000)), resource.DecimalSI)
+	case corev1.ResourceMemory, corev1.ResourceEphemeralStorage:
+		return *resource.NewQuantity(int64(math.Floor(value)), resource.BinarySI)
+	default:
+		return *resource.NewQuantity(int64(math.Floor(value)), resource.DecimalSI)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheusmonitor
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"google.golang.org/grpc"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	resourcemonitors "github.com/liqotech/liqo/pkg/liqo-controller-manager/resource-request-controller/resource-monitors"
+)
+
+// fakePrometheus is a fake Prometheus server answering instant queries with the configured values.
+type fakePrometheus struct {
+	mutex   sync.Mutex
+	results map[string]string
+	queries []string
+}
+
+func (f *fakePrometheus) set(query, value string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.results[query] = value
+}
+
+func (f *fakePrometheus) received() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.queries...)
+}
+
+func (f *fakePrometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	query := r.FormValue("query")
+	f.queries = append(f.queries, query)
+
+	value, found := f.results[query]
+	result := []interface{}{}
+	if found {
+		result = append(result, map[string]interface{}{"metric": map[string]string{}, "value": []interface{}{1, value}})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "success",
+		"data":   map[string]interface{}{"resultType": "vector", "result": result},
+	})
+}
+
+var _ = Describe("Options", func() {
+	var options Options
+
+	BeforeEach(func() {
+		options = Options{
+			Queries:         DefaultQueries(),
+			Headroom:        10,
+			SmoothingWindow: 5 * time.Minute,
+			ResyncPeriod:    time.Second,
+			QueryTimeout:    time.Second,
+		}
+	})
+
+	DescribeTable("Validate",
+		func(mutate func(o *Options), expectErr bool) {
+			mutate(&options)
+			if expectErr {
+				Expect(options.Validate()).To(HaveOccurred())
+			} else {
+				Expect(options.Validate()).To(Succeed())
+			}
+		},
+		Entry("default options", func(o *Options) {}, false),
+		Entry("no queries", func(o *Options) { o.Queries = nil }, true),
+		Entry("missing usage query", func(o *Options) { o.Queries["gpu"] = ResourceQuery{Allocatable: "vector(1)"} }, true),
+		Entry("full headroom", func(o *Options) { o.Headroom = 100 }, true),
+		Entry("zero smoothing window", func(o *Options) { o.SmoothingWindow = 0 }, true),
+	)
+
+	It("should replace the placeholders", func() {
+		Expect(options.forgeQuery(`rate(foo{cluster="$clusterID"}[$window])`, `bar"`)).
+			To(Equal(`rate(foo{cluster="bar\""}[5m])`))
+	})
+
+	It("should merge the queries read from file with the default ones", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "queries.yaml")
+		Expect(os.WriteFile(path, []byte("cpu:\n  allocatable: vector(4)\n  usage: vector(1)\n"+
+			"nvidia.com/gpu:\n  allocatable: vector(2)\n  usage: vector(0)\n"), 0o600)).To(Succeed())
+
+		queries, err := LoadQueries(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(queries).To(HaveLen(4))
+		Expect(queries).To(HaveKeyWithValue(corev1.ResourceCPU, ResourceQuery{Allocatable: "vector(4)", Usage: "vector(1)"}))
+		Expect(queries).To(HaveKeyWithValue(corev1.ResourceName("nvidia.com/gpu"), ResourceQuery{Allocatable: "vector(2)", Usage: "vector(0)"}))
+		Expect(queries).To(HaveKeyWithValue(corev1.ResourceMemory, DefaultQueries()[corev1.ResourceMemory]))
+	})
+
+	It("should fail to load a malformed queries file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "queries.yaml")
+		Expect(os.WriteFile(path, []byte("cpu:\n  unknown: vector(4)\n"), 0o600)).To(Succeed())
+		_, err := LoadQueries(path)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Server", func() {
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+
+		prometheus *fakePrometheus
+		httpServer *httptest.Server
+		server     *Server
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+
+		prometheus = &fakePrometheus{results: map[string]string{
+			"cpu_allocatable":                  "10",
+			"cpu_usage[2m]":                    "3.5",
+			"memory_allocatable":               "10000",
+			"memory_usage[2m]":                 "1000",
+			`cpu_cluster_usage{cluster="foo"}`: "1.5",
+		}}
+		httpServer = httptest.NewServer(prometheus)
+
+		client, err := promapi.NewClient(promapi.Config{Address: httpServer.URL})
+		Expect(err).ToNot(HaveOccurred())
+
+		server, err = NewServer(promv1.NewAPI(client), &Options{
+			Queries: map[corev1.ResourceName]ResourceQuery{
+				corev1.ResourceCPU: {
+					Allocatable:  "cpu_allocatable",
+					Usage:        "cpu_usage[$window]",
+					ClusterUsage: `cpu_cluster_usage{cluster="$clusterID"}`,
+				},
+				corev1.ResourceMemory: {Allocatable: "memory_allocatable", Usage: "memory_usage[$window]"},
+				corev1.ResourcePods:   {Allocatable: "pods_allocatable", Usage: "pods_usage"},
+			},
+			Headroom:        20,
+			SmoothingWindow: 2 * time.Minute,
+			ResyncPeriod:    50 * time.Millisecond,
+			QueryTimeout:    time.Second,
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cancel()
+		httpServer.Close()
+	})
+
+	read := func(clusterID string) corev1.ResourceList {
+		response, err := server.ReadResources(ctx, &resourcemonitors.ClusterIdentity{ClusterID: clusterID})
+		Expect(err).ToNot(HaveOccurred())
+		resources := corev1.ResourceList{}
+		for name, quantity := range response.Resources {
+			resources[corev1.ResourceName(name)] = *quantity
+		}
+		return resources
+	}
+
+	It("should compute the offerable resources, taking into account the headroom", func() {
+		resources := read("")
+		Expect(resources).To(HaveLen(3))
+		// 10 * 0.8 - 3.5 = 4.5
+		Expect(resources.Cpu().String()).To(Equal("4500m"))
+		// 10000 * 0.8 - 1000 = 7000
+		Expect(resources.Memory().Value()).To(BeNumerically("==", 7000))
+		// Empty results are considered as zero.
+		Expect(resources.Pods().IsZero()).To(BeTrue())
+		Expect(prometheus.received()).To(ContainElements("cpu_usage[2m]", "memory_usage[2m]"))
+	})
+
+	It("should give back the resources used by the requesting cluster", func() {
+		resources := read("foo")
+		// 10 * 0.8 - 3.5 + 1.5 = 6
+		Expect(resources.Cpu().String()).To(Equal("6"))
+		Expect(resources.Memory().Value()).To(BeNumerically("==", 7000))
+	})
+
+	It("should never offer negative resources", func() {
+		prometheus.set("cpu_usage[2m]", "12")
+		resources := read("")
+		Expect(resources.Cpu().IsZero()).To(BeTrue())
+	})
+
+	It("should fail in case of invalid values", func() {
+		prometheus.set("cpu_usage[2m]", "NaN")
+		_, err := server.ReadResources(ctx, &resourcemonitors.ClusterIdentity{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should refresh the stale metrics", func() {
+		resources := read("")
+		Expect(resources.Cpu().String()).To(Equal("4500m"))
+
+		prometheus.set("cpu_usage[2m]", "1")
+		server.mutex.Lock()
+		server.timestamp = time.Now().Add(-time.Second)
+		server.mutex.Unlock()
+		resources = read("")
+		Expect(resources.Cpu().String()).To(Equal("7"))
+	})
+
+	It("should fail in case the metrics are stale and cannot be refreshed", func() {
+		resources := read("")
+		Expect(resources.Cpu().String()).To(Equal("4500m"))
+
+		httpServer.Close()
+		server.mutex.Lock()
+		server.timestamp = time.Now().Add(-time.Second)
+		server.mutex.Unlock()
+		_, err := server.ReadResources(ctx, &resourcemonitors.ClusterIdentity{})
+		Expect(err).To(MatchError(ContainSubstring("stale")))
+	})
+
+	It("should notify the subscribers when the offerable resources change", func() {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		grpcServer := grpc.NewServer()
+		resourcemonitors.RegisterResourceReaderServer(grpcServer, server)
+		go func() { _ = grpcServer.Serve(lis) }()
+		defer grpcServer.Stop()
+
+		monitor, err := resourcemonitors.NewExternalMonitor(ctx, lis.Addr().String(), 5*time.Second)
+		Expect(err).ToNot(HaveOccurred())
+
+		stream, err := monitor.Subscribe(ctx, &resourcemonitors.Empty{})
+		Expect(err).ToNot(HaveOccurred())
+		// Wait for the subscription to be registered, before starting the periodic refresh.
+		Eventually(func() int {
+			server.mutex.RLock()
+			defer server.mutex.RUnlock()
+			return len(server.subscribers)
+		}).Should(Equal(1))
+		go server.Start(ctx)
+
+		notification, err := stream.Recv()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(notification.ClusterID).To(Equal(resourcemonitors.AllClusterIDs))
+
+		resources, err := monitor.ReadResources(ctx, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resources.Cpu().Equal(resource.MustParse("4500m"))).To(BeTrue())
+
+		prometheus.set("cpu_usage[2m]", "1")
+		_, err = stream.Recv()
+		Expect(err).ToNot(HaveOccurred())
+		Eventually(func() *resource.Quantity {
+			resources, err := monitor.ReadResources(ctx, "")
+			Expect(err).ToNot(HaveOccurred())
+			return resources.Cpu()
+		}).Should(WithTransform(func(q *resource.Quantity) string { return q.String() }, Equal("7")))
+	})
+})
@@ -0,0 +1,320 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemonitors
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/discovery"
+)
+
+// SharingPolicyReader shares the resources of a ResourceReader among the consumer clusters, according to the
+// configured SharingPolicies. Each consumer is granted the resources reserved to it (which are withheld from the
+// others), plus a weighted fair share of the remaining ones, up to its limits. The resources which cannot be
+// assigned to a consumer because of its limits are redistributed among the other ones. The shares are computed
+// over the total capacity (i.e., the free resources plus the ones used by all consumers), so that the resources
+// already used by a consumer do not shrink the shares of the others. Yet, a consumer is never granted more than
+// the resources actually available to it (i.e., the free ones plus its own usage), hence the resources used by
+// the other consumers beyond their shares are subtracted from its grant.
+type SharingPolicyReader struct {
+	Provider ResourceReader
+	Client   client.Client
+	Notifier ResourceUpdateNotifier
+}
+
+// consumerPolicy is the sharing policy enforced for a given consumer cluster.
+type consumerPolicy struct {
+	reserved corev1.ResourceList
+	limits   corev1.ResourceList
+	weight   int64
+}
+
+// defaultConsumerPolicy is the policy enforced for the consumer clusters not selected by any SharingPolicy.
+var defaultConsumerPolicy = consumerPolicy{weight: 1}
+
+// NewSharingPolicyReader creates a new SharingPolicyReader, notifying the registered notifier whenever the
+// SharingPolicies, the ResourceRequests or the labels of the ForeignClusters change.
+func NewSharingPolicyReader(ctx context.Context, provider ResourceReader, cl client.Client,
+	informers cache.Informers) (*SharingPolicyReader, error) {
+	reader := &SharingPolicyReader{Provider: provider, Client: cl}
+
+	for _, obj := range []client.Object{&sharingv1alpha1.SharingPolicy{},
+		&discoveryv1alpha1.ResourceRequest{}, &discoveryv1alpha1.ForeignCluster{}} {
+		informer, err := informers.GetInformer(ctx, obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the informer for %T: %w", obj, err)
+		}
+		informer.AddEventHandler(reader.eventHandler())
+	}
+
+	return reader, nil
+}
+
+// Register sets an update notifier.
+func (r *SharingPolicyReader) Register(ctx context.Context, notifier ResourceUpdateNotifier) {
+	r.Notifier = notifier
+	r.Provider.Register(ctx, notifier)
+}
+
+// ReadResources returns the resources of the provider granted to the given cluster, according to the sharing policies.
+func (r *SharingPolicyReader) ReadResources(ctx context.Context, clusterID string) (corev1.ResourceList, error) {
+	policies, err := r.consumerPolicies(ctx, clusterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the sharing policies: %w", err)
+	}
+
+	// The resources returned for an unknown cluster ID do not include the usage of any consumer.
+	free, err := r.Provider.ReadResources(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[string]corev1.ResourceList, len(policies))
+	for id := range policies {
+		if available[id], err = r.Provider.ReadResources(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return shareResources(free, available, clusterID, policies), nil
+}
+
+// RemoveClusterID removes the given clusterID from the provider.
+func (r *SharingPolicyReader) RemoveClusterID(ctx context.Context, clusterID string) error {
+	return r.Provider.RemoveClusterID(ctx, clusterID)
+}
+
+// eventHandler returns an event handler notifying a change for all clusters when the observed objects change.
+func (r *SharingPolicyReader) eventHandler() toolscache.ResourceEventHandler {
+	notify := func() {
+		if r.Notifier != nil {
+			r.Notifier.NotifyChange(AllClusterIDs)
+		}
+	}
+
+	return toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) { notify() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, oldOk := oldObj.(metav1.Object)
+			newMeta, newOk := newObj.(metav1.Object)
+			// Status updates and changes to the other metadata do not affect the sharing of resources.
+			if !oldOk || !newOk || oldMeta.GetGeneration() != newMeta.GetGeneration() ||
+				!reflect.DeepEqual(oldMeta.GetLabels(), newMeta.GetLabels()) {
+				notify()
+			}
+		},
+		DeleteFunc: func(_ interface{}) { notify() },
+	}
+}
+
+// consumerPolicies returns the policies enforced for the current consumer clusters (always including the given one).
+func (r *SharingPolicyReader) consumerPolicies(ctx context.Context, clusterID string) (map[string]consumerPolicy, error) {
+	var policies sharingv1alpha1.SharingPolicyList
+	if err := r.Client.List(ctx, &policies); err != nil {
+		return nil, err
+	}
+	// Sort the policies by name, so that the first matching one is selected deterministically.
+	sort.Slice(policies.Items, func(i, j int) bool { return policies.Items[i].Name < policies.Items[j].Name })
+
+	var requests discoveryv1alpha1.ResourceRequestList
+	if err := r.Client.List(ctx, &requests, client.HasLabels{consts.ReplicationStatusLabel}); err != nil {
+		return nil, err
+	}
+
+	consumers := map[string]consumerPolicy{clusterID: defaultConsumerPolicy}
+	for i := range requests.Items {
+		if id := requests.Items[i].Labels[consts.ReplicationOriginLabel]; id != "" {
+			consumers[id] = defaultConsumerPolicy
+		}
+	}
+
+	for id := range consumers {
+		lbls, err := r.foreignClusterLabels(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range policies.Items {
+			selector, err := metav1.LabelSelectorAsSelector(&policies.Items[i].Spec.ClusterSelector)
+			if err != nil {
+				klog.Warningf("Invalid cluster selector in SharingPolicy %q: %v", policies.Items[i].Name, err)
+				continue
+			}
+
+			if selector.Matches(lbls) {
+				consumers[id] = consumerPolicy{
+					reserved: policies.Items[i].Spec.Reserved,
+					limits:   policies.Items[i].Spec.Limits,
+					weight:   int64(policies.Items[i].Spec.Weight),
+				}
+				break
+			}
+		}
+	}
+
+	return consumers, nil
+}
+
+// foreignClusterLabels returns the labels of the ForeignCluster corresponding to the given cluster ID. The cluster ID
+// label is always included, to allow selecting a specific cluster even before the ForeignCluster creation.
+func (r *SharingPolicyReader) foreignClusterLabels(ctx context.Context, clusterID string) (labels.Set, error) {
+	var foreignClusters discoveryv1alpha1.ForeignClusterList
+	if err := r.Client.List(ctx, &foreignClusters, client.MatchingLabels{discovery.ClusterIDLabel: clusterID}); err != nil {
+		return nil, err
+	}
+
+	lbls := labels.Set{discovery.ClusterIDLabel: clusterID}
+	if len(foreignClusters.Items) > 0 {
+		lbls = labels.Merge(foreignClusters.Items[0].Labels, lbls)
+	}
+	return lbls, nil
+}
+
+// shareResources returns the resources granted to the target consumer cluster, sharing the total capacity (i.e., the
+// free resources plus the ones used by each consumer) among all consumer clusters according to the given policies,
+// and capping the result to the resources available to the target (i.e., the free ones plus its own usage).
+func shareResources(free corev1.ResourceList, available map[string]corev1.ResourceList, target string,
+	policies map[string]consumerPolicy) corev1.ResourceList {
+	ids := make([]string, 0, len(policies))
+	for id := range policies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	granted := corev1.ResourceList{}
+	for name, quantity := range available[target] {
+		pool, freeValue := int64(0), int64(0)
+		if freeQuantity, found := free[name]; found {
+			freeValue = toValue(name, freeQuantity)
+		}
+		for _, id := range ids {
+			// The usage of each consumer is the amount exceeding the free resources.
+			if consumerQuantity, found := available[id][name]; found && toValue(name, consumerQuantity) > freeValue {
+				pool += toValue(name, consumerQuantity) - freeValue
+			}
+		}
+
+		shares := shareResource(name, pool+freeValue, ids, policies)
+		granted[name] = fromValue(name, capValue(shares[target], toValue(name, quantity)), quantity.Format)
+	}
+	return granted
+}
+
+// shareResource shares the given amount of a resource among the consumer clusters, returning the amount assigned to each.
+func shareResource(name corev1.ResourceName, pool int64, ids []string, policies map[string]consumerPolicy) map[string]int64 {
+	if pool < 0 {
+		pool = 0
+	}
+
+	allocated := make(map[string]int64, len(ids))
+	limits := make(map[string]int64, len(ids))
+
+	// First, assign the reserved resources (up to the limits), scaling them down in case they exceed the pool.
+	var reserved int64
+	for _, id := range ids {
+		allocated[id], limits[id] = 0, -1
+		if limit, found := policies[id].limits[name]; found {
+			limits[id] = toValue(name, limit)
+		}
+
+		if quantity, found := policies[id].reserved[name]; found {
+			allocated[id] = capValue(toValue(name, quantity), limits[id])
+			reserved += allocated[id]
+		}
+	}
+	if reserved > pool {
+		for _, id := range ids {
+			allocated[id] = int64(float64(allocated[id]) * float64(pool) / float64(reserved))
+		}
+		return allocated
+	}
+
+	// Then, share the remaining resources according to the weights, redistributing the ones exceeding the limits.
+	remaining := pool - reserved
+	active := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if policies[id].weight > 0 && (limits[id] < 0 || allocated[id] < limits[id]) {
+			active = append(active, id)
+		}
+	}
+
+	for remaining > 0 && len(active) > 0 {
+		var weights int64
+		for _, id := range active {
+			weights += policies[id].weight
+		}
+
+		capped := -1
+		for i, id := range active {
+			share := int64(float64(remaining) * float64(policies[id].weight) / float64(weights))
+			if limits[id] >= 0 && allocated[id]+share >= limits[id] {
+				capped = i
+				break
+			}
+		}
+
+		if capped < 0 {
+			for _, id := range active {
+				allocated[id] += int64(float64(remaining) * float64(policies[id].weight) / float64(weights))
+			}
+			break
+		}
+
+		id := active[capped]
+		remaining -= limits[id] - allocated[id]
+		allocated[id] = limits[id]
+		active = append(active[:capped], active[capped+1:]...)
+	}
+
+	return allocated
+}
+
+// toValue converts a quantity into an integer value (using milli-units for CPU).
+func toValue(name corev1.ResourceName, quantity resource.Quantity) int64 {
+	if name == corev1.ResourceCPU {
+		return quantity.MilliValue()
+	}
+	return quantity.Value()
+}
+
+// fromValue converts an integer value (using milli-units for CPU) into a quantity.
+func fromValue(name corev1.ResourceName, value int64, format resource.Format) resource.Quantity {
+	if name == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(value, format)
+	}
+	return *resource.NewQuantity(value, format)
+}
+
+// capValue returns the given value, capped to the limit (if not negative).
+func capValue(value, limit int64) int64 {
+	if limit >= 0 && value > limit {
+		return limit
+	}
+	return value
+}
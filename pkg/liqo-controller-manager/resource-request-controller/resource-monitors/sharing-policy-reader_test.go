@@ -0,0 +1,178 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourcemonitors
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/discovery"
+)
+
+// fakeUsageReader is a ResourceReader returning the free resources plus the ones used by the given cluster.
+type fakeUsageReader struct {
+	free  corev1.ResourceList
+	usage map[string]corev1.ResourceList
+}
+
+func (r fakeUsageReader) Register(context.Context, ResourceUpdateNotifier) {}
+
+func (r fakeUsageReader) ReadResources(_ context.Context, clusterID string) (corev1.ResourceList, error) {
+	resources := r.free.DeepCopy()
+	for name, quantity := range r.usage[clusterID] {
+		value := resources[name]
+		value.Add(quantity)
+		resources[name] = value
+	}
+	return resources, nil
+}
+
+func (r fakeUsageReader) RemoveClusterID(context.Context, string) error { return nil }
+
+var _ = Describe("SharingPolicyReader", func() {
+	Describe("The shareResource function", func() {
+		var policies map[string]consumerPolicy
+
+		share := func(pool int64) map[string]int64 {
+			return shareResource(corev1.ResourceMemory, pool, []string{"a", "b", "c"}, policies)
+		}
+
+		BeforeEach(func() {
+			policies = map[string]consumerPolicy{"a": defaultConsumerPolicy, "b": defaultConsumerPolicy, "c": defaultConsumerPolicy}
+		})
+
+		It("should share the resources evenly by default", func() {
+			Expect(share(300)).To(Equal(map[string]int64{"a": 100, "b": 100, "c": 100}))
+		})
+
+		It("should share the resources according to the weights", func() {
+			policies["a"] = consumerPolicy{weight: 2}
+			policies["c"] = consumerPolicy{weight: 0}
+			Expect(share(300)).To(Equal(map[string]int64{"a": 200, "b": 100, "c": 0}))
+		})
+
+		It("should guarantee the reservations and share the remaining resources", func() {
+			policies["a"] = consumerPolicy{weight: 1, reserved: corev1.ResourceList{corev1.ResourceMemory: *resource.NewQuantity(90, "")}}
+			Expect(share(300)).To(Equal(map[string]int64{"a": 160, "b": 70, "c": 70}))
+		})
+
+		It("should enforce the limits and redistribute the exceeding resources", func() {
+			policies["a"] = consumerPolicy{weight: 1, limits: corev1.ResourceList{corev1.ResourceMemory: *resource.NewQuantity(50, "")}}
+			Expect(share(300)).To(Equal(map[string]int64{"a": 50, "b": 125, "c": 125}))
+		})
+
+		It("should scale down the reservations exceeding the available resources", func() {
+			reserved := corev1.ResourceList{corev1.ResourceMemory: *resource.NewQuantity(200, "")}
+			policies["a"] = consumerPolicy{weight: 1, reserved: reserved}
+			policies["b"] = consumerPolicy{weight: 1, reserved: reserved}
+			Expect(share(300)).To(Equal(map[string]int64{"a": 150, "b": 150, "c": 0}))
+		})
+	})
+
+	Describe("The ReadResources function", func() {
+		var (
+			reader  *SharingPolicyReader
+			objects []runtime.Object
+		)
+
+		request := func(clusterID string) *discoveryv1alpha1.ResourceRequest {
+			return &discoveryv1alpha1.ResourceRequest{ObjectMeta: metav1.ObjectMeta{
+				Name: clusterID, Namespace: "liqo-tenant-" + clusterID,
+				Labels: map[string]string{consts.ReplicationStatusLabel: "true", consts.ReplicationOriginLabel: clusterID},
+			}}
+		}
+
+		BeforeEach(func() {
+			objects = []runtime.Object{
+				request("foo"), request("bar"),
+				&discoveryv1alpha1.ForeignCluster{ObjectMeta: metav1.ObjectMeta{
+					Name: "bar", Labels: map[string]string{discovery.ClusterIDLabel: "bar", "tier": "gold"},
+				}},
+				&sharingv1alpha1.SharingPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "gold"},
+					Spec: sharingv1alpha1.SharingPolicySpec{
+						ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "gold"}},
+						Weight:          3,
+					},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(discoveryv1alpha1.AddToScheme(scheme)).To(Succeed())
+			Expect(sharingv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			reader = &SharingPolicyReader{
+				Provider: FakeResourceReader{corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				}},
+				Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+			}
+		})
+
+		It("should grant the resources according to the matching policies", func() {
+			resources, err := reader.ReadResources(context.Background(), "bar")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources.Cpu().Equal(resource.MustParse("3"))).To(BeTrue())
+			Expect(resources.Memory().Equal(resource.MustParse("6Gi"))).To(BeTrue())
+		})
+
+		It("should grant the resources according to the default policy if none matches", func() {
+			resources, err := reader.ReadResources(context.Background(), "foo")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources.Cpu().Equal(resource.MustParse("1"))).To(BeTrue())
+			Expect(resources.Memory().Equal(resource.MustParse("2Gi"))).To(BeTrue())
+		})
+
+		When("the provider accounts for the resources used by each consumer", func() {
+			BeforeEach(func() {
+				objects = append(objects, request("baz"))
+			})
+
+			JustBeforeEach(func() {
+				reader.Provider = fakeUsageReader{
+					free: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("5")},
+					usage: map[string]corev1.ResourceList{
+						"foo": {corev1.ResourceCPU: resource.MustParse("3")},
+						"bar": {corev1.ResourceCPU: resource.MustParse("2")},
+					},
+				}
+			})
+
+			DescribeTable("should share the total capacity and cap the grant to the available resources",
+				func(clusterID, expected string) {
+					resources, err := reader.ReadResources(context.Background(), clusterID)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(resources.Cpu().Equal(resource.MustParse(expected))).To(BeTrue(), "got %v", resources.Cpu())
+				},
+				Entry("consumer with a higher weight", "bar", "6"),
+				Entry("consumer using more than its share", "foo", "2"),
+				Entry("consumer not using any resource", "baz", "2"),
+			)
+		})
+	})
+})
@@ -17,6 +17,7 @@ package resourcerequestoperator
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -28,6 +29,7 @@ import (
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	"github.com/liqotech/liqo/internal/crdReplicator/reflection"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 )
 
 // ResourceRequestReconciler reconciles a ResourceRequest object.
@@ -41,6 +43,7 @@ type ResourceRequestReconciler struct {
 
 // +kubebuilder:rbac:groups=sharing.liqo.io,resources=resourceoffers,verbs=get;list;watch;create;update;patch;
 // +kubebuilder:rbac:groups=sharing.liqo.io,resources=resourceoffers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=sharing.liqo.io,resources=sharingpolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups=discovery.liqo.io,resources=resourcerequests,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=discovery.liqo.io,resources=resourcerequests/status;resourcerequests/finalizers,verbs=get;update;patch
 // +kubebuilder:rbac:groups=discovery.liqo.io,resources=foreignclusters,verbs=get;list;watch;create;update;patch;delete
@@ -58,6 +61,11 @@ func (r *ResourceRequestReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, nil
 	}
 
+	// The span is child of the one which originated the resource request (i.e., the peering in the requesting cluster).
+	ctx, span := tracing.Start(tracing.ExtractAnnotations(ctx, resourceRequest.Annotations), "ResourceRequest reconcile",
+		attribute.String("liqo.namespace", req.Namespace), attribute.String("liqo.name", req.Name))
+	defer func() { tracing.End(span, err) }()
+
 	remoteCluster := resourceRequest.Spec.ClusterIdentity
 
 	// ensure the ForeignCluster existence, if not exists we have to add a new one
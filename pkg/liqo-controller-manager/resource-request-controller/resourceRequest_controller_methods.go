@@ -28,6 +28,7 @@ import (
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/demands"
 )
 
 func (r *ResourceRequestReconciler) ensureClusterRole(ctx context.Context,
@@ -121,9 +122,12 @@ func (r *ResourceRequestReconciler) checkOfferState(ctx context.Context,
 
 	if apierrors.IsNotFound(err) {
 		resourceRequest.Status.OfferState = discoveryv1alpha1.OfferStateNone
+		resourceRequest.Status.GrantedResources = nil
 	} else {
 		resourceRequest.Status.OfferState = discoveryv1alpha1.OfferStateCreated
+		resourceRequest.Status.GrantedResources = resourceOffer.Spec.ResourceQuota.Hard.DeepCopy()
 	}
+	resourceRequest.Status.DemandsState = demands.State(resourceRequest.Spec.Demands, resourceRequest.Status.GrantedResources)
 
 	return nil
 }
@@ -99,7 +99,7 @@ func createCluster() {
 	enableStorage := true
 	monitor = resourcemonitors.NewLocalMonitor(ctx, clientset, 5*time.Second)
 	scaledMonitor = &resourcemonitors.ResourceScaler{Provider: monitor, Factor: DefaultScaleFactor}
-	updater = NewOfferUpdater(ctx, k8sClient, homeCluster, nil, scaledMonitor, 5, localStorageClassName, enableStorage)
+	updater = NewOfferUpdater(ctx, k8sClient, homeCluster, nil, scaledMonitor, 5, localStorageClassName, enableStorage, 0, nil, nil)
 
 	Expect(k8sManager.Add(updater)).To(Succeed())
 
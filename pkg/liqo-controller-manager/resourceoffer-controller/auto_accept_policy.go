@@ -0,0 +1,50 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resourceoffercontroller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+)
+
+// AutoAcceptPolicy defines the ResourceOffers which are automatically accepted when the automatic acceptance is disabled.
+type AutoAcceptPolicy struct {
+	// MaxResources is the maximum amount of each resource which can be offered (resources not listed are not constrained).
+	MaxResources corev1.ResourceList
+	// ClusterSelector selects the ForeignClusters whose ResourceOffers can be accepted (all, if nil).
+	ClusterSelector labels.Selector
+}
+
+// Matches returns whether the given ResourceOffer, received from the given ForeignCluster, satisfies the policy.
+// A nil policy never matches.
+func (p *AutoAcceptPolicy) Matches(resourceOffer *sharingv1alpha1.ResourceOffer, foreignCluster *discoveryv1alpha1.ForeignCluster) bool {
+	if p == nil {
+		return false
+	}
+
+	if p.ClusterSelector != nil && !p.ClusterSelector.Matches(labels.Set(foreignCluster.GetLabels())) {
+		return false
+	}
+
+	for name, max := range p.MaxResources {
+		if quantity, found := resourceOffer.Spec.ResourceQuota.Hard[name]; found && quantity.Cmp(max) > 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -24,11 +24,12 @@ import (
 )
 
 // NewResourceOfferController creates and returns a new reconciler for the ResourceOffers.
+// When the automatic acceptance is disabled, the ResourceOffers matching the given policy (if any) are accepted anyway.
 func NewResourceOfferController(
 	mgr manager.Manager, cluster discoveryv1alpha1.ClusterIdentity,
 	resyncPeriod time.Duration, liqoNamespace string,
 	virtualKubeletOpts *forge.VirtualKubeletOpts,
-	disableAutoAccept bool) *ResourceOfferReconciler {
+	disableAutoAccept bool, autoAcceptPolicy *AutoAcceptPolicy) *ResourceOfferReconciler {
 	return &ResourceOfferReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
@@ -40,6 +41,7 @@ func NewResourceOfferController(
 
 		virtualKubeletOpts: virtualKubeletOpts,
 		disableAutoAccept:  disableAutoAccept,
+		autoAcceptPolicy:   autoAcceptPolicy,
 
 		resyncPeriod: resyncPeriod,
 	}
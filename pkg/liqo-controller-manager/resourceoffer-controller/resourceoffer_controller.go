@@ -58,6 +58,7 @@ type ResourceOfferReconciler struct {
 
 	virtualKubeletOpts *forge.VirtualKubeletOpts
 	disableAutoAccept  bool
+	autoAcceptPolicy   *AutoAcceptPolicy
 
 	resyncPeriod time.Duration
 }
@@ -127,7 +128,10 @@ func (r *ResourceOfferReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}()
 
 	// filter resource offers and create a virtual-kubelet only for the good ones
-	r.setResourceOfferPhase(&resourceOffer)
+	if err = r.setResourceOfferPhase(ctx, &resourceOffer); err != nil {
+		klog.Error(err)
+		return ctrl.Result{}, err
+	}
 
 	// check the virtual kubelet deployment
 	if err = r.checkVirtualKubeletDeployment(ctx, &resourceOffer); err != nil {
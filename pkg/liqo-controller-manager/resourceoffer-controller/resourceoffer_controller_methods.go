@@ -17,6 +17,7 @@ package resourceoffercontroller
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	appsv1 "k8s.io/api/apps/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -50,17 +51,85 @@ func (r *ResourceOfferReconciler) setControllerReference(
 }
 
 // setResourceOfferPhase checks if the resource request can be accepted and set its phase accordingly.
-func (r *ResourceOfferReconciler) setResourceOfferPhase(resourceOffer *sharingv1alpha1.ResourceOffer) {
-	// we want only to care about resource offers with a pending status
-	if resourceOffer.Status.Phase != "" && resourceOffer.Status.Phase != sharingv1alpha1.ResourceOfferPending {
-		return
+func (r *ResourceOfferReconciler) setResourceOfferPhase(ctx context.Context, resourceOffer *sharingv1alpha1.ResourceOffer) error {
+	// the explicit decision of an operator, if any, takes precedence over the automatic one.
+	approvedBy := resourceOffer.Annotations[consts.ResourceOfferApprovedByAnnotationKey]
+	approval, current := manualApproval(resourceOffer)
+	if approval != "" && !current {
+		// the decision is not valid (e.g., the spec changed afterwards), hence it is discarded and must be taken again.
+		resetApproval(resourceOffer)
+		r.setPhase(resourceOffer, sharingv1alpha1.ResourceOfferPending, "OfferApprovalDiscarded",
+			fmt.Sprintf("ResourceOffer manual decision (%v) discarded, since outdated or not recorded by the webhook", approval))
+		approval = ""
+	}
+
+	switch approval {
+	case consts.ResourceOfferApprovalAccepted:
+		r.setPhase(resourceOffer, sharingv1alpha1.ResourceOfferAccepted, "OfferAccepted",
+			fmt.Sprintf("ResourceOffer manually accepted by %q", approvedBy))
+		return nil
+	case consts.ResourceOfferApprovalRefused:
+		r.setPhase(resourceOffer, sharingv1alpha1.ResourceOfferRefused, "OfferRefused",
+			fmt.Sprintf("ResourceOffer manually refused by %q", approvedBy))
+		return nil
+	}
+
+	// we want only to care about resource offers waiting for a decision
+	switch resourceOffer.Status.Phase {
+	case "", sharingv1alpha1.ResourceOfferPending, sharingv1alpha1.ResourceOfferManualActionRequired:
+	default:
+		return nil
 	}
 
-	if r.disableAutoAccept {
-		resourceOffer.Status.Phase = sharingv1alpha1.ResourceOfferManualActionRequired
-	} else {
+	if !r.disableAutoAccept {
 		resourceOffer.Status.Phase = sharingv1alpha1.ResourceOfferAccepted
+		return nil
 	}
+
+	foreignCluster, err := foreigncluster.GetForeignClusterByID(ctx, r.Client, resourceOffer.Spec.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	if r.autoAcceptPolicy.Matches(resourceOffer, foreignCluster) {
+		r.setPhase(resourceOffer, sharingv1alpha1.ResourceOfferAccepted, "OfferAccepted",
+			"ResourceOffer automatically accepted, since it matches the auto-accept policy")
+		return nil
+	}
+
+	r.setPhase(resourceOffer, sharingv1alpha1.ResourceOfferManualActionRequired, "OfferApprovalRequired",
+		"ResourceOffer waiting for manual approval")
+	return nil
+}
+
+// manualApproval returns the decision taken by an operator concerning the ResourceOffer, if any, and whether it is
+// valid, i.e., it has been recorded by the webhook (which discards the decisions not taken by local users), and it
+// refers to the current generation of the ResourceOffer (i.e., the spec did not change afterwards).
+func manualApproval(resourceOffer *sharingv1alpha1.ResourceOffer) (approval consts.ResourceOfferApproval, current bool) {
+	approval = consts.ResourceOfferApproval(resourceOffer.Annotations[consts.ResourceOfferApprovalAnnotationKey])
+	generation := resourceOffer.Annotations[consts.ResourceOfferApprovalGenerationAnnotationKey]
+	recorded := resourceOffer.Annotations[consts.ResourceOfferApprovedByAnnotationKey] != ""
+	return approval, recorded && generation == strconv.FormatInt(resourceOffer.Generation, 10)
+}
+
+// resetApproval removes the decision taken by an operator concerning the ResourceOffer.
+func resetApproval(resourceOffer *sharingv1alpha1.ResourceOffer) {
+	for _, key := range []string{consts.ResourceOfferApprovalAnnotationKey, consts.ResourceOfferApprovedByAnnotationKey,
+		consts.ResourceOfferApprovalTimestampAnnotationKey, consts.ResourceOfferApprovalGenerationAnnotationKey} {
+		delete(resourceOffer.Annotations, key)
+	}
+}
+
+// setPhase sets the phase of the ResourceOffer, recording an event in case it changed.
+func (r *ResourceOfferReconciler) setPhase(resourceOffer *sharingv1alpha1.ResourceOffer,
+	phase sharingv1alpha1.OfferPhase, reason, msg string) {
+	if resourceOffer.Status.Phase == phase {
+		return
+	}
+
+	klog.Infof("[%v] %v", resourceOffer.Spec.ClusterID, msg)
+	resourceOffer.Status.Phase = phase
+	r.eventsRecorder.Event(resourceOffer, "Normal", reason, msg)
 }
 
 // checkVirtualKubeletDeployment checks the existence of the VirtualKubelet Deployment
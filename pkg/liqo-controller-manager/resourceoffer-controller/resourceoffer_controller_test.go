@@ -25,8 +25,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -111,7 +114,7 @@ var _ = Describe("ResourceOffer Controller", func() {
 		}
 
 		controller = NewResourceOfferController(mgr, remoteClusterIdentity,
-			10*time.Second, testNamespace, kubeletOpts, true)
+			10*time.Second, testNamespace, kubeletOpts, true, nil)
 		if err := controller.SetupWithManager(mgr); err != nil {
 			By(err.Error())
 			os.Exit(1)
@@ -514,4 +517,121 @@ var _ = Describe("ResourceOffer Operator util functions", func() {
 
 	})
 
+	Context("manualApproval", func() {
+
+		type manualApprovalTestcase struct {
+			annotations      map[string]string
+			expectedApproval consts.ResourceOfferApproval
+			expectedCurrent  bool
+		}
+
+		DescribeTable("manualApproval table",
+
+			func(c manualApprovalTestcase) {
+				resourceOffer := &sharingv1alpha1.ResourceOffer{ObjectMeta: metav1.ObjectMeta{Generation: 2, Annotations: c.annotations}}
+				approval, current := manualApproval(resourceOffer)
+				Expect(approval).To(Equal(c.expectedApproval))
+				Expect(current).To(Equal(c.expectedCurrent))
+			},
+
+			Entry("no approval", manualApprovalTestcase{
+				annotations:      nil,
+				expectedApproval: "",
+				expectedCurrent:  false,
+			}),
+
+			Entry("approval referring to the current generation", manualApprovalTestcase{
+				annotations: map[string]string{
+					consts.ResourceOfferApprovalAnnotationKey:           string(consts.ResourceOfferApprovalAccepted),
+					consts.ResourceOfferApprovedByAnnotationKey:         "alice",
+					consts.ResourceOfferApprovalGenerationAnnotationKey: "2",
+				},
+				expectedApproval: consts.ResourceOfferApprovalAccepted,
+				expectedCurrent:  true,
+			}),
+
+			Entry("approval not recorded by the webhook", manualApprovalTestcase{
+				annotations: map[string]string{
+					consts.ResourceOfferApprovalAnnotationKey:           string(consts.ResourceOfferApprovalAccepted),
+					consts.ResourceOfferApprovalGenerationAnnotationKey: "2",
+				},
+				expectedApproval: consts.ResourceOfferApprovalAccepted,
+				expectedCurrent:  false,
+			}),
+
+			Entry("approval referring to a previous generation", manualApprovalTestcase{
+				annotations: map[string]string{
+					consts.ResourceOfferApprovalAnnotationKey:           string(consts.ResourceOfferApprovalAccepted),
+					consts.ResourceOfferApprovalGenerationAnnotationKey: "1",
+				},
+				expectedApproval: consts.ResourceOfferApprovalAccepted,
+				expectedCurrent:  false,
+			}),
+
+			Entry("approval without generation", manualApprovalTestcase{
+				annotations:      map[string]string{consts.ResourceOfferApprovalAnnotationKey: string(consts.ResourceOfferApprovalRefused)},
+				expectedApproval: consts.ResourceOfferApprovalRefused,
+				expectedCurrent:  false,
+			}),
+		)
+
+	})
+
+	Context("AutoAcceptPolicy", func() {
+
+		type autoAcceptPolicyTestcase struct {
+			policy   *AutoAcceptPolicy
+			expected bool
+		}
+
+		DescribeTable("AutoAcceptPolicy table",
+
+			func(c autoAcceptPolicyTestcase) {
+				resourceOffer := &sharingv1alpha1.ResourceOffer{Spec: sharingv1alpha1.ResourceOfferSpec{
+					ResourceQuota: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("4"),
+						corev1.ResourceMemory: resource.MustParse("8Gi"),
+					}},
+				}}
+				foreignCluster := &discoveryv1alpha1.ForeignCluster{ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"environment": "production"},
+				}}
+				Expect(c.policy.Matches(resourceOffer, foreignCluster)).To(Equal(c.expected))
+			},
+
+			Entry("nil policy", autoAcceptPolicyTestcase{
+				policy:   nil,
+				expected: false,
+			}),
+
+			Entry("empty policy", autoAcceptPolicyTestcase{
+				policy:   &AutoAcceptPolicy{},
+				expected: true,
+			}),
+
+			Entry("resources within the limits", autoAcceptPolicyTestcase{
+				policy: &AutoAcceptPolicy{MaxResources: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10"),
+				}},
+				expected: true,
+			}),
+
+			Entry("resources exceeding the limits", autoAcceptPolicyTestcase{
+				policy:   &AutoAcceptPolicy{MaxResources: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}},
+				expected: false,
+			}),
+
+			Entry("matching cluster selector", autoAcceptPolicyTestcase{
+				policy:   &AutoAcceptPolicy{ClusterSelector: labels.SelectorFromSet(labels.Set{"environment": "production"})},
+				expected: true,
+			}),
+
+			Entry("non-matching cluster selector", autoAcceptPolicyTestcase{
+				policy:   &AutoAcceptPolicy{ClusterSelector: labels.SelectorFromSet(labels.Set{"environment": "staging"})},
+				expected: false,
+			}),
+		)
+
+	})
+
 })
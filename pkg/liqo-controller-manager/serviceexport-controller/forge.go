@@ -0,0 +1,136 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceexportctrl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// ExportedServiceName returns the name of the ExportedService associated with the given service.
+// Namespace and service names cannot contain dots, hence the resulting name is unique.
+func ExportedServiceName(service types.NamespacedName) string {
+	return fmt.Sprintf("%s.%s", service.Name, service.Namespace)
+}
+
+// ForgeExportedServiceLabels returns the labels of the ExportedService associated with the given service,
+// ensuring it is replicated to the given remote cluster.
+func ForgeExportedServiceLabels(service types.NamespacedName, remoteClusterID string, existing map[string]string) map[string]string {
+	return labels.Merge(existing, map[string]string{
+		consts.ReplicationRequestedLabel:     strconv.FormatBool(true),
+		consts.ReplicationDestinationLabel:   remoteClusterID,
+		consts.ExportedServiceNamespaceLabel: service.Namespace,
+		consts.ExportedServiceNameLabel:      service.Name,
+	})
+}
+
+// exportedServicesSelector returns the selector matching the local ExportedServices associated with the given service.
+func exportedServicesSelector(service types.NamespacedName) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{
+		consts.ReplicationRequestedLabel:     strconv.FormatBool(true),
+		consts.ExportedServiceNamespaceLabel: service.Namespace,
+		consts.ExportedServiceNameLabel:      service.Name,
+	})
+}
+
+// ForgeExportedServiceSpec forges the spec of the ExportedService describing the given service and endpointslices.
+func ForgeExportedServiceSpec(svc *corev1.Service, endpointslices []discoveryv1.EndpointSlice) *netv1alpha1.ExportedServiceSpec {
+	spec := &netv1alpha1.ExportedServiceSpec{
+		ServiceNamespace: svc.Namespace,
+		ServiceName:      svc.Name,
+		Service: mcsv1alpha1.ServiceImportSpec{
+			Type:                  mcsv1alpha1.ClusterSetIP,
+			SessionAffinity:       svc.Spec.SessionAffinity,
+			SessionAffinityConfig: svc.Spec.SessionAffinityConfig.DeepCopy(),
+		},
+	}
+
+	if svc.Spec.ClusterIP == corev1.ClusterIPNone {
+		spec.Service.Type = mcsv1alpha1.Headless
+	}
+
+	for i := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[i]
+		spec.Service.Ports = append(spec.Service.Ports, mcsv1alpha1.ServicePort{
+			Name: port.Name, Protocol: port.Protocol, AppProtocol: port.AppProtocol, Port: port.Port,
+		})
+	}
+
+	for i := range endpointslices {
+		if endpoints := forgeExportedEndpoints(&endpointslices[i]); endpoints != nil {
+			spec.Endpoints = append(spec.Endpoints, *endpoints)
+		}
+	}
+
+	// Sort the endpoints, to prevent unnecessary updates due to the order of the endpointslices.
+	sort.Slice(spec.Endpoints, func(i, j int) bool {
+		return spec.Endpoints[i].Addresses[0] < spec.Endpoints[j].Addresses[0]
+	})
+
+	return spec
+}
+
+// forgeExportedEndpoints returns the ready endpoints of the given endpointslice, or nil if none.
+// Only IPv4 endpointslices are currently supported, consistently with the rest of the network fabric.
+func forgeExportedEndpoints(endpointslice *discoveryv1.EndpointSlice) *netv1alpha1.ExportedEndpoints {
+	if endpointslice.AddressType != discoveryv1.AddressTypeIPv4 {
+		return nil
+	}
+
+	var addresses []string
+	for i := range endpointslice.Endpoints {
+		endpoint := &endpointslice.Endpoints[i]
+		if !pointer.BoolDeref(endpoint.Conditions.Ready, true) {
+			continue
+		}
+		addresses = append(addresses, endpoint.Addresses...)
+	}
+
+	if len(addresses) == 0 {
+		return nil
+	}
+	sort.Strings(addresses)
+
+	ports := make([]mcsv1alpha1.ServicePort, 0, len(endpointslice.Ports))
+	for i := range endpointslice.Ports {
+		port := &endpointslice.Ports[i]
+		if port.Port == nil {
+			continue
+		}
+		protocol := corev1.ProtocolTCP
+		if port.Protocol != nil {
+			protocol = *port.Protocol
+		}
+		ports = append(ports, mcsv1alpha1.ServicePort{
+			Name:        pointer.StringDeref(port.Name, ""),
+			Protocol:    protocol,
+			AppProtocol: port.AppProtocol,
+			Port:        *port.Port,
+		})
+	}
+
+	return &netv1alpha1.ExportedEndpoints{Addresses: addresses, Ports: ports}
+}
@@ -0,0 +1,254 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceexportctrl
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/internal/crdReplicator/reflection"
+	"github.com/liqotech/liqo/pkg/consts"
+	foreignclusterutils "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+)
+
+const (
+	// ReasonServiceExported is the reason of the Valid condition, in case the service has been exported.
+	ReasonServiceExported = "ServiceExported"
+	// ReasonServiceNotFound is the reason of the Valid condition, in case the service to be exported does not exist.
+	ReasonServiceNotFound = "ServiceNotFound"
+	// ReasonServiceTypeNotSupported is the reason of the Valid condition, in case the service type cannot be exported.
+	ReasonServiceTypeNotSupported = "ServiceTypeNotSupported"
+)
+
+// Reconciler reconciles ServiceExport objects, propagating the exported services towards the peered clusters.
+type Reconciler struct {
+	client.Client
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceexports,verbs=get;list;watch
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceexports/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=net.liqo.io,resources=exportedservices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=discovery.liqo.io,resources=foreignclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+
+// Reconcile ensures the ExportedService resources describing the given exported service are present in the
+// tenant namespaces of all the peered clusters (and they are removed once the service is no longer exported).
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var export mcsv1alpha1.ServiceExport
+	if err := r.Get(ctx, req.NamespacedName, &export); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("ServiceExport %q not found, ensuring the service is no longer exported", req.NamespacedName)
+			return ctrl.Result{}, r.ensureExportedServices(ctx, req.NamespacedName, nil)
+		}
+		klog.Errorf("Failed to retrieve ServiceExport %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if !export.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.ensureExportedServices(ctx, req.NamespacedName, nil)
+	}
+
+	var svc corev1.Service
+	if err := r.Get(ctx, req.NamespacedName, &svc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to retrieve Service %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+
+		if err := r.ensureExportedServices(ctx, req.NamespacedName, nil); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.updateStatus(ctx, &export, metav1.ConditionFalse, ReasonServiceNotFound,
+			fmt.Sprintf("Service %q not found", req.NamespacedName))
+	}
+
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		if err := r.ensureExportedServices(ctx, req.NamespacedName, nil); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.updateStatus(ctx, &export, metav1.ConditionFalse, ReasonServiceTypeNotSupported,
+			fmt.Sprintf("Services of type %s cannot be exported", svc.Spec.Type))
+	}
+
+	var endpointslices discoveryv1.EndpointSliceList
+	if err := r.List(ctx, &endpointslices, client.InNamespace(svc.Namespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: svc.Name}); err != nil {
+		klog.Errorf("Failed to retrieve the EndpointSlices of Service %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	spec := ForgeExportedServiceSpec(&svc, endpointslices.Items)
+	if err := r.ensureExportedServices(ctx, req.NamespacedName, spec); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, r.updateStatus(ctx, &export, metav1.ConditionTrue, ReasonServiceExported,
+		"Service exported to the peered clusters")
+}
+
+// ensureExportedServices ensures one ExportedService with the given spec exists for each peered cluster,
+// and removes the stale ones. A nil spec causes all the ExportedServices associated with the given service to be removed.
+func (r *Reconciler) ensureExportedServices(ctx context.Context, service types.NamespacedName, spec *netv1alpha1.ExportedServiceSpec) error {
+	desired := map[string]*discoveryv1alpha1.ForeignCluster{}
+	if spec != nil {
+		var foreignclusters discoveryv1alpha1.ForeignClusterList
+		if err := r.List(ctx, &foreignclusters); err != nil {
+			klog.Errorf("Failed to list ForeignClusters: %v", err)
+			return err
+		}
+
+		for i := range foreignclusters.Items {
+			fc := &foreignclusters.Items[i]
+			if isPeered(fc) && fc.Status.TenantNamespace.Local != "" {
+				desired[fc.Status.TenantNamespace.Local] = fc
+			}
+		}
+	}
+
+	var existing netv1alpha1.ExportedServiceList
+	if err := r.List(ctx, &existing, client.MatchingLabelsSelector{Selector: exportedServicesSelector(service)}); err != nil {
+		klog.Errorf("Failed to list the ExportedServices associated with Service %q: %v", service, err)
+		return err
+	}
+
+	for i := range existing.Items {
+		if _, found := desired[existing.Items[i].Namespace]; found {
+			continue
+		}
+		if err := client.IgnoreNotFound(r.Delete(ctx, &existing.Items[i])); err != nil {
+			klog.Errorf("Failed to delete ExportedService %q: %v", klog.KObj(&existing.Items[i]), err)
+			return err
+		}
+		klog.Infof("ExportedService %q correctly deleted", klog.KObj(&existing.Items[i]))
+	}
+
+	for namespace, fc := range desired {
+		exported := &netv1alpha1.ExportedService{ObjectMeta: metav1.ObjectMeta{Name: ExportedServiceName(service), Namespace: namespace}}
+		result, err := controllerutil.CreateOrUpdate(ctx, r.Client, exported, func() error {
+			exported.SetLabels(ForgeExportedServiceLabels(service, fc.Spec.ClusterIdentity.ClusterID, exported.GetLabels()))
+			exported.Spec = *spec.DeepCopy()
+			return nil
+		})
+		if err != nil {
+			klog.Errorf("Failed to enforce ExportedService %q: %v", klog.KObj(exported), err)
+			return err
+		}
+		klog.V(4).Infof("ExportedService %q correctly enforced (%v)", klog.KObj(exported), result)
+	}
+
+	return nil
+}
+
+// updateStatus updates the Valid condition of the given ServiceExport, if necessary.
+func (r *Reconciler) updateStatus(ctx context.Context, export *mcsv1alpha1.ServiceExport,
+	status metav1.ConditionStatus, reason, message string) error {
+	original := export.DeepCopy()
+	meta.SetStatusCondition(&export.Status.Conditions, metav1.Condition{
+		Type:               mcsv1alpha1.ServiceExportValid,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: export.Generation,
+	})
+
+	if equality.Semantic.DeepEqual(original.Status.Conditions, export.Status.Conditions) {
+		return nil
+	}
+
+	if err := r.Status().Patch(ctx, export, client.MergeFrom(original)); err != nil {
+		klog.Errorf("Failed to update the status of ServiceExport %q: %v", klog.KObj(export), err)
+		return err
+	}
+	klog.Infof("Status of ServiceExport %q correctly updated (reason: %v)", klog.KObj(export), reason)
+	return nil
+}
+
+// SetupWithManager registers a new controller for ServiceExport resources.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	localExportedServices, err := predicate.LabelSelectorPredicate(reflection.LocalResourcesLabelSelector())
+	utilruntime.Must(err)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mcsv1alpha1.ServiceExport{}).
+		Watches(&source.Kind{Type: &corev1.Service{}}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: &discoveryv1.EndpointSlice{}}, handler.EnqueueRequestsFromMapFunc(r.endpointSliceEnqueuer)).
+		Watches(&source.Kind{Type: &netv1alpha1.ExportedService{}}, handler.EnqueueRequestsFromMapFunc(r.exportedServiceEnqueuer),
+			builder.WithPredicates(localExportedServices)).
+		Watches(&source.Kind{Type: &discoveryv1alpha1.ForeignCluster{}}, handler.EnqueueRequestsFromMapFunc(r.foreignClusterEnqueuer)).
+		Complete(r)
+}
+
+func (r *Reconciler) endpointSliceEnqueuer(obj client.Object) []reconcile.Request {
+	name, found := obj.GetLabels()[discoveryv1.LabelServiceName]
+	if !found {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+}
+
+func (r *Reconciler) exportedServiceEnqueuer(obj client.Object) []reconcile.Request {
+	namespace, name := obj.GetLabels()[consts.ExportedServiceNamespaceLabel], obj.GetLabels()[consts.ExportedServiceNameLabel]
+	if namespace == "" || name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+}
+
+func (r *Reconciler) foreignClusterEnqueuer(_ client.Object) []reconcile.Request {
+	var exports mcsv1alpha1.ServiceExportList
+	if err := r.List(context.Background(), &exports); err != nil {
+		klog.Errorf("Failed to list ServiceExports: %v", err)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(exports.Items))
+	for i := range exports.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&exports.Items[i])})
+	}
+	return requests
+}
+
+// isPeered returns whether a peering (in either direction) is currently active with the given cluster.
+func isPeered(fc *discoveryv1alpha1.ForeignCluster) bool {
+	switch foreignclusterutils.GetPeeringPhase(fc) {
+	case consts.PeeringPhaseIncoming, consts.PeeringPhaseOutgoing, consts.PeeringPhaseBidirectional:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceexportctrl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	peeringconditionsutils "github.com/liqotech/liqo/pkg/utils/peeringConditions"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("ServiceExport controller", func() {
+	const (
+		namespace       = "default"
+		name            = "service"
+		tenantNamespace = "liqo-tenant-remote"
+		remoteClusterID = "remote-cluster-id"
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		reconciler *Reconciler
+		key        types.NamespacedName
+
+		export        *mcsv1alpha1.ServiceExport
+		svc           *corev1.Service
+		endpointslice *discoveryv1.EndpointSlice
+		fc            *discoveryv1alpha1.ForeignCluster
+
+		err error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Namespace: namespace, Name: name}
+
+		export = &mcsv1alpha1.ServiceExport{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		svc = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.ServiceSpec{
+				ClusterIP: "10.96.0.10",
+				Ports:     []corev1.ServicePort{{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}},
+			},
+		}
+		endpointslice = &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-abcde", Namespace: namespace,
+				Labels: map[string]string{discoveryv1.LabelServiceName: name}},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"10.0.0.2"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)}},
+				{Addresses: []string{"10.0.0.1"}},
+				{Addresses: []string{"10.0.0.3"}, Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(false)}},
+			},
+			Ports: []discoveryv1.EndpointPort{{Name: pointer.String("http"), Port: pointer.Int32(8080)}},
+		}
+		fc = &discoveryv1alpha1.ForeignCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "remote"},
+			Spec: discoveryv1alpha1.ForeignClusterSpec{
+				ClusterIdentity: discoveryv1alpha1.ClusterIdentity{ClusterID: remoteClusterID, ClusterName: "remote"},
+			},
+			Status: discoveryv1alpha1.ForeignClusterStatus{
+				TenantNamespace: discoveryv1alpha1.TenantNamespaceType{Local: tenantNamespace},
+			},
+		}
+		peeringconditionsutils.EnsureStatus(fc, discoveryv1alpha1.OutgoingPeeringCondition,
+			discoveryv1alpha1.PeeringConditionStatusEstablished, "", "")
+	})
+
+	Describe("the ForgeExportedServiceSpec function", func() {
+		var spec *netv1alpha1.ExportedServiceSpec
+
+		JustBeforeEach(func() { spec = ForgeExportedServiceSpec(svc, []discoveryv1.EndpointSlice{*endpointslice}) })
+
+		It("should reference the original service", func() {
+			Expect(spec.ServiceNamespace).To(Equal(namespace))
+			Expect(spec.ServiceName).To(Equal(name))
+		})
+		It("should configure the service characteristics", func() {
+			Expect(spec.Service.Type).To(Equal(mcsv1alpha1.ClusterSetIP))
+			Expect(spec.Service.Ports).To(ConsistOf(mcsv1alpha1.ServicePort{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80}))
+		})
+		It("should include only the ready endpoints, sorted", func() {
+			Expect(spec.Endpoints).To(HaveLen(1))
+			Expect(spec.Endpoints[0].Addresses).To(Equal([]string{"10.0.0.1", "10.0.0.2"}))
+			Expect(spec.Endpoints[0].Ports).To(ConsistOf(mcsv1alpha1.ServicePort{Name: "http", Protocol: corev1.ProtocolTCP, Port: 8080}))
+		})
+
+		When("the service is headless", func() {
+			BeforeEach(func() { svc.Spec.ClusterIP = corev1.ClusterIPNone })
+			It("should set the Headless type", func() { Expect(spec.Service.Type).To(Equal(mcsv1alpha1.Headless)) })
+		})
+
+		When("the endpointslice is IPv6", func() {
+			BeforeEach(func() { endpointslice.AddressType = discoveryv1.AddressTypeIPv6 })
+			It("should be ignored", func() { Expect(spec.Endpoints).To(BeEmpty()) })
+		})
+	})
+
+	Describe("the Reconcile function", func() {
+		var objects []client.Object
+
+		BeforeEach(func() { objects = []client.Object{export, svc, endpointslice, fc} })
+
+		JustBeforeEach(func() {
+			cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+			reconciler = &Reconciler{Client: cl}
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		})
+
+		getExportedService := func() (*netv1alpha1.ExportedService, error) {
+			var exported netv1alpha1.ExportedService
+			err := cl.Get(ctx, types.NamespacedName{Namespace: tenantNamespace, Name: ExportedServiceName(key)}, &exported)
+			return &exported, err
+		}
+
+		getCondition := func() *metav1.Condition {
+			var current mcsv1alpha1.ServiceExport
+			Expect(cl.Get(ctx, key, &current)).To(Succeed())
+			return meta.FindStatusCondition(current.Status.Conditions, mcsv1alpha1.ServiceExportValid)
+		}
+
+		When("the service is exported", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should create the ExportedService in the tenant namespace", func() {
+				exported, err := getExportedService()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(exported.Labels).To(HaveKeyWithValue(consts.ReplicationRequestedLabel, "true"))
+				Expect(exported.Labels).To(HaveKeyWithValue(consts.ReplicationDestinationLabel, remoteClusterID))
+				Expect(exported.Spec.Endpoints).To(HaveLen(1))
+			})
+			It("should set the Valid condition", func() {
+				Expect(getCondition()).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Status": Equal(metav1.ConditionTrue), "Reason": Equal(ReasonServiceExported)})))
+			})
+		})
+
+		When("the service does not exist", func() {
+			BeforeEach(func() {
+				stale := &netv1alpha1.ExportedService{ObjectMeta: metav1.ObjectMeta{
+					Name: ExportedServiceName(key), Namespace: tenantNamespace,
+					Labels: ForgeExportedServiceLabels(key, remoteClusterID, nil)}}
+				objects = []client.Object{export, fc, stale}
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should remove the stale ExportedService", func() {
+				_, err := getExportedService()
+				Expect(err).To(testutil.BeNotFound())
+			})
+			It("should report the service is not found", func() {
+				Expect(getCondition()).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Status": Equal(metav1.ConditionFalse), "Reason": Equal(ReasonServiceNotFound)})))
+			})
+		})
+
+		When("the service is of type ExternalName", func() {
+			BeforeEach(func() { svc.Spec.Type = corev1.ServiceTypeExternalName })
+			It("should not create the ExportedService", func() {
+				_, err := getExportedService()
+				Expect(err).To(testutil.BeNotFound())
+			})
+			It("should report the type is not supported", func() {
+				Expect(getCondition()).To(PointTo(MatchFields(IgnoreExtras, Fields{
+					"Status": Equal(metav1.ConditionFalse), "Reason": Equal(ReasonServiceTypeNotSupported)})))
+			})
+		})
+
+		When("the remote cluster is not peered", func() {
+			BeforeEach(func() { fc.Status.PeeringConditions = nil })
+			It("should not create the ExportedService", func() {
+				_, err := getExportedService()
+				Expect(err).To(testutil.BeNotFound())
+			})
+		})
+	})
+})
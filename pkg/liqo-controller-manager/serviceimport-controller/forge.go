@@ -0,0 +1,185 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceimportctrl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	liqonetutils "github.com/liqotech/liqo/pkg/liqonet/utils"
+)
+
+// EndpointSliceManagedBy is the manager associated with the EndpointSlices of the imported services.
+const EndpointSliceManagedBy = "endpointslice.multicluster.liqo.io"
+
+// DerivedServiceName returns the name of the Service backing the ServiceImport with the given name.
+func DerivedServiceName(name string) string {
+	return "derived-" + shortHash(name)
+}
+
+// endpointSliceName returns the name of the index-th EndpointSlice of the given derived service, for the given cluster.
+func endpointSliceName(derived, clusterID string, index int) string {
+	return fmt.Sprintf("%s-%s-%d", derived, shortHash(clusterID), index)
+}
+
+func shortHash(value string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(value)))[:10]
+}
+
+// importedServicesSelector returns the selector matching the ExportedServices, replicated by the remote clusters,
+// associated with the given service.
+func importedServicesSelector(service types.NamespacedName) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{
+		consts.ReplicationStatusLabel:        strconv.FormatBool(true),
+		consts.ExportedServiceNamespaceLabel: service.Namespace,
+		consts.ExportedServiceNameLabel:      service.Name,
+	})
+}
+
+// managedLabels returns the labels characterizing the resources managed by this controller.
+func managedLabels(name string) labels.Set {
+	return labels.Set{
+		consts.ManagedByLabelKey:     consts.ManagedByMultiClusterServicesValue,
+		mcsv1alpha1.LabelServiceName: name,
+	}
+}
+
+// ForgeServiceImportSpec forges the spec of a ServiceImport, merging the characteristics of the given exported services.
+// The type and the session affinity are taken from the first one, while the ports are the union of all of them.
+func ForgeServiceImportSpec(exported []netv1alpha1.ExportedService) mcsv1alpha1.ServiceImportSpec {
+	spec := mcsv1alpha1.ServiceImportSpec{
+		Type:                  exported[0].Spec.Service.Type,
+		SessionAffinity:       exported[0].Spec.Service.SessionAffinity,
+		SessionAffinityConfig: exported[0].Spec.Service.SessionAffinityConfig.DeepCopy(),
+	}
+
+	type portKey struct {
+		name     string
+		protocol corev1.Protocol
+	}
+	known := map[portKey]struct{}{}
+	for i := range exported {
+		for j := range exported[i].Spec.Service.Ports {
+			port := &exported[i].Spec.Service.Ports[j]
+			key := portKey{name: port.Name, protocol: port.Protocol}
+			if _, found := known[key]; found {
+				continue
+			}
+			known[key] = struct{}{}
+			spec.Ports = append(spec.Ports, *port.DeepCopy())
+		}
+	}
+
+	return spec
+}
+
+// forgeDerivedServiceSpec mutates the spec of the derived service, according to the given ServiceImport.
+func forgeDerivedServiceSpec(svc *corev1.Service, spec *mcsv1alpha1.ServiceImportSpec) {
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	if spec.Type == mcsv1alpha1.Headless {
+		svc.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+
+	svc.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	svc.Spec.SessionAffinityConfig = nil
+	if spec.SessionAffinity == corev1.ServiceAffinityClientIP {
+		svc.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+		svc.Spec.SessionAffinityConfig = spec.SessionAffinityConfig.DeepCopy()
+	}
+
+	svc.Spec.Ports = make([]corev1.ServicePort, 0, len(spec.Ports))
+	for i := range spec.Ports {
+		port := &spec.Ports[i]
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:        port.Name,
+			Protocol:    protocolOrDefault(port.Protocol),
+			AppProtocol: port.AppProtocol,
+			Port:        port.Port,
+			TargetPort:  intstr.FromInt(int(port.Port)),
+		})
+	}
+}
+
+// forgeEndpointSlice mutates the given endpointslice, configuring the given addresses and ports.
+func forgeEndpointSlice(endpointslice *discoveryv1.EndpointSlice, addresses []string, ports []mcsv1alpha1.ServicePort) {
+	endpointslice.AddressType = discoveryv1.AddressTypeIPv4
+
+	endpointslice.Endpoints = make([]discoveryv1.Endpoint, 0, len(addresses))
+	for _, address := range addresses {
+		endpointslice.Endpoints = append(endpointslice.Endpoints, discoveryv1.Endpoint{
+			Addresses:  []string{address},
+			Conditions: discoveryv1.EndpointConditions{Ready: pointer.Bool(true)},
+		})
+	}
+
+	endpointslice.Ports = make([]discoveryv1.EndpointPort, 0, len(ports))
+	for i := range ports {
+		protocol := protocolOrDefault(ports[i].Protocol)
+		endpointslice.Ports = append(endpointslice.Ports, discoveryv1.EndpointPort{
+			Name:        pointer.String(ports[i].Name),
+			Protocol:    &protocol,
+			AppProtocol: ports[i].AppProtocol,
+			Port:        pointer.Int32(ports[i].Port),
+		})
+	}
+}
+
+// TranslateAddresses maps the given pod addresses of the remote cluster described by the given TunnelEndpoint to the
+// ones they are reachable at from the local cluster, according to the remapping performed by the IPAM (if any).
+// Addresses not belonging to the pod CIDR of the remote cluster are discarded, as not reachable through the tunnel.
+func TranslateAddresses(tep *netv1alpha1.TunnelEndpoint, addresses []string) []string {
+	_, podCIDR, err := net.ParseCIDR(tep.Spec.RemotePodCIDR)
+	if err != nil {
+		klog.Warningf("Failed to parse the pod CIDR %q of remote cluster %q: %v", tep.Spec.RemotePodCIDR, tep.Spec.ClusterIdentity, err)
+		return nil
+	}
+	_, remappedPodCIDR := liqonetutils.GetPodCIDRS(tep)
+
+	translated := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip == nil || !podCIDR.Contains(ip) {
+			klog.V(4).Infof("Skipping address %v of remote cluster %q, as not belonging to the pod CIDR", address, tep.Spec.ClusterIdentity)
+			continue
+		}
+
+		mapped, err := liqonetutils.MapIPToNetwork(remappedPodCIDR, address)
+		if err != nil {
+			klog.Warningf("Failed to translate address %v of remote cluster %q: %v", address, tep.Spec.ClusterIdentity, err)
+			continue
+		}
+		translated = append(translated, mapped)
+	}
+	return translated
+}
+
+func protocolOrDefault(protocol corev1.Protocol) corev1.Protocol {
+	if protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return protocol
+}
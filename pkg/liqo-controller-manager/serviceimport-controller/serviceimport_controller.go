@@ -0,0 +1,335 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceimportctrl
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/internal/crdReplicator/reflection"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/getters"
+)
+
+// Reconciler reconciles the services exported by the peered clusters, materializing the corresponding ServiceImports.
+type Reconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceimports,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=multicluster.x-k8s.io,resources=serviceimports/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=net.liqo.io,resources=exportedservices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=net.liqo.io,resources=tunnelendpoints,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile ensures the ServiceImport (and the corresponding derived Service and EndpointSlices) associated with the
+// given service reflects the ExportedServices replicated by the peered clusters.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var exported netv1alpha1.ExportedServiceList
+	if err := r.List(ctx, &exported, client.MatchingLabelsSelector{Selector: importedServicesSelector(req.NamespacedName)}); err != nil {
+		klog.Errorf("Failed to list the ExportedServices associated with service %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if len(exported.Items) == 0 {
+		return ctrl.Result{}, r.ensureServiceImportAbsence(ctx, req.NamespacedName)
+	}
+
+	var namespace corev1.Namespace
+	if err := r.Get(ctx, types.NamespacedName{Name: req.Namespace}, &namespace); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("Namespace %q not found, skipping the import of service %q", req.Namespace, req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve namespace %q: %v", req.Namespace, err)
+		return ctrl.Result{}, err
+	}
+
+	// Sort the exported services, to guarantee a deterministic outcome.
+	sort.Slice(exported.Items, func(i, j int) bool {
+		return exported.Items[i].Labels[consts.ReplicationOriginLabel] < exported.Items[j].Labels[consts.ReplicationOriginLabel]
+	})
+
+	si := &mcsv1alpha1.ServiceImport{ObjectMeta: metav1.ObjectMeta{Name: req.Name, Namespace: req.Namespace}}
+	if err := r.Get(ctx, req.NamespacedName, si); client.IgnoreNotFound(err) != nil {
+		klog.Errorf("Failed to retrieve ServiceImport %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+	if si.ResourceVersion != "" && !isManaged(si) {
+		klog.Warningf("ServiceImport %q is not managed by Liqo, skipping", req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	spec := ForgeServiceImportSpec(exported.Items)
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, si, func() error {
+		si.SetLabels(labels.Merge(si.GetLabels(), managedLabels(req.Name)))
+		// Preserve the IPs, which are configured once the derived service has been created.
+		ips := si.Spec.IPs
+		si.Spec = spec
+		si.Spec.IPs = ips
+		return nil
+	}); err != nil {
+		klog.Errorf("Failed to enforce ServiceImport %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	svc, requeue, err := r.enforceDerivedService(ctx, si)
+	if err != nil || requeue {
+		return ctrl.Result{Requeue: requeue}, err
+	}
+
+	if err := r.enforceEndpointSlices(ctx, si, svc.Name, exported.Items); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, r.enforceServiceImportIPsAndStatus(ctx, si, svc, exported.Items)
+}
+
+// ensureServiceImportAbsence deletes the given ServiceImport, in case it is managed by Liqo. The derived Service
+// and EndpointSlices are then garbage collected, thanks to the owner references.
+func (r *Reconciler) ensureServiceImportAbsence(ctx context.Context, key types.NamespacedName) error {
+	var si mcsv1alpha1.ServiceImport
+	if err := r.Get(ctx, key, &si); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		klog.Errorf("Failed to retrieve ServiceImport %q: %v", key, err)
+		return err
+	}
+
+	if !isManaged(&si) {
+		return nil
+	}
+
+	if err := client.IgnoreNotFound(r.Delete(ctx, &si)); err != nil {
+		klog.Errorf("Failed to delete ServiceImport %q: %v", key, err)
+		return err
+	}
+	klog.Infof("ServiceImport %q correctly deleted", key)
+	return nil
+}
+
+// enforceDerivedService ensures the derived Service associated with the given ServiceImport exists and is up-to-date.
+// Since the cluster IP cannot be modified, the service is recreated in case the type of the ServiceImport changed.
+func (r *Reconciler) enforceDerivedService(ctx context.Context, si *mcsv1alpha1.ServiceImport) (svc *corev1.Service, requeue bool, err error) {
+	svc = &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: DerivedServiceName(si.Name), Namespace: si.Namespace}}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(svc), svc); client.IgnoreNotFound(err) != nil {
+		klog.Errorf("Failed to retrieve derived Service %q: %v", klog.KObj(svc), err)
+		return nil, false, err
+	}
+
+	headless := si.Spec.Type == mcsv1alpha1.Headless
+	if svc.ResourceVersion != "" && (svc.Spec.ClusterIP == corev1.ClusterIPNone) != headless {
+		if err := client.IgnoreNotFound(r.Delete(ctx, svc)); err != nil {
+			klog.Errorf("Failed to delete derived Service %q: %v", klog.KObj(svc), err)
+			return nil, false, err
+		}
+		klog.Infof("Derived Service %q deleted, as the type of ServiceImport %q changed", klog.KObj(svc), klog.KObj(si))
+		return nil, true, nil
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, svc, func() error {
+		svc.SetLabels(labels.Merge(svc.GetLabels(), managedLabels(si.Name)))
+		forgeDerivedServiceSpec(svc, &si.Spec)
+		return controllerutil.SetControllerReference(si, svc, r.Scheme)
+	})
+	if err != nil {
+		klog.Errorf("Failed to enforce derived Service %q: %v", klog.KObj(svc), err)
+		return nil, false, err
+	}
+	klog.V(4).Infof("Derived Service %q correctly enforced (%v)", klog.KObj(svc), result)
+	return svc, false, nil
+}
+
+// enforceEndpointSlices ensures one EndpointSlice exists for each group of endpoints exported by the peered clusters,
+// with the addresses translated according to the network remapping, and removes the stale ones.
+func (r *Reconciler) enforceEndpointSlices(ctx context.Context, si *mcsv1alpha1.ServiceImport,
+	derived string, exported []netv1alpha1.ExportedService) error {
+	desired := map[string]struct{}{}
+
+	for i := range exported {
+		clusterID := exported[i].Labels[consts.ReplicationOriginLabel]
+		tep, err := getters.GetTunnelEndpoint(ctx, r.Client, &discoveryv1alpha1.ClusterIdentity{ClusterID: clusterID}, exported[i].Namespace)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				klog.V(4).Infof("TunnelEndpoint for cluster %q not yet available, skipping its endpoints for service %q", clusterID, klog.KObj(si))
+				continue
+			}
+			klog.Errorf("Failed to retrieve the TunnelEndpoint for cluster %q: %v", clusterID, err)
+			return err
+		}
+
+		for j := range exported[i].Spec.Endpoints {
+			group := &exported[i].Spec.Endpoints[j]
+			endpointslice := &discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+				Name: endpointSliceName(derived, clusterID, j), Namespace: si.Namespace}}
+			desired[endpointslice.Name] = struct{}{}
+
+			result, err := controllerutil.CreateOrUpdate(ctx, r.Client, endpointslice, func() error {
+				endpointslice.SetLabels(labels.Merge(endpointslice.GetLabels(), labels.Set{
+					discoveryv1.LabelServiceName:   derived,
+					discoveryv1.LabelManagedBy:     EndpointSliceManagedBy,
+					mcsv1alpha1.LabelServiceName:   si.Name,
+					mcsv1alpha1.LabelSourceCluster: clusterID,
+				}))
+				forgeEndpointSlice(endpointslice, TranslateAddresses(tep, group.Addresses), group.Ports)
+				return controllerutil.SetControllerReference(si, endpointslice, r.Scheme)
+			})
+			if err != nil {
+				klog.Errorf("Failed to enforce EndpointSlice %q: %v", klog.KObj(endpointslice), err)
+				return err
+			}
+			klog.V(4).Infof("EndpointSlice %q correctly enforced (%v)", klog.KObj(endpointslice), result)
+		}
+	}
+
+	var existing discoveryv1.EndpointSliceList
+	if err := r.List(ctx, &existing, client.InNamespace(si.Namespace), client.MatchingLabels{
+		discoveryv1.LabelManagedBy: EndpointSliceManagedBy, mcsv1alpha1.LabelServiceName: si.Name}); err != nil {
+		klog.Errorf("Failed to list the EndpointSlices associated with ServiceImport %q: %v", klog.KObj(si), err)
+		return err
+	}
+
+	for i := range existing.Items {
+		if _, found := desired[existing.Items[i].Name]; found {
+			continue
+		}
+		if err := client.IgnoreNotFound(r.Delete(ctx, &existing.Items[i])); err != nil {
+			klog.Errorf("Failed to delete EndpointSlice %q: %v", klog.KObj(&existing.Items[i]), err)
+			return err
+		}
+		klog.Infof("EndpointSlice %q correctly deleted", klog.KObj(&existing.Items[i]))
+	}
+
+	return nil
+}
+
+// enforceServiceImportIPsAndStatus configures the IPs of the given ServiceImport, according to the derived service,
+// as well as the list of clusters exporting the service.
+func (r *Reconciler) enforceServiceImportIPsAndStatus(ctx context.Context, si *mcsv1alpha1.ServiceImport,
+	svc *corev1.Service, exported []netv1alpha1.ExportedService) error {
+	var ips []string
+	if si.Spec.Type == mcsv1alpha1.ClusterSetIP && svc.Spec.ClusterIP != "" && svc.Spec.ClusterIP != corev1.ClusterIPNone {
+		ips = []string{svc.Spec.ClusterIP}
+	}
+
+	if !equality.Semantic.DeepEqual(si.Spec.IPs, ips) {
+		original := si.DeepCopy()
+		si.Spec.IPs = ips
+		if err := r.Patch(ctx, si, client.MergeFrom(original)); err != nil {
+			klog.Errorf("Failed to update the IPs of ServiceImport %q: %v", klog.KObj(si), err)
+			return err
+		}
+	}
+
+	clusters := make([]mcsv1alpha1.ClusterStatus, 0, len(exported))
+	for i := range exported {
+		clusters = append(clusters, mcsv1alpha1.ClusterStatus{Cluster: exported[i].Labels[consts.ReplicationOriginLabel]})
+	}
+
+	if equality.Semantic.DeepEqual(si.Status.Clusters, clusters) {
+		return nil
+	}
+
+	original := si.DeepCopy()
+	si.Status.Clusters = clusters
+	if err := r.Status().Patch(ctx, si, client.MergeFrom(original)); err != nil {
+		klog.Errorf("Failed to update the status of ServiceImport %q: %v", klog.KObj(si), err)
+		return err
+	}
+	klog.Infof("ServiceImport %q correctly updated (clusters: %v)", klog.KObj(si), clusters)
+	return nil
+}
+
+// SetupWithManager registers a new controller for the imported services.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	replicatedExportedServices, err := predicate.LabelSelectorPredicate(reflection.ReplicatedResourcesLabelSelector())
+	utilruntime.Must(err)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mcsv1alpha1.ServiceImport{}, builder.WithPredicates(predicate.NewPredicateFuncs(isManaged))).
+		Owns(&corev1.Service{}).
+		Owns(&discoveryv1.EndpointSlice{}).
+		Watches(&source.Kind{Type: &netv1alpha1.ExportedService{}}, handler.EnqueueRequestsFromMapFunc(r.exportedServiceEnqueuer),
+			builder.WithPredicates(replicatedExportedServices)).
+		Watches(&source.Kind{Type: &netv1alpha1.TunnelEndpoint{}}, handler.EnqueueRequestsFromMapFunc(r.tunnelEndpointEnqueuer)).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(r.namespaceEnqueuer)).
+		Complete(r)
+}
+
+func (r *Reconciler) exportedServiceEnqueuer(obj client.Object) []reconcile.Request {
+	namespace, name := obj.GetLabels()[consts.ExportedServiceNamespaceLabel], obj.GetLabels()[consts.ExportedServiceNameLabel]
+	if namespace == "" || name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+}
+
+func (r *Reconciler) tunnelEndpointEnqueuer(obj client.Object) []reconcile.Request {
+	return r.enqueueExportedServices(client.InNamespace(obj.GetNamespace()),
+		client.MatchingLabels{consts.ReplicationStatusLabel: "true"})
+}
+
+func (r *Reconciler) namespaceEnqueuer(obj client.Object) []reconcile.Request {
+	return r.enqueueExportedServices(client.MatchingLabels{
+		consts.ReplicationStatusLabel:        "true",
+		consts.ExportedServiceNamespaceLabel: obj.GetName(),
+	})
+}
+
+func (r *Reconciler) enqueueExportedServices(opts ...client.ListOption) []reconcile.Request {
+	var exported netv1alpha1.ExportedServiceList
+	if err := r.List(context.Background(), &exported, opts...); err != nil {
+		klog.Errorf("Failed to list ExportedServices: %v", err)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(exported.Items))
+	for i := range exported.Items {
+		requests = append(requests, r.exportedServiceEnqueuer(&exported.Items[i])...)
+	}
+	return requests
+}
+
+// isManaged returns whether the given object is managed by this controller.
+func isManaged(obj client.Object) bool {
+	return obj.GetLabels()[consts.ManagedByLabelKey] == consts.ManagedByMultiClusterServicesValue
+}
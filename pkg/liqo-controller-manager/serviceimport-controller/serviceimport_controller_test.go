@@ -0,0 +1,207 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceimportctrl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mcsv1alpha1 "github.com/liqotech/liqo/apis/multicluster/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("ServiceImport controller", func() {
+	const (
+		namespace       = "default"
+		name            = "service"
+		tenantNamespace = "liqo-tenant-remote"
+		remoteClusterID = "remote-cluster-id"
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		reconciler *Reconciler
+		key        types.NamespacedName
+
+		exported *netv1alpha1.ExportedService
+		tep      *netv1alpha1.TunnelEndpoint
+
+		err error
+	)
+
+	forgeExportedService := func(clusterID string, ports ...mcsv1alpha1.ServicePort) *netv1alpha1.ExportedService {
+		return &netv1alpha1.ExportedService{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name + "." + namespace, Namespace: tenantNamespace,
+				Labels: map[string]string{
+					consts.ReplicationStatusLabel:        "true",
+					consts.ReplicationOriginLabel:        clusterID,
+					consts.ExportedServiceNamespaceLabel: namespace,
+					consts.ExportedServiceNameLabel:      name,
+				},
+			},
+			Spec: netv1alpha1.ExportedServiceSpec{
+				ServiceNamespace: namespace, ServiceName: name,
+				Service:   mcsv1alpha1.ServiceImportSpec{Type: mcsv1alpha1.ClusterSetIP, Ports: ports},
+				Endpoints: []netv1alpha1.ExportedEndpoints{{Addresses: []string{"10.0.0.1", "192.168.0.1"}, Ports: ports}},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Namespace: namespace, Name: name}
+
+		exported = forgeExportedService(remoteClusterID, mcsv1alpha1.ServicePort{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80})
+		tep = &netv1alpha1.TunnelEndpoint{
+			ObjectMeta: metav1.ObjectMeta{Name: "tep", Namespace: tenantNamespace,
+				Labels: map[string]string{consts.ClusterIDLabelName: remoteClusterID}},
+			Spec: netv1alpha1.TunnelEndpointSpec{
+				RemotePodCIDR:    "10.0.0.0/16",
+				RemoteNATPodCIDR: "10.50.0.0/16",
+			},
+		}
+	})
+
+	Describe("the ForgeServiceImportSpec function", func() {
+		It("should merge the ports of the different clusters", func() {
+			spec := ForgeServiceImportSpec([]netv1alpha1.ExportedService{
+				*exported,
+				*forgeExportedService("other",
+					mcsv1alpha1.ServicePort{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+					mcsv1alpha1.ServicePort{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090}),
+			})
+			Expect(spec.Type).To(Equal(mcsv1alpha1.ClusterSetIP))
+			Expect(spec.Ports).To(ConsistOf(
+				mcsv1alpha1.ServicePort{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80},
+				mcsv1alpha1.ServicePort{Name: "metrics", Protocol: corev1.ProtocolTCP, Port: 9090},
+			))
+		})
+	})
+
+	Describe("the TranslateAddresses function", func() {
+		When("the remote pod CIDR is remapped", func() {
+			It("should translate the addresses, and discard the ones outside the pod CIDR", func() {
+				Expect(TranslateAddresses(tep, []string{"10.0.0.1", "10.0.1.2", "192.168.0.1", "invalid"})).
+					To(Equal([]string{"10.50.0.1", "10.50.1.2"}))
+			})
+		})
+
+		When("the remote pod CIDR is not remapped", func() {
+			BeforeEach(func() { tep.Spec.RemoteNATPodCIDR = consts.DefaultCIDRValue })
+			It("should preserve the addresses", func() {
+				Expect(TranslateAddresses(tep, []string{"10.0.0.1"})).To(Equal([]string{"10.0.0.1"}))
+			})
+		})
+	})
+
+	Describe("the Reconcile function", func() {
+		var objects []client.Object
+
+		BeforeEach(func() {
+			objects = []client.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}},
+				exported, tep,
+			}
+		})
+
+		JustBeforeEach(func() {
+			cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+			reconciler = &Reconciler{Client: cl, Scheme: scheme}
+			_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		})
+
+		When("a remote cluster exports the service", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+
+			It("should create the ServiceImport", func() {
+				var si mcsv1alpha1.ServiceImport
+				Expect(cl.Get(ctx, key, &si)).To(Succeed())
+				Expect(si.Labels).To(HaveKeyWithValue(consts.ManagedByLabelKey, consts.ManagedByMultiClusterServicesValue))
+				Expect(si.Spec.Ports).To(HaveLen(1))
+				Expect(si.Status.Clusters).To(ConsistOf(mcsv1alpha1.ClusterStatus{Cluster: remoteClusterID}))
+			})
+
+			It("should create the derived service", func() {
+				var svc corev1.Service
+				Expect(cl.Get(ctx, types.NamespacedName{Namespace: namespace, Name: DerivedServiceName(name)}, &svc)).To(Succeed())
+				Expect(svc.Spec.Ports).To(HaveLen(1))
+				Expect(svc.Spec.Ports[0].Port).To(BeNumerically("==", 80))
+				Expect(svc.OwnerReferences).To(HaveLen(1))
+			})
+
+			It("should create the endpointslice with the translated addresses", func() {
+				var endpointslices discoveryv1.EndpointSliceList
+				Expect(cl.List(ctx, &endpointslices, client.InNamespace(namespace))).To(Succeed())
+				Expect(endpointslices.Items).To(HaveLen(1))
+
+				endpointslice := &endpointslices.Items[0]
+				Expect(endpointslice.Labels).To(HaveKeyWithValue(discoveryv1.LabelServiceName, DerivedServiceName(name)))
+				Expect(endpointslice.Labels).To(HaveKeyWithValue(mcsv1alpha1.LabelSourceCluster, remoteClusterID))
+				Expect(endpointslice.Endpoints).To(HaveLen(1))
+				Expect(endpointslice.Endpoints[0].Addresses).To(ConsistOf("10.50.0.1"))
+			})
+		})
+
+		When("the TunnelEndpoint does not exist yet", func() {
+			BeforeEach(func() { objects = objects[:2] })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not create any endpointslice", func() {
+				var endpointslices discoveryv1.EndpointSliceList
+				Expect(cl.List(ctx, &endpointslices, client.InNamespace(namespace))).To(Succeed())
+				Expect(endpointslices.Items).To(BeEmpty())
+			})
+		})
+
+		When("the service is no longer exported", func() {
+			BeforeEach(func() {
+				objects = []client.Object{&mcsv1alpha1.ServiceImport{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace,
+					Labels: managedLabels(name)}}}
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should delete the ServiceImport", func() {
+				Expect(cl.Get(ctx, key, &mcsv1alpha1.ServiceImport{})).To(testutil.BeNotFound())
+			})
+		})
+
+		When("an unmanaged ServiceImport exists", func() {
+			BeforeEach(func() {
+				objects = append(objects, &mcsv1alpha1.ServiceImport{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}})
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should not modify it", func() {
+				var si mcsv1alpha1.ServiceImport
+				Expect(cl.Get(ctx, key, &si)).To(Succeed())
+				Expect(si.Labels).To(BeEmpty())
+				Expect(si.Spec.Ports).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -17,12 +17,15 @@ package shadowpodctrl
 import (
 	"context"
 
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
@@ -31,27 +34,38 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
+	liqogetters "github.com/liqotech/liqo/pkg/utils/getters"
+	liqolabels "github.com/liqotech/liqo/pkg/utils/labels"
+	podutils "github.com/liqotech/liqo/pkg/utils/pod"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 )
 
 // Reconciler reconciles a ShadowPod object.
 type Reconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// ClientSet is used to add the ephemeral containers to the pods, through the corresponding subresource.
+	ClientSet kubernetes.Interface
 }
 
 // +kubebuilder:rbac:groups=virtualkubelet.liqo.io,resources=shadowpods,verbs=get;list;watch;update;patch;delete
 // +kubebuilder:rbac:groups=virtualkubelet.liqo.io,resources=shadowpods/finalizers,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods/ephemeralcontainers,verbs=update;patch
+// +kubebuilder:rbac:groups=sharing.liqo.io,resources=resourceoffers,verbs=get;list;watch
 
 // Reconcile ShadowPods objects.
-func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	nsName := req.NamespacedName
 	klog.V(4).Infof("reconcile shadowpod %s", nsName)
 
 	shadowPod := vkv1alpha1.ShadowPod{}
-	if err := r.Get(ctx, nsName, &shadowPod); err != nil {
+	if err = r.Get(ctx, nsName, &shadowPod); err != nil {
 		err = client.IgnoreNotFound(err)
 		if err == nil {
 			klog.V(4).Infof("skip: shadowpod %s not found", nsName)
@@ -59,6 +73,19 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	// The span is child of the one which originated the shadowpod (i.e., the reflection of the pod in the origin cluster).
+	ctx, span := tracing.Start(tracing.ExtractAnnotations(ctx, shadowPod.Annotations), "ShadowPod reconcile",
+		attribute.String("liqo.namespace", nsName.Namespace), attribute.String("liqo.name", nsName.Name))
+	defer func() { tracing.End(span, err) }()
+
+	classes, err := r.extendedResourceClasses(ctx, &shadowPod)
+	if err != nil {
+		klog.Errorf("unable to retrieve the extended resources offered to the origin cluster of shadowpod %q: %v", klog.KObj(&shadowPod), err)
+		return ctrl.Result{}, err
+	}
+	// The class-qualified extended resources are replaced with the ones actually exposed by the local nodes.
+	extendedresources.Translate(&shadowPod.Spec.Pod, classes)
+
 	pod := corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        nsName.Name,
@@ -66,17 +93,27 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 			Labels:      labels.Merge(shadowPod.Labels, labels.Set{consts.ManagedByLabelKey: consts.ManagedByShadowPodValue}),
 			Annotations: shadowPod.Annotations,
 		},
-		Spec: shadowPod.Spec.Pod,
+		Spec: *shadowPod.Spec.Pod.DeepCopy(),
 	}
 
+	// Ephemeral containers cannot be specified at creation time, and are added afterwards through the corresponding subresource.
+	pod.Spec.EphemeralContainers = nil
+
 	utilruntime.Must(ctrl.SetControllerReference(&shadowPod, &pod, r.Scheme))
 
-	if err := r.Get(ctx, nsName, &pod); err == nil {
-		klog.V(4).Infof("skip: pod %q already running", klog.KObj(&pod))
-		return ctrl.Result{}, nil
+	if err = r.Get(ctx, nsName, &pod); err == nil {
+		current := &pod
+		if len(shadowPod.Spec.Pod.EphemeralContainers) > len(pod.Spec.EphemeralContainers) {
+			// The remaining mutations are applied in the same pass, starting from the pod returned by the update,
+			// since changes to the pod only trigger a new reconciliation in case of deletion.
+			if current, err = r.addEphemeralContainers(ctx, &shadowPod, &pod); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, r.updatePod(ctx, &shadowPod, current)
 	}
 
-	if err := r.Create(ctx, &pod); err != nil {
+	if err = r.Create(ctx, &pod); err != nil {
 		if errors.IsAlreadyExists(err) {
 			klog.V(4).Infof("pod %q already exists", klog.KObj(&pod))
 			return ctrl.Result{}, nil
@@ -88,7 +125,87 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 
 	klog.Infof("created pod %q for shadowpod %q", klog.KObj(&pod), klog.KObj(&shadowPod))
 
-	return ctrl.Result{}, nil
+	// Requeue the shadowpod to add the ephemeral containers, if any.
+	return ctrl.Result{Requeue: len(shadowPod.Spec.Pod.EphemeralContainers) > 0}, nil
+}
+
+// updatePod applies to the given pod the in-place mutations specified by the shadowpod (i.e., labels, annotations and the
+// mutable fields of the pod spec). The resize of the containers is performed afterwards, as it is not supported by all clusters.
+func (r *Reconciler) updatePod(ctx context.Context, shadowPod *vkv1alpha1.ShadowPod, pod *corev1.Pod) error {
+	updated := pod.DeepCopy()
+	updated.SetLabels(labels.Merge(shadowPod.Labels, labels.Set{consts.ManagedByLabelKey: consts.ManagedByShadowPodValue}))
+	// Annotations are merged, to preserve the ones possibly added by the remote cluster (e.g., by admission plugins).
+	updated.SetAnnotations(labels.Merge(pod.Annotations, shadowPod.Annotations))
+	podutils.MutatePodSpec(&updated.Spec, &shadowPod.Spec.Pod, false)
+
+	if !equality.Semantic.DeepEqual(pod, updated) {
+		if err := r.Update(ctx, updated); err != nil {
+			klog.Errorf("unable to update pod %q: %v", klog.KObj(pod), err)
+			return err
+		}
+		klog.Infof("updated pod %q for shadowpod %q", klog.KObj(pod), klog.KObj(shadowPod))
+	}
+
+	resized := updated.DeepCopy()
+	podutils.MutateContainers(resized.Spec.Containers, shadowPod.Spec.Pod.Containers, true)
+	if equality.Semantic.DeepEqual(updated, resized) {
+		klog.V(4).Infof("skip: pod %q already up-to-date", klog.KObj(pod))
+		return nil
+	}
+
+	if err := r.Update(ctx, resized); err != nil {
+		// In-place resize is not supported by the remote cluster (e.g., the InPlacePodVerticalScaling feature is not enabled).
+		if errors.IsInvalid(err) {
+			klog.Warningf("unable to resize pod %q, as in-place resize is not supported: %v", klog.KObj(pod), err)
+			return nil
+		}
+		klog.Errorf("unable to resize pod %q: %v", klog.KObj(pod), err)
+		return err
+	}
+
+	klog.Infof("resized pod %q for shadowpod %q", klog.KObj(pod), klog.KObj(shadowPod))
+	return nil
+}
+
+// addEphemeralContainers adds to the given pod the ephemeral containers specified by the shadowpod and not yet present,
+// returning the updated pod.
+func (r *Reconciler) addEphemeralContainers(ctx context.Context, shadowPod *vkv1alpha1.ShadowPod, pod *corev1.Pod) (*corev1.Pod, error) {
+	existing := make(map[string]struct{}, len(pod.Spec.EphemeralContainers))
+	for i := range pod.Spec.EphemeralContainers {
+		existing[pod.Spec.EphemeralContainers[i].Name] = struct{}{}
+	}
+
+	updated := pod.DeepCopy()
+	for i := range shadowPod.Spec.Pod.EphemeralContainers {
+		if _, found := existing[shadowPod.Spec.Pod.EphemeralContainers[i].Name]; !found {
+			updated.Spec.EphemeralContainers = append(updated.Spec.EphemeralContainers, shadowPod.Spec.Pod.EphemeralContainers[i])
+		}
+	}
+
+	pods := r.ClientSet.CoreV1().Pods(pod.GetNamespace())
+	result, err := pods.UpdateEphemeralContainers(ctx, pod.GetName(), updated, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Errorf("unable to add ephemeral containers to pod %q: %v", klog.KObj(pod), err)
+		return nil, err
+	}
+
+	klog.Infof("added %d ephemeral containers to pod %q", len(updated.Spec.EphemeralContainers)-len(pod.Spec.EphemeralContainers), klog.KObj(pod))
+	return result, nil
+}
+
+// extendedResourceClasses returns the classes of devices backing the extended resources offered to the cluster
+// originating the given shadowpod, which define the class-qualified resources the shadowpod can request.
+func (r *Reconciler) extendedResourceClasses(ctx context.Context, shadowPod *vkv1alpha1.ShadowPod) ([]sharingv1alpha1.ExtendedResourceClass, error) {
+	clusterID, found := shadowPod.Labels[forge.LiqoOriginClusterIDKey]
+	if !found {
+		return nil, nil
+	}
+
+	offer, err := liqogetters.GetResourceOfferByLabel(ctx, r.Client, corev1.NamespaceAll, liqolabels.LocalLabelSelectorForCluster(clusterID))
+	if err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return offer.Spec.ExtendedResources, nil
 }
 
 // SetupWithManager monitors only updates on ShadowPods.
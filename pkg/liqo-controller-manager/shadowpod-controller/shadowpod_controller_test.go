@@ -23,16 +23,21 @@ import (
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gstruct"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog/v2"
 	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/utils/pointer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
 	shadowpodctrl "github.com/liqotech/liqo/pkg/liqo-controller-manager/shadowpod-controller"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 )
 
 var _ = Describe("Reconcile", func() {
@@ -96,8 +101,9 @@ var _ = Describe("Reconcile", func() {
 
 	JustBeforeEach(func() {
 		r := &shadowpodctrl.Reconciler{
-			Client: k8sClient,
-			Scheme: scheme.Scheme,
+			Client:    k8sClient,
+			Scheme:    scheme.Scheme,
+			ClientSet: clientset,
 		}
 
 		res, err = r.Reconcile(ctx, req)
@@ -119,13 +125,156 @@ var _ = Describe("Reconcile", func() {
 	When("pod has been already created", func() {
 		BeforeEach(func() {
 			Expect(k8sClient.Create(ctx, &testShadowPod)).To(Succeed())
+			testPod.SetLabels(labels.Merge(testShadowPod.Labels, labels.Set{consts.ManagedByLabelKey: consts.ManagedByShadowPodValue}))
+			testPod.SetAnnotations(testShadowPod.Annotations)
 			Expect(k8sClient.Create(ctx, &testPod)).To(Succeed())
 		})
 
 		It("should ignore it", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(res).To(BeZero())
-			Expect(buffer.String()).To(ContainSubstring("skip: pod \"default/test-shadow-pod\" already running"))
+			Expect(buffer.String()).To(ContainSubstring("skip: pod \"default/test-shadow-pod\" already up-to-date"))
+		})
+	})
+
+	When("the shadowpod has been updated after the creation of the pod", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &testPod)).To(Succeed())
+			testShadowPod.Spec.Pod.Containers[0].Image = "nginx:latest"
+			testShadowPod.Spec.Pod.ActiveDeadlineSeconds = pointer.Int64(300)
+			testShadowPod.Spec.Pod.Tolerations = []corev1.Toleration{{Key: "foo", Operator: corev1.TolerationOpExists}}
+			Expect(k8sClient.Create(ctx, &testShadowPod)).To(Succeed())
+		})
+
+		It("should apply the in-place mutations to the pod", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(BeZero())
+
+			pod := corev1.Pod{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, &pod)).To(Succeed())
+			Expect(pod.Spec.Containers[0].Image).To(Equal("nginx:latest"))
+			Expect(pod.Spec.ActiveDeadlineSeconds).To(PointTo(BeNumerically("==", 300)))
+			Expect(pod.Spec.Tolerations).To(ContainElement(corev1.Toleration{Key: "foo", Operator: corev1.TolerationOpExists}))
+		})
+
+		It("should align the labels and annotations of the pod", func() {
+			pod := corev1.Pod{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, &pod)).To(Succeed())
+			Expect(pod.Labels).To(HaveKeyWithValue("label1-key", "label1-value"))
+			Expect(pod.Labels).To(HaveKeyWithValue(consts.ManagedByLabelKey, consts.ManagedByShadowPodValue))
+			Expect(pod.Annotations).To(HaveKeyWithValue("annotation1-key", "annotation1-value"))
+		})
+	})
+
+	When("ephemeral containers have been added to the shadowpod", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &testPod)).To(Succeed())
+			testShadowPod.Spec.Pod.EphemeralContainers = []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name: "debugger", Image: "busybox", TerminationMessagePolicy: corev1.TerminationMessageReadFile},
+				TargetContainerName: "nginx",
+			}}
+			Expect(k8sClient.Create(ctx, &testShadowPod)).To(Succeed())
+		})
+
+		It("should add them to the pod", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(BeZero())
+
+			pod := corev1.Pod{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, &pod)).To(Succeed())
+			Expect(pod.Spec.EphemeralContainers).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"EphemeralContainerCommon": MatchFields(IgnoreExtras, Fields{"Name": Equal("debugger")}),
+				"TargetContainerName":      Equal("nginx"),
+			})))
+		})
+	})
+
+	When("ephemeral containers and in-place mutations have been added to the shadowpod at the same time", func() {
+		BeforeEach(func() {
+			Expect(k8sClient.Create(ctx, &testPod)).To(Succeed())
+			testShadowPod.Spec.Pod.Containers[0].Image = "nginx:latest"
+			testShadowPod.Spec.Pod.EphemeralContainers = []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+					Name: "debugger", Image: "busybox", TerminationMessagePolicy: corev1.TerminationMessageReadFile},
+				TargetContainerName: "nginx",
+			}}
+			Expect(k8sClient.Create(ctx, &testShadowPod)).To(Succeed())
+		})
+
+		It("should apply both in the same reconciliation", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(BeZero())
+
+			pod := corev1.Pod{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, &pod)).To(Succeed())
+			Expect(pod.Spec.EphemeralContainers).To(HaveLen(1))
+			Expect(pod.Spec.Containers[0].Image).To(Equal("nginx:latest"))
+			Expect(pod.Labels).To(HaveKeyWithValue("label1-key", "label1-value"))
+			Expect(pod.Annotations).To(HaveKeyWithValue("annotation1-key", "annotation1-value"))
+		})
+	})
+
+	When("create pod with ephemeral containers", func() {
+		BeforeEach(func() {
+			testShadowPod.Spec.Pod.EphemeralContainers = []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"},
+			}}
+			Expect(k8sClient.Create(ctx, &testShadowPod)).To(Succeed())
+		})
+
+		It("should create the pod without them, and requeue the request", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res.Requeue).To(BeTrue())
+
+			pod := corev1.Pod{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, &pod)).To(Succeed())
+			Expect(pod.Spec.EphemeralContainers).To(BeEmpty())
+		})
+	})
+
+	When("create pod requesting a class of devices", func() {
+		const (
+			clusterID = "origin-cluster-id"
+			gpu       = corev1.ResourceName("nvidia.com/gpu")
+			gpuClass  = corev1.ResourceName("nvidia.com/gpu-1a2b3c4d")
+		)
+
+		var offer sharingv1alpha1.ResourceOffer
+
+		BeforeEach(func() {
+			offer = sharingv1alpha1.ResourceOffer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "offer", Namespace: shadowPodNamespace,
+					Labels: map[string]string{consts.ReplicationRequestedLabel: "true", consts.ReplicationDestinationLabel: clusterID},
+				},
+				Spec: sharingv1alpha1.ResourceOfferSpec{
+					ClusterID: "local-cluster-id",
+					ExtendedResources: []sharingv1alpha1.ExtendedResourceClass{{
+						Name: gpu, ResourceName: gpuClass, Quantity: resource.MustParse("2"),
+						Attributes: map[string]string{"nvidia.com/gpu.product": "NVIDIA-A100"},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &offer)).To(Succeed())
+
+			testShadowPod.Labels[forge.LiqoOriginClusterIDKey] = clusterID
+			testShadowPod.Spec.Pod.Containers[0].Resources.Limits = corev1.ResourceList{gpuClass: resource.MustParse("1")}
+			Expect(k8sClient.Create(ctx, &testShadowPod)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &offer)).To(Succeed())
+		})
+
+		It("should request the corresponding extended resource, on the nodes exposing the devices of the class", func() {
+			Expect(err).NotTo(HaveOccurred())
+
+			pod := corev1.Pod{}
+			Expect(k8sClient.Get(ctx, req.NamespacedName, &pod)).To(Succeed())
+			Expect(pod.Spec.Containers[0].Resources.Limits).To(HaveLen(1))
+			Expect(pod.Spec.Containers[0].Resources.Limits.Name(gpu, resource.DecimalSI).Value()).To(BeNumerically("==", 1))
+			Expect(pod.Spec.NodeSelector).To(Equal(map[string]string{"nvidia.com/gpu.product": "NVIDIA-A100"}))
 		})
 	})
 
@@ -21,16 +21,19 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	"github.com/liqotech/liqo/pkg/utils/testutil"
 )
 
 var testEnv *envtest.Environment
 var k8sClient client.Client
+var clientset kubernetes.Interface
 
 func TestShadowPodController(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -50,9 +53,11 @@ var _ = BeforeSuite(func() {
 
 	Expect(corev1.AddToScheme(scheme.Scheme)).To(Succeed())
 	Expect(vkv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+	Expect(sharingv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
 
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).NotTo(HaveOccurred())
+	clientset = kubernetes.NewForConfigOrDie(cfg)
 })
 
 var _ = AfterSuite(func() {
@@ -0,0 +1,95 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// IsExpansionRequired returns whether the given bound virtual PVC requests more storage than currently available.
+func IsExpansionRequired(virtualPvc *corev1.PersistentVolumeClaim) bool {
+	requested := virtualPvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	capacity := virtualPvc.Status.Capacity[corev1.ResourceStorage]
+	return virtualPvc.Spec.VolumeName != "" && requested.Cmp(capacity) > 0
+}
+
+// IsExpansionPropagated returns whether the storage request of the given virtual PVC has already been
+// propagated to the corresponding real PVC.
+func IsExpansionPropagated(virtualPvc, realPvc *corev1.PersistentVolumeClaim) bool {
+	requested := virtualPvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	realRequested := realPvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	return realRequested.Cmp(requested) >= 0
+}
+
+// ForgeExpansionStatus forges the status of a virtual PVC being expanded, given the corresponding real PVC.
+// The resize conditions are mirrored from the real PVC, while the capacity is updated once the real volume
+// has been completely resized. Additionally, it returns the capacity the virtual PV shall be expanded to,
+// or nil in case the real volume has not yet been expanded by the underlying storage driver.
+func ForgeExpansionStatus(virtualPvc, realPvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaimStatus, *resource.Quantity) {
+	status := virtualPvc.Status.DeepCopy()
+	requested := virtualPvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	realCapacity := realPvc.Status.Capacity[corev1.ResourceStorage]
+
+	conditions := []corev1.PersistentVolumeClaimCondition{}
+	for i := range status.Conditions {
+		if !isResizeCondition(status.Conditions[i].Type) {
+			conditions = append(conditions, status.Conditions[i])
+		}
+	}
+
+	// The real volume has been completely resized (including the file system, if any).
+	if realCapacity.Cmp(requested) >= 0 {
+		status.Conditions = conditions
+		if status.Capacity == nil {
+			status.Capacity = corev1.ResourceList{}
+		}
+		status.Capacity[corev1.ResourceStorage] = realCapacity
+		return status, &realCapacity
+	}
+
+	var capacity *resource.Quantity
+	for i := range realPvc.Status.Conditions {
+		if isResizeCondition(realPvc.Status.Conditions[i].Type) {
+			conditions = append(conditions, realPvc.Status.Conditions[i])
+		}
+		// The volume has been expanded, and only the file system resize (performed by the kubelet) is pending.
+		if realPvc.Status.Conditions[i].Type == corev1.PersistentVolumeClaimFileSystemResizePending {
+			capacity = &requested
+		}
+	}
+
+	status.Conditions = conditions
+	return status, capacity
+}
+
+// EnsureVolumeCapacity increases the capacity of the given virtual PV up to the given value,
+// returning whether it has been modified.
+func EnsureVolumeCapacity(virtualPv *corev1.PersistentVolume, capacity resource.Quantity) bool {
+	current := virtualPv.Spec.Capacity[corev1.ResourceStorage]
+	if current.Cmp(capacity) >= 0 {
+		return false
+	}
+
+	if virtualPv.Spec.Capacity == nil {
+		virtualPv.Spec.Capacity = corev1.ResourceList{}
+	}
+	virtualPv.Spec.Capacity[corev1.ResourceStorage] = capacity
+	return true
+}
+
+func isResizeCondition(condition corev1.PersistentVolumeClaimConditionType) bool {
+	return condition == corev1.PersistentVolumeClaimResizing || condition == corev1.PersistentVolumeClaimFileSystemResizePending
+}
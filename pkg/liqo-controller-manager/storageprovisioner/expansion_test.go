@@ -0,0 +1,143 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Volume expansion helpers", func() {
+	var virtualPvc, realPvc corev1.PersistentVolumeClaim
+
+	claim := func(requested, capacity string, conditions ...corev1.PersistentVolumeClaimConditionType) corev1.PersistentVolumeClaim {
+		pvc := corev1.PersistentVolumeClaim{
+			Spec: corev1.PersistentVolumeClaimSpec{
+				VolumeName: "volume",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(requested)},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)},
+			},
+		}
+		for _, condition := range conditions {
+			pvc.Status.Conditions = append(pvc.Status.Conditions,
+				corev1.PersistentVolumeClaimCondition{Type: condition, Status: corev1.ConditionTrue})
+		}
+		return pvc
+	}
+
+	Describe("the IsExpansionRequired function", func() {
+		It("should return false if the capacity matches the request", func() {
+			pvc := claim("1Gi", "1Gi")
+			Expect(IsExpansionRequired(&pvc)).To(BeFalse())
+		})
+		It("should return false if the claim is not bound", func() {
+			pvc := claim("2Gi", "1Gi")
+			pvc.Spec.VolumeName = ""
+			Expect(IsExpansionRequired(&pvc)).To(BeFalse())
+		})
+		It("should return true if the request exceeds the capacity", func() {
+			pvc := claim("2Gi", "1Gi")
+			Expect(IsExpansionRequired(&pvc)).To(BeTrue())
+		})
+	})
+
+	Describe("the IsExpansionPropagated function", func() {
+		BeforeEach(func() { virtualPvc = claim("2Gi", "1Gi") })
+
+		It("should return false if the real request is lower", func() {
+			realPvc = claim("1Gi", "1Gi")
+			Expect(IsExpansionPropagated(&virtualPvc, &realPvc)).To(BeFalse())
+		})
+		It("should return true if the real request matches", func() {
+			realPvc = claim("2Gi", "1Gi")
+			Expect(IsExpansionPropagated(&virtualPvc, &realPvc)).To(BeTrue())
+		})
+	})
+
+	Describe("the ForgeExpansionStatus function", func() {
+		var (
+			status   *corev1.PersistentVolumeClaimStatus
+			capacity *resource.Quantity
+		)
+
+		BeforeEach(func() { virtualPvc = claim("2Gi", "1Gi", corev1.PersistentVolumeClaimResizing) })
+		JustBeforeEach(func() { status, capacity = ForgeExpansionStatus(&virtualPvc, &realPvc) })
+
+		When("the real volume is being resized", func() {
+			BeforeEach(func() { realPvc = claim("2Gi", "1Gi", corev1.PersistentVolumeClaimResizing) })
+
+			It("should not modify the capacity", func() {
+				Expect(status.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("1Gi")))
+			})
+			It("should mirror the resize condition", func() {
+				Expect(status.Conditions).To(ConsistOf(
+					MatchFields(IgnoreExtras, Fields{"Type": Equal(corev1.PersistentVolumeClaimResizing)})))
+			})
+			It("should not require the expansion of the virtual volume", func() { Expect(capacity).To(BeNil()) })
+		})
+
+		When("the file system resize is pending", func() {
+			BeforeEach(func() { realPvc = claim("2Gi", "1Gi", corev1.PersistentVolumeClaimFileSystemResizePending) })
+
+			It("should not modify the capacity", func() {
+				Expect(status.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("1Gi")))
+			})
+			It("should mirror the resize condition", func() {
+				Expect(status.Conditions).To(ConsistOf(
+					MatchFields(IgnoreExtras, Fields{"Type": Equal(corev1.PersistentVolumeClaimFileSystemResizePending)})))
+			})
+			It("should require the expansion of the virtual volume", func() {
+				Expect(capacity).To(PointTo(Equal(resource.MustParse("2Gi"))))
+			})
+		})
+
+		When("the real volume has been resized", func() {
+			BeforeEach(func() { realPvc = claim("2Gi", "3Gi") })
+
+			It("should update the capacity", func() {
+				Expect(status.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("3Gi")))
+			})
+			It("should remove the resize conditions", func() { Expect(status.Conditions).To(BeEmpty()) })
+			It("should require the expansion of the virtual volume", func() {
+				Expect(capacity).To(PointTo(Equal(resource.MustParse("3Gi"))))
+			})
+		})
+	})
+
+	Describe("the EnsureVolumeCapacity function", func() {
+		var pv corev1.PersistentVolume
+
+		BeforeEach(func() {
+			pv = corev1.PersistentVolume{Spec: corev1.PersistentVolumeSpec{
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("2Gi")}}}
+		})
+
+		It("should not shrink the volume", func() {
+			Expect(EnsureVolumeCapacity(&pv, resource.MustParse("1Gi"))).To(BeFalse())
+			Expect(pv.Spec.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("2Gi")))
+		})
+		It("should expand the volume", func() {
+			Expect(EnsureVolumeCapacity(&pv, resource.MustParse("3Gi"))).To(BeTrue())
+			Expect(pv.Spec.Capacity).To(HaveKeyWithValue(corev1.ResourceStorage, resource.MustParse("3Gi")))
+		})
+	})
+})
@@ -18,9 +18,12 @@ import (
 	"context"
 	"fmt"
 
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
 
@@ -39,8 +42,13 @@ func (p *liqoLocalStorageProvisioner) provisionLocalPVC(ctx context.Context,
 		},
 	}
 
+	dataSource, err := p.localRealDataSource(ctx, virtualPvc)
+	if err != nil {
+		return nil, controller.ProvisioningInBackground, err
+	}
+
 	if operation, err := controllerutil.CreateOrUpdate(ctx, p.client, &realPvc, func() error {
-		return p.mutateLocalRealPVC(virtualPvc, &realPvc, options.SelectedNode)
+		return p.mutateLocalRealPVC(virtualPvc, &realPvc, options.SelectedNode, dataSource)
 	}); err != nil {
 		return nil, controller.ProvisioningInBackground, err
 	} else if operation != controllerutil.OperationResultNone {
@@ -99,9 +107,9 @@ func (p *liqoLocalStorageProvisioner) provisionLocalPVC(ctx context.Context,
 // with the ones coming from the virtualPVC.
 // i.e. the PVC spec is the copy of the virtual one, but the storage class is the one set in the
 // storage provisioner or the one previously set in the PVC (since it is a read-only field). The
-// real volumeName is preserved too.
+// real volumeName is preserved too, while the data source is replaced with the given one (if any).
 func (p *liqoLocalStorageProvisioner) mutateLocalRealPVC(virtualPvc, realPvc *v1.PersistentVolumeClaim,
-	selectedNode *v1.Node) error {
+	selectedNode *v1.Node, dataSource *v1.TypedLocalObjectReference) error {
 	if realPvc.ObjectMeta.Annotations == nil {
 		realPvc.ObjectMeta.Annotations = map[string]string{}
 	}
@@ -124,10 +132,48 @@ func (p *liqoLocalStorageProvisioner) mutateLocalRealPVC(virtualPvc, realPvc *v1
 	realPvc.Spec = *virtualPvc.Spec.DeepCopy()
 	realPvc.Spec.VolumeName = realPvName
 	realPvc.Spec.StorageClassName = storageClassName
+	if dataSource != nil {
+		realPvc.Spec.DataSource = dataSource.DeepCopy()
+		realPvc.Spec.DataSourceRef = dataSource.DeepCopy()
+	}
 
 	return nil
 }
 
+// localRealDataSource returns the translated data source of the real PVC, in case the virtual PVC is restored
+// from a virtual VolumeSnapshot (nil otherwise). The real VolumeSnapshot is required to be stored in the local cluster.
+func (p *liqoLocalStorageProvisioner) localRealDataSource(ctx context.Context,
+	virtualPvc *v1.PersistentVolumeClaim) (*v1.TypedLocalObjectReference, error) {
+	if !IsSnapshotDataSource(virtualPvc.Spec.DataSource) {
+		return nil, nil
+	}
+
+	var snapshot snapshotv1.VolumeSnapshot
+	if err := p.client.Get(ctx, types.NamespacedName{
+		Namespace: virtualPvc.GetNamespace(),
+		Name:      virtualPvc.Spec.DataSource.Name,
+	}, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to retrieve the VolumeSnapshot to restore: %w", err)
+	}
+
+	var realSnapshot snapshotv1.VolumeSnapshot
+	if err := p.client.Get(ctx, types.NamespacedName{
+		Namespace: p.storageNamespace,
+		Name:      string(snapshot.GetUID()),
+	}, &realSnapshot); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("VolumeSnapshot %q is not stored in the local cluster", klog.KObj(&snapshot))
+		}
+		return nil, fmt.Errorf("failed to retrieve the real VolumeSnapshot to restore: %w", err)
+	}
+
+	return &v1.TypedLocalObjectReference{
+		APIGroup: virtualPvc.Spec.DataSource.APIGroup,
+		Kind:     VolumeSnapshotKind,
+		Name:     realSnapshot.GetName(),
+	}, nil
+}
+
 func mergeAffinities(vol1, vol2 *v1.PersistentVolumeSpec) *v1.VolumeNodeAffinity {
 	if emptyVolumeNodeAffinity(vol1) {
 		return vol2.NodeAffinity.DeepCopy()
@@ -0,0 +1,47 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// IsRemoteAccessEnabled returns whether the given PVC has been configured to be accessed by the pods offloaded to remote clusters.
+func IsRemoteAccessEnabled(pvc *corev1.PersistentVolumeClaim) bool {
+	enabled, err := strconv.ParseBool(pvc.GetAnnotations()[consts.VolumeRemoteAccessAnnotation])
+	return err == nil && enabled
+}
+
+// IsRemoteAccessSupported returns whether the given PVC can be concurrently accessed by the pods offloaded to remote clusters,
+// i.e., whether it can be mounted by multiple nodes in read-write mode.
+func IsRemoteAccessSupported(pvc *corev1.PersistentVolumeClaim) bool {
+	for _, mode := range pvc.Spec.AccessModes {
+		if mode == corev1.ReadWriteMany {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteAccessEndpoint returns the address (as seen from the local cluster) of the gateway exposing the given PVC,
+// and whether it is already available.
+func RemoteAccessEndpoint(pvc *corev1.PersistentVolumeClaim) (string, bool) {
+	endpoint, found := pvc.GetAnnotations()[consts.VolumeRemoteAccessEndpointAnnotation]
+	return endpoint, found && endpoint != ""
+}
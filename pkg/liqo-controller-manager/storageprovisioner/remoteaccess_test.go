@@ -0,0 +1,63 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Remote access helpers", func() {
+	claim := func(annotations map[string]string, modes ...corev1.PersistentVolumeAccessMode) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Spec:       corev1.PersistentVolumeClaimSpec{AccessModes: modes},
+		}
+	}
+
+	DescribeTable("the IsRemoteAccessEnabled function",
+		func(annotations map[string]string, expected bool) {
+			Expect(IsRemoteAccessEnabled(claim(annotations))).To(Equal(expected))
+		},
+		Entry("no annotations", nil, false),
+		Entry("annotation set to false", map[string]string{consts.VolumeRemoteAccessAnnotation: "false"}, false),
+		Entry("annotation set to an invalid value", map[string]string{consts.VolumeRemoteAccessAnnotation: "foo"}, false),
+		Entry("annotation set to true", map[string]string{consts.VolumeRemoteAccessAnnotation: "true"}, true),
+	)
+
+	DescribeTable("the IsRemoteAccessSupported function",
+		func(modes []corev1.PersistentVolumeAccessMode, expected bool) {
+			Expect(IsRemoteAccessSupported(claim(nil, modes...))).To(Equal(expected))
+		},
+		Entry("ReadWriteOnce", []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, false),
+		Entry("ReadWriteMany", []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce, corev1.ReadWriteMany}, true),
+	)
+
+	Describe("the RemoteAccessEndpoint function", func() {
+		It("should return the published endpoint", func() {
+			endpoint, found := RemoteAccessEndpoint(claim(map[string]string{consts.VolumeRemoteAccessEndpointAnnotation: "10.96.0.42"}))
+			Expect(found).To(BeTrue())
+			Expect(endpoint).To(Equal("10.96.0.42"))
+		})
+		It("should report the endpoint as not available if not published", func() {
+			_, found := RemoteAccessEndpoint(claim(nil))
+			Expect(found).To(BeFalse())
+		})
+	})
+})
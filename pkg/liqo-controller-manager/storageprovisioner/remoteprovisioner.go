@@ -23,6 +23,7 @@ import (
 	v1apply "k8s.io/client-go/applyconfigurations/core/v1"
 	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/controller"
 
 	"github.com/liqotech/liqo/pkg/utils/maps"
@@ -97,6 +98,14 @@ func ProvisionRemotePVC(ctx context.Context,
 	return pv, controller.ProvisioningFinished, nil
 }
 
+// ExpandRemotePVC propagates the storage request of the given virtual PVC to the corresponding remote one.
+func ExpandRemotePVC(ctx context.Context, virtualPvc, remotePvc *corev1.PersistentVolumeClaim,
+	remotePvcClient corev1clients.PersistentVolumeClaimInterface) error {
+	mutation := remotePersistentVolumeClaim(virtualPvc, pointer.StringDeref(remotePvc.Spec.StorageClassName, ""), remotePvc.GetNamespace())
+	_, err := remotePvcClient.Apply(ctx, mutation, forge.ApplyOptions())
+	return err
+}
+
 // remotePersistentVolumeClaim forges the apply patch for the reflected PersistentVolumeClaim, given the local one.
 func remotePersistentVolumeClaim(virtualPvc *corev1.PersistentVolumeClaim,
 	storageClass, namespace string) *v1apply.PersistentVolumeClaimApplyConfiguration {
@@ -123,6 +132,12 @@ func remotePersistentVolumeClaimSpec(virtualPvc *corev1.PersistentVolumeClaim,
 		res.WithStorageClassName(storageClass)
 	}
 
+	// The virtual VolumeSnapshots are reflected with the same name in the remote namespace.
+	if dataSource := virtualPvc.Spec.DataSource; IsSnapshotDataSource(dataSource) {
+		res.WithDataSource(v1apply.TypedLocalObjectReference().
+			WithAPIGroup(*dataSource.APIGroup).WithKind(dataSource.Kind).WithName(dataSource.Name))
+	}
+
 	return res
 }
 
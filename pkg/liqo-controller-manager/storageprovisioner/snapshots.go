@@ -0,0 +1,104 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+const (
+	// VolumeSnapshotKind is the kind of the VolumeSnapshot resource.
+	VolumeSnapshotKind = "VolumeSnapshot"
+	// VolumeSnapshotContentBoundFinalizer is the finalizer added by the snapshot controller to the VolumeSnapshotContents
+	// bound to a VolumeSnapshot, which is expected to be removed by the driver once the backing snapshot has been released.
+	VolumeSnapshotContentBoundFinalizer = "snapshot.storage.kubernetes.io/volumesnapshotcontent-bound-protection"
+
+	snapshotContentPrefix = "snapcontent-"
+)
+
+// IsVirtualSnapshotClass returns whether the given VolumeSnapshotClass refers to the liqo virtual storage.
+func IsVirtualSnapshotClass(class *snapshotv1.VolumeSnapshotClass) bool {
+	return class.Driver == consts.StorageProvisionerName
+}
+
+// IsSnapshotDataSource returns whether the given data source refers to a VolumeSnapshot.
+func IsSnapshotDataSource(dataSource *corev1.TypedLocalObjectReference) bool {
+	return dataSource != nil && dataSource.Kind == VolumeSnapshotKind &&
+		dataSource.APIGroup != nil && *dataSource.APIGroup == snapshotv1.GroupName
+}
+
+// VirtualSnapshotContentName returns the name of the VolumeSnapshotContent bound to the given virtual VolumeSnapshot,
+// which matches the one that would be generated by the snapshot controller.
+func VirtualSnapshotContentName(snapshot *snapshotv1.VolumeSnapshot) string {
+	return snapshotContentPrefix + string(snapshot.GetUID())
+}
+
+// ForgeVirtualSnapshotContent forges the VolumeSnapshotContent bound to the given virtual VolumeSnapshot,
+// acting as the driver of the virtual storage class. The volume handle identifies the real PVC being snapshotted.
+func ForgeVirtualSnapshotContent(snapshot *snapshotv1.VolumeSnapshot, class *snapshotv1.VolumeSnapshotClass,
+	volumeHandle string, labels map[string]string) *snapshotv1.VolumeSnapshotContent {
+	return &snapshotv1.VolumeSnapshotContent{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   VirtualSnapshotContentName(snapshot),
+			Labels: labels,
+		},
+		Spec: snapshotv1.VolumeSnapshotContentSpec{
+			VolumeSnapshotRef: corev1.ObjectReference{
+				Kind:            VolumeSnapshotKind,
+				APIVersion:      snapshotv1.SchemeGroupVersion.String(),
+				Namespace:       snapshot.GetNamespace(),
+				Name:            snapshot.GetName(),
+				UID:             snapshot.GetUID(),
+				ResourceVersion: snapshot.GetResourceVersion(),
+			},
+			DeletionPolicy:          class.DeletionPolicy,
+			Driver:                  class.Driver,
+			VolumeSnapshotClassName: pointer.String(class.GetName()),
+			Source:                  snapshotv1.VolumeSnapshotContentSource{VolumeHandle: pointer.String(volumeHandle)},
+		},
+	}
+}
+
+// ForgeVirtualSnapshotContentStatus forges the status of a virtual VolumeSnapshotContent, given the one of the real
+// VolumeSnapshot backing it. The snapshot handle identifies the real VolumeSnapshot.
+func ForgeVirtualSnapshotContentStatus(snapshotHandle string, real *snapshotv1.VolumeSnapshot) *snapshotv1.VolumeSnapshotContentStatus {
+	status := &snapshotv1.VolumeSnapshotContentStatus{
+		SnapshotHandle: pointer.String(snapshotHandle),
+		ReadyToUse:     pointer.Bool(false),
+	}
+
+	if real.Status == nil {
+		return status
+	}
+
+	if real.Status.ReadyToUse != nil {
+		status.ReadyToUse = pointer.Bool(*real.Status.ReadyToUse)
+	}
+	if real.Status.CreationTime != nil {
+		status.CreationTime = pointer.Int64(real.Status.CreationTime.UnixNano())
+	}
+	if real.Status.RestoreSize != nil {
+		status.RestoreSize = pointer.Int64(real.Status.RestoreSize.Value())
+	}
+	if real.Status.Error != nil {
+		status.Error = real.Status.Error.DeepCopy()
+	}
+	return status
+}
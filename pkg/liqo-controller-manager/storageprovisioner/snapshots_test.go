@@ -0,0 +1,84 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storageprovisioner
+
+import (
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("VolumeSnapshot helpers", func() {
+	Describe("the IsSnapshotDataSource function", func() {
+		DescribeTable("should correctly identify VolumeSnapshot data sources",
+			func(dataSource *corev1.TypedLocalObjectReference, expected bool) {
+				Expect(IsSnapshotDataSource(dataSource)).To(Equal(expected))
+			},
+			Entry("nil data source", nil, false),
+			Entry("PVC data source", &corev1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: "foo"}, false),
+			Entry("VolumeSnapshot data source", &corev1.TypedLocalObjectReference{
+				APIGroup: pointer.String(snapshotv1.GroupName), Kind: VolumeSnapshotKind, Name: "foo"}, true),
+		)
+	})
+
+	Describe("the ForgeVirtualSnapshotContentStatus function", func() {
+		var (
+			real   snapshotv1.VolumeSnapshot
+			status *snapshotv1.VolumeSnapshotContentStatus
+		)
+
+		JustBeforeEach(func() { status = ForgeVirtualSnapshotContentStatus("namespace/name", &real) })
+
+		When("the real VolumeSnapshot has no status", func() {
+			BeforeEach(func() { real = snapshotv1.VolumeSnapshot{} })
+
+			It("should set the snapshot handle", func() { Expect(status.SnapshotHandle).To(PointTo(Equal("namespace/name"))) })
+			It("should report the snapshot as not ready", func() { Expect(status.ReadyToUse).To(PointTo(BeFalse())) })
+		})
+
+		When("the real VolumeSnapshot is ready", func() {
+			creation := metav1.NewTime(time.Now().Truncate(time.Second))
+
+			BeforeEach(func() {
+				size := resource.MustParse("1Gi")
+				real = snapshotv1.VolumeSnapshot{Status: &snapshotv1.VolumeSnapshotStatus{
+					ReadyToUse: pointer.Bool(true), CreationTime: &creation, RestoreSize: &size}}
+			})
+
+			It("should report the snapshot as ready", func() { Expect(status.ReadyToUse).To(PointTo(BeTrue())) })
+			It("should set the creation time", func() { Expect(status.CreationTime).To(PointTo(Equal(creation.UnixNano()))) })
+			It("should set the restore size", func() { Expect(status.RestoreSize).To(PointTo(BeEquivalentTo(1 << 30))) })
+		})
+	})
+
+	Describe("the remotePersistentVolumeClaimSpec function", func() {
+		It("should preserve the VolumeSnapshot data sources", func() {
+			pvc := &corev1.PersistentVolumeClaim{Spec: corev1.PersistentVolumeClaimSpec{
+				DataSource: &corev1.TypedLocalObjectReference{APIGroup: pointer.String(snapshotv1.GroupName), Kind: VolumeSnapshotKind, Name: "foo"},
+			}}
+			spec := remotePersistentVolumeClaimSpec(pvc, "")
+			Expect(spec.DataSource).ToNot(BeNil())
+			Expect(spec.DataSource.Name).To(PointTo(Equal("foo")))
+			Expect(spec.DataSource.Kind).To(PointTo(Equal(VolumeSnapshotKind)))
+		})
+	})
+})
@@ -0,0 +1,167 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumeexpansionctrl
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
+)
+
+// Reconciler reconciles the expansion of the virtual PVCs of the Liqo storage class, whose volumes are stored in the local cluster.
+type Reconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	// VirtualStorageClassName is the name of the Liqo virtual storage class.
+	VirtualStorageClassName string
+	// StorageNamespace is the namespace hosting the real PVCs.
+	StorageNamespace string
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;update
+
+// Reconcile propagates the storage request of an expanded virtual PVC to the corresponding real one, and reflects
+// the progress of the resize operation back to the virtual PV and PVC, as the underlying storage driver expands the volume.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, req.NamespacedName, &pvc); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("PersistentVolumeClaim %q not found", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve PersistentVolumeClaim %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if pointer.StringDeref(pvc.Spec.StorageClassName, "") != r.VirtualStorageClassName || !liqostorageprovisioner.IsExpansionRequired(&pvc) {
+		return ctrl.Result{}, nil
+	}
+
+	// The real PVC is named after the UID of the virtual one, and it is present only if stored in the local cluster.
+	// Otherwise, the expansion is handled by the virtual kubelet responsible for the virtual node the PVC is bound to.
+	var realPvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.StorageNamespace, Name: string(pvc.UID)}, &realPvc); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("Skipping PersistentVolumeClaim %q, as the corresponding volume is not stored in the local cluster", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve the real PersistentVolumeClaim of %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.enforceRealRequest(ctx, &pvc, &realPvc); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status, capacity := liqostorageprovisioner.ForgeExpansionStatus(&pvc, &realPvc)
+	if capacity != nil {
+		if err := r.enforceVolumeCapacity(ctx, &pvc, *capacity); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(&pvc.Status, status) {
+		pvc.Status = *status
+		if err := r.Status().Update(ctx, &pvc); err != nil {
+			klog.Errorf("Failed to update the status of PersistentVolumeClaim %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+		klog.Infof("Status of PersistentVolumeClaim %q updated to reflect the expansion progress", req.NamespacedName)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// enforceRealRequest propagates the storage request of the virtual PVC to the real one, if not already done.
+func (r *Reconciler) enforceRealRequest(ctx context.Context, pvc, realPvc *corev1.PersistentVolumeClaim) error {
+	if liqostorageprovisioner.IsExpansionPropagated(pvc, realPvc) {
+		return nil
+	}
+
+	original := realPvc.DeepCopy()
+	if realPvc.Spec.Resources.Requests == nil {
+		realPvc.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	realPvc.Spec.Resources.Requests[corev1.ResourceStorage] = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+
+	if err := r.Patch(ctx, realPvc, client.MergeFrom(original)); err != nil {
+		klog.Errorf("Failed to expand the real PersistentVolumeClaim %q: %v", klog.KObj(realPvc), err)
+		r.Recorder.Eventf(pvc, corev1.EventTypeWarning, "VolumeResizeFailed", "Failed to expand the real volume: %v", err)
+		return err
+	}
+
+	klog.Infof("Real PersistentVolumeClaim %q of %q expanded", klog.KObj(realPvc), klog.KObj(pvc))
+	r.Recorder.Event(pvc, corev1.EventTypeNormal, "Resizing", "Expansion propagated to the real volume")
+	return nil
+}
+
+// enforceVolumeCapacity increases the capacity of the virtual PV bound to the given PVC, once the real volume has been expanded.
+func (r *Reconciler) enforceVolumeCapacity(ctx context.Context, pvc *corev1.PersistentVolumeClaim, capacity resource.Quantity) error {
+	var pv corev1.PersistentVolume
+	if err := r.Get(ctx, client.ObjectKey{Name: pvc.Spec.VolumeName}, &pv); err != nil {
+		klog.Errorf("Failed to retrieve the PersistentVolume of %q: %v", klog.KObj(pvc), err)
+		return err
+	}
+
+	if !liqostorageprovisioner.EnsureVolumeCapacity(&pv, capacity) {
+		return nil
+	}
+
+	if err := r.Update(ctx, &pv); err != nil {
+		klog.Errorf("Failed to expand the PersistentVolume of %q: %v", klog.KObj(pvc), err)
+		return err
+	}
+
+	klog.Infof("PersistentVolume %q of %q expanded to %v", pv.Name, klog.KObj(pvc), capacity.String())
+	return nil
+}
+
+// SetupWithManager registers a new controller for the expansion of the virtual PVCs of the Liqo storage class.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// The real PVCs trigger the reconciliation of the corresponding virtual ones.
+	realPvcEnqueuer := func(obj client.Object) []reconcile.Request {
+		namespace, nsok := obj.GetLabels()[consts.VirtualPvcNamespaceLabel]
+		name, nameok := obj.GetLabels()[consts.VirtualPvcNameLabel]
+		if obj.GetNamespace() != r.StorageNamespace || !nsok || !nameok {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("volumeexpansion").
+		For(&corev1.PersistentVolumeClaim{}).
+		Watches(&source.Kind{Type: &corev1.PersistentVolumeClaim{}}, handler.EnqueueRequestsFromMapFunc(realPvcEnqueuer)).
+		Complete(r)
+}
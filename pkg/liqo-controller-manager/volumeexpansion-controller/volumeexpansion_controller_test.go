@@ -0,0 +1,174 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumeexpansionctrl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("VolumeExpansion controller", func() {
+	const (
+		namespace        = "default"
+		name             = "data"
+		storageNamespace = "liqo-storage"
+		pvcUID           = "2c26b46b-68ff-c68f-f99b-453c1d304134"
+		pvName           = "pvc-" + pvcUID
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		reconciler *Reconciler
+		key        types.NamespacedName
+
+		pvc, realPvc *corev1.PersistentVolumeClaim
+		pv           *corev1.PersistentVolume
+
+		err error
+	)
+
+	storage := func(quantity string) corev1.ResourceList {
+		return corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(quantity)}
+	}
+
+	reconcile := func() {
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	get := func(obj client.Object, namespace, name string) client.Object {
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, obj)).To(Succeed())
+		return obj
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Namespace: namespace, Name: name}
+
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: pvcUID},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: pointer.String("liqo"), VolumeName: pvName,
+				Resources: corev1.ResourceRequirements{Requests: storage("2Gi")},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound, Capacity: storage("1Gi")},
+		}
+
+		realPvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: pvcUID, Namespace: storageNamespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: pointer.String("standard"), VolumeName: "pv-real",
+				Resources: corev1.ResourceRequirements{Requests: storage("1Gi")},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound, Capacity: storage("1Gi")},
+		}
+
+		pv = &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: pvName},
+			Spec:       corev1.PersistentVolumeSpec{StorageClassName: "liqo", Capacity: storage("1Gi")},
+		}
+	})
+
+	JustBeforeEach(func() {
+		cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(pvc, realPvc, pv).Build()
+		reconciler = &Reconciler{
+			Client:                  cl,
+			Recorder:                record.NewFakeRecorder(10),
+			VirtualStorageClassName: "liqo",
+			StorageNamespace:        storageNamespace,
+		}
+		reconcile()
+	})
+
+	When("the PersistentVolumeClaim does not belong to the virtual storage class", func() {
+		BeforeEach(func() { pvc.Spec.StorageClassName = pointer.String("standard") })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not modify the real PersistentVolumeClaim", func() {
+			Expect(get(&corev1.PersistentVolumeClaim{}, storageNamespace, pvcUID).(*corev1.PersistentVolumeClaim).
+				Spec.Resources.Requests).To(Equal(storage("1Gi")))
+		})
+	})
+
+	When("the corresponding volume is not stored in the local cluster", func() {
+		BeforeEach(func() { realPvc.Name = "other" })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not modify the virtual PersistentVolume", func() {
+			Expect(get(&corev1.PersistentVolume{}, "", pvName).(*corev1.PersistentVolume).Spec.Capacity).To(Equal(storage("1Gi")))
+		})
+	})
+
+	When("the PersistentVolumeClaim has been expanded", func() {
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should propagate the request to the real PersistentVolumeClaim", func() {
+			Expect(get(&corev1.PersistentVolumeClaim{}, storageNamespace, pvcUID).(*corev1.PersistentVolumeClaim).
+				Spec.Resources.Requests).To(Equal(storage("2Gi")))
+		})
+		It("should not yet modify the virtual PersistentVolume", func() {
+			Expect(get(&corev1.PersistentVolume{}, "", pvName).(*corev1.PersistentVolume).Spec.Capacity).To(Equal(storage("1Gi")))
+		})
+	})
+
+	When("the file system resize of the real volume is pending", func() {
+		BeforeEach(func() {
+			realPvc.Spec.Resources.Requests = storage("2Gi")
+			realPvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+				Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue}}
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should expand the virtual PersistentVolume", func() {
+			Expect(get(&corev1.PersistentVolume{}, "", pvName).(*corev1.PersistentVolume).Spec.Capacity).To(Equal(storage("2Gi")))
+		})
+		It("should reflect the resize condition", func() {
+			status := get(&corev1.PersistentVolumeClaim{}, namespace, name).(*corev1.PersistentVolumeClaim).Status
+			Expect(status.Capacity).To(Equal(storage("1Gi")))
+			Expect(status.Conditions).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"Type": Equal(corev1.PersistentVolumeClaimFileSystemResizePending)})))
+		})
+	})
+
+	When("the real volume has been completely resized", func() {
+		BeforeEach(func() {
+			realPvc.Spec.Resources.Requests = storage("2Gi")
+			realPvc.Status.Capacity = storage("2Gi")
+			pvc.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+				Type: corev1.PersistentVolumeClaimResizing, Status: corev1.ConditionTrue}}
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should expand the virtual PersistentVolume", func() {
+			Expect(get(&corev1.PersistentVolume{}, "", pvName).(*corev1.PersistentVolume).Spec.Capacity).To(Equal(storage("2Gi")))
+		})
+		It("should reflect the new capacity", func() {
+			status := get(&corev1.PersistentVolumeClaim{}, namespace, name).(*corev1.PersistentVolumeClaim).Status
+			Expect(status.Capacity).To(Equal(storage("2Gi")))
+			Expect(status.Conditions).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,92 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumegatewayctrl
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+const (
+	nfsServerImage = "itsthenetwork/nfs-server-alpine:12"
+	nfsPort        = 2049
+
+	gatewayDataVolume = "data"
+	gatewayExportPath = "/exports"
+)
+
+// GatewayName returns the name of the gateway exposing the given PVC.
+func GatewayName(pvc *corev1.PersistentVolumeClaim) string {
+	return "volume-gateway-" + string(pvc.GetUID())
+}
+
+// gatewayLabels returns the labels identifying the gateway exposing the given PVC.
+func gatewayLabels(pvc *corev1.PersistentVolumeClaim) map[string]string {
+	return map[string]string{liqoconst.VolumeGatewayLabel: string(pvc.GetUID())}
+}
+
+// mutateGatewayService configures the service exposing the NFS gateway of the given PVC.
+func mutateGatewayService(svc *corev1.Service, pvc *corev1.PersistentVolumeClaim) {
+	labels := gatewayLabels(pvc)
+	svc.SetLabels(labels)
+	svc.Spec.Type = corev1.ServiceTypeClusterIP
+	svc.Spec.Selector = labels
+	svc.Spec.Ports = []corev1.ServicePort{{
+		Name:       "nfs",
+		Port:       nfsPort,
+		TargetPort: intstr.FromInt(nfsPort),
+		Protocol:   corev1.ProtocolTCP,
+	}}
+}
+
+// mutateGatewayDeployment configures the deployment of the NFS gateway exposing the given PVC.
+func mutateGatewayDeployment(deployment *appsv1.Deployment, pvc *corev1.PersistentVolumeClaim) {
+	labels := gatewayLabels(pvc)
+	deployment.SetLabels(labels)
+	deployment.Spec.Replicas = pointer.Int32(1)
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	// Prevent two NFS servers from concurrently exporting the same volume during updates.
+	deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	deployment.Spec.Template = forgeGatewayPodTemplate(labels, pvc)
+}
+
+// forgeGatewayPodTemplate forges the pod template of the NFS gateway, which mounts the given PVC and exports it over NFSv4.
+func forgeGatewayPodTemplate(labels map[string]string, pvc *corev1.PersistentVolumeClaim) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:  "nfs-server",
+				Image: nfsServerImage,
+				Env:   []corev1.EnvVar{{Name: "SHARED_DIRECTORY", Value: gatewayExportPath}},
+				Ports: []corev1.ContainerPort{{Name: "nfs", ContainerPort: nfsPort, Protocol: corev1.ProtocolTCP}},
+				// The NFS server requires the privileges to mount the nfsd file system and export the directory.
+				SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+				VolumeMounts:    []corev1.VolumeMount{{Name: gatewayDataVolume, MountPath: gatewayExportPath}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: gatewayDataVolume,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.GetName()},
+				},
+			}},
+		},
+	}
+}
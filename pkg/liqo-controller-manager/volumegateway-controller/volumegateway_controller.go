@@ -0,0 +1,168 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumegatewayctrl
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
+)
+
+// Reconciler reconciles the PVCs to be accessed by the pods offloaded to remote clusters, enforcing the corresponding NFS gateways.
+type Reconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;delete
+
+// Reconcile ensures that the NFS gateway exposing a PVC with remote access enabled exists, and that its address is
+// published on the PVC itself, to be leveraged by the virtual kubelets when offloading the pods mounting that volume.
+// Conversely, it tears down the gateway once the remote access is disabled.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, req.NamespacedName, &pvc); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The gateway, if any, is garbage collected through the owner references.
+			klog.V(4).Infof("PersistentVolumeClaim %q not found", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve PersistentVolumeClaim %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if !liqostorageprovisioner.IsRemoteAccessEnabled(&pvc) || !pvc.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.disableRemoteAccess(ctx, &pvc)
+	}
+
+	if !liqostorageprovisioner.IsRemoteAccessSupported(&pvc) {
+		klog.Warningf("Cannot enable the remote access to PersistentVolumeClaim %q, as not %s", req.NamespacedName, corev1.ReadWriteMany)
+		r.Recorder.Eventf(&pvc, corev1.EventTypeWarning, "RemoteAccessUnsupported",
+			"The remote access requires the %s access mode", corev1.ReadWriteMany)
+		return ctrl.Result{}, r.disableRemoteAccess(ctx, &pvc)
+	}
+
+	svc, err := r.enforceGateway(ctx, &pvc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, r.enforceEndpoint(ctx, &pvc, svc.Spec.ClusterIP)
+}
+
+// enforceGateway ensures the existence of the NFS gateway exposing the given PVC, and returns the corresponding service.
+func (r *Reconciler) enforceGateway(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*corev1.Service, error) {
+	name := GatewayName(pvc)
+
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: pvc.GetNamespace()}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, deployment, func() error {
+		mutateGatewayDeployment(deployment, pvc)
+		return controllerutil.SetControllerReference(pvc, deployment, r.Scheme)
+	}); err != nil {
+		klog.Errorf("Failed to enforce the volume gateway deployment %q: %v", klog.KObj(deployment), err)
+		return nil, err
+	}
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: pvc.GetNamespace()}}
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, svc, func() error {
+		mutateGatewayService(svc, pvc)
+		return controllerutil.SetControllerReference(pvc, svc, r.Scheme)
+	})
+	if err != nil {
+		klog.Errorf("Failed to enforce the volume gateway service %q: %v", klog.KObj(svc), err)
+		return nil, err
+	}
+
+	if result != controllerutil.OperationResultNone {
+		klog.Infof("Volume gateway of PersistentVolumeClaim %q correctly enforced", klog.KObj(pvc))
+		r.Recorder.Event(pvc, corev1.EventTypeNormal, "RemoteAccessEnabled", "Volume gateway correctly enforced")
+	}
+	return svc, nil
+}
+
+// enforceEndpoint publishes the address of the gateway on the given PVC, or removes it in case the address is empty.
+func (r *Reconciler) enforceEndpoint(ctx context.Context, pvc *corev1.PersistentVolumeClaim, endpoint string) error {
+	if current := pvc.GetAnnotations()[liqoconst.VolumeRemoteAccessEndpointAnnotation]; current == endpoint {
+		return nil
+	}
+
+	original := pvc.DeepCopy()
+	if endpoint == "" {
+		delete(pvc.Annotations, liqoconst.VolumeRemoteAccessEndpointAnnotation)
+	} else {
+		metav1.SetMetaDataAnnotation(&pvc.ObjectMeta, liqoconst.VolumeRemoteAccessEndpointAnnotation, endpoint)
+	}
+
+	if err := r.Patch(ctx, pvc, client.MergeFrom(original)); err != nil {
+		klog.Errorf("Failed to update the remote access endpoint of PersistentVolumeClaim %q: %v", klog.KObj(pvc), err)
+		return err
+	}
+
+	klog.Infof("Remote access endpoint of PersistentVolumeClaim %q set to %q", klog.KObj(pvc), endpoint)
+	return nil
+}
+
+// disableRemoteAccess ensures the absence of the NFS gateway exposing the given PVC, as well as of the published endpoint.
+func (r *Reconciler) disableRemoteAccess(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	if err := r.enforceEndpoint(ctx, pvc, ""); err != nil {
+		return err
+	}
+
+	name := GatewayName(pvc)
+	for _, obj := range []client.Object{&corev1.Service{}, &appsv1.Deployment{}} {
+		// Retrieve the object from the cache first, to prevent issuing useless delete requests for the PVCs never exposed.
+		if err := r.Get(ctx, client.ObjectKey{Namespace: pvc.GetNamespace(), Name: name}, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			klog.Errorf("Failed to retrieve %T %q: %v", obj, klog.KRef(pvc.GetNamespace(), name), err)
+			return err
+		}
+
+		if err := client.IgnoreNotFound(r.Delete(ctx, obj)); err != nil {
+			klog.Errorf("Failed to delete %T %q: %v", obj, klog.KObj(obj), err)
+			return err
+		}
+		klog.Infof("Volume gateway %T %q of PersistentVolumeClaim %q correctly deleted", obj, klog.KObj(obj), klog.KObj(pvc))
+	}
+
+	return nil
+}
+
+// SetupWithManager registers a new controller for the PVCs to be accessed by the pods offloaded to remote clusters.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("volumegateway").
+		For(&corev1.PersistentVolumeClaim{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Complete(r)
+}
@@ -0,0 +1,152 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumegatewayctrl
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("VolumeGateway controller", func() {
+	const (
+		namespace   = "default"
+		name        = "shared"
+		pvcUID      = "2c26b46b-68ff-c68f-f99b-453c1d304134"
+		gatewayName = "volume-gateway-" + pvcUID
+		clusterIP   = "10.96.0.42"
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		reconciler *Reconciler
+		key        types.NamespacedName
+
+		pvc     *corev1.PersistentVolumeClaim
+		objects []client.Object
+
+		err error
+	)
+
+	reconcile := func() {
+		_, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	getPvc := func() *corev1.PersistentVolumeClaim {
+		var pvc corev1.PersistentVolumeClaim
+		Expect(cl.Get(ctx, key, &pvc)).To(Succeed())
+		return &pvc
+	}
+
+	getGateway := func() (*appsv1.Deployment, *corev1.Service, error) {
+		var deployment appsv1.Deployment
+		var svc corev1.Service
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: gatewayName}, &deployment); err != nil {
+			return nil, nil, err
+		}
+		if err := cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: gatewayName}, &svc); err != nil {
+			return nil, nil, err
+		}
+		return &deployment, &svc, nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Namespace: namespace, Name: name}
+
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: pvcUID,
+				Annotations: map[string]string{consts.VolumeRemoteAccessAnnotation: "true"}},
+			Spec: corev1.PersistentVolumeClaimSpec{AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}},
+		}
+
+		// The fake client does not allocate the cluster IP, hence the service is created in advance.
+		objects = []client.Object{&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: gatewayName, Namespace: namespace},
+			Spec:       corev1.ServiceSpec{ClusterIP: clusterIP},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, pvc)...).Build()
+		reconciler = &Reconciler{Client: cl, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+		reconcile()
+	})
+
+	When("the remote access is enabled", func() {
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should create the gateway mounting the PersistentVolumeClaim", func() {
+			deployment, svc, err := getGateway()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deployment.Spec.Template.Spec.Volumes).To(ConsistOf(MatchFields(IgnoreExtras, Fields{
+				"VolumeSource": MatchFields(IgnoreExtras, Fields{
+					"PersistentVolumeClaim": PointTo(MatchFields(IgnoreExtras, Fields{"ClaimName": Equal(name)})),
+				}),
+			})))
+			Expect(deployment.Spec.Template.GetLabels()).To(HaveKeyWithValue(consts.VolumeGatewayLabel, pvcUID))
+			Expect(svc.Spec.Selector).To(Equal(deployment.Spec.Template.GetLabels()))
+			Expect(metav1.IsControlledBy(deployment, pvc)).To(BeTrue())
+			Expect(metav1.IsControlledBy(svc, pvc)).To(BeTrue())
+		})
+		It("should publish the gateway endpoint", func() {
+			Expect(getPvc().GetAnnotations()).To(HaveKeyWithValue(consts.VolumeRemoteAccessEndpointAnnotation, clusterIP))
+		})
+	})
+
+	When("the PersistentVolumeClaim does not support concurrent accesses", func() {
+		BeforeEach(func() { pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce} })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not create the gateway", func() {
+			_, _, err := getGateway()
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+		It("should not publish the gateway endpoint", func() {
+			Expect(getPvc().GetAnnotations()).ToNot(HaveKey(consts.VolumeRemoteAccessEndpointAnnotation))
+		})
+	})
+
+	When("the remote access has been disabled", func() {
+		BeforeEach(func() {
+			pvc.Annotations = map[string]string{consts.VolumeRemoteAccessEndpointAnnotation: clusterIP}
+			objects = append(objects, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: gatewayName, Namespace: namespace}})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should delete the gateway", func() {
+			var deployment appsv1.Deployment
+			Expect(kerrors.IsNotFound(cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: gatewayName}, &deployment))).To(BeTrue())
+			var svc corev1.Service
+			Expect(kerrors.IsNotFound(cl.Get(ctx, client.ObjectKey{Namespace: namespace, Name: gatewayName}, &svc))).To(BeTrue())
+		})
+		It("should remove the gateway endpoint", func() {
+			Expect(getPvc().GetAnnotations()).ToNot(HaveKey(consts.VolumeRemoteAccessEndpointAnnotation))
+		})
+	})
+})
@@ -0,0 +1,292 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumereplicationctrl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils"
+)
+
+const (
+	resticServerImage = "restic/rest-server:0.11.0"
+	resticPort        = 8000
+
+	registryDataVolume = "data"
+	selectedNodeAnnot  = "volume.kubernetes.io/selected-node"
+)
+
+// RegistryName returns the name of the restic registry associated with the given VolumeReplication.
+func RegistryName(replication *offv1alpha1.VolumeReplication) string {
+	return fmt.Sprintf("restic-%s", replication.GetUID())
+}
+
+// registryLabels returns the labels identifying the restic registry associated with the given VolumeReplication.
+func registryLabels(replication *offv1alpha1.VolumeReplication) map[string]string {
+	return map[string]string{
+		liqoconst.VolumeReplicationNamespaceLabel: replication.Namespace,
+		liqoconst.VolumeReplicationNameLabel:      replication.Name,
+	}
+}
+
+// sourceNode returns the node the given PVC is currently stored on.
+func (r *Reconciler) sourceNode(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*corev1.Node, error) {
+	nodeName, found := pvc.Annotations[selectedNodeAnnot]
+	if !found || pvc.Spec.VolumeName == "" {
+		return nil, fmt.Errorf("PersistentVolumeClaim %q is not yet bound", pvc.Name)
+	}
+
+	var node corev1.Node
+	if err := r.Get(ctx, client.ObjectKey{Name: nodeName}, &node); err != nil {
+		return nil, fmt.Errorf("failed to retrieve node %q hosting PersistentVolumeClaim %q: %w", nodeName, pvc.Name, err)
+	}
+	return &node, nil
+}
+
+// ensureNamespaceOffloading ensures the replication namespace exists, and it is offloaded to all the remote
+// clusters either storing a replicated volume or hosting a restic registry.
+func (r *Reconciler) ensureNamespaceOffloading(ctx context.Context) error {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: r.Namespace}}
+	if err := r.Create(ctx, namespace); err != nil && !apierrors.IsAlreadyExists(err) {
+		klog.Errorf("Failed to create the volume replication namespace %q: %v", r.Namespace, err)
+		return err
+	}
+
+	nodes, err := r.remoteNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	nsoff := &offv1alpha1.NamespaceOffloading{ObjectMeta: metav1.ObjectMeta{
+		Name: liqoconst.DefaultNamespaceOffloadingName, Namespace: r.Namespace}}
+	if len(nodes) == 0 {
+		if err := client.IgnoreNotFound(r.Delete(ctx, nsoff)); err != nil {
+			klog.Errorf("Failed to delete NamespaceOffloading %q: %v", klog.KObj(nsoff), err)
+			return err
+		}
+		return nil
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, r.Client, nsoff, func() error {
+		nsoff.Spec.NamespaceMappingStrategy = offv1alpha1.DefaultNameMappingStrategyType
+		nsoff.Spec.PodOffloadingStrategy = offv1alpha1.LocalAndRemotePodOffloadingStrategyType
+		nsoff.Spec.ClusterSelector = corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      corev1.LabelHostname,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   nodes,
+				}},
+			}},
+		}
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("Failed to enforce NamespaceOffloading %q: %v", klog.KObj(nsoff), err)
+		return err
+	}
+	klog.V(4).Infof("NamespaceOffloading %q correctly enforced (%v)", klog.KObj(nsoff), result)
+	return nil
+}
+
+// remoteNodes returns the sorted list of virtual nodes involved in at least one volume replication,
+// either as source or as target.
+func (r *Reconciler) remoteNodes(ctx context.Context) ([]string, error) {
+	var replications offv1alpha1.VolumeReplicationList
+	if err := r.List(ctx, &replications); err != nil {
+		klog.Errorf("Failed to list VolumeReplications: %v", err)
+		return nil, err
+	}
+
+	names := sets.NewString()
+	for i := range replications.Items {
+		replication := &replications.Items[i]
+		if !replication.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		names.Insert(replication.Spec.TargetNode)
+		var pvc corev1.PersistentVolumeClaim
+		if err := r.Get(ctx, client.ObjectKey{Namespace: replication.Namespace, Name: replication.Spec.PersistentVolumeClaimName}, &pvc); err == nil {
+			if node, found := pvc.Annotations[selectedNodeAnnot]; found {
+				names.Insert(node)
+			}
+		}
+	}
+
+	var nodes []string
+	for _, name := range names.List() {
+		var node corev1.Node
+		if err := r.Get(ctx, client.ObjectKey{Name: name}, &node); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			klog.Errorf("Failed to retrieve node %q: %v", name, err)
+			return nil, err
+		}
+		if utils.IsVirtualNode(&node) {
+			nodes = append(nodes, name)
+		}
+	}
+
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// ensureRegistry ensures the restic registry storing the replicas of the given volume exists, and it is
+// scheduled on the target node.
+func (r *Reconciler) ensureRegistry(ctx context.Context, replication *offv1alpha1.VolumeReplication, pvc *corev1.PersistentVolumeClaim) error {
+	name, labels := RegistryName(replication), registryLabels(replication)
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: r.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, svc, func() error {
+		svc.SetLabels(labels)
+		svc.Spec.Selector = labels
+		svc.Spec.Ports = []corev1.ServicePort{{
+			Port:       resticPort,
+			TargetPort: intstr.FromInt(resticPort),
+			Protocol:   corev1.ProtocolTCP,
+		}}
+		return nil
+	}); err != nil {
+		klog.Errorf("Failed to enforce the restic registry service %q: %v", klog.KObj(svc), err)
+		return err
+	}
+
+	statefulSet := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: r.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, statefulSet, func() error {
+		statefulSet.SetLabels(labels)
+		if statefulSet.CreationTimestamp.IsZero() {
+			// The volume claim templates are immutable, hence they are configured only at creation time.
+			statefulSet.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+			statefulSet.Spec.ServiceName = name
+			statefulSet.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: registryDataVolume},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					StorageClassName: pointer.String(r.VirtualStorageClassName),
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceStorage: pvc.Spec.Resources.Requests[corev1.ResourceStorage]},
+					},
+				},
+			}}
+		}
+		statefulSet.Spec.Replicas = pointer.Int32(1)
+		statefulSet.Spec.Template = forgeRegistryPodTemplate(labels, replication.Spec.TargetNode)
+		return nil
+	}); err != nil {
+		klog.Errorf("Failed to enforce the restic registry statefulset %q: %v", klog.KObj(statefulSet), err)
+		return err
+	}
+
+	return nil
+}
+
+// forgeRegistryPodTemplate forges the pod template of the restic registry, which is bound to the target node.
+func forgeRegistryPodTemplate(labels map[string]string, targetNode string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: labels},
+		Spec: corev1.PodSpec{
+			Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+							MatchExpressions: []corev1.NodeSelectorRequirement{{
+								Key:      corev1.LabelHostname,
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{targetNode},
+							}},
+						}},
+					},
+				},
+			},
+			Containers: []corev1.Container{{
+				Name:  "rest-server",
+				Image: resticServerImage,
+				Env: []corev1.EnvVar{
+					{Name: "DISABLE_AUTHENTICATION", Value: "1"},
+					{Name: "OPTIONS", Value: "--no-auth"},
+				},
+				Ports:        []corev1.ContainerPort{{ContainerPort: resticPort}},
+				VolumeMounts: []corev1.VolumeMount{{Name: registryDataVolume, MountPath: "/data"}},
+			}},
+		},
+	}
+}
+
+// registryReady returns whether the restic registry associated with the given VolumeReplication is ready.
+func (r *Reconciler) registryReady(ctx context.Context, replication *offv1alpha1.VolumeReplication) (bool, error) {
+	var statefulSet appsv1.StatefulSet
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: RegistryName(replication)}, &statefulSet); err != nil {
+		klog.Errorf("Failed to retrieve the restic registry of VolumeReplication %q: %v", klog.KObj(replication), err)
+		return false, err
+	}
+	return statefulSet.Status.ReadyReplicas > 0, nil
+}
+
+// deleteRegistry deletes the restic registry associated with the given VolumeReplication, including the stored data.
+func (r *Reconciler) deleteRegistry(ctx context.Context, replication *offv1alpha1.VolumeReplication) error {
+	name := RegistryName(replication)
+
+	objects := []client.Object{
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: r.Namespace}},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: r.Namespace}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s-0", registryDataVolume, name), Namespace: r.Namespace}},
+	}
+
+	for _, obj := range objects {
+		if err := client.IgnoreNotFound(r.Delete(ctx, obj)); err != nil {
+			klog.Errorf("Failed to delete %T %q: %v", obj, klog.KObj(obj), err)
+			return err
+		}
+	}
+
+	klog.Infof("Restic registry of VolumeReplication %q correctly deleted", klog.KObj(replication))
+	return nil
+}
+
+// repositoryURL returns the URL of the restic repository storing the replicas of the given volume,
+// as reachable from the cluster the given node refers to.
+func (r *Reconciler) repositoryURL(ctx context.Context, replication *offv1alpha1.VolumeReplication,
+	pvc *corev1.PersistentVolumeClaim, node *corev1.Node) (string, error) {
+	namespace := r.Namespace
+	if utils.IsVirtualNode(node) {
+		var nsoff offv1alpha1.NamespaceOffloading
+		if err := r.Get(ctx, client.ObjectKey{Namespace: r.Namespace, Name: liqoconst.DefaultNamespaceOffloadingName}, &nsoff); err != nil {
+			return "", err
+		}
+		if nsoff.Status.OffloadingPhase != offv1alpha1.ReadyOffloadingPhaseType || nsoff.Status.RemoteNamespaceName == "" {
+			return "", fmt.Errorf("namespace %q is not yet offloaded", r.Namespace)
+		}
+		namespace = nsoff.Status.RemoteNamespaceName
+	}
+
+	return fmt.Sprintf("rest:http://%s.%s.svc.cluster.local:%d/%s", RegistryName(replication), namespace, resticPort, pvc.GetUID()), nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumereplicationctrl
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+)
+
+const (
+	resticImage = "restic/restic:0.14.0"
+
+	// replicationScript initializes the restic repository (if not already present), takes an incremental snapshot
+	// of the volume, and prunes the snapshots exceeding the retention policy.
+	replicationScript = `set -e
+restic snapshots --latest 1 > /dev/null 2>&1 || restic init
+restic backup . --host liqo
+restic forget --host liqo --keep-last "${KEEP_LAST}" --prune`
+)
+
+// forgeReplicationJob forges the job replicating the given volume to the restic repository.
+func forgeReplicationJob(replication *offv1alpha1.VolumeReplication, pvc *corev1.PersistentVolumeClaim) *batchv1.Job {
+	keepLast := replication.Spec.KeepLast
+	if keepLast < 1 {
+		keepLast = 1
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-replication-", replication.Name),
+			Namespace:    replication.Namespace,
+			Labels:       registryLabels(replication),
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer.Int32(3),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: registryLabels(replication)},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            "restic",
+						Image:           resticImage,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"/bin/sh", "-c", replicationScript},
+						Env: []corev1.EnvVar{
+							{Name: "RESTIC_REPOSITORY", Value: replication.Status.SourceRepositoryURL},
+							{Name: "RESTIC_PASSWORD", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: replication.Spec.PasswordSecretRef.DeepCopy()}},
+							{Name: "KEEP_LAST", Value: fmt.Sprintf("%d", keepLast)},
+						},
+						WorkingDir:   "/backup",
+						VolumeMounts: []corev1.VolumeMount{{Name: "backup", MountPath: "/backup", ReadOnly: true}},
+					}},
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Volumes: []corev1.Volume{{
+						Name: "backup",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.GetName(), ReadOnly: true},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	// The job is owned by the VolumeReplication, so that it is garbage collected in case of deletion, and its
+	// completion triggers a new reconciliation.
+	job.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(replication,
+		offv1alpha1.GroupVersion.WithKind("VolumeReplication"))})
+	return job
+}
+
+// processJobs processes the replication jobs associated with the given VolumeReplication, updating its status
+// according to the completed ones (which are then deleted). It returns whether a replication job is still active.
+func (r *Reconciler) processJobs(ctx context.Context, replication *offv1alpha1.VolumeReplication) (active bool, err error) {
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(replication.Namespace), client.MatchingLabels(registryLabels(replication))); err != nil {
+		klog.Errorf("Failed to list the replication jobs of VolumeReplication %q: %v", klog.KObj(replication), err)
+		return false, err
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+
+		switch {
+		case job.Status.Succeeded > 0:
+			completion := job.Status.CompletionTime
+			if completion == nil {
+				completion = &metav1.Time{Time: job.CreationTimestamp.Time}
+			}
+			if last := replication.Status.LastSuccessfulReplicationTime; last == nil || completion.After(last.Time) {
+				replication.Status.LastSuccessfulReplicationTime = completion.DeepCopy()
+				r.setPhase(replication, offv1alpha1.UpToDateVolumeReplicationPhaseType, "")
+			}
+			klog.Infof("Replication job %q for VolumeReplication %q completed successfully", klog.KObj(job), klog.KObj(replication))
+		case isJobFailed(job):
+			r.setPhase(replication, offv1alpha1.FailedVolumeReplicationPhaseType, fmt.Sprintf("Replication job %q failed", job.Name))
+			klog.Warningf("Replication job %q for VolumeReplication %q failed", klog.KObj(job), klog.KObj(replication))
+		default:
+			active = true
+			continue
+		}
+
+		if err := client.IgnoreNotFound(r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))); err != nil {
+			klog.Errorf("Failed to delete replication job %q: %v", klog.KObj(job), err)
+			return false, err
+		}
+	}
+
+	return active, nil
+}
+
+// isJobFailed returns whether the given job failed.
+func isJobFailed(job *batchv1.Job) bool {
+	for i := range job.Status.Conditions {
+		condition := &job.Status.Conditions[i]
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
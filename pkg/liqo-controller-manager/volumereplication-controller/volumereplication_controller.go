@@ -0,0 +1,272 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumereplicationctrl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+)
+
+const (
+	volumeReplicationControllerFinalizer = "volumereplication-controller.liqo.io/finalizer"
+
+	// pendingRequeuePeriod is the interval after which the replication is reconciled again, while waiting for
+	// the replication infrastructure to be ready.
+	pendingRequeuePeriod = 10 * time.Second
+	// retryPeriod is the minimum interval between two consecutive replication attempts, in case of failures.
+	retryPeriod = time.Minute
+)
+
+// Reconciler reconciles VolumeReplication objects, periodically replicating the corresponding volumes
+// towards the selected standby node.
+type Reconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	// VirtualStorageClassName is the name of the Liqo virtual storage class.
+	VirtualStorageClassName string
+	// Namespace is the namespace hosting the restic repositories, which is offloaded to the involved clusters.
+	Namespace string
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=volumereplications,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=volumereplications/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=volumereplications/finalizers,verbs=get;update;patch
+// +kubebuilder:rbac:groups=offloading.liqo.io,resources=namespaceoffloadings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+// Reconcile ensures the replication infrastructure associated with the given VolumeReplication is present,
+// and periodically starts the replication jobs according to the configured recovery point objective.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var replication offv1alpha1.VolumeReplication
+	if err := r.Get(ctx, req.NamespacedName, &replication); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("VolumeReplication %q not found", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve VolumeReplication %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if !replication.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.handleDeletion(ctx, &replication)
+	}
+
+	if !controllerutil.ContainsFinalizer(&replication, volumeReplicationControllerFinalizer) {
+		original := replication.DeepCopy()
+		controllerutil.AddFinalizer(&replication, volumeReplicationControllerFinalizer)
+		if err := r.Patch(ctx, &replication, client.MergeFrom(original)); err != nil {
+			klog.Errorf("Failed to add the finalizer to VolumeReplication %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+	}
+
+	original := replication.DeepCopy()
+	result, err := r.reconcileReplication(ctx, &replication)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	replication.Status.ObservedGeneration = replication.Generation
+	if !equality.Semantic.DeepEqual(original.Status, replication.Status) {
+		if err := r.Status().Patch(ctx, &replication, client.MergeFrom(original)); err != nil {
+			klog.Errorf("Failed to update the status of VolumeReplication %q: %v", req.NamespacedName, err)
+			return ctrl.Result{}, err
+		}
+		klog.V(4).Infof("Status of VolumeReplication %q correctly updated (phase: %v)", req.NamespacedName, replication.Status.Phase)
+	}
+
+	return result, nil
+}
+
+// reconcileReplication performs the actual reconciliation of the given VolumeReplication, updating its status.
+func (r *Reconciler) reconcileReplication(ctx context.Context, replication *offv1alpha1.VolumeReplication) (ctrl.Result, error) {
+	var pvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, client.ObjectKey{Namespace: replication.Namespace, Name: replication.Spec.PersistentVolumeClaimName}, &pvc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to retrieve the PVC of VolumeReplication %q: %v", klog.KObj(replication), err)
+			return ctrl.Result{}, err
+		}
+		r.setPhase(replication, offv1alpha1.FailedVolumeReplicationPhaseType,
+			fmt.Sprintf("PersistentVolumeClaim %q not found", replication.Spec.PersistentVolumeClaimName))
+		return ctrl.Result{RequeueAfter: retryPeriod}, nil
+	}
+
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != r.VirtualStorageClassName {
+		r.setPhase(replication, offv1alpha1.FailedVolumeReplicationPhaseType,
+			fmt.Sprintf("PersistentVolumeClaim %q does not belong to the %q storage class", pvc.Name, r.VirtualStorageClassName))
+		return ctrl.Result{}, nil
+	}
+
+	sourceNode, err := r.sourceNode(ctx, &pvc)
+	if err != nil {
+		r.setPhase(replication, offv1alpha1.PendingVolumeReplicationPhaseType, err.Error())
+		return ctrl.Result{RequeueAfter: pendingRequeuePeriod}, nil
+	}
+
+	var targetNode corev1.Node
+	if err := r.Get(ctx, client.ObjectKey{Name: replication.Spec.TargetNode}, &targetNode); err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Failed to retrieve the target node of VolumeReplication %q: %v", klog.KObj(replication), err)
+			return ctrl.Result{}, err
+		}
+		r.setPhase(replication, offv1alpha1.FailedVolumeReplicationPhaseType,
+			fmt.Sprintf("Target node %q not found", replication.Spec.TargetNode))
+		return ctrl.Result{RequeueAfter: retryPeriod}, nil
+	}
+
+	if sourceNode.Name == targetNode.Name {
+		r.setPhase(replication, offv1alpha1.FailedVolumeReplicationPhaseType,
+			fmt.Sprintf("PersistentVolumeClaim %q is already stored on the target node", pvc.Name))
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.ensureNamespaceOffloading(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.ensureRegistry(ctx, replication, &pvc); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	ready, err := r.registryReady(ctx, replication)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	sourceURL, sourceErr := r.repositoryURL(ctx, replication, &pvc, sourceNode)
+	targetURL, targetErr := r.repositoryURL(ctx, replication, &pvc, &targetNode)
+	if !ready || sourceErr != nil || targetErr != nil {
+		r.setPhase(replication, offv1alpha1.PendingVolumeReplicationPhaseType, "Waiting for the replication repository to be ready")
+		return ctrl.Result{RequeueAfter: pendingRequeuePeriod}, nil
+	}
+	replication.Status.SourceRepositoryURL = sourceURL
+	replication.Status.TargetRepositoryURL = targetURL
+
+	active, err := r.processJobs(ctx, replication)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if active {
+		r.setPhase(replication, offv1alpha1.ReplicatingVolumeReplicationPhaseType, "Replication in progress")
+		return ctrl.Result{}, nil
+	}
+
+	if replication.Spec.Suspend {
+		klog.V(4).Infof("VolumeReplication %q is suspended", klog.KObj(replication))
+		return ctrl.Result{}, nil
+	}
+
+	now := time.Now()
+	if next := nextReplicationTime(replication); next.After(now) {
+		if replication.Status.Phase != offv1alpha1.FailedVolumeReplicationPhaseType {
+			r.setPhase(replication, offv1alpha1.UpToDateVolumeReplicationPhaseType, "")
+		}
+		return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+	}
+
+	job := forgeReplicationJob(replication, &pvc)
+	if err := r.Create(ctx, job); err != nil {
+		klog.Errorf("Failed to create the replication job for VolumeReplication %q: %v", klog.KObj(replication), err)
+		return ctrl.Result{}, err
+	}
+	klog.Infof("Replication job %q for VolumeReplication %q correctly created", klog.KObj(job), klog.KObj(replication))
+
+	replication.Status.LastReplicationAttemptTime = &metav1.Time{Time: now}
+	r.setPhase(replication, offv1alpha1.ReplicatingVolumeReplicationPhaseType, "Replication in progress")
+	return ctrl.Result{}, nil
+}
+
+// handleDeletion tears down the replication infrastructure associated with the given VolumeReplication,
+// and removes the finalizer.
+func (r *Reconciler) handleDeletion(ctx context.Context, replication *offv1alpha1.VolumeReplication) error {
+	if !controllerutil.ContainsFinalizer(replication, volumeReplicationControllerFinalizer) {
+		return nil
+	}
+
+	if err := r.deleteRegistry(ctx, replication); err != nil {
+		return err
+	}
+
+	// The namespace offloading is shrunk before removing the finalizer, so that it is retried in case of errors.
+	// The current replication is not considered, since it is being deleted.
+	if err := r.ensureNamespaceOffloading(ctx); err != nil {
+		return err
+	}
+
+	original := replication.DeepCopy()
+	controllerutil.RemoveFinalizer(replication, volumeReplicationControllerFinalizer)
+	if err := r.Patch(ctx, replication, client.MergeFrom(original)); err != nil {
+		klog.Errorf("Failed to remove the finalizer from VolumeReplication %q: %v", klog.KObj(replication), err)
+		return err
+	}
+	return nil
+}
+
+// setPhase sets the phase of the given VolumeReplication, recording an event in case it changed.
+func (r *Reconciler) setPhase(replication *offv1alpha1.VolumeReplication, phase offv1alpha1.VolumeReplicationPhaseType, message string) {
+	if replication.Status.Phase != phase {
+		eventType := corev1.EventTypeNormal
+		if phase == offv1alpha1.FailedVolumeReplicationPhaseType {
+			eventType = corev1.EventTypeWarning
+		}
+		r.Recorder.Event(replication, eventType, string(phase), fmt.Sprintf("Volume replication is %s: %s", phase, message))
+	}
+
+	replication.Status.Phase = phase
+	replication.Status.Message = message
+}
+
+// nextReplicationTime returns the time at which the next replication is expected to start.
+func nextReplicationTime(replication *offv1alpha1.VolumeReplication) time.Time {
+	var next time.Time
+	if last := replication.Status.LastSuccessfulReplicationTime; last != nil {
+		next = last.Add(replication.Spec.RecoveryPointObjective.Duration)
+	}
+
+	// Prevent starting a new attempt too early, in case the previous one failed.
+	if attempt := replication.Status.LastReplicationAttemptTime; attempt != nil && attempt.Add(retryPeriod).After(next) {
+		next = attempt.Add(retryPeriod)
+	}
+	return next
+}
+
+// SetupWithManager registers a new controller for VolumeReplication resources.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&offv1alpha1.VolumeReplication{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
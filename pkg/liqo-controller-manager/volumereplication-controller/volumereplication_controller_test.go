@@ -0,0 +1,316 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumereplicationctrl
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+)
+
+var _ = Describe("VolumeReplication controller", func() {
+	const (
+		namespace            = "default"
+		name                 = "replication"
+		replicationNamespace = "liqo-replication"
+		localNode            = "local-node"
+		virtualNode          = "liqo-remote"
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		reconciler *Reconciler
+		key        types.NamespacedName
+
+		replication *offv1alpha1.VolumeReplication
+		pvc         *corev1.PersistentVolumeClaim
+		objects     []client.Object
+
+		result ctrl.Result
+		err    error
+	)
+
+	reconcile := func() {
+		result, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	getReplication := func() *offv1alpha1.VolumeReplication {
+		var vr offv1alpha1.VolumeReplication
+		Expect(cl.Get(ctx, key, &vr)).To(Succeed())
+		return &vr
+	}
+
+	listJobs := func() []batchv1.Job {
+		var jobs batchv1.JobList
+		Expect(cl.List(ctx, &jobs, client.InNamespace(namespace))).To(Succeed())
+		return jobs.Items
+	}
+
+	// setReady marks the restic registry and the namespace offloading as ready.
+	setReady := func() {
+		var sts appsv1.StatefulSet
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: replicationNamespace, Name: RegistryName(replication)}, &sts)).To(Succeed())
+		sts.Status.ReadyReplicas = 1
+		Expect(cl.Status().Update(ctx, &sts)).To(Succeed())
+
+		var nsoff offv1alpha1.NamespaceOffloading
+		Expect(cl.Get(ctx, client.ObjectKey{Namespace: replicationNamespace, Name: consts.DefaultNamespaceOffloadingName}, &nsoff)).To(Succeed())
+		nsoff.Status.OffloadingPhase = offv1alpha1.ReadyOffloadingPhaseType
+		nsoff.Status.RemoteNamespaceName = "liqo-replication-local"
+		Expect(cl.Status().Update(ctx, &nsoff)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Namespace: namespace, Name: name}
+
+		replication = &offv1alpha1.VolumeReplication{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: "8f3f8dc4-1c1f-4f3e-9a6e-4f1a0b7f3c21"},
+			Spec: offv1alpha1.VolumeReplicationSpec{
+				PersistentVolumeClaimName: "data",
+				TargetNode:                virtualNode,
+				RecoveryPointObjective:    metav1.Duration{Duration: time.Hour},
+				PasswordSecretRef: corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "restic"}, Key: "password"},
+				KeepLast: 5,
+			},
+		}
+
+		pvc = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: namespace, UID: "pvc-uid",
+				Annotations: map[string]string{selectedNodeAnnot: localNode}},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: pointer.String("liqo"),
+				VolumeName:       "pv-data",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+		}
+
+		objects = []client.Object{
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: localNode}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: virtualNode, Labels: map[string]string{consts.TypeLabel: consts.TypeNode}}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, replication, pvc)...).Build()
+		reconciler = &Reconciler{
+			Client:                  cl,
+			Recorder:                record.NewFakeRecorder(10),
+			VirtualStorageClassName: "liqo",
+			Namespace:               replicationNamespace,
+		}
+	})
+
+	When("the PVC does not belong to the virtual storage class", func() {
+		BeforeEach(func() { pvc.Spec.StorageClassName = pointer.String("standard") })
+		JustBeforeEach(reconcile)
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should set the failed phase", func() {
+			vr := getReplication()
+			Expect(vr.Status.Phase).To(Equal(offv1alpha1.FailedVolumeReplicationPhaseType))
+			Expect(vr.Status.Message).To(ContainSubstring("storage class"))
+		})
+		It("should add the finalizer", func() { Expect(getReplication().Finalizers).To(ContainElement(volumeReplicationControllerFinalizer)) })
+	})
+
+	When("the PVC is already stored on the target node", func() {
+		BeforeEach(func() { pvc.Annotations[selectedNodeAnnot] = virtualNode })
+		JustBeforeEach(reconcile)
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should set the failed phase", func() {
+			Expect(getReplication().Status.Phase).To(Equal(offv1alpha1.FailedVolumeReplicationPhaseType))
+		})
+	})
+
+	When("the replication infrastructure is not yet ready", func() {
+		JustBeforeEach(reconcile)
+
+		It("should succeed and requeue", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(pendingRequeuePeriod))
+		})
+		It("should set the pending phase", func() {
+			Expect(getReplication().Status.Phase).To(Equal(offv1alpha1.PendingVolumeReplicationPhaseType))
+		})
+		It("should offload the replication namespace to the involved virtual nodes", func() {
+			var nsoff offv1alpha1.NamespaceOffloading
+			Expect(cl.Get(ctx, client.ObjectKey{Namespace: replicationNamespace, Name: consts.DefaultNamespaceOffloadingName}, &nsoff)).To(Succeed())
+			Expect(nsoff.Spec.PodOffloadingStrategy).To(Equal(offv1alpha1.LocalAndRemotePodOffloadingStrategyType))
+			Expect(nsoff.Spec.ClusterSelector.NodeSelectorTerms).To(HaveLen(1))
+			Expect(nsoff.Spec.ClusterSelector.NodeSelectorTerms[0].MatchExpressions[0].Values).To(ConsistOf(virtualNode))
+		})
+		It("should create the restic registry on the target node", func() {
+			var sts appsv1.StatefulSet
+			Expect(cl.Get(ctx, client.ObjectKey{Namespace: replicationNamespace, Name: RegistryName(replication)}, &sts)).To(Succeed())
+			Expect(sts.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.
+				NodeSelectorTerms[0].MatchExpressions[0].Values).To(ConsistOf(virtualNode))
+			Expect(sts.Spec.VolumeClaimTemplates).To(HaveLen(1))
+			Expect(sts.Spec.VolumeClaimTemplates[0].Spec.StorageClassName).To(PointTo(Equal("liqo")))
+			Expect(sts.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage().String()).To(Equal("10Gi"))
+
+			var svc corev1.Service
+			Expect(cl.Get(ctx, client.ObjectKey{Namespace: replicationNamespace, Name: RegistryName(replication)}, &svc)).To(Succeed())
+			Expect(svc.Spec.Selector).To(Equal(sts.Spec.Template.Labels))
+		})
+		It("should not create any replication job", func() { Expect(listJobs()).To(BeEmpty()) })
+	})
+
+	When("the replication infrastructure is ready", func() {
+		JustBeforeEach(func() {
+			reconcile()
+			Expect(err).ToNot(HaveOccurred())
+			setReady()
+			reconcile()
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should set the repository URLs", func() {
+			vr := getReplication()
+			Expect(vr.Status.SourceRepositoryURL).To(Equal(
+				"rest:http://restic-8f3f8dc4-1c1f-4f3e-9a6e-4f1a0b7f3c21.liqo-replication.svc.cluster.local:8000/pvc-uid"))
+			Expect(vr.Status.TargetRepositoryURL).To(Equal(
+				"rest:http://restic-8f3f8dc4-1c1f-4f3e-9a6e-4f1a0b7f3c21.liqo-replication-local.svc.cluster.local:8000/pvc-uid"))
+		})
+		It("should create the replication job", func() {
+			jobs := listJobs()
+			Expect(jobs).To(HaveLen(1))
+			container := jobs[0].Spec.Template.Spec.Containers[0]
+			Expect(container.Env).To(ContainElements(
+				corev1.EnvVar{Name: "RESTIC_REPOSITORY", Value: getReplication().Status.SourceRepositoryURL},
+				corev1.EnvVar{Name: "KEEP_LAST", Value: "5"},
+			))
+			Expect(jobs[0].Spec.Template.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("data"))
+			Expect(jobs[0].OwnerReferences).To(HaveLen(1))
+		})
+		It("should set the replicating phase", func() {
+			vr := getReplication()
+			Expect(vr.Status.Phase).To(Equal(offv1alpha1.ReplicatingVolumeReplicationPhaseType))
+			Expect(vr.Status.LastReplicationAttemptTime).ToNot(BeNil())
+		})
+
+		When("the replication job completes successfully", func() {
+			completion := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+
+			JustBeforeEach(func() {
+				job := listJobs()[0]
+				job.Status.Succeeded = 1
+				job.Status.CompletionTime = &completion
+				Expect(cl.Status().Update(ctx, &job)).To(Succeed())
+				reconcile()
+			})
+
+			It("should succeed and requeue before the RPO expires", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically("~", 59*time.Minute, time.Minute))
+			})
+			It("should update the last successful replication time", func() {
+				vr := getReplication()
+				Expect(vr.Status.Phase).To(Equal(offv1alpha1.UpToDateVolumeReplicationPhaseType))
+				Expect(vr.Status.LastSuccessfulReplicationTime.Time).To(BeTemporally("==", completion.Time))
+			})
+			It("should delete the completed job", func() { Expect(listJobs()).To(BeEmpty()) })
+		})
+
+		When("the replication job fails", func() {
+			JustBeforeEach(func() {
+				job := listJobs()[0]
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+				Expect(cl.Status().Update(ctx, &job)).To(Succeed())
+				reconcile()
+			})
+
+			It("should succeed and retry later", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically("~", retryPeriod, 5*time.Second))
+			})
+			It("should set the failed phase", func() {
+				vr := getReplication()
+				Expect(vr.Status.Phase).To(Equal(offv1alpha1.FailedVolumeReplicationPhaseType))
+				Expect(vr.Status.LastSuccessfulReplicationTime).To(BeNil())
+			})
+			It("should delete the failed job", func() { Expect(listJobs()).To(BeEmpty()) })
+		})
+
+		When("the VolumeReplication is deleted", func() {
+			JustBeforeEach(func() {
+				Expect(cl.Delete(ctx, getReplication())).To(Succeed())
+				reconcile()
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should delete the restic registry", func() {
+				var sts appsv1.StatefulSet
+				Expect(cl.Get(ctx, client.ObjectKey{Namespace: replicationNamespace, Name: RegistryName(replication)}, &sts)).
+					To(testutil.BeNotFound())
+			})
+			It("should delete the namespace offloading", func() {
+				var nsoff offv1alpha1.NamespaceOffloading
+				Expect(cl.Get(ctx, client.ObjectKey{Namespace: replicationNamespace, Name: consts.DefaultNamespaceOffloadingName}, &nsoff)).
+					To(testutil.BeNotFound())
+			})
+			It("should remove the finalizer", func() {
+				Expect(cl.Get(ctx, key, &offv1alpha1.VolumeReplication{})).To(testutil.BeNotFound())
+			})
+		})
+	})
+})
+
+var _ = Describe("nextReplicationTime", func() {
+	var replication offv1alpha1.VolumeReplication
+	now := time.Now().Truncate(time.Second)
+
+	BeforeEach(func() {
+		replication = offv1alpha1.VolumeReplication{Spec: offv1alpha1.VolumeReplicationSpec{
+			RecoveryPointObjective: metav1.Duration{Duration: 10 * time.Minute}}}
+	})
+
+	It("should return the zero time if never replicated", func() {
+		Expect(nextReplicationTime(&replication).IsZero()).To(BeTrue())
+	})
+	It("should add the RPO to the last successful replication", func() {
+		replication.Status.LastSuccessfulReplicationTime = &metav1.Time{Time: now}
+		replication.Status.LastReplicationAttemptTime = &metav1.Time{Time: now.Add(-time.Minute)}
+		Expect(nextReplicationTime(&replication)).To(BeTemporally("==", now.Add(10*time.Minute)))
+	})
+	It("should delay the retries after a failed attempt", func() {
+		replication.Status.LastSuccessfulReplicationTime = &metav1.Time{Time: now.Add(-time.Hour)}
+		replication.Status.LastReplicationAttemptTime = &metav1.Time{Time: now}
+		Expect(nextReplicationTime(&replication)).To(BeTemporally("==", now.Add(retryPeriod)))
+	})
+})
@@ -0,0 +1,18 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package volumesnapshotctrl contains the controller acting as the snapshot driver of the Liqo virtual storage class
+// for the volumes stored in the local cluster: the VolumeSnapshots of virtual PVCs are translated into VolumeSnapshots
+// of the corresponding real PVCs, and their readiness is reported back through the bound VolumeSnapshotContents.
+package volumesnapshotctrl
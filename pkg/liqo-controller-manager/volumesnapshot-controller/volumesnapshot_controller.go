@@ -0,0 +1,307 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumesnapshotctrl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
+)
+
+// pendingRequeuePeriod is the interval after which a VolumeSnapshot is reconciled again, while waiting for its PVC to be bound.
+const pendingRequeuePeriod = 10 * time.Second
+
+// Reconciler reconciles the VolumeSnapshots of the Liqo virtual storage class, whose PVCs are stored in the local cluster.
+type Reconciler struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	// StorageNamespace is the namespace hosting the real PVCs and VolumeSnapshots.
+	StorageNamespace string
+}
+
+// cluster-role
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch
+
+// Reconcile ensures that the real VolumeSnapshot corresponding to the given virtual VolumeSnapshot exists, and that
+// its readiness is reported back through the bound VolumeSnapshotContent. Once the virtual VolumeSnapshot is deleted,
+// the real one is released according to the configured deletion policy.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var snapshot snapshotv1.VolumeSnapshot
+	if err := r.Get(ctx, req.NamespacedName, &snapshot); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("VolumeSnapshot %q not found", req.NamespacedName)
+			return ctrl.Result{}, r.releaseRealSnapshots(ctx, req.NamespacedName, true)
+		}
+		klog.Errorf("Failed to retrieve VolumeSnapshot %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if !snapshot.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.releaseRealSnapshots(ctx, req.NamespacedName, false)
+	}
+
+	class, err := r.virtualSnapshotClass(ctx, &snapshot)
+	if err != nil || class == nil {
+		return ctrl.Result{}, err
+	}
+
+	var pvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, client.ObjectKey{Namespace: snapshot.Namespace, Name: *snapshot.Spec.Source.PersistentVolumeClaimName}, &pvc); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("PersistentVolumeClaim of VolumeSnapshot %q not found", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve the PersistentVolumeClaim of VolumeSnapshot %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	if pvc.Spec.VolumeName == "" {
+		klog.V(4).Infof("PersistentVolumeClaim of VolumeSnapshot %q not yet bound", req.NamespacedName)
+		return ctrl.Result{RequeueAfter: pendingRequeuePeriod}, nil
+	}
+
+	// The real PVC is named after the UID of the virtual one, and it is present only if stored in the local cluster.
+	// Otherwise, the snapshot is handled by the virtual kubelet responsible for the virtual node the PVC is bound to.
+	var realPvc corev1.PersistentVolumeClaim
+	if err := r.Get(ctx, client.ObjectKey{Namespace: r.StorageNamespace, Name: string(pvc.UID)}, &realPvc); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("Skipping VolumeSnapshot %q, as the corresponding volume is not stored in the local cluster", req.NamespacedName)
+			return ctrl.Result{}, nil
+		}
+		klog.Errorf("Failed to retrieve the real PersistentVolumeClaim of VolumeSnapshot %q: %v", req.NamespacedName, err)
+		return ctrl.Result{}, err
+	}
+
+	realSnapshot, err := r.enforceRealSnapshot(ctx, &snapshot, &realPvc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, r.enforceSnapshotContent(ctx, &snapshot, class, &realPvc, realSnapshot)
+}
+
+// virtualSnapshotClass returns the VolumeSnapshotClass of the given VolumeSnapshot, if it refers to the virtual storage.
+func (r *Reconciler) virtualSnapshotClass(ctx context.Context, snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshotClass, error) {
+	// Pre-provisioned snapshots, as well as the ones relying on the default class, cannot refer to the virtual storage.
+	if snapshot.Spec.Source.PersistentVolumeClaimName == nil || snapshot.Spec.VolumeSnapshotClassName == nil {
+		return nil, nil
+	}
+
+	var class snapshotv1.VolumeSnapshotClass
+	if err := r.Get(ctx, client.ObjectKey{Name: *snapshot.Spec.VolumeSnapshotClassName}, &class); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(4).Infof("VolumeSnapshotClass of VolumeSnapshot %q not found", klog.KObj(snapshot))
+			return nil, nil
+		}
+		klog.Errorf("Failed to retrieve the VolumeSnapshotClass of VolumeSnapshot %q: %v", klog.KObj(snapshot), err)
+		return nil, err
+	}
+
+	if !liqostorageprovisioner.IsVirtualSnapshotClass(&class) {
+		return nil, nil
+	}
+	return &class, nil
+}
+
+// enforceRealSnapshot ensures the existence of the real VolumeSnapshot corresponding to the given virtual one.
+// The real VolumeSnapshot relies on the default VolumeSnapshotClass associated with the driver of the real PVC.
+func (r *Reconciler) enforceRealSnapshot(ctx context.Context, snapshot *snapshotv1.VolumeSnapshot,
+	realPvc *corev1.PersistentVolumeClaim) (*snapshotv1.VolumeSnapshot, error) {
+	realSnapshot := &snapshotv1.VolumeSnapshot{ObjectMeta: metav1.ObjectMeta{Name: string(snapshot.UID), Namespace: r.StorageNamespace}}
+	operation, err := controllerutil.CreateOrUpdate(ctx, r.Client, realSnapshot, func() error {
+		if realSnapshot.Labels == nil {
+			realSnapshot.Labels = map[string]string{}
+		}
+		realSnapshot.Labels[consts.VirtualSnapshotNamespaceLabel] = snapshot.Namespace
+		realSnapshot.Labels[consts.VirtualSnapshotNameLabel] = snapshot.Name
+
+		if realSnapshot.Annotations == nil {
+			realSnapshot.Annotations = map[string]string{}
+		}
+		realSnapshot.Annotations[consts.VirtualSnapshotContentAnnotation] = liqostorageprovisioner.VirtualSnapshotContentName(snapshot)
+
+		// The specifications of VolumeSnapshots are immutable, hence they are configured only upon creation.
+		if realSnapshot.CreationTimestamp.IsZero() {
+			realSnapshot.Spec.Source.PersistentVolumeClaimName = &realPvc.Name
+		}
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("Failed to enforce the real VolumeSnapshot of VolumeSnapshot %q: %v", klog.KObj(snapshot), err)
+		return nil, err
+	}
+
+	if operation == controllerutil.OperationResultCreated {
+		klog.Infof("Real VolumeSnapshot %q of VolumeSnapshot %q created", klog.KObj(realSnapshot), klog.KObj(snapshot))
+		r.Recorder.Eventf(snapshot, corev1.EventTypeNormal, "RealSnapshotCreated",
+			"Created the VolumeSnapshot of the real PersistentVolumeClaim %q", klog.KObj(realPvc))
+	}
+	return realSnapshot, nil
+}
+
+// enforceSnapshotContent ensures the existence of the VolumeSnapshotContent bound to the given virtual VolumeSnapshot,
+// and that its status reflects the one of the real VolumeSnapshot.
+func (r *Reconciler) enforceSnapshotContent(ctx context.Context, snapshot *snapshotv1.VolumeSnapshot, class *snapshotv1.VolumeSnapshotClass,
+	realPvc *corev1.PersistentVolumeClaim, realSnapshot *snapshotv1.VolumeSnapshot) error {
+	var content snapshotv1.VolumeSnapshotContent
+	err := r.Get(ctx, client.ObjectKey{Name: liqostorageprovisioner.VirtualSnapshotContentName(snapshot)}, &content)
+	switch {
+	case apierrors.IsNotFound(err):
+		content = *liqostorageprovisioner.ForgeVirtualSnapshotContent(snapshot, class, handle(realPvc.Namespace, realPvc.Name), nil)
+		if err := r.Create(ctx, &content); err != nil {
+			klog.Errorf("Failed to create the VolumeSnapshotContent of VolumeSnapshot %q: %v", klog.KObj(snapshot), err)
+			return err
+		}
+		klog.Infof("VolumeSnapshotContent %q of VolumeSnapshot %q created", content.Name, klog.KObj(snapshot))
+	case err != nil:
+		klog.Errorf("Failed to retrieve the VolumeSnapshotContent of VolumeSnapshot %q: %v", klog.KObj(snapshot), err)
+		return err
+	case content.Spec.VolumeSnapshotRef.UID != snapshot.UID:
+		return fmt.Errorf("VolumeSnapshotContent %q is not bound to VolumeSnapshot %q", content.Name, klog.KObj(snapshot))
+	}
+
+	status := liqostorageprovisioner.ForgeVirtualSnapshotContentStatus(handle(realSnapshot.Namespace, realSnapshot.Name), realSnapshot)
+	if equality.Semantic.DeepEqual(content.Status, status) {
+		return nil
+	}
+
+	content.Status = status
+	if err := r.Status().Update(ctx, &content); err != nil {
+		klog.Errorf("Failed to update the status of VolumeSnapshotContent %q: %v", content.Name, err)
+		return err
+	}
+	klog.V(4).Infof("Status of VolumeSnapshotContent %q correctly updated (ready: %t)", content.Name, *status.ReadyToUse)
+	return nil
+}
+
+// releaseRealSnapshots releases the real VolumeSnapshots corresponding to the given virtual VolumeSnapshot, which is
+// either being deleted or already vanished. The real VolumeSnapshots are deleted if the corresponding content is
+// being deleted (or no longer exists), and retained otherwise once the virtual VolumeSnapshot vanished.
+func (r *Reconciler) releaseRealSnapshots(ctx context.Context, snapshot types.NamespacedName, vanished bool) error {
+	var realSnapshots snapshotv1.VolumeSnapshotList
+	if err := r.List(ctx, &realSnapshots, client.InNamespace(r.StorageNamespace), client.MatchingLabels{
+		consts.VirtualSnapshotNamespaceLabel: snapshot.Namespace,
+		consts.VirtualSnapshotNameLabel:      snapshot.Name,
+	}); err != nil {
+		klog.Errorf("Failed to list the real VolumeSnapshots of VolumeSnapshot %q: %v", snapshot, err)
+		return err
+	}
+
+	for i := range realSnapshots.Items {
+		if err := r.releaseRealSnapshot(ctx, &realSnapshots.Items[i], vanished); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) releaseRealSnapshot(ctx context.Context, realSnapshot *snapshotv1.VolumeSnapshot, vanished bool) error {
+	var content snapshotv1.VolumeSnapshotContent
+	err := r.Get(ctx, client.ObjectKey{Name: realSnapshot.Annotations[consts.VirtualSnapshotContentAnnotation]}, &content)
+	if client.IgnoreNotFound(err) != nil {
+		klog.Errorf("Failed to retrieve the VolumeSnapshotContent backed by %q: %v", klog.KObj(realSnapshot), err)
+		return err
+	}
+	found := err == nil
+
+	switch {
+	case !found || !content.DeletionTimestamp.IsZero():
+		if err := client.IgnoreNotFound(r.Delete(ctx, realSnapshot)); err != nil {
+			klog.Errorf("Failed to delete the real VolumeSnapshot %q: %v", klog.KObj(realSnapshot), err)
+			return err
+		}
+		klog.Infof("Real VolumeSnapshot %q deleted", klog.KObj(realSnapshot))
+	case vanished && content.Spec.DeletionPolicy == snapshotv1.VolumeSnapshotContentRetain:
+		// Detach the real VolumeSnapshot from the virtual one, so that it is not affected by homonymous ones.
+		original := realSnapshot.DeepCopy()
+		delete(realSnapshot.Labels, consts.VirtualSnapshotNamespaceLabel)
+		delete(realSnapshot.Labels, consts.VirtualSnapshotNameLabel)
+		if err := r.Patch(ctx, realSnapshot, client.MergeFrom(original)); err != nil {
+			klog.Errorf("Failed to retain the real VolumeSnapshot %q: %v", klog.KObj(realSnapshot), err)
+			return err
+		}
+		klog.Infof("Real VolumeSnapshot %q retained", klog.KObj(realSnapshot))
+	default:
+		// Wait for the snapshot controller to either delete the content or remove the virtual VolumeSnapshot.
+		return nil
+	}
+
+	if found && controllerutil.ContainsFinalizer(&content, liqostorageprovisioner.VolumeSnapshotContentBoundFinalizer) {
+		original := content.DeepCopy()
+		controllerutil.RemoveFinalizer(&content, liqostorageprovisioner.VolumeSnapshotContentBoundFinalizer)
+		if err := r.Patch(ctx, &content, client.MergeFrom(original)); err != nil {
+			klog.Errorf("Failed to remove the finalizer from VolumeSnapshotContent %q: %v", content.Name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// handle returns the handle identifying the given resource in the local cluster.
+func handle(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// SetupWithManager registers a new controller for the VolumeSnapshots of the Liqo virtual storage class.
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// The real VolumeSnapshots trigger the reconciliation of the corresponding virtual ones.
+	realSnapshotEnqueuer := func(obj client.Object) []reconcile.Request {
+		namespace, nsok := obj.GetLabels()[consts.VirtualSnapshotNamespaceLabel]
+		name, nameok := obj.GetLabels()[consts.VirtualSnapshotNameLabel]
+		if obj.GetNamespace() != r.StorageNamespace || !nsok || !nameok {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+	}
+
+	// The VolumeSnapshotContents of the virtual storage trigger the reconciliation of the bound VolumeSnapshots.
+	contentEnqueuer := func(obj client.Object) []reconcile.Request {
+		content, ok := obj.(*snapshotv1.VolumeSnapshotContent)
+		if !ok || content.Spec.Driver != consts.StorageProvisionerName {
+			return nil
+		}
+		ref := content.Spec.VolumeSnapshotRef
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}}}
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&snapshotv1.VolumeSnapshot{}).
+		Watches(&source.Kind{Type: &snapshotv1.VolumeSnapshot{}}, handler.EnqueueRequestsFromMapFunc(realSnapshotEnqueuer)).
+		Watches(&source.Kind{Type: &snapshotv1.VolumeSnapshotContent{}}, handler.EnqueueRequestsFromMapFunc(contentEnqueuer)).
+		Complete(r)
+}
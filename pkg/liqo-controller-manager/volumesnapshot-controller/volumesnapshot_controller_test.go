@@ -0,0 +1,260 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package volumesnapshotctrl
+
+import (
+	"context"
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
+)
+
+var _ = Describe("VolumeSnapshot controller", func() {
+	const (
+		namespace        = "default"
+		name             = "snapshot"
+		storageNamespace = "liqo-storage"
+		snapshotUID      = "e3b0c442-98fc-1c14-9afb-f4c8996fb924"
+		pvcUID           = "2c26b46b-68ff-c68f-f99b-453c1d304134"
+		contentName      = "snapcontent-" + snapshotUID
+	)
+
+	var (
+		ctx        context.Context
+		cl         client.Client
+		reconciler *Reconciler
+		key        types.NamespacedName
+
+		snapshot *snapshotv1.VolumeSnapshot
+		class    *snapshotv1.VolumeSnapshotClass
+		objects  []client.Object
+
+		result ctrl.Result
+		err    error
+	)
+
+	reconcile := func() {
+		result, err = reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+	}
+
+	getRealSnapshot := func() (*snapshotv1.VolumeSnapshot, error) {
+		var real snapshotv1.VolumeSnapshot
+		return &real, cl.Get(ctx, client.ObjectKey{Namespace: storageNamespace, Name: snapshotUID}, &real)
+	}
+
+	getContent := func() (*snapshotv1.VolumeSnapshotContent, error) {
+		var content snapshotv1.VolumeSnapshotContent
+		return &content, cl.Get(ctx, client.ObjectKey{Name: contentName}, &content)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = types.NamespacedName{Namespace: namespace, Name: name}
+
+		snapshot = &snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: snapshotUID},
+			Spec: snapshotv1.VolumeSnapshotSpec{
+				Source:                  snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: pointer.String("data")},
+				VolumeSnapshotClassName: pointer.String("liqo"),
+			},
+		}
+
+		class = &snapshotv1.VolumeSnapshotClass{
+			ObjectMeta:     metav1.ObjectMeta{Name: "liqo"},
+			Driver:         consts.StorageProvisionerName,
+			DeletionPolicy: snapshotv1.VolumeSnapshotContentDelete,
+		}
+
+		objects = []client.Object{
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: namespace, UID: pvcUID},
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: pointer.String("liqo"), VolumeName: "pv-data"},
+			},
+			&corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: pvcUID, Namespace: storageNamespace},
+				Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: pointer.String("standard"), VolumeName: "pv-real"},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, snapshot, class)...).Build()
+		reconciler = &Reconciler{
+			Client:           cl,
+			Recorder:         record.NewFakeRecorder(10),
+			StorageNamespace: storageNamespace,
+		}
+	})
+
+	When("the VolumeSnapshot does not refer to the virtual storage", func() {
+		BeforeEach(func() { class.Driver = "csi.example.com" })
+		JustBeforeEach(reconcile)
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not create the real VolumeSnapshot", func() {
+			_, err := getRealSnapshot()
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+		It("should not create the VolumeSnapshotContent", func() {
+			_, err := getContent()
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("the volume is not stored in the local cluster", func() {
+		BeforeEach(func() { objects = objects[:1] })
+		JustBeforeEach(reconcile)
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not create the real VolumeSnapshot", func() {
+			_, err := getRealSnapshot()
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("the PVC is not yet bound", func() {
+		BeforeEach(func() { objects[0].(*corev1.PersistentVolumeClaim).Spec.VolumeName = "" })
+		JustBeforeEach(reconcile)
+
+		It("should succeed and requeue", func() {
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(pendingRequeuePeriod))
+		})
+	})
+
+	When("the volume is stored in the local cluster", func() {
+		JustBeforeEach(reconcile)
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should create the real VolumeSnapshot", func() {
+			real, err := getRealSnapshot()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(real.Spec.Source.PersistentVolumeClaimName).To(PointTo(Equal(pvcUID)))
+			Expect(real.Spec.VolumeSnapshotClassName).To(BeNil())
+			Expect(real.Labels).To(HaveKeyWithValue(consts.VirtualSnapshotNamespaceLabel, namespace))
+			Expect(real.Labels).To(HaveKeyWithValue(consts.VirtualSnapshotNameLabel, name))
+			Expect(real.Annotations).To(HaveKeyWithValue(consts.VirtualSnapshotContentAnnotation, contentName))
+		})
+		It("should create the VolumeSnapshotContent bound to the virtual VolumeSnapshot", func() {
+			content, err := getContent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content.Spec.Driver).To(Equal(consts.StorageProvisionerName))
+			Expect(content.Spec.DeletionPolicy).To(Equal(snapshotv1.VolumeSnapshotContentDelete))
+			Expect(content.Spec.VolumeSnapshotClassName).To(PointTo(Equal("liqo")))
+			Expect(content.Spec.VolumeSnapshotRef.UID).To(BeEquivalentTo(snapshotUID))
+			Expect(content.Spec.Source.VolumeHandle).To(PointTo(Equal(storageNamespace + "/" + pvcUID)))
+		})
+		It("should report the VolumeSnapshotContent as not ready", func() {
+			content, err := getContent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content.Status).ToNot(BeNil())
+			Expect(content.Status.SnapshotHandle).To(PointTo(Equal(storageNamespace + "/" + snapshotUID)))
+			Expect(content.Status.ReadyToUse).To(PointTo(BeFalse()))
+		})
+
+		When("the real VolumeSnapshot becomes ready", func() {
+			creation := metav1.NewTime(time.Now().Truncate(time.Second))
+
+			JustBeforeEach(func() {
+				real, err := getRealSnapshot()
+				Expect(err).ToNot(HaveOccurred())
+				size := resource.MustParse("1Gi")
+				real.Status = &snapshotv1.VolumeSnapshotStatus{
+					ReadyToUse: pointer.Bool(true), CreationTime: &creation, RestoreSize: &size}
+				Expect(cl.Status().Update(ctx, real)).To(Succeed())
+				reconcile()
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should report the readiness through the VolumeSnapshotContent", func() {
+				content, err := getContent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(content.Status.ReadyToUse).To(PointTo(BeTrue()))
+				Expect(content.Status.CreationTime).To(PointTo(Equal(creation.UnixNano())))
+				Expect(content.Status.RestoreSize).To(PointTo(BeEquivalentTo(1 << 30)))
+			})
+		})
+
+		When("the virtual VolumeSnapshot is deleted", func() {
+			var vanish func()
+
+			JustBeforeEach(func() {
+				content, err := getContent()
+				Expect(err).ToNot(HaveOccurred())
+				content.Finalizers = []string{liqostorageprovisioner.VolumeSnapshotContentBoundFinalizer}
+				Expect(cl.Update(ctx, content)).To(Succeed())
+
+				vanish()
+				Expect(cl.Delete(ctx, snapshot)).To(Succeed())
+				reconcile()
+			})
+
+			When("the VolumeSnapshotContent is being deleted", func() {
+				BeforeEach(func() {
+					vanish = func() {
+						content, err := getContent()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(cl.Delete(ctx, content)).To(Succeed())
+					}
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should delete the real VolumeSnapshot", func() {
+					_, err := getRealSnapshot()
+					Expect(kerrors.IsNotFound(err)).To(BeTrue())
+				})
+				It("should release the VolumeSnapshotContent", func() {
+					_, err := getContent()
+					Expect(kerrors.IsNotFound(err)).To(BeTrue())
+				})
+			})
+
+			When("the VolumeSnapshotContent is retained", func() {
+				BeforeEach(func() {
+					class.DeletionPolicy = snapshotv1.VolumeSnapshotContentRetain
+					vanish = func() {}
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should retain the real VolumeSnapshot, detaching it from the virtual one", func() {
+					real, err := getRealSnapshot()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(real.Labels).ToNot(HaveKey(consts.VirtualSnapshotNameLabel))
+				})
+				It("should remove the finalizer from the VolumeSnapshotContent", func() {
+					content, err := getContent()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(content.Finalizers).To(BeEmpty())
+				})
+			})
+		})
+	})
+})
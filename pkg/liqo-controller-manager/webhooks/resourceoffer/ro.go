@@ -0,0 +1,167 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rowh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+type rowh struct {
+	decoder *admission.Decoder
+}
+
+// New returns a new ResourceOffer mutating webhook, which records the user who accepted or refused each ResourceOffer.
+func New() *webhook.Admission {
+	return &webhook.Admission{Handler: &rowh{}}
+}
+
+// InjectDecoder injects the decoder - this method is used by controller runtime.
+func (w *rowh) InjectDecoder(decoder *admission.Decoder) error {
+	w.decoder = decoder
+	return nil
+}
+
+// DecodeResourceOffer decodes the ResourceOffer from the incoming request.
+func (w *rowh) DecodeResourceOffer(obj runtime.RawExtension) (*sharingv1alpha1.ResourceOffer, error) {
+	var ro sharingv1alpha1.ResourceOffer
+	err := w.decoder.DecodeRaw(obj, &ro)
+	return &ro, err
+}
+
+// Handle implements the ResourceOffer mutating webhook logic.
+//
+//nolint:gocritic // The signature of this method is imposed by controller runtime.
+func (w *rowh) Handle(ctx context.Context, req admission.Request) admission.Response {
+	ro, err := w.DecodeResourceOffer(req.Object)
+	if err != nil {
+		klog.Errorf("Failed decoding ResourceOffer object: %v", err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// The old object is nil in case of creation.
+	var old *sharingv1alpha1.ResourceOffer
+	if req.Operation != admissionv1.Create {
+		if old, err = w.DecodeResourceOffer(req.OldObject); err != nil {
+			klog.Errorf("Failed decoding ResourceOffer object: %v", err)
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+
+	if err := MutateApproval(ro, old, &req.UserInfo, time.Now()); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	marshaledRo, err := json.Marshal(ro)
+	if err != nil {
+		klog.Errorf("Failed marshaling ResourceOffer object: %v", err)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledRo)
+}
+
+// approvalInfoAnnotations are the annotations recording the information about the decision taken concerning a ResourceOffer.
+var approvalInfoAnnotations = []string{
+	consts.ResourceOfferApprovedByAnnotationKey,
+	consts.ResourceOfferApprovalTimestampAnnotationKey,
+	consts.ResourceOfferApprovalGenerationAnnotationKey,
+}
+
+// MutateApproval records the given user, timestamp and the current generation of the ResourceOffer in its annotations,
+// in case a new decision has been taken (i.e., the approval annotation changed, or the generation annotation has been
+// set to refresh a previous decision). Otherwise, it restores the previous values, to prevent tampering. Decisions are
+// accepted only from local users modifying an existing ResourceOffer (i.e., the old object is not nil), hence preventing
+// the provider from approving its own ResourceOffers, either at creation time or through the identity granted to it.
+func MutateApproval(ro, old *sharingv1alpha1.ResourceOffer, userInfo *authenticationv1.UserInfo, now time.Time) error {
+	if old == nil || IsPeerUser(userInfo) {
+		restoreAnnotation(ro, old, consts.ResourceOfferApprovalAnnotationKey)
+		for _, key := range approvalInfoAnnotations {
+			restoreAnnotation(ro, old, key)
+		}
+		return nil
+	}
+
+	approval := consts.ResourceOfferApproval(ro.Annotations[consts.ResourceOfferApprovalAnnotationKey])
+	switch approval {
+	case "", consts.ResourceOfferApprovalAccepted, consts.ResourceOfferApprovalRefused:
+	default:
+		return fmt.Errorf("invalid %v annotation value %q (allowed values: %v, %v)", consts.ResourceOfferApprovalAnnotationKey,
+			approval, consts.ResourceOfferApprovalAccepted, consts.ResourceOfferApprovalRefused)
+	}
+
+	if approval == consts.ResourceOfferApproval(old.Annotations[consts.ResourceOfferApprovalAnnotationKey]) &&
+		(approval == "" || ro.Annotations[consts.ResourceOfferApprovalGenerationAnnotationKey] ==
+			old.Annotations[consts.ResourceOfferApprovalGenerationAnnotationKey]) {
+		for _, key := range approvalInfoAnnotations {
+			restoreAnnotation(ro, old, key)
+		}
+		return nil
+	}
+
+	if approval == "" {
+		for _, key := range approvalInfoAnnotations {
+			delete(ro.Annotations, key)
+		}
+		return nil
+	}
+
+	ro.Annotations[consts.ResourceOfferApprovedByAnnotationKey] = userInfo.Username
+	ro.Annotations[consts.ResourceOfferApprovalTimestampAnnotationKey] = now.UTC().Format(time.RFC3339)
+	ro.Annotations[consts.ResourceOfferApprovalGenerationAnnotationKey] = strconv.FormatInt(ro.Generation, 10)
+	return nil
+}
+
+// IsPeerUser returns whether the given user corresponds to the identity granted to a remote cluster.
+func IsPeerUser(userInfo *authenticationv1.UserInfo) bool {
+	for _, group := range userInfo.Groups {
+		if group == consts.PeerIdentityGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreAnnotation restores the value of the given annotation from the old object (if any).
+func restoreAnnotation(ro, old *sharingv1alpha1.ResourceOffer, key string) {
+	var value string
+	var found bool
+	if old != nil {
+		value, found = old.Annotations[key]
+	}
+	if !found {
+		delete(ro.Annotations, key)
+		return
+	}
+
+	if ro.Annotations == nil {
+		ro.Annotations = map[string]string{}
+	}
+	ro.Annotations[key] = value
+}
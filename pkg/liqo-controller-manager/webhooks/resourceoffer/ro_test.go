@@ -0,0 +1,207 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rowh_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	rowh "github.com/liqotech/liqo/pkg/liqo-controller-manager/webhooks/resourceoffer"
+)
+
+var _ = Describe("ResourceOffer approval mutation", func() {
+	const (
+		username  = "alice"
+		timestamp = "2022-11-03T10:00:00Z"
+	)
+
+	var (
+		ro, old  *sharingv1alpha1.ResourceOffer
+		userInfo authenticationv1.UserInfo
+		now      time.Time
+		err      error
+	)
+
+	forge := func(annotations map[string]string) *sharingv1alpha1.ResourceOffer {
+		return &sharingv1alpha1.ResourceOffer{ObjectMeta: metav1.ObjectMeta{Generation: 1, Annotations: annotations}}
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2022, 11, 4, 12, 30, 0, 0, time.UTC)
+		old = forge(nil)
+		userInfo = authenticationv1.UserInfo{Username: username, Groups: []string{"system:authenticated"}}
+	})
+
+	JustBeforeEach(func() { err = rowh.MutateApproval(ro, old, &userInfo, now) })
+
+	When("the approval annotation is set", func() {
+		BeforeEach(func() {
+			ro = forge(map[string]string{consts.ResourceOfferApprovalAnnotationKey: string(consts.ResourceOfferApprovalAccepted)})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should record the approver", func() {
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovedByAnnotationKey, username))
+		})
+		It("should record the timestamp", func() {
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalTimestampAnnotationKey, "2022-11-04T12:30:00Z"))
+		})
+		It("should record the generation", func() {
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalGenerationAnnotationKey, "1"))
+		})
+	})
+
+	When("the ResourceOffer is created with the approval annotations", func() {
+		BeforeEach(func() {
+			old = nil
+			ro = forge(map[string]string{
+				consts.ResourceOfferApprovalAnnotationKey:           string(consts.ResourceOfferApprovalAccepted),
+				consts.ResourceOfferApprovedByAnnotationKey:         "mallory",
+				consts.ResourceOfferApprovalGenerationAnnotationKey: "1",
+				"other": "value",
+			})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should remove the approval annotations", func() {
+			Expect(ro.Annotations).To(Equal(map[string]string{"other": "value"}))
+		})
+	})
+
+	When("the approval annotation is set by a peer", func() {
+		BeforeEach(func() {
+			userInfo = authenticationv1.UserInfo{Username: "remote-cluster-id", Groups: []string{consts.PeerIdentityGroup, "system:authenticated"}}
+			old = forge(map[string]string{consts.ResourceOfferApprovalAnnotationKey: string(consts.ResourceOfferApprovalRefused)})
+			ro = forge(map[string]string{
+				consts.ResourceOfferApprovalAnnotationKey:           string(consts.ResourceOfferApprovalAccepted),
+				consts.ResourceOfferApprovalGenerationAnnotationKey: "1",
+			})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should restore the previous approval information", func() {
+			Expect(ro.Annotations).To(Equal(map[string]string{consts.ResourceOfferApprovalAnnotationKey: string(consts.ResourceOfferApprovalRefused)}))
+		})
+	})
+
+	When("the approval annotations are removed by a peer", func() {
+		BeforeEach(func() {
+			userInfo = authenticationv1.UserInfo{Username: "remote-cluster-id", Groups: []string{consts.PeerIdentityGroup}}
+			old = forge(map[string]string{
+				consts.ResourceOfferApprovalAnnotationKey:   string(consts.ResourceOfferApprovalAccepted),
+				consts.ResourceOfferApprovedByAnnotationKey: "bob",
+			})
+			ro = forge(nil)
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should preserve the local decision", func() {
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalAnnotationKey, string(consts.ResourceOfferApprovalAccepted)))
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovedByAnnotationKey, "bob"))
+		})
+	})
+
+	When("the approval is refreshed for a new generation", func() {
+		BeforeEach(func() {
+			old = forge(map[string]string{
+				consts.ResourceOfferApprovalAnnotationKey:           string(consts.ResourceOfferApprovalAccepted),
+				consts.ResourceOfferApprovedByAnnotationKey:         "bob",
+				consts.ResourceOfferApprovalTimestampAnnotationKey:  timestamp,
+				consts.ResourceOfferApprovalGenerationAnnotationKey: "1",
+			})
+			old.Generation = 2
+			ro = old.DeepCopy()
+			ro.Annotations[consts.ResourceOfferApprovalGenerationAnnotationKey] = "2"
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should record the new approval information", func() {
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovedByAnnotationKey, username))
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalTimestampAnnotationKey, "2022-11-04T12:30:00Z"))
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalGenerationAnnotationKey, "2"))
+		})
+	})
+
+	When("the approval annotation has an invalid value", func() {
+		BeforeEach(func() {
+			ro = forge(map[string]string{consts.ResourceOfferApprovalAnnotationKey: "maybe"})
+		})
+
+		It("should fail", func() { Expect(err).To(HaveOccurred()) })
+	})
+
+	When("the approval annotation is not modified", func() {
+		BeforeEach(func() {
+			old = forge(map[string]string{
+				consts.ResourceOfferApprovalAnnotationKey:           string(consts.ResourceOfferApprovalRefused),
+				consts.ResourceOfferApprovedByAnnotationKey:         "bob",
+				consts.ResourceOfferApprovalTimestampAnnotationKey:  timestamp,
+				consts.ResourceOfferApprovalGenerationAnnotationKey: "1",
+			})
+			ro = forge(map[string]string{
+				consts.ResourceOfferApprovalAnnotationKey:           string(consts.ResourceOfferApprovalRefused),
+				consts.ResourceOfferApprovedByAnnotationKey:         "mallory",
+				consts.ResourceOfferApprovalGenerationAnnotationKey: "1",
+			})
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should restore the previous approval information", func() {
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovedByAnnotationKey, "bob"))
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalTimestampAnnotationKey, timestamp))
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalGenerationAnnotationKey, "1"))
+		})
+	})
+
+	When("the approval annotation is modified", func() {
+		BeforeEach(func() {
+			old = forge(map[string]string{
+				consts.ResourceOfferApprovalAnnotationKey:          string(consts.ResourceOfferApprovalAccepted),
+				consts.ResourceOfferApprovedByAnnotationKey:        "bob",
+				consts.ResourceOfferApprovalTimestampAnnotationKey: timestamp,
+			})
+			ro = old.DeepCopy()
+			ro.Annotations[consts.ResourceOfferApprovalAnnotationKey] = string(consts.ResourceOfferApprovalRefused)
+		})
+
+		It("should update the approval information", func() {
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovedByAnnotationKey, username))
+			Expect(ro.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalTimestampAnnotationKey, "2022-11-04T12:30:00Z"))
+		})
+	})
+
+	When("the approval annotation is removed", func() {
+		BeforeEach(func() {
+			old = forge(map[string]string{
+				consts.ResourceOfferApprovalAnnotationKey:          string(consts.ResourceOfferApprovalAccepted),
+				consts.ResourceOfferApprovedByAnnotationKey:        "bob",
+				consts.ResourceOfferApprovalTimestampAnnotationKey: timestamp,
+			})
+			ro = old.DeepCopy()
+			delete(ro.Annotations, consts.ResourceOfferApprovalAnnotationKey)
+		})
+
+		It("should remove the approval information", func() {
+			Expect(ro.Annotations).ToNot(HaveKey(consts.ResourceOfferApprovedByAnnotationKey))
+			Expect(ro.Annotations).ToNot(HaveKey(consts.ResourceOfferApprovalTimestampAnnotationKey))
+		})
+	})
+})
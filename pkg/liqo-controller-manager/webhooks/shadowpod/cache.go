@@ -70,7 +70,7 @@ func (spv *Validator) initializeCache(ctx context.Context) (err error) {
 		pi := createPeeringInfo(discoveryv1alpha1.ClusterIdentity{
 			ClusterID:   clusterID,
 			ClusterName: clusterName,
-		}, ro.Spec.ResourceQuota.Hard)
+		}, ro.Spec.ResourceQuota.Hard, ro.Spec.ExtendedResources)
 
 		// Get the List of shadow pods running on the cluster with a given clusterID
 		shadowPodList, err := spv.getShadowPodListByClusterID(ctx, clusterID)
@@ -175,7 +175,7 @@ func (pi *peeringInfo) checkAndAddShadowPods(shadowPod *vkv1alpha1.ShadowPod, ns
 	_, found = pi.shadowPods[nsname.String()]
 	if !found {
 		// Errors are intentionally ignored here.
-		spQuota, _ := getQuotaFromShadowPod(shadowPod, false)
+		spQuota, _ := pi.getQuotaFromShadowPod(shadowPod, false)
 		pi.addShadowPod(createShadowPodDescription(shadowPod.GetName(), shadowPod.GetNamespace(), shadowPod.GetUID(), *spQuota))
 	}
 	return
@@ -205,7 +205,7 @@ func (spv *Validator) checkAlignmentResourceOfferPeeringInfo(ctx context.Context
 		if newPI, found := spv.PeeringCache.peeringInfo.LoadOrStore(clusterID, createPeeringInfo(discoveryv1alpha1.ClusterIdentity{
 			ClusterID:   clusterID,
 			ClusterName: clusterName,
-		}, ro.Spec.ResourceQuota.Hard)); !found {
+		}, ro.Spec.ResourceQuota.Hard, ro.Spec.ExtendedResources)); !found {
 			klog.V(4).Infof("ResourceOffer %q not found in cache, adding it", clusterName)
 			// Get the List of ShadowPods running on the cluster
 			shadowPodList, err := spv.getShadowPodListByClusterID(ctx, clusterID)
@@ -233,10 +233,10 @@ func (spv *Validator) checkAlignmentResourceOfferPeeringInfo(ctx context.Context
 	return nil
 }
 
-func (pi *peeringInfo) alignResourceOfferUpdates(resources corev1.ResourceList) {
+func (pi *peeringInfo) alignResourceOfferUpdates(resources corev1.ResourceList, classes []sharing.ExtendedResourceClass) {
 	pi.mu.Lock()
 	defer pi.mu.Unlock()
-	pi.updateQuotas(resources)
+	pi.updateQuotas(resources, classes)
 	klog.V(4).Infof("Quota of PeeringInfo for cluster %q has been updated", pi.clusterIdentity.String())
 }
 
@@ -84,7 +84,7 @@ var _ = Describe("Webhook Cache", func() {
 			BeforeEach(func() {
 				spList = forgeShadowPodList(fakeShadowPod, fakeShadowPod2)
 				sp1 := createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID, *resourceQuota4)
-				peering = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peering = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 				peering.addShadowPod(sp1)
 			})
 			It("should align correctly the peering info", func() {
@@ -109,7 +109,7 @@ var _ = Describe("Webhook Cache", func() {
 				sp4 := createShadowPodDescription(testShadowPodName+"-4", testNamespace, testShadowPodUID+"-4", *resourceQuota4)
 				sp4.creationTimestamp = time.Now().Add(time.Duration(-40) * time.Second)
 				sp5 := createShadowPodDescription(testShadowPodName+"-5", testNamespace, testShadowPodUID+"-5", *resourceQuota4)
-				peering = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peering = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 				peering.addShadowPod(sp1)
 				peering.addShadowPod(sp3)
 				peering.addShadowPod(sp4)
@@ -152,8 +152,8 @@ var _ = Describe("Webhook Cache", func() {
 				sp2 := createShadowPodDescription(testShadowPodName2, testNamespace, testShadowPodUID2, *resourceQuota4)
 				sp5 := createShadowPodDescription(testShadowPodName+"-5", testNamespace+"-3", testShadowPodUID+"-5", *resourceQuota4)
 				sp6 := createShadowPodDescription(testShadowPodName+"-6", testNamespace+"-3", testShadowPodUID+"-6", *resourceQuota4)
-				peering = createPeeringInfo(*clusterIdentity, *resourceQuota)
-				peeringToBeDeleted := createPeeringInfo(*forgeClusterIdentity(clusterName3, clusterID3), *resourceQuota)
+				peering = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
+				peeringToBeDeleted := createPeeringInfo(*forgeClusterIdentity(clusterName3, clusterID3), *resourceQuota, nil)
 				peering.addShadowPod(sp1)
 				peering.addShadowPod(sp2)
 				peeringToBeDeleted.addShadowPod(sp5)
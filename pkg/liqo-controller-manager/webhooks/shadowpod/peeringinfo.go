@@ -21,11 +21,14 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	quotav1 "k8s.io/apiserver/pkg/quota/v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharing "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
 )
 
 // PeeringInfo is the struct that holds the information about the peering with a remote cluster.
@@ -34,7 +37,9 @@ type peeringInfo struct {
 	shadowPods      map[string]*Description
 	totalQuota      corev1.ResourceList
 	usedQuota       corev1.ResourceList
-	mu              sync.RWMutex
+	// extendedResources are the classes of devices backing the offered extended resources.
+	extendedResources []sharing.ExtendedResourceClass
+	mu                sync.RWMutex
 }
 
 /**
@@ -42,24 +47,27 @@ type peeringInfo struct {
  */
 
 // createPeeringInfo creates a new PeeringInfo struct.
-func createPeeringInfo(clusterIdentity discoveryv1alpha1.ClusterIdentity, resources corev1.ResourceList) *peeringInfo {
+func createPeeringInfo(clusterIdentity discoveryv1alpha1.ClusterIdentity, resources corev1.ResourceList,
+	classes []sharing.ExtendedResourceClass) *peeringInfo {
 	return &peeringInfo{
-		clusterIdentity: clusterIdentity,
-		shadowPods:      map[string]*Description{},
-		totalQuota:      resources,
-		usedQuota:       generateQuotaPattern(resources),
+		clusterIdentity:   clusterIdentity,
+		shadowPods:        map[string]*Description{},
+		totalQuota:        resources,
+		usedQuota:         generateQuotaPattern(resources),
+		extendedResources: classes,
 	}
 }
 
 // getOrCreatePeeringInfo returns the PeeringInfo struct for the given clusterIdentity. If it doesn't exist, it creates a new one.
-func (pc *peeringCache) getOrCreatePeeringInfo(clusterIdentity discoveryv1alpha1.ClusterIdentity, roQuota corev1.ResourceList) *peeringInfo {
-	pi, found := pc.peeringInfo.LoadOrStore(clusterIdentity.ClusterID, createPeeringInfo(clusterIdentity, roQuota))
+func (pc *peeringCache) getOrCreatePeeringInfo(clusterIdentity discoveryv1alpha1.ClusterIdentity, roQuota corev1.ResourceList,
+	roClasses []sharing.ExtendedResourceClass) *peeringInfo {
+	pi, found := pc.peeringInfo.LoadOrStore(clusterIdentity.ClusterID, createPeeringInfo(clusterIdentity, roQuota, roClasses))
 	if !found {
 		klog.V(4).Infof("PeeringInfo not found for cluster %q, created...", clusterIdentity.String())
 		klog.V(5).Infof("New Quota limits for cluster %q %s", clusterIdentity.String(), quotaFormatter(pi.(*peeringInfo).totalQuota))
 		return pi.(*peeringInfo)
 	}
-	pi.(*peeringInfo).alignResourceOfferUpdates(roQuota)
+	pi.(*peeringInfo).alignResourceOfferUpdates(roQuota, roClasses)
 	return pi.(*peeringInfo)
 }
 
@@ -149,6 +157,53 @@ func (pi *peeringInfo) testAndUpdateCreation(ctx context.Context, c client.Clien
 	return nil
 }
 
+func (pi *peeringInfo) testAndUpdateResize(sp *vkv1alpha1.ShadowPod, dryRun bool) error {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+
+	spd, err := pi.getShadowPodDescription(sp)
+	if err != nil {
+		return err
+	}
+
+	quota, err := pi.getQuotaFromShadowPod(sp, true)
+	if err != nil {
+		return err
+	}
+
+	klog.V(5).Infof("ShadowPod resource limits %s (previous: %s)", quotaFormatter(*quota), quotaFormatter(spd.quota))
+
+	// The quota of terminating shadowpods has already been released, hence no accounting is required.
+	if !spd.running {
+		return nil
+	}
+
+	klog.V(5).Infof("Cluster %q total quota %s", pi.clusterIdentity, quotaFormatter(pi.totalQuota))
+	klog.V(5).Infof("Cluster %q used quota %s", pi.clusterIdentity, quotaFormatter(pi.usedQuota))
+	klog.V(5).Infof("Cluster %q free quota %s", pi.clusterIdentity, quotaFormatter(pi.getFreeQuota()))
+
+	// Only the increments need to be checked against the free quota.
+	increments := corev1.ResourceList{}
+	for key, val := range quotav1.Subtract(*quota, spd.quota) {
+		if val.Sign() > 0 {
+			increments[key] = val
+		}
+	}
+
+	if err := pi.checkQuota(increments); err != nil {
+		return err
+	}
+	if !dryRun {
+		pi.subUsedResources(spd.quota)
+		pi.addUsedResources(*quota)
+		spd.quota = *quota
+		klog.V(5).Infof("Cluster %q updated total quota %s", pi.clusterIdentity.String(), quotaFormatter(pi.totalQuota))
+		klog.V(5).Infof("Cluster %q updated used quota %s", pi.clusterIdentity.String(), quotaFormatter(pi.usedQuota))
+		klog.V(5).Infof("Cluster %q updated free quota %s", pi.clusterIdentity.String(), quotaFormatter(pi.getFreeQuota()))
+	}
+	return nil
+}
+
 func (pi *peeringInfo) updateDeletion(sp *vkv1alpha1.ShadowPod, dryRun bool) error {
 	pi.mu.Lock()
 	defer pi.mu.Unlock()
@@ -175,8 +230,14 @@ func (pi *peeringInfo) updateDeletion(sp *vkv1alpha1.ShadowPod, dryRun bool) err
 }
 
 func (pi *peeringInfo) checkResources(spd *Description) error {
+	return pi.checkQuota(spd.quota)
+}
+
+// checkQuota checks whether the given quota fits the free one of the peering. The class-qualified extended resources
+// are checked both on the quota of the specific class and on the aggregate one of the corresponding extended resource.
+func (pi *peeringInfo) checkQuota(quota corev1.ResourceList) error {
 	freePeeringQuota := pi.getFreeQuota()
-	for key, val := range spd.quota {
+	for key, val := range quota {
 		if freeQuota, ok := freePeeringQuota[key]; ok {
 			if freeQuota.Cmp(val) < 0 {
 				return fmt.Errorf("peering %s quota usage exceeded - free %s / requested %s",
@@ -189,12 +250,24 @@ func (pi *peeringInfo) checkResources(spd *Description) error {
 	return nil
 }
 
-func (pi *peeringInfo) updateQuotas(newQuota corev1.ResourceList) {
+func (pi *peeringInfo) updateQuotas(newQuota corev1.ResourceList, newClasses []sharing.ExtendedResourceClass) {
 	klog.V(5).Infof("Cluster %q old total quota %s", pi.clusterIdentity.String(), quotaFormatter(pi.totalQuota))
 	pi.totalQuota = newQuota.DeepCopy()
+	pi.extendedResources = newClasses
 	klog.V(5).Infof("Cluster %q new total quota %s", pi.clusterIdentity.String(), quotaFormatter(pi.totalQuota))
 }
 
+// getQuotaFromShadowPod returns the quota consumed by the given shadowpod, where the class-qualified extended resources
+// are also accounted on the corresponding extended resources.
+func (pi *peeringInfo) getQuotaFromShadowPod(sp *vkv1alpha1.ShadowPod, validate bool) (*corev1.ResourceList, error) {
+	quota, err := getQuotaFromShadowPod(sp, validate)
+	if err != nil {
+		return nil, err
+	}
+	aggregated := extendedresources.Aggregate(*quota, pi.extendedResources)
+	return &aggregated, nil
+}
+
 func generateQuotaPattern(quota corev1.ResourceList) corev1.ResourceList {
 	zero := resource.MustParse("0")
 	result := corev1.ResourceList{}
@@ -20,10 +20,12 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 )
 
@@ -53,7 +55,7 @@ var _ = Describe("Peering Info", func() {
 
 		cache = spValidator.PeeringCache
 
-		peeringInfoTest = createPeeringInfo(*clusterIdentity, *resourceQuota)
+		peeringInfoTest = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 
 		containers = []containerResource{{cpu: int64(resourceCPU), memory: int64(resourceMemory)}}
 
@@ -64,12 +66,12 @@ var _ = Describe("Peering Info", func() {
 
 	Describe("Get or Create a PeeringInfo", func() {
 		JustBeforeEach(func() {
-			peeringInfo = cache.getOrCreatePeeringInfo(*clusterIdentity, *resourceQuota)
+			peeringInfo = cache.getOrCreatePeeringInfo(*clusterIdentity, *resourceQuota, nil)
 		})
 
 		When("The Peering Info exists", func() {
 			BeforeEach(func() {
-				cache.peeringInfo.Store(clusterID, createPeeringInfo(*clusterIdentity, *forgeResourceList(int64(resourceCPU*2), int64(resourceMemory*2))))
+				cache.peeringInfo.Store(clusterID, createPeeringInfo(*clusterIdentity, *forgeResourceList(int64(resourceCPU*2), int64(resourceMemory*2)), nil))
 			})
 			It("should return a peering info", func() {
 				Expect(peeringInfo).To(Equal(peeringInfoTest))
@@ -89,7 +91,7 @@ var _ = Describe("Peering Info", func() {
 
 		When("The Peering Info exists", func() {
 			BeforeEach(func() {
-				cache.peeringInfo.Store(clusterID, createPeeringInfo(*clusterIdentity, *resourceQuota))
+				cache.peeringInfo.Store(clusterID, createPeeringInfo(*clusterIdentity, *resourceQuota, nil))
 			})
 			It("should return a peering info and found is true", func() {
 				Expect(found).To(BeTrue())
@@ -112,7 +114,7 @@ var _ = Describe("Peering Info", func() {
 		When("resources are available", func() {
 			BeforeEach(func() {
 				spd = createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID, *resourceQuota)
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 			})
 			It("should not return any error", func() {
 				Expect(err).To(BeNil())
@@ -121,7 +123,7 @@ var _ = Describe("Peering Info", func() {
 		When("CPU resources are not available", func() {
 			BeforeEach(func() {
 				resourceQuotaLower := forgeResourceList(int64(resourceCPU/2), int64(resourceMemory))
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuotaLower)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuotaLower, nil)
 				spd = createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID, *resourceQuota)
 				freeQuota := peeringInfo.getFreeQuota()
 				errTest = fmt.Errorf("peering %s quota usage exceeded - free %s / requested %s",
@@ -133,7 +135,7 @@ var _ = Describe("Peering Info", func() {
 		})
 		When("A requested resource quota is not defined for a specific peering", func() {
 			BeforeEach(func() {
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 				resourcesWithGpu := forgeResourceList(int64(resourceCPU), int64(resourceMemory), 1000)
 				spd = createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID, *resourcesWithGpu)
 				errTest = fmt.Errorf("nvidia.com/gpu quota limit not found for this peering")
@@ -143,6 +145,27 @@ var _ = Describe("Peering Info", func() {
 				Expect(err).To(Equal(errTest))
 			})
 		})
+		When("A requested extended resource is available", func() {
+			BeforeEach(func() {
+				peeringInfo = createPeeringInfo(*clusterIdentity, *forgeResourceList(int64(resourceCPU), int64(resourceMemory), 2), nil)
+				spd = createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID,
+					*forgeResourceList(int64(resourceCPU), int64(resourceMemory), 2))
+			})
+			It("should not return any error", func() {
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+		When("A requested extended resource is not available", func() {
+			BeforeEach(func() {
+				peeringInfo = createPeeringInfo(*clusterIdentity, *forgeResourceList(int64(resourceCPU), int64(resourceMemory), 1), nil)
+				spd = createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID,
+					*forgeResourceList(int64(resourceCPU), int64(resourceMemory), 2))
+				errTest = fmt.Errorf("peering nvidia.com/gpu quota usage exceeded - free 1 / requested 2")
+			})
+			It("should return an error", func() {
+				Expect(err).To(Equal(errTest))
+			})
+		})
 	})
 
 	Describe("Test and update creation", func() {
@@ -153,7 +176,7 @@ var _ = Describe("Peering Info", func() {
 		When("resources are available and dryRun flag is false", func() {
 			BeforeEach(func() {
 				dryRun = false
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 			})
 			It("should not return any error and available resources will be decremented", func() {
 				Expect(err).To(BeNil())
@@ -164,7 +187,7 @@ var _ = Describe("Peering Info", func() {
 		When("resources are available and dryRun flag is true", func() {
 			BeforeEach(func() {
 				dryRun = true
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 			})
 			It("should not return any error and available resources will not be decremented", func() {
 				Expect(err).To(BeNil())
@@ -177,7 +200,7 @@ var _ = Describe("Peering Info", func() {
 			BeforeEach(func() {
 				dryRun = false
 				resourceQuotaLower := forgeResourceList(int64(resourceCPU/2), int64(resourceMemory))
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuotaLower)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuotaLower, nil)
 			})
 			It("should return an error and available resources will not be decremented", func() {
 				Expect(err).ToNot(BeNil())
@@ -188,13 +211,50 @@ var _ = Describe("Peering Info", func() {
 			BeforeEach(func() {
 				dryRun = true
 				resourceQuotaLower := forgeResourceList(int64(resourceCPU/2), int64(resourceMemory))
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuotaLower)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuotaLower, nil)
 			})
 			It("should return an error and available resources will not be decremented", func() {
 				Expect(err).ToNot(BeNil())
 				Expect(peeringInfo.usedQuota.Cpu().Value()).To(Equal(freeQuotaZero.Cpu().Value()))
 			})
 		})
+		When("a class of devices is requested", func() {
+			const (
+				gpu      = corev1.ResourceName("nvidia.com/gpu")
+				gpuClass = corev1.ResourceName("nvidia.com/gpu-1a2b3c4d")
+			)
+
+			BeforeEach(func() {
+				dryRun = false
+				quota := *forgeResourceList(int64(resourceCPU), int64(resourceMemory), 2)
+				quota[gpuClass] = *resource.NewQuantity(1, resource.DecimalSI)
+				peeringInfo = createPeeringInfo(*clusterIdentity, quota, []sharingv1alpha1.ExtendedResourceClass{{Name: gpu, ResourceName: gpuClass}})
+				shadowPod.Spec.Pod.Containers[0].Resources.Limits[gpuClass] = *resource.NewQuantity(1, resource.DecimalSI)
+			})
+			It("should account it both on the quota of the class and on the aggregated one", func() {
+				Expect(err).ToNot(HaveOccurred())
+				Expect(peeringInfo.usedQuota.Name(gpu, resource.DecimalSI).Value()).To(BeNumerically("==", 1))
+				Expect(peeringInfo.usedQuota.Name(gpuClass, resource.DecimalSI).Value()).To(BeNumerically("==", 1))
+			})
+
+			When("the quota of the class is exceeded", func() {
+				BeforeEach(func() {
+					shadowPod.Spec.Pod.Containers[0].Resources.Limits[gpuClass] = *resource.NewQuantity(2, resource.DecimalSI)
+				})
+				It("should return an error", func() {
+					Expect(err).To(MatchError("peering nvidia.com/gpu-1a2b3c4d quota usage exceeded - free 1 / requested 2"))
+				})
+			})
+
+			When("the aggregated quota is exceeded", func() {
+				BeforeEach(func() {
+					peeringInfo.addUsedResources(corev1.ResourceList{gpu: *resource.NewQuantity(2, resource.DecimalSI)})
+				})
+				It("should return an error", func() {
+					Expect(err).To(MatchError("peering nvidia.com/gpu quota usage exceeded - free 0 / requested 1"))
+				})
+			})
+		})
 	})
 
 	Describe("Update deletion", func() {
@@ -205,7 +265,7 @@ var _ = Describe("Peering Info", func() {
 		When("Shadow pod description exists and dryRun flag is false", func() {
 			BeforeEach(func() {
 				dryRun = false
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 				peeringInfo.addShadowPod(createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID, *resourceQuota))
 			})
 			It("should not return any error and available resources will be incremented", func() {
@@ -217,7 +277,7 @@ var _ = Describe("Peering Info", func() {
 		When("Shadow pod description exists and dryRun flag is true", func() {
 			BeforeEach(func() {
 				dryRun = true
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 				peeringInfo.addShadowPod(createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID, *resourceQuota))
 			})
 			It("should not return any error and available resources will not be incremented", func() {
@@ -228,7 +288,7 @@ var _ = Describe("Peering Info", func() {
 		})
 		When("Shadow pod description does not exist", func() {
 			BeforeEach(func() {
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 			})
 			It("should return an error", func() {
 				Expect(err).ToNot(BeNil())
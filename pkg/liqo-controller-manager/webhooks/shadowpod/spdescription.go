@@ -17,6 +17,8 @@ package shadowpod
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -26,6 +28,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
 )
 
 // Description is a struct that contains the main informations about a shadow pod.
@@ -49,7 +52,7 @@ func createShadowPodDescription(name, namespace string, uid types.UID, resources
 
 func (pi *peeringInfo) getOrCreateShadowPodDescription(ctx context.Context, c client.Client, sp *vkv1alpha1.ShadowPod) (*Description, error) {
 	nsname := types.NamespacedName{Name: sp.Name, Namespace: sp.Namespace}
-	spQuota, err := getQuotaFromShadowPod(sp, true)
+	spQuota, err := pi.getQuotaFromShadowPod(sp, true)
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +165,15 @@ func getQuotaFromShadowPod(shadowpod *vkv1alpha1.ShadowPod, validate bool) (*cor
 }
 
 func quotaFormatter(quota corev1.ResourceList) string {
-	return fmt.Sprintf("[ cpu: %v, memory %v, storage: %v, ephemeral-storage: %v ]",
-		quota.Cpu(), quota.Memory(), quota.Storage(), quota.StorageEphemeral())
+	// Extended resources (e.g., GPUs) are included only if present, sorted by name for readability.
+	var extended []string
+	for name, value := range quota {
+		if extendedresources.IsExtendedResourceName(name) {
+			extended = append(extended, fmt.Sprintf(", %s: %v", name, value.String()))
+		}
+	}
+	sort.Strings(extended)
+
+	return fmt.Sprintf("[ cpu: %v, memory %v, storage: %v, ephemeral-storage: %v%s ]",
+		quota.Cpu(), quota.Memory(), quota.Storage(), quota.StorageEphemeral(), strings.Join(extended, ""))
 }
@@ -53,7 +53,7 @@ var _ = Describe("ShadowPod Description", func() {
 
 		spValidator = webhook.Admission{Handler: NewValidator(fakeClient, false)}.Handler.(*Validator)
 
-		peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+		peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 
 		spNamespacedName = types.NamespacedName{Name: testShadowPodName, Namespace: testNamespace}
 
@@ -219,4 +219,11 @@ var _ = Describe("ShadowPod Description", func() {
 		})
 	})
 
+	Describe("Format a quota", func() {
+		It("should include the extended resources, if any", func() {
+			Expect(quotaFormatter(*forgeResourceList(1, 2))).To(Equal("[ cpu: 1, memory 2, storage: 0, ephemeral-storage: 0 ]"))
+			Expect(quotaFormatter(*forgeResourceList(1, 2, 3))).To(Equal(
+				"[ cpu: 1, memory 2, storage: 0, ephemeral-storage: 0, nvidia.com/gpu: 3 ]"))
+		})
+	})
 })
@@ -23,6 +23,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	quotav1 "k8s.io/apiserver/pkg/quota/v1"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -127,7 +128,7 @@ func (spv *Validator) HandleCreate(ctx context.Context, req *admission.Request)
 	peeringInfo := spv.PeeringCache.getOrCreatePeeringInfo(discoveryv1alpha1.ClusterIdentity{
 		ClusterID:   clusterID,
 		ClusterName: clusterName,
-	}, resourceoffer.Spec.ResourceQuota.Hard)
+	}, resourceoffer.Spec.ResourceQuota.Hard, resourceoffer.Spec.ExtendedResources)
 
 	err = peeringInfo.testAndUpdateCreation(ctx, spv.client, shadowpod, *req.DryRun)
 	if err != nil {
@@ -169,11 +170,44 @@ func (spv *Validator) HandleUpdate(ctx context.Context, req *admission.Request)
 		return admission.Denied("shadopow Cluster ID label is changed")
 	}
 
-	if pod.CheckShadowPodUpdate(&shadowpod.Spec.Pod, &oldShadowpod.Spec.Pod) {
+	// The check is performed on a copy, as the updated object gets mutated.
+	if !pod.CheckShadowPodUpdate(&oldShadowpod.Spec.Pod, shadowpod.Spec.Pod.DeepCopy()) {
+		return admission.Denied("")
+	}
+
+	if !spv.enableResourceValidation {
 		return admission.Allowed("")
 	}
 
-	return admission.Denied("")
+	// Re-validate the quota usage in case the resources have been modified (i.e., in-place resize).
+	quota, err := getQuotaFromShadowPod(shadowpod, false)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	oldQuota, err := getQuotaFromShadowPod(oldShadowpod, false)
+	if err != nil {
+		return admission.Denied(err.Error())
+	}
+	if quotav1.Equals(*quota, *oldQuota) {
+		return admission.Allowed("")
+	}
+
+	clusterName := retrieveClusterName(ctx, spv.client, clusterID)
+	peeringInfo, found := spv.PeeringCache.getPeeringInfo(discoveryv1alpha1.ClusterIdentity{
+		ClusterID:   clusterID,
+		ClusterName: clusterName,
+	})
+	if !found {
+		klog.Warningf("PeeringInfo not found in cache for cluster %q", clusterName)
+		return admission.Denied(fmt.Sprintf("peering not found in cache for cluster %q", clusterName))
+	}
+
+	if err := peeringInfo.testAndUpdateResize(shadowpod, *req.DryRun); err != nil {
+		klog.Warning(err)
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
 }
 
 // HandleDelete is the function in charge of handling Deletion requests.
@@ -154,7 +154,7 @@ var _ = Describe("Validating webhook", func() {
 
 		When("The ResourceOffer exists and the ShadowPod Description exists and is running", func() {
 			BeforeEach(func() {
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 				peeringInfo.addShadowPod(
 					createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID, *resourceQuota))
 				spValidatorWithResources.PeeringCache.peeringInfo.Store(clusterID, peeringInfo)
@@ -173,7 +173,7 @@ var _ = Describe("Validating webhook", func() {
 		})
 		When("The ResourceOffer exists but the ShadowPod Description does not exist", func() {
 			BeforeEach(func() {
-				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota)
+				peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
 				spValidatorWithResources.PeeringCache.peeringInfo.Store(clusterID, peeringInfo)
 				containers = nil
 				containers = append(containers, containerResource{cpu: int64(resourceCPU), memory: int64(resourceMemory)})
@@ -198,4 +198,85 @@ var _ = Describe("Validating webhook", func() {
 			})
 		})
 	})
+
+	Describe("Handle update ShadowPod with resource validation", func() {
+		var fakeOldShadowPod *vkv1alpha1.ShadowPod
+
+		BeforeEach(func() {
+			containers = []containerResource{{cpu: int64(resourceCPU / 2), memory: int64(resourceMemory / 2)}}
+			fakeOldShadowPod = forgeShadowPodWithResourceLimits(containers, nil)
+
+			peeringInfo = createPeeringInfo(*clusterIdentity, *resourceQuota, nil)
+			peeringInfo.addShadowPod(createShadowPodDescription(testShadowPodName, testNamespace, testShadowPodUID,
+				*forgeResourceList(int64(resourceCPU/2), int64(resourceMemory/2))))
+			spValidatorWithResources.PeeringCache.peeringInfo.Store(clusterID, peeringInfo)
+		})
+
+		JustBeforeEach(func() {
+			request = forgeRequest(admissionv1.Update, fakeNewShadowPod, fakeOldShadowPod)
+			response = spValidatorWithResources.Handle(ctx, request)
+		})
+
+		When("only the container image is modified", func() {
+			BeforeEach(func() {
+				fakeNewShadowPod = fakeOldShadowPod.DeepCopy()
+				fakeNewShadowPod.Spec.Pod.Containers[0].Image = "test-image:v2"
+			})
+			It("request is allowed and the used quota is not modified", func() {
+				Expect(response.Allowed).To(BeTrue())
+				Expect(peeringInfo.usedQuota).To(Equal(*forgeResourceList(int64(resourceCPU/2), int64(resourceMemory/2))))
+			})
+		})
+		When("the container resources are increased and the required resources are available", func() {
+			BeforeEach(func() {
+				containers = []containerResource{{cpu: int64(resourceCPU), memory: int64(resourceMemory / 2)}}
+				fakeNewShadowPod = forgeShadowPodWithResourceLimits(containers, nil)
+			})
+			It("request is allowed and the used quota is updated", func() {
+				Expect(response.Allowed).To(BeTrue())
+				Expect(peeringInfo.usedQuota.Cpu().Value()).To(BeNumerically("==", resourceCPU))
+				Expect(peeringInfo.usedQuota.Memory().Value()).To(BeNumerically("==", resourceMemory/2))
+			})
+		})
+		When("the container resources are decreased", func() {
+			BeforeEach(func() {
+				containers = []containerResource{{cpu: int64(resourceCPU / 4), memory: int64(resourceMemory / 2)}}
+				fakeNewShadowPod = forgeShadowPodWithResourceLimits(containers, nil)
+			})
+			It("request is allowed and the used quota is updated", func() {
+				Expect(response.Allowed).To(BeTrue())
+				Expect(peeringInfo.usedQuota.Cpu().Value()).To(BeNumerically("==", resourceCPU/4))
+			})
+		})
+		When("the container resources are increased but the required resources are not available", func() {
+			BeforeEach(func() {
+				containers = []containerResource{{cpu: int64(resourceCPU * 2), memory: int64(resourceMemory / 2)}}
+				fakeNewShadowPod = forgeShadowPodWithResourceLimits(containers, nil)
+			})
+			It("request is denied and the used quota is not modified", func() {
+				Expect(response.Allowed).To(BeFalse())
+				Expect(response.Result.Code).To(BeNumerically("==", http.StatusForbidden))
+				Expect(peeringInfo.usedQuota.Cpu().Value()).To(BeNumerically("==", resourceCPU/2))
+			})
+		})
+		When("the container resources are modified but the PeeringInfo does not exist", func() {
+			BeforeEach(func() {
+				spValidatorWithResources.PeeringCache.peeringInfo.Delete(clusterID)
+				containers = []containerResource{{cpu: int64(resourceCPU), memory: int64(resourceMemory / 2)}}
+				fakeNewShadowPod = forgeShadowPodWithResourceLimits(containers, nil)
+			})
+			It("request is denied", func() {
+				Expect(response.Allowed).To(BeFalse())
+			})
+		})
+		When("an immutable field is modified", func() {
+			BeforeEach(func() {
+				fakeNewShadowPod = fakeOldShadowPod.DeepCopy()
+				fakeNewShadowPod.Spec.Pod.RestartPolicy = corev1.RestartPolicyNever
+			})
+			It("request is denied", func() {
+				Expect(response.Allowed).To(BeFalse())
+			})
+		})
+	})
 })
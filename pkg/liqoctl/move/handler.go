@@ -22,6 +22,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
 	"github.com/liqotech/liqo/pkg/liqoctl/factory"
 	"github.com/liqotech/liqo/pkg/liqoctl/output"
 	"github.com/liqotech/liqo/pkg/utils"
@@ -39,6 +40,9 @@ type Options struct {
 	ContainersRAMRequests, ContainersRAMLimits resource.Quantity
 
 	ResticPassword string
+
+	// replication is the VolumeReplication continuously replicating the volume towards the target node, if any.
+	replication *offv1alpha1.VolumeReplication
 }
 
 // Run implements the move volume command.
@@ -61,6 +65,14 @@ func (o *Options) Run(ctx context.Context) error {
 	}
 	s.Success("Pre-flight checks passed")
 
+	if o.replication, err = getVolumeReplication(ctx, o.CRClient, &pvc, o.TargetNode); err != nil {
+		o.Printer.Error.Printfln("Failed to retrieve the volume replications: %v", output.PrettyErr(err))
+		return err
+	}
+	if o.replication != nil {
+		return o.failover(ctx, &pvc)
+	}
+
 	s = o.Printer.StartSpinner("Offloading the liqo-storage namespace")
 
 	var targetNode corev1.Node
@@ -168,6 +180,17 @@ func getResticRepositoryURL(ctx context.Context, cl client.Client, isLocal bool)
 	return fmt.Sprintf("rest:http://%s.%s.svc.cluster.local:%d/", resticRegistry, namespace, resticPort), nil
 }
 
+// forgeResticPasswordEnvVar forges the environment variable configuring the password of the restic repository.
+func (o *Options) forgeResticPasswordEnvVar() corev1.EnvVar {
+	if o.replication != nil {
+		return corev1.EnvVar{
+			Name:      "RESTIC_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: o.replication.Spec.PasswordSecretRef.DeepCopy()},
+		}
+	}
+	return corev1.EnvVar{Name: "RESTIC_PASSWORD", Value: o.ResticPassword}
+}
+
 func (o *Options) forgeContainerResources() corev1.ResourceRequirements {
 	return pod.ForgeContainerResources(o.ContainersCPURequests, o.ContainersCPULimits, o.ContainersRAMRequests, o.ContainersRAMLimits)
 }
@@ -21,6 +21,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gstruct"
+	gomegatypes "github.com/onsi/gomega/types"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -311,6 +312,35 @@ var _ = Context("Move Volumes", func() {
 				})
 			})
 
+			When("creates a snapshotter job for a replicated volume", func() {
+
+				var (
+					job       *batchv1.Job
+					err       error
+					secretRef corev1.SecretKeySelector
+				)
+
+				BeforeEach(func() {
+					secretRef = corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "restic"}, Key: "password"}
+					o.replication = &offv1alpha1.VolumeReplication{Spec: offv1alpha1.VolumeReplicationSpec{PasswordSecretRef: secretRef}}
+
+					job, err = o.createSnapshotterJob(ctx, pvc, resticRepositoryURL)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(job).ToNot(BeNil())
+				})
+
+				It("should not initialize the repository", func() {
+					Expect(job.Spec.Template.Spec.InitContainers).To(BeEmpty())
+				})
+
+				It("should retrieve the password from the replication secret", func() {
+					Expect(job.Spec.Template.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+						Name:      "RESTIC_PASSWORD",
+						ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &secretRef},
+					}))
+				})
+			})
+
 		})
 
 		Context("createRestorerJob", func() {
@@ -669,4 +699,56 @@ var _ = Context("Move Volumes", func() {
 
 	})
 
+	Context("volume replication", func() {
+
+		type getVolumeReplicationTestcase struct {
+			replication *offv1alpha1.VolumeReplication
+			expected    gomegatypes.GomegaMatcher
+		}
+
+		var newReplication = func(pvcName, targetNode string, replicated bool) *offv1alpha1.VolumeReplication {
+			replication := &offv1alpha1.VolumeReplication{
+				ObjectMeta: metav1.ObjectMeta{Name: "replication", Namespace: "default"},
+				Spec:       offv1alpha1.VolumeReplicationSpec{PersistentVolumeClaimName: pvcName, TargetNode: targetNode},
+			}
+			if replicated {
+				now := metav1.Now()
+				replication.Status.LastSuccessfulReplicationTime = &now
+				replication.Status.SourceRepositoryURL = "rest:http://restic.liqo-replication.svc.cluster.local:8000/pvc1"
+				replication.Status.TargetRepositoryURL = "rest:http://restic.liqo-replication-foo.svc.cluster.local:8000/pvc1"
+			}
+			return replication
+		}
+
+		DescribeTable("getVolumeReplication function", func(c getVolumeReplicationTestcase) {
+			scheme := runtime.NewScheme()
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			Expect(offv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if c.replication != nil {
+				builder = builder.WithObjects(c.replication)
+			}
+
+			replication, err := getVolumeReplication(ctx, builder.Build(), newPvc("pvc1"), "node1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(replication).To(c.expected)
+		}, Entry("no replication", getVolumeReplicationTestcase{
+			expected: BeNil(),
+		}), Entry("replication towards the target node", getVolumeReplicationTestcase{
+			replication: newReplication("pvc1", "node1", true),
+			expected: PointTo(gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{
+				"Spec": gstruct.MatchFields(gstruct.IgnoreExtras, gstruct.Fields{"TargetNode": Equal("node1")}),
+			})),
+		}), Entry("replication towards a different node", getVolumeReplicationTestcase{
+			replication: newReplication("pvc1", "node2", true),
+			expected:    BeNil(),
+		}), Entry("replication of a different volume", getVolumeReplicationTestcase{
+			replication: newReplication("pvc2", "node1", true),
+			expected:    BeNil(),
+		}), Entry("replication not yet completed", getVolumeReplicationTestcase{
+			replication: newReplication("pvc1", "node1", false),
+			expected:    BeNil(),
+		}))
+	})
 })
@@ -0,0 +1,121 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package move
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	offv1alpha1 "github.com/liqotech/liqo/apis/offloading/v1alpha1"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+)
+
+// getVolumeReplication returns the VolumeReplication continuously replicating the given PVC towards the target node,
+// provided that at least one replication completed successfully. It returns nil if no such replication exists.
+func getVolumeReplication(ctx context.Context, cl client.Client,
+	pvc *corev1.PersistentVolumeClaim, targetNode string) (*offv1alpha1.VolumeReplication, error) {
+	var replications offv1alpha1.VolumeReplicationList
+	if err := cl.List(ctx, &replications, client.InNamespace(pvc.Namespace)); err != nil {
+		return nil, err
+	}
+
+	for i := range replications.Items {
+		replication := &replications.Items[i]
+		if replication.Spec.PersistentVolumeClaimName == pvc.Name && replication.Spec.TargetNode == targetNode &&
+			replication.Status.LastSuccessfulReplicationTime != nil &&
+			replication.Status.SourceRepositoryURL != "" && replication.Status.TargetRepositoryURL != "" {
+			return replication, nil
+		}
+	}
+	return nil, nil
+}
+
+// failover moves the given volume leveraging the repository populated by the volume replication controller,
+// hence only requiring a final incremental snapshot before restoring the data on the target node.
+func (o *Options) failover(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	s := o.Printer.StartSpinner("Suspending the volume replication")
+	if err := suspendVolumeReplication(ctx, o.CRClient, o.replication); err != nil {
+		s.Fail("Failed to suspend the volume replication: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success(fmt.Sprintf("Volume replication suspended (last replication: %s)",
+		o.replication.Status.LastSuccessfulReplicationTime.Format(time.RFC3339)))
+
+	// The repository URLs reported by the replication controller already include the PVC UID,
+	// which is appended by the snapshotter and the restorer jobs.
+	sourceURL := strings.TrimSuffix(o.replication.Status.SourceRepositoryURL, string(pvc.GetUID()))
+	targetURL := strings.TrimSuffix(o.replication.Status.TargetRepositoryURL, string(pvc.GetUID()))
+
+	s = o.Printer.StartSpinner("Taking the final incremental snapshot")
+	if err := o.takeSnapshot(ctx, pvc, sourceURL); err != nil {
+		s.Fail("Failed to take snapshot: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success("Final snapshot taken")
+
+	s = o.Printer.StartSpinner("Moving the volume")
+	newPvc, err := recreatePvc(ctx, o.CRClient, pvc)
+	if err != nil {
+		s.Fail("Failed to recreate PVC: ", output.PrettyErr(err))
+		return err
+	}
+
+	if err = o.restoreSnapshot(ctx, pvc, newPvc, targetURL); err != nil {
+		s.Fail("Failed to restore snapshot: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success("Restore completed")
+
+	// The replication refers to the original PVC, which no longer exists.
+	s = o.Printer.StartSpinner("Removing the volume replication")
+	if err := client.IgnoreNotFound(o.CRClient.Delete(ctx, o.replication)); err != nil {
+		s.Fail("Failed to remove the volume replication: ", output.PrettyErr(err))
+		return err
+	}
+	s.Success("Volume replication removed")
+	return nil
+}
+
+// suspendVolumeReplication suspends the given VolumeReplication, and waits for the in-progress replication
+// (if any) to complete, so that it does not conflict with the final snapshot.
+func suspendVolumeReplication(ctx context.Context, cl client.Client, replication *offv1alpha1.VolumeReplication) error {
+	original := replication.DeepCopy()
+	replication.Spec.Suspend = true
+	if err := cl.Patch(ctx, replication, client.MergeFrom(original)); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute*5)
+	defer cancel()
+	ticker := time.NewTicker(time.Second * 5)
+	defer ticker.Stop()
+
+	for replication.Status.Phase == offv1alpha1.ReplicatingVolumeReplicationPhaseType {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := cl.Get(ctx, client.ObjectKeyFromObject(replication), replication); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -74,12 +74,7 @@ func (o *Options) createRestorerJob(ctx context.Context,
 								"restore", "latest",
 								"--target", "/restore",
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "RESTIC_PASSWORD",
-									Value: o.ResticPassword,
-								},
-							},
+							Env:       []corev1.EnvVar{o.forgeResticPasswordEnvVar()},
 							Resources: o.forgeContainerResources(),
 							VolumeMounts: []corev1.VolumeMount{
 								{
@@ -61,12 +61,7 @@ func (o *Options) createSnapshotterJob(ctx context.Context, pvc *corev1.Persiste
 								fmt.Sprintf("%s%s", resticRepositoryURL, pvc.GetUID()),
 								"init",
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "RESTIC_PASSWORD",
-									Value: o.ResticPassword,
-								},
-							},
+							Env:       []corev1.EnvVar{o.forgeResticPasswordEnvVar()},
 							Resources: o.forgeContainerResources(),
 						},
 					},
@@ -81,12 +76,7 @@ func (o *Options) createSnapshotterJob(ctx context.Context, pvc *corev1.Persiste
 								"backup", ".",
 								"--host", "liqo",
 							},
-							Env: []corev1.EnvVar{
-								{
-									Name:  "RESTIC_PASSWORD",
-									Value: o.ResticPassword,
-								},
-							},
+							Env:        []corev1.EnvVar{o.forgeResticPasswordEnvVar()},
 							Resources:  o.forgeContainerResources(),
 							WorkingDir: "/backup",
 							VolumeMounts: []corev1.VolumeMount{
@@ -113,6 +103,11 @@ func (o *Options) createSnapshotterJob(ctx context.Context, pvc *corev1.Persiste
 		},
 	}
 
+	if o.replication != nil {
+		// The repository has already been initialized by the volume replication controller.
+		job.Spec.Template.Spec.InitContainers = nil
+	}
+
 	if err := o.CRClient.Create(ctx, &job); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,153 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pterm/pterm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	liqogetters "github.com/liqotech/liqo/pkg/utils/getters"
+	liqolabels "github.com/liqotech/liqo/pkg/utils/labels"
+)
+
+// Options encapsulates the arguments of the offer commands.
+type Options struct {
+	*factory.Factory
+
+	ClusterName string
+}
+
+// RunList implements the offer list command.
+func (o *Options) RunList(ctx context.Context) error {
+	var offers sharingv1alpha1.ResourceOfferList
+	if err := o.CRClient.List(ctx, &offers, client.MatchingLabelsSelector{Selector: liqolabels.RemoteLabelSelector()}); err != nil {
+		o.Printer.Error.Printfln("Failed retrieving the ResourceOffers: %v", output.PrettyErr(err))
+		return err
+	}
+
+	var foreignClusters discoveryv1alpha1.ForeignClusterList
+	if err := o.CRClient.List(ctx, &foreignClusters); err != nil {
+		o.Printer.Error.Printfln("Failed retrieving the ForeignClusters: %v", output.PrettyErr(err))
+		return err
+	}
+
+	if len(offers.Items) == 0 {
+		o.Printer.Info.Println("No ResourceOffers received from remote clusters")
+		return nil
+	}
+
+	return o.Printer.Table.WithData(forgeTable(offers.Items, foreignClusters.Items, time.Now())).Render()
+}
+
+// RunAccept implements the offer accept command.
+func (o *Options) RunAccept(ctx context.Context) error {
+	return o.approve(ctx, consts.ResourceOfferApprovalAccepted)
+}
+
+// RunRefuse implements the offer refuse command.
+func (o *Options) RunRefuse(ctx context.Context) error {
+	return o.approve(ctx, consts.ResourceOfferApprovalRefused)
+}
+
+// approve annotates the ResourceOffer received from the given cluster with the given approval decision, which is
+// then enforced by the controller manager (recording the approving user through the corresponding webhook).
+func (o *Options) approve(ctx context.Context, approval consts.ResourceOfferApproval) error {
+	s := o.Printer.StartSpinner(fmt.Sprintf("Marking the ResourceOffer received from %q as %v", o.ClusterName, approval))
+
+	var foreignCluster discoveryv1alpha1.ForeignCluster
+	if err := o.CRClient.Get(ctx, client.ObjectKey{Name: o.ClusterName}, &foreignCluster); err != nil {
+		s.Fail(fmt.Sprintf("Failed retrieving the ForeignCluster %q: %v", o.ClusterName, output.PrettyErr(err)))
+		return err
+	}
+
+	offer, err := liqogetters.GetResourceOfferByLabel(ctx, o.CRClient, metav1.NamespaceAll,
+		liqolabels.RemoteLabelSelectorForCluster(foreignCluster.Spec.ClusterIdentity.ClusterID))
+	if err != nil {
+		s.Fail(fmt.Sprintf("Failed retrieving the ResourceOffer received from %q: %v", o.ClusterName, output.PrettyErr(err)))
+		return err
+	}
+
+	original := offer.DeepCopy()
+	if offer.Annotations == nil {
+		offer.Annotations = map[string]string{}
+	}
+	// The decision refers to the current generation, and it is discarded in case the ResourceOffer changes afterwards.
+	offer.Annotations[consts.ResourceOfferApprovalAnnotationKey] = string(approval)
+	offer.Annotations[consts.ResourceOfferApprovalGenerationAnnotationKey] = strconv.FormatInt(offer.Generation, 10)
+	if err := o.CRClient.Patch(ctx, offer, client.MergeFrom(original)); err != nil {
+		s.Fail(fmt.Sprintf("Failed marking the ResourceOffer received from %q as %v: %v", o.ClusterName, approval, output.PrettyErr(err)))
+		return err
+	}
+
+	s.Success(fmt.Sprintf("ResourceOffer received from %q correctly marked as %v", o.ClusterName, approval))
+	return nil
+}
+
+// forgeTable returns the table describing the given ResourceOffers.
+func forgeTable(offers []sharingv1alpha1.ResourceOffer, foreignClusters []discoveryv1alpha1.ForeignCluster,
+	now time.Time) pterm.TableData {
+	names := map[string]string{}
+	for i := range foreignClusters {
+		names[foreignClusters[i].Spec.ClusterIdentity.ClusterID] = foreignClusters[i].Name
+	}
+
+	data := pterm.TableData{{"CLUSTER", "PHASE", "CPU", "MEMORY", "PODS", "APPROVAL", "APPROVED BY", "AGE"}}
+	for i := range offers {
+		offer := &offers[i]
+		name, found := names[offer.Spec.ClusterID]
+		if !found {
+			name = offer.Spec.ClusterID
+		}
+
+		hard := offer.Spec.ResourceQuota.Hard
+		data = append(data, []string{
+			name, string(offer.Status.Phase),
+			quantity(hard, corev1.ResourceCPU), quantity(hard, corev1.ResourceMemory), quantity(hard, corev1.ResourcePods),
+			valueOrNone(offer.Annotations[consts.ResourceOfferApprovalAnnotationKey]),
+			valueOrNone(offer.Annotations[consts.ResourceOfferApprovedByAnnotationKey]),
+			duration.HumanDuration(now.Sub(offer.CreationTimestamp.Time)),
+		})
+	}
+	return data
+}
+
+// quantity returns the stringified quantity of the given resource.
+func quantity(resources corev1.ResourceList, name corev1.ResourceName) string {
+	if value, found := resources[name]; found {
+		return value.String()
+	}
+	return "-"
+}
+
+// valueOrNone returns the given value, or a placeholder if it is empty.
+func valueOrNone(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,155 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package offer
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pterm/pterm"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+)
+
+var _ = Describe("Offer", func() {
+	const (
+		clusterID   = "remote-cluster-id"
+		clusterName = "remote"
+	)
+
+	var (
+		ctx            context.Context
+		options        *Options
+		foreignCluster *discoveryv1alpha1.ForeignCluster
+		offer          *sharingv1alpha1.ResourceOffer
+		now            time.Time
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		now = time.Date(2022, 11, 4, 12, 0, 0, 0, time.UTC)
+
+		foreignCluster = &discoveryv1alpha1.ForeignCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterName},
+			Spec: discoveryv1alpha1.ForeignClusterSpec{
+				ClusterIdentity: discoveryv1alpha1.ClusterIdentity{ClusterID: clusterID, ClusterName: clusterName},
+			},
+		}
+
+		offer = &sharingv1alpha1.ResourceOffer{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "offer", Namespace: "liqo-tenant-remote",
+				CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour)),
+				Labels: map[string]string{
+					consts.ReplicationStatusLabel: "true",
+					consts.ReplicationOriginLabel: clusterID,
+				},
+				Annotations: map[string]string{
+					consts.ResourceOfferApprovalAnnotationKey:   string(consts.ResourceOfferApprovalAccepted),
+					consts.ResourceOfferApprovedByAnnotationKey: "alice",
+				},
+			},
+			Spec: sharingv1alpha1.ResourceOfferSpec{
+				ClusterID: clusterID,
+				ResourceQuota: corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				}},
+			},
+			Status: sharingv1alpha1.ResourceOfferStatus{Phase: sharingv1alpha1.ResourceOfferAccepted},
+		}
+
+		options = &Options{Factory: &factory.Factory{Printer: output.NewFakePrinter(GinkgoWriter)}, ClusterName: clusterName}
+	})
+
+	Describe("The forgeTable function", func() {
+		var data pterm.TableData
+
+		JustBeforeEach(func() {
+			data = forgeTable([]sharingv1alpha1.ResourceOffer{*offer}, []discoveryv1alpha1.ForeignCluster{*foreignCluster}, now)
+		})
+
+		It("should include the header", func() {
+			Expect(data[0]).To(Equal([]string{"CLUSTER", "PHASE", "CPU", "MEMORY", "PODS", "APPROVAL", "APPROVED BY", "AGE"}))
+		})
+
+		It("should describe the ResourceOffer", func() {
+			Expect(data).To(HaveLen(2))
+			Expect(data[1]).To(Equal([]string{clusterName, "Accepted", "4", "8Gi", "-", "accepted", "alice", "120m"}))
+		})
+
+		When("the ForeignCluster does not exist", func() {
+			BeforeEach(func() { foreignCluster.Spec.ClusterIdentity.ClusterID = "other" })
+
+			It("should fallback to the cluster ID", func() {
+				Expect(data[1][0]).To(Equal(clusterID))
+			})
+		})
+	})
+
+	Describe("The approval functions", func() {
+		var err error
+
+		BeforeEach(func() {
+			delete(offer.Annotations, consts.ResourceOfferApprovalAnnotationKey)
+			offer.Generation = 3
+			options.CRClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(foreignCluster, offer).Build()
+		})
+
+		When("accepting a ResourceOffer", func() {
+			JustBeforeEach(func() { err = options.RunAccept(ctx) })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should set the approval annotation", func() {
+				Expect(options.CRClient.Get(ctx, client.ObjectKeyFromObject(offer), offer)).To(Succeed())
+				Expect(offer.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalAnnotationKey,
+					string(consts.ResourceOfferApprovalAccepted)))
+			})
+			It("should bind the approval to the current generation", func() {
+				Expect(options.CRClient.Get(ctx, client.ObjectKeyFromObject(offer), offer)).To(Succeed())
+				Expect(offer.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalGenerationAnnotationKey, "3"))
+			})
+		})
+
+		When("refusing a ResourceOffer", func() {
+			JustBeforeEach(func() { err = options.RunRefuse(ctx) })
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should set the approval annotation", func() {
+				Expect(options.CRClient.Get(ctx, client.ObjectKeyFromObject(offer), offer)).To(Succeed())
+				Expect(offer.Annotations).To(HaveKeyWithValue(consts.ResourceOfferApprovalAnnotationKey,
+					string(consts.ResourceOfferApprovalRefused)))
+			})
+		})
+
+		When("the ForeignCluster does not exist", func() {
+			BeforeEach(func() { options.ClusterName = "non-existing" })
+			JustBeforeEach(func() { err = options.RunAccept(ctx) })
+
+			It("should fail", func() { Expect(err).To(HaveOccurred()) })
+		})
+	})
+})
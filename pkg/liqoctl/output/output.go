@@ -76,6 +76,7 @@ type Printer struct {
 	box        *pterm.BoxPrinter
 	spinner    *pterm.SpinnerPrinter
 	BulletList *pterm.BulletListPrinter
+	Table      *pterm.TablePrinter
 	Section    *pterm.SectionPrinter
 	Paragraph  *pterm.ParagraphPrinter
 	verbose    bool
@@ -257,6 +258,7 @@ func newPrinter(scope string, color pterm.Color, scoped, verbose bool) *Printer
 	}
 
 	printer.BulletList = &pterm.BulletListPrinter{}
+	printer.Table = pterm.DefaultTable.WithHasHeader()
 
 	printer.Section = &pterm.SectionPrinter{
 		Style: StatusSectionStyle,
@@ -279,6 +281,7 @@ func NewFakePrinter(writer io.Writer) *Printer {
 	printer.Warning.Writer = writer
 	printer.Error.Writer = writer
 	printer.BulletList.Writer = writer
+	printer.Table.Writer = writer
 	printer.Section.Writer = writer
 	printer.box.Writer = writer
 	return printer
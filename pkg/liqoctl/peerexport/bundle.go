@@ -0,0 +1,265 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerexport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/yaml"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	liqoconsts "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/discovery"
+	authenticationtokenutils "github.com/liqotech/liqo/pkg/utils/authenticationtoken"
+)
+
+const (
+	// documentSeparator is the separator between the different manifests of the bundle.
+	documentSeparator = "---\n"
+	// pgpMessageType is the type of the armored block containing a sealed bundle.
+	pgpMessageType = "PGP MESSAGE"
+)
+
+// Bundle contains the manifests required to establish an out-of-band peering towards a given cluster.
+type Bundle struct {
+	ForeignCluster *discoveryv1alpha1.ForeignCluster
+	TokenSecret    *corev1.Secret
+	// NetworkConfig contains the network parameters of the cluster to peer with. It is optional,
+	// for compatibility with bundles generated before its introduction.
+	NetworkConfig *netv1alpha1.NetworkConfig
+}
+
+// NetworkParameters contains the network parameters characterizing the cluster to peer with.
+type NetworkParameters struct {
+	PodCIDR      string
+	ExternalCIDR string
+	EndpointIP   string
+	EndpointPort string
+	PublicKey    string
+}
+
+// NewBundle forges a new bundle, given the parameters characterizing the cluster to peer with.
+func NewBundle(identity *discoveryv1alpha1.ClusterIdentity, authURL, token, liqoNamespace string,
+	insecureSkipTLSVerify bool, network *NetworkParameters) *Bundle {
+	return &Bundle{
+		ForeignCluster: &discoveryv1alpha1.ForeignCluster{
+			TypeMeta: metav1.TypeMeta{APIVersion: discoveryv1alpha1.GroupVersion.String(), Kind: "ForeignCluster"},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   identity.ClusterName,
+				Labels: map[string]string{discovery.ClusterIDLabel: identity.ClusterID},
+			},
+			Spec: discoveryv1alpha1.ForeignClusterSpec{
+				PeeringType:            discoveryv1alpha1.PeeringTypeOutOfBand,
+				ClusterIdentity:        *identity,
+				OutgoingPeeringEnabled: discoveryv1alpha1.PeeringEnabledYes,
+				IncomingPeeringEnabled: discoveryv1alpha1.PeeringEnabledAuto,
+				ForeignAuthURL:         authURL,
+				InsecureSkipTLSVerify:  pointer.Bool(insecureSkipTLSVerify),
+			},
+		},
+		TokenSecret:   authenticationtokenutils.ForgeSecret(identity.ClusterID, token, liqoNamespace),
+		NetworkConfig: forgeNetworkConfig(identity, network),
+	}
+}
+
+// forgeNetworkConfig forges the NetworkConfig advertising the network parameters of the cluster to peer with.
+// The remote cluster field is left empty, as it is set by the importing cluster to its own identity.
+func forgeNetworkConfig(identity *discoveryv1alpha1.ClusterIdentity, network *NetworkParameters) *netv1alpha1.NetworkConfig {
+	if network == nil {
+		return nil
+	}
+
+	return &netv1alpha1.NetworkConfig{
+		TypeMeta: metav1.TypeMeta{APIVersion: netv1alpha1.GroupVersion.String(), Kind: "NetworkConfig"},
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{liqoconsts.ReplicationOriginLabel: identity.ClusterID},
+		},
+		Spec: netv1alpha1.NetworkConfigSpec{
+			PodCIDR:      network.PodCIDR,
+			ExternalCIDR: network.ExternalCIDR,
+			EndpointIP:   network.EndpointIP,
+			BackendType:  liqoconsts.DriverName,
+			BackendConfig: map[string]string{
+				liqoconsts.PublicKey:     network.PublicKey,
+				liqoconsts.ListeningPort: network.EndpointPort,
+			},
+		},
+	}
+}
+
+// Token returns the authentication token stored in the bundle.
+func (b *Bundle) Token() string {
+	return b.TokenSecret.StringData["token"]
+}
+
+// Marshal returns the YAML representation of the bundle, as a multi-document stream of manifests.
+func (b *Bundle) Marshal() ([]byte, error) {
+	var buffer bytes.Buffer
+	objects := []interface{}{b.ForeignCluster, b.TokenSecret}
+	if b.NetworkConfig != nil {
+		objects = append(objects, b.NetworkConfig)
+	}
+
+	for _, obj := range objects {
+		encoded, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		buffer.WriteString(documentSeparator)
+		buffer.Write(encoded)
+	}
+	return buffer.Bytes(), nil
+}
+
+// Unmarshal parses a bundle from its YAML representation, and checks its consistency.
+func Unmarshal(data []byte) (*Bundle, error) {
+	var bundle Bundle
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+
+		// Skip empty documents, which may include a leftover document separator.
+		var content map[string]interface{}
+		if err := yaml.Unmarshal(document, &content); err != nil {
+			return nil, fmt.Errorf("failed to parse bundle: %w", err)
+		}
+		if len(content) == 0 {
+			continue
+		}
+
+		var meta metav1.TypeMeta
+		if err := yaml.Unmarshal(document, &meta); err != nil {
+			return nil, fmt.Errorf("failed to parse bundle: %w", err)
+		}
+
+		var target interface{}
+		switch meta.Kind {
+		case "ForeignCluster":
+			bundle.ForeignCluster = &discoveryv1alpha1.ForeignCluster{}
+			target = bundle.ForeignCluster
+		case "Secret":
+			bundle.TokenSecret = &corev1.Secret{}
+			target = bundle.TokenSecret
+		case "NetworkConfig":
+			bundle.NetworkConfig = &netv1alpha1.NetworkConfig{}
+			target = bundle.NetworkConfig
+		default:
+			return nil, fmt.Errorf("unexpected object of kind %q in bundle", meta.Kind)
+		}
+
+		if err := yaml.Unmarshal(document, target); err != nil {
+			return nil, fmt.Errorf("failed to parse %v: %w", meta.Kind, err)
+		}
+	}
+
+	return &bundle, bundle.validate()
+}
+
+func (b *Bundle) validate() error {
+	switch {
+	case b.ForeignCluster == nil:
+		return errors.New("the bundle does not contain any ForeignCluster")
+	case b.TokenSecret == nil:
+		return errors.New("the bundle does not contain any authentication token")
+	case b.ForeignCluster.Spec.ClusterIdentity.ClusterID == "":
+		return errors.New("the ForeignCluster in the bundle does not specify any cluster ID")
+	case b.ForeignCluster.Spec.ForeignAuthURL == "":
+		return errors.New("the ForeignCluster in the bundle does not specify any authentication URL")
+	case b.TokenSecret.Labels[discovery.ClusterIDLabel] != b.ForeignCluster.Spec.ClusterIdentity.ClusterID:
+		return errors.New("the authentication token in the bundle refers to a different cluster")
+	case b.Token() == "":
+		return errors.New("the bundle does not contain any authentication token")
+	case b.NetworkConfig != nil && b.NetworkConfig.Labels[liqoconsts.ReplicationOriginLabel] != b.ForeignCluster.Spec.ClusterIdentity.ClusterID:
+		return errors.New("the network configuration in the bundle refers to a different cluster")
+	case b.NetworkConfig != nil && (b.NetworkConfig.Spec.PodCIDR == "" || b.NetworkConfig.Spec.EndpointIP == ""):
+		return errors.New("the network configuration in the bundle is incomplete")
+	}
+	return nil
+}
+
+// Seal encrypts the given data for the recipients in the armored PGP keyring.
+func Seal(data []byte, keyring io.Reader) ([]byte, error) {
+	recipients, err := openpgp.ReadArmoredKeyRing(keyring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the recipients keyring: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	armored, err := armor.Encode(&buffer, pgpMessageType, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := openpgp.Encrypt(armored, recipients, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal bundle: %w", err)
+	}
+
+	if _, err := plaintext.Write(data); err != nil {
+		return nil, err
+	}
+	if err := plaintext.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+	buffer.WriteString("\n")
+	return buffer.Bytes(), nil
+}
+
+// IsSealed returns whether the given data corresponds to a sealed bundle.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN "+pgpMessageType+"-----"))
+}
+
+// Unseal decrypts a sealed bundle, leveraging the private keys in the armored PGP keyring.
+func Unseal(data []byte, keyring io.Reader) ([]byte, error) {
+	keys, err := openpgp.ReadArmoredKeyRing(keyring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the private keyring: %w", err)
+	}
+
+	block, err := armor.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode sealed bundle: %w", err)
+	}
+	if block.Type != pgpMessageType {
+		return nil, fmt.Errorf("unexpected block of type %q in sealed bundle", block.Type)
+	}
+
+	message, err := openpgp.ReadMessage(block.Body, keys, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unseal bundle: %w", err)
+	}
+	return io.ReadAll(message.UnverifiedBody)
+}
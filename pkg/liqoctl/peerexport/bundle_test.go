@@ -0,0 +1,161 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerexport
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	liqoconsts "github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Peering bundles", func() {
+	var (
+		bundle *Bundle
+		data   []byte
+		err    error
+	)
+
+	BeforeEach(func() {
+		identity := discoveryv1alpha1.ClusterIdentity{ClusterID: "remote-cluster-id", ClusterName: "remote-cluster-name"}
+		network := NetworkParameters{PodCIDR: "10.0.0.0/16", ExternalCIDR: "10.1.0.0/16",
+			EndpointIP: "1.2.3.4", EndpointPort: "5871", PublicKey: "public-key"}
+		bundle = NewBundle(&identity, "https://remote.auth", "remote-token", "liqo-non-standard", false, &network)
+	})
+
+	Describe("the Marshal and Unmarshal functions", func() {
+		JustBeforeEach(func() {
+			data, err = bundle.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should preserve the bundle content", func() {
+			decoded, err := Unmarshal(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded.ForeignCluster.Name).To(Equal("remote-cluster-name"))
+			Expect(decoded.ForeignCluster.Spec.ClusterIdentity.ClusterID).To(Equal("remote-cluster-id"))
+			Expect(decoded.ForeignCluster.Spec.ForeignAuthURL).To(Equal("https://remote.auth"))
+			Expect(decoded.ForeignCluster.Spec.PeeringType).To(Equal(discoveryv1alpha1.PeeringTypeOutOfBand))
+			Expect(decoded.TokenSecret.Name).To(Equal("remote-token-remote-cluster-id"))
+			Expect(decoded.TokenSecret.Namespace).To(Equal("liqo-non-standard"))
+			Expect(decoded.Token()).To(Equal("remote-token"))
+			Expect(decoded.ForeignCluster.Spec.InsecureSkipTLSVerify).To(HaveValue(BeFalse()))
+		})
+
+		It("should preserve the network parameters", func() {
+			decoded, err := Unmarshal(data)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded.NetworkConfig).ToNot(BeNil())
+			Expect(decoded.NetworkConfig.Spec.PodCIDR).To(Equal("10.0.0.0/16"))
+			Expect(decoded.NetworkConfig.Spec.ExternalCIDR).To(Equal("10.1.0.0/16"))
+			Expect(decoded.NetworkConfig.Spec.EndpointIP).To(Equal("1.2.3.4"))
+			Expect(decoded.NetworkConfig.Spec.BackendType).To(Equal(liqoconsts.DriverName))
+			Expect(decoded.NetworkConfig.Spec.BackendConfig).To(HaveKeyWithValue(liqoconsts.PublicKey, "public-key"))
+			Expect(decoded.NetworkConfig.Spec.BackendConfig).To(HaveKeyWithValue(liqoconsts.ListeningPort, "5871"))
+		})
+
+		It("should tolerate CRLF line endings and additional separators", func() {
+			mangled := "---\n" + strings.ReplaceAll(string(data), "\n", "\r\n") + "---\n"
+			decoded, err := Unmarshal([]byte(mangled))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded.Token()).To(Equal("remote-token"))
+			Expect(decoded.NetworkConfig).ToNot(BeNil())
+		})
+
+		When("the bundle does not contain the network parameters", func() {
+			BeforeEach(func() { bundle.NetworkConfig = nil })
+			It("should succeed for backward compatibility", func() {
+				decoded, err := Unmarshal(data)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(decoded.NetworkConfig).To(BeNil())
+			})
+		})
+
+		When("the network parameters refer to a different cluster", func() {
+			BeforeEach(func() { bundle.NetworkConfig.Labels[liqoconsts.ReplicationOriginLabel] = "other-cluster-id" })
+			It("should fail", func() {
+				_, err := Unmarshal(data)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("the bundle is inconsistent", func() {
+			BeforeEach(func() { bundle.TokenSecret.StringData = nil })
+			It("should fail", func() {
+				_, err := Unmarshal(data)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("the Unmarshal function", func() {
+		It("should fail if the bundle contains unexpected objects", func() {
+			_, err := Unmarshal([]byte("---\napiVersion: v1\nkind: Pod\n"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should fail if the bundle is empty", func() {
+			_, err := Unmarshal([]byte{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("the Seal and Unseal functions", func() {
+		var public, private bytes.Buffer
+
+		BeforeEach(func() {
+			entity, err := openpgp.NewEntity("recipient", "", "recipient@liqo.io", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			public.Reset()
+			writer, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entity.Serialize(writer)).To(Succeed())
+			Expect(writer.Close()).To(Succeed())
+
+			private.Reset()
+			writer, err = armor.Encode(&private, openpgp.PrivateKeyType, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entity.SerializePrivate(writer, nil)).To(Succeed())
+			Expect(writer.Close()).To(Succeed())
+		})
+
+		JustBeforeEach(func() {
+			plaintext, err := bundle.Marshal()
+			Expect(err).ToNot(HaveOccurred())
+			data, err = Seal(plaintext, &public)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should produce a sealed bundle", func() {
+			Expect(IsSealed(data)).To(BeTrue())
+			Expect(string(data)).ToNot(ContainSubstring("remote-token"))
+		})
+
+		It("should be possible to unseal the bundle with the private key", func() {
+			plaintext, err := Unseal(data, &private)
+			Expect(err).ToNot(HaveOccurred())
+			decoded, err := Unmarshal(plaintext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decoded.Token()).To(Equal("remote-token"))
+		})
+	})
+})
@@ -0,0 +1,170 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerexport
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/liqotech/liqo/pkg/auth"
+	liqoconsts "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/factory"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/utils"
+	foreigncluster "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+	liqogetters "github.com/liqotech/liqo/pkg/utils/getters"
+	liqolabels "github.com/liqotech/liqo/pkg/utils/labels"
+)
+
+// Options encapsulates the arguments of the peer export command.
+type Options struct {
+	*factory.Factory
+
+	// RemoteLiqoNamespace is the namespace where Liqo is installed in the cluster importing the bundle.
+	RemoteLiqoNamespace string
+	// OutputFile is the file where the bundle is written (stdout if empty).
+	OutputFile string
+	// RecipientsKeyring is the path of the armored PGP keyring used to seal the bundle (no sealing if empty).
+	RecipientsKeyring string
+	// InsecureSkipTLSVerify is whether the importing cluster skips the TLS verification of the local authentication service.
+	InsecureSkipTLSVerify bool
+}
+
+// Run implements the peer export command.
+func (o *Options) Run(ctx context.Context) error {
+	data, err := o.export(ctx)
+	if err != nil {
+		o.Printer.Error.Printfln("Failed to export peering information: %v", output.PrettyErr(err))
+		return err
+	}
+
+	if o.OutputFile == "" {
+		fmt.Print(string(data))
+		return nil
+	}
+
+	if err := os.WriteFile(o.OutputFile, data, 0o600); err != nil {
+		o.Printer.Error.Printfln("Failed to write peering bundle: %v", output.PrettyErr(err))
+		return err
+	}
+
+	o.Printer.Success.Printfln("Peering bundle correctly written to %q", o.OutputFile)
+	return nil
+}
+
+func (o *Options) export(ctx context.Context) ([]byte, error) {
+	bundle, err := o.bundle(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := bundle.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.RecipientsKeyring == "" {
+		return data, nil
+	}
+
+	keyring, err := os.Open(o.RecipientsKeyring)
+	if err != nil {
+		return nil, err
+	}
+	defer keyring.Close()
+	return Seal(data, keyring)
+}
+
+func (o *Options) bundle(ctx context.Context) (*Bundle, error) {
+	localToken, err := auth.GetToken(ctx, o.CRClient, o.LiqoNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterIdentity, err := utils.GetClusterIdentityWithControllerClient(ctx, o.CRClient, o.LiqoNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	authEP, err := foreigncluster.GetHomeAuthURL(ctx, o.CRClient, o.LiqoNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fallback to the cluster ID if the cluster name is not set, since it is used as name of the ForeignCluster.
+	if clusterIdentity.ClusterName == "" {
+		clusterIdentity.ClusterName = clusterIdentity.ClusterID
+	}
+
+	network, err := o.networkParameters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBundle(&clusterIdentity, authEP, localToken, o.RemoteLiqoNamespace, o.InsecureSkipTLSVerify, network), nil
+}
+
+// networkParameters retrieves the network parameters of the local cluster, to be advertised to the importing one.
+func (o *Options) networkParameters(ctx context.Context) (*NetworkParameters, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&liqolabels.IPAMStorageLabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	ipamStore, err := liqogetters.GetIPAMStorageByLabel(ctx, o.CRClient, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve network configuration: %w", err)
+	}
+	netcfg, err := liqogetters.RetrieveNetworkConfiguration(ipamStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve network configuration: %w", err)
+	}
+
+	selector, err = metav1.LabelSelectorAsSelector(&liqolabels.GatewayServiceLabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := liqogetters.GetServiceByLabel(ctx, o.CRClient, o.LiqoNamespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve WireGuard configuration: %w", err)
+	}
+	ip, port, err := liqogetters.RetrieveWGEPFromService(svc, liqoconsts.GatewayServiceAnnotationKey, liqoconsts.DriverName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve WireGuard configuration: %w", err)
+	}
+
+	selector, err = metav1.LabelSelectorAsSelector(&liqolabels.WireGuardSecretLabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := liqogetters.GetSecretByLabel(ctx, o.CRClient, o.LiqoNamespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve WireGuard configuration: %w", err)
+	}
+	pubKey, err := liqogetters.RetrieveWGPubKeyFromSecret(secret, liqoconsts.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve WireGuard configuration: %w", err)
+	}
+
+	return &NetworkParameters{
+		PodCIDR:      netcfg.PodCIDR,
+		ExternalCIDR: netcfg.ExternalCIDR,
+		EndpointIP:   ip,
+		EndpointPort: port,
+		PublicKey:    pubKey.String(),
+	}, nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerimport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	liqoconsts "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqoctl/output"
+	"github.com/liqotech/liqo/pkg/liqoctl/peer"
+	"github.com/liqotech/liqo/pkg/liqoctl/peerexport"
+	"github.com/liqotech/liqo/pkg/liqoctl/peeroob"
+	tenantnamespace "github.com/liqotech/liqo/pkg/tenantNamespace"
+	"github.com/liqotech/liqo/pkg/utils"
+	foreigncluster "github.com/liqotech/liqo/pkg/utils/foreignCluster"
+)
+
+// Options encapsulates the arguments of the peer import command.
+type Options struct {
+	*peer.Options
+
+	// InputFile is the file containing the bundle ("-" to read from stdin).
+	InputFile string
+	// PrivateKeyring is the path of the armored PGP keyring used to unseal the bundle, if sealed.
+	PrivateKeyring string
+}
+
+// Run implements the peer import command.
+func (o *Options) Run(ctx context.Context) error {
+	bundle, err := o.load()
+	if err != nil {
+		o.Printer.Error.Printfln("Failed to load peering bundle: %v", output.PrettyErr(err))
+		return err
+	}
+
+	if bundle.NetworkConfig != nil {
+		s := o.Printer.StartSpinner("Applying the network configuration of the remote cluster")
+		if err := o.applyNetworkConfig(ctx, bundle); err != nil {
+			s.Fail("Failed applying the network configuration: ", output.PrettyErr(err))
+			return err
+		}
+		s.Success("Network configuration applied")
+	}
+
+	// The import operation is idempotent, as the out-of-band peering enforces the desired configuration.
+	o.ClusterName = bundle.ForeignCluster.Name
+	oob := peeroob.Options{
+		Options:               o.Options,
+		ClusterID:             bundle.ForeignCluster.Spec.ClusterIdentity.ClusterID,
+		ClusterAuthURL:        bundle.ForeignCluster.Spec.ForeignAuthURL,
+		ClusterToken:          bundle.Token(),
+		InsecureSkipTLSVerify: bundle.ForeignCluster.Spec.InsecureSkipTLSVerify,
+	}
+	return oob.Run(ctx)
+}
+
+// applyNetworkConfig enforces the NetworkConfig advertised by the remote cluster in the corresponding tenant namespace.
+// The resource mirrors the one that would be replicated by the remote cluster once the peering is established,
+// hence allowing the network setup to proceed in parallel with the authentication, and converging with the replicated one.
+func (o *Options) applyNetworkConfig(ctx context.Context, bundle *peerexport.Bundle) error {
+	localIdentity, err := utils.GetClusterIdentityWithControllerClient(ctx, o.CRClient, o.LiqoNamespace)
+	if err != nil {
+		return err
+	}
+
+	remoteIdentity := bundle.ForeignCluster.Spec.ClusterIdentity
+	if localIdentity.ClusterID == remoteIdentity.ClusterID {
+		return fmt.Errorf("the Cluster ID of the remote cluster is the same of that of the local cluster")
+	}
+
+	namespace, err := tenantnamespace.NewManager(o.KubeClient).CreateNamespace(ctx, remoteIdentity)
+	if err != nil {
+		return err
+	}
+
+	netcfg := &netv1alpha1.NetworkConfig{}
+	netcfg.SetName(foreigncluster.UniqueName(&localIdentity))
+	netcfg.SetNamespace(namespace.Name)
+	_, err = controllerutil.CreateOrUpdate(ctx, o.CRClient, netcfg, func() error {
+		if netcfg.Labels == nil {
+			netcfg.Labels = map[string]string{}
+		}
+		netcfg.Labels[liqoconsts.ReplicationRequestedLabel] = strconv.FormatBool(false)
+		netcfg.Labels[liqoconsts.ReplicationStatusLabel] = strconv.FormatBool(true)
+		netcfg.Labels[liqoconsts.ReplicationOriginLabel] = remoteIdentity.ClusterID
+		netcfg.Labels[liqoconsts.ReplicationDestinationLabel] = localIdentity.ClusterID
+
+		netcfg.Spec = *bundle.NetworkConfig.Spec.DeepCopy()
+		netcfg.Spec.RemoteCluster = localIdentity
+		return nil
+	})
+	return err
+}
+
+func (o *Options) load() (*peerexport.Bundle, error) {
+	data, err := o.read()
+	if err != nil {
+		return nil, err
+	}
+
+	if peerexport.IsSealed(data) {
+		if o.PrivateKeyring == "" {
+			return nil, errors.New("the bundle is sealed, but no private keyring has been specified")
+		}
+
+		keyring, err := os.Open(o.PrivateKeyring)
+		if err != nil {
+			return nil, err
+		}
+		defer keyring.Close()
+
+		if data, err = peerexport.Unseal(data, keyring); err != nil {
+			return nil, err
+		}
+	}
+
+	return peerexport.Unmarshal(data)
+}
+
+func (o *Options) read() ([]byte, error) {
+	if o.InputFile == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(o.InputFile)
+}
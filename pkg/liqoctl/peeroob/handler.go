@@ -39,6 +39,9 @@ type Options struct {
 	ClusterToken   string
 	ClusterAuthURL string
 	ClusterID      string
+
+	// InsecureSkipTLSVerify, if set, enforces whether to skip the TLS verification of the remote authentication service.
+	InsecureSkipTLSVerify *bool
 }
 
 // Run implements the peer out-of-band command.
@@ -112,7 +115,9 @@ func (o *Options) enforceForeignCluster(ctx context.Context) (*discoveryv1alpha1
 		if fc.Spec.IncomingPeeringEnabled == "" {
 			fc.Spec.IncomingPeeringEnabled = discoveryv1alpha1.PeeringEnabledAuto
 		}
-		if fc.Spec.InsecureSkipTLSVerify == nil {
+		if o.InsecureSkipTLSVerify != nil {
+			fc.Spec.InsecureSkipTLSVerify = pointer.BoolPtr(*o.InsecureSkipTLSVerify)
+		} else if fc.Spec.InsecureSkipTLSVerify == nil {
 			fc.Spec.InsecureSkipTLSVerify = pointer.BoolPtr(true)
 		}
 		return nil
@@ -15,7 +15,9 @@
 package conncheck
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -24,6 +26,7 @@ type Msg struct {
 	ClusterID string    `json:"clusterID"`
 	MsgType   MsgTypes  `json:"msgType"`
 	TimeStamp time.Time `json:"timeStamp"`
+	Padding   string    `json:"padding,omitempty"`
 }
 
 func (msg Msg) String() string {
@@ -44,4 +47,21 @@ const (
 )
 
 // UpdateFunc is a function called when a Receiver gets a PONG or when a connection is declared failed.
-type UpdateFunc func(connected bool, latency time.Duration, time time.Time) error
+type UpdateFunc func(connected bool, quality Quality, time time.Time) error
+
+// marshalWithPadding marshals the given message, adding the padding required to reach the configured packet size.
+func marshalWithPadding(msg *Msg) ([]byte, error) {
+	msg.Padding = ""
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The padding field adds the overhead corresponding to its key, in addition to the padding itself.
+	overhead := len(`,"padding":""`)
+	if missing := int(PingPacketSize) - len(b) - overhead; missing > 0 {
+		msg.Padding = strings.Repeat("0", missing)
+		return json.Marshal(msg)
+	}
+	return b, nil
+}
@@ -86,7 +86,9 @@ func (c *ConnChecker) AddAndRunSender(clusterID, ip string, updateCallback Updat
 		err = c.senders[clusterID].SendPing(ctx)
 		if err != nil {
 			klog.Warningf("failed to send ping: %s", err)
+			return false, nil
 		}
+		c.receiver.pingSent(clusterID)
 		return false, nil
 	}
 	c.sm.Unlock()
@@ -131,3 +133,23 @@ func (c *ConnChecker) GetConnected(clusterID string) (bool, error) {
 	}
 	return false, fmt.Errorf("sender %s not found", clusterID)
 }
+
+// GetQuality returns the current quality of the connection with clusterID.
+func (c *ConnChecker) GetQuality(clusterID string) (Quality, error) {
+	c.receiver.m.RLock()
+	defer c.receiver.m.RUnlock()
+	if peer, ok := c.receiver.peers[clusterID]; ok {
+		return peer.quality(), nil
+	}
+	return Quality{}, fmt.Errorf("sender %s not found", clusterID)
+}
+
+// GetStatistics returns a snapshot of the statistics characterizing the connection with clusterID.
+func (c *ConnChecker) GetStatistics(clusterID string) (*Statistics, error) {
+	c.receiver.m.RLock()
+	defer c.receiver.m.RUnlock()
+	if peer, ok := c.receiver.peers[clusterID]; ok {
+		return peer.statistics.snapshot(), nil
+	}
+	return nil, fmt.Errorf("sender %s not found", clusterID)
+}
@@ -17,8 +17,20 @@ package conncheck
 import "time"
 
 const (
-	port     = 12345
-	buffSize = 1024
+	port = 12345
+	// MaxPingPacketSize is the maximum size of the connection check packets.
+	MaxPingPacketSize = 65507
+	buffSize          = MaxPingPacketSize
+
+	// lossWindowSize is the number of probes over which the packet loss ratio is computed.
+	lossWindowSize = 10
+	// jitterSmoothingFactor is the smoothing factor of the jitter estimation (as defined in RFC 3550).
+	jitterSmoothingFactor = 16
+
+	// probeOverhead is the overhead of the IPv4 and UDP headers, to compute the payload of the path MTU probes.
+	probeOverhead = 28
+	// probeAttempts is the number of probes of a given size sent before considering the path MTU as confirmed.
+	probeAttempts = 3
 )
 
 var (
@@ -26,4 +38,8 @@ var (
 	PingLossThreshold uint
 	// PingInterval is the interval at which the ping is sent.
 	PingInterval time.Duration
+	// PingPacketSize is the size of the connection check packets, which are padded if necessary (0 to disable padding).
+	PingPacketSize uint
+	// ProbeTimeout is the time awaited after each path MTU probe, for the possible ICMP errors to be received.
+	ProbeTimeout = time.Second
 )
@@ -0,0 +1,126 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conncheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+// ProbePathMTU returns the path MTU, capped to the [lower, upper] range, towards the remote host the given UDP socket is
+// connected to. The discovery leverages the path MTU discovery of the kernel: packets as large as the current estimate are
+// sent with the DF bit set, and the ICMP "fragmentation needed" errors received from the routers along the path lower the
+// estimate, until no further errors are received. Hence, the probes shall be sent on the underlay network towards the
+// remote endpoint, since the packets encapsulated by the tunnel do not carry the DF bit. The probes are not required to
+// be answered by the remote host. An error is returned if the path MTU is lower than the lower bound.
+func ProbePathMTU(ctx context.Context, conn *net.UDPConn, lower, upper int) (int, error) {
+	if err := setsockopt(conn, unix.IP_MTU_DISCOVER, unix.IP_PMTUDISC_DO); err != nil {
+		return 0, fmt.Errorf("failed to configure the DF bit on the probing socket: %w", err)
+	}
+
+	for {
+		mtu, err := getPathMTU(conn)
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve the path MTU towards %s: %w", conn.RemoteAddr(), err)
+		}
+		if mtu > upper {
+			mtu = upper
+		}
+		if mtu < lower {
+			return 0, fmt.Errorf("path MTU %d towards %s is lower than %d", mtu, conn.RemoteAddr(), lower)
+		}
+
+		decreased, err := probe(ctx, conn, mtu)
+		if err != nil {
+			return 0, err
+		}
+		if !decreased {
+			return mtu, nil
+		}
+	}
+}
+
+// probe sends a few probes of the given size, and returns whether the path MTU estimated by the kernel decreased below that size.
+func probe(ctx context.Context, conn *net.UDPConn, size int) (bool, error) {
+	payload := make([]byte, size-probeOverhead)
+	for attempt := 0; attempt < probeAttempts; attempt++ {
+		if _, err := conn.Write(payload); err != nil {
+			switch {
+			case errors.Is(err, unix.EMSGSIZE):
+				// The estimate has been lowered by an ICMP error received in response to a previous probe.
+				klog.V(8).Infof("conncheck prober: probe of size %d towards %s too large", size, conn.RemoteAddr())
+				return true, nil
+			case errors.Is(err, unix.ECONNREFUSED):
+				// The remote host rejected a previous probe, which nonetheless traversed the whole path.
+			default:
+				return false, fmt.Errorf("failed to write a probe of size %d to %s: %w", size, conn.RemoteAddr(), err)
+			}
+		}
+		klog.V(8).Infof("conncheck prober: sent a probe of size %d towards %s", size, conn.RemoteAddr())
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(ProbeTimeout):
+		}
+
+		mtu, err := getPathMTU(conn)
+		if err != nil {
+			return false, fmt.Errorf("failed to retrieve the path MTU towards %s: %w", conn.RemoteAddr(), err)
+		}
+		if mtu < size {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getPathMTU returns the path MTU towards the remote host the given socket is connected to, as currently estimated by the kernel.
+func getPathMTU(conn *net.UDPConn) (mtu int, err error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		mtu, serr = unix.GetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU)
+	}); err != nil {
+		return 0, err
+	}
+	return mtu, serr
+}
+
+// setsockopt sets the given IP level option on the given socket.
+func setsockopt(conn *net.UDPConn, option, value int) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var serr error
+	if err := raw.Control(func(fd uintptr) {
+		serr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, option, value)
+	}); err != nil {
+		return err
+	}
+	return serr
+}
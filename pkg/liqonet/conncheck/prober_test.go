@@ -0,0 +1,64 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conncheck
+
+import (
+	"context"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path MTU prober", func() {
+	var (
+		conn   *net.UDPConn
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ProbeTimeout = 10 * time.Millisecond
+		ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
+
+		var err error
+		// No one is listening on the remote port, hence the probes are rejected (which shall not affect the discovery).
+		conn, err = net.DialUDP("udp4", nil, &net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: port + 1})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		ProbeTimeout = time.Second
+		cancel()
+		Expect(conn.Close()).To(Succeed())
+	})
+
+	It("should cap the path MTU to the upper bound", func() {
+		// The MTU of the loopback interface is larger than the upper bound.
+		Expect(ProbePathMTU(ctx, conn, 576, 1500)).To(Equal(1500))
+	})
+
+	It("should fail if the path MTU is lower than the lower bound", func() {
+		_, err := ProbePathMTU(ctx, conn, 1600, 1500)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should fail if the context is canceled", func() {
+		cancel()
+		_, err := ProbePathMTU(ctx, conn, 576, 1500)
+		Expect(err).To(MatchError(context.Canceled))
+	})
+})
@@ -33,6 +33,12 @@ type Peer struct {
 	// lastReceivedTimestamp is the timestamp when the last received PING has been sent.
 	lastReceivedTimestamp time.Time
 	updateCallback        UpdateFunc
+	statistics            *peerStatistics
+}
+
+// quality returns the current quality of the connection towards the peer.
+func (p *Peer) quality() Quality {
+	return Quality{Latency: p.latency, Jitter: p.statistics.jitter, PacketLoss: p.statistics.packetLoss}
 }
 
 // Receiver is a receiver for conncheck messages.
@@ -55,7 +61,7 @@ func NewReceiver(conn *net.UDPConn) *Receiver {
 // SendPong sends a PONG message to the given address.
 func (r *Receiver) SendPong(raddr *net.UDPAddr, msg *Msg) error {
 	msg.MsgType = PONG
-	b, err := json.Marshal(msg)
+	b, err := marshalWithPadding(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal msg: %w", err)
 	}
@@ -77,11 +83,13 @@ func (r *Receiver) ReceivePong(msg *Msg) error {
 			return nil
 		}
 		now := time.Now()
+		previous := peer.latency
 		peer.lastReceivedTimestamp = msg.TimeStamp
 		peer.latency = now.Sub(msg.TimeStamp)
 		peer.connected = true
+		peer.statistics.observeRTT(peer.latency, previous)
 
-		err := peer.updateCallback(true, peer.latency, now)
+		err := peer.updateCallback(true, peer.quality(), now)
 		if err != nil {
 			return fmt.Errorf("failed to update peer %s: %w", msg.ClusterID, err)
 		}
@@ -99,10 +107,20 @@ func (r *Receiver) InitPeer(clusterID string, updateCallback UpdateFunc) error {
 		latency:               0,
 		lastReceivedTimestamp: time.Now(),
 		updateCallback:        updateCallback,
+		statistics:            newPeerStatistics(),
 	}
 	return nil
 }
 
+// pingSent records that a new PING has been sent to the given peer.
+func (r *Receiver) pingSent(clusterID string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if peer, ok := r.peers[clusterID]; ok {
+		peer.statistics.observeSent()
+	}
+}
+
 // Run starts the receiver.
 func (r *Receiver) Run() {
 	klog.V(8).Infof("conncheck receiver: starting")
@@ -148,7 +166,7 @@ func (r *Receiver) RunDisconnectObserver() {
 				klog.V(8).Infof("conncheck receiver: %s unreachable", id)
 				peer.connected = false
 				peer.latency = 0
-				err := peer.updateCallback(false, 0, time.Time{})
+				err := peer.updateCallback(false, peer.quality(), time.Time{})
 				if err != nil {
 					klog.Errorf("conncheck receiver: failed to update peer %s: %s", peer.lastReceivedTimestamp, err)
 				}
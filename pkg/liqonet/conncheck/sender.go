@@ -16,7 +16,6 @@ package conncheck
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net"
 	"time"
@@ -45,7 +44,7 @@ func NewSender(ctx context.Context, clusterID string, cancel func(), conn *net.U
 // SendPing sends a PING message to the given address.
 func (s *Sender) SendPing(ctx context.Context) error {
 	msgOut := Msg{ClusterID: s.clusterID, MsgType: PING, TimeStamp: time.Now()}
-	b, err := json.Marshal(msgOut)
+	b, err := marshalWithPadding(&msgOut)
 	if err != nil {
 		return fmt.Errorf("conncheck sender: failed to marshal msg: %w", err)
 	}
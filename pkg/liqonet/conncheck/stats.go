@@ -0,0 +1,152 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conncheck
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// RTTBuckets are the upper bounds (in seconds) of the round-trip time histogram buckets.
+	RTTBuckets = prometheus.ExponentialBuckets(0.001, 2, 12)
+	// JitterBuckets are the upper bounds (in seconds) of the jitter histogram buckets.
+	JitterBuckets = prometheus.ExponentialBuckets(0.0001, 2, 12)
+	// PacketLossBuckets are the upper bounds of the packet loss ratio histogram buckets.
+	PacketLossBuckets = []float64{0, 0.01, 0.05, 0.1, 0.2, 0.5, 1}
+)
+
+// Quality summarizes the current quality of the connection towards a remote cluster.
+type Quality struct {
+	// Latency is the latest round-trip time measurement.
+	Latency time.Duration
+	// Jitter is the smoothed variation of the round-trip time.
+	Jitter time.Duration
+	// PacketLoss is the ratio of probes lost over the last measurement window.
+	PacketLoss float64
+}
+
+// Histogram is a snapshot of a histogram, in the format expected by prometheus constant histograms.
+type Histogram struct {
+	Count uint64
+	Sum   float64
+	// Buckets associates each upper bound to the cumulative count of observations.
+	Buckets map[float64]uint64
+}
+
+// Statistics contains the histograms characterizing the connection towards a remote cluster.
+type Statistics struct {
+	RTT        Histogram
+	Jitter     Histogram
+	PacketLoss Histogram
+}
+
+// histogram is a minimal histogram implementation, which is not safe for concurrent use.
+type histogram struct {
+	upperBounds []float64
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func newHistogram(upperBounds []float64) *histogram {
+	return &histogram{upperBounds: upperBounds, counts: make([]uint64, len(upperBounds))}
+}
+
+// observe adds a single observation to the histogram.
+func (h *histogram) observe(value float64) {
+	h.count++
+	h.sum += value
+	for i, bound := range h.upperBounds {
+		if value <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+// snapshot returns a snapshot of the histogram, with cumulative bucket counts.
+func (h *histogram) snapshot() Histogram {
+	buckets := make(map[float64]uint64, len(h.upperBounds))
+	var cumulative uint64
+	for i, bound := range h.upperBounds {
+		cumulative += h.counts[i]
+		buckets[bound] = cumulative
+	}
+	return Histogram{Count: h.count, Sum: h.sum, Buckets: buckets}
+}
+
+// peerStatistics tracks the connection quality towards a given peer.
+type peerStatistics struct {
+	jitter     time.Duration
+	packetLoss float64
+	// sent and received are the number of probes sent and answered in the current loss measurement window.
+	sent     uint
+	received uint
+
+	rtt                 *histogram
+	jitterHistogram     *histogram
+	packetLossHistogram *histogram
+}
+
+func newPeerStatistics() *peerStatistics {
+	return &peerStatistics{
+		rtt:                 newHistogram(RTTBuckets),
+		jitterHistogram:     newHistogram(JitterBuckets),
+		packetLossHistogram: newHistogram(PacketLossBuckets),
+	}
+}
+
+// observeRTT records a new round-trip time measurement, given the previous one (0 if not available).
+func (ps *peerStatistics) observeRTT(rtt, previous time.Duration) {
+	ps.received++
+	ps.rtt.observe(rtt.Seconds())
+
+	if previous == 0 {
+		return
+	}
+	// Jitter estimation, as defined in RFC 3550: J = J + (|D| - J) / 16.
+	diff := rtt - previous
+	if diff < 0 {
+		diff = -diff
+	}
+	ps.jitter += (diff - ps.jitter) / jitterSmoothingFactor
+	ps.jitterHistogram.observe(ps.jitter.Seconds())
+}
+
+// observeSent records a new probe sent, and updates the packet loss ratio once the measurement window is complete.
+func (ps *peerStatistics) observeSent() {
+	ps.sent++
+	if ps.sent < lossWindowSize {
+		return
+	}
+
+	// Late replies may be accounted in the subsequent window, hence received can exceed sent.
+	ps.packetLoss = 0
+	if ps.received < ps.sent {
+		ps.packetLoss = float64(ps.sent-ps.received) / float64(ps.sent)
+	}
+	ps.packetLossHistogram.observe(ps.packetLoss)
+	ps.sent, ps.received = 0, 0
+}
+
+func (ps *peerStatistics) snapshot() *Statistics {
+	return &Statistics{
+		RTT:        ps.rtt.snapshot(),
+		Jitter:     ps.jitterHistogram.snapshot(),
+		PacketLoss: ps.packetLossHistogram.snapshot(),
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conncheck
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Statistics", func() {
+	Describe("testing the histogram", func() {
+		It("should return cumulative bucket counts", func() {
+			h := newHistogram([]float64{1, 2, 4})
+			for _, value := range []float64{0.5, 1.5, 3, 10} {
+				h.observe(value)
+			}
+
+			snapshot := h.snapshot()
+			Expect(snapshot.Count).To(BeNumerically("==", 4))
+			Expect(snapshot.Sum).To(BeNumerically("~", 15))
+			Expect(snapshot.Buckets).To(Equal(map[float64]uint64{1: 1, 2: 2, 4: 3}))
+		})
+	})
+
+	Describe("testing the peer statistics", func() {
+		var ps *peerStatistics
+
+		BeforeEach(func() { ps = newPeerStatistics() })
+
+		It("should estimate the jitter", func() {
+			ps.observeRTT(10*time.Millisecond, 0)
+			Expect(ps.jitter).To(BeZero())
+
+			ps.observeRTT(26*time.Millisecond, 10*time.Millisecond)
+			Expect(ps.jitter).To(Equal(time.Millisecond))
+			Expect(ps.snapshot().RTT.Count).To(BeNumerically("==", 2))
+			Expect(ps.snapshot().Jitter.Count).To(BeNumerically("==", 1))
+		})
+
+		It("should compute the packet loss once the window is complete", func() {
+			for i := 0; i < lossWindowSize; i++ {
+				if i%2 == 0 {
+					ps.observeRTT(time.Millisecond, 0)
+				}
+				Expect(ps.packetLoss).To(BeZero())
+				ps.observeSent()
+			}
+			Expect(ps.packetLoss).To(BeNumerically("~", 0.5))
+			Expect(ps.snapshot().PacketLoss.Count).To(BeNumerically("==", 1))
+		})
+
+		It("should not report negative packet loss in case of late replies", func() {
+			for i := 0; i < lossWindowSize; i++ {
+				ps.observeRTT(time.Millisecond, 0)
+				ps.observeRTT(time.Millisecond, 0)
+				ps.observeSent()
+			}
+			Expect(ps.packetLoss).To(BeZero())
+		})
+	})
+
+	Describe("testing the message padding", func() {
+		var msg *Msg
+
+		BeforeEach(func() { msg = &Msg{ClusterID: "cluster-id", MsgType: PING, TimeStamp: time.Now()} })
+		AfterEach(func() { PingPacketSize = 0 })
+
+		It("should not pad the message if not requested", func() {
+			b, err := marshalWithPadding(msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(msg.Padding).To(BeEmpty())
+			Expect(json.Unmarshal(b, &Msg{})).To(Succeed())
+		})
+
+		It("should pad the message up to the requested size", func() {
+			PingPacketSize = 512
+			b, err := marshalWithPadding(msg)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b).To(HaveLen(512))
+			Expect(json.Unmarshal(b, &Msg{})).To(Succeed())
+		})
+	})
+})
@@ -23,6 +23,7 @@ import (
 	"sync"
 
 	goipam "github.com/metal-stack/go-ipam"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	grpc "google.golang.org/grpc"
 	"inet.af/netaddr"
 	"k8s.io/client-go/dynamic"
@@ -75,6 +76,9 @@ type Ipam interface {
 	Terminate()
 	// SetSpecificNatMapping sets a specific NAT mapping.
 	SetSpecificNatMapping(oldIPLocal, oldIP, newIP, clusterID string) error
+	// SetTransitRoutesPerCluster reserves the networks of the transit routes requested by a remote cluster,
+	// and returns the accepted ones (i.e., the routes whose networks do not conflict with the ones already in use).
+	SetTransitRoutesPerCluster(clusterID string, routes []netv1alpha1.TransitRoute) ([]netv1alpha1.TransitRoute, error)
 	IpamServer
 }
 
@@ -151,7 +155,7 @@ func (liqoIPAM *IPAM) initRPCServer(port int) error {
 	if err != nil {
 		return err
 	}
-	liqoIPAM.grpcServer = grpc.NewServer()
+	liqoIPAM.grpcServer = grpc.NewServer(grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor()))
 	RegisterIpamServer(liqoIPAM.grpcServer, liqoIPAM)
 	go func() {
 		err := liqoIPAM.grpcServer.Serve(lis)
@@ -615,6 +619,11 @@ func (liqoIPAM *IPAM) RemoveClusterConfig(clusterID string) error {
 		}
 	}
 
+	// Free the networks reserved for the transit routes requested by the remote cluster, if any.
+	if _, err := liqoIPAM.SetTransitRoutesPerCluster(clusterID, nil); err != nil {
+		return fmt.Errorf("unable to free transit networks for cluster %s: %w", clusterID, err)
+	}
+
 	// Get cluster subnets
 	clusterSubnets := liqoIPAM.ipamStorage.getClusterSubnets()
 
@@ -878,6 +887,11 @@ func (liqoIPAM *IPAM) AddLocalSubnetsPerCluster(podCIDR, externalCIDR, clusterID
 		}
 	}
 
+	// Free the networks reserved for the transit routes requested by the remote cluster, if any.
+	if _, err := liqoIPAM.SetTransitRoutesPerCluster(clusterID, nil); err != nil {
+		return fmt.Errorf("unable to free transit networks for cluster %s: %w", clusterID, err)
+	}
+
 	// Get cluster subnets
 	clusterSubnets := liqoIPAM.ipamStorage.getClusterSubnets()
 
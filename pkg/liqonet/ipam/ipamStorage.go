@@ -47,6 +47,7 @@ const (
 	podCIDRUpdate               = "podCIDR"
 	serviceCIDRUpdate           = "serviceCIDR"
 	natMappingsConfiguredUpdate = "natMappingsConfigured"
+	transitNetworksUpdate       = "transitNetworks"
 	updateOpAdd                 = "add"
 	updateOpRemove              = "remove"
 )
@@ -61,6 +62,7 @@ type IpamStorage interface {
 	updateServiceCIDR(serviceCIDR string) error
 	updateReservedSubnets(subnet, operation string) error
 	updateNatMappingsConfigured(natMappingsConfigured map[string]netv1alpha1.ConfiguredCluster) error
+	updateTransitNetworks(transitNetworks map[string][]string) error
 	getClusterSubnets() map[string]netv1alpha1.Subnets
 	getPools() []string
 	getExternalCIDR() string
@@ -69,6 +71,7 @@ type IpamStorage interface {
 	getServiceCIDR() string
 	getReservedSubnets() []string
 	getNatMappingsConfigured() map[string]netv1alpha1.ConfiguredCluster
+	getTransitNetworks() map[string][]string
 	goipam.Storage
 }
 
@@ -267,6 +270,10 @@ func (ipamStorage *IPAMStorage) updateNatMappingsConfigured(natMappingsConfigure
 	return ipamStorage.updateConfig(natMappingsConfiguredUpdate, natMappingsConfigured)
 }
 
+func (ipamStorage *IPAMStorage) updateTransitNetworks(transitNetworks map[string][]string) error {
+	return ipamStorage.updateConfig(transitNetworksUpdate, transitNetworks)
+}
+
 func (ipamStorage *IPAMStorage) updateConfig(updateType string, data interface{}) error {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -274,9 +281,10 @@ func (ipamStorage *IPAMStorage) updateConfig(updateType string, data interface{}
 		return err
 	}
 
+	// The add operation replaces the value of the field if already present, while also supporting optional fields.
 	var b bytes.Buffer
 	patch := fmt.Sprintf(
-		`[{"op": "replace", "path": "/spec/%s", "value": `,
+		`[{"op": "add", "path": "/spec/%s", "value": `,
 		updateType)
 	b.WriteString(patch)
 	b.Write(jsonData)
@@ -332,6 +340,14 @@ func (ipamStorage *IPAMStorage) getNatMappingsConfigured() map[string]netv1alpha
 	return ipamStorage.getConfig().Spec.NatMappingsConfigured
 }
 
+func (ipamStorage *IPAMStorage) getTransitNetworks() map[string][]string {
+	transitNetworks := ipamStorage.getConfig().Spec.TransitNetworks
+	if transitNetworks == nil {
+		transitNetworks = make(map[string][]string)
+	}
+	return transitNetworks
+}
+
 func (ipamStorage *IPAMStorage) getConfig() *netv1alpha1.IpamStorage {
 	ipamStorage.m.RLock()
 	defer ipamStorage.m.RUnlock()
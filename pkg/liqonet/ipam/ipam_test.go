@@ -1725,6 +1725,114 @@ var _ = Describe("Ipam", func() {
 			})
 		})
 	})
+	Describe("SetTransitRoutesPerCluster", func() {
+		const (
+			transitSourceCIDR      = "10.70.0.0/16"
+			transitDestinationCIDR = "10.80.0.0/16"
+		)
+		var route liqonetapi.TransitRoute
+
+		BeforeEach(func() {
+			Expect(ipam.SetPodCIDR(homePodCIDR)).To(Succeed())
+			route = liqonetapi.TransitRoute{
+				DestinationClusterID: clusterID2,
+				SourceCIDRs:          []string{transitSourceCIDR},
+				DestinationCIDRs:     []string{transitDestinationCIDR},
+			}
+		})
+
+		Context("Passing an empty cluster ID", func() {
+			It("Should return a WrongParameter error", func() {
+				_, err := ipam.SetTransitRoutesPerCluster("", nil)
+				Expect(err).To(MatchError(fmt.Sprintf("%s must be %s", consts.ClusterIDLabelName, liqoneterrors.StringNotEmpty)))
+			})
+		})
+
+		Context("Requesting a route whose networks are available", func() {
+			It("Should accept the route and reserve the networks", func() {
+				accepted, err := ipam.SetTransitRoutesPerCluster(clusterID1, []liqonetapi.TransitRoute{route})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(accepted).To(ConsistOf(route))
+
+				ipamStorage, err := getIpamStorageResource()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ipamStorage.Spec.TransitNetworks).To(HaveKeyWithValue(transitSourceCIDR, ConsistOf(clusterID1)))
+				Expect(ipamStorage.Spec.TransitNetworks).To(HaveKeyWithValue(transitDestinationCIDR, ConsistOf(clusterID1)))
+				Expect(ipamStorage.Spec.Prefixes).To(HaveKey(transitSourceCIDR))
+				Expect(ipamStorage.Spec.Prefixes).To(HaveKey(transitDestinationCIDR))
+
+				// The reserved networks cannot be assigned to other clusters.
+				mappedPodCIDR, _, err := ipam.GetSubnetsPerCluster(transitDestinationCIDR, remoteExternalCIDR, clusterID3)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(mappedPodCIDR).ToNot(Equal(transitDestinationCIDR))
+			})
+		})
+
+		Context("Requesting a route conflicting with the networks of another cluster", func() {
+			It("Should reject the route", func() {
+				_, _, err := ipam.GetSubnetsPerCluster(transitDestinationCIDR, remoteExternalCIDR, clusterID3)
+				Expect(err).ToNot(HaveOccurred())
+
+				accepted, err := ipam.SetTransitRoutesPerCluster(clusterID1, []liqonetapi.TransitRoute{route})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(accepted).To(BeEmpty())
+
+				// The networks possibly acquired for the rejected route shall have been released.
+				ipamStorage, err := getIpamStorageResource()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ipamStorage.Spec.TransitNetworks).To(BeEmpty())
+				Expect(ipamStorage.Spec.Prefixes).ToNot(HaveKey(transitSourceCIDR))
+			})
+		})
+
+		Context("Requesting a route towards the networks already assigned to the destination cluster", func() {
+			It("Should accept the route without reserving them again", func() {
+				_, _, err := ipam.GetSubnetsPerCluster(transitDestinationCIDR, remoteExternalCIDR, clusterID2)
+				Expect(err).ToNot(HaveOccurred())
+
+				accepted, err := ipam.SetTransitRoutesPerCluster(clusterID1, []liqonetapi.TransitRoute{route})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(accepted).To(ConsistOf(route))
+
+				ipamStorage, err := getIpamStorageResource()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ipamStorage.Spec.TransitNetworks).To(HaveKey(transitSourceCIDR))
+				Expect(ipamStorage.Spec.TransitNetworks).ToNot(HaveKey(transitDestinationCIDR))
+			})
+		})
+
+		Context("Requesting the same networks from both ends of the route", func() {
+			It("Should free the networks only when no longer requested by any cluster", func() {
+				mirrored := liqonetapi.TransitRoute{
+					DestinationClusterID: clusterID1,
+					SourceCIDRs:          []string{transitDestinationCIDR},
+					DestinationCIDRs:     []string{transitSourceCIDR},
+				}
+
+				_, err := ipam.SetTransitRoutesPerCluster(clusterID1, []liqonetapi.TransitRoute{route})
+				Expect(err).ToNot(HaveOccurred())
+				accepted, err := ipam.SetTransitRoutesPerCluster(clusterID2, []liqonetapi.TransitRoute{mirrored})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(accepted).To(ConsistOf(mirrored))
+
+				// The route is no longer requested by the first cluster.
+				_, err = ipam.SetTransitRoutesPerCluster(clusterID1, nil)
+				Expect(err).ToNot(HaveOccurred())
+				ipamStorage, err := getIpamStorageResource()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ipamStorage.Spec.TransitNetworks).To(HaveKeyWithValue(transitSourceCIDR, ConsistOf(clusterID2)))
+				Expect(ipamStorage.Spec.Prefixes).To(HaveKey(transitSourceCIDR))
+
+				// The configuration of the second cluster is removed.
+				Expect(ipam.RemoveClusterConfig(clusterID2)).To(Succeed())
+				ipamStorage, err = getIpamStorageResource()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(ipamStorage.Spec.TransitNetworks).To(BeEmpty())
+				Expect(ipamStorage.Spec.Prefixes).ToNot(HaveKey(transitSourceCIDR))
+				Expect(ipamStorage.Spec.Prefixes).ToNot(HaveKey(transitDestinationCIDR))
+			})
+		})
+	})
 })
 
 func checkForPrefixes(subnets []string) {
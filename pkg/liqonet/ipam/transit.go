@@ -0,0 +1,170 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/klog/v2"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	liqoneterrors "github.com/liqotech/liqo/pkg/liqonet/errors"
+	liqonetutils "github.com/liqotech/liqo/pkg/liqonet/utils"
+	"github.com/liqotech/liqo/pkg/utils/slice"
+)
+
+// transitNetwork associates a network involved in a transit route with the cluster the corresponding traffic is forwarded to.
+type transitNetwork struct {
+	network string
+	owner   string
+}
+
+/*
+SetTransitRoutesPerCluster receives the transit routes requested by a remote cluster, which leverages the local one
+as intermediate hop towards the clusters not directly reachable (i.e., multi-hop peering). The networks of each route
+are reserved, so that they cannot be used by any other cluster, unless they correspond to the ones already assigned
+to the cluster the traffic is forwarded to. Routes whose networks conflict with the ones already in use are rejected,
+while the networks previously reserved and no longer requested are freed. The accepted routes are returned.
+*/
+func (liqoIPAM *IPAM) SetTransitRoutesPerCluster(clusterID string, routes []netv1alpha1.TransitRoute) ([]netv1alpha1.TransitRoute, error) {
+	if clusterID == "" {
+		return nil, &liqoneterrors.WrongParameter{
+			Parameter: consts.ClusterIDLabelName,
+			Reason:    liqoneterrors.StringNotEmpty,
+		}
+	}
+
+	original := liqoIPAM.ipamStorage.getTransitNetworks()
+	transitNetworks := liqoIPAM.ipamStorage.getTransitNetworks()
+	clusterSubnets := liqoIPAM.ipamStorage.getClusterSubnets()
+
+	var accepted []netv1alpha1.TransitRoute
+	var acquired []string
+	requested := make(map[string]struct{})
+	for i := range routes {
+		route := &routes[i]
+		networks, err := liqoIPAM.acquireTransitRoute(clusterID, route, transitNetworks, clusterSubnets)
+		if err != nil {
+			klog.Warningf("Transit route requested by cluster %s towards cluster %s rejected: %v", clusterID, route.DestinationClusterID, err)
+			continue
+		}
+
+		klog.V(4).Infof("Transit route requested by cluster %s towards cluster %s accepted", clusterID, route.DestinationClusterID)
+		accepted = append(accepted, *route.DeepCopy())
+		acquired = append(acquired, networks...)
+		for _, network := range transitRouteNetworks(clusterID, route) {
+			requested[network.network] = struct{}{}
+		}
+	}
+
+	// Free the networks no longer requested by the given cluster.
+	for network, requesters := range transitNetworks {
+		if _, found := requested[network]; found || !slice.ContainsString(requesters, clusterID) {
+			continue
+		}
+		if err := liqoIPAM.releaseTransitNetwork(clusterID, network, transitNetworks); err != nil {
+			return nil, err
+		}
+	}
+
+	// Avoid performing updates in case it is not necessary
+	if reflect.DeepEqual(original, transitNetworks) {
+		return accepted, nil
+	}
+
+	if err := liqoIPAM.ipamStorage.updateTransitNetworks(transitNetworks); err != nil {
+		for _, network := range acquired {
+			_ = liqoIPAM.releaseTransitNetwork(clusterID, network, transitNetworks)
+		}
+		return nil, fmt.Errorf("cannot update transit networks: %w", err)
+	}
+	return accepted, nil
+}
+
+// acquireTransitRoute reserves the networks of the given transit route on behalf of the given cluster. In case of conflicts,
+// the networks already reserved by this invocation are released. It returns the networks the cluster has been added to.
+func (liqoIPAM *IPAM) acquireTransitRoute(clusterID string, route *netv1alpha1.TransitRoute,
+	transitNetworks map[string][]string, clusterSubnets map[string]netv1alpha1.Subnets) ([]string, error) {
+	var acquired []string
+	rollback := func() {
+		for _, network := range acquired {
+			_ = liqoIPAM.releaseTransitNetwork(clusterID, network, transitNetworks)
+		}
+	}
+
+	for _, network := range transitRouteNetworks(clusterID, route) {
+		if err := liqonetutils.IsValidCIDR(network.network); err != nil {
+			rollback()
+			return nil, fmt.Errorf("network %q is an invalid CIDR: %w", network.network, err)
+		}
+
+		// The network is already assigned to the cluster the traffic is forwarded to, hence no reservation is necessary.
+		subnets := clusterSubnets[network.owner]
+		if network.network == subnets.RemotePodCIDR || network.network == subnets.RemoteExternalCIDR {
+			continue
+		}
+
+		// The network has already been reserved by another cluster (i.e., the one at the other end of the route).
+		if requesters, found := transitNetworks[network.network]; found {
+			if !slice.ContainsString(requesters, clusterID) {
+				transitNetworks[network.network] = append(requesters, clusterID)
+				acquired = append(acquired, network.network)
+			}
+			continue
+		}
+
+		if err := liqoIPAM.AcquireReservedSubnet(network.network); err != nil {
+			rollback()
+			return nil, err
+		}
+		transitNetworks[network.network] = []string{clusterID}
+		acquired = append(acquired, network.network)
+	}
+
+	return acquired, nil
+}
+
+// releaseTransitNetwork removes the given cluster from the ones requesting the given transit network,
+// and frees the network in case it is no longer requested by any cluster.
+func (liqoIPAM *IPAM) releaseTransitNetwork(clusterID, network string, transitNetworks map[string][]string) error {
+	requesters := slice.RemoveString(transitNetworks[network], clusterID)
+	if len(requesters) > 0 {
+		transitNetworks[network] = requesters
+		return nil
+	}
+
+	if err := liqoIPAM.FreeReservedSubnet(network); err != nil {
+		return fmt.Errorf("cannot free transit network %s: %w", network, err)
+	}
+	delete(transitNetworks, network)
+	klog.Infof("Transit network %s has just been freed", network)
+	return nil
+}
+
+// transitRouteNetworks returns the networks involved in the given transit route, associated with the cluster
+// the corresponding traffic is forwarded to: the source networks towards the requester, and the destination ones
+// towards the destination cluster.
+func transitRouteNetworks(clusterID string, route *netv1alpha1.TransitRoute) []transitNetwork {
+	networks := make([]transitNetwork, 0, len(route.SourceCIDRs)+len(route.DestinationCIDRs))
+	for _, network := range route.SourceCIDRs {
+		networks = append(networks, transitNetwork{network: network, owner: clusterID})
+	}
+	for _, network := range route.DestinationCIDRs {
+		networks = append(networks, transitNetwork{network: network, owner: route.DestinationClusterID})
+	}
+	return networks
+}
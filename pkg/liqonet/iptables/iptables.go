@@ -17,9 +17,12 @@ package iptables
 import (
 	"encoding/csv"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/coreos/go-iptables/iptables"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
@@ -36,6 +39,8 @@ const (
 	liqonetPreroutingChain = "LIQO-PREROUTING"
 	// liqonetForwardingChain is the name of the forwarding chain inserted by liqo.
 	liqonetForwardingChain = "LIQO-FORWARD"
+	// liqonetTransitChain is the name of the chain inserted by liqo to prevent the NAT of transit traffic.
+	liqonetTransitChain = "LIQO-TRANSIT"
 	// liqonetPostroutingClusterChainPrefix the prefix used to name the postrouting chains for a specific cluster.
 	liqonetPostroutingClusterChainPrefix = "LIQO-PSTRT-CLS-"
 	// liqonetPreroutingClusterChainPrefix prefix used to name the prerouting chains for a specific cluster.
@@ -44,6 +49,10 @@ const (
 	liqonetForwardingExtClusterChainPrefix = "LIQO-FRWD-EXT-CLS-"
 	// liqonetPreRoutingMappingClusterChainPrefix prefix used to name the prerouting mapping chain for a specific cluster.
 	liqonetPreRoutingMappingClusterChainPrefix = "LIQO-PRRT-MAP-CLS-"
+	// liqonetTransitClusterChainPrefix prefix used to name the transit chain for a specific cluster.
+	liqonetTransitClusterChainPrefix = "LIQO-TRNST-CLS-"
+	// liqonetForwardingACLClusterChainPrefix prefix used to name the chain filtering the traffic of a specific cluster.
+	liqonetForwardingACLClusterChainPrefix = "LIQO-FRWD-ACL-CLS-"
 	// natTable constant used for the "nat" table.
 	natTable = "nat"
 	// filterTable constant used for the "filter" table.
@@ -68,6 +77,8 @@ const (
 	ACCEPT = "ACCEPT"
 	// DROP action constant.
 	DROP = "DROP"
+	// RETURN action constant.
+	RETURN = "RETURN"
 )
 
 // IPTableRule is a slice of string. This is the format used by module go-iptables.
@@ -156,6 +167,8 @@ func getLiqoRules() map[string]IPTableRule {
 		forwardChain:     {"-j", liqonetForwardingChain},
 		preroutingChain:  {"-j", liqonetPreroutingChain},
 		postroutingChain: {"-j", liqonetPostroutingChain},
+		// The transit traffic shall not be natted, hence it is processed before the rules of the single clusters.
+		liqonetPostroutingChain: {"-j", liqonetTransitChain},
 	}
 }
 
@@ -199,6 +212,7 @@ func (h IPTHandler) deleteLiqoChainsFromTable(liqoChains map[string]string, tabl
 		chainsToBeRemoved = append(chainsToBeRemoved,
 			liqoChains[preroutingChain],
 			liqoChains[postroutingChain],
+			liqoChains[liqonetPostroutingChain],
 		)
 		// Get cluster chains that may have not been removed in table
 		chainsToBeRemoved = append(chainsToBeRemoved,
@@ -207,6 +221,9 @@ func (h IPTHandler) deleteLiqoChainsFromTable(liqoChains map[string]string, tabl
 		chainsToBeRemoved = append(chainsToBeRemoved,
 			getSliceContainingString(existingChains, liqonetPreroutingClusterChainPrefix)...,
 		)
+		chainsToBeRemoved = append(chainsToBeRemoved,
+			getSliceContainingString(existingChains, liqonetTransitClusterChainPrefix)...,
+		)
 	case filterTable:
 		// Add to the set of chains to be removed the Liqo chains
 		chainsToBeRemoved = append(chainsToBeRemoved,
@@ -215,6 +232,9 @@ func (h IPTHandler) deleteLiqoChainsFromTable(liqoChains map[string]string, tabl
 		chainsToBeRemoved = append(chainsToBeRemoved,
 			getSliceContainingString(existingChains, liqonetForwardingExtClusterChainPrefix)...,
 		)
+		chainsToBeRemoved = append(chainsToBeRemoved,
+			getSliceContainingString(existingChains, liqonetForwardingACLClusterChainPrefix)...,
+		)
 	}
 	// Delete chains in table
 	if err := h.deleteChainsInTable(table, existingChains, chainsToBeRemoved); err != nil {
@@ -312,9 +332,11 @@ func (h IPTHandler) getExistingChainRules(clusterID, chain string) ([]string, er
 func getChainsPerCluster(clusterID string) []string {
 	chains := []string{
 		getClusterForwardExtChain(clusterID),
+		getClusterForwardACLChain(clusterID),
 		getClusterPostRoutingChain(clusterID),
 		getClusterPreRoutingChain(clusterID),
 		getClusterPreRoutingMappingChain(clusterID),
+		getClusterTransitChain(clusterID),
 	}
 	return chains
 }
@@ -342,19 +364,21 @@ func (h IPTHandler) EnsureChainsPerCluster(clusterID string) error {
 // the table name the chain should belong to.
 func getTableFromChain(chain string) string {
 	// First manage the case the chain is a cluster chain
-	if strings.Contains(chain, liqonetForwardingExtClusterChainPrefix) {
+	if strings.Contains(chain, liqonetForwardingExtClusterChainPrefix) ||
+		strings.Contains(chain, liqonetForwardingACLClusterChainPrefix) {
 		return filterTable
 	}
 	if strings.Contains(chain, liqonetPostroutingClusterChainPrefix) ||
 		strings.Contains(chain, liqonetPreRoutingMappingClusterChainPrefix) ||
-		strings.Contains(chain, liqonetPreroutingClusterChainPrefix) {
+		strings.Contains(chain, liqonetPreroutingClusterChainPrefix) ||
+		strings.Contains(chain, liqonetTransitClusterChainPrefix) {
 		return natTable
 	}
 	// Chain is a default iptables chain or a Liqo chain
 	switch chain {
 	case forwardChain, inputChain, liqonetForwardingChain:
 		return filterTable
-	case preroutingChain, postroutingChain, liqonetPreroutingChain, liqonetPostroutingChain:
+	case preroutingChain, postroutingChain, liqonetPreroutingChain, liqonetPostroutingChain, liqonetTransitChain:
 		return natTable
 	}
 	return ""
@@ -474,6 +498,19 @@ func (h IPTHandler) EnsureForwardExtRules(tep *netv1alpha1.TunnelEndpoint) error
 	return h.updateRulesPerChain(getClusterForwardExtChain(tep.Spec.ClusterIdentity.ClusterID), rules)
 }
 
+// EnsureForwardACLRules makes sure that the rules restricting the local destinations reachable by
+// a given cluster reflect the specified PeeringFirewalls. The chain is reconciled without ever
+// transiently allowing traffic that is forbidden both by the old and by the new set of rules.
+func (h IPTHandler) EnsureForwardACLRules(clusterID string, firewalls []netv1alpha1.PeeringFirewall) error {
+	if clusterID == "" {
+		return &errors.WrongParameter{
+			Parameter: consts.ClusterIDLabelName,
+			Reason:    errors.StringNotEmpty,
+		}
+	}
+	return h.updateOrderedRulesPerChain(getClusterForwardACLChain(clusterID), getClusterForwardACLRules(firewalls))
+}
+
 // EnsurePostroutingRules makes sure that the postrouting rules for a given cluster are in place and updated.
 func (h IPTHandler) EnsurePostroutingRules(tep *netv1alpha1.TunnelEndpoint) error {
 	rules, err := getPostroutingRules(tep)
@@ -483,6 +520,12 @@ func (h IPTHandler) EnsurePostroutingRules(tep *netv1alpha1.TunnelEndpoint) erro
 	return h.updateRulesPerChain(getClusterPostRoutingChain(tep.Spec.ClusterIdentity.ClusterID), rules)
 }
 
+// EnsureTransitRules makes sure that the rules preventing the NAT of the transit traffic
+// requested by a given cluster are in place and updated.
+func (h IPTHandler) EnsureTransitRules(tep *netv1alpha1.TunnelEndpoint) error {
+	return h.updateRulesPerChain(getClusterTransitChain(tep.Spec.ClusterIdentity.ClusterID), getTransitRules(tep))
+}
+
 // EnsurePreroutingRulesPerTunnelEndpoint makes sure that the prerouting rules extracted from a
 // TunnelEndpoint resource are place and updated.
 func (h IPTHandler) EnsurePreroutingRulesPerTunnelEndpoint(tep *netv1alpha1.TunnelEndpoint) error {
@@ -683,6 +726,57 @@ func (h IPTHandler) updateRulesPerChain(chain string, newRules []IPTableRule) er
 	return h.updateSpecificRulesPerChain(chain, existingRules, newRules)
 }
 
+// Function to update the rules in a given chain, enforcing also their order.
+// Outdated rules are removed first, then the missing ones are inserted in the appropriate
+// position, and finally the leftover duplicates are removed from the bottom of the chain.
+func (h IPTHandler) updateOrderedRulesPerChain(chain string, newRules []IPTableRule) error {
+	table := getTableFromChain(chain)
+	existingRules, err := h.ListRulesInChain(chain)
+	if err != nil {
+		return fmt.Errorf("cannot list rules in chain %s (table %s): %w", chain, table, err)
+	}
+
+	desired := make([]string, len(newRules))
+	for i := range newRules {
+		desired[i] = newRules[i].String()
+	}
+
+	current := make([]string, 0, len(existingRules))
+	for _, existingRuleString := range existingRules {
+		existingRule, err := ParseRule(existingRuleString)
+		if err != nil {
+			return fmt.Errorf("cannot parse rule %q: %w", existingRuleString, err)
+		}
+		if slice.ContainsString(desired, existingRule.String()) {
+			current = append(current, existingRule.String())
+			continue
+		}
+		if err := h.ipt.Delete(table, chain, existingRule...); err != nil {
+			return fmt.Errorf("unable to delete outdated rule %s from chain %s (table %s): %w", existingRule, chain, table, err)
+		}
+		klog.Infof("Deleted outdated rule %s from chain %s (table %s)", existingRule, chain, table)
+	}
+
+	for i, rule := range newRules {
+		if i < len(current) && current[i] == desired[i] {
+			continue
+		}
+		// Rule positions in iptables start from 1.
+		if err := h.ipt.Insert(table, chain, i+1, rule...); err != nil {
+			return fmt.Errorf("unable to insert rule %s in chain %s (table %s): %w", rule, chain, table, err)
+		}
+		current = append(current[:i], append([]string{desired[i]}, current[i:]...)...)
+		klog.Infof("Inserted rule '%s' in chain %s (table %s)", rule, chain, table)
+	}
+
+	for i := len(current); i > len(desired); i-- {
+		if err := h.ipt.Delete(table, chain, strconv.Itoa(len(desired)+1)); err != nil {
+			return fmt.Errorf("unable to delete duplicated rule %s from chain %s (table %s): %w", current[i-1], chain, table, err)
+		}
+	}
+	return nil
+}
+
 func (h IPTHandler) insertRulesIfNotPresent(table, chain string, rules []IPTableRule) error {
 	for _, rule := range rules {
 		exists, err := h.ipt.Exists(table, chain, rule...)
@@ -712,6 +806,87 @@ func getClusterForwardExtRules(tep *netv1alpha1.TunnelEndpoint) ([]IPTableRule,
 	}, nil
 }
 
+// Function that returns the set of rules restricting the local destinations reachable by a remote cluster,
+// according to the given PeeringFirewalls. The allowed traffic is returned to the calling chain (rather than
+// being accepted), so that the other checks still apply. No rules are returned (hence, all the traffic is allowed)
+// in case no PeeringFirewall is specified.
+func getClusterForwardACLRules(firewalls []netv1alpha1.PeeringFirewall) []IPTableRule {
+	if len(firewalls) == 0 {
+		return []IPTableRule{}
+	}
+
+	// Sort the firewalls, to guarantee a stable ordering of the resulting rules.
+	sorted := make([]*netv1alpha1.PeeringFirewall, len(firewalls))
+	for i := range firewalls {
+		sorted[i] = &firewalls[i]
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	rules := []IPTableRule{{"-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", RETURN}}
+	added := make(map[string]struct{})
+	for _, firewall := range sorted {
+		for i := range firewall.Spec.Ingress {
+			for _, dst := range getFirewallRuleDestinations(firewall, i) {
+				for _, port := range getFirewallRulePorts(&firewall.Spec.Ingress[i]) {
+					rule := make(IPTableRule, 0, len(dst)+len(port)+2)
+					rule = append(append(append(rule, dst...), port...), "-j", RETURN)
+					if _, found := added[rule.String()]; !found {
+						added[rule.String()] = struct{}{}
+						rules = append(rules, rule)
+					}
+				}
+			}
+		}
+	}
+	return append(rules, IPTableRule{"-j", DROP})
+}
+
+// Function that returns the destination matches for the given rule of a PeeringFirewall. An empty match
+// is returned if all the destinations are allowed, while no matches are returned if the resolved destinations
+// are not yet available (or outdated), to prevent allowing unwanted traffic.
+func getFirewallRuleDestinations(firewall *netv1alpha1.PeeringFirewall, idx int) []IPTableRule {
+	if len(firewall.Spec.Ingress[idx].To) == 0 {
+		return []IPTableRule{{}}
+	}
+	if firewall.Status.ObservedGeneration != firewall.Generation || idx >= len(firewall.Status.Rules) {
+		return nil
+	}
+
+	destinations := make([]IPTableRule, 0, len(firewall.Status.Rules[idx].Destinations))
+	for _, dst := range firewall.Status.Rules[idx].Destinations {
+		// The /32 suffix is omitted, to match the rules listed by ListRulesInChain.
+		destinations = append(destinations, IPTableRule{"-d", strings.TrimSuffix(dst, "/32")})
+	}
+	return destinations
+}
+
+// Function that returns the protocol and port matches for the given rule of a PeeringFirewall.
+// An empty match is returned if all the protocols and ports are allowed.
+func getFirewallRulePorts(rule *netv1alpha1.PeeringFirewallRule) []IPTableRule {
+	if len(rule.Ports) == 0 {
+		return []IPTableRule{{}}
+	}
+
+	ports := make([]IPTableRule, 0, len(rule.Ports))
+	for i := range rule.Ports {
+		protocol := strings.ToLower(string(rule.Ports[i].Protocol))
+		if protocol == "" {
+			protocol = strings.ToLower(string(corev1.ProtocolTCP))
+		}
+
+		port := IPTableRule{"-p", protocol}
+		if rule.Ports[i].Port != nil {
+			dport := strconv.Itoa(int(*rule.Ports[i].Port))
+			if rule.Ports[i].EndPort != nil && *rule.Ports[i].EndPort > *rule.Ports[i].Port {
+				dport = fmt.Sprintf("%s:%d", dport, *rule.Ports[i].EndPort)
+			}
+			port = append(port, "-m", protocol, "--dport", dport)
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
 func getPostroutingRules(tep *netv1alpha1.TunnelEndpoint) ([]IPTableRule, error) {
 	if err := liqonetutils.CheckTep(tep); err != nil {
 		return nil, fmt.Errorf("invalid TunnelEndpoint resource: %w", err)
@@ -748,6 +923,14 @@ func getPostroutingRules(tep *netv1alpha1.TunnelEndpoint) ([]IPTableRule, error)
 	}, nil
 }
 
+func getTransitRules(tep *netv1alpha1.TunnelEndpoint) []IPTableRule {
+	return []IPTableRule{
+		{"-m", "comment", "--comment",
+			// WARNING: Never use double-quotes inside the comment, otherwise IpTableRule parser will fail
+			fmt.Sprintf("Do not NAT transit traffic of '%s'", tep.Spec.ClusterIdentity.ClusterName), "-j", ACCEPT},
+	}
+}
+
 // Function that returns the set of rules used in Liqo chains (e.g. LIQO-PREROUTING)
 // related to a remote cluster. Return value is a map of slices in which value
 // is the a set of rules and key is the chain the set of rules should belong to.
@@ -764,6 +947,19 @@ func getChainRulesPerCluster(tep *netv1alpha1.TunnelEndpoint) (map[string][]IPTa
 	chainRules[liqonetPostroutingChain] = make([]IPTableRule, 0)
 	chainRules[liqonetPreroutingChain] = make([]IPTableRule, 0)
 	chainRules[liqonetForwardingChain] = make([]IPTableRule, 0)
+	chainRules[liqonetTransitChain] = make([]IPTableRule, 0)
+
+	// Traffic forwarded on behalf of the remote cluster, in both directions (i.e., multi-hop peering).
+	for i := range tep.Spec.TransitRoutes {
+		route := &tep.Spec.TransitRoutes[i]
+		for _, src := range route.SourceCIDRs {
+			for _, dst := range route.DestinationCIDRs {
+				chainRules[liqonetTransitChain] = append(chainRules[liqonetTransitChain],
+					IPTableRule{"-s", src, "-d", dst, "-j", getClusterTransitChain(clusterID)},
+					IPTableRule{"-s", dst, "-d", src, "-j", getClusterTransitChain(clusterID)})
+			}
+		}
+	}
 
 	// For these rules, source in not necessary since
 	// the remotePodCIDR is unique in home cluster
@@ -781,7 +977,17 @@ func getChainRulesPerCluster(tep *netv1alpha1.TunnelEndpoint) (map[string][]IPTa
 		IPTableRule{
 			"-s", remotePodCIDR,
 			"-d", localRemappedExternalCIDR,
-			"-j", getClusterForwardExtChain(clusterID)})
+			"-j", getClusterForwardExtChain(clusterID)},
+		// The traffic originated by the remote cluster towards the local one is subject to the access control lists,
+		// regardless of whether it comes from the remote pods or from the remote external CIDR (e.g., host network).
+		IPTableRule{
+			"-s", remotePodCIDR,
+			"-o", consts.GatewayVethName,
+			"-j", getClusterForwardACLChain(clusterID)},
+		IPTableRule{
+			"-s", remoteExternalCIDR,
+			"-o", consts.GatewayVethName,
+			"-j", getClusterForwardACLChain(clusterID)})
 
 	chainRules[liqonetPreroutingChain] = append(chainRules[liqonetPreroutingChain],
 		IPTableRule{
@@ -826,17 +1032,27 @@ func getClusterForwardExtChain(clusterID string) string {
 	return fmt.Sprintf("%s%s", liqonetForwardingExtClusterChainPrefix, strings.Split(clusterID, "-")[0])
 }
 
+func getClusterForwardACLChain(clusterID string) string {
+	return fmt.Sprintf("%s%s", liqonetForwardingACLClusterChainPrefix, strings.Split(clusterID, "-")[0])
+}
+
 func getClusterPreRoutingMappingChain(clusterID string) string {
 	return fmt.Sprintf("%s%s", liqonetPreRoutingMappingClusterChainPrefix, strings.Split(clusterID, "-")[0])
 }
 
+func getClusterTransitChain(clusterID string) string {
+	return fmt.Sprintf("%s%s", liqonetTransitClusterChainPrefix, strings.Split(clusterID, "-")[0])
+}
+
 // Function that returns the set of Liqo default chains.
-// Value is the Liqo chain, key is the related default chain.
+// Value is the Liqo chain, key is the chain it is inserted in.
 // Example: key: PREROUTING, value: LIQO-PREROUTING.
 func getLiqoChains() map[string]string {
 	return map[string]string{
 		preroutingChain:  liqonetPreroutingChain,
 		postroutingChain: liqonetPostroutingChain,
 		forwardChain:     liqonetForwardingChain,
+		// The transit chain is inserted in the Liqo postrouting chain.
+		liqonetPostroutingChain: liqonetTransitChain,
 	}
 }
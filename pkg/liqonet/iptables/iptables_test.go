@@ -22,6 +22,8 @@ import (
 	. "github.com/coreos/go-iptables/iptables"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 
 	discv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
@@ -1025,6 +1027,144 @@ var _ = Describe("iptables", func() {
 			})
 		})
 	})
+	Describe("EnsureForwardACLRules", func() {
+		var firewall *netv1alpha1.PeeringFirewall
+
+		BeforeEach(func() {
+			Expect(h.EnsureChainsPerCluster(clusterID1)).To(Succeed())
+			tep = validTep.DeepCopy()
+			firewall = &netv1alpha1.PeeringFirewall{
+				ObjectMeta: metav1.ObjectMeta{Name: "firewall", Generation: 1},
+				Spec: netv1alpha1.PeeringFirewallSpec{
+					ClusterID: clusterID1,
+					Ingress:   []netv1alpha1.PeeringFirewallRule{{To: []netv1alpha1.PeeringFirewallPeer{{CIDR: "10.0.0.0/24"}}}},
+				},
+				Status: netv1alpha1.PeeringFirewallStatus{
+					ObservedGeneration: 1,
+					Rules:              []netv1alpha1.PeeringFirewallRuleStatus{{Destinations: []string{oldIP1, "10.0.0.0/24"}}},
+				},
+			}
+		})
+		AfterEach(func() {
+			Expect(h.RemoveIPTablesConfigurationPerCluster(tep)).To(Succeed())
+		})
+
+		Context("If the clusterID is empty", func() {
+			It("should return a WrongParameter error", func() {
+				err := h.EnsureForwardACLRules("", nil)
+				Expect(err).To(MatchError(fmt.Sprintf("%s must be %s", consts.ClusterIDLabelName, errors.StringNotEmpty)))
+			})
+		})
+		Context("Call with different firewalls", func() {
+			It("should enforce the rules in the expected order", func() {
+				Expect(h.EnsureForwardACLRules(clusterID1, []netv1alpha1.PeeringFirewall{*firewall})).To(Succeed())
+				rules, err := h.ListRulesInChain(getClusterForwardACLChain(clusterID1))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(normalizeRules(rules)).To(Equal([]string{
+					"-m conntrack --ctstate RELATED,ESTABLISHED -j RETURN",
+					fmt.Sprintf("-d %s -j RETURN", oldIP1),
+					"-d 10.0.0.0/24 -j RETURN",
+					"-j DROP",
+				}))
+
+				firewall.Status.Rules[0].Destinations = []string{oldIP2, oldIP1}
+				Expect(h.EnsureForwardACLRules(clusterID1, []netv1alpha1.PeeringFirewall{*firewall})).To(Succeed())
+				rules, err = h.ListRulesInChain(getClusterForwardACLChain(clusterID1))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(normalizeRules(rules)).To(Equal([]string{
+					"-m conntrack --ctstate RELATED,ESTABLISHED -j RETURN",
+					fmt.Sprintf("-d %s -j RETURN", oldIP2),
+					fmt.Sprintf("-d %s -j RETURN", oldIP1),
+					"-j DROP",
+				}))
+
+				Expect(h.EnsureForwardACLRules(clusterID1, nil)).To(Succeed())
+				rules, err = h.ListRulesInChain(getClusterForwardACLChain(clusterID1))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(rules).To(BeEmpty())
+			})
+		})
+	})
+	Describe("getClusterForwardACLRules", func() {
+		var (
+			port, endPort int32 = 80, 90
+			firewall      netv1alpha1.PeeringFirewall
+		)
+
+		BeforeEach(func() {
+			firewall = netv1alpha1.PeeringFirewall{
+				ObjectMeta: metav1.ObjectMeta{Name: "firewall", Generation: 1},
+				Spec: netv1alpha1.PeeringFirewallSpec{
+					ClusterID: clusterID1,
+					Ingress: []netv1alpha1.PeeringFirewallRule{{
+						To: []netv1alpha1.PeeringFirewallPeer{{CIDR: "10.0.0.0/24"}},
+						Ports: []netv1alpha1.PeeringFirewallPort{
+							{Protocol: corev1.ProtocolTCP, Port: &port, EndPort: &endPort},
+							{Protocol: corev1.ProtocolUDP},
+						},
+					}},
+				},
+				Status: netv1alpha1.PeeringFirewallStatus{
+					ObservedGeneration: 1,
+					Rules:              []netv1alpha1.PeeringFirewallRuleStatus{{Destinations: []string{oldIP1 + "/32", "10.0.0.0/24"}}},
+				},
+			}
+		})
+
+		It("should allow all the traffic if no firewall is specified", func() {
+			Expect(getClusterForwardACLRules(nil)).To(BeEmpty())
+		})
+
+		It("should allow the traffic towards the resolved destinations and ports", func() {
+			Expect(getClusterForwardACLRules([]netv1alpha1.PeeringFirewall{firewall})).To(Equal([]IPTableRule{
+				{"-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", RETURN},
+				{"-d", oldIP1, "-p", "tcp", "-m", "tcp", "--dport", "80:90", "-j", RETURN},
+				{"-d", oldIP1, "-p", "udp", "-j", RETURN},
+				{"-d", "10.0.0.0/24", "-p", "tcp", "-m", "tcp", "--dport", "80:90", "-j", RETURN},
+				{"-d", "10.0.0.0/24", "-p", "udp", "-j", RETURN},
+				{"-j", DROP},
+			}))
+		})
+
+		It("should allow all the destinations if none is specified", func() {
+			firewall.Spec.Ingress[0].To = nil
+			firewall.Spec.Ingress[0].Ports = firewall.Spec.Ingress[0].Ports[:1]
+			Expect(getClusterForwardACLRules([]netv1alpha1.PeeringFirewall{firewall})).To(Equal([]IPTableRule{
+				{"-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", RETURN},
+				{"-p", "tcp", "-m", "tcp", "--dport", "80:90", "-j", RETURN},
+				{"-j", DROP},
+			}))
+		})
+
+		It("should not allow any destination if the status is outdated", func() {
+			firewall.Generation = 2
+			Expect(getClusterForwardACLRules([]netv1alpha1.PeeringFirewall{firewall})).To(Equal([]IPTableRule{
+				{"-m", "conntrack", "--ctstate", "RELATED,ESTABLISHED", "-j", RETURN},
+				{"-j", DROP},
+			}))
+		})
+
+		It("should not duplicate the rules shared by multiple firewalls", func() {
+			other := *firewall.DeepCopy()
+			other.Name = "another"
+			Expect(getClusterForwardACLRules([]netv1alpha1.PeeringFirewall{firewall, other})).To(
+				Equal(getClusterForwardACLRules([]netv1alpha1.PeeringFirewall{firewall})))
+		})
+	})
+	Describe("getChainRulesPerCluster", func() {
+		It("should subject the traffic from both the remote pod and external CIDRs to the access control lists", func() {
+			tep = validTep.DeepCopy()
+			_, remotePodCIDR := liqonetutils.GetPodCIDRS(tep)
+			_, remoteExternalCIDR := liqonetutils.GetExternalCIDRS(tep)
+
+			chainRules, err := getChainRulesPerCluster(tep)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(chainRules[liqonetForwardingChain]).To(ContainElements(
+				IPTableRule{"-s", remotePodCIDR, "-o", consts.GatewayVethName, "-j", getClusterForwardACLChain(clusterID1)},
+				IPTableRule{"-s", remoteExternalCIDR, "-o", consts.GatewayVethName, "-j", getClusterForwardACLChain(clusterID1)},
+			))
+		})
+	})
 	Describe("Utilities", func() {
 		var (
 			words = []string{"word0", "word1", "word2", "word3"}
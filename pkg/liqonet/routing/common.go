@@ -38,6 +38,12 @@ const (
 
 // AddRoute adds a new route on the given interface.
 func AddRoute(dstNet, gwIP string, iFaceIndex, tableID, flags int, scope netlink.Scope) (bool, error) {
+	return AddRouteWithMTU(dstNet, gwIP, iFaceIndex, tableID, flags, scope, 0)
+}
+
+// AddRouteWithMTU adds a new route on the given interface, with the given MTU (zero to inherit the one of the interface).
+// The MTU is ignored if not lower than the one of the interface, since it would not have any effect.
+func AddRouteWithMTU(dstNet, gwIP string, iFaceIndex, tableID, flags int, scope netlink.Scope, mtu int) (bool, error) {
 	var route *netlink.Route
 	var gatewayIP net.IP
 	// Convert destination in *net.IPNet.
@@ -59,6 +65,7 @@ func AddRoute(dstNet, gwIP string, iFaceIndex, tableID, flags int, scope netlink
 		LinkIndex: iFaceIndex,
 		Flags:     flags,
 		Scope:     scope,
+		MTU:       routeMTU(iFaceIndex, mtu),
 	}
 	// Check if already exists a route for the given destination.
 	routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, route, netlink.RT_FILTER_TABLE|netlink.RT_FILTER_DST)
@@ -70,14 +77,17 @@ func AddRoute(dstNet, gwIP string, iFaceIndex, tableID, flags int, scope netlink
 	if len(routes) == 1 {
 		r := routes[0]
 		// Check if the existing rule is equal to the one that we want to configure.
-		if reflect.DeepEqual(r.Gw, gatewayIP.To4()) && r.LinkIndex == iFaceIndex {
+		if reflect.DeepEqual(r.Gw, gatewayIP.To4()) && r.LinkIndex == iFaceIndex && r.MTU == route.MTU {
 			klog.V(5).Infof("route {%s} already exists", route.String())
 			return false, nil
 		}
-		// Otherwise remove the outdated route.
-		if err := netlink.RouteDel(&r); err != nil {
+		// Otherwise atomically replace the outdated route (e.g., in case of gateway failover), to prevent
+		// the traffic from being temporarily routed through the default routes.
+		klog.V(5).Infof("replacing route {%s} with {%s}", r.String(), route.String())
+		if err := netlink.RouteReplace(route); err != nil {
 			return false, err
 		}
+		return true, nil
 	}
 	klog.V(5).Infof("inserting route {%s}", route.String())
 	if err := netlink.RouteAdd(route); err != nil {
@@ -86,6 +96,22 @@ func AddRoute(dstNet, gwIP string, iFaceIndex, tableID, flags int, scope netlink
 	return true, nil
 }
 
+// routeMTU returns the MTU to be configured for a route on the given interface, given the desired one.
+func routeMTU(iFaceIndex, mtu int) int {
+	if mtu <= 0 {
+		return 0
+	}
+	link, err := netlink.LinkByIndex(iFaceIndex)
+	if err != nil {
+		klog.Warningf("failed to retrieve the interface with index %d: %v", iFaceIndex, err)
+		return mtu
+	}
+	if mtu >= link.Attrs().MTU {
+		return 0
+	}
+	return mtu
+}
+
 // DelRoute removes a route described by the given parameters.
 func DelRoute(dstNet, gwIP string, iFaceIndex, tableID int) (bool, error) {
 	var route *netlink.Route
@@ -182,6 +182,26 @@ var _ = Describe("Common", func() {
 				Expect(routes[0].LinkIndex).Should(BeNumerically("==", dummyLink2.Attrs().Index))
 				Expect(routes[0].Gw.String()).Should(Equal(gwIPCorrect))
 			})
+
+			It("update MTU of existing route: should return true and nil", func() {
+				added, err := AddRouteWithMTU(existingRoutesCM[0].Dst.String(), existingRoutesCM[0].Gw.String(), existingRoutesCM[0].LinkIndex,
+					existingRoutesCM[0].Table, DefaultFlags, DefaultScope, 1400)
+				Expect(added).Should(Equal(true))
+				Expect(err).NotTo(HaveOccurred())
+				// Get the route and check it has the right parameters
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, existingRoutesCM[0], netlink.RT_FILTER_DST|netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes[0].MTU).Should(BeNumerically("==", 1400))
+
+				// An MTU not lower than the one of the interface should be ignored.
+				added, err = AddRouteWithMTU(existingRoutesCM[0].Dst.String(), existingRoutesCM[0].Gw.String(), existingRoutesCM[0].LinkIndex,
+					existingRoutesCM[0].Table, DefaultFlags, DefaultScope, dummylink1.Attrs().MTU)
+				Expect(added).Should(Equal(true))
+				Expect(err).NotTo(HaveOccurred())
+				routes, err = netlink.RouteListFiltered(netlink.FAMILY_V4, existingRoutesCM[0], netlink.RT_FILTER_DST|netlink.RT_FILTER_TABLE)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(routes[0].MTU).Should(BeZero())
+			})
 		})
 	})
 
@@ -81,13 +81,15 @@ func (drm *DirectRoutingManager) EnsureRoutesPerCluster(tep *netv1alpha1.TunnelE
 	// Add routes for the given cluster.
 	klog.Infof("%s -> adding route for destination {%s} with gateway {%s} in routing table with ID {%d}",
 		clusterID, dstPodCIDR, gatewayIP, drm.routingTableID)
-	routePodCIDRAdd, err = AddRoute(dstPodCIDR, gatewayIP, iFaceIndex, drm.routingTableID, DefaultFlags, DefaultScope)
+	routePodCIDRAdd, err = AddRouteWithMTU(dstPodCIDR, gatewayIP, iFaceIndex,
+		drm.routingTableID, DefaultFlags, DefaultScope, tep.Status.PathMTU)
 	if err != nil {
 		return routePodCIDRAdd, err
 	}
 	klog.Infof("%s -> adding route for destination {%s} with gateway {%s} in routing table with ID {%d}",
 		clusterID, dstExternalCIDR, gatewayIP, drm.routingTableID)
-	routeExternalCIDRAdd, err = AddRoute(dstExternalCIDR, gatewayIP, iFaceIndex, drm.routingTableID, DefaultFlags, DefaultScope)
+	routeExternalCIDRAdd, err = AddRouteWithMTU(dstExternalCIDR, gatewayIP, iFaceIndex,
+		drm.routingTableID, DefaultFlags, DefaultScope, tep.Status.PathMTU)
 	if err != nil {
 		return routeExternalCIDRAdd, err
 	}
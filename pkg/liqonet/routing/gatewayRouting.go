@@ -16,6 +16,7 @@ package routing
 
 import (
 	"strconv"
+	"sync"
 
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
@@ -23,6 +24,7 @@ import (
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	liqoerrors "github.com/liqotech/liqo/pkg/liqonet/errors"
 	liqonetutils "github.com/liqotech/liqo/pkg/liqonet/utils"
+	"github.com/liqotech/liqo/pkg/utils/slice"
 )
 
 // GatewayRoutingManager implements the routing manager interface.
@@ -30,10 +32,21 @@ import (
 type GatewayRoutingManager struct {
 	routingTableID int
 	tunnelDevice   netlink.Link
+	// defaultMTU is the MTU of the routes towards the clusters whose path MTU has not been discovered
+	// (zero to inherit the one of the tunnel device).
+	defaultMTU int
+	// transitRoutes keeps track, for each next-hop cluster, of the routes towards the networks of the clusters
+	// connected through the local one (i.e., multi-hop peering), to remove them along with the ones of that cluster.
+	transitRoutes map[string][]string
+	// directRoutes keeps track, for each cluster, of the routes towards its own networks, which may coincide with
+	// the transit networks associated with other clusters, hence preventing their removal.
+	directRoutes map[string][]string
+	transitMutex sync.Mutex
 }
 
 // NewGatewayRoutingManager returns a GatewayRoutingManager ready to be used or an error.
-func NewGatewayRoutingManager(routingTableID int, tunnelDevice netlink.Link) (Routing, error) {
+// The defaultMTU is configured on the routes towards the clusters whose path MTU has not been discovered.
+func NewGatewayRoutingManager(routingTableID int, tunnelDevice netlink.Link, defaultMTU int) (Routing, error) {
 	// Check the validity of input parameters.
 	if routingTableID > unix.RT_TABLE_MAX {
 		return nil, &liqoerrors.WrongParameter{Parameter: "routingTableID", Reason: liqoerrors.MinorOrEqual + strconv.Itoa(unix.RT_TABLE_MAX)}
@@ -50,6 +63,9 @@ func NewGatewayRoutingManager(routingTableID int, tunnelDevice netlink.Link) (Ro
 	return &GatewayRoutingManager{
 		routingTableID: routingTableID,
 		tunnelDevice:   tunnelDevice,
+		defaultMTU:     defaultMTU,
+		transitRoutes:  make(map[string][]string),
+		directRoutes:   make(map[string][]string),
 	}, nil
 }
 
@@ -63,23 +79,49 @@ func (grm *GatewayRoutingManager) EnsureRoutesPerCluster(tep *netv1alpha1.Tunnel
 	// Extract and save route information from the given tep.
 	_, dstPodCIDRNet := liqonetutils.GetPodCIDRS(tep)
 	_, dstExternalCIDRNet := liqonetutils.GetExternalCIDRS(tep)
+	// The routes for the given cluster are configured with its path MTU, if discovered.
+	mtu := grm.defaultMTU
+	if tep.Status.PathMTU > 0 {
+		mtu = tep.Status.PathMTU
+	}
 	// Add routes for the given cluster.
-	routePodCIDRAdd, err = AddRoute(dstPodCIDRNet, "", grm.tunnelDevice.Attrs().Index, grm.routingTableID, DefaultFlags, DefaultScope)
+	routePodCIDRAdd, err = AddRouteWithMTU(dstPodCIDRNet, "", grm.tunnelDevice.Attrs().Index, grm.routingTableID, DefaultFlags, DefaultScope, mtu)
 	if err != nil {
 		return routePodCIDRAdd, err
 	}
-	routeExternalCIDRAdd, err = AddRoute(dstExternalCIDRNet, "", grm.tunnelDevice.Attrs().Index, grm.routingTableID, DefaultFlags, DefaultScope)
+	routeExternalCIDRAdd, err = AddRouteWithMTU(dstExternalCIDRNet, "", grm.tunnelDevice.Attrs().Index, grm.routingTableID,
+		DefaultFlags, DefaultScope, mtu)
 	if err != nil {
 		return routeExternalCIDRAdd, err
 	}
 	if routePodCIDRAdd || routeExternalCIDRAdd {
 		configured = true
 	}
-	return configured, nil
+	// Add routes for the clusters the given one is connected to through the local cluster (i.e., multi-hop peering).
+	transitCIDRs := liqonetutils.GetTransitCIDRs(tep)
+	for _, cidr := range transitCIDRs {
+		// The route towards the networks of the given cluster has already been configured.
+		if cidr == dstPodCIDRNet || cidr == dstExternalCIDRNet {
+			continue
+		}
+		routeTransitCIDRAdd, err := AddRouteWithMTU(cidr, "", grm.tunnelDevice.Attrs().Index, grm.routingTableID,
+			DefaultFlags, DefaultScope, grm.defaultMTU)
+		if err != nil {
+			return routeTransitCIDRAdd, err
+		}
+		configured = configured || routeTransitCIDRAdd
+	}
+	// Remove the routes towards the transit networks no longer associated with the given cluster.
+	routeTransitCIDRDel, err := grm.setTransitRoutes(tep.Spec.ClusterIdentity.ClusterID, transitCIDRs, dstPodCIDRNet, dstExternalCIDRNet)
+	if err != nil {
+		return routeTransitCIDRDel, err
+	}
+	return configured || routeTransitCIDRDel, nil
 }
 
 // RemoveRoutesPerCluster accepts as input a netv1alpha.tunnelendpoint.
-// It deletes the routes if they do exist.
+// It deletes the routes if they do exist, including the ones towards the transit networks associated with the given cluster,
+// unless they are still leveraged by other clusters.
 // Returns true if the routes exist and have been deleted, false if nothing is removed.
 // An error if something goes wrong and the routes can not be removed.
 func (grm *GatewayRoutingManager) RemoveRoutesPerCluster(tep *netv1alpha1.TunnelEndpoint) (bool, error) {
@@ -100,7 +142,57 @@ func (grm *GatewayRoutingManager) RemoveRoutesPerCluster(tep *netv1alpha1.Tunnel
 	if routePodCIDRDel || routeExternalCIDRDel {
 		configured = true
 	}
-	return configured, nil
+	// Delete the routes towards the transit networks associated with the given cluster.
+	routeTransitCIDRDel, err := grm.setTransitRoutes(tep.Spec.ClusterIdentity.ClusterID, nil)
+	if err != nil {
+		return routeTransitCIDRDel, err
+	}
+	return configured || routeTransitCIDRDel, nil
+}
+
+// setTransitRoutes updates the routes towards the transit networks associated with the given next-hop cluster,
+// deleting the ones no longer present, unless still leveraged by other clusters or matching its own networks.
+// Returns true if at least one route has been deleted.
+func (grm *GatewayRoutingManager) setTransitRoutes(clusterID string, cidrs []string, direct ...string) (bool, error) {
+	grm.transitMutex.Lock()
+	defer grm.transitMutex.Unlock()
+
+	if len(direct) == 0 {
+		delete(grm.directRoutes, clusterID)
+	} else {
+		grm.directRoutes[clusterID] = direct
+	}
+
+	var deleted bool
+	for _, cidr := range grm.transitRoutes[clusterID] {
+		if slice.ContainsString(cidrs, cidr) || slice.ContainsString(direct, cidr) || grm.isRouteInUse(clusterID, cidr) {
+			continue
+		}
+		routeTransitCIDRDel, err := DelRoute(cidr, "", grm.tunnelDevice.Attrs().Index, grm.routingTableID)
+		if err != nil {
+			return deleted, err
+		}
+		deleted = deleted || routeTransitCIDRDel
+	}
+
+	if len(cidrs) == 0 {
+		delete(grm.transitRoutes, clusterID)
+	} else {
+		grm.transitRoutes[clusterID] = cidrs
+	}
+	return deleted, nil
+}
+
+// isRouteInUse returns whether the route towards the given network is associated with any cluster but the given one.
+func (grm *GatewayRoutingManager) isRouteInUse(clusterID, cidr string) bool {
+	for _, routes := range []map[string][]string{grm.transitRoutes, grm.directRoutes} {
+		for cluster, cidrs := range routes {
+			if cluster != clusterID && slice.ContainsString(cidrs, cidr) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // CleanRoutingTable stub function, as the gateway only operates in custom network namespace.
@@ -23,6 +23,7 @@ import (
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
 
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	liqoerrors "github.com/liqotech/liqo/pkg/liqonet/errors"
 )
@@ -55,19 +56,19 @@ var _ = Describe("GatewayRouting", func() {
 
 		Context("when parameters are not valid", func() {
 			It("routingTableID parameter out of range: a negative number", func() {
-				grm, err := NewGatewayRoutingManager(-244, tunnelDevice)
+				grm, err := NewGatewayRoutingManager(-244, tunnelDevice, 0)
 				Expect(grm).Should(BeNil())
 				Expect(err).Should(Equal(&liqoerrors.WrongParameter{Parameter: "routingTableID", Reason: liqoerrors.GreaterOrEqual + strconv.Itoa(0)}))
 			})
 
 			It("routingTableID parameter out of range: superior to max value ", func() {
-				grm, err := NewGatewayRoutingManager(unix.RT_TABLE_MAX+1, tunnelDevice)
+				grm, err := NewGatewayRoutingManager(unix.RT_TABLE_MAX+1, tunnelDevice, 0)
 				Expect(grm).Should(BeNil())
 				Expect(err).Should(
 					Equal(&liqoerrors.WrongParameter{Parameter: "routingTableID", Reason: liqoerrors.MinorOrEqual + strconv.Itoa(unix.RT_TABLE_MAX)}))
 			})
 			It("tunnelDevice is nil", func() {
-				grm, err := NewGatewayRoutingManager(routingTableIDGRM, nil)
+				grm, err := NewGatewayRoutingManager(routingTableIDGRM, nil, 0)
 				Expect(grm).Should(BeNil())
 				Expect(err).Should(Equal(&liqoerrors.WrongParameter{Parameter: "tunnelDevice", Reason: liqoerrors.NotNil}))
 			})
@@ -75,7 +76,7 @@ var _ = Describe("GatewayRouting", func() {
 
 		Context("when parameters are correct", func() {
 			It("right parameters", func() {
-				grm, err := NewGatewayRoutingManager(routingTableIDGRM, tunnelDevice)
+				grm, err := NewGatewayRoutingManager(routingTableIDGRM, tunnelDevice, 0)
 				Expect(grm).ShouldNot(BeNil())
 				Expect(err).ShouldNot(HaveOccurred())
 			})
@@ -233,6 +234,80 @@ var _ = Describe("GatewayRouting", func() {
 		})
 	})
 
+	Describe("removing route configuration for a remote cluster acting as next hop for transit networks", func() {
+		var tepNextHop, tepOther netv1alpha1.TunnelEndpoint
+
+		forgeTep := func(clusterID, podCIDR, externalCIDR string, transitCIDRs ...string) netv1alpha1.TunnelEndpoint {
+			return netv1alpha1.TunnelEndpoint{
+				Spec: netv1alpha1.TunnelEndpointSpec{
+					ClusterIdentity:       discoveryv1alpha1.ClusterIdentity{ClusterID: clusterID},
+					RemoteNATPodCIDR:      podCIDR,
+					RemoteNATExternalCIDR: externalCIDR,
+					TransitRoutes: []netv1alpha1.TransitRoute{{
+						DestinationClusterID: "destination-cluster",
+						SourceCIDRs:          transitCIDRs[:1],
+						DestinationCIDRs:     transitCIDRs[1:],
+					}},
+				}}
+		}
+
+		routeExists := func(cidr string) bool {
+			_, dst, err := net.ParseCIDR(cidr)
+			Expect(err).ShouldNot(HaveOccurred())
+			routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Dst: dst, Table: routingTableIDGRM},
+				netlink.RT_FILTER_DST|netlink.RT_FILTER_TABLE)
+			Expect(err).ShouldNot(HaveOccurred())
+			return len(routes) > 0
+		}
+
+		JustBeforeEach(func() {
+			tepNextHop = forgeTep("next-hop-cluster", "10.170.0.0/16", "10.171.0.0/16", "10.172.0.0/16", "10.173.0.0/16")
+			tepOther = forgeTep("other-cluster", "10.180.0.0/16", "10.181.0.0/16", "10.173.0.0/16", "10.170.0.0/16")
+
+			_, err := grm.EnsureRoutesPerCluster(&tepNextHop)
+			Expect(err).ShouldNot(HaveOccurred())
+			_, err = grm.EnsureRoutesPerCluster(&tepOther)
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		JustAfterEach(func() {
+			tearDownRoutes(routingTableIDGRM)
+		})
+
+		It("transit routes should be removed along with the ones of the next-hop cluster, unless used by other clusters", func() {
+			deleted, err := grm.RemoveRoutesPerCluster(&tepNextHop)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deleted).Should(BeTrue())
+			Expect(routeExists("10.170.0.0/16")).Should(BeFalse())
+			Expect(routeExists("10.171.0.0/16")).Should(BeFalse())
+			Expect(routeExists("10.172.0.0/16")).Should(BeFalse())
+			// The transit network is still associated with the other cluster.
+			Expect(routeExists("10.173.0.0/16")).Should(BeTrue())
+
+			deleted, err = grm.RemoveRoutesPerCluster(&tepOther)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(deleted).Should(BeTrue())
+			Expect(routeExists("10.173.0.0/16")).Should(BeFalse())
+		})
+
+		It("transit routes no longer present in the tep should be removed", func() {
+			tepOther.Spec.TransitRoutes = nil
+			_, err := grm.EnsureRoutesPerCluster(&tepOther)
+			Expect(err).ShouldNot(HaveOccurred())
+			// The transit network is still associated with the next-hop cluster.
+			Expect(routeExists("10.173.0.0/16")).Should(BeTrue())
+			// The transit network matches the networks of the next-hop cluster.
+			Expect(routeExists("10.170.0.0/16")).Should(BeTrue())
+			Expect(routeExists("10.180.0.0/16")).Should(BeTrue())
+
+			tepNextHop.Spec.TransitRoutes = nil
+			_, err = grm.EnsureRoutesPerCluster(&tepNextHop)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(routeExists("10.172.0.0/16")).Should(BeFalse())
+			Expect(routeExists("10.173.0.0/16")).Should(BeFalse())
+		})
+	})
+
 	Describe("removing all routes configurations managed by the gateway route manager", func() {
 		Context("removing routes, should return nil", func() {
 			JustBeforeEach(func() {
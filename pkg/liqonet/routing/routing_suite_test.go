@@ -93,7 +93,7 @@ var _ = BeforeSuite(func() {
 	Expect(tunnelDevice).NotTo(BeNil())
 	// Set up dummy tunnel device
 	Expect(netlink.LinkSetUp(tunnelDevice)).To(BeNil())
-	grm, err = NewGatewayRoutingManager(routingTableIDGRM, tunnelDevice)
+	grm, err = NewGatewayRoutingManager(routingTableIDGRM, tunnelDevice, 0)
 	Expect(err).Should(BeNil())
 	Expect(grm).NotTo(BeNil())
 })
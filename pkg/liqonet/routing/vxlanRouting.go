@@ -130,7 +130,8 @@ func (vrm *VxlanRoutingManager) EnsureRoutesPerCluster(tep *netv1alpha1.TunnelEn
 	// Add routes for the given cluster.
 	klog.V(5).Infof("%s -> adding route for destination {%s} with gateway {%s} in routing table with ID {%d} on device {%s}",
 		clusterID, dstPodCIDR, gatewayIP, vrm.routingTableID, iFaceName)
-	routePodCIDRAdd, err = AddRoute(dstPodCIDR, gatewayIP, iFaceIndex, vrm.routingTableID, DefaultFlags, DefaultScope)
+	routePodCIDRAdd, err = AddRouteWithMTU(dstPodCIDR, gatewayIP, iFaceIndex,
+		vrm.routingTableID, DefaultFlags, DefaultScope, tep.Status.PathMTU)
 	if err != nil {
 		return routePodCIDRAdd, fmt.Errorf("%s -> unable to add route for destination {%s} with gateway {%s} "+
 			"in routing table with ID {%d} on device {%s}: %w",
@@ -138,7 +139,8 @@ func (vrm *VxlanRoutingManager) EnsureRoutesPerCluster(tep *netv1alpha1.TunnelEn
 	}
 	klog.V(5).Infof("%s -> adding route for destination {%s} with gateway {%s} in routing table with ID {%d} on device {%s}",
 		clusterID, dstExternalCIDR, gatewayIP, vrm.routingTableID, iFaceName)
-	routeExternalCIDRAdd, err = AddRoute(dstExternalCIDR, gatewayIP, iFaceIndex, vrm.routingTableID, DefaultFlags, DefaultScope)
+	routeExternalCIDRAdd, err = AddRouteWithMTU(dstExternalCIDR, gatewayIP, iFaceIndex,
+		vrm.routingTableID, DefaultFlags, DefaultScope, tep.Status.PathMTU)
 	if err != nil {
 		return routeExternalCIDRAdd, fmt.Errorf("%s -> unable to add route for destination {%s} with gateway "+
 			"{%s} in routing table with ID {%d} on device {%s}: %w",
@@ -248,6 +248,24 @@ var _ = Describe("VxlanRouting", func() {
 			Expect(routes[0].Dst.String()).Should(Equal(tepVRM.Spec.RemoteNATPodCIDR))
 			Expect(routes[0].Gw.String()).Should(Equal("240.0.0.5"))
 		})
+
+		It("gateway failover, should switch all the routes to the new active gateway", func() {
+			tepVRM.Spec.RemoteNATPodCIDR = existingRoutesVRM[0].Dst.String()
+			tepVRM.Spec.RemoteNATExternalCIDR = existingRoutesVRM[1].Dst.String()
+			// The standby replica running on a different node becomes the active one.
+			tepVRM.Status.GatewayIP = gwIPCorrect
+			added, err := vrm.EnsureRoutesPerCluster(&tepVRM)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(added).Should(BeTrue())
+			// Check that each route has been replaced, rather than duplicated.
+			for _, existing := range existingRoutesVRM {
+				routes, err := netlink.RouteListFiltered(netlink.FAMILY_V4, existing, netlink.RT_FILTER_DST|netlink.RT_FILTER_TABLE)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(routes).Should(HaveLen(1))
+				Expect(routes[0].Gw.String()).Should(Equal("240.0.0.5"))
+				Expect(routes[0].LinkIndex).Should(Equal(overlayDevice.Link.Index))
+			}
+		})
 	})
 
 	Describe("removing route configuration for a remote peering cluster", func() {
@@ -15,6 +15,9 @@
 package tunnel
 
 import (
+	"context"
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/vishvananda/netlink"
 	k8s "k8s.io/client-go/kubernetes"
@@ -43,6 +46,17 @@ func AddDriver(name string, driverCreate DriverCreateFunc) {
 type Config struct {
 	MTU           int
 	ListeningPort int
+	// KeysRotationInterval is the interval after which the keys are rotated (zero disables the rotation).
+	KeysRotationInterval time.Duration
+	// KeysRotationOverlap is the time the next keys are announced to the remote clusters before being used.
+	KeysRotationOverlap time.Duration
+	// NATTraversalTimeout is the time after which a failed hole punching attempt falls back to the relayed mode.
+	NATTraversalTimeout time.Duration
+	// NATTraversalRetryInterval is the interval after which the hole punching is attempted again, while relayed.
+	NATTraversalRetryInterval time.Duration
+	// PathMTUDiscoveryInterval is the interval after which the path MTU towards each remote cluster is discovered again
+	// (zero disables the discovery).
+	PathMTUDiscoveryInterval time.Duration
 }
 
 // Driver the interface needed to be implemented by new vpn drivers.
@@ -59,3 +73,15 @@ type Driver interface {
 
 	prometheus.Collector
 }
+
+// KeysRotator is the interface implemented by the drivers supporting the periodic rotation of the tunnel keys.
+type KeysRotator interface {
+	// RotateKeys performs, if necessary, the next step of the keys rotation process.
+	RotateKeys(ctx context.Context) error
+}
+
+// PathMTUDiscoverer is the interface implemented by the drivers supporting the discovery of the path MTU towards the remote clusters.
+type PathMTUDiscoverer interface {
+	// PathMTU returns the path MTU discovered towards the given remote cluster, or zero if not (yet) available.
+	PathMTU(clusterID string) int
+}
@@ -28,6 +28,12 @@ var (
 	PeerLatency *prometheus.Desc
 	// PeerIsConnected is the metric that outputs the connection status.
 	PeerIsConnected *prometheus.Desc
+	// PeerRTT is the metric that exposes the distribution of the round-trip time towards a given peer.
+	PeerRTT *prometheus.Desc
+	// PeerJitter is the metric that exposes the distribution of the jitter towards a given peer.
+	PeerJitter *prometheus.Desc
+	// PeerPacketLoss is the metric that exposes the distribution of the packet loss ratio towards a given peer.
+	PeerPacketLoss *prometheus.Desc
 	// MetricsLabels is the labels that are used for the metrics.
 	MetricsLabels []string
 )
@@ -63,6 +69,27 @@ func init() {
 		MetricsLabels,
 		nil,
 	)
+
+	PeerRTT = prometheus.NewDesc(
+		"liqo_peer_rtt_seconds",
+		"Round-trip time towards a given peer, measured by the connection check.",
+		MetricsLabels,
+		nil,
+	)
+
+	PeerJitter = prometheus.NewDesc(
+		"liqo_peer_jitter_seconds",
+		"Jitter of the round-trip time towards a given peer, measured by the connection check.",
+		MetricsLabels,
+		nil,
+	)
+
+	PeerPacketLoss = prometheus.NewDesc(
+		"liqo_peer_packet_loss_ratio",
+		"Ratio of connection check probes lost towards a given peer, measured over windows of consecutive probes.",
+		MetricsLabels,
+		nil,
+	)
 }
 
 // Describe implements prometheus.Collector.
@@ -71,6 +98,9 @@ func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
 	ch <- PeerTransmittedBytes
 	ch <- PeerLatency
 	ch <- PeerIsConnected
+	ch <- PeerRTT
+	ch <- PeerJitter
+	ch <- PeerPacketLoss
 }
 
 // MetricsErrorHandler is a function that handles metrics errors.
@@ -79,4 +109,7 @@ func (m *Metrics) MetricsErrorHandler(err error, ch chan<- prometheus.Metric) {
 	ch <- prometheus.NewInvalidMetric(PeerTransmittedBytes, err)
 	ch <- prometheus.NewInvalidMetric(PeerLatency, err)
 	ch <- prometheus.NewInvalidMetric(PeerIsConnected, err)
+	ch <- prometheus.NewInvalidMetric(PeerRTT, err)
+	ch <- prometheus.NewInvalidMetric(PeerJitter, err)
+	ch <- prometheus.NewInvalidMetric(PeerPacketLoss, err)
 }
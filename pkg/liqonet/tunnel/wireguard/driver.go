@@ -27,6 +27,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
@@ -53,10 +54,20 @@ const (
 	DriverName = "wireguard"
 	// PrivateKey is the key of private for the secret containing the wireguard keys.
 	PrivateKey = "privateKey"
+	// NextPrivateKey is the key of the next private key (i.e., the one being rotated in) for the secret containing the wireguard keys.
+	NextPrivateKey = "nextPrivateKey"
 	// EndpointIP is the key of the endpointIP entry in back-end map.
 	EndpointIP = "endpointIP"
 	// AllowedIPs is the key of the allowedIPs entry in the back-end map.
 	AllowedIPs = "allowedIPs"
+	// TransitClusterID is the key of the transitClusterID entry in the back-end map.
+	TransitClusterID = "transitClusterID"
+	// RendezvousClusterID is the key of the rendezvousClusterID entry in the back-end map.
+	RendezvousClusterID = "rendezvousClusterID"
+	// NATTraversalMode is the key of the natTraversalMode entry in the back-end map.
+	NATTraversalMode = "natTraversalMode"
+	// HolePunchingAttempts is the key of the holePunchingAttempts entry in the back-end map.
+	HolePunchingAttempts = "holePunchingAttempts"
 	// name of the secret that contains the public key used by wireguard.
 	keysName = "wireguard-pubkey"
 )
@@ -75,6 +86,24 @@ type wgConfig struct {
 	pubKey wgtypes.Key
 	// iFaceMTU  mtu of wg interface.
 	iFaceMTU int
+	// keysRotationInterval is the interval after which a new key pair is generated (0 disables the rotation).
+	keysRotationInterval time.Duration
+	// keysRotationOverlap is the interval the next keys are announced to the remote clusters, before being used.
+	keysRotationOverlap time.Duration
+	// natTraversalTimeout is the interval after which a failed hole punching attempt falls back to the relayed mode.
+	natTraversalTimeout time.Duration
+	// natTraversalRetryInterval is the interval after which the hole punching is attempted again, while relayed.
+	natTraversalRetryInterval time.Duration
+	// pmtuDiscoveryInterval is the interval after which the path MTU towards each remote cluster is discovered again
+	// (0 disables the discovery).
+	pmtuDiscoveryInterval time.Duration
+}
+
+// wgClient is the subset of the wgctrl.Client methods used by the driver to interact with the WireGuard device.
+type wgClient interface {
+	ConfigureDevice(name string, cfg wgtypes.Config) error
+	Device(name string) (*wgtypes.Device, error)
+	Close() error
 }
 
 // ResolverFunc type of function that knows how to resolve an ip address belonging to
@@ -89,10 +118,36 @@ type Wireguard struct {
 	connectionsMutex sync.RWMutex
 	// connectedClusterIdentities key is the peer's public key.
 	connectedClusterIdentities map[wgtypes.Key]*discv1alpha1.ClusterIdentity
-	client                     *wgctrl.Client
+	client                     wgClient
 	link                       netlink.Link
 	conf                       wgConfig
 	Connchecker                *conncheck.ConnChecker
+	// transitAllowedIPs key is the clusterID of a peer, while the value associates the clusterID of the cluster
+	// which requested them to the additional allowed IPs required to act as intermediate hop (i.e., multi-hop peering).
+	transitAllowedIPs map[string]map[string][]net.IPNet
+	// directAllowedIPs key is the clusterID of a peer, while the value are its allowed IPs, excluding the transit ones.
+	directAllowedIPs map[string][]net.IPNet
+	transitMutex     sync.RWMutex
+	// stalePeers key is a clusterID, while the value is the previous public key of the remote cluster,
+	// whose peer is kept until the connection through the new one is confirmed (protected by connectionsMutex).
+	stalePeers map[string]wgtypes.Key
+	// lastConnected key is the clusterID of a remote cluster reached through a direct peer, while the value is the last
+	// time the connection check succeeded, while connected (protected by connectionsMutex).
+	lastConnected map[string]time.Time
+	// keysMutex protects the keys configured in the device, as well as the state of the switch to the next ones.
+	keysMutex sync.Mutex
+	// keysSwitch tracks the switch to the next keys, pending the confirmation of the connection checks (nil if none).
+	keysSwitch *keysSwitchState
+	// natTraversal key is the clusterID of a remote cluster reached by means of NAT traversal.
+	natTraversal      map[string]*natTraversalState
+	natTraversalMutex sync.Mutex
+	// pathMTUs key is the clusterID of a remote cluster whose path MTU is discovered.
+	pathMTUs     map[string]*pathMTUState
+	pathMTUMutex sync.Mutex
+	// underlayNetns is the network namespace hosting the WireGuard socket, where the path MTU is probed.
+	underlayNetns ns.NetNS
+	k8sClient     k8s.Interface
+	namespace     string
 }
 
 // NewDriver creates a new WireGuard driver.
@@ -101,25 +156,45 @@ func NewDriver(k8sClient k8s.Interface, namespace string, config tunnel.Config)
 	w := Wireguard{
 		connections:                make(map[string]*netv1alpha1.Connection),
 		connectedClusterIdentities: make(map[wgtypes.Key]*discv1alpha1.ClusterIdentity),
+		transitAllowedIPs:          make(map[string]map[string][]net.IPNet),
+		directAllowedIPs:           make(map[string][]net.IPNet),
+		stalePeers:                 make(map[string]wgtypes.Key),
+		lastConnected:              make(map[string]time.Time),
+		natTraversal:               make(map[string]*natTraversalState),
+		pathMTUs:                   make(map[string]*pathMTUState),
+		k8sClient:                  k8sClient,
+		namespace:                  namespace,
 		conf: wgConfig{
-			port:     config.ListeningPort,
-			iFaceMTU: config.MTU,
+			port:                 config.ListeningPort,
+			iFaceMTU:             config.MTU,
+			keysRotationInterval: config.KeysRotationInterval,
+			keysRotationOverlap:  config.KeysRotationOverlap,
+
+			natTraversalTimeout:       config.NATTraversalTimeout,
+			natTraversalRetryInterval: config.NATTraversalRetryInterval,
+			pmtuDiscoveryInterval:     config.PathMTUDiscoveryInterval,
 		},
 	}
 	err = w.setKeys(k8sClient, namespace)
 	if err != nil {
 		return nil, err
 	}
+	// the device is created in the current network namespace, which hence hosts the WireGuard socket.
+	if w.underlayNetns, err = ns.GetCurrentNS(); err != nil {
+		return nil, fmt.Errorf("failed to retrieve the current network namespace: %w", err)
+	}
 	if err = w.setWGLink(); err != nil {
 		return nil, fmt.Errorf("failed to setup %s link: %w", liqoconst.DriverName, err)
 	}
 	// create controller.
-	if w.client, err = wgctrl.New(); err != nil {
+	client, err := wgctrl.New()
+	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("wgctrl is not available on this system")
 		}
 		return nil, fmt.Errorf("failed to open wgctl client: %w", err)
 	}
+	w.client = client
 
 	defer func() {
 		if err != nil {
@@ -167,18 +242,49 @@ func (w *Wireguard) Init() error {
 // ConnectToEndpoint connects to a remote cluster described by the given tep.
 // updateStatusCallback is a function used by conncheck to update TunnelEndpoint connected status.
 func (w *Wireguard) ConnectToEndpoint(tep *netv1alpha1.TunnelEndpoint, updateStatus conncheck.UpdateFunc) (*netv1alpha1.Connection, error) {
+	// the remote cluster is reached through an intermediate one.
+	if tep.Spec.TransitClusterID != "" {
+		w.forgetNATTraversal(tep.Spec.ClusterIdentity.ClusterID)
+		return w.connectThroughTransit(tep, updateStatus)
+	}
+
+	// the remote cluster is reached by means of NAT traversal, through a rendezvous cluster.
+	if tep.Spec.RendezvousClusterID != "" {
+		return w.connectWithNATTraversal(tep, updateStatus)
+	}
+
+	w.forgetNATTraversal(tep.Spec.ClusterIdentity.ClusterID)
+	return w.connectDirectly(tep, updateStatus)
+}
+
+// connectDirectly connects to a remote cluster described by the given tep, configuring the corresponding WireGuard peer.
+func (w *Wireguard) connectDirectly(tep *netv1alpha1.TunnelEndpoint, updateStatus conncheck.UpdateFunc) (*netv1alpha1.Connection, error) {
 	// parse allowed IPs.
 	allowedIPs, stringAllowedIPs, err := getAllowedIPs(tep)
 	if err != nil {
 		return newConnectionOnError(err.Error()), err
 	}
 
+	// parse the allowed IPs required to act as intermediate hop for the remote cluster.
+	transitAllowedIPs, err := getTransitAllowedIPs(tep)
+	if err != nil {
+		return newConnectionOnError(err.Error()), err
+	}
+	w.setDirectAllowedIPs(tep.Spec.ClusterIdentity.ClusterID, allowedIPs)
+	transitChanged := w.setTransitAllowedIPs(tep.Spec.ClusterIdentity.ClusterID, transitAllowedIPs)
+
 	// parse remote public key.
 	remoteKey, err := getKey(tep)
 	if err != nil {
 		return newConnectionOnError(err.Error()), err
 	}
 
+	// parse the next public key announced by the remote cluster, if any.
+	nextKey, err := getNextKey(tep)
+	if err != nil {
+		return newConnectionOnError(err.Error()), err
+	}
+
 	// parse remote endpoint.
 	endpoint, err := getEndpoint(tep, func(address string) (*net.IPAddr, error) {
 		return resolver.Resolve(context.TODO(), address)
@@ -192,26 +298,40 @@ func (w *Wireguard) ConnectToEndpoint(tep *netv1alpha1.TunnelEndpoint, updateSta
 	oldCon, found := w.connections[tep.Spec.ClusterIdentity.ClusterID]
 	w.connectionsMutex.RUnlock()
 	if found {
+		// remove the peer configured with the next key previously announced, if no longer valid.
+		if err := w.unstagePeer(oldCon.PeerConfiguration[liqoconst.NextPublicKey], *remoteKey, nextKey); err != nil {
+			return newConnectionOnError(err.Error()), fmt.Errorf("failed to remove staged peer with cluster %s: %w", tep.Spec.ClusterIdentity, err)
+		}
+
 		// check if the peer configuration is updated.
 		if stringAllowedIPs == oldCon.PeerConfiguration[AllowedIPs] && remoteKey.String() == oldCon.PeerConfiguration[liqoconst.PublicKey] &&
 			endpoint.IP.String() == oldCon.PeerConfiguration[EndpointIP] && strconv.Itoa(endpoint.Port) == oldCon.PeerConfiguration[liqoconst.ListeningPort] {
+			// Update the transit allowed IPs, if necessary.
+			if err := w.refreshPeers(transitChanged); err != nil {
+				return newConnectionOnError(err.Error()), err
+			}
+			if keyString(nextKey) != oldCon.PeerConfiguration[liqoconst.NextPublicKey] {
+				return w.stageNextKey(tep, oldCon, nextKey, endpoint)
+			}
 			// Update connection status.
-			return &tep.Status.Connection, nil
+			if tep.Spec.RendezvousClusterID != "" {
+				return &tep.Status.Connection, nil
+			}
+			return w.withObservedEndpoint(&tep.Status.Connection, *remoteKey), nil
 		}
-		// If the configuration has changed then remove the peer.
 		klog.V(4).Infof("updating peer configuration for cluster %s", tep.Spec.ClusterIdentity)
-		err = w.client.ConfigureDevice(liqoconst.DeviceName, wgtypes.Config{
-			ReplacePeers: false,
-			Peers: []wgtypes.PeerConfig{{PublicKey: *remoteKey,
-				Remove: true,
-			}},
-		})
-
-		w.Connchecker.DelAndStopSender(tep.Spec.ClusterIdentity.ClusterID)
 
-		if err != nil {
-			return newConnectionOnError(err.Error()), fmt.Errorf("failed to configure peer with cluster %s: %w", tep.Spec.ClusterIdentity, err)
+		// If the remote cluster rotated its keys, then the old peer is preserved (although the allowed IPs are moved to the
+		// new one) until the connection is confirmed, so that the in-flight traffic is not disrupted.
+		if oldKey, err := wgtypes.ParseKey(oldCon.PeerConfiguration[liqoconst.PublicKey]); err == nil && oldKey != *remoteKey {
+			klog.Infof("Public key of cluster %s changed from %s to %s", tep.Spec.ClusterIdentity, oldKey, remoteKey)
+			w.connectionsMutex.Lock()
+			delete(w.connectedClusterIdentities, oldKey)
+			w.stalePeers[tep.Spec.ClusterIdentity.ClusterID] = oldKey
+			w.connectionsMutex.Unlock()
 		}
+
+		w.Connchecker.DelAndStopSender(tep.Spec.ClusterIdentity.ClusterID)
 	} else {
 		klog.V(4).Infof("Connecting cluster %s endpoint %s with publicKey %s",
 			tep.Spec.ClusterIdentity, endpoint.IP.String(), remoteKey)
@@ -231,7 +351,9 @@ func (w *Wireguard) ConnectToEndpoint(tep *netv1alpha1.TunnelEndpoint, updateSta
 		UpdateOnly:        false,
 		Endpoint:          endpoint,
 		ReplaceAllowedIPs: true,
-		AllowedIPs:        allowedIPs,
+		AllowedIPs:        w.peerAllowedIPs(tep.Spec.ClusterIdentity.ClusterID, allowedIPs),
+		// keep the NAT mappings open, in case the remote gateway is reached through hole punching.
+		PersistentKeepaliveInterval: persistentKeepalive(tep),
 	}}
 
 	err = w.client.ConfigureDevice(liqoconst.DeviceName, wgtypes.Config{
@@ -242,6 +364,13 @@ func (w *Wireguard) ConnectToEndpoint(tep *netv1alpha1.TunnelEndpoint, updateSta
 		return newConnectionOnError(err.Error()), fmt.Errorf("failed to configure peer with cluster %s: %w", tep.Spec.ClusterIdentity, err)
 	}
 
+	// pre-configure the peer with the next key announced by the remote cluster, if any.
+	if nextKey != nil && *nextKey != *remoteKey {
+		if err := w.stagePeer(*nextKey, endpoint); err != nil {
+			return newConnectionOnError(err.Error()), fmt.Errorf("failed to configure staged peer with cluster %s: %w", tep.Spec.ClusterIdentity, err)
+		}
+	}
+
 	c := &netv1alpha1.Connection{
 		Status:        netv1alpha1.Connecting,
 		StatusMessage: netv1alpha1.ConnectingMessage,
@@ -252,15 +381,24 @@ func (w *Wireguard) ConnectToEndpoint(tep *netv1alpha1.TunnelEndpoint, updateSta
 			Timestamp: metav1.Time{Time: time.Now()},
 		},
 	}
+	if nextKey != nil {
+		c.PeerConfiguration[liqoconst.NextPublicKey] = nextKey.String()
+	}
 	w.connectionsMutex.Lock()
 	w.connections[tep.Spec.ClusterIdentity.ClusterID] = c
+	w.connectedClusterIdentities[*remoteKey] = &tep.Spec.ClusterIdentity
 	w.connectionsMutex.Unlock()
 
-	w.connectedClusterIdentities[*remoteKey] = &tep.Spec.ClusterIdentity
+	// Update the allowed IPs of the other peers involved in the transit routes, if necessary.
+	if err := w.refreshPeers(transitChanged); err != nil {
+		return newConnectionOnError(err.Error()), err
+	}
 
 	klog.Infof("%s -> starting conncheck sender", tep.Spec.ClusterIdentity)
 
-	go w.Connchecker.AddAndRunSender(tep.Spec.ClusterIdentity.ClusterID, pingIP, updateStatus)
+	go w.Connchecker.AddAndRunSender(tep.Spec.ClusterIdentity.ClusterID, pingIP,
+		w.discoverPathMTUOnConnected(tep.Spec.ClusterIdentity.ClusterID, endpoint,
+			w.trackKeysRotation(tep.Spec.ClusterIdentity.ClusterID, updateStatus)))
 
 	klog.V(4).Infof("Done connecting cluster peer %s@%s", tep.Spec.ClusterIdentity, endpoint.String())
 	return c, nil
@@ -269,9 +407,22 @@ func (w *Wireguard) ConnectToEndpoint(tep *netv1alpha1.TunnelEndpoint, updateSta
 // DisconnectFromEndpoint disconnects a remote cluster described by the given tep.
 func (w *Wireguard) DisconnectFromEndpoint(tep *netv1alpha1.TunnelEndpoint) error {
 	klog.V(4).Infof("Removing connection with cluster %s", tep.Spec.ClusterIdentity)
+	w.forgetNATTraversal(tep.Spec.ClusterIdentity.ClusterID)
+	w.forgetPathMTU(tep.Spec.ClusterIdentity.ClusterID)
+
+	// Remove the allowed IPs configured for the transit routes involving the remote cluster, if any.
+	w.setDirectAllowedIPs(tep.Spec.ClusterIdentity.ClusterID, nil)
+	if err := w.enforceTransitAllowedIPs(tep.Spec.ClusterIdentity.ClusterID, nil); err != nil {
+		return err
+	}
 
 	s, found := tep.Status.Connection.PeerConfiguration[liqoconst.PublicKey]
 	if !found {
+		w.connectionsMutex.Lock()
+		delete(w.connections, tep.Spec.ClusterIdentity.ClusterID)
+		w.connectionsMutex.Unlock()
+		w.Connchecker.DelAndStopSender(tep.Spec.ClusterIdentity.ClusterID)
+
 		klog.V(4).Infof("no tunnel configured for cluster %s, nothing to be removed", tep.Spec.ClusterIdentity)
 		return nil
 	}
@@ -295,6 +446,13 @@ func (w *Wireguard) DisconnectFromEndpoint(tep *netv1alpha1.TunnelEndpoint) erro
 		return fmt.Errorf("failed to remove WireGuard peer with cluster %s: %w", tep.Spec.ClusterIdentity, err)
 	}
 
+	// Remove the peers configured with the next and the previous keys of the remote cluster, if any.
+	if err := w.unstagePeer(tep.Status.Connection.PeerConfiguration[liqoconst.NextPublicKey], key, nil); err != nil {
+		return fmt.Errorf("failed to remove staged WireGuard peer with cluster %s: %w", tep.Spec.ClusterIdentity, err)
+	}
+	w.dropStalePeer(tep.Spec.ClusterIdentity.ClusterID)
+
+	w.connectionsMutex.Lock()
 	remoteKey, err := getKey(tep)
 	if err == nil {
 		delete(w.connectedClusterIdentities, *remoteKey)
@@ -302,9 +460,8 @@ func (w *Wireguard) DisconnectFromEndpoint(tep *netv1alpha1.TunnelEndpoint) erro
 	} else {
 		klog.Errorf("failed to get public key for cluster %s: %v", tep.Spec.ClusterIdentity, err)
 	}
-
-	w.connectionsMutex.Lock()
 	delete(w.connections, tep.Spec.ClusterIdentity.ClusterID)
+	delete(w.lastConnected, tep.Spec.ClusterIdentity.ClusterID)
 	w.connectionsMutex.Unlock()
 
 	w.Connchecker.DelAndStopSender(tep.Spec.ClusterIdentity.ClusterID)
@@ -480,6 +637,7 @@ func (w *Wireguard) setKeys(c k8s.Interface, namespace string) error {
 			},
 			StringData: map[string]string{liqoconst.PublicKey: pub.String(), PrivateKey: priv.String()},
 		}
+		setTimestampAnnotation(&pKey, liqoconst.KeysGenerationTimestampAnnotation, time.Now())
 		_, err = c.CoreV1().Secrets(namespace).Create(context.Background(), &pKey, metav1.CreateOptions{})
 		if err != nil {
 			return fmt.Errorf("failed to create the secret with name %s: %w", keysName, err)
@@ -530,14 +688,16 @@ func (w *Wireguard) Collect(ch chan<- prometheus.Metric) {
 	}
 
 	for i := range device.Peers {
-		publicKey := device.Peers[i].PublicKey
-		labels := []string{
-			DriverName, device.Name,
-			w.connectedClusterIdentities[publicKey].ClusterID,
-			w.connectedClusterIdentities[publicKey].ClusterName,
+		w.connectionsMutex.RLock()
+		identity, found := w.connectedClusterIdentities[device.Peers[i].PublicKey]
+		w.connectionsMutex.RUnlock()
+		if !found {
+			// Skip the peers not associated with an established connection (e.g., the ones configured during keys rotation).
+			continue
 		}
+		labels := []string{DriverName, device.Name, identity.ClusterID, identity.ClusterName}
 
-		connected, err := w.Connchecker.GetConnected(w.connectedClusterIdentities[publicKey].ClusterID)
+		connected, err := w.Connchecker.GetConnected(identity.ClusterID)
 		if err != nil {
 			ch <- prometheus.NewInvalidMetric(metrics.PeerIsConnected, err)
 		} else {
@@ -568,7 +728,7 @@ func (w *Wireguard) Collect(ch chan<- prometheus.Metric) {
 				labels...,
 			)
 
-			latency, err := w.Connchecker.GetLatency(w.connectedClusterIdentities[publicKey].ClusterID)
+			latency, err := w.Connchecker.GetLatency(identity.ClusterID)
 			if err != nil {
 				ch <- prometheus.NewInvalidMetric(metrics.PeerLatency, err)
 			}
@@ -579,5 +739,17 @@ func (w *Wireguard) Collect(ch chan<- prometheus.Metric) {
 				labels...,
 			)
 		}
+
+		stats, err := w.Connchecker.GetStatistics(identity.ClusterID)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(metrics.PeerRTT, err)
+			ch <- prometheus.NewInvalidMetric(metrics.PeerJitter, err)
+			ch <- prometheus.NewInvalidMetric(metrics.PeerPacketLoss, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstHistogram(metrics.PeerRTT, stats.RTT.Count, stats.RTT.Sum, stats.RTT.Buckets, labels...)
+		ch <- prometheus.MustNewConstHistogram(metrics.PeerJitter, stats.Jitter.Count, stats.Jitter.Sum, stats.Jitter.Buckets, labels...)
+		ch <- prometheus.MustNewConstHistogram(metrics.PeerPacketLoss,
+			stats.PacketLoss.Count, stats.PacketLoss.Sum, stats.PacketLoss.Buckets, labels...)
 	}
 }
@@ -0,0 +1,275 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"k8s.io/klog/v2"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqonet/conncheck"
+)
+
+// natTraversalMode is the mode used to reach a remote cluster by means of NAT traversal.
+type natTraversalMode string
+
+const (
+	// natTraversalDirect is the mode in which the tunnel is established directly with the remote gateway,
+	// towards the public endpoint observed by the rendezvous cluster (i.e., UDP hole punching).
+	natTraversalDirect natTraversalMode = "Direct"
+	// natTraversalRelayed is the mode in which the traffic is relayed through the rendezvous cluster.
+	natTraversalRelayed natTraversalMode = "Relayed"
+
+	// natTraversalKeepalive is the persistent keepalive interval configured for the peers reached through hole punching,
+	// which both triggers the simultaneous handshakes from the two sides and keeps the NAT mappings open.
+	natTraversalKeepalive = 10 * time.Second
+)
+
+// natTraversalState tracks the NAT traversal process towards a remote cluster.
+type natTraversalState struct {
+	mode natTraversalMode
+	// endpoint is the public endpoint of the remote gateway, as observed by the rendezvous cluster.
+	endpoint string
+	// since is the time of the last mode change, or of the last loss of connectivity.
+	since time.Time
+	// attempts is the number of hole punching attempts performed towards the current endpoint.
+	attempts int
+	// connected is whether the current hole punching attempt succeeded.
+	connected bool
+	// generation identifies the current hole punching attempt, to discard the outdated connectivity updates.
+	generation uint64
+}
+
+// newNATTraversalState returns a new natTraversalState, starting in relayed mode.
+func newNATTraversalState(now time.Time) *natTraversalState {
+	return &natTraversalState{mode: natTraversalRelayed, since: now}
+}
+
+// restoreNATTraversalState returns the NAT traversal state surfaced in the status of the given tep (e.g., by the previously
+// active gateway replica, in case of failover), or a new one if not available. The restored attempt is considered as just
+// started, hence the hole punching towards the same endpoint is not restarted, while the timeouts are fully granted again.
+func restoreNATTraversalState(tep *netv1alpha1.TunnelEndpoint, now time.Time) *natTraversalState {
+	status := tep.Status.Connection.PeerConfiguration
+	if status[RendezvousClusterID] != tep.Spec.RendezvousClusterID || status[liqoconst.ObservedEndpoint] == "" {
+		return newNATTraversalState(now)
+	}
+
+	attempts, err := strconv.Atoi(status[HolePunchingAttempts])
+	if err != nil {
+		attempts = 0
+	}
+	state := &natTraversalState{endpoint: status[liqoconst.ObservedEndpoint], since: now, attempts: attempts}
+	switch natTraversalMode(status[NATTraversalMode]) {
+	case natTraversalDirect:
+		state.mode = natTraversalDirect
+		state.generation = 1
+	case natTraversalRelayed:
+		state.mode = natTraversalRelayed
+	default:
+		return newNATTraversalState(now)
+	}
+	return state
+}
+
+// update updates the NAT traversal state, given the currently observed endpoint of the remote gateway, and returns
+// whether a new hole punching attempt is started or the relayed mode is entered. A new attempt is started when the
+// endpoint changes, or after the retry interval while relayed, while the connection falls back to the relayed mode
+// when no endpoint is known, or if the remote gateway is not reachable directly within the given timeout.
+func (s *natTraversalState) update(endpoint string, now time.Time, timeout, retryInterval time.Duration) bool {
+	switch {
+	case endpoint != s.endpoint:
+		s.endpoint = endpoint
+		s.attempts = 0
+		if endpoint == "" {
+			return s.setMode(natTraversalRelayed, now)
+		}
+		s.setMode(natTraversalDirect, now)
+		return true
+	case endpoint == "":
+		return false
+	case s.mode == natTraversalDirect && !s.connected && now.Sub(s.since) >= timeout:
+		return s.setMode(natTraversalRelayed, now)
+	case s.mode == natTraversalRelayed && now.Sub(s.since) >= retryInterval:
+		return s.setMode(natTraversalDirect, now)
+	default:
+		return false
+	}
+}
+
+// setMode sets the NAT traversal mode, and returns whether it changed.
+func (s *natTraversalState) setMode(mode natTraversalMode, now time.Time) bool {
+	changed := s.mode != mode
+	s.mode = mode
+	s.since = now
+	if mode == natTraversalDirect {
+		s.attempts++
+		s.connected = false
+		s.generation++
+	}
+	return changed
+}
+
+// connectWithNATTraversal connects to a remote cluster behind NAT, described by the given tep. The tunnel is established
+// directly with the public endpoint of the remote gateway advertised by the rendezvous cluster (both gateways simultaneously
+// send their handshakes, hence opening the respective NAT mappings), while the traffic is relayed through the rendezvous
+// cluster in case the hole punching fails.
+func (w *Wireguard) connectWithNATTraversal(tep *netv1alpha1.TunnelEndpoint, updateStatus conncheck.UpdateFunc) (*netv1alpha1.Connection, error) {
+	clusterID := tep.Spec.ClusterIdentity.ClusterID
+
+	endpoint := tep.Spec.ObservedEndpoint
+	if _, _, err := net.SplitHostPort(endpoint); endpoint != "" && err != nil {
+		klog.Warningf("%s -> ignoring invalid observed endpoint %q: %v", tep.Spec.ClusterIdentity, endpoint, err)
+		endpoint = ""
+	}
+
+	w.natTraversalMutex.Lock()
+	state, found := w.natTraversal[clusterID]
+	if !found {
+		// The state is restored from the tep status, to preserve the progress of the previously active replica (if any).
+		state = restoreNATTraversalState(tep, time.Now())
+		w.natTraversal[clusterID] = state
+	}
+	if state.update(endpoint, time.Now(), w.conf.natTraversalTimeout, w.conf.natTraversalRetryInterval) {
+		klog.Infof("%s -> NAT traversal mode set to %s (observed endpoint %q, attempt %d)",
+			tep.Spec.ClusterIdentity, state.mode, state.endpoint, state.attempts)
+	}
+	current := *state
+	w.natTraversalMutex.Unlock()
+
+	var con *netv1alpha1.Connection
+	var err error
+	if current.mode == natTraversalDirect {
+		con, err = w.connectDirectly(forgeHolePunchingTunnelEndpoint(tep, current.endpoint),
+			w.trackNATTraversal(clusterID, current.generation, updateStatus))
+	} else {
+		con, err = w.connectThroughTransit(forgeRelayedTunnelEndpoint(tep), updateStatus)
+	}
+	if err != nil {
+		return con, err
+	}
+
+	return withNATTraversalStatus(con, tep.Spec.RendezvousClusterID, &current), nil
+}
+
+// trackNATTraversal wraps the given update function, to track whether the current hole punching attempt succeeded.
+func (w *Wireguard) trackNATTraversal(clusterID string, generation uint64, updateStatus conncheck.UpdateFunc) conncheck.UpdateFunc {
+	return func(connected bool, quality conncheck.Quality, timestamp time.Time) error {
+		w.natTraversalMutex.Lock()
+		if state, found := w.natTraversal[clusterID]; found && state.generation == generation && state.connected != connected {
+			state.connected = connected
+			if !connected {
+				// The connectivity has been lost, hence start counting the timeout to fall back to the relayed mode.
+				state.since = time.Now()
+			}
+		}
+		w.natTraversalMutex.Unlock()
+		return updateStatus(connected, quality, timestamp)
+	}
+}
+
+// forgetNATTraversal removes the NAT traversal state associated with the given cluster, if any.
+func (w *Wireguard) forgetNATTraversal(clusterID string) {
+	w.natTraversalMutex.Lock()
+	delete(w.natTraversal, clusterID)
+	w.natTraversalMutex.Unlock()
+}
+
+// forgeHolePunchingTunnelEndpoint returns a copy of the given tep, targeting the given public endpoint of the remote gateway.
+func forgeHolePunchingTunnelEndpoint(tep *netv1alpha1.TunnelEndpoint, endpoint string) *netv1alpha1.TunnelEndpoint {
+	// The endpoint has already been validated.
+	host, port, _ := net.SplitHostPort(endpoint)
+
+	punching := tep.DeepCopy()
+	punching.Spec.EndpointIP = host
+	if punching.Spec.BackendConfig == nil {
+		punching.Spec.BackendConfig = make(map[string]string)
+	}
+	punching.Spec.BackendConfig[liqoconst.ListeningPort] = port
+	return punching
+}
+
+// forgeRelayedTunnelEndpoint returns a copy of the given tep, reaching the remote cluster through the rendezvous one.
+func forgeRelayedTunnelEndpoint(tep *netv1alpha1.TunnelEndpoint) *netv1alpha1.TunnelEndpoint {
+	relayed := tep.DeepCopy()
+	relayed.Spec.TransitClusterID = tep.Spec.RendezvousClusterID
+	return relayed
+}
+
+// withNATTraversalStatus returns the given connection, updated with the information concerning the NAT traversal process.
+func withNATTraversalStatus(con *netv1alpha1.Connection, rendezvousClusterID string, state *natTraversalState) *netv1alpha1.Connection {
+	status := map[string]string{
+		RendezvousClusterID:        rendezvousClusterID,
+		NATTraversalMode:           string(state.mode),
+		HolePunchingAttempts:       strconv.Itoa(state.attempts),
+		liqoconst.ObservedEndpoint: state.endpoint,
+	}
+
+	updated := con
+	for key, value := range status {
+		if con.PeerConfiguration[key] == value {
+			continue
+		}
+		if updated == con {
+			updated = con.DeepCopy()
+			if updated.PeerConfiguration == nil {
+				updated.PeerConfiguration = make(map[string]string)
+			}
+		}
+		updated.PeerConfiguration[key] = value
+	}
+	return updated
+}
+
+// persistentKeepalive returns the persistent keepalive interval to be configured for the peer described by the given tep.
+func persistentKeepalive(tep *netv1alpha1.TunnelEndpoint) *time.Duration {
+	if tep.Spec.RendezvousClusterID == "" {
+		return nil
+	}
+	keepalive := natTraversalKeepalive
+	return &keepalive
+}
+
+// withObservedEndpoint returns the given connection, updated with the public endpoint of the remote gateway as observed by the
+// local device (i.e., after the NAT translation, if any), which is advertised in case the local cluster acts as rendezvous.
+func (w *Wireguard) withObservedEndpoint(con *netv1alpha1.Connection, key wgtypes.Key) *netv1alpha1.Connection {
+	device, err := w.client.Device(liqoconst.DeviceName)
+	if err != nil {
+		klog.Warningf("failed to retrieve the configuration of the WireGuard device: %v", err)
+		return con
+	}
+
+	for i := range device.Peers {
+		peer := &device.Peers[i]
+		if peer.PublicKey != key || peer.Endpoint == nil || peer.LastHandshakeTime.IsZero() {
+			continue
+		}
+
+		if endpoint := peer.Endpoint.String(); endpoint != con.PeerConfiguration[liqoconst.ObservedEndpoint] {
+			updated := con.DeepCopy()
+			if updated.PeerConfiguration == nil {
+				updated.PeerConfiguration = make(map[string]string)
+			}
+			updated.PeerConfiguration[liqoconst.ObservedEndpoint] = endpoint
+			return updated
+		}
+		break
+	}
+	return con
+}
@@ -0,0 +1,220 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	discv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqonet/conncheck"
+)
+
+var _ = Describe("NAT traversal", func() {
+	const (
+		endpoint      = "1.2.3.4:51820"
+		timeout       = time.Minute
+		retryInterval = 10 * time.Minute
+	)
+
+	Describe("testing the NAT traversal state machine", func() {
+		var (
+			state *natTraversalState
+			now   time.Time
+		)
+
+		BeforeEach(func() {
+			now = time.Now()
+			state = newNATTraversalState(now)
+		})
+
+		It("should remain relayed until an endpoint is observed", func() {
+			Expect(state.update("", now.Add(retryInterval), timeout, retryInterval)).To(BeFalse())
+			Expect(state.mode).To(Equal(natTraversalRelayed))
+		})
+
+		It("should attempt the hole punching as soon as an endpoint is observed", func() {
+			Expect(state.update(endpoint, now, timeout, retryInterval)).To(BeTrue())
+			Expect(state.mode).To(Equal(natTraversalDirect))
+			Expect(state.attempts).To(Equal(1))
+		})
+
+		It("should remain direct once connected", func() {
+			state.update(endpoint, now, timeout, retryInterval)
+			state.connected = true
+			Expect(state.update(endpoint, now.Add(2*timeout), timeout, retryInterval)).To(BeFalse())
+			Expect(state.mode).To(Equal(natTraversalDirect))
+		})
+
+		It("should fall back to the relayed mode after the timeout, and retry after the retry interval", func() {
+			state.update(endpoint, now, timeout, retryInterval)
+			Expect(state.update(endpoint, now.Add(timeout/2), timeout, retryInterval)).To(BeFalse())
+			Expect(state.update(endpoint, now.Add(timeout), timeout, retryInterval)).To(BeTrue())
+			Expect(state.mode).To(Equal(natTraversalRelayed))
+
+			Expect(state.update(endpoint, now.Add(timeout+retryInterval/2), timeout, retryInterval)).To(BeFalse())
+			Expect(state.update(endpoint, now.Add(timeout+retryInterval), timeout, retryInterval)).To(BeTrue())
+			Expect(state.mode).To(Equal(natTraversalDirect))
+			Expect(state.attempts).To(Equal(2))
+		})
+
+		It("should restart the hole punching if the endpoint changes", func() {
+			state.update(endpoint, now, timeout, retryInterval)
+			generation := state.generation
+			Expect(state.update("5.6.7.8:51820", now.Add(time.Second), timeout, retryInterval)).To(BeTrue())
+			Expect(state.mode).To(Equal(natTraversalDirect))
+			Expect(state.attempts).To(Equal(1))
+			Expect(state.generation).ToNot(Equal(generation))
+		})
+
+		It("should fall back to the relayed mode if the endpoint is no longer observed", func() {
+			state.update(endpoint, now, timeout, retryInterval)
+			Expect(state.update("", now.Add(time.Second), timeout, retryInterval)).To(BeTrue())
+			Expect(state.mode).To(Equal(natTraversalRelayed))
+		})
+	})
+
+	Describe("testing the restoration of the state from the tep status", func() {
+		var (
+			tep *netv1alpha1.TunnelEndpoint
+			now time.Time
+		)
+
+		BeforeEach(func() {
+			now = time.Now()
+			tep = &netv1alpha1.TunnelEndpoint{
+				Spec: netv1alpha1.TunnelEndpointSpec{RendezvousClusterID: "rendezvous", ObservedEndpoint: endpoint},
+				Status: netv1alpha1.TunnelEndpointStatus{Connection: netv1alpha1.Connection{PeerConfiguration: map[string]string{
+					RendezvousClusterID:        "rendezvous",
+					NATTraversalMode:           string(natTraversalDirect),
+					HolePunchingAttempts:       "2",
+					liqoconst.ObservedEndpoint: endpoint,
+				}}},
+			}
+		})
+
+		It("should continue the hole punching attempt of the previously active replica", func() {
+			state := restoreNATTraversalState(tep, now)
+			Expect(state.mode).To(Equal(natTraversalDirect))
+			Expect(state.endpoint).To(Equal(endpoint))
+			Expect(state.attempts).To(Equal(2))
+			Expect(state.connected).To(BeFalse())
+
+			// The same endpoint does not trigger a new attempt, while the timeout is counted from the takeover.
+			Expect(state.update(endpoint, now.Add(timeout/2), timeout, retryInterval)).To(BeFalse())
+			Expect(state.attempts).To(Equal(2))
+			Expect(state.update(endpoint, now.Add(timeout), timeout, retryInterval)).To(BeTrue())
+			Expect(state.mode).To(Equal(natTraversalRelayed))
+		})
+
+		It("should remain relayed until the retry interval expires", func() {
+			tep.Status.Connection.PeerConfiguration[NATTraversalMode] = string(natTraversalRelayed)
+			state := restoreNATTraversalState(tep, now)
+			Expect(state.mode).To(Equal(natTraversalRelayed))
+			Expect(state.update(endpoint, now.Add(timeout), timeout, retryInterval)).To(BeFalse())
+			Expect(state.update(endpoint, now.Add(retryInterval), timeout, retryInterval)).To(BeTrue())
+			Expect(state.attempts).To(Equal(3))
+		})
+
+		It("should start from scratch if the status refers to a different rendezvous cluster", func() {
+			tep.Spec.RendezvousClusterID = "other"
+			Expect(restoreNATTraversalState(tep, now)).To(Equal(newNATTraversalState(now)))
+		})
+
+		It("should start from scratch if the status does not contain the NAT traversal information", func() {
+			tep.Status.Connection.PeerConfiguration = nil
+			Expect(restoreNATTraversalState(tep, now)).To(Equal(newNATTraversalState(now)))
+		})
+	})
+
+	Describe("testing the connectivity tracking", func() {
+		var (
+			w       *Wireguard
+			updated bool
+		)
+
+		BeforeEach(func() {
+			updated = false
+			w = &Wireguard{natTraversal: map[string]*natTraversalState{"remote": {mode: natTraversalDirect, generation: 2}}}
+		})
+
+		update := func(_ bool, _ conncheck.Quality, _ time.Time) error {
+			updated = true
+			return nil
+		}
+
+		It("should track the connectivity of the current attempt", func() {
+			Expect(w.trackNATTraversal("remote", 2, update)(true, conncheck.Quality{}, time.Now())).To(Succeed())
+			Expect(w.natTraversal["remote"].connected).To(BeTrue())
+			Expect(updated).To(BeTrue())
+		})
+
+		It("should ignore the connectivity of outdated attempts", func() {
+			Expect(w.trackNATTraversal("remote", 1, update)(true, conncheck.Quality{}, time.Now())).To(Succeed())
+			Expect(w.natTraversal["remote"].connected).To(BeFalse())
+			Expect(updated).To(BeTrue())
+		})
+	})
+
+	Describe("testing the forging of the tunnel endpoints", func() {
+		var tep *netv1alpha1.TunnelEndpoint
+
+		BeforeEach(func() {
+			tep = &netv1alpha1.TunnelEndpoint{Spec: netv1alpha1.TunnelEndpointSpec{
+				ClusterIdentity:     discv1alpha1.ClusterIdentity{ClusterID: "remote"},
+				EndpointIP:          "10.0.0.1",
+				BackendConfig:       map[string]string{liqoconst.ListeningPort: "5871"},
+				RendezvousClusterID: "rendezvous",
+				ObservedEndpoint:    endpoint,
+			}}
+		})
+
+		It("should target the observed endpoint, without modifying the original tep", func() {
+			punching := forgeHolePunchingTunnelEndpoint(tep, endpoint)
+			Expect(punching.Spec.EndpointIP).To(Equal("1.2.3.4"))
+			Expect(punching.Spec.BackendConfig).To(HaveKeyWithValue(liqoconst.ListeningPort, "51820"))
+			Expect(tep.Spec.BackendConfig).To(HaveKeyWithValue(liqoconst.ListeningPort, "5871"))
+			Expect(*persistentKeepalive(punching)).To(Equal(natTraversalKeepalive))
+		})
+
+		It("should route the traffic through the rendezvous cluster", func() {
+			Expect(forgeRelayedTunnelEndpoint(tep).Spec.TransitClusterID).To(Equal("rendezvous"))
+			Expect(tep.Spec.TransitClusterID).To(BeEmpty())
+		})
+	})
+
+	Describe("testing withNATTraversalStatus", func() {
+		state := &natTraversalState{mode: natTraversalDirect, endpoint: endpoint, attempts: 3}
+
+		It("should surface the NAT traversal information", func() {
+			con := &netv1alpha1.Connection{PeerConfiguration: map[string]string{liqoconst.PublicKey: "key"}}
+			updated := withNATTraversalStatus(con, "rendezvous", state)
+			Expect(updated.PeerConfiguration).To(HaveKeyWithValue(RendezvousClusterID, "rendezvous"))
+			Expect(updated.PeerConfiguration).To(HaveKeyWithValue(NATTraversalMode, "Direct"))
+			Expect(updated.PeerConfiguration).To(HaveKeyWithValue(HolePunchingAttempts, "3"))
+			Expect(updated.PeerConfiguration).To(HaveKeyWithValue(liqoconst.ObservedEndpoint, endpoint))
+			Expect(con.PeerConfiguration).ToNot(HaveKey(NATTraversalMode))
+		})
+
+		It("should return the same connection if already up-to-date", func() {
+			con := withNATTraversalStatus(&netv1alpha1.Connection{}, "rendezvous", state)
+			Expect(withNATTraversalStatus(con, "rendezvous", state)).To(BeIdenticalTo(con))
+		})
+	})
+})
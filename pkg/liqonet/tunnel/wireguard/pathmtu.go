@@ -0,0 +1,170 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"k8s.io/klog/v2"
+
+	"github.com/liqotech/liqo/pkg/liqonet/conncheck"
+)
+
+const (
+	// minPathMTU is the lower bound of the path MTU discovery (i.e., the minimum MTU all IPv4 hosts must support).
+	minPathMTU = 576
+	// pathMTUDiscoveryTimeout is the maximum duration of a path MTU discovery.
+	pathMTUDiscoveryTimeout = time.Minute
+	// wireguardOverhead is the overhead introduced by the WireGuard encapsulation over IPv4
+	// (i.e., 20 bytes IPv4 header, 8 bytes UDP header, 32 bytes WireGuard header).
+	wireguardOverhead = 60
+)
+
+// pathMTUState tracks the path MTU discovered towards a remote cluster.
+type pathMTUState struct {
+	// mtu is the last discovered path MTU (zero if not yet discovered).
+	mtu int
+	// timestamp is the time of the last discovery attempt.
+	timestamp time.Time
+	// probing is whether a discovery is currently in progress.
+	probing bool
+	// connected and quality are the last outcome of the connection check, to notify the path MTU changes.
+	connected bool
+	quality   conncheck.Quality
+}
+
+// shouldProbe returns whether a new discovery shall be started, given the configured discovery interval.
+func (s *pathMTUState) shouldProbe(now time.Time, interval time.Duration) bool {
+	return !s.probing && (s.timestamp.IsZero() || now.Sub(s.timestamp) >= interval)
+}
+
+// PathMTU returns the path MTU discovered towards the given remote cluster, or zero if not (yet) available.
+// The remote clusters reached through an intermediate one inherit the path MTU towards the latter.
+func (w *Wireguard) PathMTU(clusterID string) int {
+	w.connectionsMutex.RLock()
+	if con, found := w.connections[clusterID]; found && con.PeerConfiguration[TransitClusterID] != "" {
+		clusterID = con.PeerConfiguration[TransitClusterID]
+	}
+	w.connectionsMutex.RUnlock()
+
+	w.pathMTUMutex.Lock()
+	defer w.pathMTUMutex.Unlock()
+
+	if state, found := w.pathMTUs[clusterID]; found {
+		return state.mtu
+	}
+	return 0
+}
+
+// discoverPathMTUOnConnected wraps the given update function, to periodically discover the path MTU towards the given
+// remote cluster (reachable at the given endpoint) while the connection check reports it as connected. Any previous
+// discovery is invalidated, since the path changes whenever the tunnel is reconfigured, although the last known MTU is
+// kept until a new one is discovered. The update function is additionally invoked whenever the path MTU changes.
+func (w *Wireguard) discoverPathMTUOnConnected(clusterID string, endpoint *net.UDPAddr, updateStatus conncheck.UpdateFunc) conncheck.UpdateFunc {
+	if w.conf.pmtuDiscoveryInterval == 0 {
+		return updateStatus
+	}
+
+	w.pathMTUMutex.Lock()
+	state := &pathMTUState{}
+	if previous, found := w.pathMTUs[clusterID]; found {
+		state.mtu = previous.mtu
+	}
+	w.pathMTUs[clusterID] = state
+	w.pathMTUMutex.Unlock()
+
+	return func(connected bool, quality conncheck.Quality, timestamp time.Time) error {
+		w.pathMTUMutex.Lock()
+		state.connected, state.quality = connected, quality
+		if connected && w.pathMTUs[clusterID] == state && state.shouldProbe(time.Now(), w.conf.pmtuDiscoveryInterval) {
+			state.probing = true
+			go w.discoverPathMTU(clusterID, endpoint, state, updateStatus)
+		}
+		w.pathMTUMutex.Unlock()
+		return updateStatus(connected, quality, timestamp)
+	}
+}
+
+// discoverPathMTU discovers the path MTU towards the given remote cluster, storing the result in the given state.
+// The given update function is invoked in case the path MTU changed, to propagate it to the tunnel endpoint.
+func (w *Wireguard) discoverPathMTU(clusterID string, endpoint *net.UDPAddr, state *pathMTUState, updateStatus conncheck.UpdateFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), pathMTUDiscoveryTimeout)
+	defer cancel()
+
+	klog.V(4).Infof("Starting path MTU discovery towards cluster %s (endpoint %s)", clusterID, endpoint)
+	mtu, err := w.probeUnderlayPathMTU(ctx, endpoint)
+
+	w.pathMTUMutex.Lock()
+	state.probing = false
+	state.timestamp = time.Now()
+	if err != nil {
+		w.pathMTUMutex.Unlock()
+		klog.Warningf("Path MTU discovery towards cluster %s failed: %v", clusterID, err)
+		return
+	}
+	changed := state.mtu != mtu
+	state.mtu = mtu
+	notify := changed && state.connected && w.pathMTUs[clusterID] == state
+	quality := state.quality
+	w.pathMTUMutex.Unlock()
+
+	if changed {
+		klog.Infof("Path MTU towards cluster %s changed to %d", clusterID, mtu)
+	}
+	if notify {
+		if err := updateStatus(true, quality, time.Now()); err != nil {
+			klog.Errorf("Failed to propagate the path MTU towards cluster %s: %v", clusterID, err)
+		}
+	}
+}
+
+// probeUnderlayPathMTU probes the path MTU on the underlay network towards the given endpoint, and returns the
+// corresponding MTU inside the tunnel (i.e., net of the encapsulation overhead).
+func (w *Wireguard) probeUnderlayPathMTU(ctx context.Context, endpoint *net.UDPAddr) (int, error) {
+	var mtu int
+	probe := func(_ ns.NetNS) error {
+		conn, err := net.DialUDP("udp4", nil, endpoint)
+		if err != nil {
+			return fmt.Errorf("failed to create the probing socket towards %s: %w", endpoint, err)
+		}
+		defer conn.Close()
+
+		mtu, err = conncheck.ProbePathMTU(ctx, conn, minPathMTU+wireguardOverhead, w.conf.iFaceMTU+wireguardOverhead)
+		return err
+	}
+
+	// The probes are sent from the same network namespace hosting the WireGuard socket, hence following the same path.
+	var err error
+	if w.underlayNetns != nil {
+		err = w.underlayNetns.Do(probe)
+	} else {
+		err = probe(nil)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return mtu - wireguardOverhead, nil
+}
+
+// forgetPathMTU removes the path MTU state associated with the given cluster, if any.
+func (w *Wireguard) forgetPathMTU(clusterID string) {
+	w.pathMTUMutex.Lock()
+	delete(w.pathMTUs, clusterID)
+	w.pathMTUMutex.Unlock()
+}
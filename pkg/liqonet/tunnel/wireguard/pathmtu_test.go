@@ -0,0 +1,260 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"context"
+	"net"
+	"os"
+	"time"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/liqonet/conncheck"
+)
+
+var _ = Describe("Path MTU discovery", func() {
+	const (
+		clusterID = "foo"
+		interval  = 10 * time.Minute
+	)
+
+	endpoint := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5871}
+
+	Describe("testing the shouldProbe function", func() {
+		var (
+			state *pathMTUState
+			now   time.Time
+		)
+
+		BeforeEach(func() {
+			now = time.Now()
+			state = &pathMTUState{}
+		})
+
+		It("should probe if never discovered", func() {
+			Expect(state.shouldProbe(now, interval)).To(BeTrue())
+		})
+
+		It("should not probe if a discovery is in progress", func() {
+			state.probing = true
+			Expect(state.shouldProbe(now, interval)).To(BeFalse())
+		})
+
+		It("should probe again only after the interval", func() {
+			state.timestamp = now
+			Expect(state.shouldProbe(now.Add(interval/2), interval)).To(BeFalse())
+			Expect(state.shouldProbe(now.Add(interval), interval)).To(BeTrue())
+		})
+	})
+
+	Describe("testing the discoverPathMTUOnConnected function", func() {
+		var (
+			w       *Wireguard
+			updates int
+			update  conncheck.UpdateFunc
+		)
+
+		BeforeEach(func() {
+			updates = 0
+			update = func(_ bool, _ conncheck.Quality, _ time.Time) error { updates++; return nil }
+			w = &Wireguard{pathMTUs: make(map[string]*pathMTUState), conf: wgConfig{pmtuDiscoveryInterval: interval}}
+		})
+
+		It("should return the update function unchanged if the discovery is disabled", func() {
+			w.conf.pmtuDiscoveryInterval = 0
+			Expect(w.discoverPathMTUOnConnected(clusterID, endpoint, update)(true, conncheck.Quality{}, time.Now())).To(Succeed())
+			Expect(updates).To(Equal(1))
+			Expect(w.pathMTUs).To(BeEmpty())
+		})
+
+		It("should preserve the last known path MTU", func() {
+			w.pathMTUs[clusterID] = &pathMTUState{mtu: 1400, timestamp: time.Now()}
+			w.discoverPathMTUOnConnected(clusterID, endpoint, update)
+			Expect(w.PathMTU(clusterID)).To(Equal(1400))
+			Expect(w.pathMTUs[clusterID].timestamp).To(BeZero())
+		})
+
+		It("should not start a discovery while disconnected", func() {
+			wrapped := w.discoverPathMTUOnConnected(clusterID, endpoint, update)
+			Expect(wrapped(false, conncheck.Quality{}, time.Time{})).To(Succeed())
+			Expect(updates).To(Equal(1))
+			Expect(w.pathMTUs[clusterID].probing).To(BeFalse())
+		})
+
+		It("should not start a discovery if one is already in progress", func() {
+			wrapped := w.discoverPathMTUOnConnected(clusterID, endpoint, update)
+			w.pathMTUs[clusterID].probing = true
+			Expect(wrapped(true, conncheck.Quality{}, time.Now())).To(Succeed())
+			Expect(updates).To(Equal(1))
+		})
+	})
+
+	Describe("testing the PathMTU function", func() {
+		var w *Wireguard
+
+		BeforeEach(func() {
+			w = &Wireguard{pathMTUs: make(map[string]*pathMTUState), connections: make(map[string]*netv1alpha1.Connection)}
+		})
+
+		It("should return zero if not discovered", func() {
+			Expect(w.PathMTU(clusterID)).To(BeZero())
+			w.pathMTUs[clusterID] = &pathMTUState{mtu: 1300}
+			Expect(w.PathMTU(clusterID)).To(Equal(1300))
+			w.forgetPathMTU(clusterID)
+			Expect(w.PathMTU(clusterID)).To(BeZero())
+		})
+
+		It("should return the path MTU towards the intermediate cluster, if reached through transit", func() {
+			w.pathMTUs["bar"] = &pathMTUState{mtu: 1300}
+			w.connections[clusterID] = &netv1alpha1.Connection{PeerConfiguration: map[string]string{TransitClusterID: "bar"}}
+			Expect(w.PathMTU(clusterID)).To(Equal(1300))
+		})
+	})
+
+	Describe("testing the path MTU discovery on the underlay network", Ordered, func() {
+		// The topology is composed of two hosts (A and B) connected through a router (R), with the links between
+		// A and R configured with an MTU of 1500, while those between R and B with an MTU of 1300.
+		var (
+			hostA, router, hostB ns.NetNS
+			w                    *Wireguard
+			updates              chan int
+			update               conncheck.UpdateFunc
+		)
+
+		endpointB := &net.UDPAddr{IP: net.ParseIP("10.200.2.1"), Port: 5871}
+
+		link := func(netnsA ns.NetNS, nameA, addrA string, netnsB ns.NetNS, nameB, addrB string, mtu int) {
+			veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: nameA, MTU: mtu}, PeerName: nameB}
+			Expect(netnsA.Do(func(_ ns.NetNS) error {
+				if err := netlink.LinkAdd(veth); err != nil {
+					return err
+				}
+				peer, err := netlink.LinkByName(nameB)
+				if err != nil {
+					return err
+				}
+				return netlink.LinkSetNsFd(peer, int(netnsB.Fd()))
+			})).To(Succeed())
+
+			configure := func(name, addr string) func(ns.NetNS) error {
+				return func(_ ns.NetNS) error {
+					l, err := netlink.LinkByName(name)
+					if err != nil {
+						return err
+					}
+					if err := netlink.LinkSetMTU(l, mtu); err != nil {
+						return err
+					}
+					ip, err := netlink.ParseAddr(addr)
+					if err != nil {
+						return err
+					}
+					if err := netlink.AddrAdd(l, ip); err != nil {
+						return err
+					}
+					return netlink.LinkSetUp(l)
+				}
+			}
+			Expect(netnsA.Do(configure(nameA, addrA))).To(Succeed())
+			Expect(netnsB.Do(configure(nameB, addrB))).To(Succeed())
+		}
+
+		defaultRoute := func(netns ns.NetNS, gateway string) {
+			Expect(netns.Do(func(_ ns.NetNS) error {
+				return netlink.RouteAdd(&netlink.Route{Gw: net.ParseIP(gateway)})
+			})).To(Succeed())
+		}
+
+		BeforeAll(func() {
+			var err error
+			for _, netns := range []*ns.NetNS{&hostA, &router, &hostB} {
+				*netns, err = testutils.NewNS()
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			link(hostA, "a0", "10.200.1.1/24", router, "r0", "10.200.1.254/24", 1500)
+			link(router, "r1", "10.200.2.254/24", hostB, "b0", "10.200.2.1/24", 1300)
+			defaultRoute(hostA, "10.200.1.254")
+			defaultRoute(hostB, "10.200.2.254")
+			Expect(router.Do(func(_ ns.NetNS) error {
+				return os.WriteFile("/proc/sys/net/ipv4/ip_forward", []byte("1"), 0600)
+			})).To(Succeed())
+
+			conncheck.ProbeTimeout = 100 * time.Millisecond
+		})
+
+		AfterAll(func() {
+			conncheck.ProbeTimeout = time.Second
+			for _, netns := range []ns.NetNS{hostA, router, hostB} {
+				Expect(netns.Close()).To(Succeed())
+				Expect(testutils.UnmountNS(netns)).To(Succeed())
+			}
+		})
+
+		BeforeEach(func() {
+			updates = make(chan int, 10)
+			w = &Wireguard{pathMTUs: make(map[string]*pathMTUState), underlayNetns: hostA,
+				conf: wgConfig{iFaceMTU: 1440, pmtuDiscoveryInterval: interval}}
+			update = func(_ bool, _ conncheck.Quality, _ time.Time) error { updates <- w.PathMTU(clusterID); return nil }
+		})
+
+		It("should discover the path MTU, net of the encapsulation overhead", func() {
+			Expect(w.probeUnderlayPathMTU(context.Background(), endpointB)).To(Equal(1300 - wireguardOverhead))
+		})
+
+		It("should notify the discovered path MTU", func() {
+			wrapped := w.discoverPathMTUOnConnected(clusterID, endpointB, update)
+			Expect(wrapped(true, conncheck.Quality{}, time.Now())).To(Succeed())
+			Eventually(updates, 10*time.Second).Should(Receive(Equal(1300 - wireguardOverhead)))
+		})
+
+		It("should not notify the path MTU if unchanged", func() {
+			w.pathMTUs[clusterID] = &pathMTUState{mtu: 1300 - wireguardOverhead}
+			wrapped := w.discoverPathMTUOnConnected(clusterID, endpointB, update)
+			Expect(wrapped(true, conncheck.Quality{}, time.Now())).To(Succeed())
+			Expect(updates).To(Receive())
+			Eventually(func() bool {
+				w.pathMTUMutex.Lock()
+				defer w.pathMTUMutex.Unlock()
+				return w.pathMTUs[clusterID].probing
+			}, 10*time.Second).Should(BeFalse())
+			Consistently(updates, 500*time.Millisecond).ShouldNot(Receive())
+		})
+
+		It("should fail if the path MTU is lower than the minimum", func() {
+			setRouterMTU := func(mtu int) func() error {
+				return func() error {
+					return router.Do(func(_ ns.NetNS) error {
+						l, err := netlink.LinkByName("r1")
+						if err != nil {
+							return err
+						}
+						return netlink.LinkSetMTU(l, mtu)
+					})
+				}
+			}
+			Expect(setRouterMTU(600)()).To(Succeed())
+			_, err := w.probeUnderlayPathMTU(context.Background(), endpointB)
+			Expect(setRouterMTU(1300)()).To(Succeed())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
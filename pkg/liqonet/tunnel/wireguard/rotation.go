@@ -0,0 +1,430 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqonet/conncheck"
+)
+
+// keysSwitchTimeout is the maximum time the connectivity with the remote clusters is awaited after the switch to the next keys,
+// before rolling back to the current ones.
+const keysSwitchTimeout = 2 * time.Minute
+
+// keysSwitchState tracks the switch of the device to the next keys, pending the confirmation of the connection checks.
+type keysSwitchState struct {
+	// key is the next private key configured in the device.
+	key wgtypes.Key
+	// since is the time of the switch.
+	since time.Time
+	// clusters are the remote clusters connected at the time of the switch, whose connectivity shall be confirmed.
+	clusters []string
+}
+
+// RotateKeys performs, if necessary, the next step of the keys rotation process, which is driven by the content of the secret
+// storing the keys (hence, surviving to the gateway restarts and failovers). Once the rotation interval is expired, a new key pair
+// is generated and announced to the remote clusters (which pre-configure the corresponding peer). After the overlap window, the
+// device switches to the next keys, which replace the current ones in the secret only once the connection checks towards the
+// remote clusters succeed again (otherwise, the device rolls back to the current keys, and the switch is postponed).
+// Additionally, it ensures the private key of the device matches the one stored in the secret.
+func (w *Wireguard) RotateKeys(ctx context.Context) error {
+	secret, err := w.k8sClient.CoreV1().Secrets(w.namespace).Get(ctx, keysName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the secret with name %s: %w", keysName, err)
+	}
+
+	current, err := parseKeyFromSecret(secret, PrivateKey)
+	if err != nil {
+		return err
+	}
+	var next *wgtypes.Key
+	if secret.Data[NextPrivateKey] != nil {
+		if next, err = parseKeyFromSecret(secret, NextPrivateKey); err != nil {
+			return err
+		}
+	}
+
+	// Align the device configuration with the keys stored in the secret (e.g., in case of failover),
+	// unless the switch to the next keys is pending confirmation.
+	switching := w.pendingKeysSwitch(next)
+	if switching == nil {
+		if err := w.setPrivateKey(*current); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case secret.Annotations[liqoconst.KeysGenerationTimestampAnnotation] == "":
+		// The keys have been generated before the introduction of the keys rotation, hence start counting from now.
+		setTimestampAnnotation(secret, liqoconst.KeysGenerationTimestampAnnotation, now)
+		return w.updateKeysSecret(ctx, secret)
+
+	case next != nil:
+		if now.Sub(getTimestampAnnotation(secret, liqoconst.NextKeysGenerationTimestampAnnotation)) < w.conf.keysRotationOverlap {
+			return nil
+		}
+
+		if switching == nil {
+			// The overlap window expired, hence the device switches to the next keys, pending the confirmation.
+			klog.Infof("Switching the %s keys to the ones announced to the remote clusters, with public key %s",
+				liqoconst.DriverName, next.PublicKey())
+			return w.switchPrivateKey(*next, now)
+		}
+
+		switch {
+		case w.keysSwitchConfirmed(switching):
+			// The connectivity through the next keys is confirmed, hence they replace the current ones.
+			klog.Infof("Replacing the %s keys with the ones announced to the remote clusters", liqoconst.DriverName)
+			secret.Data[PrivateKey] = []byte(next.String())
+			secret.Data[liqoconst.PublicKey] = []byte(next.PublicKey().String())
+			delete(secret.Data, NextPrivateKey)
+			delete(secret.Data, liqoconst.NextPublicKey)
+			delete(secret.Annotations, liqoconst.NextKeysGenerationTimestampAnnotation)
+			setTimestampAnnotation(secret, liqoconst.KeysGenerationTimestampAnnotation, now)
+			if err := w.updateKeysSecret(ctx, secret); err != nil {
+				return err
+			}
+			w.clearKeysSwitch()
+			return nil
+
+		case now.Sub(switching.since) >= keysSwitchTimeout:
+			// The connectivity through the next keys has not been confirmed, hence the device rolls back to the current ones,
+			// while the next ones keep being announced for another overlap window, before attempting the switch again.
+			klog.Warningf("Connectivity through the next %s keys not confirmed within %s, rolling back to the current ones",
+				liqoconst.DriverName, keysSwitchTimeout)
+			w.clearKeysSwitch()
+			if err := w.setPrivateKey(*current); err != nil {
+				return err
+			}
+			setTimestampAnnotation(secret, liqoconst.NextKeysGenerationTimestampAnnotation, now)
+			return w.updateKeysSecret(ctx, secret)
+		}
+		return nil
+
+	case w.conf.keysRotationInterval > 0 &&
+		now.Sub(getTimestampAnnotation(secret, liqoconst.KeysGenerationTimestampAnnotation)) >= w.conf.keysRotationInterval:
+		// The rotation interval expired, hence the next keys are generated and announced to the remote clusters.
+		next, err := wgtypes.GeneratePrivateKey()
+		if err != nil {
+			return fmt.Errorf("error generating private key for wireguard backend: %w", err)
+		}
+		klog.Infof("Announcing the next %s keys to the remote clusters, with public key %s", liqoconst.DriverName, next.PublicKey())
+		secret.Data[NextPrivateKey] = []byte(next.String())
+		secret.Data[liqoconst.NextPublicKey] = []byte(next.PublicKey().String())
+		setTimestampAnnotation(secret, liqoconst.NextKeysGenerationTimestampAnnotation, now)
+		return w.updateKeysSecret(ctx, secret)
+	}
+
+	return nil
+}
+
+// setPrivateKey configures the given private key in the wireguard device, if different from the current one.
+func (w *Wireguard) setPrivateKey(key wgtypes.Key) error {
+	w.keysMutex.Lock()
+	defer w.keysMutex.Unlock()
+
+	if key == w.conf.priKey {
+		return nil
+	}
+
+	if err := w.client.ConfigureDevice(liqoconst.DeviceName, wgtypes.Config{PrivateKey: &key}); err != nil {
+		return fmt.Errorf("failed to configure the private key of the WireGuard device: %w", err)
+	}
+	w.conf.priKey = key
+	w.conf.pubKey = key.PublicKey()
+	klog.Infof("%s interface named %s configured with publicKey %s", liqoconst.DriverName, liqoconst.DeviceName, w.conf.pubKey)
+	return nil
+}
+
+// switchPrivateKey configures the given next private key in the wireguard device, tracking the remote clusters currently
+// connected, whose connectivity shall be confirmed before committing the switch.
+func (w *Wireguard) switchPrivateKey(key wgtypes.Key, now time.Time) error {
+	w.connectionsMutex.RLock()
+	clusters := make([]string, 0, len(w.lastConnected))
+	for clusterID := range w.lastConnected {
+		clusters = append(clusters, clusterID)
+	}
+	w.connectionsMutex.RUnlock()
+
+	if err := w.setPrivateKey(key); err != nil {
+		return err
+	}
+
+	w.keysMutex.Lock()
+	defer w.keysMutex.Unlock()
+	w.keysSwitch = &keysSwitchState{key: key, since: now, clusters: clusters}
+	return nil
+}
+
+// pendingKeysSwitch returns the switch to the given next key, if pending confirmation and still in effect, and nil otherwise.
+func (w *Wireguard) pendingKeysSwitch(next *wgtypes.Key) *keysSwitchState {
+	w.keysMutex.Lock()
+	defer w.keysMutex.Unlock()
+
+	if w.keysSwitch == nil || next == nil || w.keysSwitch.key != *next || w.conf.priKey != *next {
+		w.keysSwitch = nil
+		return nil
+	}
+	switching := *w.keysSwitch
+	return &switching
+}
+
+// clearKeysSwitch forgets the switch to the next keys, if any.
+func (w *Wireguard) clearKeysSwitch() {
+	w.keysMutex.Lock()
+	defer w.keysMutex.Unlock()
+	w.keysSwitch = nil
+}
+
+// keysSwitchConfirmed returns whether the connection checks succeeded after the given switch to the next keys, for all the
+// remote clusters connected at the time of the switch (and not removed in the meanwhile).
+func (w *Wireguard) keysSwitchConfirmed(switching *keysSwitchState) bool {
+	w.connectionsMutex.RLock()
+	defer w.connectionsMutex.RUnlock()
+
+	for _, clusterID := range switching.clusters {
+		if _, found := w.connections[clusterID]; !found {
+			continue
+		}
+		if !w.lastConnected[clusterID].After(switching.since) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *Wireguard) updateKeysSecret(ctx context.Context, secret *corev1.Secret) error {
+	if _, err := w.k8sClient.CoreV1().Secrets(w.namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update the secret with name %s: %w", keysName, err)
+	}
+	return nil
+}
+
+// stagePeer configures a peer with the next public key announced by the remote cluster, and no allowed IPs (since they
+// cannot be shared with the current peer). This way, the handshake can be immediately completed once the remote cluster starts
+// using its next keys, while the allowed IPs are moved to the staged peer as soon as the switch is detected (promoteStagedPeer).
+func (w *Wireguard) stagePeer(key wgtypes.Key, endpoint *net.UDPAddr) error {
+	return w.client.ConfigureDevice(liqoconst.DeviceName, wgtypes.Config{
+		ReplacePeers: false,
+		Peers: []wgtypes.PeerConfig{{
+			PublicKey:         key,
+			Endpoint:          endpoint,
+			ReplaceAllowedIPs: true,
+		}},
+	})
+}
+
+// stageNextKey updates the connection with the given remote cluster, as a consequence of the announcement
+// of its next public key, while the rest of the configuration is unchanged.
+func (w *Wireguard) stageNextKey(tep *netv1alpha1.TunnelEndpoint, oldCon *netv1alpha1.Connection,
+	nextKey *wgtypes.Key, endpoint *net.UDPAddr) (*netv1alpha1.Connection, error) {
+	con := tep.Status.Connection.DeepCopy()
+	con.PeerConfiguration = make(map[string]string, len(oldCon.PeerConfiguration))
+	for key, value := range oldCon.PeerConfiguration {
+		con.PeerConfiguration[key] = value
+	}
+	delete(con.PeerConfiguration, liqoconst.NextPublicKey)
+
+	if nextKey != nil && nextKey.String() != oldCon.PeerConfiguration[liqoconst.PublicKey] {
+		klog.Infof("Cluster %s announced the next public key %s", tep.Spec.ClusterIdentity, nextKey)
+		if err := w.stagePeer(*nextKey, endpoint); err != nil {
+			return newConnectionOnError(err.Error()), fmt.Errorf("failed to configure staged peer with cluster %s: %w", tep.Spec.ClusterIdentity, err)
+		}
+		con.PeerConfiguration[liqoconst.NextPublicKey] = nextKey.String()
+	}
+
+	w.connectionsMutex.Lock()
+	w.connections[tep.Spec.ClusterIdentity.ClusterID] = con
+	w.connectionsMutex.Unlock()
+	return con, nil
+}
+
+// unstagePeer removes the peer configured with the next public key previously announced by the remote cluster,
+// in case it is neither the current public key nor the next one.
+func (w *Wireguard) unstagePeer(staged string, current wgtypes.Key, next *wgtypes.Key) error {
+	if staged == "" || staged == current.String() || (next != nil && staged == next.String()) {
+		return nil
+	}
+
+	key, err := wgtypes.ParseKey(staged)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key %s: %w", staged, err)
+	}
+	return w.removePeerWithKey(key)
+}
+
+// removePeerWithKey removes the WireGuard peer with the given public key.
+func (w *Wireguard) removePeerWithKey(key wgtypes.Key) error {
+	return w.client.ConfigureDevice(liqoconst.DeviceName, wgtypes.Config{
+		ReplacePeers: false,
+		Peers:        []wgtypes.PeerConfig{{PublicKey: key, Remove: true}},
+	})
+}
+
+// trackKeysRotation wraps the given update function, to track the outcome of the connection checks during the keys rotation.
+// Once the connection check confirms the traffic flows through the new peer, the one configured with the previous public key
+// of the remote cluster (if any) is removed. Conversely, in case the connectivity is lost, the peer configured with the next
+// public key of the remote cluster (if any) is promoted, since the remote cluster might have switched to it.
+func (w *Wireguard) trackKeysRotation(clusterID string, updateStatus conncheck.UpdateFunc) conncheck.UpdateFunc {
+	return func(connected bool, quality conncheck.Quality, timestamp time.Time) error {
+		w.connectionsMutex.Lock()
+		if connected {
+			w.lastConnected[clusterID] = timestamp
+		} else {
+			delete(w.lastConnected, clusterID)
+		}
+		w.connectionsMutex.Unlock()
+
+		if connected {
+			w.dropStalePeer(clusterID)
+		} else if err := w.promoteStagedPeer(clusterID); err != nil {
+			klog.Errorf("failed to promote the peer with the next public key of cluster %s: %v", clusterID, err)
+		}
+		return updateStatus(connected, quality, timestamp)
+	}
+}
+
+// promoteStagedPeer moves the allowed IPs of the given remote cluster to the peer configured with its next public key, if a
+// handshake has been completed through it after the last one of the current peer (i.e., the remote cluster switched to its next
+// keys, while the local tunnel endpoint has not yet been updated accordingly).
+func (w *Wireguard) promoteStagedPeer(clusterID string) error {
+	w.connectionsMutex.RLock()
+	con, found := w.connections[clusterID]
+	w.connectionsMutex.RUnlock()
+	if !found || con.PeerConfiguration[liqoconst.NextPublicKey] == "" {
+		return nil
+	}
+
+	current, err := wgtypes.ParseKey(con.PeerConfiguration[liqoconst.PublicKey])
+	if err != nil {
+		return fmt.Errorf("failed to parse public key %s: %w", con.PeerConfiguration[liqoconst.PublicKey], err)
+	}
+	next, err := wgtypes.ParseKey(con.PeerConfiguration[liqoconst.NextPublicKey])
+	if err != nil {
+		return fmt.Errorf("failed to parse next public key %s: %w", con.PeerConfiguration[liqoconst.NextPublicKey], err)
+	}
+
+	device, err := w.client.Device(liqoconst.DeviceName)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the configuration of the WireGuard device: %w", err)
+	}
+	var currentPeer, nextPeer *wgtypes.Peer
+	for i := range device.Peers {
+		switch device.Peers[i].PublicKey {
+		case current:
+			currentPeer = &device.Peers[i]
+		case next:
+			nextPeer = &device.Peers[i]
+		}
+	}
+	if nextPeer == nil || len(nextPeer.AllowedIPs) > 0 || nextPeer.LastHandshakeTime.IsZero() ||
+		(currentPeer != nil && !nextPeer.LastHandshakeTime.After(currentPeer.LastHandshakeTime)) {
+		return nil
+	}
+
+	klog.Infof("Cluster %s switched to the next public key %s, moving the allowed IPs to the corresponding peer", clusterID, next)
+	w.transitMutex.RLock()
+	direct := w.directAllowedIPs[clusterID]
+	w.transitMutex.RUnlock()
+	return w.client.ConfigureDevice(liqoconst.DeviceName, wgtypes.Config{
+		ReplacePeers: false,
+		Peers: []wgtypes.PeerConfig{{
+			PublicKey:         next,
+			UpdateOnly:        true,
+			ReplaceAllowedIPs: true,
+			AllowedIPs:        w.peerAllowedIPs(clusterID, direct),
+		}},
+	})
+}
+
+// dropStalePeer removes the peer configured with the previous public key of the given remote cluster, if any.
+func (w *Wireguard) dropStalePeer(clusterID string) {
+	w.connectionsMutex.Lock()
+	defer w.connectionsMutex.Unlock()
+
+	key, found := w.stalePeers[clusterID]
+	if !found {
+		return
+	}
+
+	if err := w.removePeerWithKey(key); err != nil {
+		klog.Errorf("failed to remove the peer with the previous public key of cluster %s: %v", clusterID, err)
+		return
+	}
+	delete(w.stalePeers, clusterID)
+	klog.Infof("Removed the peer with the previous public key %s of cluster %s", key, clusterID)
+}
+
+// getNextKey returns the next public key announced by the remote cluster, if any.
+func getNextKey(tep *netv1alpha1.TunnelEndpoint) (*wgtypes.Key, error) {
+	s, found := tep.Spec.BackendConfig[liqoconst.NextPublicKey]
+	if !found || s == "" {
+		return nil, nil
+	}
+
+	key, err := wgtypes.ParseKey(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse next public key %s: %w", s, err)
+	}
+	return &key, nil
+}
+
+// keyString returns the string representation of the given key, or the empty string if nil.
+func keyString(key *wgtypes.Key) string {
+	if key == nil {
+		return ""
+	}
+	return key.String()
+}
+
+func parseKeyFromSecret(secret *corev1.Secret, name string) (*wgtypes.Key, error) {
+	data, found := secret.Data[name]
+	if !found {
+		return nil, fmt.Errorf("no data with key '%s' found in secret %s", name, keysName)
+	}
+	key, err := wgtypes.ParseKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while parsing the %s for the wireguard driver: %w", name, err)
+	}
+	return &key, nil
+}
+
+func getTimestampAnnotation(secret *corev1.Secret, annotation string) time.Time {
+	timestamp, err := time.Parse(time.RFC3339, secret.Annotations[annotation])
+	if err != nil {
+		// Consider the keys as just generated, to prevent unexpected rotations.
+		return time.Now()
+	}
+	return timestamp
+}
+
+func setTimestampAnnotation(secret *corev1.Secret, annotation string, timestamp time.Time) {
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[annotation] = timestamp.Format(time.RFC3339)
+}
@@ -0,0 +1,355 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"context"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+)
+
+// fakeWgClient is a fake implementation of the wgClient interface, recording the configurations applied to the device.
+type fakeWgClient struct {
+	device  wgtypes.Device
+	configs []wgtypes.Config
+}
+
+func (c *fakeWgClient) ConfigureDevice(_ string, cfg wgtypes.Config) error {
+	c.configs = append(c.configs, cfg)
+	return nil
+}
+
+func (c *fakeWgClient) Device(_ string) (*wgtypes.Device, error) {
+	return &c.device, nil
+}
+
+func (c *fakeWgClient) Close() error {
+	return nil
+}
+
+var _ = Describe("Keys rotation", func() {
+	const namespace = "liqo"
+
+	Describe("testing getNextKey", func() {
+		It("should return nil if no next key is announced", func() {
+			key, err := getNextKey(&netv1alpha1.TunnelEndpoint{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key).To(BeNil())
+		})
+
+		It("should return the announced next key", func() {
+			priv, err := wgtypes.GeneratePrivateKey()
+			Expect(err).ToNot(HaveOccurred())
+			tep := &netv1alpha1.TunnelEndpoint{Spec: netv1alpha1.TunnelEndpointSpec{
+				BackendConfig: map[string]string{liqoconst.NextPublicKey: priv.PublicKey().String()},
+			}}
+
+			key, err := getNextKey(tep)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*key).To(Equal(priv.PublicKey()))
+		})
+
+		It("should fail in case of invalid next key", func() {
+			tep := &netv1alpha1.TunnelEndpoint{Spec: netv1alpha1.TunnelEndpointSpec{
+				BackendConfig: map[string]string{liqoconst.NextPublicKey: "invalid"},
+			}}
+			_, err := getNextKey(tep)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("testing RotateKeys", func() {
+		var (
+			ctx    context.Context
+			w      *Wireguard
+			priv   wgtypes.Key
+			secret *corev1.Secret
+			err    error
+		)
+
+		getSecret := func() *corev1.Secret {
+			s, err := w.k8sClient.CoreV1().Secrets(namespace).Get(ctx, keysName, metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			return s
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			priv, err = wgtypes.GeneratePrivateKey()
+			Expect(err).ToNot(HaveOccurred())
+
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: keysName, Namespace: namespace, Annotations: map[string]string{}},
+				Data: map[string][]byte{
+					PrivateKey:          []byte(priv.String()),
+					liqoconst.PublicKey: []byte(priv.PublicKey().String()),
+				},
+			}
+			w = &Wireguard{
+				namespace:     namespace,
+				client:        &fakeWgClient{},
+				connections:   map[string]*netv1alpha1.Connection{},
+				lastConnected: map[string]time.Time{},
+				conf: wgConfig{
+					priKey:               priv,
+					pubKey:               priv.PublicKey(),
+					keysRotationInterval: time.Hour,
+					keysRotationOverlap:  5 * time.Minute,
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			w.k8sClient = fake.NewSimpleClientset(secret)
+			Expect(w.RotateKeys(ctx)).To(Succeed())
+		})
+
+		When("the keys generation timestamp is not set", func() {
+			It("should initialize it", func() {
+				Expect(getSecret().Annotations).To(HaveKey(liqoconst.KeysGenerationTimestampAnnotation))
+				Expect(getSecret().Data).ToNot(HaveKey(NextPrivateKey))
+			})
+		})
+
+		When("the rotation interval is not yet expired", func() {
+			BeforeEach(func() {
+				setTimestampAnnotation(secret, liqoconst.KeysGenerationTimestampAnnotation, time.Now().Add(-time.Minute))
+			})
+
+			It("should not generate the next keys", func() {
+				Expect(getSecret().Data).ToNot(HaveKey(NextPrivateKey))
+				Expect(getSecret().Data).ToNot(HaveKey(liqoconst.NextPublicKey))
+			})
+		})
+
+		When("the rotation interval is expired", func() {
+			BeforeEach(func() {
+				setTimestampAnnotation(secret, liqoconst.KeysGenerationTimestampAnnotation, time.Now().Add(-2*time.Hour))
+			})
+
+			It("should announce the next keys", func() {
+				s := getSecret()
+				Expect(s.Data).To(HaveKey(NextPrivateKey))
+				Expect(s.Annotations).To(HaveKey(liqoconst.NextKeysGenerationTimestampAnnotation))
+
+				next, err := parseKeyFromSecret(s, NextPrivateKey)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(s.Data).To(HaveKeyWithValue(liqoconst.NextPublicKey, []byte(next.PublicKey().String())))
+			})
+
+			It("should keep using the current keys", func() {
+				Expect(getSecret().Data).To(HaveKeyWithValue(PrivateKey, []byte(priv.String())))
+				Expect(w.conf.priKey).To(Equal(priv))
+			})
+		})
+
+		When("the rotation is disabled", func() {
+			BeforeEach(func() {
+				w.conf.keysRotationInterval = 0
+				setTimestampAnnotation(secret, liqoconst.KeysGenerationTimestampAnnotation, time.Now().Add(-2*time.Hour))
+			})
+
+			It("should not generate the next keys", func() {
+				Expect(getSecret().Data).ToNot(HaveKey(NextPrivateKey))
+			})
+		})
+
+		When("the overlap window is not yet expired", func() {
+			BeforeEach(func() {
+				next, err := wgtypes.GeneratePrivateKey()
+				Expect(err).ToNot(HaveOccurred())
+				secret.Data[NextPrivateKey] = []byte(next.String())
+				secret.Data[liqoconst.NextPublicKey] = []byte(next.PublicKey().String())
+				setTimestampAnnotation(secret, liqoconst.KeysGenerationTimestampAnnotation, time.Now().Add(-2*time.Hour))
+				setTimestampAnnotation(secret, liqoconst.NextKeysGenerationTimestampAnnotation, time.Now().Add(-time.Minute))
+			})
+
+			It("should keep both the current and the next keys", func() {
+				s := getSecret()
+				Expect(s.Data).To(HaveKeyWithValue(PrivateKey, []byte(priv.String())))
+				Expect(s.Data).To(HaveKey(NextPrivateKey))
+				Expect(w.conf.priKey).To(Equal(priv))
+			})
+		})
+
+		When("the overlap window is expired", func() {
+			var next wgtypes.Key
+
+			BeforeEach(func() {
+				next, err = wgtypes.GeneratePrivateKey()
+				Expect(err).ToNot(HaveOccurred())
+				secret.Data[NextPrivateKey] = []byte(next.String())
+				secret.Data[liqoconst.NextPublicKey] = []byte(next.PublicKey().String())
+				setTimestampAnnotation(secret, liqoconst.KeysGenerationTimestampAnnotation, time.Now().Add(-2*time.Hour))
+				setTimestampAnnotation(secret, liqoconst.NextKeysGenerationTimestampAnnotation, time.Now().Add(-10*time.Minute))
+				w.connections["remote"] = &netv1alpha1.Connection{}
+				w.lastConnected["remote"] = time.Now().Add(-time.Second)
+			})
+
+			It("should switch the device to the next keys, without committing them", func() {
+				Expect(w.conf.priKey).To(Equal(next))
+				Expect(w.keysSwitch).ToNot(BeNil())
+				Expect(w.keysSwitch.clusters).To(ConsistOf("remote"))
+				Expect(getSecret().Data).To(HaveKeyWithValue(PrivateKey, []byte(priv.String())))
+				Expect(getSecret().Data).To(HaveKey(NextPrivateKey))
+			})
+
+			When("the connectivity is not yet confirmed", func() {
+				JustBeforeEach(func() {
+					Expect(w.RotateKeys(ctx)).To(Succeed())
+				})
+
+				It("should keep the next keys configured, without committing them", func() {
+					Expect(w.conf.priKey).To(Equal(next))
+					Expect(getSecret().Data).To(HaveKeyWithValue(PrivateKey, []byte(priv.String())))
+				})
+			})
+
+			When("the connectivity is confirmed", func() {
+				JustBeforeEach(func() {
+					w.lastConnected["remote"] = time.Now().Add(time.Second)
+					Expect(w.RotateKeys(ctx)).To(Succeed())
+				})
+
+				It("should replace the current keys with the next ones", func() {
+					s := getSecret()
+					Expect(s.Data).To(HaveKeyWithValue(PrivateKey, []byte(next.String())))
+					Expect(s.Data).To(HaveKeyWithValue(liqoconst.PublicKey, []byte(next.PublicKey().String())))
+					Expect(s.Data).ToNot(HaveKey(NextPrivateKey))
+					Expect(w.conf.priKey).To(Equal(next))
+					Expect(w.keysSwitch).To(BeNil())
+				})
+			})
+
+			When("the connectivity is not confirmed within the timeout", func() {
+				JustBeforeEach(func() {
+					w.keysSwitch.since = time.Now().Add(-keysSwitchTimeout)
+					w.lastConnected["remote"] = w.keysSwitch.since.Add(-time.Second)
+					Expect(w.RotateKeys(ctx)).To(Succeed())
+				})
+
+				It("should roll back to the current keys, and postpone the switch", func() {
+					s := getSecret()
+					Expect(s.Data).To(HaveKeyWithValue(PrivateKey, []byte(priv.String())))
+					Expect(s.Data).To(HaveKeyWithValue(NextPrivateKey, []byte(next.String())))
+					Expect(getTimestampAnnotation(s, liqoconst.NextKeysGenerationTimestampAnnotation)).To(
+						BeTemporally("~", time.Now(), 5*time.Second))
+					Expect(w.conf.priKey).To(Equal(priv))
+					Expect(w.keysSwitch).To(BeNil())
+				})
+			})
+
+			When("the remote cluster is no longer connected", func() {
+				JustBeforeEach(func() {
+					delete(w.connections, "remote")
+					Expect(w.RotateKeys(ctx)).To(Succeed())
+				})
+
+				It("should not wait for its connectivity", func() {
+					Expect(getSecret().Data).To(HaveKeyWithValue(PrivateKey, []byte(next.String())))
+				})
+			})
+		})
+	})
+
+	Describe("testing promoteStagedPeer", func() {
+		var (
+			w              *Wireguard
+			client         *fakeWgClient
+			current, next  wgtypes.Key
+			allowed        net.IPNet
+			now            time.Time
+			currentPeer    wgtypes.Peer
+			nextPeer       wgtypes.Peer
+			nextPeerExists bool
+		)
+
+		generateKey := func() wgtypes.Key {
+			key, err := wgtypes.GeneratePrivateKey()
+			Expect(err).ToNot(HaveOccurred())
+			return key.PublicKey()
+		}
+
+		BeforeEach(func() {
+			now = time.Now()
+			current, next = generateKey(), generateKey()
+			_, network, err := net.ParseCIDR("10.0.0.0/16")
+			Expect(err).ToNot(HaveOccurred())
+			allowed = *network
+
+			currentPeer = wgtypes.Peer{PublicKey: current, AllowedIPs: []net.IPNet{allowed}, LastHandshakeTime: now.Add(-time.Minute)}
+			nextPeer = wgtypes.Peer{PublicKey: next, LastHandshakeTime: now}
+			nextPeerExists = true
+
+			client = &fakeWgClient{}
+			w = &Wireguard{
+				client: client,
+				connections: map[string]*netv1alpha1.Connection{"remote": {PeerConfiguration: map[string]string{
+					liqoconst.PublicKey: current.String(), liqoconst.NextPublicKey: next.String(),
+				}}},
+				directAllowedIPs:  map[string][]net.IPNet{"remote": {allowed}},
+				transitAllowedIPs: map[string]map[string][]net.IPNet{},
+			}
+		})
+
+		JustBeforeEach(func() {
+			client.device.Peers = []wgtypes.Peer{currentPeer}
+			if nextPeerExists {
+				client.device.Peers = append(client.device.Peers, nextPeer)
+			}
+			Expect(w.promoteStagedPeer("remote")).To(Succeed())
+		})
+
+		When("the remote cluster completed a handshake through the staged peer", func() {
+			It("should move the allowed IPs to the staged peer", func() {
+				Expect(client.configs).To(HaveLen(1))
+				Expect(client.configs[0].Peers).To(HaveLen(1))
+				Expect(client.configs[0].Peers[0].PublicKey).To(Equal(next))
+				Expect(client.configs[0].Peers[0].ReplaceAllowedIPs).To(BeTrue())
+				Expect(client.configs[0].Peers[0].AllowedIPs).To(ConsistOf(allowed))
+			})
+		})
+
+		When("the handshake through the staged peer is older than the one through the current peer", func() {
+			BeforeEach(func() { nextPeer.LastHandshakeTime = now.Add(-2 * time.Minute) })
+			It("should not modify the configuration", func() { Expect(client.configs).To(BeEmpty()) })
+		})
+
+		When("no handshake has been completed through the staged peer", func() {
+			BeforeEach(func() { nextPeer.LastHandshakeTime = time.Time{} })
+			It("should not modify the configuration", func() { Expect(client.configs).To(BeEmpty()) })
+		})
+
+		When("the staged peer has already been promoted", func() {
+			BeforeEach(func() { nextPeer.AllowedIPs = []net.IPNet{allowed} })
+			It("should not modify the configuration", func() { Expect(client.configs).To(BeEmpty()) })
+		})
+
+		When("no peer has been staged", func() {
+			BeforeEach(func() { nextPeerExists = false })
+			It("should not modify the configuration", func() { Expect(client.configs).To(BeEmpty()) })
+		})
+	})
+})
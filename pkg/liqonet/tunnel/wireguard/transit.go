@@ -0,0 +1,277 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	liqoconst "github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/liqonet/conncheck"
+	liqonetutils "github.com/liqotech/liqo/pkg/liqonet/utils"
+)
+
+// connectThroughTransit connects to a remote cluster reachable through an intermediate cluster, described by the given tep.
+// No WireGuard peer is configured, while the networks of the remote cluster are added to the allowed IPs of the intermediate one.
+func (w *Wireguard) connectThroughTransit(tep *netv1alpha1.TunnelEndpoint, updateStatus conncheck.UpdateFunc) (*netv1alpha1.Connection, error) {
+	clusterID := tep.Spec.ClusterIdentity.ClusterID
+	transitClusterID := tep.Spec.TransitClusterID
+
+	allowedIPs, stringAllowedIPs, err := getAllowedIPs(tep)
+	if err != nil {
+		return newConnectionOnError(err.Error()), err
+	}
+
+	w.connectionsMutex.RLock()
+	oldCon, found := w.connections[clusterID]
+	w.connectionsMutex.RUnlock()
+	if found {
+		// check if the configuration is updated.
+		if stringAllowedIPs == oldCon.PeerConfiguration[AllowedIPs] && transitClusterID == oldCon.PeerConfiguration[TransitClusterID] {
+			return &tep.Status.Connection, nil
+		}
+
+		klog.V(4).Infof("updating transit configuration for cluster %s", tep.Spec.ClusterIdentity)
+		if err := w.removePeer(oldCon); err != nil {
+			return newConnectionOnError(err.Error()), fmt.Errorf("failed to remove peer with cluster %s: %w", tep.Spec.ClusterIdentity, err)
+		}
+		w.Connchecker.DelAndStopSender(clusterID)
+	} else {
+		klog.V(4).Infof("Connecting cluster %s through intermediate cluster %s", tep.Spec.ClusterIdentity, transitClusterID)
+	}
+
+	_, externalCIDR := liqonetutils.GetExternalCIDRS(tep)
+	pingIP, err := liqonetutils.GetTunnelIP(externalCIDR)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the tunnel ip: %w", err)
+	}
+
+	if err := w.enforceTransitAllowedIPs(clusterID, map[string][]net.IPNet{transitClusterID: allowedIPs}); err != nil {
+		return newConnectionOnError(err.Error()), fmt.Errorf("failed to configure transit for cluster %s: %w", tep.Spec.ClusterIdentity, err)
+	}
+
+	c := &netv1alpha1.Connection{
+		Status:            netv1alpha1.Connecting,
+		StatusMessage:     netv1alpha1.ConnectingMessage,
+		PeerConfiguration: map[string]string{TransitClusterID: transitClusterID, AllowedIPs: stringAllowedIPs},
+		Latency: netv1alpha1.ConnectionLatency{
+			Value:     liqoconst.NotApplicable,
+			Timestamp: metav1.Time{Time: time.Now()},
+		},
+	}
+	w.connectionsMutex.Lock()
+	w.connections[clusterID] = c
+	w.connectionsMutex.Unlock()
+
+	klog.Infof("%s -> starting conncheck sender", tep.Spec.ClusterIdentity)
+	// The path MTU is not discovered, since inherited from the intermediate cluster.
+	go w.Connchecker.AddAndRunSender(clusterID, pingIP, updateStatus)
+
+	klog.V(4).Infof("Done connecting cluster %s through intermediate cluster %s", tep.Spec.ClusterIdentity, transitClusterID)
+	return c, nil
+}
+
+// removePeer removes the WireGuard peer associated with the given connection, if any.
+func (w *Wireguard) removePeer(con *netv1alpha1.Connection) error {
+	s, found := con.PeerConfiguration[liqoconst.PublicKey]
+	if !found {
+		return nil
+	}
+
+	key, err := wgtypes.ParseKey(s)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key %s: %w", s, err)
+	}
+
+	w.connectionsMutex.Lock()
+	delete(w.connectedClusterIdentities, key)
+	w.connectionsMutex.Unlock()
+	return w.removePeerWithKey(key)
+}
+
+// getTransitAllowedIPs returns the additional allowed IPs, keyed by peer cluster ID, required to
+// forward the traffic towards the clusters for which the given cluster acts as intermediate hop.
+func getTransitAllowedIPs(tep *netv1alpha1.TunnelEndpoint) (map[string][]net.IPNet, error) {
+	allowed := make(map[string][]net.IPNet)
+	for i := range tep.Spec.TransitRoutes {
+		route := &tep.Spec.TransitRoutes[i]
+
+		sources, err := parseCIDRs(route.SourceCIDRs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transit route towards cluster %s: %w", route.DestinationClusterID, err)
+		}
+		destinations, err := parseCIDRs(route.DestinationCIDRs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transit route towards cluster %s: %w", route.DestinationClusterID, err)
+		}
+
+		// The traffic originated by the given cluster is accepted from its peer, and forwarded to the destination one.
+		allowed[tep.Spec.ClusterIdentity.ClusterID] = append(allowed[tep.Spec.ClusterIdentity.ClusterID], sources...)
+		allowed[route.DestinationClusterID] = append(allowed[route.DestinationClusterID], destinations...)
+	}
+	return allowed, nil
+}
+
+// enforceTransitAllowedIPs replaces the additional allowed IPs requested by the given owner cluster,
+// and updates the configuration of the peers whose allowed IPs changed accordingly.
+func (w *Wireguard) enforceTransitAllowedIPs(owner string, allowed map[string][]net.IPNet) error {
+	return w.refreshPeers(w.setTransitAllowedIPs(owner, allowed))
+}
+
+// refreshPeers updates the allowed IPs of the WireGuard peers associated with the given clusters.
+func (w *Wireguard) refreshPeers(clusterIDs []string) error {
+	for _, clusterID := range clusterIDs {
+		if err := w.refreshPeer(clusterID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTransitAllowedIPs replaces the additional allowed IPs requested by the given owner cluster,
+// and returns the (sorted) list of peers whose allowed IPs changed.
+func (w *Wireguard) setTransitAllowedIPs(owner string, allowed map[string][]net.IPNet) []string {
+	w.transitMutex.Lock()
+	defer w.transitMutex.Unlock()
+
+	var changed []string
+	for peer, owners := range w.transitAllowedIPs {
+		if _, found := owners[owner]; found && len(allowed[peer]) == 0 {
+			delete(owners, owner)
+			changed = append(changed, peer)
+		}
+		if len(owners) == 0 {
+			delete(w.transitAllowedIPs, peer)
+		}
+	}
+
+	for peer, networks := range allowed {
+		if len(networks) == 0 {
+			continue
+		}
+		if _, found := w.transitAllowedIPs[peer]; !found {
+			w.transitAllowedIPs[peer] = make(map[string][]net.IPNet)
+		}
+		if !reflect.DeepEqual(w.transitAllowedIPs[peer][owner], networks) {
+			w.transitAllowedIPs[peer][owner] = networks
+			changed = append(changed, peer)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// peerAllowedIPs returns the complete list of allowed IPs of the given peer, including the transit ones.
+func (w *Wireguard) peerAllowedIPs(clusterID string, direct []net.IPNet) []net.IPNet {
+	w.transitMutex.RLock()
+	defer w.transitMutex.RUnlock()
+
+	owners := make([]string, 0, len(w.transitAllowedIPs[clusterID]))
+	for owner := range w.transitAllowedIPs[clusterID] {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	allowed := append([]net.IPNet{}, direct...)
+	for _, owner := range owners {
+		for _, network := range w.transitAllowedIPs[clusterID][owner] {
+			if !containsNetwork(allowed, network) {
+				allowed = append(allowed, network)
+			}
+		}
+	}
+	return allowed
+}
+
+// refreshPeer updates the allowed IPs of the WireGuard peer associated with the given cluster, if already configured.
+func (w *Wireguard) refreshPeer(clusterID string) error {
+	w.connectionsMutex.RLock()
+	con, found := w.connections[clusterID]
+	w.connectionsMutex.RUnlock()
+	if !found {
+		klog.V(4).Infof("no tunnel configured for cluster %s, transit allowed IPs will be configured later", clusterID)
+		return nil
+	}
+
+	s, found := con.PeerConfiguration[liqoconst.PublicKey]
+	if !found {
+		return nil
+	}
+	key, err := wgtypes.ParseKey(s)
+	if err != nil {
+		return fmt.Errorf("failed to parse public key %s: %w", s, err)
+	}
+
+	w.transitMutex.RLock()
+	direct := w.directAllowedIPs[clusterID]
+	w.transitMutex.RUnlock()
+
+	err = w.client.ConfigureDevice(liqoconst.DeviceName, wgtypes.Config{
+		ReplacePeers: false,
+		Peers: []wgtypes.PeerConfig{{
+			PublicKey:         key,
+			UpdateOnly:        true,
+			ReplaceAllowedIPs: true,
+			AllowedIPs:        w.peerAllowedIPs(clusterID, direct),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update allowed IPs of peer with cluster %s: %w", clusterID, err)
+	}
+	klog.V(4).Infof("Allowed IPs of peer with cluster %s correctly updated", clusterID)
+	return nil
+}
+
+// setDirectAllowedIPs stores the allowed IPs of the peer associated with the given cluster, excluding the transit ones.
+func (w *Wireguard) setDirectAllowedIPs(clusterID string, allowed []net.IPNet) {
+	w.transitMutex.Lock()
+	defer w.transitMutex.Unlock()
+
+	if allowed == nil {
+		delete(w.directAllowedIPs, clusterID)
+		return
+	}
+	w.directAllowedIPs[clusterID] = allowed
+}
+
+func parseCIDRs(cidrs []string) ([]net.IPNet, error) {
+	networks := make([]net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse CIDR %s: %w", cidr, err)
+		}
+		networks = append(networks, *network)
+	}
+	return networks, nil
+}
+
+func containsNetwork(networks []net.IPNet, network net.IPNet) bool {
+	for i := range networks {
+		if networks[i].String() == network.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"net"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	discv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+)
+
+var _ = Describe("Transit", func() {
+	mustParseCIDR := func(cidr string) net.IPNet {
+		_, network, err := net.ParseCIDR(cidr)
+		Expect(err).ToNot(HaveOccurred())
+		return *network
+	}
+
+	Describe("testing getTransitAllowedIPs", func() {
+		It("should return the allowed IPs of both the origin and the destination peers", func() {
+			tep := &netv1alpha1.TunnelEndpoint{Spec: netv1alpha1.TunnelEndpointSpec{
+				ClusterIdentity: discv1alpha1.ClusterIdentity{ClusterID: "origin"},
+				TransitRoutes: []netv1alpha1.TransitRoute{{
+					DestinationClusterID: "destination",
+					SourceCIDRs:          []string{"10.0.0.0/16", "10.1.0.0/16"},
+					DestinationCIDRs:     []string{"10.2.0.0/16", "10.3.0.0/16"},
+				}},
+			}}
+
+			allowed, err := getTransitAllowedIPs(tep)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(allowed).To(HaveKeyWithValue("origin", ConsistOf(mustParseCIDR("10.0.0.0/16"), mustParseCIDR("10.1.0.0/16"))))
+			Expect(allowed).To(HaveKeyWithValue("destination", ConsistOf(mustParseCIDR("10.2.0.0/16"), mustParseCIDR("10.3.0.0/16"))))
+		})
+
+		It("should fail in case of invalid CIDRs", func() {
+			tep := &netv1alpha1.TunnelEndpoint{Spec: netv1alpha1.TunnelEndpointSpec{
+				TransitRoutes: []netv1alpha1.TransitRoute{{DestinationClusterID: "destination", SourceCIDRs: []string{"invalid"}}},
+			}}
+			_, err := getTransitAllowedIPs(tep)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("testing the transit allowed IPs management", func() {
+		var w *Wireguard
+
+		BeforeEach(func() {
+			w = &Wireguard{
+				transitAllowedIPs: make(map[string]map[string][]net.IPNet),
+				directAllowedIPs:  make(map[string][]net.IPNet),
+			}
+		})
+
+		It("should track the peers whose allowed IPs changed", func() {
+			allowed := map[string][]net.IPNet{"hub": {mustParseCIDR("10.2.0.0/16")}}
+			Expect(w.setTransitAllowedIPs("remote", allowed)).To(ConsistOf("hub"))
+			Expect(w.setTransitAllowedIPs("remote", allowed)).To(BeEmpty())
+			Expect(w.setTransitAllowedIPs("remote", nil)).To(ConsistOf("hub"))
+			Expect(w.transitAllowedIPs).To(BeEmpty())
+		})
+
+		It("should merge the direct and the transit allowed IPs, without duplicates", func() {
+			direct := []net.IPNet{mustParseCIDR("10.0.0.0/16")}
+			w.setTransitAllowedIPs("foo", map[string][]net.IPNet{"hub": {mustParseCIDR("10.2.0.0/16"), mustParseCIDR("10.0.0.0/16")}})
+			w.setTransitAllowedIPs("bar", map[string][]net.IPNet{"hub": {mustParseCIDR("10.3.0.0/16")}})
+			Expect(w.peerAllowedIPs("hub", direct)).To(Equal([]net.IPNet{
+				mustParseCIDR("10.0.0.0/16"), mustParseCIDR("10.3.0.0/16"), mustParseCIDR("10.2.0.0/16"),
+			}))
+			Expect(w.peerAllowedIPs("other", direct)).To(Equal(direct))
+		})
+	})
+})
@@ -20,6 +20,7 @@ import (
 	"net"
 	"net/netip"
 	"os"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -79,6 +80,15 @@ func GetPodIP() (net.IP, error) {
 	return net.ParseIP(ipAddress), nil
 }
 
+// GetPodName gets the name of the pod passed as an environment variable.
+func GetPodName() (string, error) {
+	name, isSet := os.LookupEnv("POD_NAME")
+	if !isSet || name == "" {
+		return "", errors.New("the POD_NAME environment variable is not set as an environment variable")
+	}
+	return name, nil
+}
+
 // GetPodNamespace gets the namespace of the pod passed as an environment variable.
 func GetPodNamespace() (string, error) {
 	namespace, isSet := os.LookupEnv("POD_NAMESPACE")
@@ -153,6 +163,33 @@ func GetExternalCIDRS(tep *netv1alpha1.TunnelEndpoint) (localExternalCIDR, remot
 	return
 }
 
+// ForgeTransitRoute returns the TransitRoute an intermediate cluster is requested to configure to forward the traffic
+// between the local cluster and the remote one described by the given (transit) TunnelEndpoint.
+func ForgeTransitRoute(tep *netv1alpha1.TunnelEndpoint) netv1alpha1.TransitRoute {
+	localPodCIDR, remotePodCIDR := GetPodCIDRS(tep)
+	if localPodCIDR == consts.DefaultCIDRValue {
+		localPodCIDR = tep.Spec.LocalPodCIDR
+	}
+	localExternalCIDR, remoteExternalCIDR := GetExternalCIDRS(tep)
+
+	return netv1alpha1.TransitRoute{
+		DestinationClusterID: tep.Spec.ClusterIdentity.ClusterID,
+		SourceCIDRs:          []string{localPodCIDR, localExternalCIDR},
+		DestinationCIDRs:     []string{remotePodCIDR, remoteExternalCIDR},
+	}
+}
+
+// GetTransitCIDRs returns the networks, as seen by the local cluster, of the remote clusters
+// for which the local cluster acts as intermediate hop, according to the given TunnelEndpoint.
+func GetTransitCIDRs(tep *netv1alpha1.TunnelEndpoint) []string {
+	var cidrs []string
+	for i := range tep.Spec.TransitRoutes {
+		cidrs = append(cidrs, tep.Spec.TransitRoutes[i].SourceCIDRs...)
+		cidrs = append(cidrs, tep.Spec.TransitRoutes[i].DestinationCIDRs...)
+	}
+	return cidrs
+}
+
 // IsValidCIDR returns an error if the received CIDR is invalid.
 func IsValidCIDR(cidr string) error {
 	_, _, err := net.ParseCIDR(cidr)
@@ -317,6 +354,20 @@ func SplitNetwork(network string) []string {
 	return halves
 }
 
+// FormatPacketLoss returns a string representing the given packet loss ratio as a percentage.
+func FormatPacketLoss(ratio float64) string {
+	return fmt.Sprintf("%.1f%%", ratio*100)
+}
+
+// ParsePacketLoss parses a packet loss percentage (e.g., 5%) and returns the corresponding ratio.
+func ParsePacketLoss(percentage string) (float64, error) {
+	value, err := strconv.ParseFloat(strings.TrimSuffix(percentage, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid packet loss percentage %q: %w", percentage, err)
+	}
+	return value / 100, nil
+}
+
 // FormatLatency returns a string representing the given latency in a human readable format.
 func FormatLatency(latency time.Duration) string {
 	if latency == 0 {
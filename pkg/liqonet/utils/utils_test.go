@@ -22,6 +22,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
 	liqonetutils "github.com/liqotech/liqo/pkg/liqonet/utils"
 )
 
@@ -103,6 +106,54 @@ var _ = Describe("Liqonet", func() {
 		})
 	})
 
+	Describe("testing ForgeTransitRoute function", func() {
+		var tep *netv1alpha1.TunnelEndpoint
+
+		BeforeEach(func() {
+			tep = &netv1alpha1.TunnelEndpoint{Spec: netv1alpha1.TunnelEndpointSpec{
+				ClusterIdentity:       discoveryv1alpha1.ClusterIdentity{ClusterID: "remote-cluster-id"},
+				LocalPodCIDR:          "10.0.0.0/16",
+				LocalNATPodCIDR:       consts.DefaultCIDRValue,
+				LocalExternalCIDR:     "10.1.0.0/16",
+				LocalNATExternalCIDR:  "10.3.0.0/16",
+				RemotePodCIDR:         "10.0.0.0/16",
+				RemoteNATPodCIDR:      "10.2.0.0/16",
+				RemoteExternalCIDR:    "10.4.0.0/16",
+				RemoteNATExternalCIDR: consts.DefaultCIDRValue,
+			}}
+		})
+
+		It("should return the networks as seen by the two clusters", func() {
+			Expect(liqonetutils.ForgeTransitRoute(tep)).To(Equal(netv1alpha1.TransitRoute{
+				DestinationClusterID: "remote-cluster-id",
+				SourceCIDRs:          []string{"10.0.0.0/16", "10.3.0.0/16"},
+				DestinationCIDRs:     []string{"10.2.0.0/16", "10.4.0.0/16"},
+			}))
+		})
+
+		It("the transit CIDRs should include the ones of all the routes", func() {
+			tep.Spec.TransitRoutes = []netv1alpha1.TransitRoute{liqonetutils.ForgeTransitRoute(tep)}
+			Expect(liqonetutils.GetTransitCIDRs(tep)).To(ConsistOf("10.0.0.0/16", "10.3.0.0/16", "10.2.0.0/16", "10.4.0.0/16"))
+		})
+	})
+
+	Describe("testing FormatPacketLoss and ParsePacketLoss functions", func() {
+		It("should format the packet loss as a percentage", func() {
+			Expect(liqonetutils.FormatPacketLoss(0.1)).To(Equal("10.0%"))
+		})
+
+		It("should parse the packet loss percentage", func() {
+			ratio, err := liqonetutils.ParsePacketLoss("2.5%")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ratio).To(BeNumerically("~", 0.025))
+		})
+
+		It("should fail in case of invalid percentage", func() {
+			_, err := liqonetutils.ParsePacketLoss("invalid")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("testing AddAnnotationToObj function", func() {
 		Context("when annotations map is nil", func() {
 			It("should create the map and return true", func() {
@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/types"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
@@ -325,4 +326,54 @@ var _ = Describe("ParseArguments", func() {
 		)
 	})
 
+	Context("ResourceList", func() {
+		type parseResourceListTestcase struct {
+			str            string
+			expectedError  OmegaMatcher
+			expectedValue  corev1.ResourceList
+			expectedString string
+		}
+
+		DescribeTable("ResourceList table",
+			func(c parseResourceListTestcase) {
+				rl := ResourceList{}
+				err := rl.Set(c.str)
+				Expect(err).To(c.expectedError)
+
+				if err == nil {
+					Expect(rl.ResourceList).To(HaveLen(len(c.expectedValue)))
+					for name, quantity := range c.expectedValue {
+						Expect(rl.ResourceList).To(HaveKey(name))
+						Expect(rl.ResourceList[name].Equal(quantity)).To(BeTrue())
+					}
+					Expect(rl.String()).To(Equal(c.expectedString))
+				}
+			},
+
+			Entry("empty string", parseResourceListTestcase{
+				str:            "",
+				expectedError:  Not(HaveOccurred()),
+				expectedValue:  corev1.ResourceList{},
+				expectedString: "",
+			}),
+
+			Entry("invalid format", parseResourceListTestcase{
+				str:           "cpu",
+				expectedError: HaveOccurred(),
+			}),
+
+			Entry("invalid quantity", parseResourceListTestcase{
+				str:           "cpu=11z",
+				expectedError: HaveOccurred(),
+			}),
+
+			Entry("valid string", parseResourceListTestcase{
+				str:            "memory=4Gi,cpu=2",
+				expectedError:  Not(HaveOccurred()),
+				expectedValue:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi")},
+				expectedString: "cpu=2,memory=4Gi",
+			}),
+		)
+	})
+
 })
@@ -102,9 +102,24 @@ func updateAuthTokenSecret(ctx context.Context, clientset kubernetes.Interface,
 
 func createAuthTokenSecret(ctx context.Context, clientset kubernetes.Interface,
 	secretName, liqoNamespace, clusterID, authToken string) error {
-	secret := &corev1.Secret{
+	secret := ForgeSecret(clusterID, authToken, liqoNamespace)
+	secret.Name = secretName
+
+	_, err := clientset.CoreV1().Secrets(liqoNamespace).Create(ctx, secret, metav1.CreateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ForgeSecret forges the secret storing the authentication token for a given remote cluster.
+func ForgeSecret(clusterID, authToken, liqoNamespace string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Secret"},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretName,
+			Name:      fmt.Sprintf("%v%v", authTokenSecretNamePrefix, clusterID),
 			Namespace: liqoNamespace,
 			Labels: map[string]string{
 				discovery.ClusterIDLabel: clusterID,
@@ -112,15 +127,7 @@ func createAuthTokenSecret(ctx context.Context, clientset kubernetes.Interface,
 			},
 		},
 		StringData: map[string]string{
-			"token": authToken,
+			tokenKey: authToken,
 		},
 	}
-
-	_, err := clientset.CoreV1().Secrets(liqoNamespace).Create(ctx, secret, metav1.CreateOptions{})
-	if err != nil {
-		klog.Error(err)
-		return err
-	}
-
-	return nil
 }
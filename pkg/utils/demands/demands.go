@@ -0,0 +1,77 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+)
+
+// Grant returns the resources to be granted to a consumer cluster, given its demands and the resources available
+// according to the sharing policies. Each desired resource is granted up to the available amount, while the
+// resources which are not desired are granted as available. A nil demands object grants all available resources.
+func Grant(demands *discoveryv1alpha1.ResourceDemands, available corev1.ResourceList) corev1.ResourceList {
+	granted := available.DeepCopy()
+	if granted == nil {
+		granted = corev1.ResourceList{}
+	}
+	if demands == nil {
+		return granted
+	}
+
+	for name, desired := range demands.Desired {
+		// Resources which are not available at all cannot be granted.
+		if quantity, found := granted[name]; found && quantity.Cmp(desired) > 0 {
+			granted[name] = desired.DeepCopy()
+		}
+	}
+	return granted
+}
+
+// State returns to which extent the given demands are satisfied by the granted resources.
+func State(demands *discoveryv1alpha1.ResourceDemands, granted corev1.ResourceList) discoveryv1alpha1.DemandsStateType {
+	if demands == nil || (len(demands.Desired) == 0 && len(demands.Minimum) == 0) {
+		return discoveryv1alpha1.DemandsStateNone
+	}
+
+	for name, minimum := range demands.Minimum {
+		if quantity := granted[name]; quantity.Cmp(minimum) < 0 {
+			return discoveryv1alpha1.DemandsStateUnsatisfied
+		}
+	}
+
+	for name, desired := range demands.Desired {
+		if quantity := granted[name]; quantity.Cmp(desired) < 0 {
+			return discoveryv1alpha1.DemandsStatePartiallySatisfied
+		}
+	}
+
+	return discoveryv1alpha1.DemandsStateSatisfied
+}
+
+// Format returns a compact representation of the given resources (e.g., cpu=4,memory=8Gi), sorted by name.
+func Format(resources corev1.ResourceList) string {
+	entries := make([]string, 0, len(resources))
+	for name, quantity := range resources {
+		entries = append(entries, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,102 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package demands_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/demands"
+)
+
+var _ = Describe("Resource demands", func() {
+	const gpu corev1.ResourceName = "nvidia.com/gpu"
+
+	var (
+		available corev1.ResourceList
+		dmnds     *discoveryv1alpha1.ResourceDemands
+	)
+
+	BeforeEach(func() {
+		available = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("8"),
+			corev1.ResourceMemory: resource.MustParse("16Gi"),
+			corev1.ResourcePods:   resource.MustParse("110"),
+		}
+		dmnds = &discoveryv1alpha1.ResourceDemands{
+			Desired: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("32Gi"),
+			},
+			Minimum: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+			},
+		}
+	})
+
+	Describe("The Grant function", func() {
+		It("should grant all the available resources if no demands are specified", func() {
+			Expect(demands.Grant(nil, available)).To(Equal(available))
+		})
+
+		It("should grant the desired resources up to the available amount", func() {
+			granted := demands.Grant(dmnds, available)
+			Expect(granted).To(HaveLen(3))
+			Expect(granted.Cpu().String()).To(Equal("4"))
+			Expect(granted.Memory().String()).To(Equal("16Gi"))
+			Expect(granted.Pods().String()).To(Equal("110"))
+		})
+
+		It("should not grant the desired resources which are not available", func() {
+			dmnds.Desired[gpu] = resource.MustParse("1")
+			Expect(demands.Grant(dmnds, available)).ToNot(HaveKey(gpu))
+		})
+	})
+
+	Describe("The State function", func() {
+		It("should return None if no demands are specified", func() {
+			Expect(demands.State(nil, available)).To(Equal(discoveryv1alpha1.DemandsStateNone))
+			Expect(demands.State(&discoveryv1alpha1.ResourceDemands{}, available)).To(Equal(discoveryv1alpha1.DemandsStateNone))
+		})
+
+		It("should return Satisfied if all the desired resources are granted", func() {
+			dmnds.Desired[corev1.ResourceMemory] = resource.MustParse("16Gi")
+			Expect(demands.State(dmnds, available)).To(Equal(discoveryv1alpha1.DemandsStateSatisfied))
+		})
+
+		It("should return PartiallySatisfied if only the minimum resources are granted", func() {
+			Expect(demands.State(dmnds, available)).To(Equal(discoveryv1alpha1.DemandsStatePartiallySatisfied))
+		})
+
+		It("should return Unsatisfied if not even the minimum resources are granted", func() {
+			dmnds.Minimum[gpu] = resource.MustParse("1")
+			Expect(demands.State(dmnds, available)).To(Equal(discoveryv1alpha1.DemandsStateUnsatisfied))
+		})
+	})
+
+	Describe("The Format function", func() {
+		It("should return a sorted representation of the resources", func() {
+			Expect(demands.Format(available)).To(Equal("cpu=8,memory=16Gi,pods=110"))
+		})
+
+		It("should return an empty string if no resources are given", func() {
+			Expect(demands.Format(nil)).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,258 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extendedresources
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+)
+
+// qualifiedNameMaxLength is the maximum length of the name segment of a qualified name (e.g., a resource name).
+const qualifiedNameMaxLength = 63
+
+// DefaultAttributeLabels are the node labels advertised by default as attributes of the extended resources,
+// corresponding to the ones set by the NVIDIA GPU feature discovery.
+var DefaultAttributeLabels = []string{
+	"nvidia.com/gpu.product",
+	"nvidia.com/gpu.memory",
+	"nvidia.com/gpu.family",
+	"nvidia.com/mig.strategy",
+}
+
+// IsExtendedResourceName returns whether the given resource is an extended resource, i.e., a resource whose name
+// is fully qualified outside of the kubernetes.io domain.
+func IsExtendedResourceName(name corev1.ResourceName) bool {
+	domain, _, found := strings.Cut(string(name), "/")
+	if !found || strings.HasPrefix(string(name), corev1.DefaultResourceRequestsPrefix) {
+		return false
+	}
+	return domain != "kubernetes.io" && !strings.HasSuffix(domain, ".kubernetes.io")
+}
+
+// Classes returns the classes of devices backing the extended resources exposed by the given nodes. The attributes
+// of each class are retrieved from the given node labels, limited to the ones sharing the domain of the resource
+// (e.g., nvidia.com/gpu.product for nvidia.com/gpu). Nodes exposing the same resource with the same attributes
+// are aggregated in the same class. If a resource is backed by multiple classes (e.g., multiple GPU models), each
+// of them is also assigned a class-qualified resource name, which allows targeting it specifically.
+func Classes(nodes []corev1.Node, attributeLabels []string) []sharingv1alpha1.ExtendedResourceClass {
+	classes := map[string]*sharingv1alpha1.ExtendedResourceClass{}
+
+	for i := range nodes {
+		for name, quantity := range nodes[i].Status.Allocatable {
+			if !IsExtendedResourceName(name) || quantity.Sign() <= 0 {
+				continue
+			}
+
+			attributes := attributesFor(name, nodes[i].GetLabels(), attributeLabels)
+			key := classKey(name, attributes)
+			if class, found := classes[key]; found {
+				class.Quantity.Add(quantity)
+				continue
+			}
+			classes[key] = &sharingv1alpha1.ExtendedResourceClass{Name: name, Attributes: attributes, Quantity: quantity.DeepCopy()}
+		}
+	}
+
+	keys := make([]string, 0, len(classes))
+	for key := range classes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]sharingv1alpha1.ExtendedResourceClass, 0, len(keys))
+	counts := map[corev1.ResourceName]int{}
+	for _, key := range keys {
+		result = append(result, *classes[key])
+		counts[classes[key].Name]++
+	}
+
+	for i := range result {
+		if counts[result[i].Name] > 1 {
+			result[i].ResourceName = qualifiedName(result[i].Name, result[i].Attributes)
+		}
+	}
+	return result
+}
+
+// Filter returns the classes of devices backing the extended resources which are actually granted.
+func Filter(classes []sharingv1alpha1.ExtendedResourceClass, granted corev1.ResourceList) []sharingv1alpha1.ExtendedResourceClass {
+	filtered := []sharingv1alpha1.ExtendedResourceClass{}
+	for i := range classes {
+		if quantity, found := granted[classes[i].Name]; found && quantity.Sign() > 0 {
+			filtered = append(filtered, classes[i])
+		}
+	}
+	return filtered
+}
+
+// Grant returns the given granted resources, complemented with the quota of the classes of devices offered under
+// a class-qualified name. The quota of each class is capped by the one granted for the corresponding extended resource,
+// which keeps being enforced on the aggregate of all its classes.
+func Grant(classes []sharingv1alpha1.ExtendedResourceClass, granted corev1.ResourceList) corev1.ResourceList {
+	result := granted.DeepCopy()
+	for i := range classes {
+		aggregate, found := granted[classes[i].Name]
+		if classes[i].ResourceName == "" || !found || aggregate.Sign() <= 0 {
+			continue
+		}
+
+		quantity := classes[i].Quantity.DeepCopy()
+		if quantity.Cmp(aggregate) > 0 {
+			quantity = aggregate.DeepCopy()
+		}
+		result[classes[i].ResourceName] = quantity
+	}
+	return result
+}
+
+// Aggregate returns the given resources, where the class-qualified extended resources are also accounted
+// on the corresponding extended resources, to enforce the quota of the latter on the aggregate of all classes.
+func Aggregate(resources corev1.ResourceList, classes []sharingv1alpha1.ExtendedResourceClass) corev1.ResourceList {
+	result := resources.DeepCopy()
+	for i := range classes {
+		quantity, found := resources[classes[i].ResourceName]
+		if classes[i].ResourceName == "" || !found {
+			continue
+		}
+
+		total := quantity.DeepCopy()
+		if aggregate, ok := result[classes[i].Name]; ok {
+			total.Add(aggregate)
+		}
+		result[classes[i].Name] = total
+	}
+	return result
+}
+
+// Translate replaces, in the given pod specification, the class-qualified extended resources with the corresponding
+// extended resources, and constrains the pod to the nodes exposing the devices of the requested classes, through a
+// node selector matching their attributes.
+func Translate(spec *corev1.PodSpec, classes []sharingv1alpha1.ExtendedResourceClass) {
+	for i := range classes {
+		if classes[i].ResourceName == "" {
+			continue
+		}
+
+		translated := false
+		for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for j := range containers {
+				requests := renameResource(containers[j].Resources.Requests, classes[i].ResourceName, classes[i].Name)
+				limits := renameResource(containers[j].Resources.Limits, classes[i].ResourceName, classes[i].Name)
+				translated = translated || requests || limits
+			}
+		}
+
+		if !translated || len(classes[i].Attributes) == 0 {
+			continue
+		}
+		if spec.NodeSelector == nil {
+			spec.NodeSelector = map[string]string{}
+		}
+		for key, value := range classes[i].Attributes {
+			spec.NodeSelector[key] = value
+		}
+	}
+}
+
+// NodeLabels returns the labels to be added to a virtual node to describe the given classes of devices.
+// Each attribute is mapped to a label with the same key, provided that it has the same value for all the
+// classes defining it: attributes with different values (e.g., multiple GPU models) are omitted, since they
+// cannot be expressed by a single label. In that case, a specific class can be targeted by requesting the
+// corresponding class-qualified resource, exposed by the virtual node in addition to the aggregated one.
+func NodeLabels(classes []sharingv1alpha1.ExtendedResourceClass) map[string]string {
+	labels := map[string]string{}
+	conflicting := map[string]struct{}{}
+
+	for i := range classes {
+		for key, value := range classes[i].Attributes {
+			if previous, found := labels[key]; found && previous != value {
+				conflicting[key] = struct{}{}
+			}
+			labels[key] = value
+		}
+	}
+
+	for key := range conflicting {
+		delete(labels, key)
+	}
+	return labels
+}
+
+// attributesFor returns the attributes of the given extended resource, retrieved from the given node labels.
+func attributesFor(name corev1.ResourceName, nodeLabels map[string]string, attributeLabels []string) map[string]string {
+	domain, _, _ := strings.Cut(string(name), "/")
+
+	var attributes map[string]string
+	for _, key := range attributeLabels {
+		value, found := nodeLabels[key]
+		if !found || !strings.HasPrefix(key, domain+"/") {
+			continue
+		}
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+		attributes[key] = value
+	}
+	return attributes
+}
+
+// classKey returns a key uniquely identifying the class of devices with the given name and attributes.
+func classKey(name corev1.ResourceName, attributes map[string]string) string {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(string(name))
+	for _, key := range keys {
+		builder.WriteString(";" + key + "=" + attributes[key])
+	}
+	return builder.String()
+}
+
+// qualifiedName returns the class-qualified name of the extended resource with the given name and attributes,
+// obtained appending a short hash of the latter to the former (e.g., nvidia.com/gpu-1a2b3c4d).
+func qualifiedName(name corev1.ResourceName, attributes map[string]string) corev1.ResourceName {
+	suffix := fmt.Sprintf("-%x", sha256.Sum256([]byte(classKey(name, attributes))))[:9]
+	domain, base, _ := strings.Cut(string(name), "/")
+	if len(base)+len(suffix) > qualifiedNameMaxLength {
+		base = base[:qualifiedNameMaxLength-len(suffix)]
+	}
+	return corev1.ResourceName(domain + "/" + base + suffix)
+}
+
+// renameResource renames the given resource in the given resource list, if present, returning whether it was found.
+func renameResource(resources corev1.ResourceList, from, to corev1.ResourceName) bool {
+	quantity, found := resources[from]
+	if !found {
+		return false
+	}
+
+	delete(resources, from)
+	total := quantity.DeepCopy()
+	if existing, found := resources[to]; found {
+		total.Add(existing)
+	}
+	resources[to] = total
+	return true
+}
@@ -0,0 +1,160 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extendedresources_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
+)
+
+const (
+	gpu     corev1.ResourceName = "nvidia.com/gpu"
+	fpga    corev1.ResourceName = "example.com/fpga"
+	product                     = "nvidia.com/gpu.product"
+	memory                      = "nvidia.com/gpu.memory"
+)
+
+var _ = Describe("Extended resources", func() {
+	node := func(labels map[string]string, allocatable corev1.ResourceList) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Status: corev1.NodeStatus{Allocatable: allocatable}}
+	}
+
+	DescribeTable("IsExtendedResourceName",
+		func(name corev1.ResourceName, expected bool) {
+			Expect(extendedresources.IsExtendedResourceName(name)).To(Equal(expected))
+		},
+		Entry("cpu", corev1.ResourceCPU, false),
+		Entry("hugepages", corev1.ResourceName("hugepages-2Mi"), false),
+		Entry("kubernetes.io domain", corev1.ResourceName("kubernetes.io/foo"), false),
+		Entry("kubernetes.io subdomain", corev1.ResourceName("example.kubernetes.io/foo"), false),
+		Entry("requests prefix", corev1.ResourceName("requests.nvidia.com/gpu"), false),
+		Entry("nvidia gpu", gpu, true),
+		Entry("mig device", corev1.ResourceName("nvidia.com/mig-1g.5gb"), true),
+	)
+
+	Describe("Classes", func() {
+		var (
+			nodes   []corev1.Node
+			classes []sharingv1alpha1.ExtendedResourceClass
+		)
+
+		BeforeEach(func() {
+			nodes = []corev1.Node{
+				node(map[string]string{product: "A100", memory: "40960", "other": "foo"},
+					corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8"), gpu: resource.MustParse("4")}),
+				node(map[string]string{product: "A100", memory: "40960"},
+					corev1.ResourceList{gpu: resource.MustParse("2"), fpga: resource.MustParse("1")}),
+				node(map[string]string{product: "T4"},
+					corev1.ResourceList{gpu: resource.MustParse("1")}),
+				node(map[string]string{product: "T4"},
+					corev1.ResourceList{gpu: resource.MustParse("0")}),
+			}
+			classes = extendedresources.Classes(nodes, append(extendedresources.DefaultAttributeLabels, "other"))
+		})
+
+		It("should aggregate the devices with the same attributes", func() {
+			Expect(classes).To(HaveLen(3))
+			Expect(classes[0].Name).To(Equal(fpga))
+			Expect(classes[0].Attributes).To(BeNil())
+			Expect(classes[0].Quantity.Value()).To(BeNumerically("==", 1))
+			Expect(classes[1].Name).To(Equal(gpu))
+			Expect(classes[1].Attributes).To(Equal(map[string]string{product: "A100", memory: "40960"}))
+			Expect(classes[1].Quantity.Value()).To(BeNumerically("==", 6))
+			Expect(classes[2].Name).To(Equal(gpu))
+			Expect(classes[2].Attributes).To(Equal(map[string]string{product: "T4"}))
+			Expect(classes[2].Quantity.Value()).To(BeNumerically("==", 1))
+		})
+
+		It("should assign a class-qualified name to the classes of the resources backed by multiple classes", func() {
+			Expect(classes[0].ResourceName).To(BeEmpty())
+			Expect(classes[1].ResourceName).To(MatchRegexp(`^nvidia\.com/gpu-[0-9a-f]{8}$`))
+			Expect(classes[2].ResourceName).To(MatchRegexp(`^nvidia\.com/gpu-[0-9a-f]{8}$`))
+			Expect(classes[1].ResourceName).ToNot(Equal(classes[2].ResourceName))
+		})
+
+		It("should assign the same class-qualified names across invocations", func() {
+			Expect(extendedresources.Classes(nodes, append(extendedresources.DefaultAttributeLabels, "other"))).To(Equal(classes))
+		})
+
+		It("should grant each class up to the quota of the corresponding extended resource", func() {
+			granted := extendedresources.Grant(classes, corev1.ResourceList{gpu: resource.MustParse("2"), fpga: resource.MustParse("1")})
+			Expect(granted).To(HaveLen(4))
+			Expect(granted.Name(gpu, resource.DecimalSI).Value()).To(BeNumerically("==", 2))
+			Expect(granted.Name(fpga, resource.DecimalSI).Value()).To(BeNumerically("==", 1))
+			Expect(granted.Name(classes[1].ResourceName, resource.DecimalSI).Value()).To(BeNumerically("==", 2))
+			Expect(granted.Name(classes[2].ResourceName, resource.DecimalSI).Value()).To(BeNumerically("==", 1))
+		})
+
+		It("should not grant the classes of the extended resources which are not granted", func() {
+			Expect(extendedresources.Grant(classes, corev1.ResourceList{fpga: resource.MustParse("1")})).To(HaveLen(1))
+		})
+
+		It("should account the class-qualified resources on the aggregated ones", func() {
+			aggregated := extendedresources.Aggregate(corev1.ResourceList{
+				gpu:                     resource.MustParse("1"),
+				classes[1].ResourceName: resource.MustParse("2"),
+				classes[2].ResourceName: resource.MustParse("1"),
+			}, classes)
+			Expect(aggregated).To(HaveLen(3))
+			Expect(aggregated.Name(gpu, resource.DecimalSI).Value()).To(BeNumerically("==", 4))
+			Expect(aggregated.Name(classes[1].ResourceName, resource.DecimalSI).Value()).To(BeNumerically("==", 2))
+		})
+
+		It("should translate the class-qualified resources requested by a pod", func() {
+			spec := corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "foo", Resources: corev1.ResourceRequirements{
+					Limits:   corev1.ResourceList{classes[2].ResourceName: resource.MustParse("1")},
+					Requests: corev1.ResourceList{classes[2].ResourceName: resource.MustParse("1")},
+				}},
+				{Name: "bar", Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}},
+			}}
+			extendedresources.Translate(&spec, classes)
+			Expect(spec.Containers[0].Resources.Limits).To(Equal(corev1.ResourceList{gpu: resource.MustParse("1")}))
+			Expect(spec.Containers[0].Resources.Requests).To(Equal(corev1.ResourceList{gpu: resource.MustParse("1")}))
+			Expect(spec.Containers[1].Resources.Limits).To(Equal(corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}))
+			Expect(spec.NodeSelector).To(Equal(map[string]string{product: "T4"}))
+		})
+
+		It("should not constrain the pods not requesting class-qualified resources", func() {
+			spec := corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{gpu: resource.MustParse("1")},
+			}}}}
+			extendedresources.Translate(&spec, classes)
+			Expect(spec.Containers[0].Resources.Limits).To(Equal(corev1.ResourceList{gpu: resource.MustParse("1")}))
+			Expect(spec.NodeSelector).To(BeNil())
+		})
+
+		It("should filter the classes which are not granted", func() {
+			filtered := extendedresources.Filter(classes, corev1.ResourceList{gpu: resource.MustParse("2"), fpga: resource.MustParse("0")})
+			Expect(filtered).To(HaveLen(2))
+			Expect(filtered[0].Name).To(Equal(gpu))
+			Expect(filtered[1].Name).To(Equal(gpu))
+		})
+
+		It("should omit the conflicting attributes from the node labels", func() {
+			Expect(extendedresources.NodeLabels(classes)).To(Equal(map[string]string{memory: "40960"}))
+		})
+
+		It("should map the attributes of a single class to the node labels", func() {
+			Expect(extendedresources.NodeLabels(classes[1:2])).To(Equal(map[string]string{product: "A100", memory: "40960"}))
+		})
+	})
+})
@@ -16,9 +16,11 @@ package pod
 
 import (
 	"reflect"
+	"sort"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	quotav1 "k8s.io/apiserver/pkg/quota/v1"
 	"k8s.io/utils/pointer"
 )
 
@@ -42,13 +44,16 @@ func IsPodReady(pod *corev1.Pod) (ready bool, reason string) {
 func IsPodSpecEqual(previous, updated *corev1.PodSpec) bool {
 	// The only fields that can be mutated are:
 	// * spec.containers[*].image
+	// * spec.containers[*].resources (in-place resize, if supported by the cluster)
 	// * spec.initContainers[*].image
 	// * spec.activeDeadlineSeconds
 	// * spec.tolerations (only new entries can be added)
+	// * spec.ephemeralContainers (only new entries can be added, through the corresponding subresource)
 	return AreContainersEqual(previous.Containers, updated.Containers) &&
 		AreContainersEqual(previous.InitContainers, updated.InitContainers) &&
 		pointer.Int64Equal(previous.ActiveDeadlineSeconds, updated.ActiveDeadlineSeconds) &&
-		len(previous.Tolerations) == len(updated.Tolerations)
+		len(previous.Tolerations) == len(updated.Tolerations) &&
+		len(previous.EphemeralContainers) == len(updated.EphemeralContainers)
 }
 
 // CheckShadowPodUpdate returns whether updated equals previous, except for the fields that are allowed to be updated.
@@ -56,22 +61,87 @@ func IsPodSpecEqual(previous, updated *corev1.PodSpec) bool {
 func CheckShadowPodUpdate(previous, updated *corev1.PodSpec) bool {
 	// The only fields that can be mutated are:
 	// * spec.containers[*].image
+	// * spec.containers[*].resources (in-place resize)
 	// * spec.initContainers[*].image
 	// * spec.activeDeadlineSeconds
 	// * spec.tolerations (only new entries can be added)
+	// * spec.ephemeralContainers (only new entries can be added)
+	if len(previous.Containers) != len(updated.Containers) || len(previous.InitContainers) != len(updated.InitContainers) {
+		return false
+	}
+	if !AreEphemeralContainersAppended(previous.EphemeralContainers, updated.EphemeralContainers) {
+		return false
+	}
 	for i := range updated.Containers {
 		updated.Containers[i].Image = previous.Containers[i].Image
+		updated.Containers[i].Resources = previous.Containers[i].Resources
 	}
 	for i := range updated.InitContainers {
 		updated.InitContainers[i].Image = previous.InitContainers[i].Image
 	}
 	updated.ActiveDeadlineSeconds = previous.ActiveDeadlineSeconds
 	updated.Tolerations = previous.Tolerations
+	updated.EphemeralContainers = previous.EphemeralContainers
 	return reflect.DeepEqual(previous, updated)
 }
 
+// AreEphemeralContainersAppended returns whether the updated ephemeral containers are obtained by appending new entries
+// to the previous ones, as the existing ephemeral containers can be neither modified nor removed.
+func AreEphemeralContainersAppended(previous, updated []corev1.EphemeralContainer) bool {
+	if len(updated) < len(previous) {
+		return false
+	}
+	return reflect.DeepEqual(previous, updated[:len(previous)])
+}
+
+// MutatePodSpec updates the target pod specifications with the fields of the desired ones that can be modified after
+// start-up time, leaving the other ones untouched. The resources of the containers are updated only if resize is true,
+// as in-place resize is supported only by recent Kubernetes versions with the InPlacePodVerticalScaling feature enabled.
+func MutatePodSpec(target, desired *corev1.PodSpec, resize bool) {
+	MutateContainers(target.Containers, desired.Containers, resize)
+	// The resources of init containers cannot be modified, as they already completed their execution.
+	MutateContainers(target.InitContainers, desired.InitContainers, false)
+
+	// The active deadline can be set or decreased, but not removed.
+	if desired.ActiveDeadlineSeconds != nil {
+		target.ActiveDeadlineSeconds = desired.ActiveDeadlineSeconds
+	}
+
+	// Tolerations can be only appended, hence preserving the existing ones (e.g., added by admission plugins).
+	for i := range desired.Tolerations {
+		if !containsToleration(target.Tolerations, &desired.Tolerations[i]) {
+			target.Tolerations = append(target.Tolerations, desired.Tolerations[i])
+		}
+	}
+}
+
+// MutateContainers updates the target containers with the fields of the desired ones (matched by name) that can be modified
+// after start-up time (i.e. the image and, if resize is true, the resource requirements), leaving the other ones untouched.
+func MutateContainers(target, desired []corev1.Container, resize bool) {
+	for i := range target {
+		for j := range desired {
+			if target[i].Name == desired[j].Name {
+				target[i].Image = desired[j].Image
+				if resize {
+					target[i].Resources = *desired[j].Resources.DeepCopy()
+				}
+				break
+			}
+		}
+	}
+}
+
+func containsToleration(tolerations []corev1.Toleration, toleration *corev1.Toleration) bool {
+	for i := range tolerations {
+		if tolerations[i].MatchToleration(toleration) {
+			return true
+		}
+	}
+	return false
+}
+
 // AreContainersEqual returns whether two container lists are equal according to the
-// fields that can be modified after start-up time (i.e. the image field).
+// fields that can be modified after start-up time (i.e. the image and the resources fields).
 func AreContainersEqual(previous, updated []corev1.Container) bool {
 	if len(previous) != len(updated) {
 		return false
@@ -81,7 +151,7 @@ outer:
 	for i := range previous {
 		for j := range updated {
 			if previous[i].Name == updated[j].Name {
-				if previous[i].Image == updated[j].Image {
+				if previous[i].Image == updated[j].Image && AreResourceRequirementsEqual(&previous[i].Resources, &updated[j].Resources) {
 					continue outer
 				}
 				return false
@@ -93,6 +163,11 @@ outer:
 	return true
 }
 
+// AreResourceRequirementsEqual returns whether two resource requirements are equal, comparing the quantities semantically.
+func AreResourceRequirementsEqual(previous, updated *corev1.ResourceRequirements) bool {
+	return quotav1.Equals(previous.Requests, updated.Requests) && quotav1.Equals(previous.Limits, updated.Limits)
+}
+
 // ForgeContainerResources forges the container resource requirements, leaving unset the ones not specified.
 func ForgeContainerResources(cpuRequests, cpuLimits, ramRequests, ramLimits resource.Quantity) corev1.ResourceRequirements {
 	configure := func(rl corev1.ResourceList, key corev1.ResourceName, value resource.Quantity) {
@@ -123,3 +198,101 @@ func ServiceAccountName(pod *corev1.Pod) string {
 
 	return "default"
 }
+
+// ReferencedConfigMaps returns the names of the ConfigMaps referenced by the given pod spec,
+// either through volumes or environment variables.
+func ReferencedConfigMaps(spec *corev1.PodSpec) []string {
+	names := map[string]struct{}{}
+	add := func(name string) { names[name] = struct{}{} }
+
+	for i := range spec.Volumes {
+		volume := &spec.Volumes[i]
+		if volume.ConfigMap != nil {
+			add(volume.ConfigMap.Name)
+		}
+		if volume.Projected != nil {
+			for j := range volume.Projected.Sources {
+				if source := volume.Projected.Sources[j].ConfigMap; source != nil {
+					add(source.Name)
+				}
+			}
+		}
+	}
+
+	visitEnv(spec, func(env []corev1.EnvVar, envFrom []corev1.EnvFromSource) {
+		for i := range env {
+			if env[i].ValueFrom != nil && env[i].ValueFrom.ConfigMapKeyRef != nil {
+				add(env[i].ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
+		for i := range envFrom {
+			if envFrom[i].ConfigMapRef != nil {
+				add(envFrom[i].ConfigMapRef.Name)
+			}
+		}
+	})
+
+	return keys(names)
+}
+
+// ReferencedSecrets returns the names of the Secrets referenced by the given pod spec,
+// either through volumes, environment variables or image pull secrets.
+func ReferencedSecrets(spec *corev1.PodSpec) []string {
+	names := map[string]struct{}{}
+	add := func(name string) { names[name] = struct{}{} }
+
+	for i := range spec.Volumes {
+		volume := &spec.Volumes[i]
+		if volume.Secret != nil {
+			add(volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for j := range volume.Projected.Sources {
+				if source := volume.Projected.Sources[j].Secret; source != nil {
+					add(source.Name)
+				}
+			}
+		}
+	}
+
+	for i := range spec.ImagePullSecrets {
+		add(spec.ImagePullSecrets[i].Name)
+	}
+
+	visitEnv(spec, func(env []corev1.EnvVar, envFrom []corev1.EnvFromSource) {
+		for i := range env {
+			if env[i].ValueFrom != nil && env[i].ValueFrom.SecretKeyRef != nil {
+				add(env[i].ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for i := range envFrom {
+			if envFrom[i].SecretRef != nil {
+				add(envFrom[i].SecretRef.Name)
+			}
+		}
+	})
+
+	return keys(names)
+}
+
+// visitEnv invokes the visitor function for the environment of all containers of the given pod spec.
+func visitEnv(spec *corev1.PodSpec, visitor func(env []corev1.EnvVar, envFrom []corev1.EnvFromSource)) {
+	for i := range spec.InitContainers {
+		visitor(spec.InitContainers[i].Env, spec.InitContainers[i].EnvFrom)
+	}
+	for i := range spec.Containers {
+		visitor(spec.Containers[i].Env, spec.Containers[i].EnvFrom)
+	}
+	for i := range spec.EphemeralContainers {
+		visitor(spec.EphemeralContainers[i].Env, spec.EphemeralContainers[i].EnvFrom)
+	}
+}
+
+func keys(set map[string]struct{}) []string {
+	output := make([]string, 0, len(set))
+	for key := range set {
+		output = append(output, key)
+	}
+	sort.Strings(output)
+	return output
+}
@@ -17,6 +17,7 @@ package pod_test
 import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
 	"github.com/onsi/gomega/types"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -58,6 +59,10 @@ var _ = Describe("Pod utility functions", func() {
 		)
 	})
 
+	limits := func(cpu string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}}
+	}
+
 	Describe("The IsPodSpecEqual function", func() {
 		type TestCase struct {
 			previous corev1.PodSpec
@@ -90,6 +95,16 @@ var _ = Describe("Pod utility functions", func() {
 				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar"}, {Name: "bar", Image: "dif"}}},
 				expected: BeFalse(),
 			}),
+			Entry("container resources are different", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("100m")}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("200m")}}},
+				expected: BeFalse(),
+			}),
+			Entry("container resources are semantically equal", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("1")}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("1000m")}}},
+				expected: BeTrue(),
+			}),
 			Entry("init containers are different", TestCase{
 				previous: corev1.PodSpec{InitContainers: []corev1.Container{{Name: "foo", Image: "bar"}, {Name: "bar", Image: "baz"}}},
 				updated:  corev1.PodSpec{InitContainers: []corev1.Container{{Name: "foo", Image: "bar"}, {Name: "bar", Image: "dif"}}},
@@ -110,9 +125,166 @@ var _ = Describe("Pod utility functions", func() {
 				updated:  corev1.PodSpec{ActiveDeadlineSeconds: nil},
 				expected: BeFalse(),
 			}),
+			Entry("more ephemeral containers are present", TestCase{
+				previous: corev1.PodSpec{},
+				updated: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger"}}}},
+				expected: BeFalse(),
+			}),
 		)
 	})
 
+	Describe("The CheckShadowPodUpdate function", func() {
+		type TestCase struct {
+			previous corev1.PodSpec
+			updated  corev1.PodSpec
+			expected types.GomegaMatcher
+		}
+
+		debugger := func(name string) corev1.EphemeralContainer {
+			return corev1.EphemeralContainer{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: name, Image: "busybox"}}
+		}
+
+		DescribeTable("tests table",
+			func(c TestCase) {
+				Expect(pod.CheckShadowPodUpdate(&c.previous, &c.updated)).To(c.expected)
+			},
+			Entry("both specs are empty", TestCase{expected: BeTrue()}),
+			Entry("the image of a container is changed", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar"}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "baz"}}},
+				expected: BeTrue(),
+			}),
+			Entry("the resources of a container are changed", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("100m")}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("200m")}}},
+				expected: BeTrue(),
+			}),
+			Entry("a container is added", TestCase{
+				previous: corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar"}}},
+				updated:  corev1.PodSpec{Containers: []corev1.Container{{Name: "foo", Image: "bar"}, {Name: "bar", Image: "baz"}}},
+				expected: BeFalse(),
+			}),
+			Entry("the resources of an init container are changed", TestCase{
+				previous: corev1.PodSpec{InitContainers: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("100m")}}},
+				updated:  corev1.PodSpec{InitContainers: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("200m")}}},
+				expected: BeFalse(),
+			}),
+			Entry("the active deadline seconds are changed", TestCase{
+				previous: corev1.PodSpec{ActiveDeadlineSeconds: pointer.Int64(5)},
+				updated:  corev1.PodSpec{ActiveDeadlineSeconds: pointer.Int64(8)},
+				expected: BeTrue(),
+			}),
+			Entry("the restart policy is changed", TestCase{
+				previous: corev1.PodSpec{RestartPolicy: corev1.RestartPolicyAlways},
+				updated:  corev1.PodSpec{RestartPolicy: corev1.RestartPolicyNever},
+				expected: BeFalse(),
+			}),
+			Entry("an ephemeral container is added", TestCase{
+				previous: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{debugger("foo")}},
+				updated:  corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{debugger("foo"), debugger("bar")}},
+				expected: BeTrue(),
+			}),
+			Entry("an ephemeral container is removed", TestCase{
+				previous: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{debugger("foo"), debugger("bar")}},
+				updated:  corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{debugger("foo")}},
+				expected: BeFalse(),
+			}),
+			Entry("an ephemeral container is modified", TestCase{
+				previous: corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{debugger("foo")}},
+				updated:  corev1.PodSpec{EphemeralContainers: []corev1.EphemeralContainer{debugger("bar")}},
+				expected: BeFalse(),
+			}),
+		)
+	})
+
+	Describe("The MutatePodSpec function", func() {
+		var target, desired corev1.PodSpec
+		var resize bool
+
+		BeforeEach(func() {
+			target = corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "foo", Image: "foo:v1", Resources: limits("100m"), Env: []corev1.EnvVar{{Name: "FOO", Value: "foo"}}},
+					{Name: "bar", Image: "bar:v1", Resources: limits("100m")},
+				},
+				InitContainers:        []corev1.Container{{Name: "init", Image: "init:v1", Resources: limits("100m")}},
+				Tolerations:           []corev1.Toleration{{Key: "foo", Operator: corev1.TolerationOpExists}},
+				ActiveDeadlineSeconds: pointer.Int64(10),
+				RestartPolicy:         corev1.RestartPolicyAlways,
+			}
+			desired = corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "bar", Image: "bar:v2", Resources: limits("200m")},
+					{Name: "foo", Image: "foo:v2", Resources: limits("200m")},
+				},
+				InitContainers:        []corev1.Container{{Name: "init", Image: "init:v2", Resources: limits("200m")}},
+				Tolerations:           []corev1.Toleration{{Key: "bar", Operator: corev1.TolerationOpExists}},
+				ActiveDeadlineSeconds: pointer.Int64(5),
+				RestartPolicy:         corev1.RestartPolicyNever,
+			}
+		})
+
+		JustBeforeEach(func() { pod.MutatePodSpec(&target, &desired, resize) })
+
+		When("resize is disabled", func() {
+			BeforeEach(func() { resize = false })
+
+			It("should update the container images, preserving the other fields", func() {
+				Expect(target.Containers[0].Name).To(Equal("foo"))
+				Expect(target.Containers[0].Image).To(Equal("foo:v2"))
+				Expect(target.Containers[0].Env).To(ConsistOf(corev1.EnvVar{Name: "FOO", Value: "foo"}))
+				Expect(target.Containers[1].Name).To(Equal("bar"))
+				Expect(target.Containers[1].Image).To(Equal("bar:v2"))
+				Expect(target.InitContainers[0].Image).To(Equal("init:v2"))
+			})
+			It("should not update the container resources", func() {
+				Expect(target.Containers[0].Resources).To(Equal(limits("100m")))
+				Expect(target.Containers[1].Resources).To(Equal(limits("100m")))
+			})
+			It("should append the new tolerations", func() {
+				Expect(target.Tolerations).To(Equal([]corev1.Toleration{
+					{Key: "foo", Operator: corev1.TolerationOpExists},
+					{Key: "bar", Operator: corev1.TolerationOpExists},
+				}))
+			})
+			It("should update the active deadline seconds", func() {
+				Expect(target.ActiveDeadlineSeconds).To(PointTo(BeNumerically("==", 5)))
+			})
+			It("should not update the immutable fields", func() {
+				Expect(target.RestartPolicy).To(Equal(corev1.RestartPolicyAlways))
+			})
+		})
+
+		When("resize is enabled", func() {
+			BeforeEach(func() { resize = true })
+
+			It("should update the container resources", func() {
+				Expect(target.Containers[0].Resources).To(Equal(limits("200m")))
+				Expect(target.Containers[1].Resources).To(Equal(limits("200m")))
+			})
+			It("should not update the init container resources", func() {
+				Expect(target.InitContainers[0].Resources).To(Equal(limits("100m")))
+			})
+		})
+
+		When("the active deadline seconds are not set in the desired spec", func() {
+			BeforeEach(func() { desired.ActiveDeadlineSeconds = nil })
+
+			It("should preserve the current value", func() {
+				Expect(target.ActiveDeadlineSeconds).To(PointTo(BeNumerically("==", 10)))
+			})
+		})
+
+		When("the desired tolerations are already present", func() {
+			BeforeEach(func() { desired.Tolerations = target.Tolerations })
+
+			It("should not duplicate them", func() {
+				Expect(target.Tolerations).To(HaveLen(1))
+			})
+		})
+	})
+
 	Describe("The AreContainersReady function", func() {
 		type TestCase struct {
 			previous []corev1.Container
@@ -141,6 +313,11 @@ var _ = Describe("Pod utility functions", func() {
 				updated:  []corev1.Container{{Name: "bar", Image: "baz"}, {Name: "foo", Image: "dif"}},
 				expected: BeFalse(),
 			}),
+			Entry("the two lists have elements with different resources", TestCase{
+				previous: []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("100m")}},
+				updated:  []corev1.Container{{Name: "foo", Image: "bar", Resources: limits("200m")}},
+				expected: BeFalse(),
+			}),
 			Entry("the two lists have different lengths", TestCase{
 				previous: []corev1.Container{{Name: "foo", Image: "bar"}, {Name: "bar", Image: "baz"}},
 				updated:  []corev1.Container{{Name: "bar", Image: "baz"}},
@@ -173,4 +350,42 @@ var _ = Describe("Pod utility functions", func() {
 			}),
 		)
 	})
+
+	Describe("The ReferencedConfigMaps and ReferencedSecrets functions", func() {
+		var spec corev1.PodSpec
+
+		BeforeEach(func() {
+			spec = corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{Name: "cm", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "cm-volume"}}}},
+					{Name: "secret", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "secret-volume"}}},
+					{Name: "projected", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+						{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "cm-projected"}}},
+						{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "secret-projected"}}},
+					}}}},
+				},
+				InitContainers: []corev1.Container{{Name: "init", EnvFrom: []corev1.EnvFromSource{
+					{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "cm-envfrom"}}},
+					{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "secret-envfrom"}}},
+				}}},
+				Containers: []corev1.Container{{Name: "main", Env: []corev1.EnvVar{
+					{Name: "A", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "cm-volume"}, Key: "a"}}},
+					{Name: "B", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "secret-env"}, Key: "b"}}},
+					{Name: "C", Value: "c"},
+				}}},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "secret-pull"}},
+			}
+		})
+
+		It("should return all the referenced configmaps, without duplicates", func() {
+			Expect(pod.ReferencedConfigMaps(&spec)).To(Equal([]string{"cm-envfrom", "cm-projected", "cm-volume"}))
+		})
+
+		It("should return all the referenced secrets, without duplicates", func() {
+			Expect(pod.ReferencedSecrets(&spec)).To(Equal([]string{"secret-env", "secret-envfrom", "secret-projected", "secret-pull", "secret-volume"}))
+		})
+	})
 })
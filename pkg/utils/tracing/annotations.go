@@ -0,0 +1,77 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// AnnotationPrefix is the prefix of the annotations carrying the span context of the replicated objects
+// (e.g., tracing.liqo.io/traceparent).
+const AnnotationPrefix = "tracing.liqo.io/"
+
+var _ propagation.TextMapCarrier = AnnotationsCarrier(nil)
+
+// AnnotationsCarrier adapts the annotations of an object to be used as a carrier of the span context.
+type AnnotationsCarrier map[string]string
+
+// Get returns the value associated with the given key.
+func (ac AnnotationsCarrier) Get(key string) string {
+	return ac[AnnotationPrefix+key]
+}
+
+// Set stores the given key-value pair.
+func (ac AnnotationsCarrier) Set(key, value string) {
+	ac[AnnotationPrefix+key] = value
+}
+
+// Keys lists the keys stored in the carrier.
+func (ac AnnotationsCarrier) Keys() []string {
+	keys := make([]string, 0, len(ac))
+	for key := range ac {
+		if strings.HasPrefix(key, AnnotationPrefix) {
+			keys = append(keys, strings.TrimPrefix(key, AnnotationPrefix))
+		}
+	}
+	return keys
+}
+
+// InjectAnnotations stores the span context contained in ctx (if any) in the given annotations.
+// The annotations map is allocated if nil, and returned.
+func InjectAnnotations(ctx context.Context, annotations map[string]string) map[string]string {
+	carrier := AnnotationsCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string, len(carrier))
+	}
+	for key, value := range carrier {
+		annotations[key] = value
+	}
+	return annotations
+}
+
+// ExtractAnnotations returns a copy of ctx carrying the span context stored in the given annotations (if any),
+// so that the spans subsequently started are children of the one which originated the object.
+func ExtractAnnotations(ctx context.Context, annotations map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, AnnotationsCarrier(annotations))
+}
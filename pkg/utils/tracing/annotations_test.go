@@ -0,0 +1,96 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/liqotech/liqo/pkg/utils/tracing"
+)
+
+var _ = Describe("The annotations-based propagation of the span context", func() {
+	var (
+		ctx      context.Context
+		recorder *tracetest.SpanRecorder
+		provider *sdktrace.TracerProvider
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	Describe("the AnnotationsCarrier type", func() {
+		It("should prefix the keys", func() {
+			carrier := tracing.AnnotationsCarrier{"foo": "bar"}
+			carrier.Set("traceparent", "value")
+			Expect(carrier).To(HaveKeyWithValue(tracing.AnnotationPrefix+"traceparent", "value"))
+			Expect(carrier.Get("traceparent")).To(Equal("value"))
+			Expect(carrier.Keys()).To(ConsistOf("traceparent"))
+		})
+	})
+
+	Describe("the InjectAnnotations function", func() {
+		When("the context does not contain a valid span", func() {
+			It("should leave the annotations untouched", func() {
+				Expect(tracing.InjectAnnotations(ctx, nil)).To(BeNil())
+				Expect(tracing.InjectAnnotations(ctx, map[string]string{"foo": "bar"})).To(Equal(map[string]string{"foo": "bar"}))
+			})
+		})
+
+		When("the context contains a valid span", func() {
+			It("should store the span context in the annotations", func() {
+				spanCtx, span := provider.Tracer("test").Start(ctx, "span")
+				defer span.End()
+
+				annotations := tracing.InjectAnnotations(spanCtx, map[string]string{"foo": "bar"})
+				Expect(annotations).To(HaveKeyWithValue("foo", "bar"))
+				Expect(annotations).To(HaveKeyWithValue(tracing.AnnotationPrefix+"traceparent",
+					ContainSubstring(span.SpanContext().TraceID().String())))
+			})
+		})
+	})
+
+	Describe("the ExtractAnnotations function", func() {
+		It("should restore the injected span context as the remote parent", func() {
+			spanCtx, span := provider.Tracer("test").Start(ctx, "origin")
+			annotations := tracing.InjectAnnotations(spanCtx, nil)
+			span.End()
+
+			_, child := provider.Tracer("test").Start(tracing.ExtractAnnotations(ctx, annotations), "child")
+			child.End()
+
+			Expect(recorder.Ended()).To(HaveLen(2))
+			parent := recorder.Ended()[1].Parent()
+			Expect(parent.IsRemote()).To(BeTrue())
+			Expect(parent.TraceID()).To(Equal(span.SpanContext().TraceID()))
+			Expect(parent.SpanID()).To(Equal(span.SpanContext().SpanID()))
+		})
+
+		It("should return a context without span if no annotation is present", func() {
+			Expect(trace.SpanContextFromContext(tracing.ExtractAnnotations(ctx, map[string]string{"foo": "bar"})).IsValid()).To(BeFalse())
+		})
+	})
+})
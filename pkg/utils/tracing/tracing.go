@@ -0,0 +1,109 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/klog/v2"
+)
+
+// InstrumentationName is the name of the instrumentation library associated with the Liqo spans.
+const InstrumentationName = "github.com/liqotech/liqo"
+
+var (
+	otlpEndpoint  string
+	otlpInsecure  bool
+	samplingRatio = 1.0
+)
+
+// InitFlags initializes the flags to configure the OpenTelemetry tracing.
+func InitFlags(flagset *flag.FlagSet) {
+	if flagset == nil {
+		flagset = flag.CommandLine
+	}
+
+	flagset.StringVar(&otlpEndpoint, "tracing-otlp-endpoint", otlpEndpoint,
+		"The address (host:port) of the OTLP collector the spans are exported to (tracing is disabled if empty)")
+	flagset.BoolVar(&otlpInsecure, "tracing-otlp-insecure", otlpInsecure,
+		"Whether to disable the transport security towards the OTLP collector")
+	flagset.Float64Var(&samplingRatio, "tracing-sampling-ratio", samplingRatio,
+		"The fraction of the root spans to be sampled (the sampling decision of remote parents is always honored)")
+}
+
+// Init configures the global tracer provider to export the spans to the OTLP collector specified through the flags,
+// and the global propagator to the W3C trace context format. The returned function flushes the pending spans
+// and shuts down the provider, and shall be invoked before the program terminates.
+// In case no endpoint is configured, spans are not recorded, although the context is still propagated.
+func Init(ctx context.Context, component string) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if otlpEndpoint == "" {
+		klog.V(4).Info("OpenTelemetry tracing disabled, as no OTLP endpoint has been configured")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(otlpEndpoint)}
+	if otlpInsecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+	}
+
+	// Additional attributes (e.g., the cluster name) can be configured through the OTEL_RESOURCE_ATTRIBUTES environment variable.
+	res, err := resource.New(ctx, resource.WithFromEnv(), resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceNameKey.String(component)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure the tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	klog.Infof("OpenTelemetry tracing enabled, exporting the spans to %q", otlpEndpoint)
+	return provider.Shutdown, nil
+}
+
+// Start creates a new span with the given name and attributes, as a child of the one possibly stored in the context.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End completes the given span, recording the error (if any) and setting the status accordingly.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
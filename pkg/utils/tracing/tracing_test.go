@@ -0,0 +1,139 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	collectortracev1 "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracev1 "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+
+	"github.com/liqotech/liqo/pkg/utils/tracing"
+)
+
+// collector is an in-process OTLP collector, which records the received spans.
+type collector struct {
+	collectortracev1.UnimplementedTraceServiceServer
+
+	mu    sync.Mutex
+	spans []*tracev1.Span
+}
+
+func (c *collector) Export(_ context.Context, req *collectortracev1.ExportTraceServiceRequest) (*collectortracev1.ExportTraceServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			c.spans = append(c.spans, ss.GetSpans()...)
+		}
+	}
+	return &collectortracev1.ExportTraceServiceResponse{}, nil
+}
+
+func (c *collector) Spans() []*tracev1.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.spans
+}
+
+var _ = Describe("The OpenTelemetry tracing utilities", func() {
+	var (
+		ctx      context.Context
+		fs       flag.FlagSet
+		shutdown func(context.Context) error
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fs = *flag.NewFlagSet("test-flags", flag.PanicOnError)
+		tracing.InitFlags(&fs)
+	})
+
+	AfterEach(func() { Expect(shutdown(ctx)).To(Succeed()) })
+
+	When("no OTLP endpoint is configured", func() {
+		BeforeEach(func() {
+			var err error
+			shutdown, err = tracing.Init(ctx, "liqo-test")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should create non recording spans", func() {
+			_, span := tracing.Start(ctx, "span")
+			defer span.End()
+			Expect(span.IsRecording()).To(BeFalse())
+		})
+	})
+
+	When("an OTLP endpoint is configured", func() {
+		var (
+			server *grpc.Server
+			col    *collector
+		)
+
+		BeforeEach(func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+
+			col = &collector{}
+			server = grpc.NewServer()
+			collectortracev1.RegisterTraceServiceServer(server, col)
+			go func() { _ = server.Serve(listener) }()
+
+			utilruntime.Must(fs.Set("tracing-otlp-endpoint", listener.Addr().String()))
+			utilruntime.Must(fs.Set("tracing-otlp-insecure", "true"))
+
+			shutdown, err = tracing.Init(ctx, "liqo-test")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Stop()
+			otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		})
+
+		It("should export the spans to the collector", func() {
+			parent, span := tracing.Start(ctx, "parent", attribute.String("foo", "bar"))
+			_, child := tracing.Start(parent, "child")
+			tracing.End(child, errors.New("failure"))
+			tracing.End(span, nil)
+
+			// The shutdown function flushes the pending spans.
+			Expect(shutdown(ctx)).To(Succeed())
+
+			spans := col.Spans()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].GetName()).To(Equal("child"))
+			Expect(spans[0].GetStatus().GetCode()).To(Equal(tracev1.Status_STATUS_CODE_ERROR))
+			Expect(spans[1].GetName()).To(Equal("parent"))
+			Expect(spans[1].GetAttributes()).To(HaveLen(1))
+			Expect(spans[1].GetAttributes()[0].GetKey()).To(Equal("foo"))
+			Expect(spans[0].GetParentSpanId()).To(Equal(spans[1].GetSpanId()))
+			Expect(spans[0].GetTraceId()).To(Equal(spans[1].GetTraceId()))
+		})
+	})
+})
@@ -31,6 +31,7 @@ import (
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	liqoconst "github.com/liqotech/liqo/pkg/consts"
 	"github.com/liqotech/liqo/pkg/utils/maps"
+	"github.com/liqotech/liqo/pkg/utils/pod"
 )
 
 const (
@@ -59,6 +60,10 @@ type SASecretRetriever func(string) string
 // KubernetesServiceIPGetter defines the function to get the remapped IP associated with the local kubernetes.default service.
 type KubernetesServiceIPGetter func() string
 
+// VolumeGatewayEndpointGetter defines the function to get the remapped IP of the gateway exposing a given local PVC,
+// and whether the PVC is exposed by a gateway at all.
+type VolumeGatewayEndpointGetter func(claimName string) (string, bool)
+
 // LocalPod forges the object meta and status of the local pod, given the remote one.
 func LocalPod(local, remote *corev1.Pod, translator PodIPTranslator, restarts int32) *corev1.Pod {
 	return &corev1.Pod{
@@ -159,10 +164,14 @@ func RemoteShadowPod(local *corev1.Pod, remote *vkv1alpha1.ShadowPod,
 // RemotePodSpec forges the specs of the reflected pod specs, given the local ones.
 // It expects the local and remote objects to be deepcopies, as they are mutated.
 func RemotePodSpec(creation bool, local, remote *corev1.PodSpec, mutators ...RemotePodSpecMutator) corev1.PodSpec {
-	// Do not mutate the pod specifications after it has been created, since it is likely the modification
-	// would be rejected by the API server, as only a very limited set of fields can be mutated.
-	// Additionally, such modification would not be currently propagated by the remote ShadowPod controller.
+	// Ephemeral containers (e.g., added through kubectl debug) can be only appended, and are propagated as is.
+	remote.EphemeralContainers = local.EphemeralContainers
+
+	// Once the pod has been created, propagate only the fields which can be mutated in-place (e.g., container images
+	// and resources), since the modification of the other ones would be rejected by the API server.
 	if !creation {
+		local.Tolerations = RemoteTolerations(local.Tolerations)
+		pod.MutatePodSpec(remote, local, true)
 		return *remote
 	}
 
@@ -226,6 +235,13 @@ func APIServerSupportMutator(enabled bool, saName string, saSecretRetriever SASe
 	}
 }
 
+// RemoteAccessVolumesMutator is a mutator which implements the support to access the PVCs exposed by the local cluster through volume gateways.
+func RemoteAccessVolumesMutator(endpointRetriever VolumeGatewayEndpointGetter) RemotePodSpecMutator {
+	return func(remote *corev1.PodSpec) {
+		remote.Volumes = RemoteAccessVolumes(remote.Volumes, endpointRetriever)
+	}
+}
+
 // AntiAffinityPropagateMutator is a mutator which implements the support to propagate a given anti-affinity constraint.
 func AntiAffinityPropagateMutator(affinity *corev1.Affinity) RemotePodSpecMutator {
 	return func(remote *corev1.PodSpec) {
@@ -367,6 +383,25 @@ func RemoteVolumes(volumes []corev1.Volume, enableAPIServerSupport bool, saSecre
 	return volumes
 }
 
+// RemoteAccessVolumes forges the volumes for a reflected pod, replacing the ones referring to the PVCs exposed by the local cluster
+// with the NFS volumes served by the corresponding volume gateways.
+func RemoteAccessVolumes(volumes []corev1.Volume, endpointRetriever VolumeGatewayEndpointGetter) []corev1.Volume {
+	for i := range volumes {
+		claim := volumes[i].PersistentVolumeClaim
+		if claim == nil {
+			continue
+		}
+
+		if endpoint, found := endpointRetriever(claim.ClaimName); found {
+			volumes[i].VolumeSource = corev1.VolumeSource{
+				NFS: &corev1.NFSVolumeSource{Server: endpoint, Path: "/", ReadOnly: claim.ReadOnly},
+			}
+		}
+	}
+
+	return volumes
+}
+
 // LocalNodeStats forges the summary stats for the node managed by the virtual kubelet.
 func LocalNodeStats(pods []statsv1alpha1.PodStats) *statsv1alpha1.Summary {
 	now := metav1.Now()
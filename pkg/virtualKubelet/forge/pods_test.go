@@ -193,6 +193,59 @@ var _ = Describe("Pod forging", func() {
 			It("should not update the pod spec", func() {
 				Expect(output.Spec.Pod).To(Equal(corev1.PodSpec{}))
 			})
+
+			When("ephemeral containers are added to the local pod", func() {
+				BeforeEach(func() {
+					local.Spec.EphemeralContainers = []corev1.EphemeralContainer{{
+						EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"},
+						TargetContainerName:      "foo",
+					}}
+				})
+
+				It("should propagate the ephemeral containers", func() {
+					Expect(output.Spec.Pod.EphemeralContainers).To(Equal(local.Spec.EphemeralContainers))
+				})
+
+				It("should not update the other fields of the pod spec", func() {
+					Expect(output.Spec.Pod.TerminationGracePeriodSeconds).To(BeNil())
+				})
+			})
+
+			When("the mutable fields of the local pod are modified", func() {
+				BeforeEach(func() {
+					remote.Spec.Pod.Containers = []corev1.Container{{
+						Name: "foo", Image: "foo/bar:v0.1", Env: []corev1.EnvVar{{Name: "ENV", Value: "value"}}}}
+					local.Spec.Containers = []corev1.Container{{Name: "foo", Image: "foo/bar:v0.2",
+						Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}}}
+					local.Spec.Tolerations = []corev1.Toleration{
+						{Key: consts.VirtualNodeTolerationKey, Operator: corev1.TolerationOpExists},
+						{Key: "foo", Operator: corev1.TolerationOpExists},
+					}
+					local.Spec.ActiveDeadlineSeconds = pointer.Int64(30)
+				})
+
+				It("should propagate the updated container images and resources", func() {
+					Expect(output.Spec.Pod.Containers).To(HaveLen(1))
+					Expect(output.Spec.Pod.Containers[0].Image).To(Equal("foo/bar:v0.2"))
+					Expect(output.Spec.Pod.Containers[0].Resources).To(Equal(local.Spec.Containers[0].Resources))
+				})
+
+				It("should preserve the remaining container fields", func() {
+					Expect(output.Spec.Pod.Containers[0].Env).To(ConsistOf(corev1.EnvVar{Name: "ENV", Value: "value"}))
+				})
+
+				It("should propagate the additional tolerations, except the virtual node one", func() {
+					Expect(output.Spec.Pod.Tolerations).To(ConsistOf(corev1.Toleration{Key: "foo", Operator: corev1.TolerationOpExists}))
+				})
+
+				It("should propagate the active deadline seconds", func() {
+					Expect(output.Spec.Pod.ActiveDeadlineSeconds).To(PointTo(BeNumerically("==", 30)))
+				})
+
+				It("should not update the other fields of the pod spec", func() {
+					Expect(output.Spec.Pod.TerminationGracePeriodSeconds).To(BeNil())
+				})
+			})
 		})
 	})
 
@@ -528,6 +581,39 @@ var _ = Describe("Pod forging", func() {
 		})
 	})
 
+	Describe("the RemoteAccessVolumes function", func() {
+		var volumes, output []corev1.Volume
+
+		BeforeEach(func() {
+			volumes = []corev1.Volume{
+				{Name: "first", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+				{Name: "second", VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "exposed", ReadOnly: true}}},
+				{Name: "third", VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "not-exposed"}}},
+			}
+		})
+
+		JustBeforeEach(func() {
+			output = forge.RemoteAccessVolumes(volumes, func(claimName string) (string, bool) {
+				return "10.200.0.42", claimName == "exposed"
+			})
+		})
+
+		It("should propagate the volumes not referring to exposed claims", func() {
+			Expect(output).To(HaveLen(3))
+			Expect(output[0].ConfigMap).ToNot(BeNil())
+			Expect(output[2].PersistentVolumeClaim).To(PointTo(Equal(corev1.PersistentVolumeClaimVolumeSource{ClaimName: "not-exposed"})))
+		})
+
+		It("should replace the volumes referring to exposed claims with the corresponding NFS volumes", func() {
+			Expect(output).To(HaveLen(3))
+			Expect(output[1].Name).To(Equal("second"))
+			Expect(output[1].VolumeSource).To(Equal(corev1.VolumeSource{
+				NFS: &corev1.NFSVolumeSource{Server: "10.200.0.42", Path: "/", ReadOnly: true}}))
+		})
+	})
+
 	Describe("the RemoteHostAliasesAPIServerSupport function", func() {
 		var aliases, output []corev1.HostAlias
 
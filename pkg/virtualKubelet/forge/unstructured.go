@@ -0,0 +1,73 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge
+
+import (
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// unstructuredMetadataField is the name of the field containing the object metadata.
+	unstructuredMetadataField = "metadata"
+	// unstructuredStatusField is the name of the field containing the object status.
+	unstructuredStatusField = "status"
+)
+
+// RemoteUnstructured forges the apply patch for the reflected object of an arbitrary resource type, given the local one.
+// All top-level fields are reflected verbatim, except for the metadata (which is forged as for the other resources),
+// the status (which is managed by the remote cluster) and the given excluded fields (each one represented as a path).
+func RemoteUnstructured(local *unstructured.Unstructured, targetNamespace string, excludedFields [][]string) *unstructured.Unstructured {
+	remote := &unstructured.Unstructured{Object: make(map[string]interface{}, len(local.Object))}
+	for key, value := range local.Object {
+		if key == unstructuredMetadataField || key == unstructuredStatusField {
+			continue
+		}
+		remote.Object[key] = runtime.DeepCopyJSONValue(value)
+	}
+
+	remote.SetName(local.GetName())
+	remote.SetNamespace(targetNamespace)
+	remote.SetLabels(labels.Merge(local.GetLabels(), ReflectionLabels()))
+	remote.SetAnnotations(local.GetAnnotations())
+
+	for _, field := range excludedFields {
+		unstructured.RemoveNestedField(remote.Object, field...)
+	}
+
+	return remote
+}
+
+// LocalUnstructuredStatus forges the apply patch to propagate the status of the remote object back to the local one.
+func LocalUnstructuredStatus(local, remote *unstructured.Unstructured) *unstructured.Unstructured {
+	patch := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	patch.SetAPIVersion(local.GetAPIVersion())
+	patch.SetKind(local.GetKind())
+	patch.SetName(local.GetName())
+	patch.SetNamespace(local.GetNamespace())
+
+	if status, found := remote.Object[unstructuredStatusField]; found {
+		patch.Object[unstructuredStatusField] = runtime.DeepCopyJSONValue(status)
+	}
+
+	return patch
+}
+
+// IsUnstructuredStatusEqual returns whether the status of the two given objects is equal.
+func IsUnstructuredStatusEqual(local, remote *unstructured.Unstructured) bool {
+	return equality.Semantic.DeepEqual(local.Object[unstructuredStatusField], remote.Object[unstructuredStatusField])
+}
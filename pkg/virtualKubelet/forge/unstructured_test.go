@@ -0,0 +1,148 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package forge_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+var _ = Describe("Unstructured Forging", func() {
+	var local, remote *unstructured.Unstructured
+
+	BeforeEach(func() {
+		local = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Example",
+			"metadata": map[string]interface{}{
+				"name": "name", "namespace": "original", "uid": "local-uid", "resourceVersion": "10",
+				"labels":      map[string]interface{}{"foo": "bar"},
+				"annotations": map[string]interface{}{"bar": "baz"},
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(3),
+				"template": map[string]interface{}{"image": "nginx", "secret": "local-secret"},
+			},
+			"status": map[string]interface{}{"ready": false},
+		}}
+		remote = &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Example",
+			"metadata":   map[string]interface{}{"name": "name", "namespace": "reflected"},
+			"status":     map[string]interface{}{"ready": true},
+		}}
+	})
+
+	Describe("the RemoteUnstructured function", func() {
+		var (
+			excluded [][]string
+			output   *unstructured.Unstructured
+		)
+
+		BeforeEach(func() { excluded = nil })
+		JustBeforeEach(func() { output = forge.RemoteUnstructured(local, "reflected", excluded) })
+
+		It("should correctly set the type information", func() {
+			Expect(output.GetAPIVersion()).To(Equal("example.com/v1"))
+			Expect(output.GetKind()).To(Equal("Example"))
+		})
+
+		It("should correctly set the name and namespace", func() {
+			Expect(output.GetName()).To(Equal("name"))
+			Expect(output.GetNamespace()).To(Equal("reflected"))
+		})
+
+		It("should not propagate the other metadata", func() {
+			Expect(output.GetUID()).To(BeEmpty())
+			Expect(output.GetResourceVersion()).To(BeEmpty())
+		})
+
+		It("should correctly set the labels", func() {
+			Expect(output.GetLabels()).To(HaveKeyWithValue("foo", "bar"))
+			Expect(output.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, LocalClusterID))
+			Expect(output.GetLabels()).To(HaveKeyWithValue(forge.LiqoDestinationClusterIDKey, RemoteClusterID))
+		})
+
+		It("should correctly set the annotations", func() {
+			Expect(output.GetAnnotations()).To(HaveKeyWithValue("bar", "baz"))
+		})
+
+		It("should correctly set the spec", func() {
+			Expect(output.Object).To(HaveKeyWithValue("spec", local.Object["spec"]))
+		})
+
+		It("should not set the status", func() {
+			Expect(output.Object).ToNot(HaveKey("status"))
+		})
+
+		It("should not mutate the local object", func() {
+			Expect(local.GetNamespace()).To(Equal("original"))
+			Expect(local.GetLabels()).ToNot(HaveKey(forge.LiqoOriginClusterIDKey))
+		})
+
+		When("some fields are excluded", func() {
+			BeforeEach(func() { excluded = [][]string{{"spec", "template", "secret"}, {"spec", "not-existing"}} })
+
+			It("should remove the excluded fields", func() {
+				Expect(output.Object).To(HaveKeyWithValue("spec", map[string]interface{}{
+					"replicas": int64(3),
+					"template": map[string]interface{}{"image": "nginx"},
+				}))
+			})
+
+			It("should not mutate the local object", func() {
+				Expect(local.Object["spec"]).To(HaveKeyWithValue("template", HaveKey("secret")))
+			})
+		})
+	})
+
+	Describe("the LocalUnstructuredStatus function", func() {
+		var output *unstructured.Unstructured
+
+		JustBeforeEach(func() { output = forge.LocalUnstructuredStatus(local, remote) })
+
+		It("should correctly set the type information", func() {
+			Expect(output.GetAPIVersion()).To(Equal("example.com/v1"))
+			Expect(output.GetKind()).To(Equal("Example"))
+		})
+
+		It("should correctly set the name and namespace", func() {
+			Expect(output.GetName()).To(Equal("name"))
+			Expect(output.GetNamespace()).To(Equal("original"))
+		})
+
+		It("should correctly set the status", func() {
+			Expect(output.Object).To(HaveKeyWithValue("status", map[string]interface{}{"ready": true}))
+		})
+
+		It("should not set the other fields", func() {
+			Expect(output.Object).ToNot(HaveKey("spec"))
+		})
+	})
+
+	Describe("the IsUnstructuredStatusEqual function", func() {
+		It("should return false if the status is different", func() {
+			Expect(forge.IsUnstructuredStatusEqual(local, remote)).To(BeFalse())
+		})
+
+		It("should return true if the status is equal", func() {
+			remote.Object["status"] = map[string]interface{}{"ready": false}
+			Expect(forge.IsUnstructuredStatusEqual(local, remote)).To(BeTrue())
+		})
+	})
+})
@@ -19,9 +19,18 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// NodeNetworkDegraded is the condition set on the virtual node when the quality of the connection towards
+// the remote cluster (i.e., latency and packet loss) does not comply with the configured thresholds.
+const NodeNetworkDegraded corev1.NodeConditionType = "NetworkDegraded"
+
+// NodeRemoteNodesUnavailable is the condition set on the virtual node when the fraction of nodes of the remote cluster
+// which are either not ready or unschedulable exceeds the configured threshold.
+const NodeRemoteNodesUnavailable corev1.NodeConditionType = "RemoteNodesUnavailable"
+
 const (
 	resourcesMessageSufficient   = "The remote cluster is advertising sufficient resources"
 	resourcesMessageInsufficient = "The remote cluster is advertising no/insufficient resources"
+	resourcesMessageRemoteNodes  = "The fraction of remote nodes under pressure exceeds the configured threshold"
 )
 
 // UnknownNodeConditions returns an array of node conditions with all unknown status.
@@ -32,6 +41,8 @@ func UnknownNodeConditions() []corev1.NodeCondition {
 		*unknownCondition(corev1.NodeDiskPressure),
 		*unknownCondition(corev1.NodePIDPressure),
 		*unknownCondition(corev1.NodeNetworkUnavailable),
+		*unknownCondition(NodeNetworkDegraded),
+		*unknownCondition(NodeRemoteNodesUnavailable),
 	}
 }
 
@@ -67,31 +78,40 @@ func nodeReadyStatus(ready bool) func() (corev1.ConditionStatus, string, string)
 }
 
 // nodeMemoryPressureStatus returns a function containing the condition information about the memory pressure status.
-func nodeMemoryPressureStatus(pressure bool) func() (corev1.ConditionStatus, string, string) {
+func nodeMemoryPressureStatus(insufficient, remote bool) func() (corev1.ConditionStatus, string, string) {
 	return func() (status corev1.ConditionStatus, reason, message string) {
-		if pressure {
+		if insufficient {
 			return corev1.ConditionTrue, "RemoteClusterHasMemoryPressure", resourcesMessageInsufficient
 		}
+		if remote {
+			return corev1.ConditionTrue, "RemoteNodesHaveMemoryPressure", resourcesMessageRemoteNodes
+		}
 		return corev1.ConditionFalse, "RemoteClusterHasSufficientMemory", resourcesMessageSufficient
 	}
 }
 
 // nodeDiskPressureStatus returns a function containing the condition information about the disk pressure status.
-func nodeDiskPressureStatus(pressure bool) func() (corev1.ConditionStatus, string, string) {
+func nodeDiskPressureStatus(insufficient, remote bool) func() (corev1.ConditionStatus, string, string) {
 	return func() (status corev1.ConditionStatus, reason, message string) {
-		if pressure {
+		if insufficient {
 			return corev1.ConditionTrue, "RemoteClusterHasDiskPressure", resourcesMessageInsufficient
 		}
+		if remote {
+			return corev1.ConditionTrue, "RemoteNodesHaveDiskPressure", resourcesMessageRemoteNodes
+		}
 		return corev1.ConditionFalse, "RemoteClusterHasNoDiskPressure", resourcesMessageSufficient
 	}
 }
 
 // nodePIDPressureStatus returns a function containing the condition information about the PID pressure status.
-func nodePIDPressureStatus(pressure bool) func() (corev1.ConditionStatus, string, string) {
+func nodePIDPressureStatus(insufficient, remote bool) func() (corev1.ConditionStatus, string, string) {
 	return func() (status corev1.ConditionStatus, reason, message string) {
-		if pressure {
+		if insufficient {
 			return corev1.ConditionTrue, "RemoteClusterHasPIDPressure", resourcesMessageInsufficient
 		}
+		if remote {
+			return corev1.ConditionTrue, "RemoteNodesHavePIDPressure", resourcesMessageRemoteNodes
+		}
 		return corev1.ConditionFalse, "RemoteClusterHasNoPIDPressure", resourcesMessageSufficient
 	}
 }
@@ -106,6 +126,29 @@ func nodeNetworkUnavailableStatus(unavailable bool) func() (corev1.ConditionStat
 	}
 }
 
+// nodeNetworkDegradedStatus returns a function containing the condition information about the networking quality.
+func nodeNetworkDegradedStatus(degraded bool) func() (corev1.ConditionStatus, string, string) {
+	return func() (status corev1.ConditionStatus, reason, message string) {
+		if degraded {
+			return corev1.ConditionTrue, "LiqoNetworkingDegraded", "The Liqo cluster interconnection exceeds the latency or packet loss thresholds"
+		}
+		return corev1.ConditionFalse, "LiqoNetworkingHealthy", "The Liqo cluster interconnection complies with the latency and packet loss thresholds"
+	}
+}
+
+// nodeRemoteNodesUnavailableStatus returns a function containing the condition information about the availability
+// of the remote nodes.
+func nodeRemoteNodesUnavailableStatus(unavailable bool) func() (corev1.ConditionStatus, string, string) {
+	return func() (status corev1.ConditionStatus, reason, message string) {
+		if unavailable {
+			return corev1.ConditionTrue, "RemoteNodesUnavailable",
+				"The fraction of not ready or unschedulable remote nodes exceeds the configured threshold"
+		}
+		return corev1.ConditionFalse, "RemoteNodesAvailable",
+			"The fraction of not ready or unschedulable remote nodes complies with the configured threshold"
+	}
+}
+
 // unknownCondition returns a new condition with unknown status.
 func unknownCondition(desired corev1.NodeConditionType) *corev1.NodeCondition {
 	return &corev1.NodeCondition{
@@ -42,6 +42,8 @@ var _ = Describe("Liqo node conditions generation", func() {
 			Describe("The NodeDiskPressure condition", DescribeBody(corev1.NodeDiskPressure))
 			Describe("The NodePIDPressure condition", DescribeBody(corev1.NodePIDPressure))
 			Describe("The NodeNetworkUnavailable condition", DescribeBody(corev1.NodeNetworkUnavailable))
+			Describe("The NodeNetworkDegraded condition", DescribeBody(NodeNetworkDegraded))
+			Describe("The NodeRemoteNodesUnavailable condition", DescribeBody(NodeRemoteNodesUnavailable))
 		})
 	})
 
@@ -151,37 +153,37 @@ var _ = Describe("Liqo node conditions generation", func() {
 				ExpectedMessage: "The Liqo Virtual Kubelet is currently not ready",
 			}),
 			Entry("of the memory pressure condition, when set", StatusGenerationCase{
-				Generator:       nodeMemoryPressureStatus(true),
+				Generator:       nodeMemoryPressureStatus(true, false),
 				ExpectedStatus:  corev1.ConditionTrue,
 				ExpectedReason:  "RemoteClusterHasMemoryPressure",
 				ExpectedMessage: "The remote cluster is advertising no/insufficient resources",
 			}),
 			Entry("of the memory pressure condition, when unset", StatusGenerationCase{
-				Generator:       nodeMemoryPressureStatus(false),
+				Generator:       nodeMemoryPressureStatus(false, false),
 				ExpectedStatus:  corev1.ConditionFalse,
 				ExpectedReason:  "RemoteClusterHasSufficientMemory",
 				ExpectedMessage: "The remote cluster is advertising sufficient resources",
 			}),
 			Entry("of the disk pressure condition, when set", StatusGenerationCase{
-				Generator:       nodeDiskPressureStatus(true),
+				Generator:       nodeDiskPressureStatus(true, false),
 				ExpectedStatus:  corev1.ConditionTrue,
 				ExpectedReason:  "RemoteClusterHasDiskPressure",
 				ExpectedMessage: "The remote cluster is advertising no/insufficient resources",
 			}),
 			Entry("of the disk pressure condition, when unset", StatusGenerationCase{
-				Generator:       nodeDiskPressureStatus(false),
+				Generator:       nodeDiskPressureStatus(false, false),
 				ExpectedStatus:  corev1.ConditionFalse,
 				ExpectedReason:  "RemoteClusterHasNoDiskPressure",
 				ExpectedMessage: "The remote cluster is advertising sufficient resources",
 			}),
 			Entry("of the PID pressure condition, when set", StatusGenerationCase{
-				Generator:       nodePIDPressureStatus(true),
+				Generator:       nodePIDPressureStatus(true, false),
 				ExpectedStatus:  corev1.ConditionTrue,
 				ExpectedReason:  "RemoteClusterHasPIDPressure",
 				ExpectedMessage: "The remote cluster is advertising no/insufficient resources",
 			}),
 			Entry("of the PID pressure condition, when unset", StatusGenerationCase{
-				Generator:       nodePIDPressureStatus(false),
+				Generator:       nodePIDPressureStatus(false, false),
 				ExpectedStatus:  corev1.ConditionFalse,
 				ExpectedReason:  "RemoteClusterHasNoPIDPressure",
 				ExpectedMessage: "The remote cluster is advertising sufficient resources",
@@ -198,6 +200,48 @@ var _ = Describe("Liqo node conditions generation", func() {
 				ExpectedReason:  "LiqoNetworkingUp",
 				ExpectedMessage: "The Liqo cluster interconnection is established",
 			}),
+			Entry("of the network degraded condition, when set", StatusGenerationCase{
+				Generator:       nodeNetworkDegradedStatus(true),
+				ExpectedStatus:  corev1.ConditionTrue,
+				ExpectedReason:  "LiqoNetworkingDegraded",
+				ExpectedMessage: "The Liqo cluster interconnection exceeds the latency or packet loss thresholds",
+			}),
+			Entry("of the network degraded condition, when unset", StatusGenerationCase{
+				Generator:       nodeNetworkDegradedStatus(false),
+				ExpectedStatus:  corev1.ConditionFalse,
+				ExpectedReason:  "LiqoNetworkingHealthy",
+				ExpectedMessage: "The Liqo cluster interconnection complies with the latency and packet loss thresholds",
+			}),
+			Entry("of the memory pressure condition, when set due to the remote nodes", StatusGenerationCase{
+				Generator:       nodeMemoryPressureStatus(false, true),
+				ExpectedStatus:  corev1.ConditionTrue,
+				ExpectedReason:  "RemoteNodesHaveMemoryPressure",
+				ExpectedMessage: "The fraction of remote nodes under pressure exceeds the configured threshold",
+			}),
+			Entry("of the disk pressure condition, when set due to the remote nodes", StatusGenerationCase{
+				Generator:       nodeDiskPressureStatus(false, true),
+				ExpectedStatus:  corev1.ConditionTrue,
+				ExpectedReason:  "RemoteNodesHaveDiskPressure",
+				ExpectedMessage: "The fraction of remote nodes under pressure exceeds the configured threshold",
+			}),
+			Entry("of the PID pressure condition, when set due to the remote nodes", StatusGenerationCase{
+				Generator:       nodePIDPressureStatus(false, true),
+				ExpectedStatus:  corev1.ConditionTrue,
+				ExpectedReason:  "RemoteNodesHavePIDPressure",
+				ExpectedMessage: "The fraction of remote nodes under pressure exceeds the configured threshold",
+			}),
+			Entry("of the remote nodes unavailable condition, when set", StatusGenerationCase{
+				Generator:       nodeRemoteNodesUnavailableStatus(true),
+				ExpectedStatus:  corev1.ConditionTrue,
+				ExpectedReason:  "RemoteNodesUnavailable",
+				ExpectedMessage: "The fraction of not ready or unschedulable remote nodes exceeds the configured threshold",
+			}),
+			Entry("of the remote nodes unavailable condition, when unset", StatusGenerationCase{
+				Generator:       nodeRemoteNodesUnavailableStatus(false),
+				ExpectedStatus:  corev1.ConditionFalse,
+				ExpectedReason:  "RemoteNodesAvailable",
+				ExpectedMessage: "The fraction of not ready or unschedulable remote nodes complies with the configured threshold",
+			}),
 		)
 	})
 
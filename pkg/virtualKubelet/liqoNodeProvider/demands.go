@@ -0,0 +1,49 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/demands"
+)
+
+// patchResourceDemands reports on the virtual node the resources demanded to the remote cluster, as well as to which
+// extent they have been granted. The annotations are removed in case no demands are configured.
+func (p *LiqoNodeProvider) patchResourceDemands(granted v1.ResourceList) error {
+	return p.patchNode(func(node *v1.Node) error {
+		forgeResourceDemandsAnnotations(node, p.resourceDemands, granted)
+		return nil
+	})
+}
+
+// forgeResourceDemandsAnnotations sets the annotations concerning the given demands on the node, comparing them
+// with the granted resources.
+func forgeResourceDemandsAnnotations(node *v1.Node, dmnds *discoveryv1alpha1.ResourceDemands, granted v1.ResourceList) {
+	state := demands.State(dmnds, granted)
+	if state == discoveryv1alpha1.DemandsStateNone {
+		delete(node.Annotations, consts.RequestedResourcesAnnotationKey)
+		delete(node.Annotations, consts.ResourceDemandsStateAnnotationKey)
+		return
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[consts.RequestedResourcesAnnotationKey] = demands.Format(dmnds.Desired)
+	node.Annotations[consts.ResourceDemandsStateAnnotationKey] = string(state)
+}
@@ -0,0 +1,70 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Resource demands", func() {
+	Describe("The forgeResourceDemandsAnnotations function", func() {
+		var (
+			node    corev1.Node
+			dmnds   *discoveryv1alpha1.ResourceDemands
+			granted corev1.ResourceList
+		)
+
+		BeforeEach(func() {
+			node = corev1.Node{}
+			dmnds = &discoveryv1alpha1.ResourceDemands{
+				Desired: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("8Gi")},
+			}
+			granted = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("8Gi")}
+		})
+
+		JustBeforeEach(func() { forgeResourceDemandsAnnotations(&node, dmnds, granted) })
+
+		When("resource demands are configured", func() {
+			It("should report the requested resources", func() {
+				Expect(node.Annotations).To(HaveKeyWithValue(consts.RequestedResourcesAnnotationKey, "cpu=4,memory=8Gi"))
+			})
+			It("should report the demands state", func() {
+				Expect(node.Annotations).To(HaveKeyWithValue(consts.ResourceDemandsStateAnnotationKey,
+					string(discoveryv1alpha1.DemandsStatePartiallySatisfied)))
+			})
+		})
+
+		When("resource demands are not configured", func() {
+			BeforeEach(func() {
+				dmnds = nil
+				node.Annotations = map[string]string{
+					consts.RequestedResourcesAnnotationKey:   "cpu=4",
+					consts.ResourceDemandsStateAnnotationKey: string(discoveryv1alpha1.DemandsStateSatisfied),
+					"foo":                                    "bar",
+				}
+			})
+
+			It("should remove the stale annotations", func() {
+				Expect(node.Annotations).To(Equal(map[string]string{"foo": "bar"}))
+			})
+		})
+	})
+})
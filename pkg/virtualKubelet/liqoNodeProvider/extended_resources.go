@@ -0,0 +1,31 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
+)
+
+// pruneExtendedResources removes from the given resource list the extended resources which are no longer offered,
+// since they would otherwise be retained by the virtual node (whose capacity is updated only for the offered ones).
+func pruneExtendedResources(resources, offered v1.ResourceList) {
+	for name := range resources {
+		if _, found := offered[name]; !found && extendedresources.IsExtendedResourceName(name) {
+			delete(resources, name)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Extended resources", func() {
+	Describe("The pruneExtendedResources function", func() {
+		const gpu corev1.ResourceName = "nvidia.com/gpu"
+		var resources corev1.ResourceList
+
+		BeforeEach(func() {
+			resources = corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("2"),
+				gpu:                resource.MustParse("1"),
+			}
+		})
+
+		It("should remove the extended resources which are no longer offered", func() {
+			pruneExtendedResources(resources, corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")})
+			Expect(resources).To(HaveKey(corev1.ResourceCPU))
+			Expect(resources).ToNot(HaveKey(gpu))
+		})
+
+		It("should retain the extended resources which are still offered", func() {
+			pruneExtendedResources(resources, corev1.ResourceList{gpu: resource.MustParse("2")})
+			Expect(resources).To(HaveKey(corev1.ResourceCPU))
+			Expect(resources).To(HaveKey(gpu))
+		})
+	})
+})
@@ -0,0 +1,81 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+// remoteNodesHealthStatus summarizes whether the fraction of remote nodes affected by a given problem exceeds the threshold.
+type remoteNodesHealthStatus struct {
+	MemoryPressure bool
+	DiskPressure   bool
+	PIDPressure    bool
+	// Unavailable is true if the remote nodes which are either not ready or unschedulable exceed the threshold.
+	Unavailable bool
+}
+
+// evaluateRemoteNodesHealth compares the health of the remote nodes with the given threshold (i.e., a fraction between
+// 0 and 1, with 0 disabling the check). A nil health summary is considered as healthy.
+func evaluateRemoteNodesHealth(health *sharingv1alpha1.NodesHealth, threshold float64) remoteNodesHealthStatus {
+	if health == nil || health.Total == 0 || threshold <= 0 {
+		return remoteNodesHealthStatus{}
+	}
+
+	exceeds := func(count int32) bool { return float64(count)/float64(health.Total) >= threshold }
+	return remoteNodesHealthStatus{
+		MemoryPressure: exceeds(health.MemoryPressure),
+		DiskPressure:   exceeds(health.DiskPressure),
+		PIDPressure:    exceeds(health.PIDPressure),
+		Unavailable:    exceeds(health.NotReady + health.Unschedulable),
+	}
+}
+
+// ensureRemoteNodesUnavailableTaint adds or removes the taint preventing new pods to be scheduled on the virtual node
+// when most of the remote nodes are unavailable. The pressure conditions, instead, are automatically translated into
+// the corresponding taints by the node lifecycle controller.
+func (p *LiqoNodeProvider) ensureRemoteNodesUnavailableTaint(unavailable bool) error {
+	if hasTaint(p.node, consts.RemoteNodesUnavailableTaintKey) == unavailable {
+		return nil
+	}
+
+	return p.patchNode(func(node *v1.Node) error {
+		taints := make([]v1.Taint, 0, len(node.Spec.Taints)+1)
+		for i := range node.Spec.Taints {
+			if node.Spec.Taints[i].Key != consts.RemoteNodesUnavailableTaintKey {
+				taints = append(taints, node.Spec.Taints[i])
+			}
+		}
+
+		if unavailable {
+			taints = append(taints, v1.Taint{Key: consts.RemoteNodesUnavailableTaintKey, Effect: v1.TaintEffectNoSchedule})
+		}
+		node.Spec.Taints = taints
+		return nil
+	})
+}
+
+// hasTaint returns whether the given node has a taint with the given key.
+func hasTaint(node *v1.Node, key string) bool {
+	for i := range node.Spec.Taints {
+		if node.Spec.Taints[i].Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
+)
+
+var _ = Describe("Remote nodes health", func() {
+	Describe("The evaluateRemoteNodesHealth function", func() {
+		health := &sharingv1alpha1.NodesHealth{
+			Total: 10, NotReady: 2, Unschedulable: 3, MemoryPressure: 6, DiskPressure: 1, PIDPressure: 5,
+		}
+
+		DescribeTable("Check the remote nodes health status",
+			func(health *sharingv1alpha1.NodesHealth, threshold float64, expected remoteNodesHealthStatus) {
+				Expect(evaluateRemoteNodesHealth(health, threshold)).To(Equal(expected))
+			},
+			Entry("when the thresholds are exceeded", health, 0.5,
+				remoteNodesHealthStatus{MemoryPressure: true, PIDPressure: true, Unavailable: true}),
+			Entry("when the thresholds are not exceeded", health, 0.8, remoteNodesHealthStatus{}),
+			Entry("when the check is disabled", health, 0.0, remoteNodesHealthStatus{}),
+			Entry("when the health summary is not available", nil, 0.5, remoteNodesHealthStatus{}),
+			Entry("when no remote nodes are present", &sharingv1alpha1.NodesHealth{}, 0.5, remoteNodesHealthStatus{}),
+		)
+	})
+})
@@ -0,0 +1,123 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	"errors"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/klog/v2"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+	liqonetutils "github.com/liqotech/liqo/pkg/liqonet/utils"
+)
+
+// NetworkThresholds are the thresholds above which the connection towards the remote cluster is considered degraded.
+type NetworkThresholds struct {
+	// MaxLatency is the maximum acceptable latency (0 to disable the check).
+	MaxLatency time.Duration
+	// MaxPacketLoss is the maximum acceptable packet loss ratio (0 to disable the check).
+	MaxPacketLoss float64
+}
+
+// Degraded returns whether the given measurements exceed the thresholds.
+func (nt *NetworkThresholds) Degraded(latency time.Duration, packetLoss float64) bool {
+	return (nt.MaxLatency > 0 && latency > nt.MaxLatency) ||
+		(nt.MaxPacketLoss > 0 && packetLoss > nt.MaxPacketLoss)
+}
+
+// forgeNetworkThresholds returns the network thresholds, overriding the defaults with the values specified
+// through the annotations of the ForeignCluster, if any.
+func forgeNetworkThresholds(defaults NetworkThresholds, annotations map[string]string) NetworkThresholds {
+	thresholds := defaults
+
+	if value, found := annotations[consts.MaxLatencyAnnotationKey]; found {
+		latency, err := time.ParseDuration(value)
+		if err != nil {
+			klog.Warningf("Invalid value %q for annotation %q: %v", value, consts.MaxLatencyAnnotationKey, err)
+		} else {
+			thresholds.MaxLatency = latency
+		}
+	}
+
+	if value, found := annotations[consts.MaxPacketLossAnnotationKey]; found {
+		packetLoss, err := liqonetutils.ParsePacketLoss(value)
+		if err != nil {
+			klog.Warningf("Invalid value %q for annotation %q: %v", value, consts.MaxPacketLossAnnotationKey, err)
+		} else {
+			thresholds.MaxPacketLoss = packetLoss
+		}
+	}
+
+	return thresholds
+}
+
+// connectionQuality returns the latency and packet loss measurements reported in the status of the given tunnel endpoint.
+// Values which are not available (e.g., since not yet measured) are returned as zero.
+func connectionQuality(tep *netv1alpha1.TunnelEndpoint) (latency time.Duration, packetLoss float64) {
+	if value := tep.Status.Connection.Latency.Value; value != "" && value != consts.NotApplicable {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			latency = parsed
+		}
+	}
+	if value := tep.Status.Connection.Quality.PacketLoss; value != "" {
+		if parsed, err := liqonetutils.ParsePacketLoss(value); err == nil {
+			packetLoss = parsed
+		}
+	}
+	return latency, packetLoss
+}
+
+func (p *LiqoNodeProvider) reconcileNodeFromForeignCluster(event watch.Event) error {
+	var fc discoveryv1alpha1.ForeignCluster
+	unstruct, ok := event.Object.(*unstructured.Unstructured)
+	if !ok {
+		return errors.New("error in casting foreign cluster: recreate watcher")
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstruct.Object, &fc); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	p.updateMutex.Lock()
+	defer p.updateMutex.Unlock()
+
+	annotations := fc.GetAnnotations()
+	p.resourceDemands = fc.Spec.ResourceDemands
+	if event.Type == watch.Deleted {
+		annotations = nil
+		p.resourceDemands = nil
+	}
+
+	if err := p.patchResourceDemands(p.node.Status.Capacity); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	thresholds := forgeNetworkThresholds(p.defaultNetworkThresholds, annotations)
+	if thresholds == p.networkThresholds {
+		return nil
+	}
+
+	klog.Infof("network thresholds updated: max latency %v, max packet loss %s",
+		thresholds.MaxLatency, liqonetutils.FormatPacketLoss(thresholds.MaxPacketLoss))
+	p.networkThresholds = thresholds
+	return p.updateNode()
+}
@@ -0,0 +1,93 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package liqonodeprovider
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
+	"github.com/liqotech/liqo/pkg/consts"
+)
+
+var _ = Describe("Network quality", func() {
+	Describe("The NetworkThresholds.Degraded function", func() {
+		thresholds := NetworkThresholds{MaxLatency: 100 * time.Millisecond, MaxPacketLoss: 0.05}
+
+		DescribeTable("Check the degraded status",
+			func(nt NetworkThresholds, latency time.Duration, packetLoss float64, expected bool) {
+				Expect(nt.Degraded(latency, packetLoss)).To(Equal(expected))
+			},
+			Entry("when within the thresholds", thresholds, 10*time.Millisecond, 0.0, false),
+			Entry("when the latency exceeds the threshold", thresholds, 200*time.Millisecond, 0.0, true),
+			Entry("when the packet loss exceeds the threshold", thresholds, 10*time.Millisecond, 0.1, true),
+			Entry("when the checks are disabled", NetworkThresholds{}, 200*time.Millisecond, 0.1, false),
+		)
+	})
+
+	Describe("The forgeNetworkThresholds function", func() {
+		defaults := NetworkThresholds{MaxLatency: 100 * time.Millisecond, MaxPacketLoss: 0.05}
+
+		When("no annotation is specified", func() {
+			It("should return the defaults", func() {
+				Expect(forgeNetworkThresholds(defaults, nil)).To(Equal(defaults))
+			})
+		})
+
+		When("the annotations are specified", func() {
+			It("should override the defaults", func() {
+				thresholds := forgeNetworkThresholds(defaults, map[string]string{
+					consts.MaxLatencyAnnotationKey:    "50ms",
+					consts.MaxPacketLossAnnotationKey: "10%",
+				})
+				Expect(thresholds.MaxLatency).To(Equal(50 * time.Millisecond))
+				Expect(thresholds.MaxPacketLoss).To(BeNumerically("~", 0.1))
+			})
+		})
+
+		When("the annotations are invalid", func() {
+			It("should return the defaults", func() {
+				thresholds := forgeNetworkThresholds(defaults, map[string]string{
+					consts.MaxLatencyAnnotationKey:    "invalid",
+					consts.MaxPacketLossAnnotationKey: "invalid",
+				})
+				Expect(thresholds).To(Equal(defaults))
+			})
+		})
+	})
+
+	Describe("The connectionQuality function", func() {
+		It("should parse the measurements from the tunnel endpoint status", func() {
+			tep := &netv1alpha1.TunnelEndpoint{Status: netv1alpha1.TunnelEndpointStatus{Connection: netv1alpha1.Connection{
+				Latency: netv1alpha1.ConnectionLatency{Value: "15ms"},
+				Quality: netv1alpha1.ConnectionQuality{PacketLoss: "20.0%"},
+			}}}
+			latency, packetLoss := connectionQuality(tep)
+			Expect(latency).To(Equal(15 * time.Millisecond))
+			Expect(packetLoss).To(BeNumerically("~", 0.2))
+		})
+
+		It("should return zero values if the measurements are not available", func() {
+			tep := &netv1alpha1.TunnelEndpoint{Status: netv1alpha1.TunnelEndpointStatus{Connection: netv1alpha1.Connection{
+				Latency: netv1alpha1.ConnectionLatency{Value: consts.NotApplicable},
+			}}}
+			latency, packetLoss := connectionQuality(tep)
+			Expect(latency).To(BeZero())
+			Expect(packetLoss).To(BeZero())
+		})
+	})
+})
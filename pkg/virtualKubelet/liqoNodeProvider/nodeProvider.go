@@ -24,6 +24,9 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 )
 
 // LiqoNodeProvider is a node provider that manages the Liqo resources.
@@ -43,6 +46,19 @@ type LiqoNodeProvider struct {
 	pingDisabled     bool
 
 	networkReady bool
+	// networkLatency and networkPacketLoss are the latest measurements of the connection towards the remote cluster.
+	networkLatency    time.Duration
+	networkPacketLoss float64
+	// networkThresholds are the thresholds currently enforced, i.e., the defaults possibly overridden by the ForeignCluster.
+	defaultNetworkThresholds NetworkThresholds
+	networkThresholds        NetworkThresholds
+
+	// remoteNodesHealth is the latest summary of the health of the remote nodes, as advertised in the ResourceOffer.
+	remoteNodesHealth             *sharingv1alpha1.NodesHealth
+	remoteNodesUnhealthyThreshold float64
+
+	// resourceDemands are the resources demanded to the remote cluster, as configured in the ForeignCluster.
+	resourceDemands *discoveryv1alpha1.ResourceDemands
 
 	onNodeChangeCallback func(*corev1.Node)
 	updateMutex          sync.Mutex
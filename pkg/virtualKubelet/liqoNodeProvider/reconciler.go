@@ -34,6 +34,7 @@ import (
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/utils/extendedresources"
 	"github.com/liqotech/liqo/pkg/utils/maps"
 )
 
@@ -173,10 +174,8 @@ func (p *LiqoNodeProvider) updateFromResourceOffer(resourceOffer *sharingv1alpha
 	p.updateMutex.Lock()
 	defer p.updateMutex.Unlock()
 
-	lbls := resourceOffer.Spec.Labels
-	if lbls == nil {
-		lbls = map[string]string{}
-	}
+	// The attributes of the extended resources are mirrored as labels, with the ones explicitly configured taking precedence.
+	lbls := maps.Merge(extendedresources.NodeLabels(resourceOffer.Spec.ExtendedResources), resourceOffer.Spec.Labels)
 	if len(resourceOffer.Spec.StorageClasses) == 0 {
 		lbls[consts.StorageAvailableLabel] = "false"
 	} else {
@@ -188,12 +187,28 @@ func (p *LiqoNodeProvider) updateFromResourceOffer(resourceOffer *sharingv1alpha
 		return err
 	}
 
+	// The patches are performed before modifying the status, since they replace the node with the one returned by the
+	// API server, which does not yet include the changes to the status.
+	if err := p.patchResourceDemands(resourceOffer.Spec.ResourceQuota.Hard); err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	p.remoteNodesHealth = resourceOffer.Spec.NodesHealth.DeepCopy()
+	remoteNodesHealth := evaluateRemoteNodesHealth(p.remoteNodesHealth, p.remoteNodesUnhealthyThreshold)
+	if err := p.ensureRemoteNodesUnavailableTaint(remoteNodesHealth.Unavailable); err != nil {
+		klog.Error(err)
+		return err
+	}
+
 	if p.node.Status.Capacity == nil {
 		p.node.Status.Capacity = v1.ResourceList{}
 	}
 	if p.node.Status.Allocatable == nil {
 		p.node.Status.Allocatable = v1.ResourceList{}
 	}
+	pruneExtendedResources(p.node.Status.Capacity, resourceOffer.Spec.ResourceQuota.Hard)
+	pruneExtendedResources(p.node.Status.Allocatable, resourceOffer.Spec.ResourceQuota.Hard)
 	for k, v := range resourceOffer.Spec.ResourceQuota.Hard {
 		p.node.Status.Capacity[k] = v
 		p.node.Status.Allocatable[k] = v
@@ -209,6 +224,8 @@ func (p *LiqoNodeProvider) updateFromTep(tep *netv1alpha1.TunnelEndpoint) error
 	p.updateMutex.Lock()
 	defer p.updateMutex.Unlock()
 
+	p.networkLatency, p.networkPacketLoss = connectionQuality(tep)
+
 	// if tep is not connected yet, return
 	if tep.Status.Connection.Status != netv1alpha1.Connected {
 		p.networkReady = false
@@ -220,12 +237,16 @@ func (p *LiqoNodeProvider) updateFromTep(tep *netv1alpha1.TunnelEndpoint) error
 
 func (p *LiqoNodeProvider) updateNode() error {
 	resourcesReady := areResourcesReady(p.node.Status.Allocatable)
+	remoteNodesHealth := evaluateRemoteNodesHealth(p.remoteNodesHealth, p.remoteNodesUnhealthyThreshold)
 
 	UpdateNodeCondition(p.node, v1.NodeReady, nodeReadyStatus(resourcesReady && p.networkReady))
-	UpdateNodeCondition(p.node, v1.NodeMemoryPressure, nodeMemoryPressureStatus(!resourcesReady))
-	UpdateNodeCondition(p.node, v1.NodeDiskPressure, nodeDiskPressureStatus(!resourcesReady))
-	UpdateNodeCondition(p.node, v1.NodePIDPressure, nodePIDPressureStatus(!resourcesReady))
+	UpdateNodeCondition(p.node, v1.NodeMemoryPressure, nodeMemoryPressureStatus(!resourcesReady, remoteNodesHealth.MemoryPressure))
+	UpdateNodeCondition(p.node, v1.NodeDiskPressure, nodeDiskPressureStatus(!resourcesReady, remoteNodesHealth.DiskPressure))
+	UpdateNodeCondition(p.node, v1.NodePIDPressure, nodePIDPressureStatus(!resourcesReady, remoteNodesHealth.PIDPressure))
+	UpdateNodeCondition(p.node, NodeRemoteNodesUnavailable, nodeRemoteNodesUnavailableStatus(remoteNodesHealth.Unavailable))
 	UpdateNodeCondition(p.node, v1.NodeNetworkUnavailable, nodeNetworkUnavailableStatus(!p.networkReady))
+	UpdateNodeCondition(p.node, NodeNetworkDegraded, nodeNetworkDegradedStatus(
+		p.networkReady && p.networkThresholds.Degraded(p.networkLatency, p.networkPacketLoss)))
 
 	p.onNodeChangeCallback(p.node.DeepCopy())
 	return nil
@@ -25,9 +25,11 @@ import (
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
 	netv1alpha1 "github.com/liqotech/liqo/apis/net/v1alpha1"
 	sharingv1alpha1 "github.com/liqotech/liqo/apis/sharing/v1alpha1"
 	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/discovery"
 )
 
 // StartProvider starts the provider with its infromers on Liqo resources.
@@ -50,11 +52,19 @@ func (p *LiqoNodeProvider) StartProvider(ctx context.Context) (ready chan struct
 	tepInformer := tepInformerFactory.ForResource(netv1alpha1.TunnelEndpointGroupVersionResource).Informer()
 	tepInformer.AddEventHandler(getEventHandler(p.reconcileNodeFromTep))
 
+	fcInformerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(p.dynClient, p.resyncPeriod, metav1.NamespaceAll,
+		func(opt *metav1.ListOptions) {
+			opt.LabelSelector = discovery.ClusterIDLabel + "=" + p.foreignClusterID
+		})
+	fcInformer := fcInformerFactory.ForResource(discoveryv1alpha1.ForeignClusterGroupVersionResource).Informer()
+	fcInformer.AddEventHandler(getEventHandler(p.reconcileNodeFromForeignCluster))
+
 	ready = make(chan struct{}, 1)
 	go func() {
 		<-ready
 		go sharingInformerFactory.Start(ctx.Done())
 		go tepInformerFactory.Start(ctx.Done())
+		go fcInformerFactory.Start(ctx.Done())
 		klog.Info("Liqo informers started")
 	}()
 
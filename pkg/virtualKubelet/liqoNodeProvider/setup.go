@@ -57,6 +57,11 @@ type InitConfig struct {
 	PodProviderStopper   chan struct{}
 	InformerResyncPeriod time.Duration
 	PingDisabled         bool
+
+	NetworkThresholds NetworkThresholds
+	// RemoteNodesUnhealthyThreshold is the fraction of remote nodes under pressure, not ready or unschedulable
+	// above which the corresponding conditions and taints are set on the virtual node (0 disables the check).
+	RemoteNodesUnhealthyThreshold float64
 }
 
 // NewLiqoNodeProvider creates and returns a new LiqoNodeProvider.
@@ -70,9 +75,13 @@ func NewLiqoNodeProvider(cfg *InitConfig) *LiqoNodeProvider {
 		terminating:       false,
 		lastAppliedLabels: map[string]string{},
 
-		networkReady: false,
-		resyncPeriod: cfg.InformerResyncPeriod,
-		pingDisabled: cfg.PingDisabled,
+		networkReady:             false,
+		defaultNetworkThresholds: cfg.NetworkThresholds,
+		networkThresholds:        cfg.NetworkThresholds,
+		resyncPeriod:             cfg.InformerResyncPeriod,
+		pingDisabled:             cfg.PingDisabled,
+
+		remoteNodesUnhealthyThreshold: cfg.RemoteNodesUnhealthyThreshold,
 
 		nodeName:         cfg.NodeName,
 		foreignClusterID: cfg.RemoteClusterID,
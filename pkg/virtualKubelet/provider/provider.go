@@ -18,13 +18,18 @@ import (
 	"context"
 	"time"
 
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
 	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
 
 	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
@@ -33,6 +38,7 @@ import (
 	"github.com/liqotech/liqo/pkg/liqonet/ipam"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/configuration"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/custom"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/exposition"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/namespacemap"
@@ -64,11 +70,15 @@ type InitConfig struct {
 	PersistenVolumeClaimWorkers uint
 	ConfigMapWorkers            uint
 	SecretWorkers               uint
+	CustomResourceWorkers       uint
+
+	CustomResources []*custom.Resource
 
 	EnableAPIServerSupport     bool
 	EnableStorage              bool
 	VirtualStorageClassName    string
 	RemoteRealStorageClassName string
+	EnableVolumeSnapshots      bool
 }
 
 // LiqoProvider implements the virtual-kubelet provider interface and stores pods in memory.
@@ -82,20 +92,24 @@ func NewLiqoProvider(ctx context.Context, cfg *InitConfig, eb record.EventBroadc
 	forge.Init(cfg.LocalCluster, cfg.RemoteCluster, cfg.NodeName, cfg.NodeIP)
 	localClient := kubernetes.NewForConfigOrDie(cfg.LocalConfig)
 	localLiqoClient := liqoclient.NewForConfigOrDie(cfg.LocalConfig)
+	localDynamicClient := dynamic.NewForConfigOrDie(cfg.LocalConfig)
 
 	remoteClient := kubernetes.NewForConfigOrDie(cfg.RemoteConfig)
 	remoteLiqoClient := liqoclient.NewForConfigOrDie(cfg.RemoteConfig)
+	remoteDynamicClient := dynamic.NewForConfigOrDie(cfg.RemoteConfig)
 	remoteMetricsClient := metrics.NewForConfigOrDie(cfg.RemoteConfig).MetricsV1beta1().PodMetricses
 
 	dialctx, cancel := context.WithTimeout(ctx, 10*time.Second)
-	connection, err := grpc.DialContext(dialctx, cfg.LiqoIpamServer, grpc.WithInsecure(), grpc.WithBlock())
+	connection, err := grpc.DialContext(dialctx, cfg.LiqoIpamServer, grpc.WithInsecure(), grpc.WithBlock(),
+		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()))
 	cancel()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to establish a connection to the IPAM")
 	}
 	ipamClient := ipam.NewIpamClient(connection)
 
-	reflectionManager := manager.New(localClient, remoteClient, localLiqoClient, remoteLiqoClient, cfg.InformerResyncPeriod, eb)
+	reflectionManager := manager.New(localClient, remoteClient, localLiqoClient, remoteLiqoClient,
+		localDynamicClient, remoteDynamicClient, cfg.InformerResyncPeriod, eb)
 	podreflector := workload.NewPodReflector(cfg.RemoteConfig, remoteMetricsClient, ipamClient, cfg.EnableAPIServerSupport, cfg.PodWorkers)
 	namespaceMapHandler := namespacemap.NewHandler(localLiqoClient, cfg.Namespace, cfg.InformerResyncPeriod)
 	reflectionManager.
@@ -109,6 +123,25 @@ func NewLiqoProvider(ctx context.Context, cfg *InitConfig, eb record.EventBroadc
 			cfg.VirtualStorageClassName, cfg.RemoteRealStorageClassName, cfg.EnableStorage)).
 		WithNamespaceHandler(namespaceMapHandler)
 
+	// The reflection of VolumeSnapshots is enabled only if the corresponding API is served by both clusters,
+	// as the reflection of the whole namespace would be otherwise prevented by the informers never synchronizing.
+	if cfg.EnableStorage && cfg.EnableVolumeSnapshots {
+		if volumeSnapshotsSupported(localClient) && volumeSnapshotsSupported(remoteClient) {
+			reflectionManager.With(storage.NewVolumeSnapshotReflector(cfg.PersistenVolumeClaimWorkers))
+		} else {
+			klog.Warning("VolumeSnapshot reflection disabled, as the snapshot.storage.k8s.io API is not available in both clusters")
+		}
+	}
+
+	// Similarly, the reflection of each custom resource is enabled only if the corresponding type is served by both clusters.
+	for _, resource := range cfg.CustomResources {
+		if !resourceSupported(localClient, resource.GroupVersionResource) || !resourceSupported(remoteClient, resource.GroupVersionResource) {
+			klog.Warningf("Reflection of %v disabled, as the resource type is not available in both clusters", resource.Name())
+			continue
+		}
+		reflectionManager.With(custom.NewCustomReflector(resource, cfg.CustomResourceWorkers))
+	}
+
 	reflectionManager.Start(ctx)
 
 	return &LiqoProvider{
@@ -121,3 +154,23 @@ func NewLiqoProvider(ctx context.Context, cfg *InitConfig, eb record.EventBroadc
 func (p *LiqoProvider) PodHandler() workload.PodHandler {
 	return p.podHandler
 }
+
+// volumeSnapshotsSupported returns whether the VolumeSnapshot API is served by the cluster the given client refers to.
+func volumeSnapshotsSupported(client kubernetes.Interface) bool {
+	_, err := client.Discovery().ServerResourcesForGroupVersion(snapshotv1.SchemeGroupVersion.String())
+	return err == nil
+}
+
+// resourceSupported returns whether the given resource type is served by the cluster the given client refers to.
+func resourceSupported(client kubernetes.Interface, gvr schema.GroupVersionResource) bool {
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if err != nil {
+		return false
+	}
+	for i := range resources.APIResources {
+		if resources.APIResources[i].Name == gvr.Resource {
+			return true
+		}
+	}
+	return false
+}
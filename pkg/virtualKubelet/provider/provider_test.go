@@ -0,0 +1,49 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provider
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Provider", func() {
+	Describe("The resourceSupported function", func() {
+		var client *fake.Clientset
+
+		BeforeEach(func() {
+			client = fake.NewSimpleClientset()
+			client.Resources = []*metav1.APIResourceList{{
+				GroupVersion: "example.com/v1",
+				APIResources: []metav1.APIResource{{Name: "foos", Namespaced: true, Kind: "Foo"}},
+			}}
+		})
+
+		It("should return true if the resource type is served", func() {
+			Expect(resourceSupported(client, schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "foos"})).To(BeTrue())
+		})
+
+		It("should return false if the resource type is not served, although the group version is", func() {
+			Expect(resourceSupported(client, schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "bars"})).To(BeFalse())
+		})
+
+		It("should return false if the group version is not served", func() {
+			Expect(resourceSupported(client, schema.GroupVersionResource{Group: "example.com", Version: "v2", Resource: "foos"})).To(BeFalse())
+		})
+	})
+})
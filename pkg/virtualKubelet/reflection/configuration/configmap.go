@@ -28,6 +28,7 @@ import (
 	"k8s.io/utils/trace"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	podutils "github.com/liqotech/liqo/pkg/utils/pod"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
@@ -71,12 +72,16 @@ func NewNamespacedConfigMapReflector(opts *options.NamespacedOpts) manager.Names
 	local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 	remote.Informer().AddEventHandler(opts.HandlerFactory(RemoteConfigMapNamespacedKeyer(opts.LocalNamespace)))
 
-	return &NamespacedConfigMapReflector{
+	reflector := &NamespacedConfigMapReflector{
 		NamespacedReflector:    generic.NewNamespacedReflector(opts, ConfigMapReflectorName),
 		localConfigMaps:        local.Lister().ConfigMaps(opts.LocalNamespace),
 		remoteConfigMaps:       remote.Lister().ConfigMaps(opts.RemoteNamespace),
 		remoteConfigMapsClient: opts.RemoteClient.CoreV1().ConfigMaps(opts.RemoteNamespace),
 	}
+
+	// Honor the reflection policies configured in the local namespace.
+	reflector.EnableReflectionPolicies(opts, local.Informer(), podutils.ReferencedConfigMaps)
+	return reflector
 }
 
 // RemoteRef returns the ObjectRef associated with the remote namespace.
@@ -106,9 +111,9 @@ func (ncr *NamespacedConfigMapReflector) Handle(ctx context.Context, name string
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is excluded by the reflection policy.
 	if !kerrors.IsNotFound(lerr) && ncr.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local ConfigMap %q as marked with the skip annotation", ncr.LocalRef(name))
+		klog.Infof("Skipping reflection of local ConfigMap %q as disabled by the skip annotation or the reflection policy", ncr.LocalRef(name))
 		ncr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
 		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
 			return nil
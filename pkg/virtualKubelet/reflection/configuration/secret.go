@@ -26,6 +26,7 @@ import (
 	"k8s.io/utils/trace"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	podutils "github.com/liqotech/liqo/pkg/utils/pod"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
@@ -64,13 +65,17 @@ func NewNamespacedSecretReflector(enableSAReflection bool) func(*options.Namespa
 		local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 		remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 
-		return &NamespacedSecretReflector{
+		reflector := &NamespacedSecretReflector{
 			NamespacedReflector: generic.NewNamespacedReflector(opts, SecretReflectorName),
 			localSecrets:        local.Lister().Secrets(opts.LocalNamespace),
 			remoteSecrets:       remote.Lister().Secrets(opts.RemoteNamespace),
 			remoteSecretsClient: opts.RemoteClient.CoreV1().Secrets(opts.RemoteNamespace),
 			enableSAReflection:  enableSAReflection,
 		}
+
+		// Honor the reflection policies configured in the local namespace.
+		reflector.EnableReflectionPolicies(opts, local.Informer(), podutils.ReferencedSecrets)
+		return reflector
 	}
 }
 
@@ -103,9 +108,9 @@ func (nsr *NamespacedSecretReflector) Handle(ctx context.Context, name string) e
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is excluded by the reflection policy.
 	if !kerrors.IsNotFound(lerr) && nsr.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local Secret %q as marked with the skip annotation", nsr.LocalRef(name))
+		klog.Infof("Skipping reflection of local Secret %q as disabled by the skip annotation or the reflection policy", nsr.LocalRef(name))
 		nsr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
 		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
 			return nil
@@ -0,0 +1,71 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/cache"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	"github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+const (
+	LocalNamespace  = "local-namespace"
+	RemoteNamespace = "remote-namespace"
+
+	LocalClusterID    = "local-cluster-id"
+	LocalClusterName  = "local-cluster-name"
+	RemoteClusterID   = "remote-cluster-id"
+	RemoteClusterName = "remote-cluster-name"
+
+	LiqoNodeName = "local-node"
+	LiqoNodeIP   = "1.1.1.1"
+)
+
+var (
+	ctx    context.Context
+	cancel context.CancelFunc
+)
+
+func TestCustom(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Custom Reflection Suite")
+}
+
+var _ = BeforeSuite(func() {
+	testutil.LogsToGinkgoWriter()
+
+	local := discoveryv1alpha1.ClusterIdentity{ClusterID: LocalClusterID, ClusterName: LocalClusterName}
+	remote := discoveryv1alpha1.ClusterIdentity{ClusterID: RemoteClusterID, ClusterName: RemoteClusterName}
+	forge.Init(local, remote, LiqoNodeName, LiqoNodeIP)
+})
+
+var _ = BeforeEach(func() { ctx, cancel = context.WithCancel(context.Background()) })
+var _ = AfterEach(func() { cancel() })
+
+var FakeEventHandler = func(options.Keyer) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(_ interface{}) {},
+		UpdateFunc: func(_, obj interface{}) {},
+		DeleteFunc: func(_ interface{}) {},
+	}
+}
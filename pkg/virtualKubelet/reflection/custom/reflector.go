@@ -0,0 +1,178 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+// NamespacedCustomReflector manages the reflection of an arbitrary resource type.
+type NamespacedCustomReflector struct {
+	generic.NamespacedReflector
+
+	resource *Resource
+
+	localObjects  cache.GenericNamespaceLister
+	remoteObjects cache.GenericNamespaceLister
+	localClient   dynamic.ResourceInterface
+	remoteClient  dynamic.ResourceInterface
+}
+
+// NewCustomReflector builds a reflector for the given arbitrary resource type.
+func NewCustomReflector(resource *Resource, workers uint) manager.Reflector {
+	return generic.NewReflector(resource.Name(), NewNamespacedCustomReflectorFactory(resource), generic.WithoutFallback(), workers)
+}
+
+// NewNamespacedCustomReflectorFactory returns a function generating NamespacedCustomReflector instances for the given resource type.
+func NewNamespacedCustomReflectorFactory(resource *Resource) generic.NamespacedReflectorFactoryFunc {
+	return func(opts *options.NamespacedOpts) manager.NamespacedReflector {
+		return NewNamespacedCustomReflector(opts, resource)
+	}
+}
+
+// NewNamespacedCustomReflector returns a new NamespacedCustomReflector instance for the given resource type.
+func NewNamespacedCustomReflector(opts *options.NamespacedOpts, resource *Resource) manager.NamespacedReflector {
+	local := opts.LocalDynamicFactory.ForResource(resource.GroupVersionResource)
+	remote := opts.RemoteDynamicFactory.ForResource(resource.GroupVersionResource)
+
+	// Using opts.LocalNamespace for both event handlers so that the object will be put in the same workqueue
+	// no matter the cluster, hence it will be processed by the handle function in the same way.
+	local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+	remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+
+	reflector := &NamespacedCustomReflector{
+		NamespacedReflector: generic.NewNamespacedReflectorWithInformers(opts, resource.Name(), local.Informer(), remote.Informer()),
+		resource:            resource,
+		localObjects:        local.Lister().ByNamespace(opts.LocalNamespace),
+		remoteObjects:       remote.Lister().ByNamespace(opts.RemoteNamespace),
+		localClient:         opts.LocalDynamicClient.Resource(resource.GroupVersionResource).Namespace(opts.LocalNamespace),
+		remoteClient:        opts.RemoteDynamicClient.Resource(resource.GroupVersionResource).Namespace(opts.RemoteNamespace),
+	}
+
+	// Honor the reflection policies configured in the local namespace.
+	reflector.EnableReflectionPolicies(opts, local.Informer(), nil)
+	return reflector
+}
+
+// Handle is responsible for reconciling the given object and ensuring it is correctly reflected.
+func (ncr *NamespacedCustomReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+	kind := ncr.resource.Name()
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling reflection of local %v %q (remote: %q)", kind, ncr.LocalRef(name), ncr.RemoteRef(name))
+
+	local, lerr := ncr.get(ncr.localObjects, name)
+	utilruntime.Must(client.IgnoreNotFound(lerr))
+	remote, rerr := ncr.get(ncr.remoteObjects, name)
+	utilruntime.Must(client.IgnoreNotFound(rerr))
+	tracer.Step("Retrieved the local and remote objects")
+
+	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
+	if rerr == nil && !forge.IsReflected(remote) {
+		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
+			klog.Infof("Skipping reflection of local %v %q as remote already exists and is not managed by us", kind, ncr.LocalRef(name))
+			ncr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
+		}
+		return nil
+	}
+
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is excluded by the reflection policy.
+	if !kerrors.IsNotFound(lerr) && ncr.ShouldSkipReflection(local) {
+		klog.Infof("Skipping reflection of local %v %q as disabled by the skip annotation or the reflection policy", kind, ncr.LocalRef(name))
+		ncr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
+		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
+			return nil
+		}
+
+		// Otherwise, let pretend the local object does not exist, so that the remote one gets deleted.
+		lerr = kerrors.NewNotFound(ncr.resource.GroupResource(), local.GetName())
+	}
+
+	tracer.Step("Performed the sanity checks")
+
+	if kerrors.IsNotFound(lerr) {
+		defer tracer.Step("Ensured the absence of the remote object")
+		if !kerrors.IsNotFound(rerr) {
+			klog.V(4).Infof("Deleting remote %v %q, since local %q does no longer exist", kind, ncr.RemoteRef(name), ncr.LocalRef(name))
+			return ncr.DeleteRemote(ctx, &deleter{ncr.remoteClient}, kind, remote.GetName(), remote.GetUID())
+		}
+
+		klog.V(4).Infof("Local %v %q and remote %v %q both vanished", kind, ncr.LocalRef(name), kind, ncr.RemoteRef(name))
+		return nil
+	}
+
+	// Forge the mutation to be applied to the remote cluster.
+	mutation := forge.RemoteUnstructured(local, ncr.RemoteNamespace(), ncr.resource.ExcludedFields)
+	tracer.Step("Remote mutation created")
+
+	if _, err := ncr.remoteClient.Apply(ctx, name, mutation, forge.ApplyOptions()); err != nil {
+		klog.Errorf("Failed to enforce remote %v %q (local: %q): %v", kind, ncr.RemoteRef(name), ncr.LocalRef(name), err)
+		ncr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+		return err
+	}
+	tracer.Step("Enforced the correctness of the remote object")
+
+	klog.Infof("Remote %v %q successfully enforced (local: %q)", kind, ncr.RemoteRef(name), ncr.LocalRef(name))
+	ncr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+
+	// Propagate the status of the remote object back to the local one, if enabled. The remote object is taken from the
+	// cache, hence the new status will be eventually reconciled once the corresponding event is received.
+	if ncr.resource.ReflectStatus && rerr == nil && !forge.IsUnstructuredStatusEqual(local, remote) {
+		defer tracer.Step("Updated the local status")
+		if _, err := ncr.localClient.ApplyStatus(ctx, name, forge.LocalUnstructuredStatus(local, remote), forge.ApplyOptions()); err != nil {
+			klog.Errorf("Failed to update the status of local %v %q: %v", kind, ncr.LocalRef(name), err)
+			return err
+		}
+		klog.Infof("Status of local %v %q successfully updated (remote: %q)", kind, ncr.LocalRef(name), ncr.RemoteRef(name))
+	}
+
+	return nil
+}
+
+// get retrieves the object with the given name from the given lister.
+func (ncr *NamespacedCustomReflector) get(lister cache.GenericNamespaceLister, name string) (*unstructured.Unstructured, error) {
+	obj, err := lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*unstructured.Unstructured), nil
+}
+
+// deleter adapts a dynamic.ResourceInterface to the generic.ResourceDeleter interface.
+type deleter struct {
+	client dynamic.ResourceInterface
+}
+
+// Delete deletes the object with the given name.
+func (d *deleter) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return d.client.Delete(ctx, name, opts)
+}
@@ -0,0 +1,215 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/trace"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/custom"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ = Describe("Custom Reflection", func() {
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "examples"}
+
+	Describe("NewCustomReflector", func() {
+		It("should create a non-nil reflector", func() {
+			Expect(custom.NewCustomReflector(&custom.Resource{GroupVersionResource: gvr}, 1)).NotTo(BeNil())
+		})
+	})
+
+	Describe("Handle", func() {
+		const name = "name"
+
+		var (
+			reflector manager.NamespacedReflector
+			resource  custom.Resource
+
+			localClient, remoteClient *dynamicfake.FakeDynamicClient
+			localObjects              []runtime.Object
+			remoteObjects             []runtime.Object
+			remoteApplied             *unstructured.Unstructured
+			localStatusApplied        *unstructured.Unstructured
+
+			err error
+		)
+
+		Forge := func(namespace string, labels map[string]interface{}, status interface{}) *unstructured.Unstructured {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "example.com/v1",
+				"kind":       "Example",
+				"metadata":   map[string]interface{}{"name": name, "namespace": namespace, "uid": namespace + "-uid", "labels": labels},
+				"spec":       map[string]interface{}{"foo": "bar", "secret": "value"},
+			}}
+			if status != nil {
+				obj.Object["status"] = status
+			}
+			return obj
+		}
+
+		ReflectionLabels := func() map[string]interface{} {
+			return map[string]interface{}{
+				forge.LiqoOriginClusterIDKey:      LocalClusterID,
+				forge.LiqoDestinationClusterIDKey: RemoteClusterID,
+			}
+		}
+
+		// ApplyReactor captures the server side apply patches, as not supported by the fake client.
+		ApplyReactor := func(target **unstructured.Unstructured) k8stesting.ReactionFunc {
+			return func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patch := action.(k8stesting.PatchAction)
+				if patch.GetPatchType() != types.ApplyPatchType {
+					return false, nil, nil
+				}
+				obj := &unstructured.Unstructured{}
+				Expect(obj.UnmarshalJSON(patch.GetPatch())).To(Succeed())
+				*target = obj
+				return true, obj, nil
+			}
+		}
+
+		BeforeEach(func() {
+			resource = custom.Resource{GroupVersionResource: gvr, ExcludedFields: [][]string{{"spec", "secret"}}}
+			localObjects, remoteObjects = nil, nil
+			remoteApplied, localStatusApplied = nil, nil
+		})
+
+		JustBeforeEach(func() {
+			listKinds := map[schema.GroupVersionResource]string{gvr: "ExampleList"}
+			localClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, localObjects...)
+			remoteClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, remoteObjects...)
+			remoteClient.PrependReactor("patch", gvr.Resource, ApplyReactor(&remoteApplied))
+			localClient.PrependReactor("patch", gvr.Resource, ApplyReactor(&localStatusApplied))
+
+			localFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(localClient, 10*time.Hour, LocalNamespace, nil)
+			remoteFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(remoteClient, 10*time.Hour, RemoteNamespace, nil)
+
+			reflector = custom.NewNamespacedCustomReflector(options.NewNamespaced().
+				WithLocal(LocalNamespace, nil, nil).WithRemote(RemoteNamespace, nil, nil).
+				WithDynamicLocal(localClient, localFactory).WithDynamicRemote(remoteClient, remoteFactory).
+				WithHandlerFactory(FakeEventHandler).
+				WithEventBroadcaster(record.NewBroadcaster()), &resource)
+
+			localFactory.Start(ctx.Done())
+			remoteFactory.Start(ctx.Done())
+			localFactory.WaitForCacheSync(ctx.Done())
+			remoteFactory.WaitForCacheSync(ctx.Done())
+
+			err = reflector.Handle(trace.ContextWithTrace(ctx, trace.New("Custom")), name)
+		})
+
+		RemoteShouldNotExist := func() {
+			_, err := remoteClient.Resource(gvr).Namespace(RemoteNamespace).Get(ctx, name, metav1.GetOptions{})
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		}
+
+		When("the local object does not exist", func() {
+			When("the remote object does not exist", func() {
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should not create the remote object", func() { Expect(remoteApplied).To(BeNil()) })
+			})
+
+			When("the remote object does exist", func() {
+				BeforeEach(func() { remoteObjects = append(remoteObjects, Forge(RemoteNamespace, ReflectionLabels(), nil)) })
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should delete the remote object", RemoteShouldNotExist)
+			})
+		})
+
+		When("the local object does exist", func() {
+			BeforeEach(func() {
+				localObjects = append(localObjects, Forge(LocalNamespace, map[string]interface{}{"foo": "bar"}, map[string]interface{}{"ready": false}))
+			})
+
+			When("the remote object does not exist", func() {
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should apply the remote object", func() {
+					Expect(remoteApplied).ToNot(BeNil())
+					Expect(remoteApplied.GetName()).To(Equal(name))
+					Expect(remoteApplied.GetNamespace()).To(Equal(RemoteNamespace))
+					Expect(remoteApplied.GetLabels()).To(HaveKeyWithValue("foo", "bar"))
+					Expect(remoteApplied.GetLabels()).To(HaveKeyWithValue(forge.LiqoOriginClusterIDKey, LocalClusterID))
+				})
+				It("should reflect only the appropriate fields", func() {
+					Expect(remoteApplied.Object).To(HaveKeyWithValue("spec", map[string]interface{}{"foo": "bar"}))
+					Expect(remoteApplied.Object).ToNot(HaveKey("status"))
+				})
+				It("should not update the local status", func() { Expect(localStatusApplied).To(BeNil()) })
+			})
+
+			When("the remote object does exist, but it is not managed by us", func() {
+				BeforeEach(func() { remoteObjects = append(remoteObjects, Forge(RemoteNamespace, nil, nil)) })
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should not mutate the remote object", func() { Expect(remoteApplied).To(BeNil()) })
+			})
+
+			When("the remote object does exist, and it has a status", func() {
+				BeforeEach(func() {
+					remoteObjects = append(remoteObjects, Forge(RemoteNamespace, ReflectionLabels(), map[string]interface{}{"ready": true}))
+				})
+
+				When("status reflection is disabled", func() {
+					It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+					It("should apply the remote object", func() { Expect(remoteApplied).ToNot(BeNil()) })
+					It("should not update the local status", func() { Expect(localStatusApplied).To(BeNil()) })
+				})
+
+				When("status reflection is enabled", func() {
+					BeforeEach(func() { resource.ReflectStatus = true })
+
+					It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+					It("should apply the remote object", func() { Expect(remoteApplied).ToNot(BeNil()) })
+					It("should update the local status", func() {
+						Expect(localStatusApplied).ToNot(BeNil())
+						Expect(localStatusApplied.GetNamespace()).To(Equal(LocalNamespace))
+						Expect(localStatusApplied.Object).To(HaveKeyWithValue("status", map[string]interface{}{"ready": true}))
+					})
+				})
+			})
+
+			When("the local object has the skip annotation", func() {
+				BeforeEach(func() {
+					local := Forge(LocalNamespace, nil, nil)
+					local.SetAnnotations(map[string]string{consts.SkipReflectionAnnotationKey: ""})
+					localObjects = []runtime.Object{local}
+					remoteObjects = append(remoteObjects, Forge(RemoteNamespace, ReflectionLabels(), nil))
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should not apply the remote object", func() { Expect(remoteApplied).To(BeNil()) })
+				It("should delete the remote object", RemoteShouldNotExist)
+			})
+		})
+	})
+})
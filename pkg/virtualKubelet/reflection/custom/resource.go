@@ -0,0 +1,90 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// optionsSeparator is the separator between the resource identifier and the subsequent options.
+	optionsSeparator = ";"
+	// statusOption is the option to enable the propagation of the status of remote objects back to the local ones.
+	statusOption = "status"
+	// excludeOption is the option to specify the comma-separated list of fields which are not reflected.
+	excludeOption = "exclude="
+)
+
+// Resource represents the configuration concerning the reflection of an arbitrary resource type.
+type Resource struct {
+	schema.GroupVersionResource
+
+	// ExcludedFields is the list of fields (each one represented as a path) which are not reflected.
+	ExcludedFields [][]string
+	// ReflectStatus is whether the status of remote objects is propagated back to the local ones.
+	ReflectStatus bool
+}
+
+// ParseResource parses the reflection configuration of an arbitrary resource type, expressed in the
+// <resource>.<version>.<group>[;status][;exclude=<field>,...] format (fields are dot-separated paths).
+// For instance, certificates.v1.cert-manager.io;status;exclude=spec.keystores,spec.secretTemplate.
+func ParseResource(value string) (*Resource, error) {
+	tokens := strings.Split(value, optionsSeparator)
+
+	gvr, _ := schema.ParseResourceArg(strings.TrimSpace(tokens[0]))
+	if gvr == nil || gvr.Resource == "" || gvr.Version == "" {
+		return nil, fmt.Errorf("invalid resource %q, expected format <resource>.<version>.<group>", tokens[0])
+	}
+
+	resource := Resource{GroupVersionResource: *gvr}
+	for _, option := range tokens[1:] {
+		option = strings.TrimSpace(option)
+		switch {
+		case option == statusOption:
+			resource.ReflectStatus = true
+		case strings.HasPrefix(option, excludeOption):
+			for _, field := range strings.Split(strings.TrimPrefix(option, excludeOption), ",") {
+				if field = strings.TrimSpace(field); field != "" {
+					resource.ExcludedFields = append(resource.ExcludedFields, strings.Split(field, "."))
+				}
+			}
+		default:
+			return nil, fmt.Errorf("invalid option %q for resource %q", option, gvr.GroupResource())
+		}
+	}
+
+	return &resource, nil
+}
+
+// ParseResources parses the reflection configuration of a list of arbitrary resource types.
+func ParseResources(values []string) ([]*Resource, error) {
+	resources := make([]*Resource, 0, len(values))
+	for _, value := range values {
+		resource, err := ParseResource(value)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// Name returns the name identifying the resource type.
+func (r *Resource) Name() string {
+	return r.GroupResource().String()
+}
@@ -0,0 +1,94 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package custom_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/custom"
+)
+
+var _ = Describe("Resource configuration", func() {
+	Describe("the ParseResource function", func() {
+		type ParseCase struct {
+			value    string
+			expected *custom.Resource
+		}
+
+		gvr := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+
+		DescribeTable("valid configurations",
+			func(c ParseCase) {
+				resource, err := custom.ParseResource(c.value)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(resource).To(Equal(c.expected))
+			},
+			Entry("only the resource", ParseCase{
+				value:    "certificates.v1.cert-manager.io",
+				expected: &custom.Resource{GroupVersionResource: gvr},
+			}),
+			Entry("a resource of the core group", ParseCase{
+				value:    "configmaps.v1.",
+				expected: &custom.Resource{GroupVersionResource: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}},
+			}),
+			Entry("the status option", ParseCase{
+				value:    "certificates.v1.cert-manager.io;status",
+				expected: &custom.Resource{GroupVersionResource: gvr, ReflectStatus: true},
+			}),
+			Entry("the exclude option", ParseCase{
+				value: "certificates.v1.cert-manager.io;exclude=spec.keystores, spec.secretTemplate",
+				expected: &custom.Resource{GroupVersionResource: gvr,
+					ExcludedFields: [][]string{{"spec", "keystores"}, {"spec", "secretTemplate"}}},
+			}),
+			Entry("the exclude option repeated for each field", ParseCase{
+				value: "certificates.v1.cert-manager.io;exclude=spec.keystores;exclude=spec.secretTemplate",
+				expected: &custom.Resource{GroupVersionResource: gvr,
+					ExcludedFields: [][]string{{"spec", "keystores"}, {"spec", "secretTemplate"}}},
+			}),
+			Entry("both options", ParseCase{
+				value: "certificates.v1.cert-manager.io;exclude=spec.keystores;status",
+				expected: &custom.Resource{GroupVersionResource: gvr,
+					ExcludedFields: [][]string{{"spec", "keystores"}}, ReflectStatus: true},
+			}),
+		)
+
+		DescribeTable("invalid configurations",
+			func(value string) {
+				_, err := custom.ParseResource(value)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("an empty value", ""),
+			Entry("a resource without version and group", "certificates"),
+			Entry("an unknown option", "certificates.v1.cert-manager.io;foo"),
+		)
+	})
+
+	Describe("the ParseResources function", func() {
+		It("should parse all the configurations", func() {
+			resources, err := custom.ParseResources([]string{"certificates.v1.cert-manager.io", "scaledobjects.v1alpha1.keda.sh;status"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resources).To(HaveLen(2))
+			Expect(resources[1].Name()).To(Equal("scaledobjects.keda.sh"))
+			Expect(resources[1].ReflectStatus).To(BeTrue())
+		})
+
+		It("should return an error if a configuration is invalid", func() {
+			_, err := custom.ParseResources([]string{"certificates.v1.cert-manager.io", "invalid"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -88,6 +88,9 @@ func NewNamespacedEndpointSliceReflector(ipamclient ipam.IpamClient) func(*optio
 		// Enqueue all existing remote EndpointSlices in case the local Service has the "skip-reflection" annotation, to ensure they are also deleted.
 		localServices.Informer().AddEventHandler(opts.HandlerFactory(ner.ServiceToEndpointSlicesKeyer))
 
+		// Honor the reflection policies configured in the local namespace (both for EndpointSlices and the corresponding Services).
+		ner.EnableReflectionPolicies(opts, local.Informer(), nil)
+
 		return ner
 	}
 }
@@ -121,9 +124,9 @@ func (ner *NamespacedEndpointSliceReflector) Handle(ctx context.Context, name st
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is excluded by the reflection policy.
 	if !kerrors.IsNotFound(lerr) && ner.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local EndpointSlice %q as marked with the skip annotation", ner.LocalRef(name))
+		klog.Infof("Skipping reflection of local EndpointSlice %q as disabled by the skip annotation or the reflection policy", ner.LocalRef(name))
 		ner.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
 		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
 			return nil
@@ -265,7 +268,7 @@ func (ner *NamespacedEndpointSliceReflector) ShouldSkipReflection(obj metav1.Obj
 	// Continue with the reflection in case the service is not found, as this is likely due to a race conditions
 	// (i.e., the service has not yet been cached). If necessary, the informer will trigger a re-enqueue,
 	// thus performing once more this check.
-	return err == nil && ner.NamespacedReflector.ShouldSkipReflection(svc)
+	return err == nil && ner.ShouldSkipReflectionOf(ServiceReflectorName, svc)
 }
 
 // ServiceToEndpointSlicesKeyer returns the NamespacedName of all local EndpointSlices associated with the given local Service.
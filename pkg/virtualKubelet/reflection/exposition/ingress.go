@@ -62,12 +62,16 @@ func NewNamespacedIngressReflector(opts *options.NamespacedOpts) manager.Namespa
 	local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 	remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 
-	return &NamespacedIngressReflector{
+	reflector := &NamespacedIngressReflector{
 		NamespacedReflector:   generic.NewNamespacedReflector(opts, IngressReflectorName),
 		localIngresses:        local.Lister().Ingresses(opts.LocalNamespace),
 		remoteIngresses:       remote.Lister().Ingresses(opts.RemoteNamespace),
 		remoteIngressesClient: opts.RemoteClient.NetworkingV1().Ingresses(opts.RemoteNamespace),
 	}
+
+	// Honor the reflection policies configured in the local namespace.
+	reflector.EnableReflectionPolicies(opts, local.Informer(), nil)
+	return reflector
 }
 
 // Handle reconciles ingress objects.
@@ -91,9 +95,9 @@ func (nir *NamespacedIngressReflector) Handle(ctx context.Context, name string)
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is excluded by the reflection policy.
 	if !kerrors.IsNotFound(lerr) && nir.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local Ingress %q as marked with the skip annotation", nir.LocalRef(name))
+		klog.Infof("Skipping reflection of local Ingress %q as disabled by the skip annotation or the reflection policy", nir.LocalRef(name))
 		nir.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
 		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
 			return nil
@@ -64,12 +64,16 @@ func NewNamespacedServiceReflector(opts *options.NamespacedOpts) manager.Namespa
 	local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 	remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
 
-	return &NamespacedServiceReflector{
+	reflector := &NamespacedServiceReflector{
 		NamespacedReflector:  generic.NewNamespacedReflector(opts, ServiceReflectorName),
 		localServices:        local.Lister().Services(opts.LocalNamespace),
 		remoteServices:       remote.Lister().Services(opts.RemoteNamespace),
 		remoteServicesClient: opts.RemoteClient.CoreV1().Services(opts.RemoteNamespace),
 	}
+
+	// Honor the reflection policies configured in the local namespace.
+	reflector.EnableReflectionPolicies(opts, local.Informer(), nil)
+	return reflector
 }
 
 // Handle reconciles service objects.
@@ -99,9 +103,9 @@ func (nsr *NamespacedServiceReflector) Handle(ctx context.Context, name string)
 		return nil
 	}
 
-	// Abort the reflection if the local object has the "skip-reflection" annotation.
+	// Abort the reflection if the local object has the "skip-reflection" annotation, or it is excluded by the reflection policy.
 	if !kerrors.IsNotFound(lerr) && nsr.ShouldSkipReflection(local) {
-		klog.Infof("Skipping reflection of local Service %q as marked with the skip annotation", nsr.LocalRef(name))
+		klog.Infof("Skipping reflection of local Service %q as disabled by the skip annotation or the reflection policy", nsr.LocalRef(name))
 		nsr.Event(local, corev1.EventTypeNormal, forge.EventReflectionDisabled, forge.EventObjectReflectionDisabledMsg())
 		if kerrors.IsNotFound(rerr) { // The remote object does not already exist, hence no further action is required.
 			return nil
@@ -23,6 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 
@@ -38,6 +39,9 @@ type NamespacedReflector struct {
 
 	local  string
 	remote string
+
+	resource string
+	policies *policies
 }
 
 // ResourceDeleter know how to delete a Kubernetes object with the given name.
@@ -50,7 +54,24 @@ func NewNamespacedReflector(opts *options.NamespacedOpts, name string) Namespace
 	return NamespacedReflector{
 		EventRecorder: opts.EventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "liqo-" + strings.ToLower(name) + "-reflection"}),
 		local:         opts.LocalNamespace, remote: opts.RemoteNamespace, ready: opts.Ready,
+		resource: name,
+	}
+}
+
+// NewNamespacedReflectorWithInformers returns a new NamespacedReflector for the given namespaces, which is additionally
+// considered ready only once the given informers have synced. This allows to synchronize the informers specific to a
+// given reflector (e.g., the dynamic ones) separately from the ones shared by all reflectors.
+func NewNamespacedReflectorWithInformers(opts *options.NamespacedOpts, name string, informers ...cache.SharedIndexInformer) NamespacedReflector {
+	reflector := NewNamespacedReflector(opts, name)
+	reflector.ready = func() bool {
+		for _, informer := range informers {
+			if !informer.HasSynced() {
+				return false
+			}
+		}
+		return opts.Ready()
 	}
+	return reflector
 }
 
 // Ready returns whether the NamespacedReflector is completely initialized.
@@ -90,8 +111,14 @@ func (gnr *NamespacedReflector) DeleteRemote(ctx context.Context, deleter Resour
 	return nil
 }
 
-// ShouldSkipReflection returns whether the reflection of the given object should be skipped.
+// ShouldSkipReflection returns whether the reflection of the given object should be skipped,
+// according to the skip annotation and the reflection policies (if enabled).
 func (gnr *NamespacedReflector) ShouldSkipReflection(obj metav1.Object) bool {
+	return gnr.ShouldSkipReflectionOf(gnr.resource, obj)
+}
+
+// HasSkipAnnotation returns whether the given object is marked with the skip annotation.
+func (gnr *NamespacedReflector) HasSkipAnnotation(obj metav1.Object) bool {
 	_, ok := obj.GetAnnotations()[consts.SkipReflectionAnnotationKey]
 	return ok
 }
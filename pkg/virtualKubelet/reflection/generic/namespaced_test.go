@@ -24,6 +24,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
 	corev1clients "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 
@@ -38,6 +39,38 @@ var _ = Describe("NamespacedReflector tests", func() {
 		name            = "foo"
 	)
 
+	Context("the NewNamespacedReflectorWithInformers function", func() {
+		var (
+			nsrfl    NamespacedReflector
+			ready    bool
+			informer *fakeInformer
+		)
+
+		BeforeEach(func() {
+			ready = true
+			informer = &fakeInformer{}
+			opts := options.NamespacedOpts{
+				LocalNamespace: localNamespace, RemoteNamespace: remoteNamespace,
+				Ready: func() bool { return ready }, EventBroadcaster: record.NewBroadcaster(),
+			}
+			nsrfl = NewNamespacedReflectorWithInformers(&opts, name, informer)
+		})
+
+		It("should not be ready if the given informers have not synced", func() {
+			Expect(nsrfl.Ready()).To(BeFalse())
+		})
+
+		It("should not be ready if the shared informers have not synced", func() {
+			informer.synced, ready = true, false
+			Expect(nsrfl.Ready()).To(BeFalse())
+		})
+
+		It("should be ready if all the informers have synced", func() {
+			informer.synced = true
+			Expect(nsrfl.Ready()).To(BeTrue())
+		})
+	})
+
 	Context("the NewNamespacedReflector function", func() {
 		var (
 			nsrfl NamespacedReflector
@@ -115,3 +148,12 @@ var _ = Describe("NamespacedReflector tests", func() {
 		})
 	})
 })
+
+// fakeInformer is a fake informer, whose synchronization status can be configured.
+type fakeInformer struct {
+	cache.SharedIndexInformer
+	synced bool
+}
+
+// HasSynced returns whether the informer has synced.
+func (fi *fakeInformer) HasSynced() bool { return fi.synced }
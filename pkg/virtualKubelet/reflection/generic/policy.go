@@ -0,0 +1,176 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	vkv1alpha1listers "github.com/liqotech/liqo/pkg/client/listers/virtualkubelet/v1alpha1"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+// ReferencesFunc returns the names of the objects (of a given type) referenced by the given pod spec.
+type ReferencesFunc func(spec *corev1.PodSpec) []string
+
+// policies groups the information required to enforce the reflection policies.
+type policies struct {
+	lister     vkv1alpha1listers.ReflectionPolicyNamespaceLister
+	pods       corev1listers.PodNamespaceLister
+	references ReferencesFunc
+}
+
+// EnableReflectionPolicies configures the NamespacedReflector to honor the ReflectionPolicies defined in the local namespace.
+// All the objects cached by the given local informer are re-enqueued whenever a policy changes, so that the changes are enforced.
+// The references function, if not nil, enables the support for the OnlyReferencedByPods mode.
+func (gnr *NamespacedReflector) EnableReflectionPolicies(opts *options.NamespacedOpts, local cache.SharedIndexInformer, references ReferencesFunc) {
+	if opts.LocalLiqoFactory == nil {
+		klog.V(4).Infof("Reflection policies not supported for %v reflection in local namespace %q", gnr.resource, gnr.local)
+		return
+	}
+
+	informer := opts.LocalLiqoFactory.Virtualkubelet().V1alpha1().ReflectionPolicies()
+	informer.Informer().AddEventHandler(opts.HandlerFactory(StoreKeyer(opts.LocalNamespace, local.GetStore())))
+	gnr.policies = &policies{lister: informer.Lister().ReflectionPolicies(opts.LocalNamespace)}
+
+	if references != nil {
+		pods := opts.LocalFactory.Core().V1().Pods()
+		pods.Informer().AddEventHandler(opts.HandlerFactory(PodReferencesKeyer(opts.LocalNamespace, references)))
+		gnr.policies.pods = pods.Lister().Pods(opts.LocalNamespace)
+		gnr.policies.references = references
+	}
+}
+
+// ShouldSkipReflectionOf returns whether the reflection of the given object, of the given resource type, should be skipped,
+// because either marked with the skip annotation, or not selected by the reflection policy configured for that resource type.
+func (gnr *NamespacedReflector) ShouldSkipReflectionOf(resource string, obj metav1.Object) bool {
+	if gnr.HasSkipAnnotation(obj) {
+		return true
+	}
+
+	if gnr.policies == nil {
+		return false
+	}
+
+	policy := gnr.policy(resource)
+	switch policy.Mode {
+	case vkv1alpha1.ReflectionModeNone:
+		return true
+	case vkv1alpha1.ReflectionModeOptIn:
+		selector, err := metav1.LabelSelectorAsSelector(policy.Selector)
+		if err != nil {
+			klog.Errorf("Invalid selector in the %v reflection policy for local namespace %q: %v", resource, gnr.local, err)
+			return true
+		}
+		return !selector.Matches(labels.Set(obj.GetLabels()))
+	case vkv1alpha1.ReflectionModeOnlyReferencedByPods:
+		if gnr.policies.references == nil || !strings.EqualFold(resource, gnr.resource) {
+			klog.Warningf("The %v reflection mode is not supported by %v reflection (local namespace %q)", policy.Mode, resource, gnr.local)
+			return true
+		}
+		return !gnr.isReferenced(obj.GetName())
+	default:
+		return false
+	}
+}
+
+// policy returns the reflection policy associated with the given resource type. In case multiple ReflectionPolicies
+// refer to the same resource type, the one defined in the object with the lexicographically smaller name prevails.
+func (gnr *NamespacedReflector) policy(resource string) vkv1alpha1.ResourceReflectionPolicy {
+	fallback := vkv1alpha1.ResourceReflectionPolicy{Resource: resource, Mode: vkv1alpha1.ReflectionModeAll}
+
+	objects, err := gnr.policies.lister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Failed to retrieve the reflection policies for local namespace %q: %v", gnr.local, err)
+		return fallback
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].GetName() < objects[j].GetName() })
+	for _, object := range objects {
+		for i := range object.Spec.Policies {
+			if strings.EqualFold(object.Spec.Policies[i].Resource, resource) {
+				return object.Spec.Policies[i]
+			}
+		}
+	}
+
+	return fallback
+}
+
+// isReferenced returns whether the object with the given name is referenced by at least one pod offloaded to the remote cluster.
+func (gnr *NamespacedReflector) isReferenced(name string) bool {
+	pods, err := gnr.policies.pods.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("Failed to retrieve the pods in local namespace %q: %v", gnr.local, err)
+		return false
+	}
+
+	for _, pod := range pods {
+		if pod.Spec.NodeName != forge.LiqoNodeName {
+			continue
+		}
+
+		for _, reference := range gnr.policies.references(&pod.Spec) {
+			if reference == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// StoreKeyer returns a keyer which, regardless of the triggering object, retrieves the NamespacedNames
+// of all the objects stored in the given cache, associating them with the given namespace.
+func StoreKeyer(namespace string, store cache.Store) func(metadata metav1.Object) []types.NamespacedName {
+	return func(_ metav1.Object) []types.NamespacedName {
+		var keys []types.NamespacedName
+		for _, key := range store.ListKeys() {
+			_, name, err := cache.SplitMetaNamespaceKey(key)
+			if err != nil {
+				continue
+			}
+			keys = append(keys, types.NamespacedName{Namespace: namespace, Name: name})
+		}
+		return keys
+	}
+}
+
+// PodReferencesKeyer returns a keyer associated with the given namespace, which retrieves the names of
+// the objects referenced by a pod offloaded to the remote cluster through the given references function.
+func PodReferencesKeyer(namespace string, references ReferencesFunc) func(metadata metav1.Object) []types.NamespacedName {
+	return func(metadata metav1.Object) []types.NamespacedName {
+		pod, ok := metadata.(*corev1.Pod)
+		if !ok || pod.Spec.NodeName != forge.LiqoNodeName {
+			return nil
+		}
+
+		var keys []types.NamespacedName
+		for _, name := range references(&pod.Spec) {
+			keys = append(keys, types.NamespacedName{Namespace: namespace, Name: name})
+		}
+		return keys
+	}
+}
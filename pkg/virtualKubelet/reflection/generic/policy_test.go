@@ -0,0 +1,205 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generic
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	discoveryv1alpha1 "github.com/liqotech/liqo/apis/discovery/v1alpha1"
+	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
+	liqoclientfake "github.com/liqotech/liqo/pkg/client/clientset/versioned/fake"
+	liqoinformers "github.com/liqotech/liqo/pkg/client/informers/externalversions"
+	podutils "github.com/liqotech/liqo/pkg/utils/pod"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ = Describe("Reflection policies", func() {
+	const (
+		localNamespace  = "local"
+		remoteNamespace = "remote"
+		resource        = "ConfigMap"
+		name            = "foo"
+		nodeName        = "virtual-node"
+	)
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+
+		nsrfl      NamespacedReflector
+		references ReferencesFunc
+		keys       []types.NamespacedName
+
+		objects, liqoObjects []runtime.Object
+		target               *corev1.ConfigMap
+	)
+
+	Policy := func(objname string, policies ...vkv1alpha1.ResourceReflectionPolicy) *vkv1alpha1.ReflectionPolicy {
+		return &vkv1alpha1.ReflectionPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: objname, Namespace: localNamespace},
+			Spec:       vkv1alpha1.ReflectionPolicySpec{Policies: policies},
+		}
+	}
+
+	Pod := func(podname, node, configmap string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: podname, Namespace: localNamespace},
+			Spec: corev1.PodSpec{NodeName: node, Volumes: []corev1.Volume{{Name: "config", VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: configmap}}}}}},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		forge.Init(discoveryv1alpha1.ClusterIdentity{}, discoveryv1alpha1.ClusterIdentity{}, nodeName, "1.1.1.1")
+
+		references = podutils.ReferencedConfigMaps
+		keys = nil
+		target = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: localNamespace, Labels: map[string]string{"foo": "bar"}}}
+		objects = []runtime.Object{target}
+		liqoObjects = nil
+	})
+
+	AfterEach(func() { cancel() })
+
+	JustBeforeEach(func() {
+		factory := informers.NewSharedInformerFactoryWithOptions(fake.NewSimpleClientset(objects...), 10*time.Hour, informers.WithNamespace(localNamespace))
+		liqoFactory := liqoinformers.NewSharedInformerFactoryWithOptions(liqoclientfake.NewSimpleClientset(liqoObjects...),
+			10*time.Hour, liqoinformers.WithNamespace(localNamespace))
+
+		handler := func(keyer options.Keyer) cache.ResourceEventHandler {
+			return cache.ResourceEventHandlerFuncs{AddFunc: func(obj interface{}) { keys = append(keys, keyer(obj.(metav1.Object))...) }}
+		}
+
+		opts := options.NewNamespaced().WithLocal(localNamespace, nil, factory).WithLiqoLocal(nil, liqoFactory).
+			WithRemote(remoteNamespace, nil, nil).WithHandlerFactory(handler).WithEventBroadcaster(record.NewBroadcaster())
+		nsrfl = NewNamespacedReflector(opts, resource)
+		nsrfl.EnableReflectionPolicies(opts, factory.Core().V1().ConfigMaps().Informer(), references)
+
+		factory.Start(ctx.Done())
+		liqoFactory.Start(ctx.Done())
+		factory.WaitForCacheSync(ctx.Done())
+		liqoFactory.WaitForCacheSync(ctx.Done())
+	})
+
+	When("no reflection policy is present", func() {
+		It("should not skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeFalse()) })
+
+		When("the object has the skip annotation", func() {
+			BeforeEach(func() { target.SetAnnotations(map[string]string{"liqo.io/skip-reflection": ""}) })
+			It("should skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeTrue()) })
+		})
+	})
+
+	When("a reflection policy refers to a different resource type", func() {
+		BeforeEach(func() {
+			liqoObjects = append(liqoObjects, Policy("policy", vkv1alpha1.ResourceReflectionPolicy{Resource: "Secret", Mode: vkv1alpha1.ReflectionModeNone}))
+		})
+		It("should not skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeFalse()) })
+	})
+
+	When("the reflection policy is None", func() {
+		BeforeEach(func() {
+			liqoObjects = append(liqoObjects, Policy("policy",
+				vkv1alpha1.ResourceReflectionPolicy{Resource: "configmap", Mode: vkv1alpha1.ReflectionModeNone}))
+		})
+
+		It("should skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeTrue()) })
+		It("should enqueue all local objects", func() {
+			Expect(keys).To(ContainElement(types.NamespacedName{Namespace: localNamespace, Name: name}))
+		})
+	})
+
+	When("multiple reflection policies refer to the same resource type", func() {
+		BeforeEach(func() {
+			liqoObjects = append(liqoObjects,
+				Policy("bbb", vkv1alpha1.ResourceReflectionPolicy{Resource: resource, Mode: vkv1alpha1.ReflectionModeNone}),
+				Policy("aaa", vkv1alpha1.ResourceReflectionPolicy{Resource: resource, Mode: vkv1alpha1.ReflectionModeAll}))
+		})
+		It("should honor the one with the lexicographically smaller name", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeFalse()) })
+	})
+
+	When("the reflection policy is OptIn", func() {
+		BeforeEach(func() {
+			liqoObjects = append(liqoObjects, Policy("policy", vkv1alpha1.ResourceReflectionPolicy{Resource: resource, Mode: vkv1alpha1.ReflectionModeOptIn}))
+		})
+
+		When("the selector matches the object", func() {
+			BeforeEach(func() {
+				liqoObjects = []runtime.Object{Policy("policy", vkv1alpha1.ResourceReflectionPolicy{Resource: resource,
+					Mode: vkv1alpha1.ReflectionModeOptIn, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}}})}
+			})
+			It("should not skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeFalse()) })
+		})
+
+		When("the selector does not match the object", func() {
+			BeforeEach(func() {
+				liqoObjects = []runtime.Object{Policy("policy", vkv1alpha1.ResourceReflectionPolicy{Resource: resource,
+					Mode: vkv1alpha1.ReflectionModeOptIn, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"foo": "baz"}}})}
+			})
+			It("should skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeTrue()) })
+		})
+
+		When("the selector is not specified", func() {
+			It("should skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeTrue()) })
+		})
+	})
+
+	When("the reflection policy is OnlyReferencedByPods", func() {
+		BeforeEach(func() {
+			liqoObjects = append(liqoObjects, Policy("policy", vkv1alpha1.ResourceReflectionPolicy{
+				Resource: resource, Mode: vkv1alpha1.ReflectionModeOnlyReferencedByPods}))
+		})
+
+		When("the object is not referenced by any pod", func() {
+			BeforeEach(func() { objects = append(objects, Pod("pod", nodeName, "other")) })
+			It("should skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeTrue()) })
+		})
+
+		When("the object is referenced by a pod not offloaded to the remote cluster", func() {
+			BeforeEach(func() { objects = append(objects, Pod("pod", "local-node", name)) })
+			It("should skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeTrue()) })
+		})
+
+		When("the object is referenced by a pod offloaded to the remote cluster", func() {
+			BeforeEach(func() { objects = append(objects, Pod("pod", nodeName, name)) })
+			It("should not skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeFalse()) })
+			It("should enqueue the referenced object when the pod changes", func() {
+				Expect(keys).To(ContainElement(types.NamespacedName{Namespace: localNamespace, Name: name}))
+			})
+		})
+
+		When("the reflector does not support the references", func() {
+			BeforeEach(func() {
+				references = nil
+				objects = append(objects, Pod("pod", nodeName, name))
+			})
+			It("should skip the reflection", func() { Expect(nsrfl.ShouldSkipReflection(target)).To(BeTrue()) })
+		})
+	})
+})
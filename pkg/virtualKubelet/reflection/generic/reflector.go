@@ -20,6 +20,7 @@ import (
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -31,6 +32,7 @@ import (
 	"k8s.io/utils/trace"
 
 	traceutils "github.com/liqotech/liqo/pkg/utils/trace"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
 )
@@ -200,11 +202,15 @@ func (gr *reflector) processNextWorkItem() bool {
 }
 
 // handle dispatches the items to be reconciled based on the resource type and namespace.
-func (gr *reflector) handle(ctx context.Context, key types.NamespacedName) error {
+func (gr *reflector) handle(ctx context.Context, key types.NamespacedName) (err error) {
 	tracer := trace.New("Handle", trace.Field{Key: "Reflector", Value: gr.name},
 		trace.Field{Key: "Object", Value: key.Namespace}, trace.Field{Key: "Name", Value: key.Name})
 	defer tracer.LogIfLong(traceutils.LongThreshold())
 
+	ctx, span := tracing.Start(ctx, "Reflector handle", attribute.String("liqo.reflector", gr.name),
+		attribute.String("liqo.namespace", key.Namespace), attribute.String("liqo.name", key.Name))
+	defer func() { tracing.End(span, err) }()
+
 	// Retrieve the reflector associated with the given namespace.
 	reflector, found := gr.namespace(key.Namespace)
 	if !found {
@@ -22,6 +22,8 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
@@ -45,6 +47,8 @@ type manager struct {
 	remote           kubernetes.Interface
 	localLiqo        liqoclient.Interface
 	remoteLiqo       liqoclient.Interface
+	localDynamic     dynamic.Interface
+	remoteDynamic    dynamic.Interface
 	resync           time.Duration
 	eventBroadcaster record.EventBroadcaster
 
@@ -58,8 +62,8 @@ type manager struct {
 }
 
 // New returns a new manager to start the reflection towards a remote cluster.
-func New(local, remote kubernetes.Interface, localLiqo, remoteLiqo liqoclient.Interface, resync time.Duration,
-	eb record.EventBroadcaster) Manager {
+func New(local, remote kubernetes.Interface, localLiqo, remoteLiqo liqoclient.Interface,
+	localDynamic, remoteDynamic dynamic.Interface, resync time.Duration, eb record.EventBroadcaster) Manager {
 	// Configure the field selector to retrieve only the pods scheduled on the current virtual node.
 	localPodTweakListOptions := func(opts *metav1.ListOptions) {
 		opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", forge.LiqoNodeName).String()
@@ -70,6 +74,8 @@ func New(local, remote kubernetes.Interface, localLiqo, remoteLiqo liqoclient.In
 		remote:           remote,
 		localLiqo:        localLiqo,
 		remoteLiqo:       remoteLiqo,
+		localDynamic:     localDynamic,
+		remoteDynamic:    remoteDynamic,
 		resync:           resync,
 		eventBroadcaster: eb,
 
@@ -161,17 +167,20 @@ func (m *manager) StartNamespace(local, remote string) {
 	// The local informer factories, which select all resources in the given namespace.
 	localFactory := informers.NewSharedInformerFactoryWithOptions(m.local, m.resync, informers.WithNamespace(local))
 	localLiqoFactory := liqoinformers.NewSharedInformerFactoryWithOptions(m.localLiqo, m.resync, liqoinformers.WithNamespace(local))
+	localDynamicFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(m.localDynamic, m.resync, local, nil)
 
 	// The remote informer factories, which select all resources in the given namespace.
 	// We do not filter the resources by label selector, to be able to abort reflection in case the remote object already exists.
 	remoteFactory := informers.NewSharedInformerFactoryWithOptions(m.remote, m.resync, informers.WithNamespace(remote))
 	remoteLiqoFactory := liqoinformers.NewSharedInformerFactoryWithOptions(m.remoteLiqo, m.resync, liqoinformers.WithNamespace(remote))
+	remoteDynamicFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(m.remoteDynamic, m.resync, remote, nil)
 
 	ready := false
 	for _, reflector := range m.reflectors {
 		opts := options.NewNamespaced().
 			WithLocal(local, m.local, localFactory).WithLiqoLocal(m.localLiqo, localLiqoFactory).
 			WithRemote(remote, m.remote, remoteFactory).WithLiqoRemote(m.remoteLiqo, remoteLiqoFactory).
+			WithDynamicLocal(m.localDynamic, localDynamicFactory).WithDynamicRemote(m.remoteDynamic, remoteDynamicFactory).
 			WithReadinessFunc(func() bool { return ready }).WithEventBroadcaster(m.eventBroadcaster)
 		reflector.StartNamespace(opts)
 	}
@@ -181,11 +190,14 @@ func (m *manager) StartNamespace(local, remote string) {
 		tracer := trace.New("Initialization", trace.Field{Key: "LocalNamespace", Value: local}, trace.Field{Key: "RemoteNamespace", Value: remote})
 		defer tracer.LogIfLong(traceutils.LongThreshold())
 
-		// Start the factories, and wait for their caches to sync
+		// Start the factories, and wait for their caches to sync. The dynamic informers are not awaited, since
+		// specific to the corresponding reflectors, which in turn check their synchronization before becoming ready.
 		localFactory.Start(ctx.Done())
 		localLiqoFactory.Start(ctx.Done())
 		remoteFactory.Start(ctx.Done())
 		remoteLiqoFactory.Start(ctx.Done())
+		localDynamicFactory.Start(ctx.Done())
+		remoteDynamicFactory.Start(ctx.Done())
 
 		localFactory.WaitForCacheSync(ctx.Done())
 		localLiqoFactory.WaitForCacheSync(ctx.Done())
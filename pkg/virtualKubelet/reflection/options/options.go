@@ -17,6 +17,8 @@ package options
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -79,6 +81,11 @@ type NamespacedOpts struct {
 	LocalLiqoFactory  liqoinformers.SharedInformerFactory
 	RemoteLiqoFactory liqoinformers.SharedInformerFactory
 
+	LocalDynamicClient   dynamic.Interface
+	RemoteDynamicClient  dynamic.Interface
+	LocalDynamicFactory  dynamicinformer.DynamicSharedInformerFactory
+	RemoteDynamicFactory dynamicinformer.DynamicSharedInformerFactory
+
 	EventBroadcaster record.EventBroadcaster
 
 	Ready          func() bool
@@ -120,6 +127,20 @@ func (ro *NamespacedOpts) WithLiqoRemote(client liqoclient.Interface, factory li
 	return ro
 }
 
+// WithDynamicLocal configures the local dynamic client and informer factory parameters of the NamespacedOpts.
+func (ro *NamespacedOpts) WithDynamicLocal(client dynamic.Interface, factory dynamicinformer.DynamicSharedInformerFactory) *NamespacedOpts {
+	ro.LocalDynamicClient = client
+	ro.LocalDynamicFactory = factory
+	return ro
+}
+
+// WithDynamicRemote configures the remote dynamic client and informer factory parameters of the NamespacedOpts.
+func (ro *NamespacedOpts) WithDynamicRemote(client dynamic.Interface, factory dynamicinformer.DynamicSharedInformerFactory) *NamespacedOpts {
+	ro.RemoteDynamicClient = client
+	ro.RemoteDynamicFactory = factory
+	return ro
+}
+
 // WithHandlerFactory configures the handler factory of the NamespacedOpts.
 func (ro *NamespacedOpts) WithHandlerFactory(handler func(Keyer) cache.ResourceEventHandler) *NamespacedOpts {
 	ro.HandlerFactory = handler
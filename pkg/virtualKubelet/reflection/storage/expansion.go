@@ -0,0 +1,86 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/sig-storage-lib-external-provisioner/v7/util"
+
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+)
+
+// isProvisioned checks whether the given local PersistentVolumeClaim has already been provisioned by the PVC reflector,
+// hence it is bound and the corresponding remote PersistentVolumeClaim exists.
+func (npvcr *NamespacedPersistentVolumeClaimReflector) isProvisioned(local, remote *corev1.PersistentVolumeClaim) bool {
+	return remote != nil && local.Spec.VolumeName != "" && util.GetPersistentVolumeClaimClass(local) == npvcr.virtualStorageClassName
+}
+
+// handleExpansion propagates the expansion of a provisioned local PersistentVolumeClaim to the remote one,
+// and reflects the progress of the resize operation back to the local PersistentVolume and PersistentVolumeClaim.
+func (npvcr *NamespacedPersistentVolumeClaimReflector) handleExpansion(ctx context.Context, local, remote *corev1.PersistentVolumeClaim) error {
+	if !liqostorageprovisioner.IsExpansionRequired(local) {
+		klog.V(4).Infof("Skipping PersistentVolumeClaim %q since already provisioned and not expanded", npvcr.LocalRef(local.GetName()))
+		return nil
+	}
+
+	if !liqostorageprovisioner.IsExpansionPropagated(local, remote) {
+		if err := liqostorageprovisioner.ExpandRemotePVC(ctx, local, remote, npvcr.remotePersistentVolumesClaimsClient); err != nil {
+			klog.Errorf("Failed to expand the remote PersistentVolumeClaim %q: %v", npvcr.RemoteRef(remote.GetName()), err)
+			npvcr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+			return err
+		}
+		klog.Infof("Remote PersistentVolumeClaim %q expanded", npvcr.RemoteRef(remote.GetName()))
+		npvcr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+	}
+
+	status, capacity := liqostorageprovisioner.ForgeExpansionStatus(local, remote)
+	if capacity != nil {
+		pv, err := npvcr.volumes.Get(local.Spec.VolumeName)
+		if err != nil {
+			klog.Errorf("Failed to retrieve the PersistentVolume of %q: %v", npvcr.LocalRef(local.GetName()), err)
+			return err
+		}
+
+		pv = pv.DeepCopy()
+		if liqostorageprovisioner.EnsureVolumeCapacity(pv, *capacity) {
+			if _, err = npvcr.localPersistentVolumesClient.Update(ctx, pv, metav1.UpdateOptions{}); err != nil {
+				klog.Errorf("Failed to expand the PersistentVolume of %q: %v", npvcr.LocalRef(local.GetName()), err)
+				return err
+			}
+			klog.Infof("PersistentVolume %q of %q expanded to %v", pv.GetName(), npvcr.LocalRef(local.GetName()), capacity.String())
+		}
+	}
+
+	if equality.Semantic.DeepEqual(&local.Status, status) {
+		return nil
+	}
+
+	local.Status = *status
+	if _, err := npvcr.localPersistentVolumeClaimsClient.UpdateStatus(ctx, local, metav1.UpdateOptions{}); err != nil {
+		if !kerrors.IsConflict(err) {
+			npvcr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedStatusReflectionMsg(err))
+		}
+		return err
+	}
+	npvcr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulStatusReflectionMsg())
+	return nil
+}
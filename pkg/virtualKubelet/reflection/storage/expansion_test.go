@@ -0,0 +1,181 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ = Describe("PersistentVolumeClaim expansion", func() {
+	const (
+		pvcUID = "2c26b46b-68ff-c68f-f99b-453c1d304134"
+		pvName = "pvc-" + pvcUID
+	)
+
+	var (
+		pvcReflector              *NamespacedPersistentVolumeClaimReflector
+		localClient, remoteClient *fake.Clientset
+
+		local, remote *corev1.PersistentVolumeClaim
+		remoteApplied *corev1.PersistentVolumeClaim
+
+		err error
+	)
+
+	storage := func(quantity string) corev1.ResourceList {
+		return corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(quantity)}
+	}
+
+	getLocalPv := func() *corev1.PersistentVolume {
+		pv, err := localClient.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return pv
+	}
+
+	getLocalPvc := func() *corev1.PersistentVolumeClaim {
+		pvc, err := localClient.CoreV1().PersistentVolumeClaims(LocalNamespace).Get(ctx, remotePvcName, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		return pvc
+	}
+
+	BeforeEach(func() {
+		local = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: remotePvcName, Namespace: LocalNamespace, UID: pvcUID},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: pointer.String(VirtualStorageClassName), VolumeName: pvName,
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources:   corev1.ResourceRequirements{Requests: storage("2Gi")},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound, Capacity: storage("1Gi")},
+		}
+
+		remote = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: remotePvcName, Namespace: RemoteNamespace, Labels: forge.ReflectionLabels()},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: pointer.String(RealRemoteStorageClassName),
+				Resources:        corev1.ResourceRequirements{Requests: storage("1Gi")},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound, Capacity: storage("1Gi")},
+		}
+
+		remoteApplied = nil
+	})
+
+	JustBeforeEach(func() {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: pvName},
+			Spec:       corev1.PersistentVolumeSpec{StorageClassName: VirtualStorageClassName, Capacity: storage("1Gi")},
+		}
+
+		localClient = fake.NewSimpleClientset(local, pv)
+		remoteClient = fake.NewSimpleClientset(remote)
+
+		// Capture the server side apply patches, as not supported by the fake client.
+		remoteClient.PrependReactor("patch", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			patch := action.(k8stesting.PatchAction)
+			if patch.GetPatchType() != types.ApplyPatchType {
+				return false, nil, nil
+			}
+			remoteApplied = &corev1.PersistentVolumeClaim{}
+			Expect(json.Unmarshal(patch.GetPatch(), remoteApplied)).To(Succeed())
+			return true, remoteApplied, nil
+		})
+
+		localFactory := informers.NewSharedInformerFactory(localClient, 10*time.Hour)
+		remoteFactory := informers.NewSharedInformerFactory(remoteClient, 10*time.Hour)
+
+		pvcReflector = NewNamespacedPersistentVolumeClaimReflector(VirtualStorageClassName, RealRemoteStorageClassName, true)(
+			options.NewNamespaced().
+				WithLocal(LocalNamespace, localClient, localFactory).WithRemote(RemoteNamespace, remoteClient, remoteFactory).
+				WithHandlerFactory(func(options.Keyer) cache.ResourceEventHandler { return cache.ResourceEventHandlerFuncs{} }).
+				WithEventBroadcaster(record.NewBroadcaster())).(*NamespacedPersistentVolumeClaimReflector)
+
+		localFactory.Start(ctx.Done())
+		remoteFactory.Start(ctx.Done())
+		localFactory.WaitForCacheSync(ctx.Done())
+		remoteFactory.WaitForCacheSync(ctx.Done())
+
+		err = pvcReflector.Handle(ctx, remotePvcName)
+	})
+
+	When("the local PersistentVolumeClaim has not been expanded", func() {
+		BeforeEach(func() { local.Spec.Resources.Requests = storage("1Gi") })
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not modify the remote PersistentVolumeClaim", func() { Expect(remoteApplied).To(BeNil()) })
+	})
+
+	When("the local PersistentVolumeClaim has been expanded", func() {
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should propagate the request to the remote PersistentVolumeClaim", func() {
+			Expect(remoteApplied).ToNot(BeNil())
+			Expect(remoteApplied.Spec.Resources.Requests).To(Equal(storage("2Gi")))
+			Expect(remoteApplied.Spec.StorageClassName).To(PointTo(Equal(RealRemoteStorageClassName)))
+		})
+		It("should not yet modify the local PersistentVolume", func() { Expect(getLocalPv().Spec.Capacity).To(Equal(storage("1Gi"))) })
+	})
+
+	When("the file system resize of the remote volume is pending", func() {
+		BeforeEach(func() {
+			remote.Spec.Resources.Requests = storage("2Gi")
+			remote.Status.Conditions = []corev1.PersistentVolumeClaimCondition{{
+				Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue}}
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not modify the remote PersistentVolumeClaim", func() { Expect(remoteApplied).To(BeNil()) })
+		It("should expand the local PersistentVolume", func() { Expect(getLocalPv().Spec.Capacity).To(Equal(storage("2Gi"))) })
+		It("should reflect the resize condition", func() {
+			status := getLocalPvc().Status
+			Expect(status.Capacity).To(Equal(storage("1Gi")))
+			Expect(status.Conditions).To(ConsistOf(
+				MatchFields(IgnoreExtras, Fields{"Type": Equal(corev1.PersistentVolumeClaimFileSystemResizePending)})))
+		})
+	})
+
+	When("the remote volume has been completely resized", func() {
+		BeforeEach(func() {
+			remote.Spec.Resources.Requests = storage("2Gi")
+			remote.Status.Capacity = storage("2Gi")
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should expand the local PersistentVolume", func() { Expect(getLocalPv().Spec.Capacity).To(Equal(storage("2Gi"))) })
+		It("should reflect the new capacity", func() {
+			status := getLocalPvc().Status
+			Expect(status.Capacity).To(Equal(storage("2Gi")))
+			Expect(status.Conditions).To(BeEmpty())
+		})
+	})
+})
@@ -145,6 +145,11 @@ func (npvcr *NamespacedPersistentVolumeClaimReflector) Handle(ctx context.Contex
 	// DeepCopy the local object to allow modifications.
 	local = local.DeepCopy()
 
+	// The local PersistentVolumeClaim has already been provisioned. Ensure its possible expansion is propagated.
+	if rerr == nil && npvcr.isProvisioned(local, remote) {
+		return npvcr.handleExpansion(ctx, local, remote)
+	}
+
 	// Check if we should provision storage for that PVC. We have to check if no volume is already provisioned and the storage class is the expected one.
 	if should, err := npvcr.shouldProvision(local); err != nil {
 		klog.V(4).Infof("Error checking if should provision a local PersistentVolumeClaim %q: %v", npvcr.LocalRef(name), err.Error())
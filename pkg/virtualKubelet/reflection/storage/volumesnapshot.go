@@ -0,0 +1,364 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+const (
+	// VolumeSnapshotReflectorName -> The name associated with the VolumeSnapshot reflector.
+	VolumeSnapshotReflectorName = "VolumeSnapshot"
+)
+
+var (
+	// VolumeSnapshotGVR is the GroupVersionResource of the VolumeSnapshot resource.
+	VolumeSnapshotGVR = snapshotv1.SchemeGroupVersion.WithResource("volumesnapshots")
+	// VolumeSnapshotContentGVR is the GroupVersionResource of the VolumeSnapshotContent resource.
+	VolumeSnapshotContentGVR = snapshotv1.SchemeGroupVersion.WithResource("volumesnapshotcontents")
+	// VolumeSnapshotClassGVR is the GroupVersionResource of the VolumeSnapshotClass resource.
+	VolumeSnapshotClassGVR = snapshotv1.SchemeGroupVersion.WithResource("volumesnapshotclasses")
+)
+
+var _ manager.NamespacedReflector = (*NamespacedVolumeSnapshotReflector)(nil)
+
+// NamespacedVolumeSnapshotReflector manages the VolumeSnapshot reflection for a given pair of local and remote namespaces.
+// It acts as the snapshot driver of the virtual storage class for the volumes stored in the remote cluster, creating
+// the remote VolumeSnapshots and reporting their readiness back through the local VolumeSnapshotContents.
+type NamespacedVolumeSnapshotReflector struct {
+	generic.NamespacedReflector
+
+	localVolumeSnapshots         cache.GenericNamespaceLister
+	remoteVolumeSnapshots        cache.GenericNamespaceLister
+	remotePersistentVolumeClaims corev1listers.PersistentVolumeClaimNamespaceLister
+
+	remoteVolumeSnapshotsClient       dynamic.ResourceInterface
+	localVolumeSnapshotContentsClient dynamic.ResourceInterface
+	localVolumeSnapshotClassesClient  dynamic.ResourceInterface
+}
+
+// NewVolumeSnapshotReflector returns a new VolumeSnapshotReflector instance.
+func NewVolumeSnapshotReflector(workers uint) manager.Reflector {
+	return generic.NewReflector(VolumeSnapshotReflectorName, NewNamespacedVolumeSnapshotReflector, generic.WithoutFallback(), workers)
+}
+
+// NewNamespacedVolumeSnapshotReflector returns a new NamespacedVolumeSnapshotReflector instance.
+func NewNamespacedVolumeSnapshotReflector(opts *options.NamespacedOpts) manager.NamespacedReflector {
+	local := opts.LocalDynamicFactory.ForResource(VolumeSnapshotGVR)
+	remote := opts.RemoteDynamicFactory.ForResource(VolumeSnapshotGVR)
+	remotePvcs := opts.RemoteFactory.Core().V1().PersistentVolumeClaims()
+
+	local.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+	remote.Informer().AddEventHandler(opts.HandlerFactory(generic.NamespacedKeyer(opts.LocalNamespace)))
+
+	return &NamespacedVolumeSnapshotReflector{
+		NamespacedReflector: generic.NewNamespacedReflectorWithInformers(opts, VolumeSnapshotReflectorName, local.Informer(), remote.Informer()),
+
+		localVolumeSnapshots:         local.Lister().ByNamespace(opts.LocalNamespace),
+		remoteVolumeSnapshots:        remote.Lister().ByNamespace(opts.RemoteNamespace),
+		remotePersistentVolumeClaims: remotePvcs.Lister().PersistentVolumeClaims(opts.RemoteNamespace),
+
+		remoteVolumeSnapshotsClient:       opts.RemoteDynamicClient.Resource(VolumeSnapshotGVR).Namespace(opts.RemoteNamespace),
+		localVolumeSnapshotContentsClient: opts.LocalDynamicClient.Resource(VolumeSnapshotContentGVR),
+		localVolumeSnapshotClassesClient:  opts.LocalDynamicClient.Resource(VolumeSnapshotClassGVR),
+	}
+}
+
+// Handle reconciles VolumeSnapshot objects.
+func (nvsr *NamespacedVolumeSnapshotReflector) Handle(ctx context.Context, name string) error {
+	tracer := trace.FromContext(ctx)
+
+	// Retrieve the local and remote objects (only not found errors can occur).
+	klog.V(4).Infof("Handling reflection of local VolumeSnapshot %q (remote: %q)", nvsr.LocalRef(name), nvsr.RemoteRef(name))
+	local, lerr := nvsr.get(nvsr.localVolumeSnapshots, name)
+	utilruntime.Must(client.IgnoreNotFound(lerr))
+	remote, rerr := nvsr.get(nvsr.remoteVolumeSnapshots, name)
+	utilruntime.Must(client.IgnoreNotFound(rerr))
+	tracer.Step("Retrieved the local and remote objects")
+
+	// Abort the reflection if the remote object is not managed by us, as we do not want to mutate others' objects.
+	if rerr == nil && !forge.IsReflected(remote) {
+		if lerr == nil { // Do not output the warning event in case the event was triggered by the remote object (i.e., the local one does not exists).
+			klog.Infof("Skipping reflection of local VolumeSnapshot %q as remote already exists and is not managed by us", nvsr.LocalRef(name))
+			nvsr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionAlreadyExistsMsg())
+		}
+		return nil
+	}
+	tracer.Step("Performed the sanity checks")
+
+	// The local VolumeSnapshot is being deleted, or it does no longer exist. Release the remote one, if any.
+	if kerrors.IsNotFound(lerr) || !local.DeletionTimestamp.IsZero() {
+		defer tracer.Step("Released the remote object")
+		if kerrors.IsNotFound(rerr) {
+			klog.V(4).Infof("Local VolumeSnapshot %q and remote VolumeSnapshot %q both vanished", nvsr.LocalRef(name), nvsr.RemoteRef(name))
+			return nil
+		}
+		return nvsr.release(ctx, remote, kerrors.IsNotFound(lerr))
+	}
+
+	// Check if we are in charge of the given VolumeSnapshot, that is it refers to the virtual storage class
+	// and the corresponding PersistentVolumeClaim is stored in the remote cluster.
+	class, err := nvsr.virtualSnapshotClass(ctx, local)
+	if err != nil || class == nil {
+		return err
+	}
+
+	pvc, err := nvsr.remotePersistentVolumeClaims.Get(*local.Spec.Source.PersistentVolumeClaimName)
+	if kerrors.IsNotFound(err) || (err == nil && !forge.IsReflected(pvc)) {
+		klog.V(4).Infof("Skipping VolumeSnapshot %q, as the corresponding volume is not stored in the remote cluster", nvsr.LocalRef(name))
+		return nil
+	}
+	utilruntime.Must(err)
+	tracer.Step("Ensured to be in charge of the volume snapshot")
+
+	// The specifications of VolumeSnapshots are immutable, hence the remote object is created only if not already present.
+	// The remote VolumeSnapshot relies on the default VolumeSnapshotClass associated with the driver of the remote PVC.
+	if kerrors.IsNotFound(rerr) {
+		remote, err = nvsr.createRemote(ctx, local, pvc)
+		if err != nil {
+			klog.Errorf("Failed to create remote VolumeSnapshot %q (local: %q): %v", nvsr.RemoteRef(name), nvsr.LocalRef(name), err)
+			nvsr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedReflectionMsg(err))
+			return err
+		}
+		klog.Infof("Remote VolumeSnapshot %q successfully created (local: %q)", nvsr.RemoteRef(name), nvsr.LocalRef(name))
+		nvsr.Event(local, corev1.EventTypeNormal, forge.EventSuccessfulReflection, forge.EventSuccessfulReflectionMsg())
+	}
+	tracer.Step("Ensured the presence of the remote object")
+
+	// Ensure the existence of the local VolumeSnapshotContent, and propagate the status of the remote object to it.
+	if err := nvsr.enforceContent(ctx, local, class, remote); err != nil {
+		klog.Errorf("Failed to enforce the VolumeSnapshotContent of local VolumeSnapshot %q: %v", nvsr.LocalRef(name), err)
+		nvsr.Event(local, corev1.EventTypeWarning, forge.EventFailedReflection, forge.EventFailedStatusReflectionMsg(err))
+		return err
+	}
+	tracer.Step("Enforced the local VolumeSnapshotContent")
+
+	return nil
+}
+
+// virtualSnapshotClass returns the VolumeSnapshotClass of the given VolumeSnapshot, if it refers to the virtual storage.
+func (nvsr *NamespacedVolumeSnapshotReflector) virtualSnapshotClass(ctx context.Context,
+	snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshotClass, error) {
+	// Pre-provisioned snapshots, as well as the ones relying on the default class, cannot refer to the virtual storage.
+	if snapshot.Spec.Source.PersistentVolumeClaimName == nil || snapshot.Spec.VolumeSnapshotClassName == nil {
+		return nil, nil
+	}
+
+	obj, err := nvsr.localVolumeSnapshotClassesClient.Get(ctx, *snapshot.Spec.VolumeSnapshotClassName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			klog.V(4).Infof("VolumeSnapshotClass of local VolumeSnapshot %q not found", klog.KObj(snapshot))
+			return nil, nil
+		}
+		klog.Errorf("Failed to retrieve the VolumeSnapshotClass of local VolumeSnapshot %q: %v", klog.KObj(snapshot), err)
+		return nil, err
+	}
+
+	var class snapshotv1.VolumeSnapshotClass
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &class); err != nil {
+		return nil, err
+	}
+
+	if !liqostorageprovisioner.IsVirtualSnapshotClass(&class) {
+		return nil, nil
+	}
+	return &class, nil
+}
+
+// createRemote creates the remote VolumeSnapshot corresponding to the given local one.
+func (nvsr *NamespacedVolumeSnapshotReflector) createRemote(ctx context.Context, local *snapshotv1.VolumeSnapshot,
+	pvc *corev1.PersistentVolumeClaim) (*snapshotv1.VolumeSnapshot, error) {
+	remote := &snapshotv1.VolumeSnapshot{
+		TypeMeta: metav1.TypeMeta{Kind: liqostorageprovisioner.VolumeSnapshotKind, APIVersion: snapshotv1.SchemeGroupVersion.String()},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        local.Name,
+			Namespace:   nvsr.RemoteNamespace(),
+			Labels:      forge.ReflectionLabels(),
+			Annotations: map[string]string{consts.VirtualSnapshotContentAnnotation: liqostorageprovisioner.VirtualSnapshotContentName(local)},
+		},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: &pvc.Name},
+		},
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(remote)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := nvsr.remoteVolumeSnapshotsClient.Create(ctx, &unstructured.Unstructured{Object: obj},
+		metav1.CreateOptions{FieldManager: forge.ReflectionFieldManager})
+	if err != nil {
+		return nil, err
+	}
+	return nvsr.convert(created)
+}
+
+// enforceContent ensures the existence of the local VolumeSnapshotContent bound to the given local VolumeSnapshot,
+// and that its status reflects the one of the remote VolumeSnapshot.
+func (nvsr *NamespacedVolumeSnapshotReflector) enforceContent(ctx context.Context, local *snapshotv1.VolumeSnapshot,
+	class *snapshotv1.VolumeSnapshotClass, remote *snapshotv1.VolumeSnapshot) error {
+	var content snapshotv1.VolumeSnapshotContent
+	name := liqostorageprovisioner.VirtualSnapshotContentName(local)
+
+	obj, err := nvsr.localVolumeSnapshotContentsClient.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case kerrors.IsNotFound(err):
+		forged := liqostorageprovisioner.ForgeVirtualSnapshotContent(local, class,
+			nvsr.handle(*remote.Spec.Source.PersistentVolumeClaimName), forge.ReflectionLabels())
+		forged.TypeMeta = metav1.TypeMeta{Kind: "VolumeSnapshotContent", APIVersion: snapshotv1.SchemeGroupVersion.String()}
+		unstr, err := runtime.DefaultUnstructuredConverter.ToUnstructured(forged)
+		if err != nil {
+			return err
+		}
+		if obj, err = nvsr.localVolumeSnapshotContentsClient.Create(ctx, &unstructured.Unstructured{Object: unstr}, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		klog.Infof("VolumeSnapshotContent %q of local VolumeSnapshot %q created", name, nvsr.LocalRef(local.Name))
+	case err != nil:
+		return err
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &content); err != nil {
+		return err
+	}
+	if content.Spec.VolumeSnapshotRef.UID != local.UID {
+		return fmt.Errorf("VolumeSnapshotContent %q is not bound to local VolumeSnapshot %q", name, nvsr.LocalRef(local.Name))
+	}
+
+	status := liqostorageprovisioner.ForgeVirtualSnapshotContentStatus(nvsr.handle(remote.Name), remote)
+	if equality.Semantic.DeepEqual(content.Status, status) {
+		return nil
+	}
+
+	content.Status = status
+	unstr, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&content)
+	if err != nil {
+		return err
+	}
+	updated := &unstructured.Unstructured{Object: unstr}
+	if _, err := nvsr.localVolumeSnapshotContentsClient.UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	klog.Infof("Status of VolumeSnapshotContent %q successfully updated (remote: %q, ready: %t)", name, nvsr.RemoteRef(remote.Name), *status.ReadyToUse)
+	return nil
+}
+
+// release releases the given remote VolumeSnapshot, once the local one is being deleted or already vanished.
+// The remote VolumeSnapshot is deleted if the corresponding content is being deleted (or no longer exists),
+// and retained otherwise once the local VolumeSnapshot vanished.
+func (nvsr *NamespacedVolumeSnapshotReflector) release(ctx context.Context, remote *snapshotv1.VolumeSnapshot, vanished bool) error {
+	var content *snapshotv1.VolumeSnapshotContent
+	obj, err := nvsr.localVolumeSnapshotContentsClient.Get(ctx, remote.Annotations[consts.VirtualSnapshotContentAnnotation], metav1.GetOptions{})
+	switch {
+	case err == nil:
+		content = &snapshotv1.VolumeSnapshotContent{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, content); err != nil {
+			return err
+		}
+	case !kerrors.IsNotFound(err):
+		klog.Errorf("Failed to retrieve the VolumeSnapshotContent backed by remote VolumeSnapshot %q: %v", nvsr.RemoteRef(remote.Name), err)
+		return err
+	}
+
+	switch {
+	case content == nil || !content.DeletionTimestamp.IsZero():
+		if err := nvsr.DeleteRemote(ctx, &deleter{nvsr.remoteVolumeSnapshotsClient}, VolumeSnapshotReflectorName, remote.Name, remote.UID); err != nil {
+			return err
+		}
+	case vanished && content.Spec.DeletionPolicy == snapshotv1.VolumeSnapshotContentRetain:
+		// The remote VolumeSnapshot is left in place, although no longer managed by the reflection.
+		patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:null,%q:null}}}`, forge.LiqoOriginClusterIDKey, forge.LiqoDestinationClusterIDKey))
+		if _, err := nvsr.remoteVolumeSnapshotsClient.Patch(ctx, remote.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			klog.Errorf("Failed to retain remote VolumeSnapshot %q: %v", nvsr.RemoteRef(remote.Name), err)
+			return err
+		}
+		klog.Infof("Remote VolumeSnapshot %q retained, as requested by the VolumeSnapshotContent %q", nvsr.RemoteRef(remote.Name), content.Name)
+	default:
+		// Wait for the snapshot controller to either delete the content or remove the local VolumeSnapshot.
+		return nil
+	}
+
+	if content != nil && controllerutil.ContainsFinalizer(content, liqostorageprovisioner.VolumeSnapshotContentBoundFinalizer) {
+		controllerutil.RemoveFinalizer(content, liqostorageprovisioner.VolumeSnapshotContentBoundFinalizer)
+		unstr, err := runtime.DefaultUnstructuredConverter.ToUnstructured(content)
+		if err != nil {
+			return err
+		}
+		if _, err := nvsr.localVolumeSnapshotContentsClient.Update(ctx, &unstructured.Unstructured{Object: unstr}, metav1.UpdateOptions{}); err != nil {
+			klog.Errorf("Failed to remove the finalizer from VolumeSnapshotContent %q: %v", content.Name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// handle returns the handle identifying the given remote resource.
+func (nvsr *NamespacedVolumeSnapshotReflector) handle(name string) string {
+	return fmt.Sprintf("%s/%s/%s", forge.RemoteCluster.ClusterID, nvsr.RemoteNamespace(), name)
+}
+
+// get retrieves the VolumeSnapshot with the given name from the given lister.
+func (nvsr *NamespacedVolumeSnapshotReflector) get(lister cache.GenericNamespaceLister, name string) (*snapshotv1.VolumeSnapshot, error) {
+	obj, err := lister.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return nvsr.convert(obj.(*unstructured.Unstructured))
+}
+
+// convert converts the given unstructured object into a VolumeSnapshot.
+func (nvsr *NamespacedVolumeSnapshotReflector) convert(obj *unstructured.Unstructured) (*snapshotv1.VolumeSnapshot, error) {
+	var snapshot snapshotv1.VolumeSnapshot
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// deleter adapts a dynamic.ResourceInterface to the generic.ResourceDeleter interface.
+type deleter struct {
+	client dynamic.ResourceInterface
+}
+
+// Delete deletes the object with the given name.
+func (d *deleter) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return d.client.Delete(ctx, name, opts)
+}
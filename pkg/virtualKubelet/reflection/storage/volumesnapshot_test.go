@@ -0,0 +1,248 @@
+// Copyright 2019-2022 The Liqo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"time"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	"github.com/liqotech/liqo/pkg/consts"
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
+	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
+)
+
+var _ = Describe("VolumeSnapshot reflection", func() {
+	const (
+		name        = "snapshot"
+		snapshotUID = "e3b0c442-98fc-1c14-9afb-f4c8996fb924"
+		contentName = "snapcontent-" + snapshotUID
+	)
+
+	var (
+		snapshotReflector *NamespacedVolumeSnapshotReflector
+
+		localClient, remoteClient *dynamicfake.FakeDynamicClient
+		localObjects              []runtime.Object
+		remoteObjects             []runtime.Object
+		remotePvcs                []runtime.Object
+
+		class *snapshotv1.VolumeSnapshotClass
+		local *snapshotv1.VolumeSnapshot
+
+		err error
+	)
+
+	toUnstructured := func(obj interface{}, kind string) *unstructured.Unstructured {
+		unstr, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		Expect(err).ToNot(HaveOccurred())
+		res := &unstructured.Unstructured{Object: unstr}
+		res.SetAPIVersion(snapshotv1.SchemeGroupVersion.String())
+		res.SetKind(kind)
+		return res
+	}
+
+	getContent := func() (*snapshotv1.VolumeSnapshotContent, error) {
+		obj, err := localClient.Resource(VolumeSnapshotContentGVR).Get(ctx, contentName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		var content snapshotv1.VolumeSnapshotContent
+		Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &content)).To(Succeed())
+		return &content, nil
+	}
+
+	getRemote := func() (*snapshotv1.VolumeSnapshot, error) {
+		obj, err := remoteClient.Resource(VolumeSnapshotGVR).Namespace(RemoteNamespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		var snapshot snapshotv1.VolumeSnapshot
+		Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &snapshot)).To(Succeed())
+		return &snapshot, nil
+	}
+
+	forgeRemote := func(status *snapshotv1.VolumeSnapshotStatus) *unstructured.Unstructured {
+		return toUnstructured(&snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: RemoteNamespace, UID: "remote-uid", Labels: forge.ReflectionLabels(),
+				Annotations: map[string]string{consts.VirtualSnapshotContentAnnotation: contentName}},
+			Spec:   snapshotv1.VolumeSnapshotSpec{Source: snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: pointer.String(remotePvcName)}},
+			Status: status,
+		}, "VolumeSnapshot")
+	}
+
+	BeforeEach(func() {
+		class = &snapshotv1.VolumeSnapshotClass{
+			ObjectMeta:     metav1.ObjectMeta{Name: VirtualStorageClassName},
+			Driver:         consts.StorageProvisionerName,
+			DeletionPolicy: snapshotv1.VolumeSnapshotContentDelete,
+		}
+		local = &snapshotv1.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: LocalNamespace, UID: snapshotUID},
+			Spec: snapshotv1.VolumeSnapshotSpec{
+				Source:                  snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: pointer.String(remotePvcName)},
+				VolumeSnapshotClassName: pointer.String(VirtualStorageClassName),
+			},
+		}
+
+		localObjects, remoteObjects = nil, nil
+		remotePvcs = []runtime.Object{&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: remotePvcName, Namespace: RemoteNamespace, Labels: forge.ReflectionLabels()},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		listKinds := map[schema.GroupVersionResource]string{
+			VolumeSnapshotGVR:        "VolumeSnapshotList",
+			VolumeSnapshotContentGVR: "VolumeSnapshotContentList",
+			VolumeSnapshotClassGVR:   "VolumeSnapshotClassList",
+		}
+		localClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds,
+			append(localObjects, toUnstructured(class, "VolumeSnapshotClass"))...)
+		remoteClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, remoteObjects...)
+
+		localFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(localClient, 10*time.Hour, LocalNamespace, nil)
+		remoteFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(remoteClient, 10*time.Hour, RemoteNamespace, nil)
+		remoteKubeFactory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(remotePvcs...), 10*time.Hour)
+
+		snapshotReflector = NewNamespacedVolumeSnapshotReflector(options.NewNamespaced().
+			WithLocal(LocalNamespace, nil, nil).WithRemote(RemoteNamespace, nil, remoteKubeFactory).
+			WithDynamicLocal(localClient, localFactory).WithDynamicRemote(remoteClient, remoteFactory).
+			WithHandlerFactory(func(options.Keyer) cache.ResourceEventHandler { return cache.ResourceEventHandlerFuncs{} }).
+			WithEventBroadcaster(record.NewBroadcaster())).(*NamespacedVolumeSnapshotReflector)
+
+		localFactory.Start(ctx.Done())
+		remoteFactory.Start(ctx.Done())
+		remoteKubeFactory.Start(ctx.Done())
+		localFactory.WaitForCacheSync(ctx.Done())
+		remoteFactory.WaitForCacheSync(ctx.Done())
+		remoteKubeFactory.WaitForCacheSync(ctx.Done())
+
+		err = snapshotReflector.Handle(ctx, name)
+	})
+
+	When("the local VolumeSnapshot refers to a volume stored in the remote cluster", func() {
+		BeforeEach(func() { localObjects = append(localObjects, toUnstructured(local, "VolumeSnapshot")) })
+
+		When("the remote VolumeSnapshot does not exist", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should create the remote VolumeSnapshot", func() {
+				remote, err := getRemote()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(remote.Spec.Source.PersistentVolumeClaimName).To(PointTo(Equal(remotePvcName)))
+				Expect(remote.Spec.VolumeSnapshotClassName).To(BeNil())
+				Expect(forge.IsReflected(remote)).To(BeTrue())
+			})
+			It("should create the VolumeSnapshotContent bound to the local VolumeSnapshot", func() {
+				content, err := getContent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(content.Spec.Driver).To(Equal(consts.StorageProvisionerName))
+				Expect(content.Spec.VolumeSnapshotRef.UID).To(BeEquivalentTo(snapshotUID))
+				Expect(content.Spec.Source.VolumeHandle).To(PointTo(Equal(RemoteClusterID + "/" + RemoteNamespace + "/" + remotePvcName)))
+			})
+		})
+
+		When("the remote VolumeSnapshot is ready", func() {
+			BeforeEach(func() {
+				remoteObjects = append(remoteObjects, forgeRemote(&snapshotv1.VolumeSnapshotStatus{ReadyToUse: pointer.Bool(true)}))
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should report the readiness through the VolumeSnapshotContent", func() {
+				content, err := getContent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(content.Status).ToNot(BeNil())
+				Expect(content.Status.ReadyToUse).To(PointTo(BeTrue()))
+				Expect(content.Status.SnapshotHandle).To(PointTo(Equal(RemoteClusterID + "/" + RemoteNamespace + "/" + name)))
+			})
+		})
+	})
+
+	When("the local VolumeSnapshot refers to a volume not stored in the remote cluster", func() {
+		BeforeEach(func() {
+			localObjects = append(localObjects, toUnstructured(local, "VolumeSnapshot"))
+			remotePvcs = nil
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not create the remote VolumeSnapshot", func() {
+			_, err := getRemote()
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("the local VolumeSnapshot does not refer to the virtual storage", func() {
+		BeforeEach(func() {
+			class.Driver = "csi.example.com"
+			localObjects = append(localObjects, toUnstructured(local, "VolumeSnapshot"))
+		})
+
+		It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+		It("should not create the remote VolumeSnapshot", func() {
+			_, err := getRemote()
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	When("the local VolumeSnapshot vanished", func() {
+		BeforeEach(func() { remoteObjects = append(remoteObjects, forgeRemote(nil)) })
+
+		When("the VolumeSnapshotContent does no longer exist", func() {
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should delete the remote VolumeSnapshot", func() {
+				_, err := getRemote()
+				Expect(kerrors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+
+		When("the VolumeSnapshotContent is retained", func() {
+			BeforeEach(func() {
+				content := liqostorageprovisioner.ForgeVirtualSnapshotContent(local, class, "handle", nil)
+				content.Spec.DeletionPolicy = snapshotv1.VolumeSnapshotContentRetain
+				content.Finalizers = []string{liqostorageprovisioner.VolumeSnapshotContentBoundFinalizer}
+				localObjects = append(localObjects, toUnstructured(content, "VolumeSnapshotContent"))
+			})
+
+			It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+			It("should retain the remote VolumeSnapshot, which is no longer reflected", func() {
+				remote, err := getRemote()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(forge.IsReflected(remote)).To(BeFalse())
+			})
+			It("should remove the finalizer from the VolumeSnapshotContent", func() {
+				content, err := getContent()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(content.Finalizers).To(BeEmpty())
+			})
+		})
+	})
+})
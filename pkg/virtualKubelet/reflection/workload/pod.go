@@ -127,6 +127,7 @@ func (pr *PodReflector) NewNamespaced(opts *options.NamespacedOpts) manager.Name
 		remotePods:       remote.Lister().Pods(opts.RemoteNamespace),
 		remoteShadowPods: remoteShadow.Lister().ShadowPods(opts.RemoteNamespace),
 		remoteSecrets:    remoteSecrets.Lister().Secrets(opts.RemoteNamespace),
+		localPvcs:        opts.LocalFactory.Core().V1().PersistentVolumeClaims().Lister().PersistentVolumeClaims(opts.LocalNamespace),
 
 		localPodsClient:        opts.LocalClient.CoreV1().Pods(opts.LocalNamespace),
 		remotePodsClient:       opts.RemoteClient.CoreV1().Pods(opts.RemoteNamespace),
@@ -46,8 +46,10 @@ import (
 	vkv1alpha1 "github.com/liqotech/liqo/apis/virtualkubelet/v1alpha1"
 	vkv1alpha1clients "github.com/liqotech/liqo/pkg/client/clientset/versioned/typed/virtualkubelet/v1alpha1"
 	vkv1alpha1listers "github.com/liqotech/liqo/pkg/client/listers/virtualkubelet/v1alpha1"
+	liqostorageprovisioner "github.com/liqotech/liqo/pkg/liqo-controller-manager/storageprovisioner"
 	"github.com/liqotech/liqo/pkg/liqonet/ipam"
 	"github.com/liqotech/liqo/pkg/utils/pod"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/generic"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
@@ -74,6 +76,7 @@ type NamespacedPodReflector struct {
 	remotePods       corev1listers.PodNamespaceLister
 	remoteShadowPods vkv1alpha1listers.ShadowPodNamespaceLister
 	remoteSecrets    corev1listers.SecretNamespaceLister
+	localPvcs        corev1listers.PersistentVolumeClaimNamespaceLister
 
 	localPodsClient        corev1clients.PodInterface
 	remotePodsClient       corev1clients.PodInterface
@@ -296,9 +299,17 @@ func (npr *NamespacedPodReflector) ForgeShadowPod(ctx context.Context, local *co
 		return ip
 	}
 
+	// Wrap the volume gateways remapped IP retrieval, so that we do not have to handle errors in the forge logic.
+	var vgerr error
+	endpointRetriever := func(claimName string) (endpoint string, found bool) {
+		endpoint, found, vgerr = npr.RetrieveVolumeGatewayEndpoint(ctx, claimName)
+		return endpoint, found
+	}
+
 	// Forge the target shadowpod object.
 	target := forge.RemoteShadowPod(local, shadow, npr.RemoteNamespace(),
-		forge.APIServerSupportMutator(npr.enableAPIServerSupport, pod.ServiceAccountName(local), saSecretRetriever, ipGetter))
+		forge.APIServerSupportMutator(npr.enableAPIServerSupport, pod.ServiceAccountName(local), saSecretRetriever, ipGetter),
+		forge.RemoteAccessVolumesMutator(endpointRetriever))
 
 	// Check whether an error occurred during secret name retrieval.
 	if saerr != nil {
@@ -310,6 +321,17 @@ func (npr *NamespacedPodReflector) ForgeShadowPod(ctx context.Context, local *co
 		return nil, kserr
 	}
 
+	// Check whether an error occurred during volume gateways IP remapping retrieval.
+	if vgerr != nil {
+		return nil, vgerr
+	}
+
+	// Propagate the span context at creation time, so that the offloading can be traced in the remote cluster as well.
+	// Subsequent updates preserve the existing annotations, hence pointing to the span which originated the shadowpod.
+	if shadow == nil {
+		target.SetAnnotations(tracing.InjectAnnotations(ctx, target.GetAnnotations()))
+	}
+
 	return target, nil
 }
 
@@ -526,6 +548,28 @@ func (npr *NamespacedPodReflector) RetrieveServiceAccountSecretName(info *PodInf
 	}
 }
 
+// RetrieveVolumeGatewayEndpoint retrieves the remapped address of the gateway exposing the given local PVC,
+// and whether the PVC is actually exposed to remote clusters.
+func (npr *NamespacedPodReflector) RetrieveVolumeGatewayEndpoint(ctx context.Context, claimName string) (string, bool, error) {
+	pvc, err := npr.localPvcs.Get(claimName)
+	utilruntime.Must(client.IgnoreNotFound(err))
+	if kerrors.IsNotFound(err) || !liqostorageprovisioner.IsRemoteAccessEnabled(pvc) || !liqostorageprovisioner.IsRemoteAccessSupported(pvc) {
+		return "", false, nil
+	}
+
+	endpoint, found := liqostorageprovisioner.RemoteAccessEndpoint(pvc)
+	if !found {
+		return "", false, fmt.Errorf("the gateway exposing PersistentVolumeClaim %q is not yet available", npr.LocalRef(claimName))
+	}
+
+	response, err := npr.ipamclient.MapEndpointIP(ctx, &ipam.MapRequest{ClusterID: forge.RemoteCluster.ClusterID, Ip: endpoint})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to translate volume gateway IP %v: %w", endpoint, err)
+	}
+
+	return response.Ip, true, nil
+}
+
 // MapPodIP maps the remote Pod address to the corresponding local one.
 func (npr *NamespacedPodReflector) MapPodIP(ctx context.Context, info *PodInfo, original string) (string, error) {
 	// Check the pod information whether a translation already exists for the given IP.
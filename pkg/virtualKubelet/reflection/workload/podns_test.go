@@ -15,12 +15,17 @@
 package workload_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -40,6 +45,7 @@ import (
 	"github.com/liqotech/liqo/pkg/consts"
 	fakeipam "github.com/liqotech/liqo/pkg/liqonet/ipam/fake"
 	. "github.com/liqotech/liqo/pkg/utils/testutil"
+	"github.com/liqotech/liqo/pkg/utils/tracing"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/forge"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/manager"
 	"github.com/liqotech/liqo/pkg/virtualKubelet/reflection/options"
@@ -475,6 +481,100 @@ var _ = Describe("Namespaced Pod Reflection Tests", func() {
 			})
 		})
 
+		Context("retrieval of the remapped address of a volume gateway", func() {
+			var (
+				output string
+				found  bool
+				err    error
+			)
+
+			// The claim is created before the informers are started, hence it is already present in the cache.
+			CreatePvc := func(annotations map[string]string) {
+				pvc := &corev1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: LocalNamespace, Annotations: annotations},
+					Spec:       corev1.PersistentVolumeClaimSpec{AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}},
+				}
+				_, err := client.CoreV1().PersistentVolumeClaims(LocalNamespace).Create(ctx, pvc, metav1.CreateOptions{})
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			JustBeforeEach(func() {
+				output, found, err = reflector.(*workload.NamespacedPodReflector).RetrieveVolumeGatewayEndpoint(ctx, "shared")
+			})
+
+			When("the PersistentVolumeClaim is exposed by a gateway", func() {
+				BeforeEach(func() {
+					CreatePvc(map[string]string{consts.VolumeRemoteAccessAnnotation: "true", consts.VolumeRemoteAccessEndpointAnnotation: "10.96.0.42"})
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should report the claim as exposed", func() { Expect(found).To(BeTrue()) })
+				It("should return the remapped address", func() { Expect(output).To(BeIdenticalTo("192.168.200.42")) })
+			})
+
+			When("the remote access is not enabled", func() {
+				BeforeEach(func() { CreatePvc(nil) })
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should report the claim as not exposed", func() { Expect(found).To(BeFalse()) })
+			})
+
+			When("the PersistentVolumeClaim does not exist", func() {
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should report the claim as not exposed", func() { Expect(found).To(BeFalse()) })
+			})
+
+			When("the gateway is not yet available", func() {
+				BeforeEach(func() { CreatePvc(map[string]string{consts.VolumeRemoteAccessAnnotation: "true"}) })
+
+				It("should return an error", func() { Expect(err).To(HaveOccurred()) })
+			})
+		})
+
+		Context("propagation of the span context to the remote shadowpod", func() {
+			var (
+				local   corev1.Pod
+				shadow  *vkv1alpha1.ShadowPod
+				target  *vkv1alpha1.ShadowPod
+				span    oteltrace.Span
+				spanCtx context.Context
+				err     error
+			)
+
+			BeforeEach(func() {
+				otel.SetTextMapPropagator(propagation.TraceContext{})
+				spanCtx, span = sdktrace.NewTracerProvider().Tracer("test").Start(ctx, "span")
+				local = corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: PodName, Namespace: LocalNamespace}}
+				shadow = nil
+			})
+
+			JustBeforeEach(func() {
+				podinfo := workload.PodInfo{ServiceAccountSecret: "secret-name"}
+				target, err = reflector.(*workload.NamespacedPodReflector).ForgeShadowPod(spanCtx, &local, shadow, &podinfo)
+				span.End()
+			})
+
+			When("the shadowpod is being created", func() {
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should store the span context in the annotations", func() {
+					Expect(target.Annotations).To(HaveKeyWithValue(tracing.AnnotationPrefix+"traceparent",
+						ContainSubstring(span.SpanContext().SpanID().String())))
+				})
+			})
+
+			When("the shadowpod already exists", func() {
+				BeforeEach(func() {
+					shadow = &vkv1alpha1.ShadowPod{ObjectMeta: metav1.ObjectMeta{Name: PodName, Namespace: RemoteNamespace,
+						Annotations: map[string]string{tracing.AnnotationPrefix + "traceparent": "previous"}}}
+				})
+
+				It("should succeed", func() { Expect(err).ToNot(HaveOccurred()) })
+				It("should preserve the original span context", func() {
+					Expect(target.Annotations).To(HaveKeyWithValue(tracing.AnnotationPrefix+"traceparent", "previous"))
+				})
+			})
+		})
+
 		Context("address translation", func() {
 			var (
 				input, output string
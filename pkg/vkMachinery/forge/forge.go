@@ -60,6 +60,9 @@ func forgeVKContainers(
 		args = append(args, "--enable-storage",
 			stringifyArgument("--remote-real-storage-class-name",
 				getDefaultStorageClass(resourceOffer.Spec.StorageClasses).StorageClassName))
+		if opts.EnableVolumeSnapshots {
+			args = append(args, "--enable-volume-snapshots")
+		}
 	}
 
 	if extraAnnotations := opts.NodeExtraAnnotations.StringMap; len(extraAnnotations) != 0 {
@@ -33,4 +33,6 @@ type VirtualKubeletOpts struct {
 	LimitsCPU            resource.Quantity
 	RequestsRAM          resource.Quantity
 	LimitsRAM            resource.Quantity
+	// EnableVolumeSnapshots enables the reflection of the VolumeSnapshots of the virtual storage class.
+	EnableVolumeSnapshots bool
 }